@@ -16,6 +16,8 @@ package main
 
 import (
 	"os"
+	// WARM_POOL_SCHEDULE windows can name a timezone, and the base image ships no zoneinfo
+	_ "time/tzdata"
 
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/k8sapi"
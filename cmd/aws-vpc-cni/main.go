@@ -73,6 +73,7 @@ const (
 	defaultEgressV4PluginLogFile = "/var/log/aws-routed-eni/egress-v4-plugin.log"
 	defaultEgressV6PluginLogFile = "/var/log/aws-routed-eni/egress-v6-plugin.log"
 	defaultPluginLogLevel        = "Debug"
+	defaultEnableIPv4            = false
 	defaultEnableIPv6            = false
 	defaultEnableIPv6Egress      = false
 	defaultEnableIPv4Egress      = true
@@ -100,6 +101,7 @@ const (
 	envMinIPTarget           = "MINIMUM_IP_TARGET"
 	envWarmPrefixTarget      = "WARM_PREFIX_TARGET"
 	envEnBandwidthPlugin     = "ENABLE_BANDWIDTH_PLUGIN"
+	envEnIPv4                = "ENABLE_IPv4"
 	envEnIPv6                = "ENABLE_IPv6"
 	envEnIPv6Egress          = "ENABLE_V6_EGRESS"
 	envEnIPv4Egress          = "ENABLE_V4_EGRESS"
@@ -219,14 +221,16 @@ func generateJSON(jsonFile string, outFile string, getPrimaryIP func(ipv4 bool)
 	// enabledIPv6 is to determine if EKS cluster is IPv4 or IPv6 cluster
 	// if this EKS cluster is IPv6 cluster, egress-cni-plugin will enable IPv4 egress by default
 	// if this EKS cluster is IPv4 cluster, egress-cni-plugin will only enable IPv6 egress if env var "ENABLE_V6_EGRESS" is "true"
+	// in dual-stack mode, pods get addresses of both families and egress-cni-plugin is not enabled
 	enabledIPv6 := utils.GetBoolAsStringEnvVar(envEnIPv6, defaultEnableIPv6)
+	dualStack := enabledIPv6 && utils.GetBoolAsStringEnvVar(envEnIPv4, defaultEnableIPv4)
 	var egressIPAMSubnet string
 	var egressIPAMDst string
 	var egressIPAMDataDir string
 	var egressEnabled bool
 	var egressPluginLogFile string
 	var nodeIP = ""
-	if enabledIPv6 {
+	if enabledIPv6 && !dualStack {
 		// EKS IPv6 cluster
 		egressIPAMSubnet = egressPluginIpamSubnetV4
 		egressIPAMDst = egressPluginIpamDstV4
@@ -246,7 +250,7 @@ func generateJSON(jsonFile string, outFile string, getPrimaryIP func(ipv4 bool)
 		egressIPAMDst = egressPluginIpamDstV6
 		egressIPAMDataDir = egressPluginIpamDataDirV6
 		egressPluginLogFile = utils.GetEnv(envEgressV6PluginLogFile, defaultEgressV6PluginLogFile)
-		egressEnabled = utils.GetBoolAsStringEnvVar(envEnIPv6Egress, defaultEnableIPv6Egress)
+		egressEnabled = !dualStack && utils.GetBoolAsStringEnvVar(envEnIPv6Egress, defaultEnableIPv6Egress)
 		if egressEnabled {
 			nodeIP, err = getPrimaryIP(false)
 			if err != nil {
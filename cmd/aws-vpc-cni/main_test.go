@@ -100,6 +100,32 @@ func TestEgressCNIPluginIPv6EgressTakesMTUEnvVar(t *testing.T) {
 	assert.Equal(t, "8000", plugins[1].(map[string]interface{})["mtu"])
 }
 
+// Validate that egress-cni plugin is disabled in dual-stack mode, where pods get addresses of both families
+func TestEgressCNIPluginDisabledInDualStack(t *testing.T) {
+	t.Setenv(envEnIPv4, "true")
+	t.Setenv(envEnIPv6, "true")
+	t.Setenv(envEnIPv6Egress, "true")
+
+	// Use a temporary file for the parsed output.
+	tmpfile, err := os.CreateTemp("", "temp-aws-vpc-cni.conflist")
+	assert.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+
+	err = generateJSON(awsConflist, tmpfile.Name(), getPrimaryIPMock)
+	assert.NoError(t, err)
+
+	var jsonData map[string]interface{}
+	jsonFile, err := os.ReadFile(tmpfile.Name())
+	assert.NoError(t, err)
+
+	err = json.Unmarshal(jsonFile, &jsonData)
+	assert.NoError(t, err)
+
+	plugins, _ := jsonData["plugins"].([]interface{})
+	assert.Equal(t, "egress-cni", plugins[1].(map[string]interface{})["type"])
+	assert.Equal(t, "false", plugins[1].(map[string]interface{})["enabled"])
+}
+
 func TestMTUValidation(t *testing.T) {
 	// By default, ENI MTU and pod MTU should be valid
 	assert.True(t, validateMTU(envEniMTU))
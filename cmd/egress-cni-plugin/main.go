@@ -23,6 +23,10 @@ import (
 	current "github.com/containernetworking/cni/pkg/types/100"
 	cniversion "github.com/containernetworking/cni/pkg/version"
 	"github.com/containernetworking/plugins/pkg/utils"
+	"github.com/coreos/go-iptables/iptables"
+
+	"github.com/aws/amazon-vpc-cni-k8s/cmd/egress-cni-plugin/snat"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/cniutils"
 )
 
 var version string
@@ -35,7 +39,12 @@ func init() {
 }
 
 func main() {
-	skel.PluginMain(cmdAdd, nil, cmdDel, cniversion.All, fmt.Sprintf("egress CNI plugin %s", version))
+	skel.PluginMainFuncs(skel.CNIFuncs{
+		Add:    cmdAdd,
+		Del:    cmdDel,
+		GC:     cmdGC,
+		Status: cmdStatus,
+	}, cniversion.All, fmt.Sprintf("egress CNI plugin %s", version))
 }
 
 func cmdAdd(args *skel.CmdArgs) error {
@@ -97,11 +106,11 @@ func add(args *skel.CmdArgs, ec *egressContext) (err error) {
 		if ec.NetConf.NodeIP == nil || !ec.NetConf.NodeIP.IsGlobalUnicast() {
 			return fmt.Errorf("global unicast IPv6 not found in host primary interface which is mandatory to support IPv6 egress")
 		}
-		ec.SnatChain = utils.MustFormatChainNameWithPrefix(ec.NetConf.Name, args.ContainerID, "E6-")
+		ec.SnatChain = utils.MustFormatChainNameWithPrefix(ec.NetConf.Name, args.ContainerID, snatChainPrefixV6)
 		ec.NetConf.IfName = egressIPv6InterfaceName
 		err = ec.cmdAddEgressV6()
 	} else { // NodeIP is IPv4 address, pod IPv4 egress for eks IPv6 cluster
-		ec.SnatChain = utils.MustFormatChainNameWithPrefix(ec.NetConf.Name, args.ContainerID, "E4-")
+		ec.SnatChain = utils.MustFormatChainNameWithPrefix(ec.NetConf.Name, args.ContainerID, snatChainPrefixV4)
 		ec.NetConf.IfName = egressIPv4InterfaceName
 		err = ec.cmdAddEgressV4()
 	}
@@ -135,15 +144,86 @@ func del(args *skel.CmdArgs, ec *egressContext) (err error) {
 	var ipv4 bool
 	if ec.NetConf.NodeIP.To4() == nil { // NodeIP is not IPv4 address
 		ipv4 = false
-		ec.SnatChain = utils.MustFormatChainNameWithPrefix(ec.NetConf.Name, args.ContainerID, "E6-")
+		ec.SnatChain = utils.MustFormatChainNameWithPrefix(ec.NetConf.Name, args.ContainerID, snatChainPrefixV6)
 		// IPv6 egress
 		ec.NetConf.IfName = egressIPv6InterfaceName
 	} else {
 		ipv4 = true
-		ec.SnatChain = utils.MustFormatChainNameWithPrefix(ec.NetConf.Name, args.ContainerID, "E4-")
+		ec.SnatChain = utils.MustFormatChainNameWithPrefix(ec.NetConf.Name, args.ContainerID, snatChainPrefixV4)
 		// IPv4 egress
 		ec.NetConf.IfName = egressIPv4InterfaceName
 	}
 
 	return ec.cmdDelEgress(ipv4)
 }
+
+func cmdGC(args *skel.CmdArgs) error {
+	ec := NewEgressDelContext(args.Netns)
+	return gc(args, &ec)
+}
+
+// gc removes the SNAT chains of containers that are no longer in the runtime's list of valid attachments
+func gc(args *skel.CmdArgs, ec *egressContext) (err error) {
+	ec.NetConf, ec.Log, err = LoadConf(args.StdinData)
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %v", err)
+	}
+	ec.Log.Debugf("Received a GC request: %d valid attachments", len(ec.NetConf.ValidAttachments))
+
+	// We only need this plugin to kick in if egress is enabled
+	if ec.NetConf.Enabled != "true" {
+		ec.Log.Debugf("egress-cni plugin is disabled")
+		return nil
+	}
+
+	protocol, chainPrefix := iptables.ProtocolIPv4, snatChainPrefixV4
+	if ec.NetConf.NodeIP.To4() == nil { // NodeIP is not IPv4 address
+		protocol, chainPrefix = iptables.ProtocolIPv6, snatChainPrefixV6
+	}
+	if ec.IPTablesIface == nil {
+		if ec.IPTablesIface, err = ec.IptCreator(protocol); err != nil {
+			ec.Log.Error("command iptables not found")
+			return err
+		}
+	}
+
+	validChains := make(map[string]bool, len(ec.NetConf.ValidAttachments))
+	for _, attachment := range ec.NetConf.ValidAttachments {
+		validChains[utils.MustFormatChainNameWithPrefix(ec.NetConf.Name, attachment.ContainerID, chainPrefix)] = true
+	}
+	if err = snat.GC(ec.IPTablesIface, cniChainPrefix+chainPrefix, ec.NetConf.Name, validChains); err != nil {
+		ec.Log.Errorf("failed to remove stale iptables chains: %v", err)
+		return err
+	}
+	return nil
+}
+
+func cmdStatus(args *skel.CmdArgs) error {
+	ec := NewEgressDelContext(args.Netns)
+	return status(args, &ec)
+}
+
+// status reports the plugin as unavailable when the iptables binary it needs for SNAT cannot be found
+func status(args *skel.CmdArgs, ec *egressContext) (err error) {
+	ec.NetConf, ec.Log, err = LoadConf(args.StdinData)
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %v", err)
+	}
+
+	// We only need this plugin to kick in if egress is enabled
+	if ec.NetConf.Enabled != "true" {
+		return nil
+	}
+
+	protocol := iptables.ProtocolIPv4
+	if ec.NetConf.NodeIP.To4() == nil { // NodeIP is not IPv4 address
+		protocol = iptables.ProtocolIPv6
+	}
+	if ec.IPTablesIface == nil {
+		if _, err = ec.IptCreator(protocol); err != nil {
+			ec.Log.Errorf("iptables is not available: %v", err)
+			return types.NewError(cniutils.ErrPluginNotAvailable, "iptables is not available", err.Error())
+		}
+	}
+	return nil
+}
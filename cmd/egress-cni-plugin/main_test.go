@@ -14,18 +14,23 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
+	"github.com/coreos/go-iptables/iptables"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
 	mock_ipamwrapper "github.com/aws/amazon-vpc-cni-k8s/pkg/hostipamwrapper/mocks"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/iptableswrapper"
 	mock_iptables "github.com/aws/amazon-vpc-cni-k8s/pkg/iptableswrapper/mocks"
 	mock_netlinkwrapper "github.com/aws/amazon-vpc-cni-k8s/pkg/netlinkwrapper/mocks"
 	mock_nswrapper "github.com/aws/amazon-vpc-cni-k8s/pkg/nswrapper/mocks"
 	mock_procsyswrapper "github.com/aws/amazon-vpc-cni-k8s/pkg/procsyswrapper/mocks"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/cniutils"
 	mock_veth "github.com/aws/amazon-vpc-cni-k8s/pkg/vethwrapper/mocks"
 )
 
@@ -284,3 +289,73 @@ func TestCmdDelV6(t *testing.T) {
 		fmt.Sprintf("del chain nat %s", snatChainV6)}
 	assert.EqualValues(t, expectIptablesDel, actualIptablesDel)
 }
+
+func TestCmdGCV4(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	args := &skel.CmdArgs{
+		StdinData: []byte(fmt.Sprintf(`{
+				"cniVersion":"1.1.0",
+				"mtu":"9001",
+				"name":"aws-cni",
+				"enabled":"true",
+				"nodeIP": "192.168.1.123",
+				"ipam": {"type":"host-local","ranges":[[{"subnet": "169.254.172.0/22"}]],"routes":[{"dst":"0.0.0.0"}],"dataDir":"/run/cni/v6pd/egress-v4-ipam"},
+				"pluginLogFile":"egress-plugin.log",
+				"pluginLogLevel":"DEBUG",
+				"cni.dev/valid-attachments": [{"containerID":"%s","ifname":"eth0"}],
+				"type":"aws-cni",
+				"vethPrefix":"eni"
+		}`, containerIDV4)),
+	}
+	ipt := mock_iptables.NewMockIPTablesIface(ctrl)
+	ec := egressContext{
+		IPTablesIface: ipt,
+	}
+
+	staleChain := "CNI-E4-0123456789abcdef01234"
+	ipt.EXPECT().List("nat", "POSTROUTING").Return([]string{
+		"-P POSTROUTING ACCEPT",
+		fmt.Sprintf(`-A POSTROUTING -s 169.254.172.10/32 -m comment --comment "name: \"aws-cni\" id: \"%s\"" -j %s`, containerIDV4, snatChainV4),
+		fmt.Sprintf(`-A POSTROUTING -s 169.254.172.11/32 -m comment --comment "name: \"aws-cni\" id: \"stale\"" -j %s`, staleChain),
+	}, nil)
+	ipt.EXPECT().Delete("nat", "POSTROUTING", "-s", "169.254.172.11/32", "-m", "comment", "--comment", `name: "aws-cni" id: "stale"`, "-j", staleChain).Return(nil)
+	ipt.EXPECT().ClearChain("nat", staleChain).Return(nil)
+	ipt.EXPECT().DeleteChain("nat", staleChain).Return(nil)
+
+	err := gc(args, &ec)
+	assert.Nil(t, err)
+}
+
+func TestCmdStatus(t *testing.T) {
+	args := &skel.CmdArgs{
+		StdinData: []byte(`{
+				"cniVersion":"1.1.0",
+				"name":"aws-cni",
+				"enabled":"true",
+				"nodeIP": "192.168.1.123",
+				"pluginLogFile":"egress-plugin.log",
+				"pluginLogLevel":"DEBUG",
+				"type":"aws-cni"
+		}`),
+	}
+
+	ec := egressContext{
+		IptCreator: func(iptables.Protocol) (iptableswrapper.IPTablesIface, error) {
+			return nil, errors.New("iptables not found")
+		},
+	}
+	err := status(args, &ec)
+	assert.Error(t, err)
+	cniErr, ok := err.(*types.Error)
+	assert.True(t, ok)
+	assert.Equal(t, cniutils.ErrPluginNotAvailable, cniErr.Code)
+
+	ec = egressContext{
+		IptCreator: func(iptables.Protocol) (iptableswrapper.IPTablesIface, error) {
+			return mock_iptables.NewMockIPTablesIface(gomock.NewController(t)), nil
+		},
+	}
+	err = status(args, &ec)
+	assert.Nil(t, err)
+}
@@ -30,6 +30,15 @@ const (
 
 	// egressIPv6InterfaceName interface name used in container ns for IPv6 egress traffic
 	egressIPv6InterfaceName = "v6if0"
+
+	// snatChainPrefixV4 is the prefix of the per-container SNAT chain for IPv4 egress traffic
+	snatChainPrefixV4 = "E4-"
+
+	// snatChainPrefixV6 is the prefix of the per-container SNAT chain for IPv6 egress traffic
+	snatChainPrefixV6 = "E6-"
+
+	// cniChainPrefix is prepended to every chain name generated by utils.MustFormatChainNameWithPrefix
+	cniChainPrefix = "CNI-"
 )
 
 // NetConf is our CNI config structure
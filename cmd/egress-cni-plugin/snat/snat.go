@@ -15,6 +15,9 @@ package snat
 
 import (
 	"net"
+	"strings"
+
+	"github.com/containernetworking/plugins/pkg/utils"
 
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/iptableswrapper"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/cniutils"
@@ -108,3 +111,87 @@ func Del(ipt iptableswrapper.IPTablesIface, src net.IP, chain, comment string) (
 
 	return nil
 }
+
+// GC removes the SNAT chains of the network whose names start with chainPrefix but are not listed in validChains,
+// together with the POSTROUTING rules that jump to them. A chain belongs to the network when the comment of its
+// POSTROUTING rule carries the network name, chains of other networks sharing the prefix are left alone. It keeps
+// going on errors and returns the last one encountered.
+func GC(ipt iptableswrapper.IPTablesIface, chainPrefix, networkName string, validChains map[string]bool) (err error) {
+	rules, err := ipt.List("nat", "POSTROUTING")
+	if err != nil {
+		return err
+	}
+	// The comment of the rules is the network name followed by the container ID, see utils.FormatComment
+	commentPrefix := strings.TrimSuffix(utils.FormatComment(networkName, ""), `""`)
+	staleChains := make(map[string]bool)
+	var lastErr error
+	for _, rule := range rules {
+		ruleSpec := splitRuleSpec(rule)
+		// Only "-A POSTROUTING ..." entries carry a jump target
+		if len(ruleSpec) < 2 || ruleSpec[0] != "-A" {
+			continue
+		}
+		chain := ruleArg(ruleSpec, "-j")
+		if !strings.HasPrefix(chain, chainPrefix) || validChains[chain] ||
+			!strings.HasPrefix(ruleArg(ruleSpec, "--comment"), commentPrefix) {
+			continue
+		}
+		staleChains[chain] = true
+		if err := ipt.Delete("nat", "POSTROUTING", ruleSpec[2:]...); err != nil && !cniutils.IsIptableTargetNotExist(err) {
+			lastErr = err
+		}
+	}
+
+	for chain := range staleChains {
+		if err := ipt.ClearChain("nat", chain); err != nil && !cniutils.IsIptableTargetNotExist(err) {
+			lastErr = err
+			continue
+		}
+		if err := ipt.DeleteChain("nat", chain); err != nil && !cniutils.IsIptableTargetNotExist(err) {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// ruleArg returns the argument following the given option of the rule, or the empty string
+func ruleArg(ruleSpec []string, option string) string {
+	for i := 0; i < len(ruleSpec)-1; i++ {
+		if ruleSpec[i] == option {
+			return ruleSpec[i+1]
+		}
+	}
+	return ""
+}
+
+// splitRuleSpec splits a rule as printed by `iptables -S` into its arguments. Double quoted arguments are kept
+// together and the backslash escapes iptables uses for quotes inside comments are undone.
+func splitRuleSpec(rule string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg, inQuotes, escaped := false, false, false
+	for _, r := range rule {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped, inArg = true, true
+		case r == '"':
+			inQuotes, inArg = !inQuotes, true
+		case r == ' ' && !inQuotes:
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
@@ -89,6 +89,59 @@ func TestDelV4(t *testing.T) {
 	assert.EqualValuesf(t, expectRule, actualRule, "iptables rule is expected to be removed")
 }
 
+func TestGCV4(t *testing.T) {
+	ipt := mock_iptables.NewMockIPTablesIface(gomock.NewController(t))
+
+	validChain, staleChain := chainV4+"-valid", chainV4+"-stale"
+	ipt.EXPECT().List("nat", "POSTROUTING").Return([]string{
+		"-P POSTROUTING ACCEPT",
+		fmt.Sprintf(`-A POSTROUTING -s 169.254.172.11/32 -m comment --comment "name: \"aws-cni\" id: \"valid\"" -j %s`, validChain),
+		fmt.Sprintf(`-A POSTROUTING -s %s/32 -m comment --comment "name: \"aws-cni\" id: \"stale\"" -j %s`, containerIPv4, staleChain),
+	}, nil)
+
+	expectClearChain := []string{staleChain}
+	actualClearChain := []string{}
+
+	expectDeleteChain := []string{staleChain}
+	actualDeleteChain := []string{}
+
+	expectRule := []string{fmt.Sprintf(`nat POSTROUTING -s %s/32 -m comment --comment name: "aws-cni" id: "stale" -j %s`, containerIPv4, staleChain)}
+	actualRule := []string{}
+
+	setupDelExpect(ipt, &actualClearChain, &actualDeleteChain, &actualRule)
+
+	err := GC(ipt, chainV4, "aws-cni", map[string]bool{validChain: true})
+	assert.Nil(t, err)
+
+	assert.EqualValuesf(t, expectClearChain, actualClearChain, "stale iptables chain is expected to be cleared")
+
+	assert.EqualValuesf(t, expectDeleteChain, actualDeleteChain, "stale iptables chain is expected to be removed")
+
+	assert.EqualValuesf(t, expectRule, actualRule, "iptables rule jumping to the stale chain is expected to be removed")
+}
+
+func TestGCV4TwoNetworks(t *testing.T) {
+	ipt := mock_iptables.NewMockIPTablesIface(gomock.NewController(t))
+
+	// Both networks share the chain prefix, each one only knows its own valid attachments
+	ours, theirs, stale := chainV4+"-ours", chainV4+"-theirs", chainV4+"-stale"
+	ipt.EXPECT().List("nat", "POSTROUTING").Return([]string{
+		"-P POSTROUTING ACCEPT",
+		fmt.Sprintf(`-A POSTROUTING -s 169.254.172.11/32 -m comment --comment "name: \"aws-cni\" id: \"ours\"" -j %s`, ours),
+		fmt.Sprintf(`-A POSTROUTING -s 169.254.172.12/32 -m comment --comment "name: \"aws-cni-2\" id: \"theirs\"" -j %s`, theirs),
+		fmt.Sprintf(`-A POSTROUTING -s 169.254.172.13/32 -m comment --comment "name: \"aws-cni\" id: \"stale\"" -j %s`, stale),
+	}, nil)
+
+	actualClearChain, actualDeleteChain, actualRule := []string{}, []string{}, []string{}
+	setupDelExpect(ipt, &actualClearChain, &actualDeleteChain, &actualRule)
+
+	err := GC(ipt, chainV4, "aws-cni", map[string]bool{ours: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{stale}, actualClearChain)
+	assert.Equal(t, []string{stale}, actualDeleteChain)
+	assert.Equal(t, []string{fmt.Sprintf(`nat POSTROUTING -s 169.254.172.13/32 -m comment --comment name: "aws-cni" id: "stale" -j %s`, stale)}, actualRule)
+}
+
 func setupAddExpect(ipt iptableswrapper.IPTablesIface, actualNewChain, actualNewRule *[]string) {
 	ipt.(*mock_iptables.MockIPTablesIface).EXPECT().ListChains("nat").Return(
 		[]string{"POSTROUTING"}, nil)
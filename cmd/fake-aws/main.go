@@ -0,0 +1,77 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// fake-aws serves a local stand-in for EC2 and IMDS, so that an unmodified aws-node can be run against it by setting
+// AWS_EC2_ENDPOINT and AWS_EC2_METADATA_SERVICE_ENDPOINT to the listen address
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils/fakeaws"
+)
+
+func main() {
+	os.Exit(_main())
+}
+
+func _main() int {
+	var (
+		cfg    fakeaws.Config
+		listen string
+	)
+	flag.StringVar(&listen, "listen", "127.0.0.1:1338", "address to serve EC2, IMDS and the /fake/ control API on")
+	flag.StringVar(&cfg.InstanceType, "instance-type", fakeaws.DefaultInstanceType, "EC2 instance type, selects the ENI and IP limits")
+	flag.StringVar(&cfg.InstanceID, "instance-id", fakeaws.DefaultInstanceID, "EC2 instance ID")
+	flag.StringVar(&cfg.Region, "region", fakeaws.DefaultRegion, "region of the instance")
+	flag.StringVar(&cfg.AvailabilityZone, "availability-zone", fakeaws.DefaultAvailabilityZone, "availability zone of the instance")
+	flag.StringVar(&cfg.VPCCIDR, "vpc-cidr", fakeaws.DefaultVPCCIDR, "IPv4 CIDR of the VPC")
+	flag.Func("subnet", "subnet as <id>=<cidr>, may be repeated; the first one holds the primary ENI (default "+
+		fakeaws.DefaultSubnetID+"="+fakeaws.DefaultSubnetCIDR+")", func(value string) error {
+		id, cidr, ok := strings.Cut(value, "=")
+		if !ok || id == "" || cidr == "" {
+			return fmt.Errorf("expected <id>=<cidr>, got %q", value)
+		}
+		cfg.Subnets = append(cfg.Subnets, fakeaws.SubnetConfig{ID: id, CIDR: cidr})
+		return nil
+	})
+	flag.Float64Var(&cfg.ThrottleRate, "throttle-rate", 0, "fraction of EC2 calls that fail with RequestLimitExceeded")
+	flag.Int64Var(&cfg.Seed, "seed", 1, "seed for the random source of -throttle-rate")
+	flag.Parse()
+
+	if cfg.ThrottleRate < 0 || cfg.ThrottleRate > 1 {
+		fmt.Fprintf(os.Stderr, "Invalid -throttle-rate %v, must be between 0 and 1\n", cfg.ThrottleRate)
+		return 2
+	}
+	server, err := fakeaws.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up fake: %v\n", err)
+		return 1
+	}
+	l, err := net.Listen("tcp", listen)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to listen: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Serving fake EC2 and IMDS on http://%s\n", l.Addr())
+	if err := http.Serve(l, server); err != nil {
+		fmt.Fprintf(os.Stderr, "Server failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,37 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package logdefaults points the ipamd logger at ipamd-simulator.log in the working directory at info level, unless
+// the log settings of ipamd are set in the environment, so that the report is not drowned out by the errors ipamd logs
+// while pods wait for IPs. The ipamd packages bind their logger while they are initialized, so this package
+// must be initialized before them: it has no dependencies on them and sorts before them by import path, which is the
+// order Go initializes packages in.
+package logdefaults
+
+import "os"
+
+const (
+	envLogFilePath = "AWS_VPC_K8S_CNI_LOG_FILE"
+	envLogLevel    = "AWS_VPC_K8S_CNI_LOGLEVEL"
+)
+
+func init() {
+	setDefault(envLogFilePath, "ipamd-simulator.log")
+	setDefault(envLogLevel, "Info")
+}
+
+func setDefault(key, value string) {
+	if _, ok := os.LookupEnv(key); !ok {
+		os.Setenv(key, value)
+	}
+}
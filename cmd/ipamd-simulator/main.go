@@ -0,0 +1,107 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// ipamd-simulator replays a trace of pod events against the ipamd warm pool management, on an in-memory EC2 and a
+// virtual clock, and reports EC2 call counts, time-to-IP percentiles and peak idle IPs
+package main
+
+import (
+	// Must be the first import, see the package comment
+	_ "github.com/aws/amazon-vpc-cni-k8s/cmd/ipamd-simulator/logdefaults"
+
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/simulator"
+)
+
+// envDefaults are the ipamd settings that the aws-node DaemonSet sets, and that the simulator needs as well
+var envDefaults = map[string]string{
+	"MY_NODE_NAME": "ipamd-simulator",
+	"ENABLE_IPv4":  "true",
+}
+
+func main() {
+	os.Exit(_main())
+}
+
+func _main() int {
+	var (
+		cfg       simulator.Config
+		tracePath string
+		start     string
+		output    string
+	)
+	flag.StringVar(&cfg.InstanceType, "instance-type", "m5.large", "EC2 instance type of the simulated node")
+	flag.StringVar(&tracePath, "trace", "", "trace of pod events, one \"<offset>,<add|del>,<namespace>/<name>\" per line")
+	flag.IntVar(&cfg.MaxPods, "max-pods", 0, "max pods of the node, derived from the ENI and IP limits of the instance type when 0")
+	flag.StringVar(&start, "start", simulator.DefaultStart.Format(time.RFC3339), "virtual time at the start of the trace, for warm pool schedules")
+	flag.DurationVar(&cfg.EC2Latency, "ec2-latency", 200*time.Millisecond, "time taken by each EC2 API call")
+	flag.DurationVar(&cfg.ENIAttachLatency, "eni-attach-latency", 3*time.Second, "time for a new ENI to show up in IMDS")
+	flag.DurationVar(&cfg.RetryInterval, "retry-interval", time.Second, "time before a pod that did not get an IP is retried")
+	flag.DurationVar(&cfg.Drain, "drain", 5*time.Minute, "time simulated after the last event of the trace")
+	flag.StringVar(&output, "output", "text", "report format, text or json")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -trace <file> [flags]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Warm pool settings such as WARM_IP_TARGET are read from the environment, as in ipamd.")
+		fmt.Fprintln(flag.CommandLine.Output())
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if tracePath == "" || (output != "text" && output != "json") {
+		flag.Usage()
+		return 2
+	}
+	var err error
+	if cfg.Start, err = time.Parse(time.RFC3339, start); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid -start %q: %v\n", start, err)
+		return 2
+	}
+
+	f, err := os.Open(tracePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open trace: %v\n", err)
+		return 1
+	}
+	trace, err := simulator.ParseTrace(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	for key, value := range envDefaults {
+		if _, ok := os.LookupEnv(key); !ok {
+			os.Setenv(key, value)
+		}
+	}
+	report, err := simulator.Run(context.Background(), cfg, trace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Simulation failed: %v\n", err)
+		return 1
+	}
+	if output == "json" {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+	return 0
+}
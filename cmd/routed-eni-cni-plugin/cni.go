@@ -189,22 +189,8 @@ func add(args *skel.CmdArgs, cniTypes typeswrapper.CNITYPES, grpcClient grpcwrap
 		args.ContainerID, args.IfName, r)
 
 	// We will let the values in result struct guide us in terms of IP Address Family configured.
-	var v4Addr, v6Addr, addr *net.IPNet
-
-	// We don't support dual stack mode currently so it has to be either v4 or v6 mode.
-	if r.IPv4Addr != "" {
-		v4Addr = &net.IPNet{
-			IP:   net.ParseIP(r.IPv4Addr),
-			Mask: net.CIDRMask(32, 32),
-		}
-		addr = v4Addr
-	} else if r.IPv6Addr != "" {
-		v6Addr = &net.IPNet{
-			IP:   net.ParseIP(r.IPv6Addr),
-			Mask: net.CIDRMask(128, 128),
-		}
-		addr = v6Addr
-	}
+	// In dual-stack mode, both an IPv4 and an IPv6 address are returned.
+	v4Addr, v6Addr := buildContainerAddrs(r.IPv4Addr, r.IPv6Addr)
 	// AddNetwork guarantees that Gateway string is a valid IPNet
 	gw := net.ParseIP(r.PodENISubnetGW)
 
@@ -257,12 +243,16 @@ func add(args *skel.CmdArgs, cniTypes typeswrapper.CNITYPES, grpcClient grpcwrap
 	}
 
 	containerInterfaceIndex := 1
-	ips := []*current.IPConfig{
-		{
+	var ips []*current.IPConfig
+	for _, addr := range []*net.IPNet{v4Addr, v6Addr} {
+		if addr == nil {
+			continue
+		}
+		ips = append(ips, &current.IPConfig{
 			Interface: &containerInterfaceIndex,
 			Address:   *addr,
 			Gateway:   gw,
-		},
+		})
 	}
 
 	hostInterface := &current.Interface{Name: hostVethName}
@@ -409,31 +399,22 @@ func del(args *skel.CmdArgs, cniTypes typeswrapper.CNITYPES, grpcClient grpcwrap
 	log.Infof("Received del network response from ipamd for pod %s namespace %s sandbox %s: %+v", string(k8sArgs.K8S_POD_NAME),
 		string(k8sArgs.K8S_POD_NAMESPACE), string(k8sArgs.K8S_POD_INFRA_CONTAINER_ID), r)
 
-	var deletedPodIP net.IP
-	var maskLen int
-	if r.IPv4Addr != "" {
-		deletedPodIP = net.ParseIP(r.IPv4Addr)
-		maskLen = 32
-	} else if r.IPv6Addr != "" {
-		deletedPodIP = net.ParseIP(r.IPv6Addr)
-		maskLen = 128
-	}
-
-	if deletedPodIP != nil {
-		addr := &net.IPNet{
-			IP:   deletedPodIP,
-			Mask: net.CIDRMask(maskLen, maskLen),
-		}
-
+	v4Addr, v6Addr := buildContainerAddrs(r.IPv4Addr, r.IPv6Addr)
+	if v4Addr != nil || v6Addr != nil {
 		// vlanID != 0 means pod using security group
 		if r.PodVlanId != 0 {
 			if isNetnsEmpty(args.Netns) {
 				log.Infof("Ignoring TeardownPodENI as Netns is empty for SG pod:%s namespace: %s containerID:%s", k8sArgs.K8S_POD_NAME, k8sArgs.K8S_POD_NAMESPACE, k8sArgs.K8S_POD_INFRA_CONTAINER_ID)
 				return nil
 			}
+			// Branch ENI pods are single stack
+			addr := v4Addr
+			if addr == nil {
+				addr = v6Addr
+			}
 			err = driverClient.TeardownBranchENIPodNetwork(addr, int(r.PodVlanId), conf.PodSGEnforcingMode, log)
 		} else {
-			err = driverClient.TeardownPodNetwork(addr, int(r.DeviceNumber), log)
+			err = driverClient.TeardownPodNetwork(v4Addr, v6Addr, int(r.DeviceNumber), log)
 		}
 
 		if err != nil {
@@ -447,6 +428,218 @@ func del(args *skel.CmdArgs, cniTypes typeswrapper.CNITYPES, grpcClient grpcwrap
 	return nil
 }
 
+func cmdCheck(args *skel.CmdArgs) error {
+	return check(args, typeswrapper.New(), grpcwrapper.New(), rpcwrapper.New(), driver.New())
+}
+
+func check(args *skel.CmdArgs, cniTypes typeswrapper.CNITYPES, grpcClient grpcwrapper.GRPC, rpcClient rpcwrapper.RPC,
+	driverClient driver.NetworkAPIs) error {
+
+	conf, log, err := LoadNetConf(args.StdinData)
+	if err != nil {
+		return errors.Wrap(err, "check cmd: error loading config from args")
+	}
+
+	log.Infof("Received CNI check request: ContainerID(%s) Netns(%s) IfName(%s) Args(%s) Path(%s) argsStdinData(%s)",
+		args.ContainerID, args.Netns, args.IfName, args.Args, args.Path, args.StdinData)
+
+	var k8sArgs K8sArgs
+	if err := cniTypes.LoadArgs(args.Args, &k8sArgs); err != nil {
+		log.Errorf("Failed to load k8s config from args: %v", err)
+		return errors.Wrap(err, "check cmd: failed to load k8s config from args")
+	}
+
+	// The runtime must supply the result of the previous ADD; without it there is nothing to verify against.
+	if conf.PrevResult == nil {
+		return errors.New("check cmd: required prevResult missing")
+	}
+	prevResult, err := current.NewResultFromResult(conf.PrevResult)
+	if err != nil {
+		return errors.Wrap(err, "check cmd: failed to convert prevResult")
+	}
+
+	dummyIfaceName := networkutils.GeneratePodHostVethName(dummyInterfacePrefix, string(k8sArgs.K8S_POD_NAMESPACE), string(k8sArgs.K8S_POD_NAME))
+	_, dummyIface, found := cniutils.FindInterfaceByName(prevResult.Interfaces, dummyIfaceName)
+	if !found {
+		return errors.Errorf("check cmd: cannot find dummy interface %s in prevResult", dummyIfaceName)
+	}
+	podVlanID, err := strconv.Atoi(dummyIface.Mac)
+	if err != nil {
+		return errors.Errorf("check cmd: malformed vlanID in prevResult: %s", dummyIface.Mac)
+	}
+	// Branch ENI pods are not tracked in the ipamd datastore, so only the host and container side can be verified.
+	if podVlanID != 0 {
+		containerIP, err := getContainerIP(prevResult, args.IfName)
+		if err != nil {
+			return errors.Wrap(err, "check cmd")
+		}
+		hostVethNamePrefix := sgpp.BuildHostVethNamePrefix(conf.VethPrefix, conf.PodSGEnforcingMode)
+		hostVethName := networkutils.GeneratePodHostVethName(hostVethNamePrefix, string(k8sArgs.K8S_POD_NAMESPACE), string(k8sArgs.K8S_POD_NAME))
+		if err := driverClient.CheckBranchENIPodNetwork(hostVethName, args.IfName, args.Netns, &containerIP, podVlanID, conf.PodSGEnforcingMode, log); err != nil {
+			log.Errorf("Failed CheckBranchENIPodNetwork for container %s: %v", args.ContainerID, err)
+			return errors.Wrap(err, "check cmd: failed to verify pod network")
+		}
+		return nil
+	}
+
+	containerV4Addr, containerV6Addr, err := getContainerAddrs(prevResult, args.IfName)
+	if err != nil {
+		return errors.Wrap(err, "check cmd")
+	}
+	deviceNumber, err := strconv.Atoi(dummyIface.Sandbox)
+	if err != nil {
+		return errors.Errorf("check cmd: malformed device number in prevResult: %s", dummyIface.Sandbox)
+	}
+
+	conn, err := grpcClient.Dial(ipamdAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Errorf("Failed to connect to backend server for container %s: %v", args.ContainerID, err)
+		return errors.Wrap(err, "check cmd: failed to connect to backend server")
+	}
+	defer conn.Close()
+
+	c := rpcClient.NewCNIBackendClient(conn)
+
+	r, err := c.CheckNetwork(context.Background(), &pb.CheckNetworkRequest{
+		ClientVersion:              version,
+		K8S_POD_NAME:               string(k8sArgs.K8S_POD_NAME),
+		K8S_POD_NAMESPACE:          string(k8sArgs.K8S_POD_NAMESPACE),
+		K8S_POD_INFRA_CONTAINER_ID: string(k8sArgs.K8S_POD_INFRA_CONTAINER_ID),
+		ContainerID:                args.ContainerID,
+		IfName:                     args.IfName,
+		NetworkName:                conf.Name,
+	})
+	if err != nil {
+		log.Errorf("Error received from CheckNetwork gRPC call for container %s: %v", args.ContainerID, err)
+		return errors.Wrap(err, "check cmd: Error received from CheckNetwork gRPC call")
+	}
+	if !r.Success {
+		log.Errorf("ipamd has no allocation for container %s", args.ContainerID)
+		return errors.New("check cmd: ipamd has no allocation for container")
+	}
+
+	allocatedV4Addr, allocatedV6Addr := buildContainerAddrs(r.IPv4Addr, r.IPv6Addr)
+	if !sameContainerAddr(allocatedV4Addr, containerV4Addr) || !sameContainerAddr(allocatedV6Addr, containerV6Addr) {
+		return errors.Errorf("check cmd: ipamd allocation %s does not match prevResult address %s",
+			formatContainerAddrs(allocatedV4Addr, allocatedV6Addr), formatContainerAddrs(containerV4Addr, containerV6Addr))
+	}
+	if int(r.DeviceNumber) != deviceNumber {
+		return errors.Errorf("check cmd: ipamd device number %d does not match prevResult device number %d", r.DeviceNumber, deviceNumber)
+	}
+
+	hostVethName := networkutils.GeneratePodHostVethName(conf.VethPrefix, string(k8sArgs.K8S_POD_NAMESPACE), string(k8sArgs.K8S_POD_NAME))
+	if err := driverClient.CheckPodNetwork(hostVethName, args.IfName, args.Netns, containerV4Addr, containerV6Addr, deviceNumber, log); err != nil {
+		log.Errorf("Failed CheckPodNetwork for container %s: %v", args.ContainerID, err)
+		return errors.Wrap(err, "check cmd: failed to verify pod network")
+	}
+	log.Infof("Verified pod network for container %s interface %s", args.ContainerID, args.IfName)
+	return nil
+}
+
+func cmdGC(args *skel.CmdArgs) error {
+	return gc(args, grpcwrapper.New(), rpcwrapper.New(), driver.New())
+}
+
+func gc(args *skel.CmdArgs, grpcClient grpcwrapper.GRPC, rpcClient rpcwrapper.RPC, driverClient driver.NetworkAPIs) error {
+	conf, log, err := LoadNetConf(args.StdinData)
+	if err != nil {
+		return errors.Wrap(err, "gc cmd: error loading config from args")
+	}
+
+	log.Infof("Received CNI gc request: Network(%s) ValidAttachments(%d) Path(%s)", conf.Name, len(conf.ValidAttachments), args.Path)
+
+	conn, err := grpcClient.Dial(ipamdAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Errorf("Failed to connect to backend server for gc: %v", err)
+		return errors.Wrap(err, "gc cmd: failed to connect to backend server")
+	}
+	defer conn.Close()
+
+	c := rpcClient.NewCNIBackendClient(conn)
+
+	req := &pb.GCNetworkRequest{
+		ClientVersion: version,
+		NetworkName:   conf.Name,
+	}
+	for _, attachment := range conf.ValidAttachments {
+		req.ValidAttachments = append(req.ValidAttachments, &pb.GCAttachment{
+			ContainerID: attachment.ContainerID,
+			IfName:      attachment.IfName,
+		})
+	}
+	r, err := c.GCNetwork(context.Background(), req)
+	if err != nil {
+		log.Errorf("Error received from GCNetwork gRPC call: %v", err)
+		return errors.Wrap(err, "gc cmd: Error received from GCNetwork gRPC call")
+	}
+	if !r.Success {
+		log.Errorf("Failed to process gc request: Success == false")
+		return errors.New("gc cmd: failed to process gc request")
+	}
+
+	// ipamd has already released the addresses; tear down the rules and host-side veths that were left behind for them.
+	// Keep going on errors so that a single failure does not block cleanup of the remaining allocations.
+	var lastErr error
+	for _, released := range r.Released {
+		log.Infof("Tearing down stale allocation released by ipamd: ContainerID(%s) IfName(%s) PodNamespace(%s) PodName(%s) IPv4Addr(%s) IPv6Addr(%s) DeviceNumber(%d)",
+			released.ContainerID, released.IfName, released.K8S_POD_NAMESPACE, released.K8S_POD_NAME, released.IPv4Addr, released.IPv6Addr, released.DeviceNumber)
+
+		v4Addr, v6Addr := buildContainerAddrs(released.IPv4Addr, released.IPv6Addr)
+		if v4Addr != nil || v6Addr != nil {
+			if err := driverClient.TeardownPodNetwork(v4Addr, v6Addr, int(released.DeviceNumber), log); err != nil {
+				log.Errorf("Failed on TeardownPodNetwork for container ID %s: %v", released.ContainerID, err)
+				lastErr = err
+			}
+		}
+
+		// Host veth names are derived from the pod name, so leave the veth alone while another sandbox of the pod uses it.
+		// Allocations migrated from CRI carry no pod metadata to derive the name from.
+		if released.PodInUse || released.K8S_POD_NAME == "" || released.K8S_POD_NAMESPACE == "" {
+			continue
+		}
+		hostVethName := networkutils.GeneratePodHostVethName(conf.VethPrefix, released.K8S_POD_NAMESPACE, released.K8S_POD_NAME)
+		if err := driverClient.TeardownHostVeth(hostVethName, log); err != nil {
+			log.Errorf("Failed to delete host veth %s for container %s: %v", hostVethName, released.ContainerID, err)
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		return errors.Wrap(lastErr, "gc cmd: failed to tear down stale pod network")
+	}
+	return nil
+}
+
+func cmdStatus(args *skel.CmdArgs) error {
+	return status(args, grpcwrapper.New(), rpcwrapper.New())
+}
+
+func status(args *skel.CmdArgs, grpcClient grpcwrapper.GRPC, rpcClient rpcwrapper.RPC) error {
+	_, log, err := LoadNetConf(args.StdinData)
+	if err != nil {
+		return errors.Wrap(err, "status cmd: error loading config from args")
+	}
+
+	conn, err := grpcClient.Dial(ipamdAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Errorf("Failed to connect to backend server: %v", err)
+		return types.NewError(cniutils.ErrPluginNotAvailable, "ipamd is not reachable", err.Error())
+	}
+	defer conn.Close()
+
+	c := rpcClient.NewCNIBackendClient(conn)
+
+	r, err := c.Status(context.Background(), &pb.StatusRequest{ClientVersion: version})
+	if err != nil {
+		log.Errorf("Error received from Status gRPC call: %v", err)
+		return types.NewError(cniutils.ErrPluginNotAvailable, "ipamd is not reachable", err.Error())
+	}
+	if !r.Ready {
+		log.Infof("ipamd is not ready: %s", r.Reason)
+		return types.NewError(cniutils.ErrPluginNotAvailable, "ipamd is not ready", r.Reason)
+	}
+	return nil
+}
+
 func getContainerIP(prevResult *current.Result, contVethName string) (net.IPNet, error) {
 	containerIfaceIndex, _, found := cniutils.FindInterfaceByName(prevResult.Interfaces, contVethName)
 	if !found {
@@ -459,6 +652,65 @@ func getContainerIP(prevResult *current.Result, contVethName string) (net.IPNet,
 	return containerIPs[0].Address, nil
 }
 
+// getContainerAddrs returns the IPv4 and IPv6 addresses of contVethName in prevResult. Either may be nil, but not both.
+func getContainerAddrs(prevResult *current.Result, contVethName string) (*net.IPNet, *net.IPNet, error) {
+	containerIfaceIndex, _, found := cniutils.FindInterfaceByName(prevResult.Interfaces, contVethName)
+	if !found {
+		return nil, nil, errors.Errorf("cannot find contVethName %s in prevResult", contVethName)
+	}
+	var v4Addr, v6Addr *net.IPNet
+	containerIPs := cniutils.FindIPConfigsByIfaceIndex(prevResult.IPs, containerIfaceIndex)
+	for _, containerIP := range containerIPs {
+		addr := containerIP.Address
+		if addr.IP.To4() != nil && v4Addr == nil {
+			v4Addr = &addr
+		} else if addr.IP.To4() == nil && v6Addr == nil {
+			v6Addr = &addr
+		} else {
+			return nil, nil, errors.Errorf("found more than one containerIP per address family for %v in prevResult", contVethName)
+		}
+	}
+	if v4Addr == nil && v6Addr == nil {
+		return nil, nil, errors.Errorf("found 0 containerIPs for %v in prevResult", contVethName)
+	}
+	return v4Addr, v6Addr, nil
+}
+
+// buildContainerAddrs returns the host prefixes of the pod addresses returned by ipamd, nil for an empty address
+func buildContainerAddrs(ipv4Addr string, ipv6Addr string) (*net.IPNet, *net.IPNet) {
+	var v4Addr, v6Addr *net.IPNet
+	if ipv4Addr != "" {
+		v4Addr = &net.IPNet{
+			IP:   net.ParseIP(ipv4Addr),
+			Mask: net.CIDRMask(32, 32),
+		}
+	}
+	if ipv6Addr != "" {
+		v6Addr = &net.IPNet{
+			IP:   net.ParseIP(ipv6Addr),
+			Mask: net.CIDRMask(128, 128),
+		}
+	}
+	return v4Addr, v6Addr
+}
+
+func sameContainerAddr(a *net.IPNet, b *net.IPNet) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.IP.Equal(b.IP)
+}
+
+func formatContainerAddrs(v4Addr *net.IPNet, v6Addr *net.IPNet) string {
+	var addrs []string
+	for _, addr := range []*net.IPNet{v4Addr, v6Addr} {
+		if addr != nil {
+			addrs = append(addrs, addr.IP.String())
+		}
+	}
+	return strings.Join(addrs, ",")
+}
+
 // tryDelWithPrevResult will try to process CNI delete request without IPAMD.
 // returns true if the del request is handled.
 func tryDelWithPrevResult(driverClient driver.NetworkAPIs, conf *NetConf, k8sArgs K8sArgs, contVethName string, netNS string, log logger.Logger) (bool, error) {
@@ -522,13 +774,13 @@ func teardownPodNetworkWithPrevResult(driverClient driver.NetworkAPIs, conf *Net
 		log.Errorf("Invalid device number for pod: %s", dummyIface.Sandbox)
 		return false
 	}
-	containerIP, err := getContainerIP(prevResult, contVethName)
+	containerV4Addr, containerV6Addr, err := getContainerAddrs(prevResult, contVethName)
 	if err != nil {
 		log.Errorf("Failed to get container IP: %v", err)
 		return false
 	}
 
-	if err := driverClient.TeardownPodNetwork(&containerIP, deviceNumber, log); err != nil {
+	if err := driverClient.TeardownPodNetwork(containerV4Addr, containerV6Addr, deviceNumber, log); err != nil {
 		log.Errorf("Failed to teardown pod network: %v", err)
 		return false
 	}
@@ -547,7 +799,14 @@ func main() {
 	log := logger.DefaultLogger()
 	about := fmt.Sprintf("AWS CNI %s", version)
 	exitCode := 0
-	if e := skel.PluginMainWithError(cmdAdd, nil, cmdDel, cniSpecVersion.All, about); e != nil {
+	funcs := skel.CNIFuncs{
+		Add:    cmdAdd,
+		Check:  cmdCheck,
+		Del:    cmdDel,
+		GC:     cmdGC,
+		Status: cmdStatus,
+	}
+	if e := skel.PluginMainFuncsWithError(funcs, cniSpecVersion.All, about); e != nil {
 		if err := e.Print(); err != nil {
 			log.Errorf("Failed to write error to stdout: %v", err)
 		}
@@ -19,7 +19,9 @@ import (
 	"net"
 	"testing"
 
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/networkutils"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/sgpp"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/cniutils"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/logger"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	current "github.com/containernetworking/cni/pkg/types/100"
@@ -48,6 +50,7 @@ const (
 	pluginLogFile  = "/var/log/aws-routed-eni/plugin.log"
 	cniType        = "aws-cni"
 	ipAddr         = "10.0.1.15"
+	ipv6Addr       = "2001:db8::15"
 	devNum         = 4
 )
 
@@ -110,6 +113,51 @@ func TestCmdAdd(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestCmdAddDualStack(t *testing.T) {
+	ctrl, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork := setup(t)
+	defer ctrl.Finish()
+
+	stdinData, _ := json.Marshal(netConf)
+
+	cmdArgs := &skel.CmdArgs{ContainerID: containerID,
+		Netns:     netNS,
+		IfName:    ifName,
+		StdinData: stdinData}
+
+	mocksTypes.EXPECT().LoadArgs(gomock.Any(), gomock.Any()).Return(nil)
+
+	conn, _ := grpc.Dial(ipamdAddress, grpc.WithInsecure())
+
+	mocksGRPC.EXPECT().Dial(gomock.Any(), gomock.Any()).Return(conn, nil)
+	mockC := mock_rpc.NewMockCNIBackendClient(ctrl)
+	mocksRPC.EXPECT().NewCNIBackendClient(conn).Return(mockC)
+
+	addNetworkReply := &rpc.AddNetworkReply{Success: true, IPv4Addr: ipAddr, IPv6Addr: ipv6Addr, DeviceNumber: devNum, NetworkPolicyMode: "none"}
+	mockC.EXPECT().AddNetwork(gomock.Any(), gomock.Any()).Return(addNetworkReply, nil)
+
+	v4Addr := &net.IPNet{
+		IP:   net.ParseIP(addNetworkReply.IPv4Addr),
+		Mask: net.CIDRMask(32, 32),
+	}
+	v6Addr := &net.IPNet{
+		IP:   net.ParseIP(addNetworkReply.IPv6Addr),
+		Mask: net.CIDRMask(128, 128),
+	}
+	mocksNetwork.EXPECT().SetupPodNetwork(gomock.Any(), cmdArgs.IfName, cmdArgs.Netns,
+		v4Addr, v6Addr, int(addNetworkReply.DeviceNumber), gomock.Any(), gomock.Any()).Return(nil)
+
+	mocksTypes.EXPECT().PrintResult(gomock.Any(), gomock.Any()).DoAndReturn(func(result types.Result, _ string) error {
+		ips := result.(*current.Result).IPs
+		assert.Len(t, ips, 2)
+		assert.Equal(t, *v4Addr, ips[0].Address)
+		assert.Equal(t, *v6Addr, ips[1].Address)
+		return nil
+	})
+
+	err := add(cmdArgs, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork)
+	assert.Nil(t, err)
+}
+
 func TestCmdAddWithNPenabled(t *testing.T) {
 	ctrl, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork := setup(t)
 	defer ctrl.Finish()
@@ -290,7 +338,37 @@ func TestCmdDel(t *testing.T) {
 		Mask: net.IPv4Mask(255, 255, 255, 255),
 	}
 
-	mocksNetwork.EXPECT().TeardownPodNetwork(addr, int(delNetworkReply.DeviceNumber), gomock.Any()).Return(nil)
+	mocksNetwork.EXPECT().TeardownPodNetwork(addr, nil, int(delNetworkReply.DeviceNumber), gomock.Any()).Return(nil)
+
+	err := del(cmdArgs, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork)
+	assert.Nil(t, err)
+}
+
+func TestCmdDelDualStack(t *testing.T) {
+	ctrl, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork := setup(t)
+	defer ctrl.Finish()
+
+	stdinData, _ := json.Marshal(netConf)
+
+	cmdArgs := &skel.CmdArgs{ContainerID: containerID,
+		Netns:     netNS,
+		IfName:    ifName,
+		StdinData: stdinData}
+
+	mocksTypes.EXPECT().LoadArgs(gomock.Any(), gomock.Any()).Return(nil)
+
+	conn, _ := grpc.Dial(ipamdAddress, grpc.WithInsecure())
+
+	mocksGRPC.EXPECT().Dial(gomock.Any(), gomock.Any()).Return(conn, nil)
+	mockC := mock_rpc.NewMockCNIBackendClient(ctrl)
+	mocksRPC.EXPECT().NewCNIBackendClient(conn).Return(mockC)
+
+	delNetworkReply := &rpc.DelNetworkReply{Success: true, IPv4Addr: ipAddr, IPv6Addr: ipv6Addr, DeviceNumber: devNum}
+	mockC.EXPECT().DelNetwork(gomock.Any(), gomock.Any()).Return(delNetworkReply, nil)
+
+	v4Addr := &net.IPNet{IP: net.ParseIP(ipAddr), Mask: net.CIDRMask(32, 32)}
+	v6Addr := &net.IPNet{IP: net.ParseIP(ipv6Addr), Mask: net.CIDRMask(128, 128)}
+	mocksNetwork.EXPECT().TeardownPodNetwork(v4Addr, v6Addr, int(delNetworkReply.DeviceNumber), gomock.Any()).Return(nil)
 
 	err := del(cmdArgs, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork)
 	assert.Nil(t, err)
@@ -352,7 +430,7 @@ func TestCmdDelErrTeardown(t *testing.T) {
 		Mask: net.IPv4Mask(255, 255, 255, 255),
 	}
 
-	mocksNetwork.EXPECT().TeardownPodNetwork(addr, int(delNetworkReply.DeviceNumber), gomock.Any()).Return(errors.New("error on teardown"))
+	mocksNetwork.EXPECT().TeardownPodNetwork(addr, nil, int(delNetworkReply.DeviceNumber), gomock.Any()).Return(errors.New("error on teardown"))
 
 	err := del(cmdArgs, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork)
 	assert.Error(t, err)
@@ -428,6 +506,334 @@ func TestCmdDelForPodENINetwork(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func checkNetConfWithPrevResult(t *testing.T, vlanID string, deviceNumber string) []byte {
+	return checkNetConfWithPrevResultIPs(t, vlanID, deviceNumber, net.IPNet{IP: net.ParseIP(ipAddr), Mask: net.CIDRMask(32, 32)})
+}
+
+func checkNetConfWithPrevResultIPs(t *testing.T, vlanID string, deviceNumber string, addrs ...net.IPNet) []byte {
+	prevResult := &current.Result{
+		CNIVersion: "1.0.0",
+		Interfaces: []*current.Interface{
+			{Name: "enicc21c2d7785"},
+			{Name: ifName, Sandbox: netNS},
+			{Name: "dummycc21c2d7785", Mac: vlanID, Sandbox: deviceNumber},
+		},
+	}
+	for _, addr := range addrs {
+		prevResult.IPs = append(prevResult.IPs, &current.IPConfig{Address: addr, Interface: aws.Int(1)})
+	}
+	rawPrevResult, err := json.Marshal(prevResult)
+	assert.NoError(t, err)
+
+	conf := *netConf
+	conf.CNIVersion = "1.0.0"
+	conf.VethPrefix = "eni"
+	err = json.Unmarshal(rawPrevResult, &conf.RawPrevResult)
+	assert.NoError(t, err)
+	stdinData, err := json.Marshal(conf)
+	assert.NoError(t, err)
+	return stdinData
+}
+
+func loadSamplePodArgs(_ string, container interface{}) error {
+	k8sArgs := container.(*K8sArgs)
+	k8sArgs.K8S_POD_NAMESPACE = "default"
+	k8sArgs.K8S_POD_NAME = "sample-pod"
+	return nil
+}
+
+func TestCmdCheck(t *testing.T) {
+	ctrl, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork := setup(t)
+	defer ctrl.Finish()
+
+	cmdArgs := &skel.CmdArgs{ContainerID: containerID,
+		Netns:     netNS,
+		IfName:    ifName,
+		StdinData: checkNetConfWithPrevResult(t, "0", "4")}
+
+	mocksTypes.EXPECT().LoadArgs(gomock.Any(), gomock.Any()).DoAndReturn(loadSamplePodArgs)
+
+	conn, _ := grpc.Dial(ipamdAddress, grpc.WithInsecure())
+
+	mocksGRPC.EXPECT().Dial(gomock.Any(), gomock.Any()).Return(conn, nil)
+	mockC := mock_rpc.NewMockCNIBackendClient(ctrl)
+	mocksRPC.EXPECT().NewCNIBackendClient(conn).Return(mockC)
+
+	checkNetworkReply := &rpc.CheckNetworkReply{Success: true, IPv4Addr: ipAddr, DeviceNumber: devNum}
+	mockC.EXPECT().CheckNetwork(gomock.Any(), gomock.Any()).Return(checkNetworkReply, nil)
+
+	addr := &net.IPNet{
+		IP:   net.ParseIP(ipAddr),
+		Mask: net.IPv4Mask(255, 255, 255, 255),
+	}
+	mocksNetwork.EXPECT().CheckPodNetwork("enicc21c2d7785", ifName, netNS, addr, nil, devNum, gomock.Any()).Return(nil)
+
+	err := check(cmdArgs, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork)
+	assert.Nil(t, err)
+}
+
+func TestCmdCheckDualStack(t *testing.T) {
+	ctrl, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork := setup(t)
+	defer ctrl.Finish()
+
+	v4Addr := &net.IPNet{IP: net.ParseIP(ipAddr), Mask: net.CIDRMask(32, 32)}
+	v6Addr := &net.IPNet{IP: net.ParseIP(ipv6Addr), Mask: net.CIDRMask(128, 128)}
+	cmdArgs := &skel.CmdArgs{ContainerID: containerID,
+		Netns:     netNS,
+		IfName:    ifName,
+		StdinData: checkNetConfWithPrevResultIPs(t, "0", "4", *v4Addr, *v6Addr)}
+
+	mocksTypes.EXPECT().LoadArgs(gomock.Any(), gomock.Any()).DoAndReturn(loadSamplePodArgs)
+
+	conn, _ := grpc.Dial(ipamdAddress, grpc.WithInsecure())
+
+	mocksGRPC.EXPECT().Dial(gomock.Any(), gomock.Any()).Return(conn, nil)
+	mockC := mock_rpc.NewMockCNIBackendClient(ctrl)
+	mocksRPC.EXPECT().NewCNIBackendClient(conn).Return(mockC)
+
+	checkNetworkReply := &rpc.CheckNetworkReply{Success: true, IPv4Addr: ipAddr, IPv6Addr: ipv6Addr, DeviceNumber: devNum}
+	mockC.EXPECT().CheckNetwork(gomock.Any(), gomock.Any()).Return(checkNetworkReply, nil)
+
+	mocksNetwork.EXPECT().CheckPodNetwork("enicc21c2d7785", ifName, netNS, gomock.Any(), gomock.Any(), devNum, gomock.Any()).DoAndReturn(
+		func(_, _, _ string, containerV4Addr, containerV6Addr *net.IPNet, _ int, _ logger.Logger) error {
+			assert.True(t, v4Addr.IP.Equal(containerV4Addr.IP))
+			assert.True(t, v6Addr.IP.Equal(containerV6Addr.IP))
+			return nil
+		})
+
+	err := check(cmdArgs, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork)
+	assert.Nil(t, err)
+}
+
+func TestCmdCheckDualStackMissingIPv6Allocation(t *testing.T) {
+	ctrl, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork := setup(t)
+	defer ctrl.Finish()
+
+	cmdArgs := &skel.CmdArgs{ContainerID: containerID,
+		Netns:  netNS,
+		IfName: ifName,
+		StdinData: checkNetConfWithPrevResultIPs(t, "0", "4",
+			net.IPNet{IP: net.ParseIP(ipAddr), Mask: net.CIDRMask(32, 32)},
+			net.IPNet{IP: net.ParseIP(ipv6Addr), Mask: net.CIDRMask(128, 128)})}
+
+	mocksTypes.EXPECT().LoadArgs(gomock.Any(), gomock.Any()).DoAndReturn(loadSamplePodArgs)
+
+	conn, _ := grpc.Dial(ipamdAddress, grpc.WithInsecure())
+
+	mocksGRPC.EXPECT().Dial(gomock.Any(), gomock.Any()).Return(conn, nil)
+	mockC := mock_rpc.NewMockCNIBackendClient(ctrl)
+	mocksRPC.EXPECT().NewCNIBackendClient(conn).Return(mockC)
+
+	checkNetworkReply := &rpc.CheckNetworkReply{Success: true, IPv4Addr: ipAddr, DeviceNumber: devNum}
+	mockC.EXPECT().CheckNetwork(gomock.Any(), gomock.Any()).Return(checkNetworkReply, nil)
+
+	err := check(cmdArgs, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork)
+	assert.EqualError(t, err, "check cmd: ipamd allocation 10.0.1.15 does not match prevResult address 10.0.1.15,2001:db8::15")
+}
+
+func TestCmdCheckWithoutPrevResult(t *testing.T) {
+	ctrl, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork := setup(t)
+	defer ctrl.Finish()
+
+	stdinData, _ := json.Marshal(netConf)
+
+	cmdArgs := &skel.CmdArgs{ContainerID: containerID,
+		Netns:     netNS,
+		IfName:    ifName,
+		StdinData: stdinData}
+
+	mocksTypes.EXPECT().LoadArgs(gomock.Any(), gomock.Any()).Return(nil)
+
+	err := check(cmdArgs, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork)
+	assert.EqualError(t, err, "check cmd: required prevResult missing")
+}
+
+func TestCmdCheckErrUnknownPod(t *testing.T) {
+	ctrl, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork := setup(t)
+	defer ctrl.Finish()
+
+	cmdArgs := &skel.CmdArgs{ContainerID: containerID,
+		Netns:     netNS,
+		IfName:    ifName,
+		StdinData: checkNetConfWithPrevResult(t, "0", "4")}
+
+	mocksTypes.EXPECT().LoadArgs(gomock.Any(), gomock.Any()).DoAndReturn(loadSamplePodArgs)
+
+	conn, _ := grpc.Dial(ipamdAddress, grpc.WithInsecure())
+
+	mocksGRPC.EXPECT().Dial(gomock.Any(), gomock.Any()).Return(conn, nil)
+	mockC := mock_rpc.NewMockCNIBackendClient(ctrl)
+	mocksRPC.EXPECT().NewCNIBackendClient(conn).Return(mockC)
+
+	mockC.EXPECT().CheckNetwork(gomock.Any(), gomock.Any()).Return(&rpc.CheckNetworkReply{Success: false}, errors.New("datastore: unknown pod"))
+
+	err := check(cmdArgs, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork)
+	assert.EqualError(t, err, "check cmd: Error received from CheckNetwork gRPC call: datastore: unknown pod")
+}
+
+func TestCmdCheckAllocationMismatch(t *testing.T) {
+	ctrl, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork := setup(t)
+	defer ctrl.Finish()
+
+	cmdArgs := &skel.CmdArgs{ContainerID: containerID,
+		Netns:     netNS,
+		IfName:    ifName,
+		StdinData: checkNetConfWithPrevResult(t, "0", "4")}
+
+	mocksTypes.EXPECT().LoadArgs(gomock.Any(), gomock.Any()).DoAndReturn(loadSamplePodArgs)
+
+	conn, _ := grpc.Dial(ipamdAddress, grpc.WithInsecure())
+
+	mocksGRPC.EXPECT().Dial(gomock.Any(), gomock.Any()).Return(conn, nil)
+	mockC := mock_rpc.NewMockCNIBackendClient(ctrl)
+	mocksRPC.EXPECT().NewCNIBackendClient(conn).Return(mockC)
+
+	checkNetworkReply := &rpc.CheckNetworkReply{Success: true, IPv4Addr: "10.0.1.16", DeviceNumber: devNum}
+	mockC.EXPECT().CheckNetwork(gomock.Any(), gomock.Any()).Return(checkNetworkReply, nil)
+
+	err := check(cmdArgs, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork)
+	assert.EqualError(t, err, "check cmd: ipamd allocation 10.0.1.16 does not match prevResult address 10.0.1.15")
+}
+
+func TestCmdCheckForPodENINetwork(t *testing.T) {
+	ctrl, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork := setup(t)
+	defer ctrl.Finish()
+
+	cmdArgs := &skel.CmdArgs{ContainerID: containerID,
+		Netns:     netNS,
+		IfName:    ifName,
+		StdinData: checkNetConfWithPrevResult(t, "7", "")}
+
+	mocksTypes.EXPECT().LoadArgs(gomock.Any(), gomock.Any()).DoAndReturn(loadSamplePodArgs)
+
+	addr := &net.IPNet{
+		IP:   net.ParseIP(ipAddr),
+		Mask: net.IPv4Mask(255, 255, 255, 255),
+	}
+	mocksNetwork.EXPECT().CheckBranchENIPodNetwork("vlancc21c2d7785", ifName, netNS, addr, 7, sgpp.EnforcingModeStrict, gomock.Any()).Return(nil)
+
+	err := check(cmdArgs, mocksTypes, mocksGRPC, mocksRPC, mocksNetwork)
+	assert.Nil(t, err)
+}
+
+func gcNetConf(t *testing.T, validAttachments []types.GCAttachment) []byte {
+	conf := *netConf
+	conf.CNIVersion = "1.1.0"
+	conf.VethPrefix = "eni"
+	conf.ValidAttachments = validAttachments
+	stdinData, err := json.Marshal(conf)
+	assert.NoError(t, err)
+	return stdinData
+}
+
+func TestCmdGC(t *testing.T) {
+	ctrl, _, mocksGRPC, mocksRPC, mocksNetwork := setup(t)
+	defer ctrl.Finish()
+
+	cmdArgs := &skel.CmdArgs{StdinData: gcNetConf(t, []types.GCAttachment{{ContainerID: containerID, IfName: ifName}})}
+
+	conn, _ := grpc.Dial(ipamdAddress, grpc.WithInsecure())
+
+	mocksGRPC.EXPECT().Dial(gomock.Any(), gomock.Any()).Return(conn, nil)
+	mockC := mock_rpc.NewMockCNIBackendClient(ctrl)
+	mocksRPC.EXPECT().NewCNIBackendClient(conn).Return(mockC)
+
+	expectedRequest := &rpc.GCNetworkRequest{
+		NetworkName:      cniName,
+		ValidAttachments: []*rpc.GCAttachment{{ContainerID: containerID, IfName: ifName}},
+	}
+	gcNetworkReply := &rpc.GCNetworkReply{
+		Success: true,
+		Released: []*rpc.GCReleasedAllocation{
+			{ContainerID: "stale-container", IfName: ifName, K8S_POD_NAME: "sample-pod", K8S_POD_NAMESPACE: "default", IPv4Addr: ipAddr, DeviceNumber: devNum},
+			// Another sandbox of this pod still owns the host veth
+			{ContainerID: "stale-sandbox", IfName: ifName, K8S_POD_NAME: "restarted-pod", K8S_POD_NAMESPACE: "default", IPv4Addr: "10.0.1.16", DeviceNumber: devNum, PodInUse: true},
+			// Allocations migrated from CRI carry no pod metadata
+			{ContainerID: "migrated-container", IfName: "unknown", IPv4Addr: "10.0.1.17"},
+		},
+	}
+	mockC.EXPECT().GCNetwork(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ interface{}, in *rpc.GCNetworkRequest, _ ...grpc.CallOption) (*rpc.GCNetworkReply, error) {
+			assert.Equal(t, expectedRequest.NetworkName, in.NetworkName)
+			assert.Equal(t, expectedRequest.ValidAttachments, in.ValidAttachments)
+			return gcNetworkReply, nil
+		})
+
+	for ip, deviceNumber := range map[string]int{ipAddr: devNum, "10.0.1.16": devNum, "10.0.1.17": 0} {
+		addr := &net.IPNet{IP: net.ParseIP(ip), Mask: net.IPv4Mask(255, 255, 255, 255)}
+		mocksNetwork.EXPECT().TeardownPodNetwork(addr, nil, deviceNumber, gomock.Any()).Return(nil)
+	}
+	hostVethName := networkutils.GeneratePodHostVethName("eni", "default", "sample-pod")
+	mocksNetwork.EXPECT().TeardownHostVeth(hostVethName, gomock.Any()).Return(nil)
+
+	err := gc(cmdArgs, mocksGRPC, mocksRPC, mocksNetwork)
+	assert.Nil(t, err)
+}
+
+func TestCmdGCErrGCNetwork(t *testing.T) {
+	ctrl, _, mocksGRPC, mocksRPC, mocksNetwork := setup(t)
+	defer ctrl.Finish()
+
+	cmdArgs := &skel.CmdArgs{StdinData: gcNetConf(t, nil)}
+
+	conn, _ := grpc.Dial(ipamdAddress, grpc.WithInsecure())
+
+	mocksGRPC.EXPECT().Dial(gomock.Any(), gomock.Any()).Return(conn, nil)
+	mockC := mock_rpc.NewMockCNIBackendClient(ctrl)
+	mocksRPC.EXPECT().NewCNIBackendClient(conn).Return(mockC)
+
+	mockC.EXPECT().GCNetwork(gomock.Any(), gomock.Any()).Return(&rpc.GCNetworkReply{Success: false}, errors.New("error on GCNetwork"))
+
+	err := gc(cmdArgs, mocksGRPC, mocksRPC, mocksNetwork)
+	assert.EqualError(t, err, "gc cmd: Error received from GCNetwork gRPC call: error on GCNetwork")
+}
+
+func TestCmdStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		statusReply *rpc.StatusReply
+		statusErr   error
+		wantErr     error
+	}{
+		{
+			name:        "ipamd is ready",
+			statusReply: &rpc.StatusReply{Ready: true},
+		},
+		{
+			name:        "datastore is not initialized",
+			statusReply: &rpc.StatusReply{Ready: false, Reason: "datastore has no ENIs"},
+			wantErr:     types.NewError(cniutils.ErrPluginNotAvailable, "ipamd is not ready", "datastore has no ENIs"),
+		},
+		{
+			name:      "gRPC server is not serving",
+			statusErr: errors.New("connection refused"),
+			wantErr:   types.NewError(cniutils.ErrPluginNotAvailable, "ipamd is not reachable", "connection refused"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, _, mocksGRPC, mocksRPC, _ := setup(t)
+			defer ctrl.Finish()
+
+			cmdArgs := &skel.CmdArgs{StdinData: gcNetConf(t, nil)}
+
+			conn, _ := grpc.Dial(ipamdAddress, grpc.WithInsecure())
+
+			mocksGRPC.EXPECT().Dial(gomock.Any(), gomock.Any()).Return(conn, nil)
+			mockC := mock_rpc.NewMockCNIBackendClient(ctrl)
+			mocksRPC.EXPECT().NewCNIBackendClient(conn).Return(mockC)
+			mockC.EXPECT().Status(gomock.Any(), gomock.Any()).Return(tt.statusReply, tt.statusErr)
+
+			err := status(cmdArgs, mocksGRPC, mocksRPC)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func Test_tryDelWithPrevResult(t *testing.T) {
 	type teardownBranchENIPodNetworkCall struct {
 		containerAddr      *net.IPNet
@@ -825,9 +1231,10 @@ func Test_tryDelWithPrevResult(t *testing.T) {
 
 func Test_teardownPodNetworkWithPrevResult(t *testing.T) {
 	type teardownPodNetworkCall struct {
-		containerAddr *net.IPNet
-		deviceNumber  int
-		err           error
+		containerAddr   *net.IPNet
+		containerV6Addr *net.IPNet
+		deviceNumber    int
+		err             error
 	}
 	type fields struct {
 		teardownPodNetworkCalls []teardownPodNetworkCall
@@ -1118,7 +1525,7 @@ func Test_teardownPodNetworkWithPrevResult(t *testing.T) {
 
 			driverClient := mock_driver.NewMockNetworkAPIs(ctrl)
 			for _, call := range tt.fields.teardownPodNetworkCalls {
-				driverClient.EXPECT().TeardownPodNetwork(call.containerAddr, call.deviceNumber, gomock.Any()).Return(call.err)
+				driverClient.EXPECT().TeardownPodNetwork(call.containerAddr, call.containerV6Addr, call.deviceNumber, gomock.Any()).Return(call.err)
 			}
 
 			handled := teardownPodNetworkWithPrevResult(driverClient, tt.args.conf, tt.args.k8sArgs, tt.args.contVethName, testLogger)
@@ -50,13 +50,22 @@ type NetworkAPIs interface {
 	// SetupPodNetwork sets up pod network for normal ENI based pods
 	SetupPodNetwork(hostVethName string, contVethName string, netnsPath string, v4Addr *net.IPNet, v6Addr *net.IPNet, deviceNumber int, mtu int, log logger.Logger) error
 	// TeardownPodNetwork clean up pod network for normal ENI based pods
-	TeardownPodNetwork(containerAddr *net.IPNet, deviceNumber int, log logger.Logger) error
+	TeardownPodNetwork(v4Addr *net.IPNet, v6Addr *net.IPNet, deviceNumber int, log logger.Logger) error
 
 	// SetupBranchENIPodNetwork sets up pod network for branch ENI based pods
 	SetupBranchENIPodNetwork(hostVethName string, contVethName string, netnsPath string, v4Addr *net.IPNet, v6Addr *net.IPNet, vlanID int, eniMAC string,
 		subnetGW string, parentIfIndex int, mtu int, podSGEnforcingMode sgpp.EnforcingMode, log logger.Logger) error
 	// TeardownBranchENIPodNetwork cleans up pod network for branch ENI based pods
 	TeardownBranchENIPodNetwork(containerAddr *net.IPNet, vlanID int, podSGEnforcingMode sgpp.EnforcingMode, log logger.Logger) error
+
+	// CheckPodNetwork verifies that the pod network for normal ENI based pods is still in place
+	CheckPodNetwork(hostVethName string, contVethName string, netnsPath string, v4Addr *net.IPNet, v6Addr *net.IPNet, deviceNumber int, log logger.Logger) error
+	// CheckBranchENIPodNetwork verifies that the pod network for branch ENI based pods is still in place
+	CheckBranchENIPodNetwork(hostVethName string, contVethName string, netnsPath string, containerAddr *net.IPNet, vlanID int,
+		podSGEnforcingMode sgpp.EnforcingMode, log logger.Logger) error
+
+	// TeardownHostVeth deletes a leftover host-side veth of a pod whose IP address has been garbage collected
+	TeardownHostVeth(hostVethName string, log logger.Logger) error
 }
 
 type linuxNetwork struct {
@@ -153,6 +162,33 @@ func (createVethContext *createVethPairContext) run(hostNS ns.NetNS) error {
 		}
 	}
 
+	// In dual-stack mode, the container gets an address, a gateway and a default route for each family
+	for _, containerAddr := range []*net.IPNet{createVethContext.v4Addr, createVethContext.v6Addr} {
+		if containerAddr == nil {
+			continue
+		}
+		if err = createVethContext.setupContainerAddr(hostVeth, contVeth, containerAddr); err != nil {
+			return err
+		}
+	}
+
+	if createVethContext.v6Addr != nil && createVethContext.v6Addr.IP.To16() != nil {
+		if err := cniutils.WaitForAddressesToBeStable(createVethContext.netLink, createVethContext.contVethName, v6DADTimeout, WAIT_INTERVAL); err != nil {
+			return errors.Wrap(err, "setup NS network: failed while waiting for v6 addresses to be stable")
+		}
+	}
+
+	// Now that the everything has been successfully set up in the container, move the "host" end of the
+	// veth into the host namespace.
+	if err = createVethContext.netLink.LinkSetNsFd(hostVeth, int(hostNS.Fd())); err != nil {
+		return errors.Wrap(err, "setup NS network: failed to move veth to host netns")
+	}
+	return nil
+}
+
+// setupContainerAddr adds containerAddr to the container veth, together with a default route via a dummy next hop
+// of the same address family and a static ARP entry for it
+func (createVethContext *createVethPairContext) setupContainerAddr(hostVeth netlink.Link, contVeth netlink.Link, containerAddr *net.IPNet) error {
 	// Add a connected route to a dummy next hop (169.254.1.1 or fe80::1)
 	// # ip route show
 	// default via 169.254.1.1 dev eth0
@@ -160,24 +196,21 @@ func (createVethContext *createVethPairContext) run(hostNS ns.NetNS) error {
 
 	var gw net.IP
 	var maskLen int
-	var addr *netlink.Addr
 	var defNet *net.IPNet
 
-	if createVethContext.v4Addr != nil {
+	if containerAddr.IP.To4() != nil {
 		gw = net.IPv4(169, 254, 1, 1)
 		maskLen = 32
-		addr = &netlink.Addr{IPNet: createVethContext.v4Addr}
 		defNet = &net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, maskLen)}
-	} else if createVethContext.v6Addr != nil {
+	} else {
 		gw = net.IP{0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
 		maskLen = 128
-		addr = &netlink.Addr{IPNet: createVethContext.v6Addr}
 		defNet = &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, maskLen)}
 	}
 
 	gwNet := &net.IPNet{IP: gw, Mask: net.CIDRMask(maskLen, maskLen)}
 
-	if err = createVethContext.netLink.RouteReplace(&netlink.Route{
+	if err := createVethContext.netLink.RouteReplace(&netlink.Route{
 		LinkIndex: contVeth.Attrs().Index,
 		Scope:     netlink.SCOPE_LINK,
 		Dst:       gwNet}); err != nil {
@@ -186,7 +219,7 @@ func (createVethContext *createVethPairContext) run(hostNS ns.NetNS) error {
 
 	// Add a default route via dummy next hop(169.254.1.1 or fe80::1). Then all outgoing traffic will be routed by this
 	// default route via dummy next hop (169.254.1.1 or fe80::1)
-	if err = createVethContext.netLink.RouteAdd(&netlink.Route{
+	if err := createVethContext.netLink.RouteAdd(&netlink.Route{
 		LinkIndex: contVeth.Attrs().Index,
 		Scope:     netlink.SCOPE_UNIVERSE,
 		Dst:       defNet,
@@ -195,7 +228,7 @@ func (createVethContext *createVethPairContext) run(hostNS ns.NetNS) error {
 		return errors.Wrap(err, "setup NS network: failed to add default route")
 	}
 
-	if err = createVethContext.netLink.AddrAdd(contVeth, addr); err != nil {
+	if err := createVethContext.netLink.AddrAdd(contVeth, &netlink.Addr{IPNet: containerAddr}); err != nil {
 		return errors.Wrapf(err, "setup NS network: failed to add IP addr to %q", createVethContext.contVethName)
 	}
 
@@ -209,22 +242,37 @@ func (createVethContext *createVethPairContext) run(hostNS ns.NetNS) error {
 		HardwareAddr: hostVeth.Attrs().HardwareAddr,
 	}
 
-	if err = createVethContext.netLink.NeighAdd(neigh); err != nil {
+	if err := createVethContext.netLink.NeighAdd(neigh); err != nil {
 		return errors.Wrap(err, "setup NS network: failed to add static ARP")
 	}
+	return nil
+}
 
-	if createVethContext.v6Addr != nil && createVethContext.v6Addr.IP.To16() != nil {
-		if err := cniutils.WaitForAddressesToBeStable(createVethContext.netLink, createVethContext.contVethName, v6DADTimeout, WAIT_INTERVAL); err != nil {
-			return errors.Wrap(err, "setup NS network: failed while waiting for v6 addresses to be stable")
-		}
-	}
+// containerAddrRoute pairs a pod address with the route table that traffic from it is routed through
+type containerAddrRoute struct {
+	addr    *net.IPNet
+	rtTable int
+}
 
-	// Now that the everything has been successfully set up in the container, move the "host" end of the
-	// veth into the host namespace.
-	if err = createVethContext.netLink.LinkSetNsFd(hostVeth, int(hostNS.Fd())); err != nil {
-		return errors.Wrap(err, "setup NS network: failed to move veth to host netns")
+// buildContainerAddrRoutes returns the route tables for the IPv4 address, then the IPv6 address of a pod. In dual-stack
+// mode, the IPv6 address always comes from the primary ENI prefix, so it uses the main route table whatever ENI the
+// IPv4 address belongs to.
+func buildContainerAddrRoutes(v4Addr *net.IPNet, v6Addr *net.IPNet, deviceNumber int) []containerAddrRoute {
+	rtTable := unix.RT_TABLE_MAIN
+	if deviceNumber > 0 {
+		rtTable = deviceNumber + 1
 	}
-	return nil
+	var routes []containerAddrRoute
+	if v4Addr != nil {
+		routes = append(routes, containerAddrRoute{addr: v4Addr, rtTable: rtTable})
+	}
+	if v6Addr != nil {
+		if v4Addr != nil {
+			rtTable = unix.RT_TABLE_MAIN
+		}
+		routes = append(routes, containerAddrRoute{addr: v6Addr, rtTable: rtTable})
+	}
+	return routes
 }
 
 // SetupPodNetwork wires up linux networking for a pod's network
@@ -239,33 +287,22 @@ func (n *linuxNetwork) SetupPodNetwork(hostVethName string, contVethName string,
 		return errors.Wrapf(err, "SetupPodNetwork: failed to setup veth pair")
 	}
 
-	var containerAddr *net.IPNet
-	if v4Addr != nil {
-		containerAddr = v4Addr
-	} else if v6Addr != nil {
-		containerAddr = v6Addr
-	}
-
-	rtTable := unix.RT_TABLE_MAIN
-	if deviceNumber > 0 {
-		rtTable = deviceNumber + 1
-	}
-	if err := n.setupIPBasedContainerRouteRules(hostVeth, containerAddr, rtTable, log); err != nil {
-		return errors.Wrapf(err, "SetupPodNetwork: unable to setup IP based container routes and rules")
+	for _, route := range buildContainerAddrRoutes(v4Addr, v6Addr, deviceNumber) {
+		if err := n.setupIPBasedContainerRouteRules(hostVeth, route.addr, route.rtTable, log); err != nil {
+			return errors.Wrapf(err, "SetupPodNetwork: unable to setup IP based container routes and rules")
+		}
 	}
 	return nil
 }
 
 // TeardownPodNetwork cleanup ip rules
-func (n *linuxNetwork) TeardownPodNetwork(containerAddr *net.IPNet, deviceNumber int, log logger.Logger) error {
-	log.Debugf("TeardownPodNetwork: containerAddr=%s, deviceNumber=%d", containerAddr.String(), deviceNumber)
+func (n *linuxNetwork) TeardownPodNetwork(v4Addr *net.IPNet, v6Addr *net.IPNet, deviceNumber int, log logger.Logger) error {
+	log.Debugf("TeardownPodNetwork: v4Addr=%v, v6Addr=%v, deviceNumber=%d", v4Addr, v6Addr, deviceNumber)
 
-	rtTable := unix.RT_TABLE_MAIN
-	if deviceNumber > 0 {
-		rtTable = deviceNumber + 1
-	}
-	if err := n.teardownIPBasedContainerRouteRules(containerAddr, rtTable, log); err != nil {
-		return errors.Wrapf(err, "TeardownPodNetwork: unable to teardown IP based container routes and rules")
+	for _, route := range buildContainerAddrRoutes(v4Addr, v6Addr, deviceNumber) {
+		if err := n.teardownIPBasedContainerRouteRules(route.addr, route.rtTable, log); err != nil {
+			return errors.Wrapf(err, "TeardownPodNetwork: unable to teardown IP based container routes and rules")
+		}
 	}
 	return nil
 }
@@ -347,6 +384,174 @@ func (n *linuxNetwork) TeardownBranchENIPodNetwork(containerAddr *net.IPNet, vla
 	return nil
 }
 
+// CheckPodNetwork verifies the host veth, the container address and the IP based route rules set up by SetupPodNetwork
+func (n *linuxNetwork) CheckPodNetwork(hostVethName string, contVethName string, netnsPath string, v4Addr *net.IPNet, v6Addr *net.IPNet,
+	deviceNumber int, log logger.Logger) error {
+	log.Debugf("CheckPodNetwork: hostVethName=%s, contVethName=%s, netnsPath=%s, v4Addr=%v, v6Addr=%v, deviceNumber=%d",
+		hostVethName, contVethName, netnsPath, v4Addr, v6Addr, deviceNumber)
+
+	if _, err := n.checkHostVeth(hostVethName); err != nil {
+		return errors.Wrap(err, "CheckPodNetwork")
+	}
+	for _, route := range buildContainerAddrRoutes(v4Addr, v6Addr, deviceNumber) {
+		if err := n.checkContainerAddr(contVethName, netnsPath, route.addr); err != nil {
+			return errors.Wrap(err, "CheckPodNetwork")
+		}
+		if err := n.checkIPBasedContainerRouteRules(route.addr, route.rtTable, log); err != nil {
+			return errors.Wrap(err, "CheckPodNetwork")
+		}
+	}
+	return nil
+}
+
+// CheckBranchENIPodNetwork verifies the host veth, the vlan link, the container address and the route rules set up by SetupBranchENIPodNetwork
+func (n *linuxNetwork) CheckBranchENIPodNetwork(hostVethName string, contVethName string, netnsPath string, containerAddr *net.IPNet,
+	vlanID int, podSGEnforcingMode sgpp.EnforcingMode, log logger.Logger) error {
+	log.Debugf("CheckBranchENIPodNetwork: hostVethName=%s, contVethName=%s, netnsPath=%s, containerAddr=%s, vlanID=%d, podSGEnforcingMode=%v",
+		hostVethName, contVethName, netnsPath, containerAddr.String(), vlanID, podSGEnforcingMode)
+
+	if _, err := n.checkHostVeth(hostVethName); err != nil {
+		return errors.Wrap(err, "CheckBranchENIPodNetwork")
+	}
+	vlanLinkName := buildVlanLinkName(vlanID)
+	if _, err := n.netLink.LinkByName(vlanLinkName); err != nil {
+		return errors.Wrapf(err, "CheckBranchENIPodNetwork: failed to find vlan link %s", vlanLinkName)
+	}
+	if err := n.checkContainerAddr(contVethName, netnsPath, containerAddr); err != nil {
+		return errors.Wrap(err, "CheckBranchENIPodNetwork")
+	}
+
+	rtTable := vlanID + 100
+	switch podSGEnforcingMode {
+	case sgpp.EnforcingModeStrict:
+		if err := n.checkIIFBasedContainerRouteRules(hostVethName, vlanLinkName, containerAddr, rtTable, log); err != nil {
+			return errors.Wrap(err, "CheckBranchENIPodNetwork")
+		}
+	case sgpp.EnforcingModeStandard:
+		if err := n.checkIPBasedContainerRouteRules(containerAddr, rtTable, log); err != nil {
+			return errors.Wrap(err, "CheckBranchENIPodNetwork")
+		}
+	}
+	return nil
+}
+
+// TeardownHostVeth deletes the host side of the veth pair, if it still exists.
+func (n *linuxNetwork) TeardownHostVeth(hostVethName string, log logger.Logger) error {
+	hostVeth, err := n.netLink.LinkByName(hostVethName)
+	if err != nil {
+		if cniutils.IsLinkNotFoundError(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "TeardownHostVeth: failed to find hostVeth %s", hostVethName)
+	}
+	if err := n.netLink.LinkDel(hostVeth); err != nil && !cniutils.IsLinkNotFoundError(err) {
+		return errors.Wrapf(err, "TeardownHostVeth: failed to delete hostVeth %s", hostVethName)
+	}
+	log.Debugf("Successfully deleted hostVeth %s", hostVethName)
+	return nil
+}
+
+// checkHostVeth verifies that the host side of the veth pair exists and is up.
+func (n *linuxNetwork) checkHostVeth(hostVethName string) (netlink.Link, error) {
+	hostVeth, err := n.netLink.LinkByName(hostVethName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find hostVeth %s", hostVethName)
+	}
+	if hostVeth.Attrs().Flags&net.FlagUp == 0 {
+		return nil, errors.Errorf("hostVeth %s is not up", hostVethName)
+	}
+	return hostVeth, nil
+}
+
+// checkContainerAddr verifies that the container side of the veth pair still carries containerAddr.
+func (n *linuxNetwork) checkContainerAddr(contVethName string, netnsPath string, containerAddr *net.IPNet) error {
+	family := netlink.FAMILY_V4
+	if containerAddr.IP.To4() == nil {
+		family = netlink.FAMILY_V6
+	}
+	return n.ns.WithNetNSPath(netnsPath, func(ns.NetNS) error {
+		contVeth, err := n.netLink.LinkByName(contVethName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find link %q in container", contVethName)
+		}
+		addrs, err := n.netLink.AddrList(contVeth, family)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list addresses of %q in container", contVethName)
+		}
+		for _, addr := range addrs {
+			if addr.IPNet != nil && addr.IPNet.IP.Equal(containerAddr.IP) {
+				return nil
+			}
+		}
+		return errors.Errorf("container address %s not found on %q", containerAddr.String(), contVethName)
+	})
+}
+
+// checkIPBasedContainerRouteRules verifies the rules installed by setupIPBasedContainerRouteRules.
+func (n *linuxNetwork) checkIPBasedContainerRouteRules(containerAddr *net.IPNet, rtTable int, log logger.Logger) error {
+	family := unix.AF_INET
+	if containerAddr.IP.To4() == nil {
+		family = unix.AF_INET6
+	}
+	rules, err := n.netLink.RuleList(family)
+	if err != nil {
+		return errors.Wrap(err, "failed to list rules")
+	}
+
+	foundToContainer, foundFromContainer := false, rtTable == unix.RT_TABLE_MAIN
+	for _, rule := range rules {
+		if rule.Priority == networkutils.ToContainerRulePriority && rule.Table == unix.RT_TABLE_MAIN &&
+			rule.Dst != nil && rule.Dst.String() == containerAddr.String() {
+			foundToContainer = true
+		}
+		if rule.Priority == networkutils.FromPodRulePriority && rule.Table == rtTable &&
+			rule.Src != nil && rule.Src.String() == containerAddr.String() {
+			foundFromContainer = true
+		}
+	}
+	if !foundToContainer {
+		return errors.Errorf("toContainer rule not found, containerAddr=%s, rtTable=%v", containerAddr.String(), "main")
+	}
+	if !foundFromContainer {
+		return errors.Errorf("fromContainer rule not found, containerAddr=%s, rtTable=%v", containerAddr.String(), rtTable)
+	}
+	log.Debugf("Found toContainer and fromContainer rules, containerAddr=%s, rtTable=%v", containerAddr.String(), rtTable)
+	return nil
+}
+
+// checkIIFBasedContainerRouteRules verifies the rules installed by setupIIFBasedContainerRouteRules.
+func (n *linuxNetwork) checkIIFBasedContainerRouteRules(hostVethName string, vlanLinkName string, containerAddr *net.IPNet, rtTable int, log logger.Logger) error {
+	family := unix.AF_INET
+	if containerAddr.IP.To4() == nil {
+		family = unix.AF_INET6
+	}
+	rules, err := n.netLink.RuleList(family)
+	if err != nil {
+		return errors.Wrap(err, "failed to list rules")
+	}
+
+	foundFromHostVeth, foundFromHostVlan := false, false
+	for _, rule := range rules {
+		if rule.Priority != networkutils.VlanRulePriority || rule.Table != rtTable {
+			continue
+		}
+		switch rule.IifName {
+		case hostVethName:
+			foundFromHostVeth = true
+		case vlanLinkName:
+			foundFromHostVlan = true
+		}
+	}
+	if !foundFromHostVeth {
+		return errors.Errorf("fromHostVeth rule not found, hostVeth=%s, rtTable=%v", hostVethName, rtTable)
+	}
+	if !foundFromHostVlan {
+		return errors.Errorf("fromHostVlan rule not found, hostVlan=%s, rtTable=%v", vlanLinkName, rtTable)
+	}
+	log.Debugf("Found fromHostVeth and fromHostVlan rules, rtTable=%v", rtTable)
+	return nil
+}
+
 // setupVeth sets up veth for the pod.
 func (n *linuxNetwork) setupVeth(hostVethName string, contVethName string, netnsPath string, v4Addr *net.IPNet, v6Addr *net.IPNet, mtu int, log logger.Logger) (netlink.Link, error) {
 	// Clean up if hostVeth exists.
@@ -26,6 +26,7 @@ import (
 	mock_procsyswrapper "github.com/aws/amazon-vpc-cni-k8s/pkg/procsyswrapper/mocks"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/sgpp"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/logger"
+	cnins "github.com/containernetworking/plugins/pkg/ns"
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -62,6 +63,15 @@ func Test_linuxNetwork_SetupPodNetwork(t *testing.T) {
 	fromContainerRuleForRTTable4.Priority = networkutils.FromPodRulePriority
 	fromContainerRuleForRTTable4.Table = 4
 
+	containerV6Addr := &net.IPNet{
+		IP:   net.ParseIP("2001:db8::42"),
+		Mask: net.CIDRMask(128, 128),
+	}
+	toContainerV6Rule := netlink.NewRule()
+	toContainerV6Rule.Dst = containerV6Addr
+	toContainerV6Rule.Priority = networkutils.ToContainerRulePriority
+	toContainerV6Rule.Table = unix.RT_TABLE_MAIN
+
 	type linkByNameCall struct {
 		linkName string
 		link     netlink.Link
@@ -246,6 +256,83 @@ func Test_linuxNetwork_SetupPodNetwork(t *testing.T) {
 				mtu:          9001,
 			},
 		},
+		{
+			name: "successfully setup dual-stack pod network - IPv4 address sponsored by eth3",
+			fields: fields{
+				linkByNameCalls: []linkByNameCall{
+					{
+						linkName: "eni8ea2c11fe35",
+						err:      errors.New("not exists"),
+					},
+					{
+						linkName: "eni8ea2c11fe35",
+						link:     hostVethWithIndex9,
+					},
+				},
+				linkSetupCalls: []linkSetupCall{
+					{
+						link: hostVethWithIndex9,
+					},
+				},
+				routeReplaceCalls: []routeReplaceCall{
+					{
+						route: &netlink.Route{
+							LinkIndex: hostVethWithIndex9.Index,
+							Scope:     netlink.SCOPE_LINK,
+							Dst:       containerAddr,
+							Table:     unix.RT_TABLE_MAIN,
+						},
+					},
+					{
+						route: &netlink.Route{
+							LinkIndex: hostVethWithIndex9.Index,
+							Scope:     netlink.SCOPE_LINK,
+							Dst:       containerV6Addr,
+							Table:     unix.RT_TABLE_MAIN,
+						},
+					},
+				},
+				ruleAddCalls: []ruleAddCall{
+					{
+						rule: toContainerRule,
+					},
+					{
+						rule: fromContainerRuleForRTTable4,
+					},
+					{
+						rule: toContainerV6Rule,
+					},
+				},
+				withNetNSPathCalls: []withNetNSPathCall{
+					{
+						netNSPath: "/proc/42/ns/net",
+					},
+				},
+				procSysSetCalls: []procSysSetCall{
+					{
+						key:   "net/ipv6/conf/eni8ea2c11fe35/accept_ra",
+						value: "0",
+					},
+					{
+						key:   "net/ipv6/conf/eni8ea2c11fe35/accept_redirects",
+						value: "1",
+					},
+					{
+						key:   "net/ipv6/conf/eni8ea2c11fe35/forwarding",
+						value: "0",
+					},
+				},
+			},
+			args: args{
+				hostVethName: "eni8ea2c11fe35",
+				contVethName: "eth0",
+				netnsPath:    "/proc/42/ns/net",
+				v4Addr:       containerAddr,
+				v6Addr:       containerV6Addr,
+				deviceNumber: 3,
+				mtu:          9001,
+			},
+		},
 		{
 			name: "failed to setup vethPair",
 			fields: fields{
@@ -400,93 +487,504 @@ func Test_linuxNetwork_TeardownPodNetwork(t *testing.T) {
 	toContainerRule.Priority = networkutils.ToContainerRulePriority
 	toContainerRule.Table = unix.RT_TABLE_MAIN
 
-	fromContainerRuleForRTTable4 := netlink.NewRule()
-	fromContainerRuleForRTTable4.Src = containerAddr
-	fromContainerRuleForRTTable4.Priority = networkutils.FromPodRulePriority
-	fromContainerRuleForRTTable4.Table = 4
-	type routeDelCall struct {
-		route *netlink.Route
-		err   error
+	fromContainerRuleForRTTable4 := netlink.NewRule()
+	fromContainerRuleForRTTable4.Src = containerAddr
+	fromContainerRuleForRTTable4.Priority = networkutils.FromPodRulePriority
+	fromContainerRuleForRTTable4.Table = 4
+
+	containerV6Addr := &net.IPNet{
+		IP:   net.ParseIP("2001:db8::42"),
+		Mask: net.CIDRMask(128, 128),
+	}
+	toContainerV6Route := &netlink.Route{
+		Scope: netlink.SCOPE_LINK,
+		Dst:   containerV6Addr,
+		Table: unix.RT_TABLE_MAIN,
+	}
+	toContainerV6Rule := netlink.NewRule()
+	toContainerV6Rule.Dst = containerV6Addr
+	toContainerV6Rule.Priority = networkutils.ToContainerRulePriority
+	toContainerV6Rule.Table = unix.RT_TABLE_MAIN
+	type routeDelCall struct {
+		route *netlink.Route
+		err   error
+	}
+	type ruleDelCall struct {
+		rule *netlink.Rule
+		err  error
+	}
+	type fields struct {
+		routeDelCalls []routeDelCall
+		ruleDelCalls  []ruleDelCall
+	}
+
+	type args struct {
+		containerAddr   *net.IPNet
+		containerV6Addr *net.IPNet
+		deviceNumber    int
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr error
+	}{
+		{
+			name: "successfully teardown pod network - pod sponsored by eth0",
+			fields: fields{
+				routeDelCalls: []routeDelCall{
+					{
+						route: toContainerRoute,
+					},
+				},
+				ruleDelCalls: []ruleDelCall{
+					{
+						rule: toContainerRule,
+					},
+				},
+			},
+			args: args{
+				containerAddr: containerAddr,
+				deviceNumber:  0,
+			},
+		},
+		{
+			name: "successfully teardown pod network - pod sponsored by eth3",
+			fields: fields{
+				routeDelCalls: []routeDelCall{
+					{
+						route: toContainerRoute,
+					},
+				},
+				ruleDelCalls: []ruleDelCall{
+					{
+						rule: toContainerRule,
+					},
+					{
+						rule: fromContainerRuleForRTTable4,
+					},
+					{
+						rule: fromContainerRuleForRTTable4,
+						err:  syscall.ENOENT,
+					},
+				},
+			},
+			args: args{
+				containerAddr: containerAddr,
+				deviceNumber:  3,
+			},
+		},
+		{
+			name: "successfully teardown dual-stack pod network - IPv4 address sponsored by eth3",
+			fields: fields{
+				routeDelCalls: []routeDelCall{
+					{
+						route: toContainerRoute,
+					},
+					{
+						route: toContainerV6Route,
+					},
+				},
+				ruleDelCalls: []ruleDelCall{
+					{
+						rule: toContainerRule,
+					},
+					{
+						rule: fromContainerRuleForRTTable4,
+					},
+					{
+						rule: fromContainerRuleForRTTable4,
+						err:  syscall.ENOENT,
+					},
+					{
+						rule: toContainerV6Rule,
+					},
+				},
+			},
+			args: args{
+				containerAddr:   containerAddr,
+				containerV6Addr: containerV6Addr,
+				deviceNumber:    3,
+			},
+		},
+		{
+			name: "failed to delete toContainer rule",
+			fields: fields{
+				ruleDelCalls: []ruleDelCall{
+					{
+						rule: toContainerRule,
+						err:  errors.New("some error"),
+					},
+				},
+			},
+			args: args{
+				containerAddr: containerAddr,
+				deviceNumber:  3,
+			},
+			wantErr: errors.New("TeardownPodNetwork: unable to teardown IP based container routes and rules: failed to delete toContainer rule, containerAddr=192.168.100.42/32, rtTable=main: some error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			netLink := mock_netlinkwrapper.NewMockNetLink(ctrl)
+			netLink.EXPECT().NewRule().DoAndReturn(func() *netlink.Rule { return netlink.NewRule() }).AnyTimes()
+			for _, call := range tt.fields.routeDelCalls {
+				netLink.EXPECT().RouteDel(call.route).Return(call.err)
+			}
+			for _, call := range tt.fields.ruleDelCalls {
+				netLink.EXPECT().RuleDel(call.rule).Return(call.err)
+			}
+
+			n := &linuxNetwork{
+				netLink: netLink,
+			}
+			err := n.TeardownPodNetwork(tt.args.containerAddr, tt.args.containerV6Addr, tt.args.deviceNumber, testLogger)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_linuxNetwork_CheckPodNetwork(t *testing.T) {
+	hostVethName := "eni00bcc08c834"
+	contVethName := "eth0"
+	netNS := "/proc/42/ns/net"
+	containerAddr := &net.IPNet{
+		IP:   net.ParseIP("192.168.100.42"),
+		Mask: net.CIDRMask(32, 32),
+	}
+
+	hostVeth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: hostVethName, Flags: net.FlagUp}}
+	downHostVeth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: hostVethName}}
+	contVeth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: contVethName, Flags: net.FlagUp}}
+
+	toContainerRule := netlink.NewRule()
+	toContainerRule.Dst = containerAddr
+	toContainerRule.Priority = networkutils.ToContainerRulePriority
+	toContainerRule.Table = unix.RT_TABLE_MAIN
+
+	fromContainerRuleForRTTable4 := netlink.NewRule()
+	fromContainerRuleForRTTable4.Src = containerAddr
+	fromContainerRuleForRTTable4.Priority = networkutils.FromPodRulePriority
+	fromContainerRuleForRTTable4.Table = 4
+
+	containerV6Addr := &net.IPNet{
+		IP:   net.ParseIP("2001:db8::42"),
+		Mask: net.CIDRMask(128, 128),
+	}
+	toContainerV6Rule := netlink.NewRule()
+	toContainerV6Rule.Dst = containerV6Addr
+	toContainerV6Rule.Priority = networkutils.ToContainerRulePriority
+	toContainerV6Rule.Table = unix.RT_TABLE_MAIN
+
+	type fields struct {
+		hostVeth    netlink.Link
+		hostVethErr error
+		addrs       []netlink.Addr
+		rules       []netlink.Rule
+		v6Addrs     []netlink.Addr
+		v6Rules     []netlink.Rule
+	}
+	type args struct {
+		containerV6Addr *net.IPNet
+		deviceNumber    int
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr error
+	}{
+		{
+			name: "pod network intact - pod sponsored by eth0",
+			fields: fields{
+				hostVeth: hostVeth,
+				addrs:    []netlink.Addr{{IPNet: containerAddr}},
+				rules:    []netlink.Rule{*toContainerRule},
+			},
+			args: args{deviceNumber: 0},
+		},
+		{
+			name: "pod network intact - pod sponsored by eth3",
+			fields: fields{
+				hostVeth: hostVeth,
+				addrs:    []netlink.Addr{{IPNet: containerAddr}},
+				rules:    []netlink.Rule{*toContainerRule, *fromContainerRuleForRTTable4},
+			},
+			args: args{deviceNumber: 3},
+		},
+		{
+			name: "dual-stack pod network intact - IPv4 address sponsored by eth3",
+			fields: fields{
+				hostVeth: hostVeth,
+				addrs:    []netlink.Addr{{IPNet: containerAddr}},
+				rules:    []netlink.Rule{*toContainerRule, *fromContainerRuleForRTTable4},
+				v6Addrs:  []netlink.Addr{{IPNet: containerV6Addr}},
+				v6Rules:  []netlink.Rule{*toContainerV6Rule},
+			},
+			args: args{containerV6Addr: containerV6Addr, deviceNumber: 3},
+		},
+		{
+			name: "dual-stack pod network missing IPv6 address",
+			fields: fields{
+				hostVeth: hostVeth,
+				addrs:    []netlink.Addr{{IPNet: containerAddr}},
+				rules:    []netlink.Rule{*toContainerRule, *fromContainerRuleForRTTable4},
+			},
+			args:    args{containerV6Addr: containerV6Addr, deviceNumber: 3},
+			wantErr: errors.New("CheckPodNetwork: container address 2001:db8::42/128 not found on \"eth0\""),
+		},
+		{
+			name: "host veth missing",
+			fields: fields{
+				hostVethErr: errors.New("Link not found"),
+			},
+			args:    args{deviceNumber: 0},
+			wantErr: errors.New("CheckPodNetwork: failed to find hostVeth eni00bcc08c834: Link not found"),
+		},
+		{
+			name: "host veth down",
+			fields: fields{
+				hostVeth: downHostVeth,
+			},
+			args:    args{deviceNumber: 0},
+			wantErr: errors.New("CheckPodNetwork: hostVeth eni00bcc08c834 is not up"),
+		},
+		{
+			name: "container address missing",
+			fields: fields{
+				hostVeth: hostVeth,
+			},
+			args:    args{deviceNumber: 0},
+			wantErr: errors.New("CheckPodNetwork: container address 192.168.100.42/32 not found on \"eth0\""),
+		},
+		{
+			name: "fromContainer rule missing",
+			fields: fields{
+				hostVeth: hostVeth,
+				addrs:    []netlink.Addr{{IPNet: containerAddr}},
+				rules:    []netlink.Rule{*toContainerRule},
+			},
+			args:    args{deviceNumber: 3},
+			wantErr: errors.New("CheckPodNetwork: fromContainer rule not found, containerAddr=192.168.100.42/32, rtTable=4"),
+		},
+		{
+			name: "toContainer rule missing",
+			fields: fields{
+				hostVeth: hostVeth,
+				addrs:    []netlink.Addr{{IPNet: containerAddr}},
+				rules:    []netlink.Rule{*fromContainerRuleForRTTable4},
+			},
+			args:    args{deviceNumber: 3},
+			wantErr: errors.New("CheckPodNetwork: toContainer rule not found, containerAddr=192.168.100.42/32, rtTable=main"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			netLink := mock_netlinkwrapper.NewMockNetLink(ctrl)
+			ns := mock_nswrapper.NewMockNS(ctrl)
+			netLink.EXPECT().LinkByName(hostVethName).Return(tt.fields.hostVeth, tt.fields.hostVethErr)
+			ns.EXPECT().WithNetNSPath(netNS, gomock.Any()).DoAndReturn(func(_ string, f func(cnins.NetNS) error) error {
+				return f(nil)
+			}).AnyTimes()
+			netLink.EXPECT().LinkByName(contVethName).Return(contVeth, nil).AnyTimes()
+			netLink.EXPECT().AddrList(contVeth, netlink.FAMILY_V4).Return(tt.fields.addrs, nil).AnyTimes()
+			netLink.EXPECT().RuleList(unix.AF_INET).Return(tt.fields.rules, nil).AnyTimes()
+			netLink.EXPECT().AddrList(contVeth, netlink.FAMILY_V6).Return(tt.fields.v6Addrs, nil).AnyTimes()
+			netLink.EXPECT().RuleList(unix.AF_INET6).Return(tt.fields.v6Rules, nil).AnyTimes()
+
+			n := &linuxNetwork{
+				netLink: netLink,
+				ns:      ns,
+			}
+			err := n.CheckPodNetwork(hostVethName, contVethName, netNS, containerAddr, tt.args.containerV6Addr, tt.args.deviceNumber, testLogger)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_linuxNetwork_CheckBranchENIPodNetwork(t *testing.T) {
+	vlanID := 7
+	hostVethName := "eni00bcc08c834"
+	contVethName := "eth0"
+	netNS := "/proc/42/ns/net"
+	containerAddr := &net.IPNet{
+		IP:   net.ParseIP("192.168.100.42"),
+		Mask: net.CIDRMask(32, 32),
+	}
+
+	hostVeth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: hostVethName, Flags: net.FlagUp}}
+	contVeth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: contVethName, Flags: net.FlagUp}}
+	vlanLink := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: "vlan.eth.7"}, VlanId: vlanID}
+
+	fromHostVlanRule := netlink.NewRule()
+	fromHostVlanRule.IifName = "vlan.eth.7"
+	fromHostVlanRule.Priority = networkutils.VlanRulePriority
+	fromHostVlanRule.Table = 107
+	fromHostVethRule := netlink.NewRule()
+	fromHostVethRule.IifName = hostVethName
+	fromHostVethRule.Priority = networkutils.VlanRulePriority
+	fromHostVethRule.Table = 107
+
+	toContainerRule := netlink.NewRule()
+	toContainerRule.Dst = containerAddr
+	toContainerRule.Priority = networkutils.ToContainerRulePriority
+	toContainerRule.Table = unix.RT_TABLE_MAIN
+	fromContainerRule := netlink.NewRule()
+	fromContainerRule.Src = containerAddr
+	fromContainerRule.Priority = networkutils.FromPodRulePriority
+	fromContainerRule.Table = 107
+
+	type fields struct {
+		vlanLinkErr error
+		rules       []netlink.Rule
+	}
+	tests := []struct {
+		name               string
+		fields             fields
+		podSGEnforcingMode sgpp.EnforcingMode
+		wantErr            error
+	}{
+		{
+			name: "pod network intact - strict mode",
+			fields: fields{
+				rules: []netlink.Rule{*fromHostVlanRule, *fromHostVethRule},
+			},
+			podSGEnforcingMode: sgpp.EnforcingModeStrict,
+		},
+		{
+			name: "pod network intact - standard mode",
+			fields: fields{
+				rules: []netlink.Rule{*toContainerRule, *fromContainerRule},
+			},
+			podSGEnforcingMode: sgpp.EnforcingModeStandard,
+		},
+		{
+			name: "vlan link missing",
+			fields: fields{
+				vlanLinkErr: errors.New("Link not found"),
+			},
+			podSGEnforcingMode: sgpp.EnforcingModeStrict,
+			wantErr:            errors.New("CheckBranchENIPodNetwork: failed to find vlan link vlan.eth.7: Link not found"),
+		},
+		{
+			name: "fromHostVlan rule missing - strict mode",
+			fields: fields{
+				rules: []netlink.Rule{*fromHostVethRule},
+			},
+			podSGEnforcingMode: sgpp.EnforcingModeStrict,
+			wantErr:            errors.New("CheckBranchENIPodNetwork: fromHostVlan rule not found, hostVlan=vlan.eth.7, rtTable=107"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			netLink := mock_netlinkwrapper.NewMockNetLink(ctrl)
+			ns := mock_nswrapper.NewMockNS(ctrl)
+			netLink.EXPECT().LinkByName(hostVethName).Return(hostVeth, nil)
+			netLink.EXPECT().LinkByName("vlan.eth.7").Return(vlanLink, tt.fields.vlanLinkErr)
+			ns.EXPECT().WithNetNSPath(netNS, gomock.Any()).DoAndReturn(func(_ string, f func(cnins.NetNS) error) error {
+				return f(nil)
+			}).AnyTimes()
+			netLink.EXPECT().LinkByName(contVethName).Return(contVeth, nil).AnyTimes()
+			netLink.EXPECT().AddrList(contVeth, netlink.FAMILY_V4).Return([]netlink.Addr{{IPNet: containerAddr}}, nil).AnyTimes()
+			netLink.EXPECT().RuleList(unix.AF_INET).Return(tt.fields.rules, nil).AnyTimes()
+
+			n := &linuxNetwork{
+				netLink: netLink,
+				ns:      ns,
+			}
+			err := n.CheckBranchENIPodNetwork(hostVethName, contVethName, netNS, containerAddr, vlanID, tt.podSGEnforcingMode, testLogger)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_linuxNetwork_TeardownHostVeth(t *testing.T) {
+	hostVeth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "eni8ea2c11fe35"}}
+
+	type linkByNameCall struct {
+		linkName string
+		link     netlink.Link
+		err      error
 	}
-	type ruleDelCall struct {
-		rule *netlink.Rule
+	type linkDelCall struct {
+		link netlink.Link
 		err  error
 	}
-	type fields struct {
-		routeDelCalls []routeDelCall
-		ruleDelCalls  []ruleDelCall
-	}
 
-	type args struct {
-		containerAddr *net.IPNet
-		deviceNumber  int
+	type fields struct {
+		linkByNameCalls []linkByNameCall
+		linkDelCalls    []linkDelCall
 	}
 	tests := []struct {
 		name    string
 		fields  fields
-		args    args
 		wantErr error
 	}{
 		{
-			name: "successfully teardown pod network - pod sponsored by eth0",
+			name: "successfully deleted host veth",
 			fields: fields{
-				routeDelCalls: []routeDelCall{
+				linkByNameCalls: []linkByNameCall{
 					{
-						route: toContainerRoute,
+						linkName: "eni8ea2c11fe35",
+						link:     hostVeth,
 					},
 				},
-				ruleDelCalls: []ruleDelCall{
+				linkDelCalls: []linkDelCall{
 					{
-						rule: toContainerRule,
+						link: hostVeth,
 					},
 				},
 			},
-			args: args{
-				containerAddr: containerAddr,
-				deviceNumber:  0,
-			},
 		},
 		{
-			name: "successfully teardown pod network - pod sponsored by eth3",
+			name: "host veth already gone",
 			fields: fields{
-				routeDelCalls: []routeDelCall{
-					{
-						route: toContainerRoute,
-					},
-				},
-				ruleDelCalls: []ruleDelCall{
-					{
-						rule: toContainerRule,
-					},
-					{
-						rule: fromContainerRuleForRTTable4,
-					},
+				linkByNameCalls: []linkByNameCall{
 					{
-						rule: fromContainerRuleForRTTable4,
-						err:  syscall.ENOENT,
+						linkName: "eni8ea2c11fe35",
+						err:      errors.New("Link not found"),
 					},
 				},
 			},
-			args: args{
-				containerAddr: containerAddr,
-				deviceNumber:  3,
-			},
 		},
 		{
-			name: "failed to delete toContainer rule",
+			name: "failed to delete host veth",
 			fields: fields{
-				ruleDelCalls: []ruleDelCall{
+				linkByNameCalls: []linkByNameCall{
 					{
-						rule: toContainerRule,
+						linkName: "eni8ea2c11fe35",
+						link:     hostVeth,
+					},
+				},
+				linkDelCalls: []linkDelCall{
+					{
+						link: hostVeth,
 						err:  errors.New("some error"),
 					},
 				},
 			},
-			args: args{
-				containerAddr: containerAddr,
-				deviceNumber:  3,
-			},
-			wantErr: errors.New("TeardownPodNetwork: unable to teardown IP based container routes and rules: failed to delete toContainer rule, containerAddr=192.168.100.42/32, rtTable=main: some error"),
+			wantErr: errors.New("TeardownHostVeth: failed to delete hostVeth eni8ea2c11fe35: some error"),
 		},
 	}
 	for _, tt := range tests {
@@ -495,18 +993,17 @@ func Test_linuxNetwork_TeardownPodNetwork(t *testing.T) {
 			defer ctrl.Finish()
 
 			netLink := mock_netlinkwrapper.NewMockNetLink(ctrl)
-			netLink.EXPECT().NewRule().DoAndReturn(func() *netlink.Rule { return netlink.NewRule() }).AnyTimes()
-			for _, call := range tt.fields.routeDelCalls {
-				netLink.EXPECT().RouteDel(call.route).Return(call.err)
+			for _, call := range tt.fields.linkByNameCalls {
+				netLink.EXPECT().LinkByName(call.linkName).Return(call.link, call.err)
 			}
-			for _, call := range tt.fields.ruleDelCalls {
-				netLink.EXPECT().RuleDel(call.rule).Return(call.err)
+			for _, call := range tt.fields.linkDelCalls {
+				netLink.EXPECT().LinkDel(call.link).Return(call.err)
 			}
 
 			n := &linuxNetwork{
 				netLink: netLink,
 			}
-			err := n.TeardownPodNetwork(tt.args.containerAddr, tt.args.deviceNumber, testLogger)
+			err := n.TeardownHostVeth("eni8ea2c11fe35", testLogger)
 			if tt.wantErr != nil {
 				assert.EqualError(t, err, tt.wantErr.Error())
 			} else {
@@ -2286,6 +2783,177 @@ func Test_createVethPairContext_run(t *testing.T) {
 				mtu: 9001,
 			},
 		},
+		{
+			name: "successfully created vethPair for dual-stack pods",
+			fields: fields{
+				linkByNameCalls: []linkByNameCall{
+					{
+						linkName: "eni8ea2c11fe35",
+						link:     hostVethWithIndex9,
+					},
+					{
+						linkName: "eth0",
+						link:     contVethWithIndex1,
+					},
+					{
+						linkName: "eth0",
+						link:     contVethWithIndex1,
+					},
+				},
+				linkAddCalls: []linkAddCall{
+					{
+						link: &netlink.Veth{
+							LinkAttrs: netlink.LinkAttrs{
+								Name:  "eth0",
+								Flags: net.FlagUp,
+								MTU:   9001,
+							},
+							PeerName: "eni8ea2c11fe35",
+						},
+					},
+				},
+				linkSetupCalls: []linkSetupCall{
+					{
+						link: hostVethWithIndex9,
+					},
+					{
+						link: contVethWithIndex1,
+					},
+				},
+				routeReplaceCalls: []routeReplaceCall{
+					{
+						route: &netlink.Route{
+							LinkIndex: contVethWithIndex1.Attrs().Index,
+							Scope:     netlink.SCOPE_LINK,
+							Dst: &net.IPNet{
+								IP:   net.IPv4(169, 254, 1, 1),
+								Mask: net.CIDRMask(32, 32),
+							},
+						},
+					},
+					{
+						route: &netlink.Route{
+							LinkIndex: contVethWithIndex1.Attrs().Index,
+							Scope:     netlink.SCOPE_LINK,
+							Dst: &net.IPNet{
+								IP:   net.IP{0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+								Mask: net.CIDRMask(128, 128),
+							},
+						},
+					},
+				},
+				routeAddCalls: []routeAddCall{
+					{
+						route: &netlink.Route{
+							LinkIndex: contVethWithIndex1.Attrs().Index,
+							Scope:     netlink.SCOPE_UNIVERSE,
+							Dst: &net.IPNet{
+								IP:   net.IPv4zero,
+								Mask: net.CIDRMask(0, 32),
+							},
+							Gw: net.IPv4(169, 254, 1, 1),
+						},
+					},
+					{
+						route: &netlink.Route{
+							LinkIndex: contVethWithIndex1.Attrs().Index,
+							Scope:     netlink.SCOPE_UNIVERSE,
+							Dst: &net.IPNet{
+								IP:   net.IPv6zero,
+								Mask: net.CIDRMask(0, 128),
+							},
+							Gw: net.IP{0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+						},
+					},
+				},
+				addrAddCalls: []addrAddCall{
+					{
+						link: contVethWithIndex1,
+						addr: &netlink.Addr{
+							IPNet: &net.IPNet{
+								IP:   net.ParseIP("192.168.120.1"),
+								Mask: net.CIDRMask(32, 32),
+							},
+						},
+					},
+					{
+						link: contVethWithIndex1,
+						addr: &netlink.Addr{
+							IPNet: &net.IPNet{
+								IP:   net.ParseIP("2001:db8:3333:4444:5555:6666:7777:8888"),
+								Mask: net.CIDRMask(128, 128),
+							},
+						},
+					},
+				},
+				addrListCalls: []addrListCall{
+					{
+						link:   contVethWithIndex1,
+						family: netlink.FAMILY_V6,
+						addrs: []netlink.Addr{
+							{
+								IPNet: &net.IPNet{
+									IP:   net.ParseIP("2001:db8:3333:4444:5555:6666:7777:8888"),
+									Mask: net.CIDRMask(128, 128),
+								},
+							},
+						},
+					},
+				},
+				neighAddCalls: []neighAddCall{
+					{
+						neigh: &netlink.Neigh{
+							LinkIndex:    contVethWithIndex1.Attrs().Index,
+							State:        netlink.NUD_PERMANENT,
+							IP:           net.IPv4(169, 254, 1, 1),
+							HardwareAddr: hostVethWithIndex9.Attrs().HardwareAddr,
+						},
+					},
+					{
+						neigh: &netlink.Neigh{
+							LinkIndex:    contVethWithIndex1.Attrs().Index,
+							State:        netlink.NUD_PERMANENT,
+							IP:           net.IP{0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+							HardwareAddr: hostVethWithIndex9.Attrs().HardwareAddr,
+						},
+					},
+				},
+				linkSetNsFdCalls: []linkSetNsFdCall{
+					{
+						link: hostVethWithIndex9,
+						fd:   3,
+					},
+				},
+				procSysSetCalls: []procSysSetCall{
+					{
+						key:   "net/ipv6/conf/eth0/disable_ipv6",
+						value: "0",
+					},
+					{
+						key:   "net/ipv6/conf/lo/disable_ipv6",
+						value: "0",
+					},
+				},
+				nsFDCalls: []nsFDCall{
+					{
+						fd: uintptr(3),
+					},
+				},
+			},
+			args: args{
+				contVethName: "eth0",
+				hostVethName: "eni8ea2c11fe35",
+				v4Addr: &net.IPNet{
+					IP:   net.ParseIP("192.168.120.1"),
+					Mask: net.CIDRMask(32, 32),
+				},
+				v6Addr: &net.IPNet{
+					IP:   net.ParseIP("2001:db8:3333:4444:5555:6666:7777:8888"),
+					Mask: net.CIDRMask(128, 128),
+				},
+				mtu: 9001,
+			},
+		},
 		{
 			name: "failed to add vethPair",
 			fields: fields{
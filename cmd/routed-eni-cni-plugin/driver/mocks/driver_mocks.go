@@ -50,6 +50,34 @@ func (m *MockNetworkAPIs) EXPECT() *MockNetworkAPIsMockRecorder {
 	return m.recorder
 }
 
+// CheckBranchENIPodNetwork mocks base method.
+func (m *MockNetworkAPIs) CheckBranchENIPodNetwork(arg0, arg1, arg2 string, arg3 *net.IPNet, arg4 int, arg5 sgpp.EnforcingMode, arg6 logger.Logger) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckBranchENIPodNetwork", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckBranchENIPodNetwork indicates an expected call of CheckBranchENIPodNetwork.
+func (mr *MockNetworkAPIsMockRecorder) CheckBranchENIPodNetwork(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckBranchENIPodNetwork", reflect.TypeOf((*MockNetworkAPIs)(nil).CheckBranchENIPodNetwork), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+}
+
+// CheckPodNetwork mocks base method.
+func (m *MockNetworkAPIs) CheckPodNetwork(arg0, arg1, arg2 string, arg3, arg4 *net.IPNet, arg5 int, arg6 logger.Logger) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckPodNetwork", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckPodNetwork indicates an expected call of CheckPodNetwork.
+func (mr *MockNetworkAPIsMockRecorder) CheckPodNetwork(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPodNetwork", reflect.TypeOf((*MockNetworkAPIs)(nil).CheckPodNetwork), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+}
+
 // SetupBranchENIPodNetwork mocks base method.
 func (m *MockNetworkAPIs) SetupBranchENIPodNetwork(arg0, arg1, arg2 string, arg3, arg4 *net.IPNet, arg5 int, arg6, arg7 string, arg8, arg9 int, arg10 sgpp.EnforcingMode, arg11 logger.Logger) error {
 	m.ctrl.T.Helper()
@@ -92,16 +120,30 @@ func (mr *MockNetworkAPIsMockRecorder) TeardownBranchENIPodNetwork(arg0, arg1, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TeardownBranchENIPodNetwork", reflect.TypeOf((*MockNetworkAPIs)(nil).TeardownBranchENIPodNetwork), arg0, arg1, arg2, arg3)
 }
 
+// TeardownHostVeth mocks base method.
+func (m *MockNetworkAPIs) TeardownHostVeth(arg0 string, arg1 logger.Logger) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TeardownHostVeth", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TeardownHostVeth indicates an expected call of TeardownHostVeth.
+func (mr *MockNetworkAPIsMockRecorder) TeardownHostVeth(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TeardownHostVeth", reflect.TypeOf((*MockNetworkAPIs)(nil).TeardownHostVeth), arg0, arg1)
+}
+
 // TeardownPodNetwork mocks base method.
-func (m *MockNetworkAPIs) TeardownPodNetwork(arg0 *net.IPNet, arg1 int, arg2 logger.Logger) error {
+func (m *MockNetworkAPIs) TeardownPodNetwork(arg0, arg1 *net.IPNet, arg2 int, arg3 logger.Logger) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TeardownPodNetwork", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "TeardownPodNetwork", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // TeardownPodNetwork indicates an expected call of TeardownPodNetwork.
-func (mr *MockNetworkAPIsMockRecorder) TeardownPodNetwork(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockNetworkAPIsMockRecorder) TeardownPodNetwork(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TeardownPodNetwork", reflect.TypeOf((*MockNetworkAPIs)(nil).TeardownPodNetwork), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TeardownPodNetwork", reflect.TypeOf((*MockNetworkAPIs)(nil).TeardownPodNetwork), arg0, arg1, arg2, arg3)
 }
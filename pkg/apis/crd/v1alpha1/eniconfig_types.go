@@ -27,15 +27,49 @@ import (
 type ENIConfigSpec struct {
 	SecurityGroups []string `json:"securityGroups"`
 	Subnet         string   `json:"subnet"`
+	// Subnets are more subnets in the availability zone of Subnet. ENIs are created in the first subnet, in order,
+	// that has free addresses left.
+	Subnets []string `json:"subnets,omitempty"`
+}
+
+// SubnetIDs returns Subnet followed by Subnets, without duplicates
+func (s ENIConfigSpec) SubnetIDs() []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range append([]string{s.Subnet}, s.Subnets...) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 // ENIConfigStatus defines the observed state of ENIConfig
 type ENIConfigStatus struct {
-	// Fill me
+	// SubnetResolved is true when the subnets of the spec exist in EC2, in the same availability zone
+	SubnetResolved bool `json:"subnetResolved"`
+	// SecurityGroupsResolved is true when all security groups of the spec exist in EC2
+	SecurityGroupsResolved bool `json:"securityGroupsResolved"`
+	// AvailabilityZone is the availability zone of the subnet
+	AvailabilityZone string `json:"availabilityZone,omitempty"`
+	// AvailableIPAddressCount is the number of free IPv4 addresses in the subnets
+	AvailableIPAddressCount int32 `json:"availableIPAddressCount"`
+	// NodeCount is the number of nodes that reference the ENIConfig by label or annotation
+	NodeCount int32 `json:"nodeCount"`
+	// LastError is the error of the last validation, empty when it succeeded
+	LastError string `json:"lastError,omitempty"`
+	// LastUpdateTime is when the status was last validated
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Subnet",type=string,JSONPath=`.spec.subnet`
+//+kubebuilder:printcolumn:name="AZ",type=string,JSONPath=`.status.availabilityZone`
+//+kubebuilder:printcolumn:name="Free IPs",type=integer,JSONPath=`.status.availableIPAddressCount`
+//+kubebuilder:printcolumn:name="Nodes",type=integer,JSONPath=`.status.nodeCount`
+//+kubebuilder:printcolumn:name="Error",type=string,JSONPath=`.status.lastError`
 
 // ENIConfig is the Schema for the eniconfigs API
 type ENIConfig struct {
@@ -28,8 +28,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
-
 	"github.com/aws/smithy-go"
 
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
@@ -97,7 +95,13 @@ var log = logger.Get()
 // APIs defines interfaces calls for adding/getting/deleting ENIs/secondary IPs. The APIs are not thread-safe.
 type APIs interface {
 	// AllocENI creates an ENI and attaches it to the instance
-	AllocENI(useCustomCfg bool, sg []*string, eniCfgSubnet string, numIPs int) (eni string, err error)
+	AllocENI(useCustomCfg bool, sg []*string, eniCfgSubnets []string, numIPs int) (eni string, err error)
+
+	// AllocENIWithTags creates an ENI with additional tags and attaches it to the instance
+	AllocENIWithTags(useCustomCfg bool, sg []*string, eniCfgSubnets []string, numIPs int, tags map[string]string) (eni string, err error)
+
+	// AllocENIOnNetworkCard creates an ENI with additional tags and attaches it to the given network card of the instance
+	AllocENIOnNetworkCard(useCustomCfg bool, sg []*string, eniCfgSubnets []string, numIPs int, tags map[string]string, networkCard int) (eni string, err error)
 
 	// FreeENI detaches ENI interface and deletes it
 	FreeENI(eniName string) error
@@ -108,24 +112,27 @@ type APIs interface {
 	// GetAttachedENIs retrieves eni information from instance metadata service
 	GetAttachedENIs() (eniList []ENIMetadata, err error)
 
-	// GetIPv4sFromEC2 returns the IPv4 addresses for a given ENI
-	GetIPv4sFromEC2(eniID string) (addrList []ec2types.NetworkInterfacePrivateIpAddress, err error)
-
-	// GetIPv4PrefixesFromEC2 returns the IPv4 prefixes for a given ENI
-	GetIPv4PrefixesFromEC2(eniID string) (addrList []ec2types.Ipv4PrefixSpecification, err error)
-
 	// GetIPv6PrefixesFromEC2 returns the IPv6 prefixes for a given ENI
 	GetIPv6PrefixesFromEC2(eniID string) (addrList []ec2types.Ipv6PrefixSpecification, err error)
 
 	// DescribeAllENIs calls EC2 and returns a fully populated DescribeAllENIsResult struct and an error
 	DescribeAllENIs() (DescribeAllENIsResult, error)
 
+	// DescribeENIAddresses returns the IPv4 addresses and prefixes of the given ENIs with a single EC2 call
+	DescribeENIAddresses(eniIDs []string) (map[string]ENIAddresses, error)
+
+	// DescribeENIConfigNetwork looks up the subnets and security groups of an ENIConfig
+	DescribeENIConfigNetwork(subnetIDs []string, securityGroups []string) (ENIConfigNetwork, error)
+
 	// AllocIPAddress allocates an IP address for an ENI
 	AllocIPAddress(eniID string) error
 
 	// AllocIPAddresses allocates numIPs IP addresses on a ENI
 	AllocIPAddresses(eniID string, numIPs int) (*ec2.AssignPrivateIpAddressesOutput, error)
 
+	// AllocSpecificIPAddress allocates the given IPv4 address, or the prefix holding it, on a ENI
+	AllocSpecificIPAddress(eniID string, ip net.IP) (*ec2.AssignPrivateIpAddressesOutput, error)
+
 	// DeallocIPAddresses deallocates the list of IP addresses from a ENI
 	DeallocIPAddresses(eniID string, ips []string) error
 
@@ -189,6 +196,12 @@ type APIs interface {
 	//Update cached prefix delegation flag
 	InitCachedPrefixDelegation(bool)
 
+	// InitCachedMultiCardENIs sets whether the network card of the ENIs is looked up, for ENABLE_MULTI_CARD_ENIS
+	InitCachedMultiCardENIs(bool)
+
+	// IsIMDSDegraded reports whether instance metadata is served from the IMDS cache because IMDS is not answering
+	IsIMDSDegraded() bool
+
 	// GetInstanceID returns the instance ID
 	GetInstanceID() string
 
@@ -196,6 +209,12 @@ type APIs interface {
 	FetchInstanceTypeLimits() error
 
 	IsPrefixDelegationSupported() bool
+
+	// GetSubnetDiscoveryState returns the last subnet chosen by subnet discovery and the subnets it skips
+	GetSubnetDiscoveryState() SubnetDiscoveryState
+
+	// GetLeakedENIReport returns the report of the last leaked ENI cleanup
+	GetLeakedENIReport() *LeakedENIReport
 }
 
 // EC2InstanceMetadataCache caches instance metadata
@@ -218,7 +237,10 @@ type EC2InstanceMetadataCache struct {
 	useCustomNetworking    bool
 	multiCardENIs          StringSet
 	useSubnetDiscovery     bool
+	subnetDiscovery        subnetDiscovery
 	enablePrefixDelegation bool
+	enableMultiCardENIs    bool
+	leakedENICleanup       *leakedENICleanup
 
 	clusterName       string
 	additionalENITags map[string]string
@@ -238,6 +260,9 @@ type ENIMetadata struct {
 	// DeviceNumber is the  device number of network interface
 	DeviceNumber int // 0 means it is primary interface
 
+	// NetworkCard is the index of the network card the network interface is attached to
+	NetworkCard int
+
 	// SubnetIPv4CIDR is the IPv4 CIDR of network interface
 	SubnetIPv4CIDR string
 
@@ -289,6 +314,24 @@ type DescribeAllENIsResult struct {
 	MultiCardENIIDs []string
 }
 
+// ENIAddresses contains the IPv4 addresses and prefixes that EC2 reports for an ENI
+type ENIAddresses struct {
+	IPv4Addresses []ec2types.NetworkInterfacePrivateIpAddress
+	IPv4Prefixes  []ec2types.Ipv4PrefixSpecification
+}
+
+// ENIConfigNetwork contains what EC2 reports about the subnets and security groups of an ENIConfig
+type ENIConfigNetwork struct {
+	// SubnetFound is true when all subnets are in the VPC and in the availability zone of the first subnet
+	SubnetFound      bool
+	AvailabilityZone string
+	// AvailableIPAddressCount is the number of free addresses of all subnets
+	AvailableIPAddressCount int32
+	SecurityGroupsFound     bool
+	// Errors explains why the subnet or the security groups were not found
+	Errors []string
+}
+
 // msSince returns milliseconds since start.
 func msSince(start time.Time) float64 {
 	return float64(time.Since(start) / time.Millisecond)
@@ -377,16 +420,17 @@ func New(useSubnetDiscovery, useCustomNetworking, disableLeakedENICleanup, v4Ena
 	}
 	ec2Metadata := ec2metadata.NewFromConfig(awsconfig)
 	cache := &EC2InstanceMetadataCache{}
-	cache.imds = TypedIMDS{instrumentedIMDS{ec2Metadata}}
+	// Instance metadata is served from the last-known-good cache on disk while IMDS is unavailable
+	cache.imds = TypedIMDS{loadIMDSCache(instrumentedIMDS{ec2Metadata})}
 	cache.clusterName = os.Getenv(clusterNameEnvVar)
 	cache.additionalENITags = loadAdditionalENITags()
 
-	region, err := ec2Metadata.GetRegion(ctx, nil)
+	region, err := cache.imds.GetRegion(ctx)
 	if err != nil {
 		log.Errorf("Failed to retrieve region data from instance metadata %v", err)
 		return nil, errors.Wrap(err, "instance metadata: failed to retrieve region data")
 	}
-	cache.region = region.Region
+	cache.region = region
 	log.Debugf("Discovered region: %s", cache.region)
 	cache.useCustomNetworking = useCustomNetworking
 	log.Infof("Custom networking enabled %v", cache.useCustomNetworking)
@@ -395,11 +439,11 @@ func New(useSubnetDiscovery, useCustomNetworking, disableLeakedENICleanup, v4Ena
 	cache.v4Enabled = v4Enabled
 	cache.v6Enabled = v6Enabled
 
-	awsCfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region.Region))
-	if err != nil {
-		return nil, fmt.Errorf("unable to load SDK config, %v", err)
-	}
-	ec2SVC := ec2wrapper.New(awsCfg)
+	// Build the EC2 client from the session config, so that the HTTP timeout, retryer and AWS_EC2_ENDPOINT
+	// override apply to EC2 calls as well as to IMDS. Every EC2 call goes through the client-side rate limiter.
+	awsconfig.Region = region
+	awsconfig.APIOptions = append(awsconfig.APIOptions, loadEC2RateLimiter().addToStack)
+	ec2SVC := ec2wrapper.New(awsconfig)
 	cache.ec2SVC = ec2SVC
 	err = cache.initWithEC2Metadata(ctx)
 	if err != nil {
@@ -408,6 +452,7 @@ func New(useSubnetDiscovery, useCustomNetworking, disableLeakedENICleanup, v4Ena
 
 	// Clean up leaked ENIs in the background
 	if !disableLeakedENICleanup {
+		cache.leakedENICleanup = loadLeakedENICleanup()
 		go wait.Forever(cache.cleanUpLeakedENIs, time.Hour)
 	}
 	return cache, nil
@@ -418,6 +463,20 @@ func (cache *EC2InstanceMetadataCache) InitCachedPrefixDelegation(enablePrefixDe
 	log.Infof("Prefix Delegation enabled %v", cache.enablePrefixDelegation)
 }
 
+// IsIMDSDegraded reports whether instance metadata is served from the IMDS cache. The ENIs and addresses it reports
+// may then be stale, and ENIs attached since IMDS became degraded are missing.
+func (cache *EC2InstanceMetadataCache) IsIMDSDegraded() bool {
+	imdsCache, ok := cache.imds.EC2MetadataIface.(*imdsCache)
+	return ok && imdsCache.isDegraded()
+}
+
+// InitCachedMultiCardENIs sets whether the network card of the ENIs is looked up in IMDS. Without it, every ENI is
+// reported on network card 0, which spares an IMDS request per ENI.
+func (cache *EC2InstanceMetadataCache) InitCachedMultiCardENIs(enableMultiCardENIs bool) {
+	cache.enableMultiCardENIs = enableMultiCardENIs
+	log.Infof("Multi-card ENIs enabled %v", cache.enableMultiCardENIs)
+}
+
 // InitWithEC2metadata initializes the EC2InstanceMetadataCache with the data retrieved from EC2 metadata service
 func (cache *EC2InstanceMetadataCache) initWithEC2Metadata(ctx context.Context) error {
 	var err error
@@ -621,7 +680,16 @@ func (cache *EC2InstanceMetadataCache) getENIMetadata(eniMAC string) (ENIMetadat
 		deviceNum = 0
 	}
 
-	log.Debugf("Found ENI: %s, MAC %s, device %d", eniID, eniMAC, deviceNum)
+	networkCard := 0
+	if cache.enableMultiCardENIs {
+		networkCard, err = cache.imds.GetNetworkCard(ctx, eniMAC)
+		if err != nil {
+			awsAPIErrInc("GetNetworkCard", err)
+			return ENIMetadata{}, err
+		}
+	}
+
+	log.Debugf("Found ENI: %s, MAC %s, device %d, network card %d", eniID, eniMAC, deviceNum, networkCard)
 
 	// Get IMDS fields for the interface
 	macImdsFields, err := cache.imds.GetMACImdsFields(ctx, eniMAC)
@@ -662,6 +730,7 @@ func (cache *EC2InstanceMetadataCache) getENIMetadata(eniMAC string) (ENIMetadat
 			ENIID:          eniID,
 			MAC:            eniMAC,
 			DeviceNumber:   deviceNum,
+			NetworkCard:    networkCard,
 			SubnetIPv4CIDR: "",
 			IPv4Addresses:  make([]ec2types.NetworkInterfacePrivateIpAddress, 0),
 			IPv4Prefixes:   make([]ec2types.Ipv4PrefixSpecification, 0),
@@ -737,7 +806,8 @@ func (cache *EC2InstanceMetadataCache) getENIMetadata(eniMAC string) (ENIMetadat
 				Ipv6Prefix: aws.String(ipv6prefix.String()),
 			})
 		}
-	} else if cache.v4Enabled && ((eniMAC == primaryMAC && !cache.useCustomNetworking) || (eniMAC != primaryMAC)) {
+	}
+	if cache.v4Enabled && ((eniMAC == primaryMAC && !cache.useCustomNetworking) || (eniMAC != primaryMAC)) {
 		// Get prefix on primary ENI when custom networking is enabled is not needed.
 		// If primary ENI has prefixes attached and then we move to custom networking, we don't need to fetch
 		// the prefix since recommendation is to terminate the nodes and that would have deleted the prefix on the
@@ -758,6 +828,7 @@ func (cache *EC2InstanceMetadataCache) getENIMetadata(eniMAC string) (ENIMetadat
 		ENIID:          eniID,
 		MAC:            eniMAC,
 		DeviceNumber:   deviceNum,
+		NetworkCard:    networkCard,
 		SubnetIPv4CIDR: subnetV4Cidr,
 		IPv4Addresses:  ec2ip4s,
 		IPv4Prefixes:   ec2ipv4Prefixes,
@@ -767,8 +838,8 @@ func (cache *EC2InstanceMetadataCache) getENIMetadata(eniMAC string) (ENIMetadat
 	}, nil
 }
 
-// awsGetFreeDeviceNumber calls EC2 API DescribeInstances to get the next free device index
-func (cache *EC2InstanceMetadataCache) awsGetFreeDeviceNumber() (int, error) {
+// awsGetFreeDeviceNumber calls EC2 API DescribeInstances to get the next free device index on the network card
+func (cache *EC2InstanceMetadataCache) awsGetFreeDeviceNumber(networkCard int) (int, error) {
 	input := &ec2.DescribeInstancesInput{
 		InstanceIds: []string{cache.instanceID},
 	}
@@ -793,8 +864,8 @@ func (cache *EC2InstanceMetadataCache) awsGetFreeDeviceNumber() (int, error) {
 	inst := result.Reservations[0].Instances[0]
 	var device [maxENIs]bool
 	for _, eni := range inst.NetworkInterfaces {
-		// We don't support multi-card yet, so only account for network card zero
-		if eni.Attachment != nil && aws.ToInt32(eni.Attachment.NetworkCardIndex) == 0 {
+		// Device indexes are per network card, so only account for the ENIs on the same card
+		if eni.Attachment != nil && int(aws.ToInt32(eni.Attachment.NetworkCardIndex)) == networkCard {
 			if aws.ToInt32(eni.Attachment.DeviceIndex) > maxENIs {
 				log.Warnf("The Device Index %d of the attached ENI %s > instance max slot %d",
 					aws.ToInt32(eni.Attachment.DeviceIndex), aws.ToString(eni.NetworkInterfaceId),
@@ -806,7 +877,12 @@ func (cache *EC2InstanceMetadataCache) awsGetFreeDeviceNumber() (int, error) {
 		}
 	}
 
-	for freeDeviceIndex := 0; freeDeviceIndex < maxENIs; freeDeviceIndex++ {
+	// Device index 0 of the other network cards is left free, following the EC2 guidance for multi-card instances
+	firstDeviceIndex := 0
+	if networkCard != 0 {
+		firstDeviceIndex = 1
+	}
+	for freeDeviceIndex := firstDeviceIndex; freeDeviceIndex < maxENIs; freeDeviceIndex++ {
 		if !device[freeDeviceIndex] {
 			log.Debugf("Found a free device number: %d", freeDeviceIndex)
 			return freeDeviceIndex, nil
@@ -817,13 +893,23 @@ func (cache *EC2InstanceMetadataCache) awsGetFreeDeviceNumber() (int, error) {
 
 // AllocENI creates an ENI and attaches it to the instance
 // returns: newly created ENI ID
-func (cache *EC2InstanceMetadataCache) AllocENI(useCustomCfg bool, sg []*string, eniCfgSubnet string, numIPs int) (string, error) {
-	eniID, err := cache.createENI(useCustomCfg, sg, eniCfgSubnet, numIPs)
+func (cache *EC2InstanceMetadataCache) AllocENI(useCustomCfg bool, sg []*string, eniCfgSubnets []string, numIPs int) (string, error) {
+	return cache.AllocENIWithTags(useCustomCfg, sg, eniCfgSubnets, numIPs, nil)
+}
+
+// AllocENIWithTags creates an ENI that carries the given tags on top of the ones ipamd always sets, and attaches it to the instance
+func (cache *EC2InstanceMetadataCache) AllocENIWithTags(useCustomCfg bool, sg []*string, eniCfgSubnets []string, numIPs int, tags map[string]string) (string, error) {
+	return cache.AllocENIOnNetworkCard(useCustomCfg, sg, eniCfgSubnets, numIPs, tags, 0)
+}
+
+// AllocENIOnNetworkCard creates an ENI that carries the given tags, and attaches it to the given network card of the instance
+func (cache *EC2InstanceMetadataCache) AllocENIOnNetworkCard(useCustomCfg bool, sg []*string, eniCfgSubnets []string, numIPs int, tags map[string]string, networkCard int) (string, error) {
+	eniID, err := cache.createENI(useCustomCfg, sg, eniCfgSubnets, numIPs, tags)
 	if err != nil {
 		return "", errors.Wrap(err, "AllocENI: failed to create ENI")
 	}
 
-	attachmentID, err := cache.attachENI(eniID)
+	attachmentID, err := cache.attachENI(eniID, networkCard)
 	if err != nil {
 		derr := cache.deleteENI(eniID, maxENIBackoffDelay)
 		if derr != nil {
@@ -857,14 +943,14 @@ func (cache *EC2InstanceMetadataCache) AllocENI(useCustomCfg bool, sg []*string,
 		return "", errors.Wrap(err, "AllocENI: unable to change the ENI's attribute")
 	}
 
-	log.Infof("Successfully created and attached a new ENI %s to instance", eniID)
+	log.Infof("Successfully created and attached a new ENI %s to network card %d of the instance", eniID, networkCard)
 	return eniID, nil
 }
 
-// attachENI calls EC2 API to attach the ENI and returns the attachment id
-func (cache *EC2InstanceMetadataCache) attachENI(eniID string) (string, error) {
+// attachENI calls EC2 API to attach the ENI to the network card and returns the attachment id
+func (cache *EC2InstanceMetadataCache) attachENI(eniID string, networkCard int) (string, error) {
 	// attach to instance
-	freeDevice, err := cache.awsGetFreeDeviceNumber()
+	freeDevice, err := cache.awsGetFreeDeviceNumber(networkCard)
 	if err != nil {
 		return "", errors.Wrap(err, "attachENI: failed to get a free device number")
 	}
@@ -873,7 +959,7 @@ func (cache *EC2InstanceMetadataCache) attachENI(eniID string) (string, error) {
 		DeviceIndex:        aws.Int32(int32(freeDevice)),
 		InstanceId:         aws.String(cache.instanceID),
 		NetworkInterfaceId: aws.String(eniID),
-		NetworkCardIndex:   aws.Int32(0),
+		NetworkCardIndex:   aws.Int32(int32(networkCard)),
 	}
 	start := time.Now()
 	attachOutput, err := cache.ec2SVC.AttachNetworkInterface(context.Background(), attachInput)
@@ -890,11 +976,14 @@ func (cache *EC2InstanceMetadataCache) attachENI(eniID string) (string, error) {
 }
 
 // return ENI id, error
-func (cache *EC2InstanceMetadataCache) createENI(useCustomCfg bool, sg []*string, eniCfgSubnet string, numIPs int) (string, error) {
+func (cache *EC2InstanceMetadataCache) createENI(useCustomCfg bool, sg []*string, eniCfgSubnets []string, numIPs int, extraTags map[string]string) (string, error) {
 	eniDescription := eniDescriptionPrefix + cache.instanceID
 	tags := map[string]string{
 		eniCreatedAtTagKey: time.Now().Format(time.RFC3339),
 	}
+	for key, value := range extraTags {
+		tags[key] = value
+	}
 	for key, value := range cache.buildENITags() {
 		tags[key] = value
 	}
@@ -916,7 +1005,17 @@ func (cache *EC2InstanceMetadataCache) createENI(useCustomCfg bool, sg []*string
 
 	input := &ec2.CreateNetworkInterfaceInput{}
 
-	if cache.enablePrefixDelegation {
+	if cache.v6Enabled && !cache.v4Enabled {
+		// In IPv6 mode, the IPv6 prefix is assigned once the ENI is created. The ENI gets an IPv6 address of its own,
+		// which the host network is set up with.
+		input = &ec2.CreateNetworkInterfaceInput{
+			Description:       aws.String(eniDescription),
+			Groups:            cache.securityGroups.SortedList(),
+			SubnetId:          aws.String(cache.subnetID),
+			TagSpecifications: tagSpec,
+			Ipv6AddressCount:  aws.Int32(1),
+		}
+	} else if cache.enablePrefixDelegation {
 		input = &ec2.CreateNetworkInterfaceInput{
 			Description:       aws.String(eniDescription),
 			Groups:            cache.securityGroups.SortedList(),
@@ -937,12 +1036,26 @@ func (cache *EC2InstanceMetadataCache) createENI(useCustomCfg bool, sg []*string
 	var err error
 	var networkInterfaceID string
 	if cache.useCustomNetworking {
-		input = createENIUsingCustomCfg(sg, eniCfgSubnet, input)
-		log.Infof("Creating ENI with security groups: %v in subnet: %s", input.Groups, aws.ToString(input.SubnetId))
+		input = createENIUsingCustomCfg(sg, input)
+		// Fall through to the next subnet of the ENIConfig when a subnet is out of addresses
+		for i, subnetID := range eniCfgSubnets {
+			input.SubnetId = aws.String(subnetID)
+			log.Infof("Creating ENI with security groups: %v in subnet: %s", input.Groups, subnetID)
 
-		networkInterfaceID, err = cache.tryCreateNetworkInterface(input)
-		if err == nil {
-			return networkInterfaceID, nil
+			networkInterfaceID, err = cache.tryCreateNetworkInterface(input)
+			if err == nil {
+				setCustomNetworkingSubnet(eniCfgSubnets, subnetID)
+				return networkInterfaceID, nil
+			}
+			if !isSubnetExhausted(err) {
+				break
+			}
+			if i < len(eniCfgSubnets)-1 {
+				log.Warnf("Subnet %s is out of addresses, trying subnet %s", subnetID, eniCfgSubnets[i+1])
+			}
+		}
+		if len(eniCfgSubnets) == 0 {
+			err = errors.New("no subnet in ENIConfig")
 		}
 	} else {
 		if cache.useSubnetDiscovery {
@@ -955,19 +1068,35 @@ func (cache *EC2InstanceMetadataCache) createENI(useCustomCfg bool, sg []*string
 					return networkInterfaceID, nil
 				}
 			} else {
-				for _, subnet := range subnetResult {
-					if *subnet.SubnetId != cache.subnetID {
-						if !validTag(subnet) {
-							continue
-						}
+				ranked, considered := cache.subnetDiscovery.rank(subnetResult, cache.subnetID, time.Now())
+				if len(ranked) == 0 {
+					err = fmt.Errorf("subnet discovery found no subnet to use, skipped subnets: [%s]", skippedSubnets(considered))
+				}
+				for i, candidate := range ranked {
+					reason := subnetSelectionReason(ranked, i)
+					tags[eniSubnetDiscoveryTagKey] = reason
+					input.TagSpecifications = []ec2types.TagSpecification{
+						{
+							ResourceType: ec2types.ResourceTypeNetworkInterface,
+							Tags:         convertTagsToSDKTags(tags),
+						},
 					}
-					log.Infof("Creating ENI with security groups: %v in subnet: %s", input.Groups, aws.ToString(input.SubnetId))
+					input.SubnetId = aws.String(candidate.SubnetID)
+					log.Infof("Creating ENI with security groups: %v in subnet: %s (%s)", input.Groups, candidate.SubnetID, reason)
 
-					input.SubnetId = subnet.SubnetId
 					networkInterfaceID, err = cache.tryCreateNetworkInterface(input)
 					if err == nil {
+						cache.subnetDiscovery.recordSelection(SubnetSelection{
+							SubnetID:   candidate.SubnetID,
+							Reason:     reason,
+							Time:       time.Now(),
+							Candidates: considered,
+						})
 						return networkInterfaceID, nil
 					}
+					if isSubnetExhausted(err) {
+						cache.subnetDiscovery.blacklistSubnet(candidate.SubnetID, time.Now())
+					}
 				}
 			}
 		} else {
@@ -1014,6 +1143,109 @@ func (cache *EC2InstanceMetadataCache) getVpcSubnets() ([]ec2types.Subnet, error
 	return subnetResult.Subnets, nil
 }
 
+// DescribeENIConfigNetwork looks up the subnets and security groups of an ENIConfig. Resources that do not exist or are
+// not in the VPC of the instance, and subnets outside the availability zone of the first subnet, are reported in the
+// result, other EC2 errors are returned.
+func (cache *EC2InstanceMetadataCache) DescribeENIConfigNetwork(subnetIDs []string, securityGroups []string) (ENIConfigNetwork, error) {
+	var result ENIConfigNetwork
+	if len(subnetIDs) == 0 {
+		// DescribeSubnets would return all subnets of the account
+		result.Errors = append(result.Errors, "no subnet in ENIConfig")
+	} else {
+		start := time.Now()
+		subnets, err := cache.ec2SVC.DescribeSubnets(context.Background(), &ec2.DescribeSubnetsInput{SubnetIds: subnetIDs})
+		prometheusmetrics.Ec2ApiReq.WithLabelValues("DescribeSubnets").Inc()
+		prometheusmetrics.AwsAPILatency.WithLabelValues("DescribeSubnets", fmt.Sprint(err != nil), awsReqStatus(err)).Observe(msSince(start))
+		if err != nil && !isNotFoundOrMalformed(err, "InvalidSubnetID") {
+			checkAPIErrorAndBroadcastEvent(err, "ec2:DescribeSubnets")
+			awsAPIErrInc("DescribeSubnets", err)
+			prometheusmetrics.Ec2ApiErr.WithLabelValues("DescribeSubnets").Inc()
+			return result, errors.Wrap(err, "DescribeENIConfigNetwork: unable to describe subnets")
+		}
+		if err != nil {
+			var apiErr smithy.APIError
+			errors.As(err, &apiErr)
+			result.Errors = append(result.Errors, apiErr.ErrorMessage())
+		} else {
+			result.Errors = append(result.Errors, cache.checkENIConfigSubnets(subnetIDs, subnets.Subnets, &result)...)
+			result.SubnetFound = len(result.Errors) == 0
+		}
+	}
+
+	// ENIs get the security groups of the primary ENI when the ENIConfig has none
+	if len(securityGroups) == 0 {
+		result.SecurityGroupsFound = true
+		return result, nil
+	}
+	start := time.Now()
+	groups, err := cache.ec2SVC.DescribeSecurityGroups(context.Background(), &ec2.DescribeSecurityGroupsInput{GroupIds: securityGroups})
+	prometheusmetrics.Ec2ApiReq.WithLabelValues("DescribeSecurityGroups").Inc()
+	prometheusmetrics.AwsAPILatency.WithLabelValues("DescribeSecurityGroups", fmt.Sprint(err != nil), awsReqStatus(err)).Observe(msSince(start))
+	if err != nil {
+		if isNotFoundOrMalformed(err, "InvalidGroup") || isNotFoundOrMalformed(err, "InvalidGroupId") {
+			var apiErr smithy.APIError
+			errors.As(err, &apiErr)
+			result.Errors = append(result.Errors, apiErr.ErrorMessage())
+			return result, nil
+		}
+		checkAPIErrorAndBroadcastEvent(err, "ec2:DescribeSecurityGroups")
+		awsAPIErrInc("DescribeSecurityGroups", err)
+		prometheusmetrics.Ec2ApiErr.WithLabelValues("DescribeSecurityGroups").Inc()
+		return result, errors.Wrap(err, "DescribeENIConfigNetwork: unable to describe security groups")
+	}
+	result.SecurityGroupsFound = true
+	for _, group := range groups.SecurityGroups {
+		if aws.ToString(group.VpcId) != cache.vpcID {
+			result.SecurityGroupsFound = false
+			result.Errors = append(result.Errors, fmt.Sprintf("security group %s is in %s, not in %s",
+				aws.ToString(group.GroupId), aws.ToString(group.VpcId), cache.vpcID))
+		}
+	}
+	return result, nil
+}
+
+// checkENIConfigSubnets fills in the availability zone of the first subnet and the free addresses of all subnets, and
+// returns the problems of the subnets
+func (cache *EC2InstanceMetadataCache) checkENIConfigSubnets(subnetIDs []string, subnets []ec2types.Subnet, result *ENIConfigNetwork) []string {
+	var problems []string
+	byID := make(map[string]ec2types.Subnet, len(subnets))
+	for _, subnet := range subnets {
+		byID[aws.ToString(subnet.SubnetId)] = subnet
+	}
+	for i, subnetID := range subnetIDs {
+		subnet, ok := byID[subnetID]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("subnet %s not found", subnetID))
+		case aws.ToString(subnet.VpcId) != cache.vpcID:
+			problems = append(problems, fmt.Sprintf("subnet %s is in %s, not in %s", subnetID, aws.ToString(subnet.VpcId), cache.vpcID))
+		case i == 0:
+			result.AvailabilityZone = aws.ToString(subnet.AvailabilityZone)
+			result.AvailableIPAddressCount += aws.ToInt32(subnet.AvailableIpAddressCount)
+		case aws.ToString(subnet.AvailabilityZone) != result.AvailabilityZone:
+			problems = append(problems, fmt.Sprintf("subnet %s is in %s, not in %s", subnetID,
+				aws.ToString(subnet.AvailabilityZone), result.AvailabilityZone))
+		default:
+			result.AvailableIPAddressCount += aws.ToInt32(subnet.AvailableIpAddressCount)
+		}
+	}
+	return problems
+}
+
+// isNotFoundOrMalformed returns whether err is the NotFound or Malformed EC2 error of the given resource error prefix
+func isNotFoundOrMalformed(err error, prefix string) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.ErrorCode() == prefix+".NotFound" || apiErr.ErrorCode() == prefix+".Malformed"
+}
+
+// GetSubnetDiscoveryState returns the last subnet chosen by subnet discovery and the subnets it skips
+func (cache *EC2InstanceMetadataCache) GetSubnetDiscoveryState() SubnetDiscoveryState {
+	return cache.subnetDiscovery.state(time.Now())
+}
+
 func validTag(subnet ec2types.Subnet) bool {
 	for _, tag := range subnet.Tags {
 		if *tag.Key == subnetDiscoveryTagKey {
@@ -1023,7 +1255,7 @@ func validTag(subnet ec2types.Subnet) bool {
 	return false
 }
 
-func createENIUsingCustomCfg(sg []*string, eniCfgSubnet string, input *ec2.CreateNetworkInterfaceInput) *ec2.CreateNetworkInterfaceInput {
+func createENIUsingCustomCfg(sg []*string, input *ec2.CreateNetworkInterfaceInput) *ec2.CreateNetworkInterfaceInput {
 	log.Info("Using a custom network config for the new ENI")
 
 	if len(sg) != 0 {
@@ -1031,11 +1263,30 @@ func createENIUsingCustomCfg(sg []*string, eniCfgSubnet string, input *ec2.Creat
 	} else {
 		log.Warnf("No custom networking security group found, will use the node's primary ENI's SG: %v", input.Groups)
 	}
-	input.SubnetId = aws.String(eniCfgSubnet)
 
 	return input
 }
 
+// isSubnetExhausted returns whether the subnet does not have enough free addresses or prefixes for the ENI
+func isSubnetExhausted(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == "InsufficientFreeAddressesInSubnet" || apiErr.ErrorCode() == "InsufficientCidrBlocks"
+	}
+	return false
+}
+
+// setCustomNetworkingSubnet exports the subnet of the ENIConfig that the last ENI was created in
+func setCustomNetworkingSubnet(eniCfgSubnets []string, chosen string) {
+	for _, subnetID := range eniCfgSubnets {
+		value := 0.0
+		if subnetID == chosen {
+			value = 1
+		}
+		prometheusmetrics.CustomNetworkingSubnet.WithLabelValues(subnetID).Set(value)
+	}
+}
+
 func (cache *EC2InstanceMetadataCache) tryCreateNetworkInterface(input *ec2.CreateNetworkInterfaceInput) (string, error) {
 	start := time.Now()
 	result, err := cache.ec2SVC.CreateNetworkInterface(context.Background(), input)
@@ -1242,70 +1493,6 @@ func (cache *EC2InstanceMetadataCache) deleteENI(eniName string, maxBackoffDelay
 	return err
 }
 
-// GetIPv4sFromEC2 calls EC2 and returns a list of all addresses on the ENI
-func (cache *EC2InstanceMetadataCache) GetIPv4sFromEC2(eniID string) (addrList []ec2types.NetworkInterfacePrivateIpAddress, err error) {
-	eniIds := make([]*string, 0)
-	eniIds = append(eniIds, aws.String(eniID))
-	input := &ec2.DescribeNetworkInterfacesInput{NetworkInterfaceIds: aws.ToStringSlice(eniIds)}
-
-	start := time.Now()
-	result, err := cache.ec2SVC.DescribeNetworkInterfaces(context.Background(), input)
-	prometheusmetrics.Ec2ApiReq.WithLabelValues("DescribeNetworkInterfaces").Inc()
-	prometheusmetrics.AwsAPILatency.WithLabelValues("DescribeNetworkInterfaces", fmt.Sprint(err != nil), awsReqStatus(err)).Observe(msSince(start))
-	if err != nil {
-		if errors.As(err, &awsAPIError) {
-			if awsAPIError.ErrorCode() == "InvalidNetworkInterfaceID.NotFound" {
-				return nil, ErrENINotFound
-			}
-		}
-		checkAPIErrorAndBroadcastEvent(err, "ec2:DescribeNetworkInterfaces")
-		awsAPIErrInc("DescribeNetworkInterfaces", err)
-		prometheusmetrics.Ec2ApiErr.WithLabelValues("DescribeNetworkInterfaces").Inc()
-		log.Errorf("Failed to get ENI %s information from EC2 control plane %v", eniID, err)
-		return nil, errors.Wrap(err, "failed to describe network interface")
-	}
-
-	// Shouldn't happen, but let's be safe
-	if len(result.NetworkInterfaces) == 0 {
-		return nil, ErrNoNetworkInterfaces
-	}
-	firstNI := result.NetworkInterfaces[0]
-
-	return firstNI.PrivateIpAddresses, nil
-}
-
-// GetIPv4PrefixesFromEC2 calls EC2 and returns a list of all addresses on the ENI
-func (cache *EC2InstanceMetadataCache) GetIPv4PrefixesFromEC2(eniID string) (addrList []ec2types.Ipv4PrefixSpecification, err error) {
-	eniIds := []*string{aws.String(eniID)}
-	input := &ec2.DescribeNetworkInterfacesInput{NetworkInterfaceIds: aws.ToStringSlice(eniIds)}
-
-	start := time.Now()
-	result, err := cache.ec2SVC.DescribeNetworkInterfaces(context.Background(), input)
-	prometheusmetrics.Ec2ApiReq.WithLabelValues("DescribeNetworkInterfaces").Inc()
-	prometheusmetrics.AwsAPILatency.WithLabelValues("DescribeNetworkInterfaces", fmt.Sprint(err != nil), awsReqStatus(err)).Observe(msSince(start))
-	if err != nil {
-		if errors.As(err, &awsAPIError) {
-			if awsAPIError.ErrorCode() == "InvalidNetworkInterfaceID.NotFound" {
-				return nil, ErrENINotFound
-			}
-
-		}
-		checkAPIErrorAndBroadcastEvent(err, "ec2:DescribeNetworkInterfaces")
-		awsAPIErrInc("DescribeNetworkInterfaces", err)
-		prometheusmetrics.Ec2ApiErr.WithLabelValues("DescribeNetworkInterfaces").Inc()
-		log.Errorf("Failed to get ENI %s information from EC2 control plane %v", eniID, err)
-		return nil, errors.Wrap(err, "failed to describe network interface")
-	}
-
-	// Shouldn't happen, but let's be safe
-	if len(result.NetworkInterfaces) == 0 {
-		return nil, ErrNoNetworkInterfaces
-	}
-	returnedENI := result.NetworkInterfaces[0]
-
-	return returnedENI.Ipv4Prefixes, nil
-}
-
 // GetIPv6PrefixesFromEC2 calls EC2 and returns a list of all addresses on the ENI
 func (cache *EC2InstanceMetadataCache) GetIPv6PrefixesFromEC2(eniID string) (addrList []ec2types.Ipv6PrefixSpecification, err error) {
 	eniIds := []*string{aws.String(eniID)}
@@ -1337,22 +1524,31 @@ func (cache *EC2InstanceMetadataCache) GetIPv6PrefixesFromEC2(eniID string) (add
 	return returnedENI.Ipv6Prefixes, nil
 }
 
-// DescribeAllENIs calls EC2 to refresh the ENIMetadata and tags for all attached ENIs
-func (cache *EC2InstanceMetadataCache) DescribeAllENIs() (DescribeAllENIsResult, error) {
-	// Fetch all local ENI info from metadata
-	allENIs, err := cache.GetAttachedENIs()
+// DescribeENIAddresses calls EC2 once for all the given ENIs and returns their IPv4 addresses and prefixes by ENI ID.
+// ENIs that EC2 does not know about are left out of the result.
+func (cache *EC2InstanceMetadataCache) DescribeENIAddresses(eniIDs []string) (map[string]ENIAddresses, error) {
+	addresses := make(map[string]ENIAddresses, len(eniIDs))
+	if len(eniIDs) == 0 {
+		return addresses, nil
+	}
+	result, _, err := cache.describeENIs(eniIDs)
 	if err != nil {
-		return DescribeAllENIsResult{}, errors.Wrap(err, "DescribeAllENIs: failed to get local ENI metadata")
+		return nil, errors.Wrap(err, "failed to describe network interfaces")
 	}
-
-	eniMap := make(map[string]ENIMetadata, len(allENIs))
-	var eniIDs []string
-	for _, eni := range allENIs {
-		eniIDs = append(eniIDs, eni.ENIID)
-		eniMap[eni.ENIID] = eni
+	for _, ec2res := range result.NetworkInterfaces {
+		addresses[aws.ToString(ec2res.NetworkInterfaceId)] = ENIAddresses{
+			IPv4Addresses: ec2res.PrivateIpAddresses,
+			IPv4Prefixes:  ec2res.Ipv4Prefixes,
+		}
 	}
+	return addresses, nil
+}
 
-	var ec2Response *ec2.DescribeNetworkInterfacesOutput
+// describeENIs calls EC2 to describe the given ENIs. ENIs that EC2 does not know about, which IMDS can still list for a
+// while after they are gone, are dropped from the request, and the ENI IDs that were described are returned.
+func (cache *EC2InstanceMetadataCache) describeENIs(eniIDs []string) (*ec2.DescribeNetworkInterfacesOutput, []string, error) {
+	ec2Response := &ec2.DescribeNetworkInterfacesOutput{}
+	var err error
 	// Try calling EC2 to describe the interfaces.
 	for retryCount := 0; retryCount < maxENIEC2APIRetries && len(eniIDs) > 0; retryCount++ {
 		input := &ec2.DescribeNetworkInterfacesInput{NetworkInterfaceIds: eniIDs}
@@ -1374,8 +1570,6 @@ func (cache *EC2InstanceMetadataCache) DescribeAllENIs() (DescribeAllENIsResult,
 				badENIID := badENIID(awsAPIError.ErrorMessage())
 				log.Debugf("Could not find interface: %s, ID: %s", awsAPIError.ErrorMessage(), badENIID)
 				awsAPIErrInc("IMDSMetaDataOutOfSync", err)
-				// Remove this ENI from the map
-				delete(eniMap, badENIID)
 				// Remove the failing ENI ID from the EC2 API request and try again
 				var tmpENIIDs []string
 				for _, eniID := range eniIDs {
@@ -1390,10 +1584,38 @@ func (cache *EC2InstanceMetadataCache) DescribeAllENIs() (DescribeAllENIsResult,
 		// For other errors sleep a short while before the next retry
 		time.Sleep(time.Duration(retryCount*10) * time.Millisecond)
 	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return ec2Response, eniIDs, nil
+}
+
+// DescribeAllENIs calls EC2 to refresh the ENIMetadata and tags for all attached ENIs
+func (cache *EC2InstanceMetadataCache) DescribeAllENIs() (DescribeAllENIsResult, error) {
+	// Fetch all local ENI info from metadata
+	allENIs, err := cache.GetAttachedENIs()
+	if err != nil {
+		return DescribeAllENIsResult{}, errors.Wrap(err, "DescribeAllENIs: failed to get local ENI metadata")
+	}
+
+	eniMap := make(map[string]ENIMetadata, len(allENIs))
+	var eniIDs []string
+	for _, eni := range allENIs {
+		eniIDs = append(eniIDs, eni.ENIID)
+		eniMap[eni.ENIID] = eni
+	}
 
+	ec2Response, eniIDs, err := cache.describeENIs(eniIDs)
 	if err != nil {
 		return DescribeAllENIsResult{}, err
 	}
+	// Remove the ENIs EC2 does not know about
+	found := sets.NewString(eniIDs...)
+	for eniID := range eniMap {
+		if !found.Has(eniID) {
+			delete(eniMap, eniID)
+		}
+	}
 
 	// Collect the verified ENIs
 	var verifiedENIs []ENIMetadata
@@ -1720,15 +1942,8 @@ func (cache *EC2InstanceMetadataCache) AllocIPAddresses(eniID string, numIPs int
 		}
 	}
 
-	start := time.Now()
-	output, err := cache.ec2SVC.AssignPrivateIpAddresses(context.Background(), input)
-	prometheusmetrics.Ec2ApiReq.WithLabelValues("AssignPrivateIpAddresses").Inc()
-	prometheusmetrics.AwsAPILatency.WithLabelValues("AssignPrivateIpAddresses", fmt.Sprint(err != nil), awsReqStatus(err)).Observe(msSince(start))
+	output, err := cache.assignPrivateIPAddresses(input)
 	if err != nil {
-		checkAPIErrorAndBroadcastEvent(err, "ec2:AssignPrivateIpAddresses")
-		log.Errorf("Failed to allocate a private IP/Prefix addresses on ENI %v: %v", eniID, err)
-		awsAPIErrInc("AssignPrivateIpAddresses", err)
-		prometheusmetrics.Ec2ApiErr.WithLabelValues("AssignPrivateIpAddresses").Inc()
 		return nil, err
 	}
 	if output != nil {
@@ -1741,6 +1956,38 @@ func (cache *EC2InstanceMetadataCache) AllocIPAddresses(eniID string, numIPs int
 	return output, nil
 }
 
+// AllocSpecificIPAddress allocates the given IPv4 address on an ENI. With prefix delegation enabled, the /28 prefix
+// holding the address is allocated instead.
+func (cache *EC2InstanceMetadataCache) AllocSpecificIPAddress(eniID string, ip net.IP) (*ec2.AssignPrivateIpAddressesOutput, error) {
+	input := &ec2.AssignPrivateIpAddressesInput{
+		NetworkInterfaceId: aws.String(eniID),
+	}
+	if cache.enablePrefixDelegation {
+		prefix := net.IPNet{IP: ip.Mask(net.CIDRMask(28, 32)), Mask: net.CIDRMask(28, 32)}
+		log.Infof("Trying to allocate prefix %s for IP address %s on ENI %s", prefix.String(), ip.String(), eniID)
+		input.Ipv4Prefixes = []string{prefix.String()}
+	} else {
+		log.Infof("Trying to allocate IP address %s on ENI %s", ip.String(), eniID)
+		input.PrivateIpAddresses = []string{ip.String()}
+	}
+	return cache.assignPrivateIPAddresses(input)
+}
+
+func (cache *EC2InstanceMetadataCache) assignPrivateIPAddresses(input *ec2.AssignPrivateIpAddressesInput) (*ec2.AssignPrivateIpAddressesOutput, error) {
+	start := time.Now()
+	output, err := cache.ec2SVC.AssignPrivateIpAddresses(context.Background(), input)
+	prometheusmetrics.Ec2ApiReq.WithLabelValues("AssignPrivateIpAddresses").Inc()
+	prometheusmetrics.AwsAPILatency.WithLabelValues("AssignPrivateIpAddresses", fmt.Sprint(err != nil), awsReqStatus(err)).Observe(msSince(start))
+	if err != nil {
+		checkAPIErrorAndBroadcastEvent(err, "ec2:AssignPrivateIpAddresses")
+		log.Errorf("Failed to allocate a private IP/Prefix addresses on ENI %v: %v", aws.ToString(input.NetworkInterfaceId), err)
+		awsAPIErrInc("AssignPrivateIpAddresses", err)
+		prometheusmetrics.Ec2ApiErr.WithLabelValues("AssignPrivateIpAddresses").Inc()
+		return nil, err
+	}
+	return output, nil
+}
+
 func (cache *EC2InstanceMetadataCache) AllocIPv6Prefixes(eniID string) ([]*string, error) {
 	//We only need to allocate one IPv6 prefix per ENI.
 	input := &ec2.AssignIpv6AddressesInput{
@@ -1789,7 +2036,8 @@ func (cache *EC2InstanceMetadataCache) waitForENIAndIPsAttached(eni string, want
 					returnedENI.IPv4Addresses, returnedENI.IPv4Prefixes, returnedENI.IPv6Prefixes)
 				if cache.enablePrefixDelegation {
 					eniIPCount = len(returnedENI.IPv4Prefixes)
-					if cache.v6Enabled {
+					// In dual-stack mode, ENIs are attached for their IPv4 prefixes
+					if cache.v6Enabled && !cache.v4Enabled {
 						eniIPCount = len(returnedENI.IPv6Prefixes)
 					}
 				} else {
@@ -1899,22 +2147,54 @@ func (cache *EC2InstanceMetadataCache) cleanUpLeakedENIsInternal(startupDelay ti
 	time.Sleep(startupDelay)
 
 	log.Debug("Checking for leaked AWS CNI ENIs.")
-	networkInterfaces, err := cache.getLeakedENIs()
+	networkInterfaces, tooYoung, err := cache.getLeakedENIs()
 	if err != nil {
 		log.Warnf("Unable to get leaked ENIs: %v", err)
-	} else {
-		// Clean up all the leaked ones we found
-		for _, networkInterface := range networkInterfaces {
-			eniID := aws.ToString(networkInterface.NetworkInterfaceId)
-			err = cache.deleteENI(eniID, maxENIBackoffDelay)
-			if err != nil {
-				awsUtilsErrInc("cleanUpLeakedENIDeleteErr", err)
-				log.Warnf("Failed to clean up leaked ENI %s: %v", eniID, err)
-			} else {
-				log.Debugf("Cleaned up leaked CNI ENI %s", eniID)
-			}
+		return
+	}
+	now := time.Now()
+	report := LeakedENIReport{
+		Time:   now,
+		DryRun: cache.leakedENICleanup.isDryRun(),
+		MinAge: cache.leakedENICleanup.minimumAge().String(),
+	}
+	if cache.leakedENICleanup != nil {
+		for _, selector := range cache.leakedENICleanup.protectedTags {
+			report.ProtectedTags = append(report.ProtectedTags, selector.String())
 		}
 	}
+	// Clean up all the leaked ones we found, except the protected ones
+	for _, networkInterface := range networkInterfaces {
+		eni := newLeakedENI(networkInterface, now)
+		if selector, ok := cache.leakedENICleanup.protectedBy(eni.Tags); ok {
+			eni.Action = leakedENIActionProtected
+			log.Infof("Not cleaning up leaked CNI ENI %s, it has protected tag %s", eni.ENIID, selector)
+		} else if report.DryRun {
+			eni.Action = leakedENIActionReported
+			log.Infof("Dry run: would clean up leaked CNI ENI %s, age %s, tags %v", eni.ENIID, eni.Age, eni.Tags)
+		} else if err := cache.deleteENI(eni.ENIID, maxENIBackoffDelay); err != nil {
+			eni.Action = leakedENIActionDeleteFailed
+			eni.Error = err.Error()
+			awsUtilsErrInc("cleanUpLeakedENIDeleteErr", err)
+			log.Warnf("Failed to clean up leaked ENI %s: %v", eni.ENIID, err)
+		} else {
+			eni.Action = leakedENIActionDeleted
+			log.Debugf("Cleaned up leaked CNI ENI %s", eni.ENIID)
+		}
+		report.ENIs = append(report.ENIs, eni)
+	}
+	// Report the ones below the minimum age too, so that a dry run shows what the next runs will find
+	for _, networkInterface := range tooYoung {
+		eni := newLeakedENI(networkInterface, now)
+		eni.Action = leakedENIActionTooYoung
+		report.ENIs = append(report.ENIs, eni)
+	}
+	cache.leakedENICleanup.setReport(report)
+}
+
+// GetLeakedENIReport returns the report of the last leaked ENI cleanup, nil before the first cleanup
+func (cache *EC2InstanceMetadataCache) GetLeakedENIReport() *LeakedENIReport {
+	return cache.leakedENICleanup.getReport()
 }
 
 func (cache *EC2InstanceMetadataCache) tagENIcreateTS(eniID string, maxBackoffDelay time.Duration) {
@@ -1953,8 +2233,8 @@ func (cache *EC2InstanceMetadataCache) tagENIcreateTS(eniID string, maxBackoffDe
 }
 
 // getLeakedENIs calls DescribeNetworkInterfaces to get all available ENIs that were allocated by
-// the AWS CNI plugin, but were not deleted.
-func (cache *EC2InstanceMetadataCache) getLeakedENIs() ([]ec2types.NetworkInterface, error) {
+// the AWS CNI plugin, but were not deleted. ENIs younger than the minimum age are returned separately.
+func (cache *EC2InstanceMetadataCache) getLeakedENIs() (leaked, tooYoung []ec2types.NetworkInterface, err error) {
 	leakedENIFilters := []ec2types.Filter{
 		{
 			Name:   aws.String("tag-key"),
@@ -1987,7 +2267,7 @@ func (cache *EC2InstanceMetadataCache) getLeakedENIs() ([]ec2types.NetworkInterf
 		MaxResults: aws.Int32(describeENIPageSize),
 	}
 
-	var networkInterfaces []ec2types.NetworkInterface
+	var networkInterfaces, youngInterfaces []ec2types.NetworkInterface
 	filterFn := func(networkInterface ec2types.NetworkInterface) error {
 		// Verify the description starts with "aws-K8S-"
 		if !strings.HasPrefix(aws.ToString(networkInterface.Description), eniDescriptionPrefix) {
@@ -2002,8 +2282,9 @@ func (cache *EC2InstanceMetadataCache) getLeakedENIs() ([]ec2types.NetworkInterf
 				log.Warnf("ParsedTime format %s is wrong so retagging with current TS", parsedTime)
 				cache.tagENIcreateTS(aws.ToString(networkInterface.NetworkInterfaceId), maxENIBackoffDelay)
 			}
-			if time.Since(parsedTime) < eniDeleteCooldownTime {
-				log.Infof("Found an ENI created less than 5 minutes ago, so not cleaning it up")
+			if minAge := cache.leakedENICleanup.minimumAge(); time.Since(parsedTime) < minAge {
+				log.Infof("Found an ENI created less than %v ago, so not cleaning it up", minAge)
+				youngInterfaces = append(youngInterfaces, networkInterface)
 				return nil
 			}
 			log.Debugf("%v", value)
@@ -2018,18 +2299,18 @@ func (cache *EC2InstanceMetadataCache) getLeakedENIs() ([]ec2types.NetworkInterf
 		return nil
 	}
 
-	err := cache.getENIsFromPaginatedDescribeNetworkInterfaces(input, filterFn)
+	err = cache.getENIsFromPaginatedDescribeNetworkInterfaces(input, filterFn)
 	if err != nil {
-		return nil, errors.Wrap(err, "awsutils: unable to obtain filtered list of network interfaces")
+		return nil, nil, errors.Wrap(err, "awsutils: unable to obtain filtered list of network interfaces")
 	}
 
 	if len(networkInterfaces) < 1 {
 		log.Debug("No AWS CNI leaked ENIs found.")
-		return nil, nil
+		return nil, youngInterfaces, nil
 	}
 
 	log.Debugf("Found %d leaked ENIs with the AWS CNI tag.", len(networkInterfaces))
-	return networkInterfaces, nil
+	return networkInterfaces, youngInterfaces, nil
 }
 
 // GetVPCIPv4CIDRs returns VPC CIDRs
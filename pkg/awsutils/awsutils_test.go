@@ -17,6 +17,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"reflect"
 	"strconv"
@@ -228,6 +229,34 @@ func TestGetAttachedENIs(t *testing.T) {
 	}
 }
 
+func TestGetAttachedENIsNetworkCard(t *testing.T) {
+	networkCardPath := metadataMACPath + eni2MAC + "/network-card"
+	mockMetadata := testMetadata(map[string]interface{}{
+		metadataMACPath:                                primaryMAC + " " + eni2MAC,
+		metadataMACPath + eni2MAC:                      imdsMACFields,
+		metadataMACPath + eni2MAC + metadataDeviceNum:  eni2Device,
+		metadataMACPath + eni2MAC + metadataInterface:  eni2ID,
+		metadataMACPath + eni2MAC + metadataSubnetCIDR: subnetCIDR,
+		metadataMACPath + eni2MAC + metadataIPv4s:      eni2PrivateIP,
+		networkCardPath:                                "not-a-number",
+	})
+
+	// The network card is only looked up with multi-card ENIs
+	cache := &EC2InstanceMetadataCache{imds: TypedIMDS{mockMetadata}}
+	enis, err := cache.GetAttachedENIs()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, enis[1].NetworkCard)
+
+	cache.InitCachedMultiCardENIs(true)
+	_, err = cache.GetAttachedENIs()
+	assert.Error(t, err)
+	mockMetadata[networkCardPath] = "1"
+	enis, err = cache.GetAttachedENIs()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, enis[0].NetworkCard)
+	assert.Equal(t, 1, enis[1].NetworkCard)
+}
+
 func TestGetAttachedENIsWithEfaOnly(t *testing.T) {
 	mockMetadata := testMetadata(map[string]interface{}{
 		metadataMACPath:                                primaryMAC + " " + eni2MAC,
@@ -287,7 +316,7 @@ func TestAWSGetFreeDeviceNumberOnErr(t *testing.T) {
 	mockEC2.EXPECT().DescribeInstances(gomock.Any(), gomock.Any()).Return(nil, errors.New("error on DescribeInstances"))
 
 	cache := &EC2InstanceMetadataCache{ec2SVC: mockEC2}
-	_, err := cache.awsGetFreeDeviceNumber()
+	_, err := cache.awsGetFreeDeviceNumber(0)
 	assert.Error(t, err)
 }
 
@@ -312,10 +341,33 @@ func TestAWSGetFreeDeviceNumberNoDevice(t *testing.T) {
 	mockEC2.EXPECT().DescribeInstances(gomock.Any(), gomock.Any(), gomock.Any()).Return(result, nil)
 
 	cache := &EC2InstanceMetadataCache{ec2SVC: mockEC2}
-	_, err := cache.awsGetFreeDeviceNumber()
+	_, err := cache.awsGetFreeDeviceNumber(0)
 	assert.Error(t, err)
 }
 
+func TestAWSGetFreeDeviceNumberPerNetworkCard(t *testing.T) {
+	ctrl, mockEC2 := setup(t)
+	defer ctrl.Finish()
+
+	attachment := func(networkCard, deviceIndex int32) ec2types.InstanceNetworkInterface {
+		return ec2types.InstanceNetworkInterface{Attachment: &ec2types.InstanceNetworkInterfaceAttachment{
+			DeviceIndex: aws.Int32(deviceIndex), NetworkCardIndex: aws.Int32(networkCard)}}
+	}
+	result := &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{
+		Instances: []ec2types.Instance{{
+			NetworkInterfaces: []ec2types.InstanceNetworkInterface{attachment(0, 0), attachment(0, 1), attachment(1, 0)},
+		}}}}}
+	mockEC2.EXPECT().DescribeInstances(gomock.Any(), gomock.Any(), gomock.Any()).Return(result, nil).Times(3)
+
+	cache := &EC2InstanceMetadataCache{ec2SVC: mockEC2}
+	// Device index 0 is left free on the other network cards
+	for networkCard, want := range []int{2, 1, 1} {
+		device, err := cache.awsGetFreeDeviceNumber(networkCard)
+		assert.NoError(t, err)
+		assert.Equal(t, want, device, "network card %d", networkCard)
+	}
+}
+
 func TestGetENIAttachmentID(t *testing.T) {
 	ctrl, mockEC2 := setup(t)
 	defer ctrl.Finish()
@@ -437,6 +489,44 @@ func TestDescribeAllENIs(t *testing.T) {
 	}
 }
 
+func TestDescribeENIAddresses(t *testing.T) {
+	ctrl, mockEC2 := setup(t)
+	defer ctrl.Finish()
+
+	cache := &EC2InstanceMetadataCache{ec2SVC: mockEC2}
+	result := &ec2.DescribeNetworkInterfacesOutput{
+		NetworkInterfaces: []ec2types.NetworkInterface{{
+			NetworkInterfaceId: aws.String(primaryeniID),
+			PrivateIpAddresses: []ec2types.NetworkInterfacePrivateIpAddress{{PrivateIpAddress: aws.String(eni1PrivateIP)}},
+			Ipv4Prefixes:       []ec2types.Ipv4PrefixSpecification{{Ipv4Prefix: aws.String(eni1Prefix)}},
+		}},
+	}
+	notFound := &smithy.GenericAPIError{Code: "InvalidNetworkInterfaceID.NotFound", Message: "The networkInterface ID 'eni-gone' does not exist"}
+	// One call covers all the ENIs, and ENIs unknown to EC2 are dropped from the request and the result
+	gomock.InOrder(
+		mockEC2.EXPECT().DescribeNetworkInterfaces(gomock.Any(), &ec2.DescribeNetworkInterfacesInput{
+			NetworkInterfaceIds: []string{primaryeniID, "eni-gone"},
+		}, gomock.Any()).Return(nil, notFound),
+		mockEC2.EXPECT().DescribeNetworkInterfaces(gomock.Any(), &ec2.DescribeNetworkInterfacesInput{
+			NetworkInterfaceIds: []string{primaryeniID},
+		}, gomock.Any()).Return(result, nil),
+	)
+	addresses, err := cache.DescribeENIAddresses([]string{primaryeniID, "eni-gone"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]ENIAddresses{primaryeniID: {
+		IPv4Addresses: result.NetworkInterfaces[0].PrivateIpAddresses,
+		IPv4Prefixes:  result.NetworkInterfaces[0].Ipv4Prefixes,
+	}}, addresses)
+
+	addresses, err = cache.DescribeENIAddresses(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, addresses)
+
+	mockEC2.EXPECT().DescribeNetworkInterfaces(gomock.Any(), gomock.Any(), gomock.Any()).Times(maxENIEC2APIRetries).Return(nil, errors.New("other error"))
+	_, err = cache.DescribeENIAddresses([]string{primaryeniID})
+	assert.Error(t, err)
+}
+
 func TestAllocENI(t *testing.T) {
 	ctrl, mockEC2 := setup(t)
 	defer ctrl.Finish()
@@ -489,7 +579,7 @@ func TestAllocENI(t *testing.T) {
 		useSubnetDiscovery: true,
 	}
 
-	_, err := cache.AllocENI(false, nil, "", 5)
+	_, err := cache.AllocENI(false, nil, nil, 5)
 	assert.NoError(t, err)
 }
 
@@ -539,7 +629,7 @@ func TestAllocENINoFreeDevice(t *testing.T) {
 		useSubnetDiscovery: true,
 	}
 
-	_, err := cache.AllocENI(false, nil, "", 5)
+	_, err := cache.AllocENI(false, nil, nil, 5)
 	assert.Error(t, err)
 }
 
@@ -592,7 +682,7 @@ func TestAllocENIMaxReached(t *testing.T) {
 		useSubnetDiscovery: true,
 	}
 
-	_, err := cache.AllocENI(false, nil, "", 5)
+	_, err := cache.AllocENI(false, nil, nil, 5)
 	assert.Error(t, err)
 }
 
@@ -639,7 +729,7 @@ func TestAllocENIWithIPAddresses(t *testing.T) {
 	mockEC2.EXPECT().ModifyNetworkInterfaceAttribute(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 
 	cache := &EC2InstanceMetadataCache{ec2SVC: mockEC2, instanceType: "c5n.18xlarge", useSubnetDiscovery: true}
-	_, err := cache.AllocENI(false, nil, subnetID, 5)
+	_, err := cache.AllocENI(false, nil, []string{subnetID}, 5)
 	assert.NoError(t, err)
 
 	// when required IP numbers(50) is higher than ENI's limit(49)
@@ -649,7 +739,7 @@ func TestAllocENIWithIPAddresses(t *testing.T) {
 	mockEC2.EXPECT().AttachNetworkInterface(gomock.Any(), gomock.Any(), gomock.Any()).Return(attachResult, nil)
 	mockEC2.EXPECT().ModifyNetworkInterfaceAttribute(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 	cache = &EC2InstanceMetadataCache{ec2SVC: mockEC2, instanceType: "c5n.18xlarge", useSubnetDiscovery: true}
-	_, err = cache.AllocENI(false, nil, subnetID, 49)
+	_, err = cache.AllocENI(false, nil, []string{subnetID}, 49)
 	assert.NoError(t, err)
 }
 
@@ -683,7 +773,7 @@ func TestAllocENIWithIPAddressesAlreadyFull(t *testing.T) {
 		instanceType:       "t3.xlarge",
 		useSubnetDiscovery: true,
 	}
-	_, err := cache.AllocENI(true, nil, "", 14)
+	_, err := cache.AllocENI(true, nil, nil, 14)
 	assert.Error(t, err)
 }
 
@@ -737,7 +827,7 @@ func TestAllocENIWithPrefixAddresses(t *testing.T) {
 		enablePrefixDelegation: true,
 		useSubnetDiscovery:     true,
 	}
-	_, err := cache.AllocENI(false, nil, subnetID, 1)
+	_, err := cache.AllocENI(false, nil, []string{subnetID}, 1)
 	assert.NoError(t, err)
 }
 
@@ -772,7 +862,101 @@ func TestAllocENIWithPrefixesAlreadyFull(t *testing.T) {
 		enablePrefixDelegation: true,
 		useSubnetDiscovery:     true,
 	}
-	_, err := cache.AllocENI(true, nil, "", 1)
+	_, err := cache.AllocENI(true, nil, nil, 1)
+	assert.Error(t, err)
+}
+
+func TestAllocENIIPv6CustomNetworking(t *testing.T) {
+	ctrl, mockEC2 := setup(t)
+	defer ctrl.Finish()
+
+	mockMetadata := testMetadata(nil)
+
+	customSubnetID := "subnet-6b245523"
+	customSG := "sg-2e080f50"
+	currentEniID := eniID
+	eni := ec2.CreateNetworkInterfaceOutput{NetworkInterface: &ec2types.NetworkInterface{NetworkInterfaceId: &currentEniID}}
+	mockEC2.EXPECT().CreateNetworkInterface(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *ec2.CreateNetworkInterfaceInput, _ ...func(*ec2.Options)) (*ec2.CreateNetworkInterfaceOutput, error) {
+			// The ENI gets its own IPv6 address in the ENIConfig subnet, its prefix is assigned after attaching
+			assert.Equal(t, customSubnetID, aws.ToString(input.SubnetId))
+			assert.Equal(t, []string{customSG}, input.Groups)
+			assert.Equal(t, int32(1), aws.ToInt32(input.Ipv6AddressCount))
+			assert.Nil(t, input.Ipv4PrefixCount)
+			assert.Nil(t, input.SecondaryPrivateIpAddressCount)
+			return &eni, nil
+		})
+
+	ec2ENIs := make([]ec2types.InstanceNetworkInterface, 0)
+	deviceNum1 := int32(0)
+	ec2ENI := ec2types.InstanceNetworkInterface{Attachment: &ec2types.InstanceNetworkInterfaceAttachment{DeviceIndex: &deviceNum1}}
+	ec2ENIs = append(ec2ENIs, ec2ENI)
+
+	result := &ec2.DescribeInstancesOutput{
+		Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{{NetworkInterfaces: ec2ENIs}}}}}
+	mockEC2.EXPECT().DescribeInstances(gomock.Any(), gomock.Any(), gomock.Any()).Return(result, nil)
+	attachmentID := "eni-attach-58ddda9d"
+	attachResult := &ec2.AttachNetworkInterfaceOutput{
+		AttachmentId: &attachmentID}
+	mockEC2.EXPECT().AttachNetworkInterface(gomock.Any(), gomock.Any(), gomock.Any()).Return(attachResult, nil)
+	mockEC2.EXPECT().ModifyNetworkInterfaceAttribute(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	cache := &EC2InstanceMetadataCache{
+		ec2SVC:                 mockEC2,
+		imds:                   TypedIMDS{mockMetadata},
+		instanceType:           "c5n.18xlarge",
+		enablePrefixDelegation: true,
+		useCustomNetworking:    true,
+		v6Enabled:              true,
+	}
+	_, err := cache.AllocENI(true, []*string{aws.String(customSG)}, []string{customSubnetID}, 1)
+	assert.NoError(t, err)
+}
+
+func TestAllocENICustomNetworkingSubnetFallback(t *testing.T) {
+	ctrl, mockEC2 := setup(t)
+	defer ctrl.Finish()
+
+	mockMetadata := testMetadata(nil)
+
+	currentEniID := eniID
+	eni := ec2.CreateNetworkInterfaceOutput{NetworkInterface: &ec2types.NetworkInterface{NetworkInterfaceId: &currentEniID}}
+	exhausted := &smithy.GenericAPIError{Code: "InsufficientFreeAddressesInSubnet", Message: "Insufficient free IP addresses"}
+	var triedSubnets []string
+	mockEC2.EXPECT().CreateNetworkInterface(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+		func(_ context.Context, input *ec2.CreateNetworkInterfaceInput, _ ...func(*ec2.Options)) (*ec2.CreateNetworkInterfaceOutput, error) {
+			triedSubnets = append(triedSubnets, aws.ToString(input.SubnetId))
+			if aws.ToString(input.SubnetId) == "subnet-full" {
+				return nil, exhausted
+			}
+			return &eni, nil
+		})
+
+	ec2ENIs := []ec2types.InstanceNetworkInterface{
+		{Attachment: &ec2types.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int32(0)}}}
+	result := &ec2.DescribeInstancesOutput{
+		Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{{NetworkInterfaces: ec2ENIs}}}}}
+	mockEC2.EXPECT().DescribeInstances(gomock.Any(), gomock.Any(), gomock.Any()).Return(result, nil)
+	attachResult := &ec2.AttachNetworkInterfaceOutput{AttachmentId: aws.String("eni-attach-58ddda9d")}
+	mockEC2.EXPECT().AttachNetworkInterface(gomock.Any(), gomock.Any(), gomock.Any()).Return(attachResult, nil)
+	mockEC2.EXPECT().ModifyNetworkInterfaceAttribute(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	cache := &EC2InstanceMetadataCache{
+		ec2SVC:              mockEC2,
+		imds:                TypedIMDS{mockMetadata},
+		instanceType:        "t3.xlarge",
+		useCustomNetworking: true,
+	}
+	_, err := cache.AllocENI(true, nil, []string{"subnet-full", "subnet-free", "subnet-unused"}, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"subnet-full", "subnet-free"}, triedSubnets)
+	assert.Equal(t, 1.0, testutil.ToFloat64(prometheusmetrics.CustomNetworkingSubnet.WithLabelValues("subnet-free")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(prometheusmetrics.CustomNetworkingSubnet.WithLabelValues("subnet-full")))
+
+	// Other errors do not fall through to the next subnet
+	mockEC2.EXPECT().CreateNetworkInterface(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, &smithy.GenericAPIError{Code: "UnauthorizedOperation"})
+	_, err = cache.AllocENI(true, nil, []string{"subnet-a", "subnet-b"}, 5)
 	assert.Error(t, err)
 }
 
@@ -985,6 +1169,37 @@ func TestAllocIPAddressesAlreadyFull(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestAllocSpecificIPAddress(t *testing.T) {
+	ctrl, mockEC2 := setup(t)
+	defer ctrl.Finish()
+
+	input := &ec2.AssignPrivateIpAddressesInput{
+		NetworkInterfaceId: aws.String(eniID),
+		PrivateIpAddresses: []string{"10.0.1.20"},
+	}
+	mockEC2.EXPECT().AssignPrivateIpAddresses(gomock.Any(), input, gomock.Any()).Return(&ec2.AssignPrivateIpAddressesOutput{}, nil)
+
+	cache := &EC2InstanceMetadataCache{ec2SVC: mockEC2, instanceType: "c5n.18xlarge"}
+	_, err := cache.AllocSpecificIPAddress(eniID, net.ParseIP("10.0.1.20"))
+	assert.NoError(t, err)
+
+	// With prefix delegation the /28 holding the address is allocated
+	input = &ec2.AssignPrivateIpAddressesInput{
+		NetworkInterfaceId: aws.String(eniID),
+		Ipv4Prefixes:       []string{"10.0.1.16/28"},
+	}
+	mockEC2.EXPECT().AssignPrivateIpAddresses(gomock.Any(), input, gomock.Any()).Return(&ec2.AssignPrivateIpAddressesOutput{}, nil)
+
+	cache = &EC2InstanceMetadataCache{ec2SVC: mockEC2, instanceType: "c5n.18xlarge", enablePrefixDelegation: true}
+	_, err = cache.AllocSpecificIPAddress(eniID, net.ParseIP("10.0.1.20"))
+	assert.NoError(t, err)
+
+	retErr := &smithy.GenericAPIError{Code: "InvalidParameterValue", Message: "Address is in use"}
+	mockEC2.EXPECT().AssignPrivateIpAddresses(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, retErr)
+	_, err = cache.AllocSpecificIPAddress(eniID, net.ParseIP("10.0.1.20"))
+	assert.Error(t, err)
+}
+
 func TestAllocPrefixAddresses(t *testing.T) {
 	ctrl, mockEC2 := setup(t)
 	defer ctrl.Finish()
@@ -1334,10 +1549,11 @@ func TestEC2InstanceMetadataCache_getLeakedENIs(t *testing.T) {
 		describeNetworkInterfacePagesCalls []describeNetworkInterfacePagesCall
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		want    []ec2types.NetworkInterface
-		wantErr error
+		name         string
+		fields       fields
+		want         []ec2types.NetworkInterface
+		wantTooYoung int
+		wantErr      error
 	}{
 		{
 			name: "without clusterName - no leaked ENIs",
@@ -1533,7 +1749,8 @@ func TestEC2InstanceMetadataCache_getLeakedENIs(t *testing.T) {
 					},
 				},
 			},
-			want: nil,
+			want:         nil,
+			wantTooYoung: 1,
 		},
 		{
 			name: "without clusterName - no leaked ENIs",
@@ -1757,7 +1974,8 @@ func TestEC2InstanceMetadataCache_getLeakedENIs(t *testing.T) {
 					},
 				},
 			},
-			want: nil,
+			want:         nil,
+			wantTooYoung: 1,
 		},
 	}
 	for _, tt := range tests {
@@ -1782,12 +2000,13 @@ func TestEC2InstanceMetadataCache_getLeakedENIs(t *testing.T) {
 					})
 			}
 			cache := &EC2InstanceMetadataCache{ec2SVC: mockEC2, clusterName: tt.fields.clusterName, vpcID: vpcID}
-			got, err := cache.getLeakedENIs()
+			got, tooYoung, err := cache.getLeakedENIs()
 			if tt.wantErr != nil {
 				assert.EqualError(t, err, tt.wantErr.Error())
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.want, got)
+				assert.Len(t, tooYoung, tt.wantTooYoung)
 			}
 		})
 	}
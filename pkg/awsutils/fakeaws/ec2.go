@@ -0,0 +1,863 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fakeaws
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/vpc"
+)
+
+const ec2XMLNamespace = "http://ec2.amazonaws.com/doc/2016-11-15/"
+
+type ec2Action func(s *Server, form url.Values) (interface{}, *apiError)
+
+// ec2Actions are the EC2 query API actions served by the fake. Every other action fails with InvalidAction.
+var ec2Actions = map[string]ec2Action{
+	"AssignPrivateIpAddresses":        (*Server).assignPrivateIPAddresses,
+	"AttachNetworkInterface":          (*Server).attachNetworkInterface,
+	"CreateNetworkInterface":          (*Server).createNetworkInterface,
+	"CreateTags":                      (*Server).createTags,
+	"DeleteNetworkInterface":          (*Server).deleteNetworkInterface,
+	"DescribeInstanceTypes":           (*Server).describeInstanceTypes,
+	"DescribeInstances":               (*Server).describeInstances,
+	"DescribeNetworkInterfaces":       (*Server).describeNetworkInterfaces,
+	"DescribeSecurityGroups":          (*Server).describeSecurityGroups,
+	"DescribeSubnets":                 (*Server).describeSubnets,
+	"DetachNetworkInterface":          (*Server).detachNetworkInterface,
+	"ModifyNetworkInterfaceAttribute": (*Server).modifyNetworkInterfaceAttribute,
+	"UnassignPrivateIpAddresses":      (*Server).unassignPrivateIPAddresses,
+}
+
+func (s *Server) serveEC2(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeEC2Error(w, newAPIError(ErrCodeInvalidParameterValue, "Malformed request: %v", err))
+		return
+	}
+	action := r.Form.Get("Action")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[action]++
+	handler, ok := ec2Actions[action]
+	if !ok {
+		writeEC2Error(w, newAPIError(ErrCodeInvalidAction, "The action %s is not valid for this web service.", action))
+		return
+	}
+	if apiErr := s.injectedError(action); apiErr != nil {
+		writeEC2Error(w, apiErr)
+		return
+	}
+	resp, apiErr := handler(s, r.Form)
+	if apiErr != nil {
+		writeEC2Error(w, apiErr)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml;charset=UTF-8")
+	_, _ = w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	start := xml.StartElement{
+		Name: xml.Name{Local: action + "Response"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: ec2XMLNamespace}},
+	}
+	_ = enc.EncodeElement(resp, start)
+}
+
+type xmlErrorResponse struct {
+	XMLName   xml.Name   `xml:"Response"`
+	Errors    []xmlError `xml:"Errors>Error"`
+	RequestID string     `xml:"RequestID"`
+}
+
+type xmlError struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func writeEC2Error(w http.ResponseWriter, apiErr *apiError) {
+	w.Header().Set("Content-Type", "text/xml;charset=UTF-8")
+	w.WriteHeader(apiErr.status())
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(xmlErrorResponse{
+		Errors:    []xmlError{{Code: apiErr.Code, Message: apiErr.Message}},
+		RequestID: "00000000-0000-0000-0000-000000000000",
+	})
+}
+
+// Response documents. Only the fields that the CNI reads are filled in.
+
+type xmlTag struct {
+	Key   string `xml:"key"`
+	Value string `xml:"value"`
+}
+
+type xmlGroup struct {
+	GroupID string `xml:"groupId"`
+}
+
+type xmlPrivateIP struct {
+	PrivateIPAddress string `xml:"privateIpAddress"`
+	Primary          bool   `xml:"primary"`
+}
+
+type xmlIPv4Prefix struct {
+	IPv4Prefix string `xml:"ipv4Prefix"`
+}
+
+type xmlAttachment struct {
+	AttachmentID        string `xml:"attachmentId"`
+	DeleteOnTermination bool   `xml:"deleteOnTermination"`
+	DeviceIndex         int    `xml:"deviceIndex"`
+	InstanceID          string `xml:"instanceId"`
+	NetworkCardIndex    int    `xml:"networkCardIndex"`
+	Status              string `xml:"status"`
+}
+
+type xmlNetworkInterface struct {
+	NetworkInterfaceID string          `xml:"networkInterfaceId"`
+	SubnetID           string          `xml:"subnetId"`
+	VPCID              string          `xml:"vpcId"`
+	AvailabilityZone   string          `xml:"availabilityZone"`
+	Description        string          `xml:"description"`
+	Groups             []xmlGroup      `xml:"groupSet>item"`
+	InterfaceType      string          `xml:"interfaceType"`
+	MACAddress         string          `xml:"macAddress"`
+	PrivateIPAddress   string          `xml:"privateIpAddress"`
+	PrivateIPAddresses []xmlPrivateIP  `xml:"privateIpAddressesSet>item"`
+	IPv4Prefixes       []xmlIPv4Prefix `xml:"ipv4PrefixSet>item"`
+	Status             string          `xml:"status"`
+	Attachment         *xmlAttachment  `xml:"attachment"`
+	Tags               []xmlTag        `xml:"tagSet>item"`
+}
+
+func (s *Server) xmlENI(e *eni) xmlNetworkInterface {
+	x := xmlNetworkInterface{
+		NetworkInterfaceID: e.id,
+		SubnetID:           e.subnet.cfg.ID,
+		VPCID:              s.cfg.VPCID,
+		AvailabilityZone:   e.subnet.cfg.AvailabilityZone,
+		Description:        e.description,
+		InterfaceType:      "interface",
+		MACAddress:         e.mac,
+		PrivateIPAddress:   e.primaryIP.String(),
+		PrivateIPAddresses: []xmlPrivateIP{{PrivateIPAddress: e.primaryIP.String(), Primary: true}},
+		Status:             e.status(),
+	}
+	for _, g := range e.groups {
+		x.Groups = append(x.Groups, xmlGroup{GroupID: g})
+	}
+	for _, ip := range e.secondaryIPs {
+		x.PrivateIPAddresses = append(x.PrivateIPAddresses, xmlPrivateIP{PrivateIPAddress: ip.String()})
+	}
+	for _, p := range e.prefixes {
+		x.IPv4Prefixes = append(x.IPv4Prefixes, xmlIPv4Prefix{IPv4Prefix: p.String()})
+	}
+	for _, k := range e.tagOrder {
+		x.Tags = append(x.Tags, xmlTag{Key: k, Value: e.tags[k]})
+	}
+	if e.attachment != nil {
+		x.Attachment = &xmlAttachment{
+			AttachmentID:        e.attachment.id,
+			DeleteOnTermination: e.attachment.deleteOnTermination,
+			DeviceIndex:         e.attachment.deviceIndex,
+			InstanceID:          s.cfg.InstanceID,
+			NetworkCardIndex:    e.attachment.networkCardIndex,
+			Status:              "attached",
+		}
+	}
+	return x
+}
+
+type xmlReturn struct {
+	Return bool `xml:"return"`
+}
+
+func (s *Server) createNetworkInterface(form url.Values) (interface{}, *apiError) {
+	sub := s.subnet(form.Get("SubnetId"))
+	if sub == nil {
+		return nil, newAPIError(ErrCodeInvalidSubnetIDNotFound, "The subnet ID '%s' does not exist", form.Get("SubnetId"))
+	}
+	numIPs, apiErr := intParam(form, "SecondaryPrivateIpAddressCount")
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	numPrefixes, apiErr := intParam(form, "Ipv4PrefixCount")
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if 1+numIPs+numPrefixes > s.limits.IPv4Limit {
+		return nil, newAPIError(ErrCodePrivateIPAddressLimit, "Number of private addresses will exceed limit of %d for %s",
+			s.limits.IPv4Limit, s.cfg.InstanceType)
+	}
+	var tags [][2]string
+	for i := 1; form.Has(fmt.Sprintf("TagSpecification.%d.ResourceType", i)); i++ {
+		if form.Get(fmt.Sprintf("TagSpecification.%d.ResourceType", i)) != "network-interface" {
+			continue
+		}
+		tags = append(tags, tagParams(form, fmt.Sprintf("TagSpecification.%d.Tag", i))...)
+	}
+	e, apiErr := s.createENI(sub, form.Get("Description"), listParam(form, "SecurityGroupId"), tags)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := s.assign(e, numIPs, numPrefixes, nil); apiErr != nil {
+		s.deleteENI(e)
+		return nil, apiErr
+	}
+	return struct {
+		NetworkInterface xmlNetworkInterface `xml:"networkInterface"`
+	}{s.xmlENI(e)}, nil
+}
+
+func (s *Server) attachNetworkInterface(form url.Values) (interface{}, *apiError) {
+	e, apiErr := s.lookupENI(form.Get("NetworkInterfaceId"))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if form.Get("InstanceId") != s.cfg.InstanceID {
+		return nil, newAPIError(ErrCodeInvalidInstanceIDNotFound, "The instance ID '%s' does not exist", form.Get("InstanceId"))
+	}
+	if !form.Has("DeviceIndex") {
+		return nil, newAPIError(ErrCodeInvalidParameterValue, "DeviceIndex is required")
+	}
+	deviceIndex, apiErr := intParam(form, "DeviceIndex")
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	networkCardIndex, apiErr := intParam(form, "NetworkCardIndex")
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if e.subnet.cfg.AvailabilityZone != s.cfg.AvailabilityZone {
+		return nil, newAPIError(ErrCodeInvalidParameterValue, "Interface %s is in %s and the instance is in %s",
+			e.id, e.subnet.cfg.AvailabilityZone, s.cfg.AvailabilityZone)
+	}
+	if apiErr := s.attachENI(e, deviceIndex, networkCardIndex); apiErr != nil {
+		return nil, apiErr
+	}
+	return struct {
+		AttachmentID     string `xml:"attachmentId"`
+		NetworkCardIndex int    `xml:"networkCardIndex"`
+	}{e.attachment.id, networkCardIndex}, nil
+}
+
+func (s *Server) detachNetworkInterface(form url.Values) (interface{}, *apiError) {
+	id := form.Get("AttachmentId")
+	for _, e := range s.enis {
+		if e.attachment == nil || e.attachment.id != id {
+			continue
+		}
+		if apiErr := s.detachENI(e); apiErr != nil {
+			return nil, apiErr
+		}
+		return xmlReturn{true}, nil
+	}
+	return nil, newAPIError(ErrCodeInvalidAttachmentIDNotFound, "The attachment ID '%s' does not exist", id)
+}
+
+func (s *Server) deleteNetworkInterface(form url.Values) (interface{}, *apiError) {
+	e, apiErr := s.lookupENI(form.Get("NetworkInterfaceId"))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if e.attachment != nil {
+		return nil, newAPIError(ErrCodeInvalidENIInUse, "The network interface '%s' is currently in use.", e.id)
+	}
+	s.deleteENI(e)
+	return xmlReturn{true}, nil
+}
+
+func (s *Server) modifyNetworkInterfaceAttribute(form url.Values) (interface{}, *apiError) {
+	e, apiErr := s.lookupENI(form.Get("NetworkInterfaceId"))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if form.Has("Attachment.AttachmentId") {
+		if e.attachment == nil || e.attachment.id != form.Get("Attachment.AttachmentId") {
+			return nil, newAPIError(ErrCodeInvalidAttachmentIDNotFound, "The attachment ID '%s' does not exist",
+				form.Get("Attachment.AttachmentId"))
+		}
+		e.attachment.deleteOnTermination = form.Get("Attachment.DeleteOnTermination") == "true"
+	}
+	if groups := listParam(form, "SecurityGroupId"); len(groups) > 0 {
+		e.groups = groups
+	}
+	return xmlReturn{true}, nil
+}
+
+func (s *Server) assignPrivateIPAddresses(form url.Values) (interface{}, *apiError) {
+	e, apiErr := s.lookupENI(form.Get("NetworkInterfaceId"))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	numIPs, apiErr := intParam(form, "SecondaryPrivateIpAddressCount")
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	numPrefixes, apiErr := intParam(form, "Ipv4PrefixCount")
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	ips, prefixes, apiErr := parseAddresses(listParam(form, "PrivateIpAddress"), listParam(form, "Ipv4Prefix"))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if numIPs == 0 && numPrefixes == 0 && len(ips) == 0 && len(prefixes) == 0 {
+		return nil, newAPIError(ErrCodeInvalidParameterValue, "No addresses or prefixes requested")
+	}
+	ipsBefore, prefixesBefore := len(e.secondaryIPs), len(e.prefixes)
+	if apiErr := s.assign(e, numIPs, numPrefixes, ips, prefixes...); apiErr != nil {
+		return nil, apiErr
+	}
+	resp := struct {
+		NetworkInterfaceID string          `xml:"networkInterfaceId"`
+		AssignedIPs        []xmlPrivateIP  `xml:"assignedPrivateIpAddressesSet>item"`
+		AssignedPrefixes   []xmlIPv4Prefix `xml:"assignedIpv4PrefixSet>item"`
+	}{NetworkInterfaceID: e.id}
+	for _, ip := range e.secondaryIPs[ipsBefore:] {
+		resp.AssignedIPs = append(resp.AssignedIPs, xmlPrivateIP{PrivateIPAddress: ip.String()})
+	}
+	for _, p := range e.prefixes[prefixesBefore:] {
+		resp.AssignedPrefixes = append(resp.AssignedPrefixes, xmlIPv4Prefix{IPv4Prefix: p.String()})
+	}
+	return resp, nil
+}
+
+// assign adds numIPs and numPrefixes allocated addresses and prefixes plus the given ones to e. Either all of them
+// are assigned or none are.
+func (s *Server) assign(e *eni, numIPs, numPrefixes int, ips []net.IP, prefixes ...*net.IPNet) *apiError {
+	if apiErr := s.checkAddressLimit(e, numIPs+numPrefixes+len(ips)+len(prefixes)); apiErr != nil {
+		return apiErr
+	}
+	ipsBefore, prefixesBefore := len(e.secondaryIPs), len(e.prefixes)
+	rollback := func() {
+		for _, ip := range e.secondaryIPs[ipsBefore:] {
+			e.subnet.releaseIP(ip)
+		}
+		for _, p := range e.prefixes[prefixesBefore:] {
+			e.subnet.releasePrefix(p)
+		}
+		e.secondaryIPs = e.secondaryIPs[:ipsBefore]
+		e.prefixes = e.prefixes[:prefixesBefore]
+	}
+	for _, ip := range ips {
+		if apiErr := e.subnet.takeIP(ip); apiErr != nil {
+			rollback()
+			return apiErr
+		}
+		e.secondaryIPs = append(e.secondaryIPs, ip)
+	}
+	for _, prefix := range prefixes {
+		if apiErr := e.subnet.takePrefix(prefix); apiErr != nil {
+			rollback()
+			return apiErr
+		}
+		e.prefixes = append(e.prefixes, prefix)
+	}
+	for i := 0; i < numIPs; i++ {
+		ip, ok := e.subnet.allocateIP()
+		if !ok {
+			rollback()
+			return insufficientAddresses(e.subnet)
+		}
+		e.secondaryIPs = append(e.secondaryIPs, ip)
+	}
+	for i := 0; i < numPrefixes; i++ {
+		prefix, ok := e.subnet.allocatePrefix()
+		if !ok {
+			rollback()
+			return newAPIError(ErrCodeInsufficientCidrBlocks, "The specified subnet does not have enough free cidr blocks to satisfy the request.")
+		}
+		e.prefixes = append(e.prefixes, prefix)
+	}
+	return nil
+}
+
+func (s *Server) unassignPrivateIPAddresses(form url.Values) (interface{}, *apiError) {
+	e, apiErr := s.lookupENI(form.Get("NetworkInterfaceId"))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := s.unassign(e, listParam(form, "PrivateIpAddress"), listParam(form, "Ipv4Prefix")); apiErr != nil {
+		return nil, apiErr
+	}
+	return xmlReturn{true}, nil
+}
+
+// unassign removes the given secondary IPs and prefixes from e and releases them. Nothing is removed if any of them
+// is not assigned to e.
+func (s *Server) unassign(e *eni, addrs, cidrs []string) *apiError {
+	ips := make(map[string]bool)
+	for _, addr := range addrs {
+		ips[addr] = true
+	}
+	prefixes := make(map[string]bool)
+	for _, p := range cidrs {
+		prefixes[p] = true
+	}
+	if ips[e.primaryIP.String()] {
+		return newAPIError(ErrCodeInvalidParameterValue, "The primary address of interface %s cannot be unassigned", e.id)
+	}
+	var keepIPs []net.IP
+	for _, ip := range e.secondaryIPs {
+		if ips[ip.String()] {
+			delete(ips, ip.String())
+			continue
+		}
+		keepIPs = append(keepIPs, ip)
+	}
+	var keepPrefixes []*net.IPNet
+	for _, p := range e.prefixes {
+		if prefixes[p.String()] {
+			delete(prefixes, p.String())
+			continue
+		}
+		keepPrefixes = append(keepPrefixes, p)
+	}
+	if len(ips) > 0 || len(prefixes) > 0 {
+		return newAPIError(ErrCodeInvalidParameterValue, "Some of the specified addresses are not assigned to interface %s", e.id)
+	}
+	for _, ip := range e.secondaryIPs {
+		if !containsIP(keepIPs, ip) {
+			e.subnet.releaseIP(ip)
+		}
+	}
+	for _, p := range e.prefixes {
+		if !containsPrefix(keepPrefixes, p) {
+			e.subnet.releasePrefix(p)
+		}
+	}
+	e.secondaryIPs, e.prefixes = keepIPs, keepPrefixes
+	return nil
+}
+
+func (s *Server) describeNetworkInterfaces(form url.Values) (interface{}, *apiError) {
+	ids := listParam(form, "NetworkInterfaceId")
+	for _, id := range ids {
+		if _, ok := s.enis[id]; !ok {
+			return nil, newAPIError(ErrCodeInvalidENIIDNotFound, "The networkInterface ID '%s' does not exist", id)
+		}
+	}
+	filters := filterParams(form)
+	var matched []xmlNetworkInterface
+	for _, e := range s.sortedENIs() {
+		if len(ids) > 0 && !contains(ids, e.id) {
+			continue
+		}
+		ok, apiErr := filters.match(func(name string) ([]string, bool) { return s.eniFilterValues(e, name) })
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if ok {
+			matched = append(matched, s.xmlENI(e))
+		}
+	}
+	page, next, apiErr := paginate(form, len(matched))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return struct {
+		NetworkInterfaces []xmlNetworkInterface `xml:"networkInterfaceSet>item"`
+		NextToken         string                `xml:"nextToken,omitempty"`
+	}{matched[page[0]:page[1]], next}, nil
+}
+
+// eniFilterValues returns the values of e that a DescribeNetworkInterfaces filter is matched against
+func (s *Server) eniFilterValues(e *eni, name string) ([]string, bool) {
+	switch name {
+	case "network-interface-id":
+		return []string{e.id}, true
+	case "subnet-id":
+		return []string{e.subnet.cfg.ID}, true
+	case "vpc-id":
+		return []string{s.cfg.VPCID}, true
+	case "availability-zone":
+		return []string{e.subnet.cfg.AvailabilityZone}, true
+	case "status":
+		return []string{e.status()}, true
+	case "description":
+		return []string{e.description}, true
+	case "attachment.instance-id":
+		if e.attachment == nil {
+			return nil, true
+		}
+		return []string{s.cfg.InstanceID}, true
+	}
+	return tagFilterValues(e.tags, name)
+}
+
+func (s *Server) describeInstances(form url.Values) (interface{}, *apiError) {
+	for _, id := range listParam(form, "InstanceId") {
+		if id != s.cfg.InstanceID {
+			return nil, newAPIError(ErrCodeInvalidInstanceIDNotFound, "The instance ID '%s' does not exist", id)
+		}
+	}
+	type xmlInstance struct {
+		InstanceID        string                `xml:"instanceId"`
+		InstanceType      string                `xml:"instanceType"`
+		AvailabilityZone  string                `xml:"placement>availabilityZone"`
+		PrivateIPAddress  string                `xml:"privateIpAddress"`
+		SubnetID          string                `xml:"subnetId"`
+		VPCID             string                `xml:"vpcId"`
+		StateName         string                `xml:"instanceState>name"`
+		NetworkInterfaces []xmlNetworkInterface `xml:"networkInterfaceSet>item"`
+	}
+	type xmlReservation struct {
+		ReservationID string        `xml:"reservationId"`
+		Instances     []xmlInstance `xml:"instancesSet>item"`
+	}
+	primary := s.attachedENIs()[0]
+	instance := xmlInstance{
+		InstanceID:       s.cfg.InstanceID,
+		InstanceType:     s.cfg.InstanceType,
+		AvailabilityZone: s.cfg.AvailabilityZone,
+		PrivateIPAddress: primary.primaryIP.String(),
+		SubnetID:         primary.subnet.cfg.ID,
+		VPCID:            s.cfg.VPCID,
+		StateName:        "running",
+	}
+	for _, e := range s.attachedENIs() {
+		instance.NetworkInterfaces = append(instance.NetworkInterfaces, s.xmlENI(e))
+	}
+	return struct {
+		Reservations []xmlReservation `xml:"reservationSet>item"`
+	}{[]xmlReservation{{ReservationID: "r-0fa4e1c0ffee00001", Instances: []xmlInstance{instance}}}}, nil
+}
+
+func (s *Server) describeInstanceTypes(form url.Values) (interface{}, *apiError) {
+	type xmlNetworkCard struct {
+		NetworkCardIndex         int64 `xml:"networkCardIndex"`
+		MaximumNetworkInterfaces int64 `xml:"maximumNetworkInterfaces"`
+	}
+	type xmlInstanceType struct {
+		InstanceType             string           `xml:"instanceType"`
+		Hypervisor               string           `xml:"hypervisor,omitempty"`
+		BareMetal                bool             `xml:"bareMetal"`
+		MaximumNetworkInterfaces int              `xml:"networkInfo>maximumNetworkInterfaces"`
+		IPv4AddressesPerENI      int              `xml:"networkInfo>ipv4AddressesPerInterface"`
+		DefaultNetworkCardIndex  int              `xml:"networkInfo>defaultNetworkCardIndex"`
+		NetworkCards             []xmlNetworkCard `xml:"networkInfo>networkCards>item"`
+	}
+	var types []xmlInstanceType
+	for _, name := range listParam(form, "InstanceType") {
+		limits, ok := vpc.GetInstance(name)
+		if !ok {
+			return nil, newAPIError(ErrCodeInvalidInstanceType, "The following supplied instance types do not exist: [%s]", name)
+		}
+		t := xmlInstanceType{
+			InstanceType:             name,
+			Hypervisor:               limits.HypervisorType,
+			BareMetal:                limits.IsBareMetal,
+			MaximumNetworkInterfaces: limits.ENILimit,
+			IPv4AddressesPerENI:      limits.IPv4Limit,
+			DefaultNetworkCardIndex:  limits.DefaultNetworkCardIndex,
+		}
+		for _, card := range limits.NetworkCards {
+			t.NetworkCards = append(t.NetworkCards, xmlNetworkCard{card.NetworkCardIndex, card.MaximumNetworkInterfaces})
+		}
+		types = append(types, t)
+	}
+	return struct {
+		InstanceTypes []xmlInstanceType `xml:"instanceTypeSet>item"`
+	}{types}, nil
+}
+
+func (s *Server) describeSubnets(form url.Values) (interface{}, *apiError) {
+	type xmlSubnet struct {
+		SubnetID                string   `xml:"subnetId"`
+		VPCID                   string   `xml:"vpcId"`
+		CIDRBlock               string   `xml:"cidrBlock"`
+		AvailabilityZone        string   `xml:"availabilityZone"`
+		AvailableIPAddressCount int      `xml:"availableIpAddressCount"`
+		State                   string   `xml:"state"`
+		Tags                    []xmlTag `xml:"tagSet>item"`
+	}
+	ids := listParam(form, "SubnetId")
+	for _, id := range ids {
+		if s.subnet(id) == nil {
+			return nil, newAPIError(ErrCodeInvalidSubnetIDNotFound, "The subnet ID '%s' does not exist", id)
+		}
+	}
+	filters := filterParams(form)
+	var matched []xmlSubnet
+	for _, sub := range s.subnets {
+		if len(ids) > 0 && !contains(ids, sub.cfg.ID) {
+			continue
+		}
+		ok, apiErr := filters.match(func(name string) ([]string, bool) {
+			switch name {
+			case "subnet-id":
+				return []string{sub.cfg.ID}, true
+			case "vpc-id":
+				return []string{s.cfg.VPCID}, true
+			case "availability-zone":
+				return []string{sub.cfg.AvailabilityZone}, true
+			case "cidr-block":
+				return []string{sub.cfg.CIDR}, true
+			}
+			return tagFilterValues(sub.cfg.Tags, name)
+		})
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if !ok {
+			continue
+		}
+		x := xmlSubnet{
+			SubnetID:                sub.cfg.ID,
+			VPCID:                   s.cfg.VPCID,
+			CIDRBlock:               sub.ipNet.String(),
+			AvailabilityZone:        sub.cfg.AvailabilityZone,
+			AvailableIPAddressCount: sub.free(),
+			State:                   "available",
+		}
+		for _, k := range sortedKeys(sub.cfg.Tags) {
+			x.Tags = append(x.Tags, xmlTag{Key: k, Value: sub.cfg.Tags[k]})
+		}
+		matched = append(matched, x)
+	}
+	return struct {
+		Subnets []xmlSubnet `xml:"subnetSet>item"`
+	}{matched}, nil
+}
+
+func (s *Server) describeSecurityGroups(form url.Values) (interface{}, *apiError) {
+	type xmlSecurityGroup struct {
+		GroupID   string `xml:"groupId"`
+		GroupName string `xml:"groupName"`
+		VPCID     string `xml:"vpcId"`
+	}
+	ids := listParam(form, "GroupId")
+	for _, id := range ids {
+		if !contains(s.cfg.SecurityGroups, id) {
+			return nil, newAPIError(ErrCodeInvalidGroupNotFound, "The security group '%s' does not exist", id)
+		}
+	}
+	var matched []xmlSecurityGroup
+	for _, id := range s.cfg.SecurityGroups {
+		if len(ids) > 0 && !contains(ids, id) {
+			continue
+		}
+		matched = append(matched, xmlSecurityGroup{GroupID: id, GroupName: id, VPCID: s.cfg.VPCID})
+	}
+	return struct {
+		SecurityGroups []xmlSecurityGroup `xml:"securityGroupInfo>item"`
+	}{matched}, nil
+}
+
+func (s *Server) createTags(form url.Values) (interface{}, *apiError) {
+	ids := listParam(form, "ResourceId")
+	tags := tagParams(form, "Tag")
+	for _, id := range ids {
+		if _, ok := s.enis[id]; ok {
+			continue
+		}
+		if s.subnet(id) != nil {
+			continue
+		}
+		if strings.HasPrefix(id, "subnet-") {
+			return nil, newAPIError(ErrCodeInvalidSubnetIDNotFound, "The subnet ID '%s' does not exist", id)
+		}
+		return nil, newAPIError(ErrCodeInvalidENIIDNotFound, "The networkInterface ID '%s' does not exist", id)
+	}
+	for _, id := range ids {
+		if e, ok := s.enis[id]; ok {
+			for _, kv := range tags {
+				e.setTag(kv[0], kv[1])
+			}
+			continue
+		}
+		sub := s.subnet(id)
+		if sub.cfg.Tags == nil {
+			sub.cfg.Tags = make(map[string]string)
+		}
+		for _, kv := range tags {
+			sub.cfg.Tags[kv[0]] = kv[1]
+		}
+	}
+	return xmlReturn{true}, nil
+}
+
+// parseAddresses parses the IPv4 addresses and /28 prefixes of an assign request
+func parseAddresses(addrs, cidrs []string) ([]net.IP, []*net.IPNet, *apiError) {
+	var ips []net.IP
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if ip == nil || ip.To4() == nil {
+			return nil, nil, newAPIError(ErrCodeInvalidParameterValue, "Invalid IPv4 address %s", addr)
+		}
+		ips = append(ips, ip)
+	}
+	var prefixes []*net.IPNet
+	for _, cidr := range cidrs {
+		ip, prefix, err := net.ParseCIDR(cidr)
+		if err != nil || ip.To4() == nil || !ip.Equal(prefix.IP) {
+			return nil, nil, newAPIError(ErrCodeInvalidParameterValue, "Invalid IPv4 prefix %s", cidr)
+		}
+		if ones, _ := prefix.Mask.Size(); ones != prefixLen {
+			return nil, nil, newAPIError(ErrCodeInvalidParameterValue, "Only /%d prefixes can be assigned, got %s", prefixLen, cidr)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return ips, prefixes, nil
+}
+
+func (s *Server) lookupENI(id string) (*eni, *apiError) {
+	e, ok := s.enis[id]
+	if !ok {
+		return nil, newAPIError(ErrCodeInvalidENIIDNotFound, "The networkInterface ID '%s' does not exist", id)
+	}
+	return e, nil
+}
+
+// Query parameter helpers. The EC2 query protocol flattens lists into Name.1, Name.2, ... and structures into
+// Name.Member.
+
+func listParam(form url.Values, name string) []string {
+	var values []string
+	for i := 1; form.Has(fmt.Sprintf("%s.%d", name, i)); i++ {
+		values = append(values, form.Get(fmt.Sprintf("%s.%d", name, i)))
+	}
+	return values
+}
+
+func intParam(form url.Values, name string) (int, *apiError) {
+	if !form.Has(name) {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(form.Get(name))
+	if err != nil || n < 0 {
+		return 0, newAPIError(ErrCodeInvalidParameterValue, "Invalid value '%s' for %s", form.Get(name), name)
+	}
+	return n, nil
+}
+
+func tagParams(form url.Values, name string) [][2]string {
+	var tags [][2]string
+	for i := 1; form.Has(fmt.Sprintf("%s.%d.Key", name, i)); i++ {
+		tags = append(tags, [2]string{form.Get(fmt.Sprintf("%s.%d.Key", name, i)), form.Get(fmt.Sprintf("%s.%d.Value", name, i))})
+	}
+	return tags
+}
+
+type filter struct {
+	name   string
+	values []string
+}
+
+type filterList []filter
+
+func filterParams(form url.Values) filterList {
+	var filters filterList
+	for i := 1; form.Has(fmt.Sprintf("Filter.%d.Name", i)); i++ {
+		filters = append(filters, filter{
+			name:   form.Get(fmt.Sprintf("Filter.%d.Name", i)),
+			values: listParam(form, fmt.Sprintf("Filter.%d.Value", i)),
+		})
+	}
+	return filters
+}
+
+// match reports whether a resource passes every filter. valuesOf returns the resource's values for a filter name,
+// and false for filter names the resource type does not support.
+func (filters filterList) match(valuesOf func(name string) ([]string, bool)) (bool, *apiError) {
+	for _, f := range filters {
+		values, ok := valuesOf(f.name)
+		if !ok {
+			return false, newAPIError(ErrCodeInvalidParameterValue, "The filter '%s' is invalid", f.name)
+		}
+		matched := false
+		for _, v := range values {
+			if len(f.values) == 0 || contains(f.values, v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func tagFilterValues(tags map[string]string, name string) ([]string, bool) {
+	if name == "tag-key" {
+		return sortedKeys(tags), true
+	}
+	if key := strings.TrimPrefix(name, "tag:"); key != name {
+		if v, ok := tags[key]; ok {
+			return []string{v}, true
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+// paginate returns the [start, end) window of n results selected by MaxResults and NextToken, and the token of
+// the next page
+func paginate(form url.Values, n int) ([2]int, string, *apiError) {
+	start := 0
+	if token := form.Get("NextToken"); token != "" {
+		var err error
+		start, err = strconv.Atoi(token)
+		if err != nil || start < 0 || start > n {
+			return [2]int{}, "", newAPIError(ErrCodeInvalidParameterValue, "Invalid NextToken %s", token)
+		}
+	}
+	maxResults, apiErr := intParam(form, "MaxResults")
+	if apiErr != nil {
+		return [2]int{}, "", apiErr
+	}
+	if maxResults == 0 || start+maxResults >= n {
+		return [2]int{start, n}, "", nil
+	}
+	return [2]int{start, start + maxResults}, strconv.Itoa(start + maxResults), nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsIP(list []net.IP, ip net.IP) bool {
+	for _, v := range list {
+		if v.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPrefix(list []*net.IPNet, p *net.IPNet) bool {
+	for _, v := range list {
+		if v.String() == p.String() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fakeaws
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	imdsTokenTTLHeader = "X-Aws-Ec2-Metadata-Token-Ttl-Seconds"
+	imdsToken          = "fake-imds-token"
+	// imdsRole is the IAM role of the fake instance. Its credentials are served by IMDS, so that the SDK credential
+	// chain resolves without any credentials on the host.
+	imdsRole = "fake-aws-node"
+)
+
+func (s *Server) serveIMDS(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/latest/api/token":
+		if r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ttl := r.Header.Get(imdsTokenTTLHeader)
+		if _, err := strconv.Atoi(ttl); err != nil {
+			http.Error(w, "invalid token TTL", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set(imdsTokenTTLHeader, ttl)
+		_, _ = w.Write([]byte(imdsToken))
+	case r.URL.Path == "/latest/dynamic/instance-identity/document":
+		s.mu.Lock()
+		doc := map[string]string{
+			"accountId":        "000000000000",
+			"availabilityZone": s.cfg.AvailabilityZone,
+			"instanceId":       s.cfg.InstanceID,
+			"instanceType":     s.cfg.InstanceType,
+			"privateIp":        s.attachedENIs()[0].primaryIP.String(),
+			"region":           s.cfg.Region,
+		}
+		s.mu.Unlock()
+		writeJSON(w, doc)
+	case strings.HasPrefix(r.URL.Path, "/latest/meta-data"):
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/latest/meta-data"), "/")
+		s.mu.Lock()
+		value, ok := s.metadata(path)
+		s.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(value))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// metadata returns the meta-data value at path, reflecting the current EC2 state of the instance.
+// Callers must hold s.mu.
+func (s *Server) metadata(path string) (string, bool) {
+	primary := s.attachedENIs()[0]
+	switch path {
+	case "":
+		return lines("instance-id", "instance-type", "local-ipv4", "mac", "network/", "placement/", "iam/"), true
+	case "instance-id":
+		return s.cfg.InstanceID, true
+	case "instance-type":
+		return s.cfg.InstanceType, true
+	case "local-ipv4":
+		return primary.primaryIP.String(), true
+	case "mac":
+		return primary.mac, true
+	case "placement/availability-zone":
+		return s.cfg.AvailabilityZone, true
+	case "placement/region":
+		return s.cfg.Region, true
+	case "iam/security-credentials":
+		return imdsRole, true
+	case "iam/security-credentials/" + imdsRole:
+		now := time.Now().UTC()
+		creds, _ := json.Marshal(map[string]string{
+			"Code":            "Success",
+			"LastUpdated":     now.Format(time.RFC3339),
+			"Type":            "AWS-HMAC",
+			"AccessKeyId":     "AKIAFAKEAWSNODE00000",
+			"SecretAccessKey": "fake-secret-access-key",
+			"Token":           "fake-session-token",
+			"Expiration":      now.Add(6 * time.Hour).Format(time.RFC3339),
+		})
+		return string(creds), true
+	case "network/interfaces/macs":
+		var macs []string
+		for _, e := range s.attachedENIs() {
+			macs = append(macs, e.mac+"/")
+		}
+		return lines(macs...), true
+	}
+
+	rest := strings.TrimPrefix(path, "network/interfaces/macs/")
+	if rest == path {
+		return "", false
+	}
+	mac, field, _ := strings.Cut(rest, "/")
+	e := s.eniByMAC(mac)
+	if e == nil {
+		return "", false
+	}
+	return s.interfaceMetadata(e, field)
+}
+
+// interfaceMetadata returns the network/interfaces/macs/<mac>/<field> value of an attached ENI
+func (s *Server) interfaceMetadata(e *eni, field string) (string, bool) {
+	switch field {
+	case "":
+		fields := []string{"device-number", "interface-id", "local-ipv4s", "mac", "network-card", "security-group-ids",
+			"subnet-id", "subnet-ipv4-cidr-block", "vpc-id", "vpc-ipv4-cidr-blocks"}
+		if len(e.prefixes) > 0 {
+			fields = append(fields, "ipv4-prefix")
+		}
+		sort.Strings(fields)
+		return lines(fields...), true
+	case "device-number":
+		return strconv.Itoa(e.attachment.deviceIndex), true
+	case "network-card":
+		return strconv.Itoa(e.attachment.networkCardIndex), true
+	case "interface-id":
+		return e.id, true
+	case "mac":
+		return e.mac, true
+	case "local-ipv4s":
+		ips := []string{e.primaryIP.String()}
+		for _, ip := range e.secondaryIPs {
+			ips = append(ips, ip.String())
+		}
+		return lines(ips...), true
+	case "ipv4-prefix":
+		if len(e.prefixes) == 0 {
+			return "", false
+		}
+		var prefixes []string
+		for _, p := range e.prefixes {
+			prefixes = append(prefixes, p.String())
+		}
+		return lines(prefixes...), true
+	case "security-group-ids":
+		return lines(e.groups...), true
+	case "subnet-id":
+		return e.subnet.cfg.ID, true
+	case "subnet-ipv4-cidr-block":
+		return e.subnet.ipNet.String(), true
+	case "vpc-id":
+		return s.cfg.VPCID, true
+	case "vpc-ipv4-cidr-blocks":
+		return s.vpcNet.String(), true
+	}
+	return "", false
+}
+
+func lines(items ...string) string {
+	return strings.Join(items, "\n")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,168 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fakeaws
+
+import (
+	"sort"
+
+	"github.com/aws/smithy-go"
+)
+
+// The methods below are in-process counterparts of the EC2 actions, for callers that build on the fake's state without
+// going through HTTP, like the IPAM simulator in pkg/ipamd/simulator. They change the same ENIs and subnets that the
+// EC2 and IMDS endpoints serve, but do not count calls or inject faults. Errors are smithy.APIError values that carry
+// the EC2 error code.
+
+// ENI returns a snapshot of an ENI
+func (s *Server) ENI(eniID string) (ENI, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, apiErr := s.lookupENI(eniID)
+	if apiErr != nil {
+		return ENI{}, apiErr.toAPIError()
+	}
+	return e.snapshot(), nil
+}
+
+// AttachedENIs returns a snapshot of the ENIs attached to the instance, ordered by network card and device index
+func (s *Server) AttachedENIs() []ENI {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var enis []ENI
+	for _, e := range s.attachedENIs() {
+		enis = append(enis, e.snapshot())
+	}
+	return enis
+}
+
+// CreateENI creates an ENI in a subnet, with the security groups of the instance
+func (s *Server) CreateENI(subnetID, description string, tags map[string]string) (ENI, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub := s.subnet(subnetID)
+	if sub == nil {
+		return ENI{}, newAPIError(ErrCodeInvalidSubnetIDNotFound, "The subnet ID '%s' does not exist", subnetID).toAPIError()
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var tagList [][2]string
+	for _, k := range keys {
+		tagList = append(tagList, [2]string{k, tags[k]})
+	}
+	e, apiErr := s.createENI(sub, description, s.cfg.SecurityGroups, tagList)
+	if apiErr != nil {
+		return ENI{}, apiErr.toAPIError()
+	}
+	return e.snapshot(), nil
+}
+
+// AttachENI attaches an ENI to the instance at the given device and network card index
+func (s *Server) AttachENI(eniID string, deviceIndex, networkCardIndex int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, apiErr := s.lookupENI(eniID)
+	if apiErr == nil {
+		apiErr = s.attachENI(e, deviceIndex, networkCardIndex)
+	}
+	return apiErr.toAPIError()
+}
+
+// DetachENI detaches an ENI from the instance
+func (s *Server) DetachENI(eniID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, apiErr := s.lookupENI(eniID)
+	if apiErr == nil {
+		apiErr = s.detachENI(e)
+	}
+	return apiErr.toAPIError()
+}
+
+// DeleteENI deletes a detached ENI and releases its addresses
+func (s *Server) DeleteENI(eniID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, apiErr := s.lookupENI(eniID)
+	if apiErr != nil {
+		return apiErr.toAPIError()
+	}
+	if e.attachment != nil {
+		return newAPIError(ErrCodeInvalidENIInUse, "The network interface '%s' is currently in use.", e.id).toAPIError()
+	}
+	s.deleteENI(e)
+	return nil
+}
+
+// TagENI adds tags to an ENI, replacing the value of existing keys
+func (s *Server) TagENI(eniID string, tags map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, apiErr := s.lookupENI(eniID)
+	if apiErr != nil {
+		return apiErr.toAPIError()
+	}
+	for k, v := range tags {
+		e.setTag(k, v)
+	}
+	return nil
+}
+
+// AssignAddresses assigns numIPs secondary IPs and numPrefixes /28 prefixes from the subnet of an ENI, plus the given
+// addresses and prefixes, all or nothing. It returns the addresses and prefixes that were assigned.
+func (s *Server) AssignAddresses(eniID string, numIPs, numPrefixes int, addrs, cidrs []string) ([]string, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, apiErr := s.lookupENI(eniID)
+	if apiErr != nil {
+		return nil, nil, apiErr.toAPIError()
+	}
+	ips, prefixes, apiErr := parseAddresses(addrs, cidrs)
+	if apiErr != nil {
+		return nil, nil, apiErr.toAPIError()
+	}
+	ipsBefore, prefixesBefore := len(e.secondaryIPs), len(e.prefixes)
+	if apiErr := s.assign(e, numIPs, numPrefixes, ips, prefixes...); apiErr != nil {
+		return nil, nil, apiErr.toAPIError()
+	}
+	var assignedIPs, assignedPrefixes []string
+	for _, ip := range e.secondaryIPs[ipsBefore:] {
+		assignedIPs = append(assignedIPs, ip.String())
+	}
+	for _, p := range e.prefixes[prefixesBefore:] {
+		assignedPrefixes = append(assignedPrefixes, p.String())
+	}
+	return assignedIPs, assignedPrefixes, nil
+}
+
+// UnassignAddresses removes secondary IPs and prefixes from an ENI, all or nothing
+func (s *Server) UnassignAddresses(eniID string, addrs, cidrs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, apiErr := s.lookupENI(eniID)
+	if apiErr == nil {
+		apiErr = s.unassign(e, addrs, cidrs)
+	}
+	return apiErr.toAPIError()
+}
+
+// toAPIError converts e to the error type the SDK returns for EC2 errors, and a nil e to a nil error
+func (e *apiError) toAPIError() error {
+	if e == nil {
+		return nil
+	}
+	return &smithy.GenericAPIError{Code: e.Code, Message: e.Message}
+}
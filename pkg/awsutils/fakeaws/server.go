@@ -0,0 +1,335 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package fakeaws is a local stand-in for the EC2 query API and the instance metadata service of a single instance.
+// It keeps ENI and IP state consistent between the two, applies the instance type limits from pkg/vpc, and can inject
+// API errors, so that an unmodified ipamd can be run against it by pointing AWS_EC2_ENDPOINT and
+// AWS_EC2_METADATA_SERVICE_ENDPOINT at the server.
+package fakeaws
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/vpc"
+)
+
+// Defaults used for unset Config fields
+const (
+	DefaultInstanceType     = "m5.large"
+	DefaultInstanceID       = "i-0fa4e1c0ffee00001"
+	DefaultRegion           = "us-west-2"
+	DefaultAvailabilityZone = "us-west-2a"
+	DefaultVPCID            = "vpc-0fa4e1c0ffee00001"
+	DefaultVPCCIDR          = "10.0.0.0/16"
+	DefaultSubnetID         = "subnet-0fa4e1c0ffee00001"
+	DefaultSubnetCIDR       = "10.0.0.0/19"
+	DefaultSecurityGroup    = "sg-0fa4e1c0ffee00001"
+)
+
+// EC2 error codes returned by the server
+const (
+	ErrCodeRequestLimitExceeded        = "RequestLimitExceeded"
+	ErrCodeInsufficientFreeAddresses   = "InsufficientFreeAddressesInSubnet"
+	ErrCodeInsufficientCidrBlocks      = "InsufficientCidrBlocks"
+	ErrCodePrivateIPAddressLimit       = "PrivateIpAddressLimitExceeded"
+	ErrCodeAttachmentLimitExceeded     = "AttachmentLimitExceeded"
+	ErrCodeInvalidENIIDNotFound        = "InvalidNetworkInterfaceID.NotFound"
+	ErrCodeInvalidENIInUse             = "InvalidNetworkInterface.InUse"
+	ErrCodeInvalidAttachmentIDNotFound = "InvalidAttachmentID.NotFound"
+	ErrCodeInvalidSubnetIDNotFound     = "InvalidSubnetID.NotFound"
+	ErrCodeInvalidGroupNotFound        = "InvalidGroup.NotFound"
+	ErrCodeInvalidInstanceIDNotFound   = "InvalidInstanceID.NotFound"
+	ErrCodeInvalidInstanceType         = "InvalidInstanceType"
+	ErrCodeInvalidParameterValue       = "InvalidParameterValue"
+	ErrCodeOperationNotPermitted       = "OperationNotPermitted"
+	ErrCodeInvalidAction               = "InvalidAction"
+)
+
+// Config describes the instance and VPC served by a Server
+type Config struct {
+	// InstanceType selects the ENI and IP limits from pkg/vpc
+	InstanceType     string
+	InstanceID       string
+	Region           string
+	AvailabilityZone string
+	VPCID            string
+	VPCCIDR          string
+	// Subnets lists the subnets of the VPC. The first one is the subnet of the primary ENI.
+	Subnets        []SubnetConfig
+	SecurityGroups []string
+	// ThrottleRate is the fraction of EC2 calls, between 0 and 1, that fail with RequestLimitExceeded
+	ThrottleRate float64
+	// Seed seeds the random source used for ThrottleRate
+	Seed int64
+}
+
+// SubnetConfig describes one subnet of the VPC
+type SubnetConfig struct {
+	ID   string
+	CIDR string
+	// AvailabilityZone defaults to the availability zone of the instance
+	AvailabilityZone string
+	Tags             map[string]string
+}
+
+// Fault makes matching EC2 calls fail with the given error code
+type Fault struct {
+	// Action is the EC2 action to fail, e.g. AssignPrivateIpAddresses. An empty action matches every EC2 call.
+	Action  string `json:"action"`
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+	// Count is the number of calls to fail. Zero fails every matching call until the faults are cleared.
+	Count int `json:"count,omitempty"`
+}
+
+// Server serves the fake EC2 API at "/", IMDS at "/latest/" and a control API at "/fake/"
+type Server struct {
+	cfg    Config
+	limits vpc.InstanceTypeLimits
+	mux    *http.ServeMux
+
+	mu      sync.Mutex
+	rand    *rand.Rand
+	vpcNet  *net.IPNet
+	subnets []*subnet
+	enis    map[string]*eni
+	nextID  int
+	faults  []*Fault
+	calls   map[string]int
+}
+
+// New returns a Server for cfg, with the primary ENI of the instance already created and attached
+func New(cfg Config) (*Server, error) {
+	setDefaults(&cfg)
+	limits, ok := vpc.GetInstance(cfg.InstanceType)
+	if !ok {
+		return nil, errors.Errorf("unknown instance type %s", cfg.InstanceType)
+	}
+	_, vpcNet, err := net.ParseCIDR(cfg.VPCCIDR)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid VPC CIDR %s", cfg.VPCCIDR)
+	}
+	s := &Server{
+		cfg:    cfg,
+		limits: limits,
+		mux:    http.NewServeMux(),
+		rand:   rand.New(rand.NewSource(cfg.Seed)),
+		vpcNet: vpcNet,
+		enis:   make(map[string]*eni),
+		calls:  make(map[string]int),
+	}
+	for _, sc := range cfg.Subnets {
+		sub, err := newSubnet(sc, vpcNet)
+		if err != nil {
+			return nil, err
+		}
+		s.subnets = append(s.subnets, sub)
+	}
+	primary, apiErr := s.createENI(s.subnets[0], "Primary network interface", cfg.SecurityGroups, nil)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := s.attachENI(primary, 0, 0); apiErr != nil {
+		return nil, apiErr
+	}
+	primary.attachment.deleteOnTermination = true
+
+	s.mux.HandleFunc("/", s.serveEC2)
+	s.mux.HandleFunc("/latest/", s.serveIMDS)
+	s.mux.HandleFunc("/fake/faults", s.serveFaults)
+	s.mux.HandleFunc("/fake/calls", s.serveCalls)
+	s.mux.HandleFunc("/fake/enis", s.serveENIs)
+	return s, nil
+}
+
+func setDefaults(cfg *Config) {
+	if cfg.InstanceType == "" {
+		cfg.InstanceType = DefaultInstanceType
+	}
+	if cfg.InstanceID == "" {
+		cfg.InstanceID = DefaultInstanceID
+	}
+	if cfg.Region == "" {
+		cfg.Region = DefaultRegion
+	}
+	if cfg.AvailabilityZone == "" {
+		cfg.AvailabilityZone = DefaultAvailabilityZone
+	}
+	if cfg.VPCID == "" {
+		cfg.VPCID = DefaultVPCID
+	}
+	if cfg.VPCCIDR == "" {
+		cfg.VPCCIDR = DefaultVPCCIDR
+	}
+	if len(cfg.Subnets) == 0 {
+		cfg.Subnets = []SubnetConfig{{ID: DefaultSubnetID, CIDR: DefaultSubnetCIDR}}
+	}
+	for i := range cfg.Subnets {
+		if cfg.Subnets[i].AvailabilityZone == "" {
+			cfg.Subnets[i].AvailabilityZone = cfg.AvailabilityZone
+		}
+	}
+	if len(cfg.SecurityGroups) == 0 {
+		cfg.SecurityGroups = []string{DefaultSecurityGroup}
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// InjectFault queues f. Faults are matched in the order they were injected.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults drops all queued faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Calls returns the number of calls per EC2 action, including failed ones
+func (s *Server) Calls() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := make(map[string]int, len(s.calls))
+	for action, n := range s.calls {
+		calls[action] = n
+	}
+	return calls
+}
+
+// ENIs returns a snapshot of every ENI in the VPC, sorted by ID
+func (s *Server) ENIs() []ENI {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	enis := make([]ENI, 0, len(s.enis))
+	for _, e := range s.sortedENIs() {
+		enis = append(enis, e.snapshot())
+	}
+	return enis
+}
+
+// FreeAddressCount returns the number of unused addresses in a subnet
+func (s *Server) FreeAddressCount(subnetID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub := s.subnet(subnetID)
+	if sub == nil {
+		return 0, errors.Errorf("unknown subnet %s", subnetID)
+	}
+	return sub.free(), nil
+}
+
+// injectedError returns the error to fail a call to action with, if any. Callers must hold s.mu.
+func (s *Server) injectedError(action string) *apiError {
+	for i, f := range s.faults {
+		if f.Action != "" && f.Action != action {
+			continue
+		}
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		msg := f.Message
+		if msg == "" {
+			msg = fmt.Sprintf("Injected %s error", f.Code)
+		}
+		return newAPIError(f.Code, msg)
+	}
+	if s.cfg.ThrottleRate > 0 && s.rand.Float64() < s.cfg.ThrottleRate {
+		return newAPIError(ErrCodeRequestLimitExceeded, "Request limit exceeded.")
+	}
+	return nil
+}
+
+// serveFaults lets out-of-process tests inject faults. POST adds the JSON encoded Fault in the body and DELETE
+// clears all faults.
+func (s *Server) serveFaults(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var f Fault
+		if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if f.Code == "" {
+			http.Error(w, "fault code is required", http.StatusBadRequest)
+			return
+		}
+		s.InjectFault(f)
+	case http.MethodDelete:
+		s.ClearFaults()
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) serveCalls(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.Calls())
+}
+
+func (s *Server) serveENIs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.ENIs())
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// apiError is an EC2 error response
+type apiError struct {
+	Code    string
+	Message string
+}
+
+func newAPIError(code, format string, args ...interface{}) *apiError {
+	return &apiError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// status returns the HTTP status EC2 uses for the error
+func (e *apiError) status() int {
+	switch {
+	case e.Code == ErrCodeRequestLimitExceeded:
+		return http.StatusServiceUnavailable
+	case strings.HasPrefix(e.Code, "Internal"):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fakeaws
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils"
+)
+
+// newTestClient starts s and returns an unmodified awsutils client pointed at it through the endpoint overrides
+func newTestClient(t *testing.T, s *Server) (*awsutils.EC2InstanceMetadataCache, *httptest.Server) {
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	t.Setenv("AWS_EC2_ENDPOINT", ts.URL)
+	t.Setenv("AWS_EC2_METADATA_SERVICE_ENDPOINT", ts.URL)
+	// Keep the SDK away from anything on the host, so that credentials come from the fake IMDS
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_VPC_K8S_CNI_IMDS_CACHE", filepath.Join(dir, "imds-cache.json"))
+	for _, env := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE",
+		"AWS_CA_BUNDLE", "AWS_EC2_METADATA_DISABLED", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"} {
+		t.Setenv(env, "")
+	}
+
+	cache, err := awsutils.New(false, false, true, true, false)
+	require.NoError(t, err)
+	return cache, ts
+}
+
+func apiErrorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+func TestAWSUtilsAgainstFake(t *testing.T) {
+	s, err := New(Config{InstanceType: "t3.medium"})
+	require.NoError(t, err)
+	cache, _ := newTestClient(t, s)
+
+	assert.Equal(t, "t3.medium", cache.GetInstanceType())
+	assert.Equal(t, 3, cache.GetENILimit())
+	assert.Equal(t, 5, cache.GetENIIPv4Limit())
+	primary := s.ENIs()[0]
+	assert.Equal(t, primary.ID, cache.GetPrimaryENI())
+	assert.Equal(t, primary.PrimaryIP, cache.GetLocalIPv4().String())
+
+	eniID, err := cache.AllocENI(false, nil, nil, 2)
+	require.NoError(t, err)
+	eni, err := cache.WaitForENIAndIPsAttached(eniID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, eni.DeviceNumber)
+	assert.Len(t, eni.IPv4Addresses, 3)
+
+	_, err = cache.AllocIPAddresses(eniID, 3)
+	require.NoError(t, err)
+	addresses, err := cache.DescribeENIAddresses([]string{eniID})
+	require.NoError(t, err)
+	ips := addresses[eniID].IPv4Addresses
+	assert.Len(t, ips, 6)
+	_, err = cache.AllocIPAddresses(eniID, 1)
+	assert.Equal(t, ErrCodePrivateIPAddressLimit, apiErrorCode(err), "%v", err)
+
+	require.NoError(t, cache.DeallocIPAddresses(eniID, []string{*ips[5].PrivateIpAddress}))
+	addresses, err = cache.DescribeENIAddresses([]string{eniID})
+	require.NoError(t, err)
+	assert.Len(t, addresses[eniID].IPv4Addresses, 5)
+
+	result, err := cache.DescribeAllENIs()
+	require.NoError(t, err)
+	assert.Len(t, result.ENIMetadata, 2)
+
+	_, err = cache.AllocENI(false, nil, nil, 0)
+	require.NoError(t, err)
+	_, err = cache.AllocENI(false, nil, nil, 0)
+	assert.Equal(t, ErrCodeAttachmentLimitExceeded, apiErrorCode(errors.Unwrap(err)), "%v", err)
+
+	require.NoError(t, cache.FreeENI(eniID))
+	for _, e := range s.ENIs() {
+		assert.NotEqual(t, eniID, e.ID)
+	}
+}
+
+func TestRestartWithIMDSUnavailable(t *testing.T) {
+	s, err := New(Config{})
+	require.NoError(t, err)
+	cache, ts := newTestClient(t, s)
+	enis, err := cache.GetAttachedENIs()
+	require.NoError(t, err)
+
+	// aws-node restarts while IMDS is down, and comes up from the IMDS cache
+	ts.Close()
+	restarted, err := awsutils.New(false, false, true, true, false)
+	require.NoError(t, err)
+	assert.Equal(t, cache.GetInstanceID(), restarted.GetInstanceID())
+	assert.Equal(t, cache.GetPrimaryENI(), restarted.GetPrimaryENI())
+	cachedENIs, err := restarted.GetAttachedENIs()
+	require.NoError(t, err)
+	assert.Equal(t, enis, cachedENIs)
+	assert.True(t, restarted.IsIMDSDegraded())
+	assert.False(t, cache.IsIMDSDegraded())
+}
+
+func TestPrefixDelegation(t *testing.T) {
+	s, err := New(Config{InstanceType: "m5.large"})
+	require.NoError(t, err)
+	cache, _ := newTestClient(t, s)
+	cache.InitCachedPrefixDelegation(true)
+
+	eniID, err := cache.AllocENI(false, nil, nil, 1)
+	require.NoError(t, err)
+	_, err = cache.AllocIPAddresses(eniID, 2)
+	require.NoError(t, err)
+
+	addresses, err := cache.DescribeENIAddresses([]string{eniID})
+	require.NoError(t, err)
+	prefixes := addresses[eniID].IPv4Prefixes
+	require.Len(t, prefixes, 3)
+	eni, err := cache.WaitForENIAndIPsAttached(eniID, 3)
+	require.NoError(t, err)
+	assert.Len(t, eni.IPv4Prefixes, 3)
+
+	require.NoError(t, cache.DeallocPrefixAddresses(eniID, []string{*prefixes[0].Ipv4Prefix}))
+	addresses, err = cache.DescribeENIAddresses([]string{eniID})
+	require.NoError(t, err)
+	assert.Len(t, addresses[eniID].IPv4Prefixes, 2)
+}
+
+func TestSubnetExhaustion(t *testing.T) {
+	// A /28 has 11 usable addresses, one of which is taken by the primary ENI
+	s, err := New(Config{Subnets: []SubnetConfig{{ID: "subnet-small", CIDR: "10.0.0.0/28"}}})
+	require.NoError(t, err)
+	cache, _ := newTestClient(t, s)
+
+	_, err = cache.AllocENI(false, nil, nil, 9)
+	require.NoError(t, err)
+	_, err = cache.AllocIPAddresses(cache.GetPrimaryENI(), 1)
+	require.Error(t, err)
+	assert.Equal(t, ErrCodeInsufficientFreeAddresses, apiErrorCode(err), "%v", err)
+
+	free, err := s.FreeAddressCount("subnet-small")
+	require.NoError(t, err)
+	assert.Zero(t, free)
+}
+
+func TestInProcessActions(t *testing.T) {
+	s, err := New(Config{InstanceType: "t3.medium"})
+	require.NoError(t, err)
+	cache, _ := newTestClient(t, s)
+
+	eni, err := s.CreateENI(DefaultSubnetID, "aws-K8S-"+DefaultInstanceID, map[string]string{"team": "shared"})
+	require.NoError(t, err)
+	require.NoError(t, s.AttachENI(eni.ID, 1, 0))
+	ips, prefixes, err := s.AssignAddresses(eni.ID, 2, 0, nil, nil)
+	require.NoError(t, err)
+	assert.Len(t, ips, 2)
+	assert.Empty(t, prefixes)
+	_, _, err = s.AssignAddresses(eni.ID, 4, 0, nil, nil)
+	assert.Equal(t, ErrCodePrivateIPAddressLimit, apiErrorCode(err), "%v", err)
+
+	// The HTTP endpoints serve the same state
+	addresses, err := cache.DescribeENIAddresses([]string{eni.ID})
+	require.NoError(t, err)
+	assert.Len(t, addresses[eni.ID].IPv4Addresses, 3)
+	attached, err := cache.GetAttachedENIs()
+	require.NoError(t, err)
+	assert.Len(t, attached, 2)
+	assert.Len(t, s.AttachedENIs(), 2)
+
+	require.NoError(t, s.UnassignAddresses(eni.ID, ips[:1], nil))
+	assert.Error(t, s.UnassignAddresses(eni.ID, ips[:1], nil))
+	assert.Equal(t, ErrCodeInvalidENIInUse, apiErrorCode(s.DeleteENI(eni.ID)))
+	require.NoError(t, s.DetachENI(eni.ID))
+	require.NoError(t, s.DeleteENI(eni.ID))
+	_, err = s.ENI(eni.ID)
+	assert.Equal(t, ErrCodeInvalidENIIDNotFound, apiErrorCode(err))
+	assert.Equal(t, ErrCodeOperationNotPermitted, apiErrorCode(s.DetachENI(cache.GetPrimaryENI())))
+}
+
+func TestDescribeENIConfigNetwork(t *testing.T) {
+	s, err := New(Config{
+		Subnets: []SubnetConfig{
+			{ID: DefaultSubnetID, CIDR: DefaultSubnetCIDR},
+			{ID: "subnet-pods", CIDR: "10.0.64.0/28", AvailabilityZone: "us-west-2b"},
+			{ID: "subnet-pods-2", CIDR: "10.0.65.0/28", AvailabilityZone: "us-west-2b"},
+			{ID: "subnet-other-az", CIDR: "10.0.66.0/28", AvailabilityZone: "us-west-2c"},
+		},
+		SecurityGroups: []string{DefaultSecurityGroup, "sg-pods"},
+	})
+	require.NoError(t, err)
+	cache, _ := newTestClient(t, s)
+
+	network, err := cache.DescribeENIConfigNetwork([]string{"subnet-pods"}, []string{"sg-pods"})
+	require.NoError(t, err)
+	assert.Equal(t, awsutils.ENIConfigNetwork{
+		SubnetFound:             true,
+		AvailabilityZone:        "us-west-2b",
+		AvailableIPAddressCount: 11,
+		SecurityGroupsFound:     true,
+	}, network)
+
+	// The free addresses of all subnets add up, and they have to be in the same availability zone
+	network, err = cache.DescribeENIConfigNetwork([]string{"subnet-pods", "subnet-pods-2"}, nil)
+	require.NoError(t, err)
+	assert.True(t, network.SubnetFound)
+	assert.Equal(t, int32(22), network.AvailableIPAddressCount)
+	network, err = cache.DescribeENIConfigNetwork([]string{"subnet-pods", "subnet-other-az"}, nil)
+	require.NoError(t, err)
+	assert.False(t, network.SubnetFound)
+	assert.Equal(t, []string{"subnet subnet-other-az is in us-west-2c, not in us-west-2b"}, network.Errors)
+
+	// Typos in the ENIConfig are reported, not returned as errors
+	network, err = cache.DescribeENIConfigNetwork([]string{"subnet-typo"}, []string{"sg-pods", "sg-typo"})
+	require.NoError(t, err)
+	assert.False(t, network.SubnetFound)
+	assert.False(t, network.SecurityGroupsFound)
+	assert.Equal(t, []string{"The subnet ID 'subnet-typo' does not exist", "The security group 'sg-typo' does not exist"}, network.Errors)
+
+	// Other errors are returned
+	s.InjectFault(Fault{Action: "DescribeSubnets", Code: "UnauthorizedOperation", Count: 1})
+	_, err = cache.DescribeENIConfigNetwork([]string{"subnet-pods"}, nil)
+	assert.Error(t, err)
+}
+
+func TestInjectedFaults(t *testing.T) {
+	s, err := New(Config{})
+	require.NoError(t, err)
+	cache, ts := newTestClient(t, s)
+
+	// The SDK retries throttled calls, so a single throttle is invisible to the caller
+	s.InjectFault(Fault{Action: "AssignPrivateIpAddresses", Code: ErrCodeRequestLimitExceeded, Count: 1})
+	before := s.Calls()["AssignPrivateIpAddresses"]
+	_, err = cache.AllocIPAddresses(cache.GetPrimaryENI(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, before+2, s.Calls()["AssignPrivateIpAddresses"])
+
+	resp, err := http.Post(ts.URL+"/fake/faults", "application/json",
+		bytes.NewBufferString(`{"action":"AssignPrivateIpAddresses","code":"InsufficientFreeAddressesInSubnet"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	for i := 0; i < 2; i++ {
+		_, err = cache.AllocIPAddresses(cache.GetPrimaryENI(), 1)
+		assert.Equal(t, ErrCodeInsufficientFreeAddresses, apiErrorCode(err), "%v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, ts.URL+"/fake/faults", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	_, err = cache.AllocIPAddresses(cache.GetPrimaryENI(), 1)
+	assert.NoError(t, err)
+}
+
+func TestThrottleRate(t *testing.T) {
+	s, err := New(Config{ThrottleRate: 1})
+	require.NoError(t, err)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.PostForm(ts.URL, map[string][]string{"Action": {"DescribeInstances"}})
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
@@ -0,0 +1,389 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fakeaws
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// subnetReservedHead and subnetReservedTail are the addresses AWS reserves at the start and end of every subnet
+	subnetReservedHead = 4
+	subnetReservedTail = 1
+	prefixLen          = 28
+	prefixSize         = 1 << (32 - prefixLen)
+
+	eniStatusAvailable = "available"
+	eniStatusInUse     = "in-use"
+)
+
+// ENI is a snapshot of an ENI served by the fake, as returned by Server.ENIs and /fake/enis
+type ENI struct {
+	ID                  string            `json:"id"`
+	SubnetID            string            `json:"subnetId"`
+	MAC                 string            `json:"mac"`
+	Description         string            `json:"description"`
+	Status              string            `json:"status"`
+	SecurityGroups      []string          `json:"securityGroups"`
+	PrimaryIP           string            `json:"primaryIP"`
+	SecondaryIPs        []string          `json:"secondaryIPs"`
+	Prefixes            []string          `json:"prefixes"`
+	Tags                map[string]string `json:"tags,omitempty"`
+	AttachmentID        string            `json:"attachmentId,omitempty"`
+	DeviceIndex         int               `json:"deviceIndex"`
+	NetworkCardIndex    int               `json:"networkCardIndex"`
+	DeleteOnTermination bool              `json:"deleteOnTermination"`
+}
+
+type subnet struct {
+	cfg    SubnetConfig
+	ipNet  *net.IPNet
+	first  uint32
+	last   uint32
+	used   map[uint32]bool
+	usable int
+}
+
+func newSubnet(cfg SubnetConfig, vpcNet *net.IPNet) (*subnet, error) {
+	_, ipNet, err := net.ParseCIDR(cfg.CIDR)
+	if err != nil || ipNet.IP.To4() == nil {
+		return nil, errors.Errorf("invalid IPv4 CIDR %q for subnet %s", cfg.CIDR, cfg.ID)
+	}
+	if !vpcNet.Contains(ipNet.IP) {
+		return nil, errors.Errorf("subnet %s (%s) is outside the VPC CIDR %s", cfg.ID, cfg.CIDR, vpcNet)
+	}
+	ones, bits := ipNet.Mask.Size()
+	size := uint32(1) << uint(bits-ones)
+	network := ipToUint32(ipNet.IP)
+	if size <= subnetReservedHead+subnetReservedTail {
+		return nil, errors.Errorf("subnet %s (%s) is too small", cfg.ID, cfg.CIDR)
+	}
+	return &subnet{
+		cfg:    cfg,
+		ipNet:  ipNet,
+		first:  network + subnetReservedHead,
+		last:   network + size - 1 - subnetReservedTail,
+		used:   make(map[uint32]bool),
+		usable: int(size) - subnetReservedHead - subnetReservedTail,
+	}, nil
+}
+
+func (sub *subnet) free() int {
+	return sub.usable - len(sub.used)
+}
+
+// allocateIP returns the lowest free address of the subnet
+func (sub *subnet) allocateIP() (net.IP, bool) {
+	for a := sub.first; a <= sub.last; a++ {
+		if !sub.used[a] {
+			sub.used[a] = true
+			return uint32ToIP(a), true
+		}
+	}
+	return nil, false
+}
+
+// takeIP marks a specific address as used
+func (sub *subnet) takeIP(ip net.IP) *apiError {
+	a := ipToUint32(ip)
+	if !sub.ipNet.Contains(ip) || a < sub.first || a > sub.last {
+		return newAPIError(ErrCodeInvalidParameterValue, "Address %s does not fall within the subnet's address range", ip)
+	}
+	if sub.used[a] {
+		return newAPIError(ErrCodeInvalidParameterValue, "Address %s is in subnet's reserved address range or is already in use", ip)
+	}
+	sub.used[a] = true
+	return nil
+}
+
+// takePrefix marks a specific /28 prefix as used
+func (sub *subnet) takePrefix(prefix *net.IPNet) *apiError {
+	p := ipToUint32(prefix.IP)
+	if !sub.ipNet.Contains(prefix.IP) || p < sub.first || p+prefixSize-1 > sub.last {
+		return newAPIError(ErrCodeInvalidParameterValue, "Prefix %s does not fall within the subnet's address range", prefix)
+	}
+	for a := p; a < p+prefixSize; a++ {
+		if sub.used[a] {
+			return newAPIError(ErrCodeInvalidParameterValue, "Prefix %s overlaps addresses that are already in use", prefix)
+		}
+	}
+	for a := p; a < p+prefixSize; a++ {
+		sub.used[a] = true
+	}
+	return nil
+}
+
+// allocatePrefix returns the lowest aligned /28 of the subnet that has no address in use
+func (sub *subnet) allocatePrefix() (*net.IPNet, bool) {
+	start := (sub.first + prefixSize - 1) &^ (prefixSize - 1)
+	for p := start; p+prefixSize-1 <= sub.last; p += prefixSize {
+		inUse := false
+		for a := p; a < p+prefixSize; a++ {
+			if sub.used[a] {
+				inUse = true
+				break
+			}
+		}
+		if inUse {
+			continue
+		}
+		for a := p; a < p+prefixSize; a++ {
+			sub.used[a] = true
+		}
+		return &net.IPNet{IP: uint32ToIP(p), Mask: net.CIDRMask(prefixLen, 32)}, true
+	}
+	return nil, false
+}
+
+func (sub *subnet) releaseIP(ip net.IP) {
+	delete(sub.used, ipToUint32(ip))
+}
+
+func (sub *subnet) releasePrefix(prefix *net.IPNet) {
+	p := ipToUint32(prefix.IP)
+	for a := p; a < p+prefixSize; a++ {
+		delete(sub.used, a)
+	}
+}
+
+type attachment struct {
+	id                  string
+	deviceIndex         int
+	networkCardIndex    int
+	deleteOnTermination bool
+}
+
+type eni struct {
+	id           string
+	subnet       *subnet
+	mac          string
+	description  string
+	groups       []string
+	primaryIP    net.IP
+	secondaryIPs []net.IP
+	prefixes     []*net.IPNet
+	tags         map[string]string
+	tagOrder     []string
+	attachment   *attachment
+}
+
+func (e *eni) status() string {
+	if e.attachment != nil {
+		return eniStatusInUse
+	}
+	return eniStatusAvailable
+}
+
+func (e *eni) setTag(key, value string) {
+	if _, ok := e.tags[key]; !ok {
+		e.tagOrder = append(e.tagOrder, key)
+	}
+	e.tags[key] = value
+}
+
+// addressCount is the number of addresses counted against the per-ENI IPv4 limit. Each prefix counts as one.
+func (e *eni) addressCount() int {
+	return 1 + len(e.secondaryIPs) + len(e.prefixes)
+}
+
+func (e *eni) snapshot() ENI {
+	s := ENI{
+		ID:             e.id,
+		SubnetID:       e.subnet.cfg.ID,
+		MAC:            e.mac,
+		Description:    e.description,
+		Status:         e.status(),
+		SecurityGroups: append([]string(nil), e.groups...),
+		PrimaryIP:      e.primaryIP.String(),
+		SecondaryIPs:   []string{},
+		Prefixes:       []string{},
+	}
+	for _, ip := range e.secondaryIPs {
+		s.SecondaryIPs = append(s.SecondaryIPs, ip.String())
+	}
+	for _, p := range e.prefixes {
+		s.Prefixes = append(s.Prefixes, p.String())
+	}
+	if len(e.tags) > 0 {
+		s.Tags = make(map[string]string, len(e.tags))
+		for k, v := range e.tags {
+			s.Tags[k] = v
+		}
+	}
+	if e.attachment != nil {
+		s.AttachmentID = e.attachment.id
+		s.DeviceIndex = e.attachment.deviceIndex
+		s.NetworkCardIndex = e.attachment.networkCardIndex
+		s.DeleteOnTermination = e.attachment.deleteOnTermination
+	}
+	return s
+}
+
+// The methods below mutate the fake's state and must be called with s.mu held.
+
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s-%017x", prefix, s.nextID)
+}
+
+func (s *Server) subnet(id string) *subnet {
+	for _, sub := range s.subnets {
+		if sub.cfg.ID == id {
+			return sub
+		}
+	}
+	return nil
+}
+
+func (s *Server) sortedENIs() []*eni {
+	enis := make([]*eni, 0, len(s.enis))
+	for _, e := range s.enis {
+		enis = append(enis, e)
+	}
+	sort.Slice(enis, func(i, j int) bool { return enis[i].id < enis[j].id })
+	return enis
+}
+
+// attachedENIs returns the ENIs attached to the instance, ordered by device index
+func (s *Server) attachedENIs() []*eni {
+	var enis []*eni
+	for _, e := range s.enis {
+		if e.attachment != nil {
+			enis = append(enis, e)
+		}
+	}
+	sort.Slice(enis, func(i, j int) bool {
+		if enis[i].attachment.networkCardIndex != enis[j].attachment.networkCardIndex {
+			return enis[i].attachment.networkCardIndex < enis[j].attachment.networkCardIndex
+		}
+		return enis[i].attachment.deviceIndex < enis[j].attachment.deviceIndex
+	})
+	return enis
+}
+
+func (s *Server) eniByMAC(mac string) *eni {
+	for _, e := range s.enis {
+		if e.attachment != nil && e.mac == mac {
+			return e
+		}
+	}
+	return nil
+}
+
+func (s *Server) createENI(sub *subnet, description string, groups []string, tags [][2]string) (*eni, *apiError) {
+	ip, ok := sub.allocateIP()
+	if !ok {
+		return nil, insufficientAddresses(sub)
+	}
+	id := s.newID("eni")
+	n := s.nextID
+	e := &eni{
+		id:          id,
+		subnet:      sub,
+		mac:         fmt.Sprintf("02:fa:%02x:%02x:%02x:%02x", byte(n>>24), byte(n>>16), byte(n>>8), byte(n)),
+		description: description,
+		groups:      append([]string(nil), groups...),
+		primaryIP:   ip,
+		tags:        make(map[string]string),
+	}
+	for _, kv := range tags {
+		e.setTag(kv[0], kv[1])
+	}
+	s.enis[e.id] = e
+	return e, nil
+}
+
+func (s *Server) attachENI(e *eni, deviceIndex, networkCardIndex int) *apiError {
+	if e.attachment != nil {
+		return newAPIError(ErrCodeInvalidParameterValue, "Interface %s is already attached", e.id)
+	}
+	maxENIs := s.limits.ENILimit
+	if len(s.limits.NetworkCards) > 0 {
+		if networkCardIndex >= len(s.limits.NetworkCards) {
+			return newAPIError(ErrCodeInvalidParameterValue, "Network card index %d is out of range", networkCardIndex)
+		}
+		maxENIs = int(s.limits.NetworkCards[networkCardIndex].MaximumNetworkInterfaces)
+	}
+	onCard := 0
+	for _, other := range s.attachedENIs() {
+		if other.attachment.networkCardIndex != networkCardIndex {
+			continue
+		}
+		onCard++
+		if other.attachment.deviceIndex == deviceIndex {
+			return newAPIError(ErrCodeInvalidParameterValue, "Instance %s already has an interface attached at device index %d",
+				s.cfg.InstanceID, deviceIndex)
+		}
+	}
+	if onCard >= maxENIs || len(s.attachedENIs()) >= s.limits.ENILimit {
+		return newAPIError(ErrCodeAttachmentLimitExceeded, "Interface count %d exceeds the limit for %s", onCard+1, s.cfg.InstanceType)
+	}
+	e.attachment = &attachment{
+		id:               s.newID("eni-attach"),
+		deviceIndex:      deviceIndex,
+		networkCardIndex: networkCardIndex,
+	}
+	return nil
+}
+
+func (s *Server) detachENI(e *eni) *apiError {
+	if e.attachment == nil {
+		return newAPIError(ErrCodeInvalidParameterValue, "Interface %s is not attached", e.id)
+	}
+	if e.attachment.deviceIndex == 0 && e.attachment.networkCardIndex == 0 {
+		return newAPIError(ErrCodeOperationNotPermitted, "The network interface at device index 0 cannot be detached.")
+	}
+	e.attachment = nil
+	return nil
+}
+
+func (s *Server) deleteENI(e *eni) {
+	for _, ip := range e.secondaryIPs {
+		e.subnet.releaseIP(ip)
+	}
+	for _, p := range e.prefixes {
+		e.subnet.releasePrefix(p)
+	}
+	e.subnet.releaseIP(e.primaryIP)
+	delete(s.enis, e.id)
+}
+
+// checkAddressLimit fails if adding n addresses would take e over the per-ENI IPv4 limit of the instance type
+func (s *Server) checkAddressLimit(e *eni, n int) *apiError {
+	if e.addressCount()+n > s.limits.IPv4Limit {
+		return newAPIError(ErrCodePrivateIPAddressLimit, "Number of private addresses will exceed limit of %d for %s",
+			s.limits.IPv4Limit, s.cfg.InstanceType)
+	}
+	return nil
+}
+
+func insufficientAddresses(sub *subnet) *apiError {
+	return newAPIError(ErrCodeInsufficientFreeAddresses, "There are not enough free addresses in subnet '%s' to satisfy the requested number of instances.",
+		sub.cfg.ID)
+}
+
+func ipToUint32(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
+
+func uint32ToIP(a uint32) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, a)
+	return ip
+}
@@ -143,6 +143,25 @@ func (typedimds TypedIMDS) GetAZ(ctx context.Context) (string, error) {
 	return strings.TrimSpace(string(bytes)), nil
 }
 
+// GetRegion returns the region in which the instance launched.
+func (typedimds TypedIMDS) GetRegion(ctx context.Context) (string, error) {
+	output, err := typedimds.GetMetadata(ctx, &imds.GetMetadataInput{
+		Path: "placement/region"})
+	if err != nil {
+		return "", err
+	}
+	if output == nil || output.Content == nil {
+		return "", newIMDSRequestError("placement/region", fmt.Errorf("empty response"))
+	}
+
+	defer output.Content.Close()
+	bytes, err := io.ReadAll(output.Content)
+	if err != nil {
+		return "", newIMDSRequestError("placement/region", fmt.Errorf("failed to read content: %w", err))
+	}
+	return strings.TrimSpace(string(bytes)), nil
+}
+
 // GetInstanceType returns the type of this instance.
 func (typedimds TypedIMDS) GetInstanceType(ctx context.Context) (string, error) {
 	output, err := typedimds.GetMetadata(ctx, &imds.GetMetadataInput{
@@ -294,6 +313,20 @@ func (typedimds TypedIMDS) GetDeviceNumber(ctx context.Context, mac string) (int
 	return typedimds.getInt(ctx, key)
 }
 
+// GetNetworkCard returns the index of the network card the interface is attached to. Interfaces of instance types
+// with a single network card are on card 0.
+func (typedimds TypedIMDS) GetNetworkCard(ctx context.Context, mac string) (int, error) {
+	key := fmt.Sprintf("network/interfaces/macs/%s/network-card", mac)
+	networkCard, err := typedimds.getInt(ctx, key)
+	if err != nil {
+		if IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return networkCard, nil
+}
+
 // GetSubnetID returns the ID of the subnet in which the interface resides.
 func (typedimds TypedIMDS) GetSubnetID(ctx context.Context, mac string) (string, error) {
 	key := fmt.Sprintf("network/interfaces/macs/%s/subnet-id", mac)
@@ -0,0 +1,313 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsutils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/smithy-go"
+
+	"github.com/aws/amazon-vpc-cni-k8s/utils/prometheusmetrics"
+)
+
+const (
+	imdsCachePathEnvVar    = "AWS_VPC_K8S_CNI_IMDS_CACHE"
+	disableIMDSCacheEnvVar = "DISABLE_IMDS_CACHE"
+	defaultIMDSCachePath   = "/var/run/aws-node/imds-cache.json"
+	imdsCacheVersion       = 1
+
+	// imdsLiveTimeout bounds an IMDS request for metadata that is in the cache, so that a slow IMDS falls back to the
+	// cache instead of holding up ipamd
+	imdsLiveTimeout = 2 * time.Second
+	// imdsCacheRefreshInterval is how often the cached metadata is refreshed from IMDS while IMDS is degraded
+	imdsCacheRefreshInterval = 30 * time.Second
+	// imdsCacheWriteInterval limits how often the cache file is rewritten when none of the metadata changed
+	imdsCacheWriteInterval = time.Minute
+)
+
+// imdsCacheFile is the format of the cache file
+type imdsCacheFile struct {
+	Version   int                       `json:"version"`
+	UpdatedAt time.Time                 `json:"updatedAt"`
+	Entries   map[string]imdsCacheEntry `json:"entries"`
+}
+
+// imdsCacheEntry is the answer of IMDS for a metadata path
+type imdsCacheEntry struct {
+	Content  string `json:"content,omitempty"`
+	NotFound bool   `json:"notFound,omitempty"`
+}
+
+// imdsCache keeps the last answer of IMDS for every instance-level and per-MAC metadata path in a file on the host,
+// so that ipamd can start and keep running when IMDS is slow or briefly unavailable. Metadata is read from IMDS as long
+// as it answers. Once a request for cached metadata fails, IMDS is marked degraded: cached metadata is served without
+// calling IMDS, and the cache is refreshed in the background until IMDS answers for all of it again.
+type imdsCache struct {
+	EC2MetadataIface
+	path string
+	now  func() time.Time
+
+	mu sync.Mutex
+	// updatedAt is when IMDS last answered while not degraded, or the last time the whole cache was refreshed
+	updatedAt  time.Time
+	writtenAt  time.Time
+	entries    map[string]imdsCacheEntry
+	degraded   bool
+	refreshing bool
+}
+
+// loadIMDSCache wraps IMDS with the cache configured in the environment, if it is enabled
+func loadIMDSCache(live EC2MetadataIface) EC2MetadataIface {
+	if value := os.Getenv(disableIMDSCacheEnvVar); value != "" {
+		disabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Warnf("Invalid %s %q, keeping the IMDS cache enabled", disableIMDSCacheEnvVar, value)
+		} else if disabled {
+			log.Infof("IMDS cache is disabled")
+			return live
+		}
+	}
+	path := defaultIMDSCachePath
+	if value := os.Getenv(imdsCachePathEnvVar); value != "" {
+		path = value
+	}
+	return newIMDSCache(live, path)
+}
+
+// newIMDSCache returns a cache in front of IMDS, loaded from the file at path if there is one
+func newIMDSCache(live EC2MetadataIface, path string) *imdsCache {
+	c := &imdsCache{
+		EC2MetadataIface: live,
+		path:             path,
+		now:              time.Now,
+		entries:          make(map[string]imdsCacheEntry),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Failed to read the IMDS cache %s: %v", path, err)
+		}
+		return c
+	}
+	var file imdsCacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		log.Warnf("Ignoring the IMDS cache %s, failed to parse it: %v", path, err)
+		return c
+	}
+	if file.Version != imdsCacheVersion {
+		log.Warnf("Ignoring the IMDS cache %s with unknown version %d", path, file.Version)
+		return c
+	}
+	if file.Entries != nil {
+		c.entries = file.Entries
+	}
+	c.updatedAt = file.UpdatedAt
+	c.writtenAt = file.UpdatedAt
+	log.Infof("Loaded %d instance metadata entries from the IMDS cache %s, last updated at %v", len(c.entries), path, file.UpdatedAt)
+	return c
+}
+
+// GetMetadata implements EC2MetadataIface
+func (c *imdsCache) GetMetadata(ctx context.Context, params *imds.GetMetadataInput, optFns ...func(*imds.Options)) (*imds.GetMetadataOutput, error) {
+	c.mu.Lock()
+	entry, cached := c.entries[params.Path]
+	degraded := c.degraded
+	c.mu.Unlock()
+	if cached && degraded {
+		return c.serveCached(params.Path, entry)
+	}
+
+	if cached {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, imdsLiveTimeout)
+		defer cancel()
+	}
+	entry, err := c.fetch(ctx, params.Path, optFns...)
+	if err == nil {
+		return entry.output(), nil
+	}
+	if !cached || IsNotFound(err) {
+		return nil, err
+	}
+	log.Warnf("Failed to get %s from IMDS, serving instance metadata from the IMDS cache: %v", params.Path, err)
+	c.setDegraded()
+	c.mu.Lock()
+	entry = c.entries[params.Path]
+	c.mu.Unlock()
+	return c.serveCached(params.Path, entry)
+}
+
+// fetch gets a metadata path from IMDS and stores the answer, including a 404, in the cache
+func (c *imdsCache) fetch(ctx context.Context, path string, optFns ...func(*imds.Options)) (imdsCacheEntry, error) {
+	output, err := c.EC2MetadataIface.GetMetadata(ctx, &imds.GetMetadataInput{Path: path}, optFns...)
+	if err != nil {
+		if IsNotFound(err) {
+			c.store(path, imdsCacheEntry{NotFound: true})
+		}
+		return imdsCacheEntry{}, err
+	}
+	if output == nil || output.Content == nil {
+		return imdsCacheEntry{}, newIMDSRequestError(path, fmt.Errorf("empty response"))
+	}
+	defer output.Content.Close()
+	bytes, err := io.ReadAll(output.Content)
+	if err != nil {
+		return imdsCacheEntry{}, newIMDSRequestError(path, fmt.Errorf("failed to read content: %w", err))
+	}
+	entry := imdsCacheEntry{Content: string(bytes)}
+	c.store(path, entry)
+	return entry, nil
+}
+
+func (c *imdsCache) store(path string, entry imdsCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	previous, found := c.entries[path]
+	c.entries[path] = entry
+	now := c.now()
+	if !c.degraded {
+		c.updatedAt = now
+	}
+	if !found || previous != entry || now.Sub(c.writtenAt) >= imdsCacheWriteInterval {
+		c.writeUnsafe()
+	}
+}
+
+// serveCached answers a request from the cache
+func (c *imdsCache) serveCached(path string, entry imdsCacheEntry) (*imds.GetMetadataOutput, error) {
+	prometheusmetrics.IMDSCacheHits.Inc()
+	c.mu.Lock()
+	c.updateAgeUnsafe()
+	c.mu.Unlock()
+	if entry.NotFound {
+		return nil, newIMDSRequestError(path, &CustomRequestFailure{
+			code:       "NotFound",
+			message:    "not found in the IMDS cache",
+			fault:      smithy.FaultUnknown,
+			statusCode: http.StatusNotFound,
+		})
+	}
+	return entry.output(), nil
+}
+
+func (e imdsCacheEntry) output() *imds.GetMetadataOutput {
+	return &imds.GetMetadataOutput{Content: io.NopCloser(strings.NewReader(e.Content))}
+}
+
+// isDegraded reports whether cached metadata is served without calling IMDS
+func (c *imdsCache) isDegraded() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.degraded
+}
+
+// setDegraded marks IMDS as degraded and starts refreshing the cache in the background
+func (c *imdsCache) setDegraded() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.degraded {
+		log.Warnf("IMDS is degraded, serving instance metadata from the IMDS cache last updated at %v", c.updatedAt)
+		c.degraded = true
+		prometheusmetrics.IMDSCacheDegraded.Set(1)
+	}
+	c.updateAgeUnsafe()
+	if !c.refreshing {
+		c.refreshing = true
+		go c.refreshUntilAvailable()
+	}
+}
+
+func (c *imdsCache) refreshUntilAvailable() {
+	ticker := time.NewTicker(imdsCacheRefreshInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if c.refresh(context.Background()) {
+			return
+		}
+	}
+}
+
+// refresh gets all the cached metadata from IMDS, and ends the degraded state when IMDS answered for all of it
+func (c *imdsCache) refresh(ctx context.Context) bool {
+	c.mu.Lock()
+	paths := make([]string, 0, len(c.entries))
+	for path := range c.entries {
+		paths = append(paths, path)
+	}
+	c.mu.Unlock()
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if _, err := c.fetch(ctx, path); err != nil && !IsNotFound(err) {
+			log.Debugf("IMDS is still degraded, failed to refresh %s: %v", path, err)
+			c.mu.Lock()
+			c.updateAgeUnsafe()
+			c.mu.Unlock()
+			return false
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	log.Infof("IMDS is available again, refreshed %d instance metadata entries", len(paths))
+	c.degraded = false
+	c.refreshing = false
+	c.updatedAt = c.now()
+	prometheusmetrics.IMDSCacheDegraded.Set(0)
+	c.updateAgeUnsafe()
+	c.writeUnsafe()
+	return true
+}
+
+func (c *imdsCache) updateAgeUnsafe() {
+	age := 0.0
+	if c.degraded && !c.updatedAt.IsZero() {
+		age = c.now().Sub(c.updatedAt).Seconds()
+	}
+	prometheusmetrics.IMDSCacheAge.Set(age)
+}
+
+// writeUnsafe replaces the cache file, so that it is never left half written
+func (c *imdsCache) writeUnsafe() {
+	c.writtenAt = c.now()
+	data, err := json.Marshal(imdsCacheFile{Version: imdsCacheVersion, UpdatedAt: c.updatedAt, Entries: c.entries})
+	if err != nil {
+		log.Warnf("Failed to encode the IMDS cache: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		log.Warnf("Failed to write the IMDS cache %s: %v", c.path, err)
+		return
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		log.Warnf("Failed to write the IMDS cache %s: %v", c.path, err)
+		return
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		log.Warnf("Failed to write the IMDS cache %s: %v", c.path, err)
+	}
+}
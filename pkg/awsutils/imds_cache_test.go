@@ -0,0 +1,102 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsutils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aws/amazon-vpc-cni-k8s/utils/prometheusmetrics"
+)
+
+func TestIMDSCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "imds-cache.json")
+	live := FakeIMDS(map[string]interface{}{
+		"instance-id": "i-084abd1f69f27d987",
+		"local-ipv4":  "10.0.88.3",
+	})
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache := newIMDSCache(live, path)
+	cache.now = func() time.Time { return now }
+	typed := TypedIMDS{cache}
+
+	id, err := typed.GetInstanceID(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, "i-084abd1f69f27d987", id)
+	ip, err := typed.GetLocalIPv4(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.88.3", ip.String())
+	_, err = typed.GetSubnetID(context.TODO(), "02:c5:f8:3e:6b:27")
+	assert.True(t, IsNotFound(err))
+
+	// IMDS goes away, and the last known answers are served
+	now = now.Add(10 * time.Minute)
+	unavailable := errors.New("connection refused")
+	for key := range live {
+		live[key] = unavailable
+	}
+	id, err = typed.GetInstanceID(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, "i-084abd1f69f27d987", id)
+	assert.True(t, cache.degraded)
+	assert.True(t, (&EC2InstanceMetadataCache{imds: typed}).IsIMDSDegraded())
+	assert.Equal(t, 1.0, testutil.ToFloat64(prometheusmetrics.IMDSCacheDegraded))
+	assert.Equal(t, 600.0, testutil.ToFloat64(prometheusmetrics.IMDSCacheAge))
+	_, err = typed.GetSubnetID(context.TODO(), "02:c5:f8:3e:6b:27")
+	assert.True(t, IsNotFound(err), "a 404 is served from the cache as well")
+	live["instance-type"] = unavailable
+	_, err = typed.GetInstanceType(context.TODO())
+	assert.ErrorIs(t, err, unavailable, "metadata that was never cached is not served")
+
+	// aws-node restarts while IMDS is still down
+	restarted := TypedIMDS{newIMDSCache(live, path)}
+	ip, err = restarted.GetLocalIPv4(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.88.3", ip.String())
+
+	assert.False(t, cache.refresh(context.TODO()))
+	live["instance-id"] = "i-084abd1f69f27d987"
+	live["local-ipv4"] = "10.0.88.4"
+	assert.True(t, cache.refresh(context.TODO()))
+	assert.False(t, cache.degraded)
+	assert.False(t, (&EC2InstanceMetadataCache{imds: typed}).IsIMDSDegraded())
+	assert.Equal(t, 0.0, testutil.ToFloat64(prometheusmetrics.IMDSCacheDegraded))
+	assert.Equal(t, 0.0, testutil.ToFloat64(prometheusmetrics.IMDSCacheAge))
+	ip, err = TypedIMDS{newIMDSCache(live, path)}.GetLocalIPv4(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.88.4", ip.String(), "the refreshed metadata is written to the cache file")
+}
+
+func TestLoadIMDSCache(t *testing.T) {
+	live := FakeIMDS(map[string]interface{}{})
+	path := filepath.Join(t.TempDir(), "imds-cache.json")
+	t.Setenv(imdsCachePathEnvVar, path)
+	cache, ok := loadIMDSCache(live).(*imdsCache)
+	require.True(t, ok)
+	assert.Equal(t, path, cache.path)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "entries": {"instance-id": {"content": "i-1"}}}`), 0600))
+	assert.Empty(t, newIMDSCache(live, path).entries, "a cache written by an unknown version is ignored")
+
+	t.Setenv(disableIMDSCacheEnvVar, "true")
+	assert.Equal(t, live, loadIMDSCache(live))
+}
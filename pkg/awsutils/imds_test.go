@@ -32,6 +32,17 @@ func TestGetAZ(t *testing.T) {
 	}
 }
 
+func TestGetRegion(t *testing.T) {
+	f := TypedIMDS{FakeIMDS(map[string]interface{}{
+		"placement/region": "us-west-2",
+	})}
+
+	region, err := f.GetRegion(context.TODO())
+	if assert.NoError(t, err) {
+		assert.Equal(t, region, "us-west-2")
+	}
+}
+
 func TestGetInstanceType(t *testing.T) {
 	f := TypedIMDS{FakeIMDS(map[string]interface{}{
 		"instance-type": "t3.medium",
@@ -115,6 +126,23 @@ func TestGetDeviceNumber(t *testing.T) {
 	}
 }
 
+func TestGetNetworkCard(t *testing.T) {
+	f := TypedIMDS{FakeIMDS(map[string]interface{}{
+		"network/interfaces/macs/02:c5:f8:3e:6b:27/network-card": "1",
+	})}
+
+	n, err := f.GetNetworkCard(context.TODO(), "02:c5:f8:3e:6b:27")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, n)
+	}
+
+	// Instance types with a single network card do not have the key
+	n, err = f.GetNetworkCard(context.TODO(), "00:00:de:ad:be:ef")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, n)
+	}
+}
+
 func TestGetSubnetID(t *testing.T) {
 	f := TypedIMDS{FakeIMDS(map[string]interface{}{
 		"network/interfaces/macs/02:c5:f8:3e:6b:27/subnet-id": "subnet-0afaed81bf542db37",
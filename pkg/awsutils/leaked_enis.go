@@ -0,0 +1,275 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsutils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/eventrecorder"
+	"github.com/aws/amazon-vpc-cni-k8s/utils"
+)
+
+const (
+	// leakedENICleanupDryRunEnvVar makes the leaked ENI cleanup report the ENIs it would delete instead of deleting them
+	leakedENICleanupDryRunEnvVar = "LEAKED_ENI_CLEANUP_DRY_RUN"
+	// leakedENICleanupMinAgeEnvVar is the age in seconds, from the createdAt tag, from which a detached ENI is leaked
+	leakedENICleanupMinAgeEnvVar = "LEAKED_ENI_CLEANUP_MIN_AGE"
+	// leakedENICleanupProtectedTagsEnvVar is a comma separated list of key or key=value tag selectors. ENIs with a
+	// matching tag are never deleted.
+	leakedENICleanupProtectedTagsEnvVar = "LEAKED_ENI_CLEANUP_PROTECTED_TAGS"
+
+	// The actions of the leaked ENI cleanup on an ENI
+	leakedENIActionDeleted      = "deleted"
+	leakedENIActionDeleteFailed = "delete failed"
+	leakedENIActionReported     = "reported"
+	leakedENIActionProtected    = "protected"
+	leakedENIActionTooYoung     = "too young"
+
+	// leakedENIEventMaxENIs is the number of ENIs listed in the event of a cleanup, the report lists all of them
+	leakedENIEventMaxENIs = 10
+)
+
+// LeakedENI is a detached ENI created by the CNI that the leaked ENI cleanup found
+type LeakedENI struct {
+	ENIID     string            `json:"eniID"`
+	SubnetID  string            `json:"subnetID"`
+	Tags      map[string]string `json:"tags"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Age       string            `json:"age"`
+	Action    string            `json:"action"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// LeakedENIReport is the outcome of the last leaked ENI cleanup
+type LeakedENIReport struct {
+	Time          time.Time   `json:"time"`
+	DryRun        bool        `json:"dryRun"`
+	MinAge        string      `json:"minAge"`
+	ProtectedTags []string    `json:"protectedTags"`
+	ENIs          []LeakedENI `json:"enis"`
+}
+
+// tagSelector matches the ENIs that have the tag key, with the value unless matchAnyValue is set
+type tagSelector struct {
+	key           string
+	value         string
+	matchAnyValue bool
+}
+
+func (s tagSelector) String() string {
+	if s.matchAnyValue {
+		return s.key
+	}
+	return s.key + "=" + s.value
+}
+
+func (s tagSelector) matches(tags map[string]string) bool {
+	value, ok := tags[s.key]
+	return ok && (s.matchAnyValue || value == s.value)
+}
+
+// leakedENICleanup holds the settings of the leaked ENI cleanup and its last report. The report is read by
+// introspection, so it has its own lock.
+type leakedENICleanup struct {
+	dryRun        bool
+	minAge        time.Duration
+	protectedTags []tagSelector
+
+	lock   sync.Mutex
+	report *LeakedENIReport
+	// eventKey identifies the leaked ENIs and actions of the last event, so that an unchanged set is not
+	// announced again every run
+	eventKey string
+}
+
+// loadLeakedENICleanup reads the settings of the leaked ENI cleanup from the environment
+func loadLeakedENICleanup() *leakedENICleanup {
+	c := &leakedENICleanup{
+		dryRun:        utils.GetBoolAsStringEnvVar(leakedENICleanupDryRunEnvVar, false),
+		minAge:        eniDeleteCooldownTime,
+		protectedTags: parseTagSelectors(os.Getenv(leakedENICleanupProtectedTagsEnvVar)),
+	}
+	if value := os.Getenv(leakedENICleanupMinAgeEnvVar); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			log.Warnf("Ignoring %s=%s, it is not a number of seconds", leakedENICleanupMinAgeEnvVar, value)
+		} else {
+			c.minAge = time.Duration(seconds) * time.Second
+		}
+	}
+	if c.minAge < eniDeleteCooldownTime {
+		log.Warnf("Raising %s to %v, ENIs younger than that may still be attached", leakedENICleanupMinAgeEnvVar, eniDeleteCooldownTime)
+		c.minAge = eniDeleteCooldownTime
+	}
+	log.Infof("Leaked ENI cleanup: dry run %v, minimum age %v, protected tags %v", c.dryRun, c.minAge, c.protectedTags)
+	return c
+}
+
+// parseTagSelectors parses a comma separated list of key or key=value tag selectors
+func parseTagSelectors(value string) []tagSelector {
+	var selectors []tagSelector
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, value, found := strings.Cut(item, "=")
+		selectors = append(selectors, tagSelector{
+			key:           strings.TrimSpace(key),
+			value:         strings.TrimSpace(value),
+			matchAnyValue: !found,
+		})
+	}
+	return selectors
+}
+
+// minimumAge returns the age from which a detached ENI is leaked, which is never less than eniDeleteCooldownTime
+func (c *leakedENICleanup) minimumAge() time.Duration {
+	if c == nil || c.minAge < eniDeleteCooldownTime {
+		return eniDeleteCooldownTime
+	}
+	return c.minAge
+}
+
+// isDryRun returns whether leaked ENIs are only reported
+func (c *leakedENICleanup) isDryRun() bool {
+	return c != nil && c.dryRun
+}
+
+// protectedBy returns the selector that keeps the ENI with the given tags from being deleted, if any
+func (c *leakedENICleanup) protectedBy(tags map[string]string) (tagSelector, bool) {
+	if c == nil {
+		return tagSelector{}, false
+	}
+	for _, selector := range c.protectedTags {
+		if selector.matches(tags) {
+			return selector, true
+		}
+	}
+	return tagSelector{}, false
+}
+
+// newLeakedENI describes a leaked ENI found at now
+func newLeakedENI(networkInterface ec2types.NetworkInterface, now time.Time) LeakedENI {
+	tags := convertSDKTagsToTags(networkInterface.TagSet)
+	eni := LeakedENI{
+		ENIID:    aws.ToString(networkInterface.NetworkInterfaceId),
+		SubnetID: aws.ToString(networkInterface.SubnetId),
+		Tags:     tags,
+	}
+	// getLeakedENIs only returns ENIs with a valid createdAt tag
+	if createdAt, err := time.Parse(time.RFC3339, tags[eniCreatedAtTagKey]); err == nil {
+		eni.CreatedAt = createdAt
+		eni.Age = now.Sub(createdAt).Truncate(time.Second).String()
+	}
+	return eni
+}
+
+// setReport keeps the report of the last cleanup and announces it with an event on the aws-node pod when the
+// leaked ENIs or their actions changed since the last event. ENIs below the minimum age are not announced.
+func (c *leakedENICleanup) setReport(report LeakedENIReport) {
+	if c == nil {
+		return
+	}
+	key := leakedENIEventKey(report)
+	c.lock.Lock()
+	c.report = &report
+	changed := key != c.eventKey
+	c.eventKey = key
+	c.lock.Unlock()
+
+	if key == "" || !changed {
+		return
+	}
+	if eventRecorder := eventrecorder.Get(); eventRecorder != nil {
+		eventType, reason := v1.EventTypeNormal, "LeakedENIsDeleted"
+		if report.DryRun {
+			reason = "LeakedENIsReported"
+		}
+		for _, eni := range report.ENIs {
+			if eni.Action == leakedENIActionDeleteFailed {
+				eventType = v1.EventTypeWarning
+			}
+		}
+		eventRecorder.SendPodEvent(eventType, reason, "LeakedENICleanup", leakedENIEventMessage(report))
+	}
+}
+
+// getReport returns a copy of the report of the last cleanup, nil before the first cleanup
+func (c *leakedENICleanup) getReport() *LeakedENIReport {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.report == nil {
+		return nil
+	}
+	report := *c.report
+	return &report
+}
+
+// leakedENIEventKey returns the sorted leaked ENIs of the report with their actions, empty when there are none
+func leakedENIEventKey(report LeakedENIReport) string {
+	var enis []string
+	for _, eni := range report.ENIs {
+		if eni.Action != leakedENIActionTooYoung {
+			enis = append(enis, eni.ENIID+"="+eni.Action)
+		}
+	}
+	sort.Strings(enis)
+	return strings.Join(enis, ",")
+}
+
+// leakedENIEventMessage summarizes the report, by action, and lists the first ENIs
+func leakedENIEventMessage(report LeakedENIReport) string {
+	counts := make(map[string]int)
+	var enis []string
+	leaked, tooYoung := 0, 0
+	for _, eni := range report.ENIs {
+		if eni.Action == leakedENIActionTooYoung {
+			tooYoung++
+			continue
+		}
+		leaked++
+		counts[eni.Action]++
+		if len(enis) < leakedENIEventMaxENIs {
+			enis = append(enis, fmt.Sprintf("%s (%s, age %s)", eni.ENIID, eni.Action, eni.Age))
+		}
+	}
+	var actions []string
+	for action, count := range counts {
+		actions = append(actions, fmt.Sprintf("%d %s", count, action))
+	}
+	sort.Strings(actions)
+	message := fmt.Sprintf("Found %d leaked ENIs older than %s: %s. ENIs: %s", leaked, report.MinAge,
+		strings.Join(actions, ", "), strings.Join(enis, ", "))
+	if leaked > leakedENIEventMaxENIs {
+		message += fmt.Sprintf(" and %d more", leaked-leakedENIEventMaxENIs)
+	}
+	if tooYoung > 0 {
+		message += fmt.Sprintf(". %d younger ENIs are not cleaned up yet", tooYoung)
+	}
+	return message + ". See the /v1/leaked-enis introspection endpoint for their tags."
+}
@@ -0,0 +1,133 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/events"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/eventrecorder"
+)
+
+func TestLoadLeakedENICleanup(t *testing.T) {
+	t.Setenv(leakedENICleanupDryRunEnvVar, "true")
+	t.Setenv(leakedENICleanupMinAgeEnvVar, "3600")
+	t.Setenv(leakedENICleanupProtectedTagsEnvVar, "team=shared, do-not-delete ,")
+	c := loadLeakedENICleanup()
+	assert.True(t, c.isDryRun())
+	assert.Equal(t, time.Hour, c.minimumAge())
+	assert.Equal(t, []tagSelector{{key: "team", value: "shared"}, {key: "do-not-delete", matchAnyValue: true}}, c.protectedTags)
+
+	_, ok := c.protectedBy(map[string]string{"team": "other"})
+	assert.False(t, ok)
+	selector, ok := c.protectedBy(map[string]string{"team": "shared"})
+	assert.True(t, ok)
+	assert.Equal(t, "team=shared", selector.String())
+	_, ok = c.protectedBy(map[string]string{"do-not-delete": ""})
+	assert.True(t, ok)
+
+	// ENIs younger than eniDeleteCooldownTime may still be attaching
+	t.Setenv(leakedENICleanupMinAgeEnvVar, "60")
+	assert.Equal(t, eniDeleteCooldownTime, loadLeakedENICleanup().minimumAge())
+	var unset *leakedENICleanup
+	assert.Equal(t, eniDeleteCooldownTime, unset.minimumAge())
+	assert.False(t, unset.isDryRun())
+}
+
+func TestCleanUpLeakedENIsReport(t *testing.T) {
+	createdAt := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	recent := time.Now().Add(-30 * time.Minute).Format(time.RFC3339)
+	leakedENI := func(id string, created string, tags ...string) ec2types.NetworkInterface {
+		tagSet := []ec2types.Tag{
+			{Key: aws.String(eniNodeTagKey), Value: aws.String("i-0e1f3b9eb950e4980")},
+			{Key: aws.String(eniCreatedAtTagKey), Value: aws.String(created)},
+		}
+		for i := 0; i < len(tags); i += 2 {
+			tagSet = append(tagSet, ec2types.Tag{Key: aws.String(tags[i]), Value: aws.String(tags[i+1])})
+		}
+		return ec2types.NetworkInterface{
+			NetworkInterfaceId: aws.String(id),
+			Description:        aws.String(eniDescriptionPrefix + "i-0e1f3b9eb950e4980"),
+			SubnetId:           aws.String(subnetID),
+			TagSet:             tagSet,
+		}
+	}
+	interfaces := []ec2types.NetworkInterface{
+		leakedENI("eni-leaked", createdAt),
+		leakedENI("eni-shared", createdAt, "team", "shared"),
+		leakedENI("eni-recent", recent),
+	}
+	cleanup := &leakedENICleanup{
+		dryRun:        true,
+		minAge:        time.Hour,
+		protectedTags: []tagSelector{{key: "team", value: "shared"}},
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		ctrl, mockEC2 := setup(t)
+		recorder := eventrecorder.Get().Recorder.(*events.FakeRecorder)
+		setupDescribeNetworkInterfacesPagesWithContextMock(t, mockEC2, interfaces, nil, 1)
+		cleanup.dryRun = dryRun
+		wantAction := leakedENIActionReported
+		if !dryRun {
+			wantAction = leakedENIActionDeleted
+			mockEC2.EXPECT().DeleteNetworkInterface(gomock.Any(), &ec2.DeleteNetworkInterfaceInput{
+				NetworkInterfaceId: aws.String("eni-leaked")}, gomock.Any()).Return(nil, nil)
+		}
+
+		cache := &EC2InstanceMetadataCache{ec2SVC: mockEC2, leakedENICleanup: cleanup}
+		cache.cleanUpLeakedENIsInternal(0)
+
+		report := cache.GetLeakedENIReport()
+		require.NotNil(t, report)
+		assert.Equal(t, dryRun, report.DryRun)
+		assert.Equal(t, "1h0m0s", report.MinAge)
+		assert.Equal(t, []string{"team=shared"}, report.ProtectedTags)
+		require.Len(t, report.ENIs, 3)
+		assert.Equal(t, "eni-leaked", report.ENIs[0].ENIID)
+		assert.Equal(t, wantAction, report.ENIs[0].Action)
+		assert.Equal(t, "2h0m0s", report.ENIs[0].Age)
+		assert.Equal(t, subnetID, report.ENIs[0].SubnetID)
+		assert.Equal(t, "eni-shared", report.ENIs[1].ENIID)
+		assert.Equal(t, leakedENIActionProtected, report.ENIs[1].Action)
+		assert.Equal(t, "shared", report.ENIs[1].Tags["team"])
+		assert.Equal(t, "eni-recent", report.ENIs[2].ENIID)
+		assert.Equal(t, leakedENIActionTooYoung, report.ENIs[2].Action)
+		assert.Equal(t, "30m0s", report.ENIs[2].Age)
+
+		event := <-recorder.Events
+		assert.Contains(t, event, "Found 2 leaked ENIs older than 1h0m0s")
+		assert.Contains(t, event, "1 protected")
+		assert.Contains(t, event, "eni-leaked ("+wantAction+", age 2h0m0s)")
+		assert.Contains(t, event, "1 younger ENIs are not cleaned up yet")
+		assert.NotContains(t, event, "eni-recent")
+
+		if dryRun {
+			// Every node reports the same ENIs each run, only a change is announced again
+			setupDescribeNetworkInterfacesPagesWithContextMock(t, mockEC2, interfaces, nil, 1)
+			cache.cleanUpLeakedENIsInternal(0)
+			assert.Len(t, cache.GetLeakedENIReport().ENIs, 3)
+			assert.Empty(t, recorder.Events)
+		}
+		ctrl.Finish()
+	}
+}
@@ -54,7 +54,7 @@ func (m *MockAPIs) EXPECT() *MockAPIsMockRecorder {
 }
 
 // AllocENI mocks base method.
-func (m *MockAPIs) AllocENI(arg0 bool, arg1 []*string, arg2 string, arg3 int) (string, error) {
+func (m *MockAPIs) AllocENI(arg0 bool, arg1 []*string, arg2 []string, arg3 int) (string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "AllocENI", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(string)
@@ -68,6 +68,36 @@ func (mr *MockAPIsMockRecorder) AllocENI(arg0, arg1, arg2, arg3 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocENI", reflect.TypeOf((*MockAPIs)(nil).AllocENI), arg0, arg1, arg2, arg3)
 }
 
+// AllocENIOnNetworkCard mocks base method.
+func (m *MockAPIs) AllocENIOnNetworkCard(arg0 bool, arg1 []*string, arg2 []string, arg3 int, arg4 map[string]string, arg5 int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocENIOnNetworkCard", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocENIOnNetworkCard indicates an expected call of AllocENIOnNetworkCard.
+func (mr *MockAPIsMockRecorder) AllocENIOnNetworkCard(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocENIOnNetworkCard", reflect.TypeOf((*MockAPIs)(nil).AllocENIOnNetworkCard), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// AllocENIWithTags mocks base method.
+func (m *MockAPIs) AllocENIWithTags(arg0 bool, arg1 []*string, arg2 []string, arg3 int, arg4 map[string]string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocENIWithTags", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocENIWithTags indicates an expected call of AllocENIWithTags.
+func (mr *MockAPIsMockRecorder) AllocENIWithTags(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocENIWithTags", reflect.TypeOf((*MockAPIs)(nil).AllocENIWithTags), arg0, arg1, arg2, arg3, arg4)
+}
+
 // AllocIPAddress mocks base method.
 func (m *MockAPIs) AllocIPAddress(arg0 string) error {
 	m.ctrl.T.Helper()
@@ -112,6 +142,21 @@ func (mr *MockAPIsMockRecorder) AllocIPv6Prefixes(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocIPv6Prefixes", reflect.TypeOf((*MockAPIs)(nil).AllocIPv6Prefixes), arg0)
 }
 
+// AllocSpecificIPAddress mocks base method.
+func (m *MockAPIs) AllocSpecificIPAddress(arg0 string, arg1 net.IP) (*ec2.AssignPrivateIpAddressesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocSpecificIPAddress", arg0, arg1)
+	ret0, _ := ret[0].(*ec2.AssignPrivateIpAddressesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocSpecificIPAddress indicates an expected call of AllocSpecificIPAddress.
+func (mr *MockAPIsMockRecorder) AllocSpecificIPAddress(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocSpecificIPAddress", reflect.TypeOf((*MockAPIs)(nil).AllocSpecificIPAddress), arg0, arg1)
+}
+
 // DeallocIPAddresses mocks base method.
 func (m *MockAPIs) DeallocIPAddresses(arg0 string, arg1 []string) error {
 	m.ctrl.T.Helper()
@@ -155,6 +200,36 @@ func (mr *MockAPIsMockRecorder) DescribeAllENIs() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeAllENIs", reflect.TypeOf((*MockAPIs)(nil).DescribeAllENIs))
 }
 
+// DescribeENIAddresses mocks base method.
+func (m *MockAPIs) DescribeENIAddresses(arg0 []string) (map[string]awsutils.ENIAddresses, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeENIAddresses", arg0)
+	ret0, _ := ret[0].(map[string]awsutils.ENIAddresses)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeENIAddresses indicates an expected call of DescribeENIAddresses.
+func (mr *MockAPIsMockRecorder) DescribeENIAddresses(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeENIAddresses", reflect.TypeOf((*MockAPIs)(nil).DescribeENIAddresses), arg0)
+}
+
+// DescribeENIConfigNetwork mocks base method.
+func (m *MockAPIs) DescribeENIConfigNetwork(arg0, arg1 []string) (awsutils.ENIConfigNetwork, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeENIConfigNetwork", arg0, arg1)
+	ret0, _ := ret[0].(awsutils.ENIConfigNetwork)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeENIConfigNetwork indicates an expected call of DescribeENIConfigNetwork.
+func (mr *MockAPIsMockRecorder) DescribeENIConfigNetwork(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeENIConfigNetwork", reflect.TypeOf((*MockAPIs)(nil).DescribeENIConfigNetwork), arg0, arg1)
+}
+
 // FetchInstanceTypeLimits mocks base method.
 func (m *MockAPIs) FetchInstanceTypeLimits() error {
 	m.ctrl.T.Helper()
@@ -226,36 +301,6 @@ func (mr *MockAPIsMockRecorder) GetENILimit() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetENILimit", reflect.TypeOf((*MockAPIs)(nil).GetENILimit))
 }
 
-// GetIPv4PrefixesFromEC2 mocks base method.
-func (m *MockAPIs) GetIPv4PrefixesFromEC2(arg0 string) ([]types.Ipv4PrefixSpecification, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetIPv4PrefixesFromEC2", arg0)
-	ret0, _ := ret[0].([]types.Ipv4PrefixSpecification)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// GetIPv4PrefixesFromEC2 indicates an expected call of GetIPv4PrefixesFromEC2.
-func (mr *MockAPIsMockRecorder) GetIPv4PrefixesFromEC2(arg0 interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIPv4PrefixesFromEC2", reflect.TypeOf((*MockAPIs)(nil).GetIPv4PrefixesFromEC2), arg0)
-}
-
-// GetIPv4sFromEC2 mocks base method.
-func (m *MockAPIs) GetIPv4sFromEC2(arg0 string) ([]types.NetworkInterfacePrivateIpAddress, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetIPv4sFromEC2", arg0)
-	ret0, _ := ret[0].([]types.NetworkInterfacePrivateIpAddress)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// GetIPv4sFromEC2 indicates an expected call of GetIPv4sFromEC2.
-func (mr *MockAPIsMockRecorder) GetIPv4sFromEC2(arg0 interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIPv4sFromEC2", reflect.TypeOf((*MockAPIs)(nil).GetIPv4sFromEC2), arg0)
-}
-
 // GetIPv6PrefixesFromEC2 mocks base method.
 func (m *MockAPIs) GetIPv6PrefixesFromEC2(arg0 string) ([]types.Ipv6PrefixSpecification, error) {
 	m.ctrl.T.Helper()
@@ -313,6 +358,20 @@ func (mr *MockAPIsMockRecorder) GetInstanceType() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceType", reflect.TypeOf((*MockAPIs)(nil).GetInstanceType))
 }
 
+// GetLeakedENIReport mocks base method.
+func (m *MockAPIs) GetLeakedENIReport() *awsutils.LeakedENIReport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLeakedENIReport")
+	ret0, _ := ret[0].(*awsutils.LeakedENIReport)
+	return ret0
+}
+
+// GetLeakedENIReport indicates an expected call of GetLeakedENIReport.
+func (mr *MockAPIsMockRecorder) GetLeakedENIReport() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeakedENIReport", reflect.TypeOf((*MockAPIs)(nil).GetLeakedENIReport))
+}
+
 // GetLocalIPv4 mocks base method.
 func (m *MockAPIs) GetLocalIPv4() net.IP {
 	m.ctrl.T.Helper()
@@ -369,6 +428,20 @@ func (mr *MockAPIsMockRecorder) GetPrimaryENImac() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrimaryENImac", reflect.TypeOf((*MockAPIs)(nil).GetPrimaryENImac))
 }
 
+// GetSubnetDiscoveryState mocks base method.
+func (m *MockAPIs) GetSubnetDiscoveryState() awsutils.SubnetDiscoveryState {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubnetDiscoveryState")
+	ret0, _ := ret[0].(awsutils.SubnetDiscoveryState)
+	return ret0
+}
+
+// GetSubnetDiscoveryState indicates an expected call of GetSubnetDiscoveryState.
+func (mr *MockAPIsMockRecorder) GetSubnetDiscoveryState() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubnetDiscoveryState", reflect.TypeOf((*MockAPIs)(nil).GetSubnetDiscoveryState))
+}
+
 // GetVPCIPv4CIDRs mocks base method.
 func (m *MockAPIs) GetVPCIPv4CIDRs() ([]string, error) {
 	m.ctrl.T.Helper()
@@ -399,6 +472,18 @@ func (mr *MockAPIsMockRecorder) GetVPCIPv6CIDRs() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVPCIPv6CIDRs", reflect.TypeOf((*MockAPIs)(nil).GetVPCIPv6CIDRs))
 }
 
+// InitCachedMultiCardENIs mocks base method.
+func (m *MockAPIs) InitCachedMultiCardENIs(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "InitCachedMultiCardENIs", arg0)
+}
+
+// InitCachedMultiCardENIs indicates an expected call of InitCachedMultiCardENIs.
+func (mr *MockAPIsMockRecorder) InitCachedMultiCardENIs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitCachedMultiCardENIs", reflect.TypeOf((*MockAPIs)(nil).InitCachedMultiCardENIs), arg0)
+}
+
 // InitCachedPrefixDelegation mocks base method.
 func (m *MockAPIs) InitCachedPrefixDelegation(arg0 bool) {
 	m.ctrl.T.Helper()
@@ -411,6 +496,20 @@ func (mr *MockAPIsMockRecorder) InitCachedPrefixDelegation(arg0 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitCachedPrefixDelegation", reflect.TypeOf((*MockAPIs)(nil).InitCachedPrefixDelegation), arg0)
 }
 
+// IsIMDSDegraded mocks base method.
+func (m *MockAPIs) IsIMDSDegraded() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsIMDSDegraded")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsIMDSDegraded indicates an expected call of IsIMDSDegraded.
+func (mr *MockAPIsMockRecorder) IsIMDSDegraded() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsIMDSDegraded", reflect.TypeOf((*MockAPIs)(nil).IsIMDSDegraded))
+}
+
 // IsMultiCardENI mocks base method.
 func (m *MockAPIs) IsMultiCardENI(arg0 string) bool {
 	m.ctrl.T.Helper()
@@ -0,0 +1,198 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsutils
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	smithymiddleware "github.com/aws/smithy-go/middleware"
+	"golang.org/x/time/rate"
+
+	"github.com/aws/amazon-vpc-cni-k8s/utils/prometheusmetrics"
+)
+
+const (
+	ec2DescribeRateLimitEnvVar = "EC2_DESCRIBE_RATE_LIMIT"
+	ec2DescribeBurstEnvVar     = "EC2_DESCRIBE_BURST"
+	ec2MutatingRateLimitEnvVar = "EC2_MUTATING_RATE_LIMIT"
+	ec2MutatingBurstEnvVar     = "EC2_MUTATING_BURST"
+
+	defaultEC2DescribeRateLimit = 10
+	defaultEC2DescribeBurst     = 20
+	defaultEC2MutatingRateLimit = 5
+	defaultEC2MutatingBurst     = 10
+
+	// After a throttled call the rate of the API is multiplied by throttleBackoffFactor, down to
+	// 1/throttleRecoverySteps of its budget, and every successful call gives back 1/throttleRecoverySteps of the budget
+	throttleBackoffFactor = 0.5
+	throttleRecoverySteps = 20
+)
+
+// ec2RateBudget is the token bucket size and refill rate, in calls per second, given to each API of a class of EC2
+// APIs. An infinite rate disables rate limiting.
+type ec2RateBudget struct {
+	rate  rate.Limit
+	burst int
+}
+
+// ec2RateLimiter paces EC2 calls with one token bucket per API. Describe APIs and mutating APIs get separate budgets,
+// mirroring how EC2 throttles them, and the rate of an API backs off when EC2 throttles it and recovers as its calls
+// succeed again. It is installed as a middleware after the SDK retryer, so that every attempt takes a token.
+type ec2RateLimiter struct {
+	describe ec2RateBudget
+	mutating ec2RateBudget
+
+	mu      sync.Mutex
+	buckets map[string]*ec2APIBucket
+}
+
+type ec2APIBucket struct {
+	budget  ec2RateBudget
+	limiter *rate.Limiter
+}
+
+func newEC2RateLimiter(describe, mutating ec2RateBudget) *ec2RateLimiter {
+	return &ec2RateLimiter{
+		describe: describe,
+		mutating: mutating,
+		buckets:  make(map[string]*ec2APIBucket),
+	}
+}
+
+// loadEC2RateLimiter returns a rate limiter with the budgets from the environment
+func loadEC2RateLimiter() *ec2RateLimiter {
+	describe := ec2RateBudget{
+		rate:  parseRateLimit(ec2DescribeRateLimitEnvVar, defaultEC2DescribeRateLimit),
+		burst: parseBurst(ec2DescribeBurstEnvVar, defaultEC2DescribeBurst),
+	}
+	mutating := ec2RateBudget{
+		rate:  parseRateLimit(ec2MutatingRateLimitEnvVar, defaultEC2MutatingRateLimit),
+		burst: parseBurst(ec2MutatingBurstEnvVar, defaultEC2MutatingBurst),
+	}
+	log.Infof("EC2 rate limits: describe %v/s (burst %d), mutating %v/s (burst %d)",
+		describe.rate, describe.burst, mutating.rate, mutating.burst)
+	return newEC2RateLimiter(describe, mutating)
+}
+
+// parseRateLimit reads a rate in calls per second. Zero disables rate limiting.
+func parseRateLimit(envVar string, defaultValue float64) rate.Limit {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return rate.Limit(defaultValue)
+	}
+	limit, err := strconv.ParseFloat(value, 64)
+	if err != nil || limit < 0 {
+		log.Warnf("Invalid %s %q, using the default of %v", envVar, value, defaultValue)
+		return rate.Limit(defaultValue)
+	}
+	if limit == 0 {
+		return rate.Inf
+	}
+	return rate.Limit(limit)
+}
+
+func parseBurst(envVar string, defaultValue int) int {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultValue
+	}
+	burst, err := strconv.Atoi(value)
+	if err != nil || burst < 1 {
+		log.Warnf("Invalid %s %q, using the default of %d", envVar, value, defaultValue)
+		return defaultValue
+	}
+	return burst
+}
+
+// bucket returns the token bucket of an API, creating it on first use
+func (l *ec2RateLimiter) bucket(api string) *ec2APIBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[api]
+	if !ok {
+		budget := l.mutating
+		if strings.HasPrefix(api, "Describe") {
+			budget = l.describe
+		}
+		b = &ec2APIBucket{budget: budget, limiter: rate.NewLimiter(budget.rate, budget.burst)}
+		l.buckets[api] = b
+		prometheusmetrics.AwsAPIRateLimit.WithLabelValues(api).Set(float64(budget.rate))
+	}
+	return b
+}
+
+// wait blocks until the API has a token
+func (l *ec2RateLimiter) wait(ctx context.Context, api string) error {
+	b := l.bucket(api)
+	start := time.Now()
+	err := b.limiter.Wait(ctx)
+	prometheusmetrics.AwsAPIRateLimitWait.WithLabelValues(api).Observe(msSince(start))
+	return err
+}
+
+// observe adapts the rate of the API to the outcome of a call
+func (l *ec2RateLimiter) observe(api string, err error) {
+	throttled := err != nil && retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err).Bool()
+	if throttled {
+		prometheusmetrics.AwsAPIThrottled.WithLabelValues(api).Inc()
+	}
+	b := l.bucket(api)
+	if b.budget.rate == rate.Inf || (err != nil && !throttled) {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	current := b.limiter.Limit()
+	next := current
+	if throttled {
+		next = max(current*throttleBackoffFactor, b.budget.rate/throttleRecoverySteps)
+		log.Warnf("EC2 throttled %s, lowering its rate limit from %.2f/s to %.2f/s", api, current, next)
+	} else if current < b.budget.rate {
+		next = min(current+b.budget.rate/throttleRecoverySteps, b.budget.rate)
+	}
+	if next != current {
+		b.limiter.SetLimit(next)
+		prometheusmetrics.AwsAPIRateLimit.WithLabelValues(api).Set(float64(next))
+	}
+}
+
+// ID implements smithymiddleware.FinalizeMiddleware
+func (l *ec2RateLimiter) ID() string {
+	return "EC2RateLimiter"
+}
+
+// HandleFinalize implements smithymiddleware.FinalizeMiddleware
+func (l *ec2RateLimiter) HandleFinalize(ctx context.Context, in smithymiddleware.FinalizeInput, next smithymiddleware.FinalizeHandler) (
+	out smithymiddleware.FinalizeOutput, metadata smithymiddleware.Metadata, err error) {
+	api := awsmiddleware.GetOperationName(ctx)
+	if err := l.wait(ctx, api); err != nil {
+		return out, metadata, err
+	}
+	out, metadata, err = next.HandleFinalize(ctx, in)
+	l.observe(api, err)
+	return out, metadata, err
+}
+
+// addToStack installs the rate limiter right after the retryer, so that it sees every attempt
+func (l *ec2RateLimiter) addToStack(stack *smithymiddleware.Stack) error {
+	return stack.Finalize.Insert(l, (&retry.Attempt{}).ID(), smithymiddleware.After)
+}
@@ -0,0 +1,113 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsutils
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go"
+	smithymiddleware "github.com/aws/smithy-go/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils/fakeaws"
+)
+
+func TestLoadEC2RateLimiter(t *testing.T) {
+	t.Setenv(ec2DescribeRateLimitEnvVar, "2.5")
+	t.Setenv(ec2DescribeBurstEnvVar, "3")
+	t.Setenv(ec2MutatingRateLimitEnvVar, "0")
+	t.Setenv(ec2MutatingBurstEnvVar, "-1")
+	l := loadEC2RateLimiter()
+	assert.Equal(t, ec2RateBudget{rate: 2.5, burst: 3}, l.describe)
+	assert.Equal(t, ec2RateBudget{rate: rate.Inf, burst: defaultEC2MutatingBurst}, l.mutating)
+
+	t.Setenv(ec2DescribeRateLimitEnvVar, "fast")
+	assert.Equal(t, rate.Limit(defaultEC2DescribeRateLimit), loadEC2RateLimiter().describe.rate)
+}
+
+func TestEC2RateLimiterBudgets(t *testing.T) {
+	l := newEC2RateLimiter(ec2RateBudget{rate: 10, burst: 20}, ec2RateBudget{rate: 5, burst: 10})
+	assert.Equal(t, rate.Limit(10), l.bucket("DescribeNetworkInterfaces").limiter.Limit())
+	assert.Equal(t, 20, l.bucket("DescribeNetworkInterfaces").limiter.Burst())
+	assert.Equal(t, rate.Limit(5), l.bucket("AssignPrivateIpAddresses").limiter.Limit())
+	assert.Equal(t, 10, l.bucket("CreateTags").limiter.Burst())
+	assert.NotSame(t, l.bucket("CreateTags"), l.bucket("AssignPrivateIpAddresses"), "each API has its own bucket")
+}
+
+func TestEC2RateLimiterAdaptive(t *testing.T) {
+	l := newEC2RateLimiter(ec2RateBudget{rate: 10, burst: 20}, ec2RateBudget{rate: 4, burst: 10})
+	throttle := &smithy.GenericAPIError{Code: "RequestLimitExceeded"}
+	limit := func() rate.Limit { return l.bucket("AssignPrivateIpAddresses").limiter.Limit() }
+
+	l.observe("AssignPrivateIpAddresses", throttle)
+	assert.Equal(t, rate.Limit(2), limit())
+	for i := 0; i < 10; i++ {
+		l.observe("AssignPrivateIpAddresses", throttle)
+	}
+	assert.Equal(t, rate.Limit(0.2), limit(), "the rate does not drop below 1/throttleRecoverySteps of the budget")
+
+	l.observe("AssignPrivateIpAddresses", &smithy.GenericAPIError{Code: "InsufficientFreeAddressesInSubnet"})
+	assert.Equal(t, rate.Limit(0.2), limit(), "other errors do not change the rate")
+	l.observe("AssignPrivateIpAddresses", nil)
+	assert.InDelta(t, 0.4, float64(limit()), 1e-9)
+	for i := 0; i < throttleRecoverySteps; i++ {
+		l.observe("AssignPrivateIpAddresses", nil)
+	}
+	assert.Equal(t, rate.Limit(4), limit(), "the rate recovers up to the budget")
+	assert.Equal(t, rate.Limit(10), l.bucket("DescribeNetworkInterfaces").limiter.Limit(), "other APIs are not affected")
+
+	unlimited := newEC2RateLimiter(ec2RateBudget{rate: rate.Inf}, ec2RateBudget{rate: rate.Inf})
+	unlimited.observe("CreateTags", throttle)
+	assert.Equal(t, rate.Inf, unlimited.bucket("CreateTags").limiter.Limit())
+}
+
+func TestEC2RateLimiterMiddleware(t *testing.T) {
+	fake, err := fakeaws.New(fakeaws.Config{})
+	require.NoError(t, err)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	l := newEC2RateLimiter(ec2RateBudget{rate: 100, burst: 10}, ec2RateBudget{rate: 100, burst: 10})
+	client := ec2.NewFromConfig(aws.Config{
+		Region:      fakeaws.DefaultRegion,
+		Credentials: credentials.NewStaticCredentialsProvider("id", "secret", ""),
+		APIOptions:  []func(*smithymiddleware.Stack) error{l.addToStack},
+	}, func(o *ec2.Options) {
+		o.BaseEndpoint = aws.String(ts.URL)
+	})
+
+	// The SDK retries the throttled attempt, and the limiter sees both attempts
+	fake.InjectFault(fakeaws.Fault{Action: "AssignPrivateIpAddresses", Code: fakeaws.ErrCodeRequestLimitExceeded, Count: 1})
+	eniID := fake.ENIs()[0].ID
+	_, err = client.AssignPrivateIpAddresses(context.Background(), &ec2.AssignPrivateIpAddressesInput{
+		NetworkInterfaceId:             aws.String(eniID),
+		SecondaryPrivateIpAddressCount: aws.Int32(1),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, fake.Calls()["AssignPrivateIpAddresses"])
+	assert.Equal(t, rate.Limit(55), l.bucket("AssignPrivateIpAddresses").limiter.Limit())
+
+	_, err = client.DescribeNetworkInterfaces(context.Background(), &ec2.DescribeNetworkInterfacesInput{
+		NetworkInterfaceIds: []string{eniID},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, l.buckets, "DescribeNetworkInterfaces")
+}
@@ -0,0 +1,182 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsutils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+const (
+	// subnetDiscoveryPriorityTagKey ranks the subnets of subnet discovery, the higher the number the earlier the subnet
+	// is tried. Subnets without the tag have priority 0.
+	subnetDiscoveryPriorityTagKey = "kubernetes.io/role/cni-priority"
+	// subnetDiscoveryExcludeTagKey keeps subnet discovery from creating ENIs in the subnet, even in the subnet of the node
+	subnetDiscoveryExcludeTagKey = "kubernetes.io/role/cni-exclude"
+	// eniSubnetDiscoveryTagKey records on an ENI why subnet discovery created it in its subnet
+	eniSubnetDiscoveryTagKey = "node.k8s.amazonaws.com/subnet-discovery"
+	// subnetBlacklistDuration is how long subnet discovery skips a subnet that ran out of addresses or prefixes
+	subnetBlacklistDuration = 5 * time.Minute
+)
+
+// SubnetCandidate is a subnet that subnet discovery considered for an ENI
+type SubnetCandidate struct {
+	SubnetID                string `json:"subnetID"`
+	AvailableIPAddressCount int32  `json:"availableIPAddressCount"`
+	Priority                int    `json:"priority"`
+	// Skipped is why the subnet was not tried, empty for the ranked subnets
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// SubnetSelection is the subnet that subnet discovery created an ENI in, and why
+type SubnetSelection struct {
+	SubnetID   string            `json:"subnetID"`
+	Reason     string            `json:"reason"`
+	Time       time.Time         `json:"time"`
+	Candidates []SubnetCandidate `json:"candidates"`
+}
+
+// SubnetDiscoveryState is the last subnet selection and the subnets that are skipped after running out of addresses
+type SubnetDiscoveryState struct {
+	LastSelection *SubnetSelection `json:"lastSelection,omitempty"`
+	// Blacklist maps subnets to when subnet discovery tries them again
+	Blacklist map[string]time.Time `json:"blacklist"`
+}
+
+// subnetDiscovery keeps the state of subnet discovery between ENI allocations. It is read by introspection, so it has
+// its own lock.
+type subnetDiscovery struct {
+	lock          sync.Mutex
+	blacklist     map[string]time.Time
+	lastSelection *SubnetSelection
+}
+
+// rank returns the subnets to try for an ENI, by priority and then by free addresses, and all subnets considered.
+// The subnet of the node and the subnets with the subnet discovery tag are considered.
+func (d *subnetDiscovery) rank(subnets []ec2types.Subnet, nodeSubnetID string, now time.Time) (ranked, considered []SubnetCandidate) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for _, subnet := range subnets {
+		subnetID := aws.ToString(subnet.SubnetId)
+		if subnetID != nodeSubnetID && !validTag(subnet) {
+			continue
+		}
+		candidate := SubnetCandidate{
+			SubnetID:                subnetID,
+			AvailableIPAddressCount: aws.ToInt32(subnet.AvailableIpAddressCount),
+			Priority:                subnetPriority(subnet),
+		}
+		if until, ok := d.blacklist[subnetID]; ok && now.Before(until) {
+			candidate.Skipped = fmt.Sprintf("out of addresses until %s", until.Format(time.RFC3339))
+		} else if hasTag(subnet, subnetDiscoveryExcludeTagKey) {
+			candidate.Skipped = "excluded"
+		} else {
+			ranked = append(ranked, candidate)
+		}
+		considered = append(considered, candidate)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Priority != ranked[j].Priority {
+			return ranked[i].Priority > ranked[j].Priority
+		}
+		return ranked[i].AvailableIPAddressCount > ranked[j].AvailableIPAddressCount
+	})
+	return ranked, considered
+}
+
+// blacklistSubnet skips the subnet for subnetBlacklistDuration
+func (d *subnetDiscovery) blacklistSubnet(subnetID string, now time.Time) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.blacklist == nil {
+		d.blacklist = make(map[string]time.Time)
+	}
+	d.blacklist[subnetID] = now.Add(subnetBlacklistDuration)
+	log.Warnf("Subnet discovery skips subnet %s for %v, it is out of addresses", subnetID, subnetBlacklistDuration)
+}
+
+// recordSelection remembers the subnet an ENI was created in
+func (d *subnetDiscovery) recordSelection(selection SubnetSelection) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.lastSelection = &selection
+}
+
+// state returns a copy of the state, without expired blacklist entries
+func (d *subnetDiscovery) state(now time.Time) SubnetDiscoveryState {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	state := SubnetDiscoveryState{Blacklist: make(map[string]time.Time)}
+	for subnetID, until := range d.blacklist {
+		if now.Before(until) {
+			state.Blacklist[subnetID] = until
+		} else {
+			delete(d.blacklist, subnetID)
+		}
+	}
+	if d.lastSelection != nil {
+		selection := *d.lastSelection
+		state.LastSelection = &selection
+	}
+	return state
+}
+
+// subnetSelectionReason explains why the candidate at index i of the ranked subnets was chosen
+func subnetSelectionReason(ranked []SubnetCandidate, i int) string {
+	return fmt.Sprintf("rank %d of %d, priority %d, %d free addresses", i+1, len(ranked), ranked[i].Priority,
+		ranked[i].AvailableIPAddressCount)
+}
+
+// skippedSubnets lists the skipped candidates and why they were skipped
+func skippedSubnets(candidates []SubnetCandidate) string {
+	var skipped []string
+	for _, candidate := range candidates {
+		if candidate.Skipped != "" {
+			skipped = append(skipped, fmt.Sprintf("%s: %s", candidate.SubnetID, candidate.Skipped))
+		}
+	}
+	return strings.Join(skipped, ", ")
+}
+
+// subnetPriority returns the value of the priority tag of the subnet, 0 when it is missing or not a number
+func subnetPriority(subnet ec2types.Subnet) int {
+	for _, tag := range subnet.Tags {
+		if aws.ToString(tag.Key) == subnetDiscoveryPriorityTagKey {
+			priority, err := strconv.Atoi(aws.ToString(tag.Value))
+			if err != nil {
+				log.Warnf("Ignoring tag %s=%s of subnet %s, it is not a number", subnetDiscoveryPriorityTagKey,
+					aws.ToString(tag.Value), aws.ToString(subnet.SubnetId))
+				return 0
+			}
+			return priority
+		}
+	}
+	return 0
+}
+
+func hasTag(subnet ec2types.Subnet, key string) bool {
+	for _, tag := range subnet.Tags {
+		if aws.ToString(tag.Key) == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsutils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSubnet(id string, free int32, tags map[string]string) ec2types.Subnet {
+	return ec2types.Subnet{
+		SubnetId:                aws.String(id),
+		AvailableIpAddressCount: aws.Int32(free),
+		Tags:                    convertTagsToSDKTags(tags),
+	}
+}
+
+func TestSubnetDiscoveryRank(t *testing.T) {
+	now := time.Unix(1700000000, 0).UTC()
+	cni := map[string]string{subnetDiscoveryTagKey: "1"}
+	subnets := []ec2types.Subnet{
+		testSubnet("subnet-most-free", 500, cni),
+		testSubnet("subnet-untagged", 400, nil),
+		testSubnet("subnet-excluded", 300, map[string]string{subnetDiscoveryTagKey: "1", subnetDiscoveryExcludeTagKey: "true"}),
+		testSubnet("subnet-node", 200, nil),
+		testSubnet("subnet-preferred", 100, map[string]string{subnetDiscoveryTagKey: "1", subnetDiscoveryPriorityTagKey: "10"}),
+		testSubnet("subnet-bad-priority", 50, map[string]string{subnetDiscoveryTagKey: "1", subnetDiscoveryPriorityTagKey: "high"}),
+		testSubnet("subnet-exhausted", 0, map[string]string{subnetDiscoveryTagKey: "1", subnetDiscoveryPriorityTagKey: "20"}),
+	}
+
+	var d subnetDiscovery
+	d.blacklistSubnet("subnet-exhausted", now)
+	ranked, considered := d.rank(subnets, "subnet-node", now)
+
+	var rankedIDs []string
+	for _, candidate := range ranked {
+		rankedIDs = append(rankedIDs, candidate.SubnetID)
+	}
+	assert.Equal(t, []string{"subnet-preferred", "subnet-most-free", "subnet-node", "subnet-bad-priority"}, rankedIDs)
+	assert.Equal(t, "rank 1 of 4, priority 10, 100 free addresses", subnetSelectionReason(ranked, 0))
+	assert.Len(t, considered, 6)
+	assert.Equal(t, "subnet-excluded: excluded, subnet-exhausted: out of addresses until 2023-11-14T22:18:20Z",
+		skippedSubnets(considered))
+
+	// The subnet is tried again once its blacklist entry expired
+	later := now.Add(subnetBlacklistDuration)
+	ranked, _ = d.rank(subnets, "subnet-node", later)
+	assert.Equal(t, "subnet-exhausted", ranked[0].SubnetID)
+	assert.Empty(t, d.state(later).Blacklist)
+}
+
+func TestAllocENISubnetDiscovery(t *testing.T) {
+	ctrl, mockEC2 := setup(t)
+	defer ctrl.Finish()
+
+	subnets := &ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+		testSubnet("subnet-full", 10, map[string]string{subnetDiscoveryTagKey: "1", subnetDiscoveryPriorityTagKey: "5"}),
+		testSubnet(subnetID, 5, nil),
+	}}
+	mockEC2.EXPECT().DescribeSubnets(gomock.Any(), gomock.Any(), gomock.Any()).Return(subnets, nil).Times(2)
+
+	currentEniID := eniID
+	eni := ec2.CreateNetworkInterfaceOutput{NetworkInterface: &ec2types.NetworkInterface{NetworkInterfaceId: &currentEniID}}
+	exhausted := &smithy.GenericAPIError{Code: "InsufficientCidrBlocks", Message: "no /28 left"}
+	// The subnet and the subnet discovery tag of each CreateNetworkInterface call
+	var created [][2]string
+	mockEC2.EXPECT().CreateNetworkInterface(gomock.Any(), gomock.Any(), gomock.Any()).Times(3).DoAndReturn(
+		func(_ context.Context, input *ec2.CreateNetworkInterfaceInput, _ ...func(*ec2.Options)) (*ec2.CreateNetworkInterfaceOutput, error) {
+			tags := convertSDKTagsToTags(input.TagSpecifications[0].Tags)
+			created = append(created, [2]string{aws.ToString(input.SubnetId), tags[eniSubnetDiscoveryTagKey]})
+			if aws.ToString(input.SubnetId) == "subnet-full" {
+				return nil, exhausted
+			}
+			return &eni, nil
+		})
+
+	cache := &EC2InstanceMetadataCache{
+		ec2SVC:             mockEC2,
+		subnetID:           subnetID,
+		instanceType:       "t3.xlarge",
+		useSubnetDiscovery: true,
+	}
+	_, err := cache.createENI(false, nil, nil, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+	assert.Equal(t, [2]string{"subnet-full", "rank 1 of 2, priority 5, 10 free addresses"}, created[0])
+	assert.Equal(t, [2]string{subnetID, "rank 2 of 2, priority 0, 5 free addresses"}, created[1])
+
+	state := cache.GetSubnetDiscoveryState()
+	assert.Equal(t, subnetID, state.LastSelection.SubnetID)
+	assert.Contains(t, state.Blacklist, "subnet-full")
+
+	// The exhausted subnet is not tried again while it is blacklisted
+	_, err = cache.createENI(false, nil, nil, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, created, 3)
+	assert.Equal(t, [2]string{subnetID, "rank 1 of 1, priority 0, 5 free addresses"}, created[2])
+}
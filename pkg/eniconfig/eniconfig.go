@@ -22,6 +22,7 @@ import (
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/apis/crd/v1alpha1"
@@ -38,6 +39,9 @@ const (
 	// it is meant to be used for out-of-band mananagement of the eniConfig - i.e. on the kubelet or elsewhere
 	externalEniConfigLabel = "vpc.amazonaws.com/externalEniConfig"
 
+	// nodeListPageSize is the number of nodes read per request when counting the nodes using an ENIConfig
+	nodeListPageSize = 500
+
 	// when "ENI_CONFIG_LABEL_DEF is defined, ENIConfigController will use that label key to
 	// search if is setting value for eniConfigLabelDef
 	// Example:
@@ -81,8 +85,13 @@ func MyENIConfig(ctx context.Context, k8sClient client.Client) (*v1alpha1.ENICon
 	}
 
 	log.Infof("Found ENI Config Name: %s", eniConfigName)
+	return GetENIConfig(ctx, k8sClient, eniConfigName)
+}
+
+// GetENIConfig returns the ENIConfig with the given name
+func GetENIConfig(ctx context.Context, k8sClient client.Client, eniConfigName string) (*v1alpha1.ENIConfigSpec, error) {
 	var eniConfig v1alpha1.ENIConfig
-	err = k8sClient.Get(ctx, types.NamespacedName{Name: eniConfigName}, &eniConfig)
+	err := k8sClient.Get(ctx, types.NamespacedName{Name: eniConfigName}, &eniConfig)
 	if err != nil {
 		log.Errorf("error while retrieving eniconfig: %s", err)
 		return nil, ErrNoENIConfig
@@ -91,6 +100,7 @@ func MyENIConfig(ctx context.Context, k8sClient client.Client) (*v1alpha1.ENICon
 	return &v1alpha1.ENIConfigSpec{
 		SecurityGroups: eniConfig.Spec.SecurityGroups,
 		Subnet:         eniConfig.Spec.Subnet,
+		Subnets:        eniConfig.Spec.Subnets,
 	}, nil
 }
 
@@ -140,3 +150,28 @@ func GetNodeSpecificENIConfigName(node corev1.Node) (string, error) {
 
 	return eniConfigName, nil
 }
+
+// CountNodesUsingENIConfig returns the number of nodes whose ENIConfig is eniConfigName. Only the node metadata is
+// listed, and k8sClient must read from the API server since the ipamd cache only holds the local node.
+func CountNodesUsingENIConfig(ctx context.Context, k8sClient client.Reader, eniConfigName string) (int, error) {
+	count := 0
+	continueToken := ""
+	for {
+		nodes := &metav1.PartialObjectMetadataList{}
+		nodes.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NodeList"))
+		if err := k8sClient.List(ctx, nodes, client.Limit(nodeListPageSize), client.Continue(continueToken)); err != nil {
+			return 0, errors.Wrap(err, "eniconfig: failed to list nodes")
+		}
+		for _, item := range nodes.Items {
+			var node corev1.Node
+			node.ObjectMeta = item.ObjectMeta
+			if name, err := GetNodeSpecificENIConfigName(node); err == nil && name == eniConfigName {
+				count++
+			}
+		}
+		continueToken = nodes.GetContinue()
+		if continueToken == "" {
+			return count, nil
+		}
+	}
+}
@@ -270,3 +270,28 @@ func TestGetEniConfigLabelDefCustom(t *testing.T) {
 	eniConfigLabelDef := getEniConfigLabelDef()
 	assert.Equal(t, eniConfigLabelDef, "k8s.amazonaws.com/eniConfigCustom")
 }
+
+func TestCountNodesUsingENIConfig(t *testing.T) {
+	t.Setenv(envEniConfigAnnotationDef, "")
+	t.Setenv(envEniConfigLabelDef, "")
+	nodes := []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "labelled", Labels: map[string]string{defaultEniConfigLabelDef: "az1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "annotated", Annotations: map[string]string{defaultEniConfigAnnotationDef: "az1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "external", Labels: map[string]string{
+			externalEniConfigLabel: "az2", defaultEniConfigLabelDef: "az1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "unlabelled"}},
+	}
+	ctx := context.Background()
+	k8sSchema := runtime.NewScheme()
+	clientgoscheme.AddToScheme(k8sSchema)
+	k8sClient := testclient.NewClientBuilder().WithScheme(k8sSchema).Build()
+	for _, node := range nodes {
+		assert.NoError(t, k8sClient.Create(ctx, node))
+	}
+
+	for name, want := range map[string]int{"az1": 2, "az2": 1, EniConfigDefault: 1, "az3": 0} {
+		count, err := CountNodesUsingENIConfig(ctx, k8sClient, name)
+		assert.NoError(t, err)
+		assert.Equal(t, want, count, name)
+	}
+}
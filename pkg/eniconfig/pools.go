@@ -0,0 +1,109 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eniconfig
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// envENIConfigPools is a JSON object mapping ENIConfig names to the namespaces whose pods get their IPs from
+// ENIs created from that ENIConfig, e.g. {"blue": ["team-blue"], "green": []}. Pods in other namespaces can
+// opt into a pool with the ENIConfig annotation.
+const envENIConfigPools = "ENI_CONFIG_POOLS"
+
+// ErrUnknownENIConfigPool is returned when a pod asks for an ENIConfig that has no ENI pool on the node
+var ErrUnknownENIConfigPool = errors.New("eniconfig: no ENI pool for the requested eniconfig")
+
+// ENIConfigPools selects the ENIConfig backing the IP of a pod, for nodes that keep ENI pools for ENIConfigs
+// other than their own. Pods that do not select one of the pools use the node's default pool.
+type ENIConfigPools struct {
+	names         []string
+	namespaces    map[string]string
+	annotationDef string
+}
+
+// NewENIConfigPools builds the pools from a map of ENIConfig names to the namespaces using them
+func NewENIConfigPools(namespacesByENIConfig map[string][]string) (*ENIConfigPools, error) {
+	pools := &ENIConfigPools{
+		namespaces:    make(map[string]string),
+		annotationDef: getEniConfigAnnotationDef(),
+	}
+	for name, namespaces := range namespacesByENIConfig {
+		if name == "" {
+			return nil, errors.New("eniconfig: ENI pool with an empty eniconfig name")
+		}
+		pools.names = append(pools.names, name)
+		for _, namespace := range namespaces {
+			if other, ok := pools.namespaces[namespace]; ok && other != name {
+				return nil, errors.Errorf("eniconfig: namespace %s is mapped to both eniconfig %s and %s", namespace, other, name)
+			}
+			pools.namespaces[namespace] = name
+		}
+	}
+	sort.Strings(pools.names)
+	return pools, nil
+}
+
+// LoadENIConfigPools reads the ENI pools from ENI_CONFIG_POOLS. It returns nil when no pools are configured.
+func LoadENIConfigPools() (*ENIConfigPools, error) {
+	inputStr, found := os.LookupEnv(envENIConfigPools)
+	if !found || inputStr == "" {
+		return nil, nil
+	}
+	var namespacesByENIConfig map[string][]string
+	if err := json.Unmarshal([]byte(inputStr), &namespacesByENIConfig); err != nil {
+		return nil, errors.Wrapf(err, "eniconfig: failed to parse %s", envENIConfigPools)
+	}
+	if len(namespacesByENIConfig) == 0 {
+		return nil, nil
+	}
+	return NewENIConfigPools(namespacesByENIConfig)
+}
+
+// Names returns the ENIConfig names of the pools, sorted
+func (p *ENIConfigPools) Names() []string {
+	if p == nil {
+		return nil
+	}
+	return p.names
+}
+
+// Has returns true if the ENIConfig has a pool
+func (p *ENIConfigPools) Has(name string) bool {
+	if p == nil {
+		return false
+	}
+	i := sort.SearchStrings(p.names, name)
+	return i < len(p.names) && p.names[i] == name
+}
+
+// PoolForPod returns the ENIConfig whose pool the pod gets its IP from, or an empty string for the node's default pool.
+// The ENIConfig annotation on the pod takes precedence over the namespace of the pod.
+func (p *ENIConfigPools) PoolForPod(pod *corev1.Pod) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+	if name, ok := pod.Annotations[p.annotationDef]; ok {
+		if !p.Has(name) {
+			return "", errors.Wrapf(ErrUnknownENIConfigPool, "%s", name)
+		}
+		return name, nil
+	}
+	return p.namespaces[pod.Namespace], nil
+}
@@ -0,0 +1,82 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eniconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLoadENIConfigPools(t *testing.T) {
+	pools, err := LoadENIConfigPools()
+	assert.NoError(t, err)
+	assert.Nil(t, pools)
+	assert.False(t, pools.Has("blue"))
+	assert.Empty(t, pools.Names())
+
+	t.Setenv(envENIConfigPools, `{"green": [], "blue": ["team-blue", "shared"]}`)
+	pools, err = LoadENIConfigPools()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"blue", "green"}, pools.Names())
+	assert.True(t, pools.Has("green"))
+	assert.False(t, pools.Has("red"))
+
+	t.Setenv(envENIConfigPools, `{"green": ["shared"], "blue": ["shared"]}`)
+	_, err = LoadENIConfigPools()
+	assert.Error(t, err)
+
+	t.Setenv(envENIConfigPools, `["blue"]`)
+	_, err = LoadENIConfigPools()
+	assert.Error(t, err)
+}
+
+func TestPoolForPod(t *testing.T) {
+	t.Setenv(envEniConfigAnnotationDef, defaultEniConfigAnnotationDef)
+	pools, err := NewENIConfigPools(map[string][]string{"blue": {"team-blue"}, "green": nil})
+	assert.NoError(t, err)
+
+	pod := func(namespace string, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: namespace, Annotations: annotations}}
+	}
+	for _, tc := range []struct {
+		name    string
+		pod     *corev1.Pod
+		want    string
+		wantErr bool
+	}{
+		{name: "default namespace", pod: pod("default", nil), want: ""},
+		{name: "pool namespace", pod: pod("team-blue", nil), want: "blue"},
+		{name: "annotation", pod: pod("default", map[string]string{defaultEniConfigAnnotationDef: "green"}), want: "green"},
+		{name: "annotation wins over namespace", pod: pod("team-blue", map[string]string{defaultEniConfigAnnotationDef: "green"}), want: "green"},
+		{name: "unknown eniconfig", pod: pod("default", map[string]string{defaultEniConfigAnnotationDef: "red"}), wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := pools.PoolForPod(tc.pod)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrUnknownENIConfigPool)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	var noPools *ENIConfigPools
+	got, err := noPools.PoolForPod(pod("team-blue", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "", got)
+}
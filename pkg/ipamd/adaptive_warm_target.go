@@ -0,0 +1,198 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/ttime"
+	"github.com/aws/amazon-vpc-cni-k8s/utils"
+	"github.com/aws/amazon-vpc-cni-k8s/utils/prometheusmetrics"
+)
+
+const (
+	// churnBucketWidth is the resolution of the AddNetwork/DelNetwork rates, one pool manager interval
+	churnBucketWidth = ipPoolMonitorInterval
+	// shortChurnWindow reacts to deployment bursts
+	shortChurnWindow = time.Minute
+	// longChurnWindow keeps the warm target up for a while after a burst, so that the pool does not flap
+	longChurnWindow = 10 * time.Minute
+	// churnLookahead is how far ahead the warm pool has to cover AddNetwork requests. It is roughly the time
+	// it takes to attach an ENI and its IPs, plus a pool manager interval.
+	churnLookahead = time.Minute
+
+	churnBuckets = int(longChurnWindow / churnBucketWidth)
+
+	// Reasons for the chosen adaptive warm IP target
+	adaptiveReasonMinBound  = "min_bound"
+	adaptiveReasonMaxBound  = "max_bound"
+	adaptiveReasonBurst     = "burst"
+	adaptiveReasonSustained = "sustained"
+)
+
+var adaptiveReasons = []string{adaptiveReasonMinBound, adaptiveReasonMaxBound, adaptiveReasonBurst, adaptiveReasonSustained}
+
+// churnBucket counts the requests received during one churnBucketWidth
+type churnBucket struct {
+	epoch int64
+	adds  int
+	dels  int
+}
+
+// adaptiveWarmTarget sizes the warm IP target from the rate of AddNetwork and DelNetwork requests
+// observed over a short and a long sliding window, within the configured bounds
+type adaptiveWarmTarget struct {
+	lock      sync.Mutex
+	clock     ttime.Time
+	minTarget int
+	// maxTarget of 0 means the IPs of one ENI
+	maxTarget int
+	buckets   [churnBuckets]churnBucket
+}
+
+func newAdaptiveWarmTarget(clock ttime.Time, minTarget, maxTarget int) *adaptiveWarmTarget {
+	return &adaptiveWarmTarget{
+		clock:     clock,
+		minTarget: minTarget,
+		maxTarget: maxTarget,
+	}
+}
+
+// loadAdaptiveWarmTarget returns the adaptive warm IP target configured by ENABLE_ADAPTIVE_WARM_IP_TARGET, or nil
+func loadAdaptiveWarmTarget(clock ttime.Time, warmIPTarget int) *adaptiveWarmTarget {
+	if !utils.GetBoolAsStringEnvVar(envEnableAdaptiveWarmIPTarget, false) {
+		return nil
+	}
+	// A target of 0 is noWarmIPTarget, which would turn the warm IP target off instead of keeping no spare IPs
+	defaultMin := max(warmIPTarget, 1)
+	minTarget, err, input := utils.GetIntFromStringEnvVar(envAdaptiveWarmIPTargetMin, defaultMin)
+	if err != nil || minTarget < 1 {
+		log.Warnf("Invalid %s %q, using %d", envAdaptiveWarmIPTargetMin, input, defaultMin)
+		minTarget = defaultMin
+	}
+	maxTarget, err, input := utils.GetIntFromStringEnvVar(envAdaptiveWarmIPTargetMax, 0)
+	if err != nil || maxTarget < 0 || (maxTarget > 0 && maxTarget < minTarget) {
+		log.Warnf("Invalid %s %q, using the IPs of one ENI", envAdaptiveWarmIPTargetMax, input)
+		maxTarget = 0
+	}
+	log.Infof("Using adaptive warm IP target between %d and %d (0 means the IPs of one ENI)", minTarget, maxTarget)
+	return newAdaptiveWarmTarget(clock, minTarget, maxTarget)
+}
+
+// bucketUnsafe returns the bucket of the current time, resetting it if it was last used a full window ago
+func (a *adaptiveWarmTarget) bucketUnsafe() *churnBucket {
+	epoch := a.clock.Now().UnixNano() / int64(churnBucketWidth)
+	b := &a.buckets[epoch%int64(churnBuckets)]
+	if b.epoch != epoch {
+		*b = churnBucket{epoch: epoch}
+	}
+	return b
+}
+
+// recordAdd counts an AddNetwork request
+func (a *adaptiveWarmTarget) recordAdd() {
+	if a == nil {
+		return
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.bucketUnsafe().adds++
+}
+
+// recordDel counts a DelNetwork request
+func (a *adaptiveWarmTarget) recordDel() {
+	if a == nil {
+		return
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.bucketUnsafe().dels++
+}
+
+// ratesUnsafe returns the AddNetwork and DelNetwork requests per second over the window
+func (a *adaptiveWarmTarget) ratesUnsafe(window time.Duration) (adds, dels float64) {
+	now := a.clock.Now().UnixNano() / int64(churnBucketWidth)
+	oldest := now - int64(window/churnBucketWidth)
+	var addCount, delCount int
+	for _, b := range a.buckets {
+		if b.epoch > oldest && b.epoch <= now {
+			addCount += b.adds
+			delCount += b.dels
+		}
+	}
+	return float64(addCount) / window.Seconds(), float64(delCount) / window.Seconds()
+}
+
+// predictUnsafe returns the number of IPs needed to serve the AddNetwork requests expected over churnLookahead at the
+// rates of the window. Deleted pods give their IP back once it has cooled down, so they only offset the requests
+// arriving after the cooldown.
+func (a *adaptiveWarmTarget) predictUnsafe(window, cooldown time.Duration) int {
+	adds, dels := a.ratesUnsafe(window)
+	reusable := churnLookahead - cooldown
+	if reusable < 0 {
+		reusable = 0
+	}
+	need := adds*churnLookahead.Seconds() - dels*reusable.Seconds()
+	return max(int(math.Ceil(need)), 0)
+}
+
+// target returns the warm IP target for the observed churn and the reason it was chosen
+func (a *adaptiveWarmTarget) target(cooldown time.Duration, maxIPsPerENI int) (int, string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	burst := a.predictUnsafe(shortChurnWindow, cooldown)
+	sustained := a.predictUnsafe(longChurnWindow, cooldown)
+	target, reason := sustained, adaptiveReasonSustained
+	if burst > sustained {
+		target, reason = burst, adaptiveReasonBurst
+	}
+
+	maxTarget := a.maxTarget
+	if maxTarget == 0 {
+		maxTarget = max(maxIPsPerENI, a.minTarget)
+	}
+	if target <= a.minTarget {
+		return a.minTarget, adaptiveReasonMinBound
+	}
+	if target >= maxTarget {
+		return maxTarget, adaptiveReasonMaxBound
+	}
+	return target, reason
+}
+
+// updateAdaptiveWarmIPTarget replaces the warm IP target with the one predicted from the pod churn
+func (c *IPAMContext) updateAdaptiveWarmIPTarget() {
+	if c.adaptiveWarmTarget == nil {
+		return
+	}
+	target, reason := c.adaptiveWarmTarget.target(c.dataStore.GetIPCooldownPeriod(), c.maxIPsPerENI)
+	c.poolTargetsLock.Lock()
+	previous := c.warmIPTarget
+	c.warmIPTarget = target
+	c.poolTargetsLock.Unlock()
+	if target != previous {
+		log.Infof("Adaptive warm IP target changed from %d to %d (%s)", previous, target, reason)
+	}
+	prometheusmetrics.AdaptiveWarmIPTarget.Set(float64(target))
+	for _, r := range adaptiveReasons {
+		value := 0.0
+		if r == reason {
+			value = 1
+		}
+		prometheusmetrics.AdaptiveWarmIPTargetReason.WithLabelValues(r).Set(value)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/ttime"
+	"github.com/aws/amazon-vpc-cni-k8s/utils/prometheusmetrics"
+)
+
+type fakeClock struct {
+	ttime.DefaultTime
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func TestAdaptiveWarmTarget(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	a := newAdaptiveWarmTarget(clock, 2, 30)
+	cooldown := 30 * time.Second
+
+	target, reason := a.target(cooldown, 14)
+	assert.Equal(t, 2, target)
+	assert.Equal(t, adaptiveReasonMinBound, reason)
+
+	// 20 pods in the last minute are expected to keep coming for the next minute
+	for i := 0; i < 20; i++ {
+		a.recordAdd()
+	}
+	target, reason = a.target(cooldown, 14)
+	assert.Equal(t, 20, target)
+	assert.Equal(t, adaptiveReasonBurst, reason)
+
+	// A rolling update frees IPs, but only after they cooled down
+	for i := 0; i < 20; i++ {
+		a.recordDel()
+	}
+	target, reason = a.target(cooldown, 14)
+	assert.Equal(t, 10, target)
+	assert.Equal(t, adaptiveReasonBurst, reason)
+
+	// Once the burst leaves the short window, the long window keeps a share of it
+	clock.now = clock.now.Add(2 * time.Minute)
+	target, reason = a.target(cooldown, 14)
+	assert.Equal(t, 2, target)
+	assert.Equal(t, adaptiveReasonMinBound, reason)
+	for i := 0; i < 100; i++ {
+		a.recordAdd()
+	}
+	clock.now = clock.now.Add(2 * time.Minute)
+	target, reason = a.target(cooldown, 14)
+	// 120 adds and 20 dels over 10 minutes: 0.2*60 - (20/600)*30
+	assert.Equal(t, 11, target)
+	assert.Equal(t, adaptiveReasonSustained, reason)
+
+	// Bursts are capped by the upper bound, or the IPs of one ENI when there is none
+	for i := 0; i < 100; i++ {
+		a.recordAdd()
+	}
+	target, reason = a.target(cooldown, 14)
+	assert.Equal(t, 30, target)
+	assert.Equal(t, adaptiveReasonMaxBound, reason)
+	a.maxTarget = 0
+	target, reason = a.target(cooldown, 14)
+	assert.Equal(t, 14, target)
+	assert.Equal(t, adaptiveReasonMaxBound, reason)
+
+	// Requests older than the long window are forgotten
+	clock.now = clock.now.Add(longChurnWindow)
+	target, reason = a.target(cooldown, 14)
+	assert.Equal(t, 2, target)
+	assert.Equal(t, adaptiveReasonMinBound, reason)
+}
+
+func TestLoadAdaptiveWarmTarget(t *testing.T) {
+	assert.Nil(t, loadAdaptiveWarmTarget(&ttime.DefaultTime{}, 5))
+
+	t.Setenv(envEnableAdaptiveWarmIPTarget, "true")
+	a := loadAdaptiveWarmTarget(&ttime.DefaultTime{}, 5)
+	assert.Equal(t, 5, a.minTarget)
+	assert.Equal(t, 0, a.maxTarget)
+	a = loadAdaptiveWarmTarget(&ttime.DefaultTime{}, noWarmIPTarget)
+	assert.Equal(t, 1, a.minTarget)
+
+	t.Setenv(envAdaptiveWarmIPTargetMin, "3")
+	t.Setenv(envAdaptiveWarmIPTargetMax, "20")
+	a = loadAdaptiveWarmTarget(&ttime.DefaultTime{}, 5)
+	assert.Equal(t, 3, a.minTarget)
+	assert.Equal(t, 20, a.maxTarget)
+
+	t.Setenv(envAdaptiveWarmIPTargetMax, "2")
+	a = loadAdaptiveWarmTarget(&ttime.DefaultTime{}, 5)
+	assert.Equal(t, 0, a.maxTarget)
+
+	// 0 would disable the warm IP target, so it falls back to the default
+	t.Setenv(envAdaptiveWarmIPTargetMin, "0")
+	a = loadAdaptiveWarmTarget(&ttime.DefaultTime{}, 5)
+	assert.Equal(t, 5, a.minTarget)
+	a = loadAdaptiveWarmTarget(&ttime.DefaultTime{}, noWarmIPTarget)
+	assert.Equal(t, 1, a.minTarget)
+}
+
+func TestUpdateAdaptiveWarmIPTarget(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	c := &IPAMContext{
+		dataStore:          datastore.NewDataStore(log, datastore.NullCheckpoint{}, false),
+		maxIPsPerENI:       14,
+		warmIPTarget:       1,
+		adaptiveWarmTarget: newAdaptiveWarmTarget(clock, 1, 0),
+	}
+	for i := 0; i < 6; i++ {
+		c.adaptiveWarmTarget.recordAdd()
+	}
+	c.updateAdaptiveWarmIPTarget()
+	assert.Equal(t, 6, c.warmIPTarget)
+	assert.True(t, c.warmIPTargetsDefined())
+	assert.Equal(t, 6.0, testutil.ToFloat64(prometheusmetrics.AdaptiveWarmIPTarget))
+	assert.Equal(t, 1.0, testutil.ToFloat64(prometheusmetrics.AdaptiveWarmIPTargetReason.WithLabelValues(adaptiveReasonBurst)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(prometheusmetrics.AdaptiveWarmIPTargetReason.WithLabelValues(adaptiveReasonMinBound)))
+
+	// Without adaptive mode the static target is left alone
+	c.adaptiveWarmTarget = nil
+	c.warmIPTarget = 3
+	c.updateAdaptiveWarmIPTarget()
+	assert.Equal(t, 3, c.warmIPTarget)
+}
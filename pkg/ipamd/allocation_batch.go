@@ -0,0 +1,217 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/pkg/errors"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
+)
+
+// allocationBatcher coalesces requests to add IPs or prefixes to an ENI pool. A request that arrives while another one
+// for the same pool is still waiting to run joins it, so that a burst of requests is served by a single pass over the
+// ENIs that sizes the demand from the datastore once. Allocations run one at a time and never overlap with a reconcile
+// of the datastore against EC2 or with the allocation of a static IP, which runs on the AddNetwork goroutines.
+type allocationBatcher struct {
+	// run is held while an allocation or a reconcile runs
+	run sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]*allocationBatch
+}
+
+// allocationBatch is a pending allocation for an ENI pool and, once it is done, its result
+type allocationBatch struct {
+	requests  int
+	done      chan struct{}
+	increased bool
+	err       error
+}
+
+// do runs allocate once for all the requests for the pool that join before it starts, and returns its result to each
+func (b *allocationBatcher) do(pool string, allocate func() (bool, error)) (bool, error) {
+	b.mu.Lock()
+	if b.pending == nil {
+		b.pending = make(map[string]*allocationBatch)
+	}
+	batch, joined := b.pending[pool]
+	if !joined {
+		batch = &allocationBatch{done: make(chan struct{})}
+		b.pending[pool] = batch
+	}
+	batch.requests++
+	b.mu.Unlock()
+
+	if joined {
+		<-batch.done
+		return batch.increased, batch.err
+	}
+
+	b.run.Lock()
+	defer b.run.Unlock()
+	// Requests arriving from now on start a new batch, since this one may size its demand before they are counted
+	b.mu.Lock()
+	delete(b.pending, pool)
+	requests := batch.requests
+	b.mu.Unlock()
+	if requests > 1 {
+		log.Debugf("Coalesced %d requests to increase ENI pool %q", requests, pool)
+	}
+
+	batch.increased, batch.err = allocate()
+	close(batch.done)
+	return batch.increased, batch.err
+}
+
+// lock waits for the running allocation to finish and keeps new ones from starting until unlock is called
+func (b *allocationBatcher) lock() {
+	b.run.Lock()
+}
+
+func (b *allocationBatcher) unlock() {
+	b.run.Unlock()
+}
+
+// eniAllocation is the number of IPs or prefixes to request on an ENI with one AssignPrivateIpAddresses call
+type eniAllocation struct {
+	eniID string
+	count int
+}
+
+// planENIAllocations spreads the demand over the ENIs of the pool that have room for more IPs or prefixes. ENIs with
+// the most room are filled first, so that the demand is met with as few AssignPrivateIpAddresses calls as possible.
+func planENIAllocations(enis []*datastore.ENI, pool string, maxPerENI, demand int) []eniAllocation {
+	var candidates []eniAllocation
+	for _, eni := range enis {
+		if eni.Pool != pool {
+			continue
+		}
+		if room := maxPerENI - len(eni.AvailableIPv4Cidrs); room > 0 {
+			candidates = append(candidates, eniAllocation{eniID: eni.ID, count: room})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].count != candidates[j].count {
+			return candidates[i].count > candidates[j].count
+		}
+		return candidates[i].eniID < candidates[j].eniID
+	})
+
+	var plan []eniAllocation
+	for _, candidate := range candidates {
+		if demand <= 0 {
+			break
+		}
+		count := min(candidate.count, demand)
+		plan = append(plan, eniAllocation{eniID: candidate.eniID, count: count})
+		demand -= count
+	}
+	return plan
+}
+
+// assignCidrsToENIs requests the planned IPs or prefixes from EC2 and adds them to the datastore. ENIs that turn out to
+// have no room left, because the datastore is out of sync, are verified together with one DescribeNetworkInterfaces call.
+func (c *IPAMContext) assignCidrsToENIs(plan []eniAllocation, isPrefix bool) (increasedPool bool, err error) {
+	resource := "IP address"
+	if isPrefix {
+		resource = "IPv4 prefix"
+	}
+	var outOfSyncENIs []string
+	for _, alloc := range plan {
+		output, err := c.awsClient.AllocIPAddresses(alloc.eniID, alloc.count)
+		if err != nil && !containsPrivateIPAddressLimitExceededError(err) {
+			log.Warnf("failed to allocate %d %ses on ENI %s, err: %v", alloc.count, resource, alloc.eniID, err)
+			// Try to just get one more
+			output, err = c.awsClient.AllocIPAddresses(alloc.eniID, 1)
+			if err != nil && !containsPrivateIPAddressLimitExceededError(err) {
+				ipamdErrInc("increaseIPPoolAllocIPAddressesFailed")
+				if c.useSubnetDiscovery && containsInsufficientCIDRsOrSubnetIPs(err) {
+					continue
+				}
+				return increasedPool, errors.Wrap(err, fmt.Sprintf("failed to allocate one %s on ENI %s, err ", resource, alloc.eniID))
+			}
+		}
+
+		if containsPrivateIPAddressLimitExceededError(err) {
+			log.Debugf("AssignPrivateIpAddresses returned PrivateIpAddressLimitExceeded for ENI %s. This can happen if the data store is out of sync. "+
+				"We will verify the actual state by calling EC2 to see what addresses have already been assigned to this ENI.", alloc.eniID)
+			outOfSyncENIs = append(outOfSyncENIs, alloc.eniID)
+			continue
+		}
+		if output == nil {
+			ipamdErrInc("increaseIPPoolGetENIaddressesFailed")
+			return true, errors.Wrap(err, fmt.Sprintf("failed to get ENI %ses during allocation", resource))
+		}
+		if isPrefix {
+			c.addENIv4prefixesToDataStore(output.AssignedIpv4Prefixes, alloc.eniID)
+		} else {
+			var ec2ip4s []ec2types.NetworkInterfacePrivateIpAddress
+			for _, ec2Addr := range output.AssignedPrivateIpAddresses {
+				ec2ip4s = append(ec2ip4s, ec2types.NetworkInterfacePrivateIpAddress{PrivateIpAddress: ec2Addr.PrivateIpAddress})
+			}
+			c.addENIsecondaryIPsToDataStore(ec2ip4s, alloc.eniID)
+		}
+		increasedPool = true
+	}
+
+	if len(outOfSyncENIs) == 0 {
+		return increasedPool, nil
+	}
+	// This call to EC2 is needed to verify which IPs or prefixes got attached to these ENIs.
+	addresses, err := c.awsClient.DescribeENIAddresses(outOfSyncENIs)
+	if err != nil {
+		ipamdErrInc("increaseIPPoolGetENIaddressesFailed")
+		return true, errors.Wrap(err, "failed to get ENI IP addresses during IP allocation")
+	}
+	for _, eniID := range outOfSyncENIs {
+		if isPrefix {
+			c.addENIv4prefixesToDataStore(addresses[eniID].IPv4Prefixes, eniID)
+		} else {
+			c.addENIsecondaryIPsToDataStore(addresses[eniID].IPv4Addresses, eniID)
+		}
+	}
+	return true, nil
+}
+
+// ec2ENIAddresses looks up the addresses that EC2 reports for the attached ENIs during a reconcile. The first lookup
+// describes all the ENIs with a single DescribeNetworkInterfaces call, and later lookups are served from its result.
+type ec2ENIAddresses struct {
+	awsClient awsutils.APIs
+	eniIDs    []string
+
+	fetched   bool
+	addresses map[string]awsutils.ENIAddresses
+	err       error
+}
+
+func (e *ec2ENIAddresses) get(eniID string) (awsutils.ENIAddresses, error) {
+	if !e.fetched {
+		e.addresses, e.err = e.awsClient.DescribeENIAddresses(e.eniIDs)
+		e.fetched = true
+	}
+	if e.err != nil {
+		return awsutils.ENIAddresses{}, e.err
+	}
+	addresses, ok := e.addresses[eniID]
+	if !ok {
+		return awsutils.ENIAddresses{}, awsutils.ErrENINotFound
+	}
+	return addresses, nil
+}
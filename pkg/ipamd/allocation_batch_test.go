@@ -0,0 +1,144 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
+)
+
+func TestPlanENIAllocations(t *testing.T) {
+	eni := func(id, pool string, cidrs int) *datastore.ENI {
+		e := &datastore.ENI{ID: id, Pool: pool, AvailableIPv4Cidrs: make(map[string]*datastore.CidrInfo)}
+		for i := 0; i < cidrs; i++ {
+			e.AvailableIPv4Cidrs[fmt.Sprintf("10.0.0.%d/32", i)] = &datastore.CidrInfo{}
+		}
+		return e
+	}
+	enis := []*datastore.ENI{
+		eni("eni-a", datastore.DefaultENIPool, 10),
+		eni("eni-b", datastore.DefaultENIPool, 2),
+		eni("eni-c", "blue", 0),
+		eni("eni-d", datastore.DefaultENIPool, 14),
+		eni("eni-e", datastore.DefaultENIPool, 2),
+	}
+
+	assert.Equal(t, []eniAllocation{{"eni-b", 12}, {"eni-e", 8}}, planENIAllocations(enis, datastore.DefaultENIPool, 14, 20))
+	assert.Equal(t, []eniAllocation{{"eni-b", 12}, {"eni-e", 12}, {"eni-a", 4}},
+		planENIAllocations(enis, datastore.DefaultENIPool, 14, 100), "the demand is capped by the room on the ENIs")
+	assert.Equal(t, []eniAllocation{{"eni-b", 1}}, planENIAllocations(enis, datastore.DefaultENIPool, 14, 1))
+	assert.Equal(t, []eniAllocation{{"eni-c", 5}}, planENIAllocations(enis, "blue", 14, 5))
+	assert.Empty(t, planENIAllocations(enis, datastore.DefaultENIPool, 14, 0))
+}
+
+func TestAllocationBatcherCoalesces(t *testing.T) {
+	var b allocationBatcher
+	calls := 0
+	allocate := func() (bool, error) {
+		calls++
+		return true, nil
+	}
+
+	// While a reconcile holds the batcher, requests for the same pool pile up in one batch
+	b.lock()
+	const requests = 5
+	var wg sync.WaitGroup
+	results := make(chan bool, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			increased, err := b.do(datastore.DefaultENIPool, allocate)
+			assert.NoError(t, err)
+			results <- increased
+		}()
+	}
+	assert.Eventually(t, func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		batch := b.pending[datastore.DefaultENIPool]
+		return batch != nil && batch.requests == requests
+	}, 5*time.Second, time.Millisecond)
+	b.unlock()
+	wg.Wait()
+	close(results)
+
+	assert.Equal(t, 1, calls)
+	for increased := range results {
+		assert.True(t, increased)
+	}
+
+	// Once the batch is done, the next request starts a new one
+	_, _ = b.do(datastore.DefaultENIPool, allocate)
+	assert.Equal(t, 2, calls)
+}
+
+func TestTryAssignIPsSpreadsOverENIs(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+
+	mockContext := &IPAMContext{
+		awsClient:    m.awsutils,
+		dataStore:    testDatastore(),
+		maxIPsPerENI: 14,
+		primaryIP:    make(map[string]string),
+	}
+	addIPs := func(eniID string, subnet byte, n int) []ec2types.NetworkInterfacePrivateIpAddress {
+		var addrs []ec2types.NetworkInterfacePrivateIpAddress
+		for i := 1; i <= n; i++ {
+			ip := net.IPv4(10, 0, subnet, byte(i))
+			_ = mockContext.dataStore.AddIPv4CidrToStore(eniID, net.IPNet{IP: ip, Mask: net.IPv4Mask(255, 255, 255, 255)}, false)
+			addrs = append(addrs, ec2types.NetworkInterfacePrivateIpAddress{PrivateIpAddress: aws.String(ip.String())})
+		}
+		return addrs
+	}
+	_ = mockContext.dataStore.AddENI(primaryENIid, primaryDevice, true, false, false)
+	_ = mockContext.dataStore.AddENI(secENIid, secDevice, false, false, false)
+	addIPs(primaryENIid, 1, 10)
+	secIPs := addIPs(secENIid, 2, 12)
+
+	// The demand of a full ENI is spread over both ENIs, and the ENI whose datastore view is stale is verified with EC2
+	m.awsutils.EXPECT().AllocIPAddresses(primaryENIid, 4).Return(&ec2.AssignPrivateIpAddressesOutput{
+		AssignedPrivateIpAddresses: []ec2types.AssignedPrivateIpAddress{
+			{PrivateIpAddress: aws.String("10.0.1.11")},
+			{PrivateIpAddress: aws.String("10.0.1.12")},
+			{PrivateIpAddress: aws.String("10.0.1.13")},
+			{PrivateIpAddress: aws.String("10.0.1.14")},
+		},
+	}, nil)
+	m.awsutils.EXPECT().AllocIPAddresses(secENIid, 2).Return(nil, &smithy.GenericAPIError{Code: "PrivateIpAddressLimitExceeded"})
+	secIPs = append(secIPs,
+		ec2types.NetworkInterfacePrivateIpAddress{PrivateIpAddress: aws.String("10.0.2.13")},
+		ec2types.NetworkInterfacePrivateIpAddress{PrivateIpAddress: aws.String("10.0.2.14")})
+	m.awsutils.EXPECT().DescribeENIAddresses([]string{secENIid}).Return(map[string]awsutils.ENIAddresses{
+		secENIid: {IPv4Addresses: secIPs},
+	}, nil)
+
+	increased, err := mockContext.tryAssignCidrs(datastore.DefaultENIPool)
+	assert.NoError(t, err)
+	assert.True(t, increased)
+	assert.Equal(t, 28, mockContext.dataStore.GetIPStats(ipV4AddrFamily).TotalIPs)
+}
@@ -4,6 +4,10 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/logger"
 )
 
 // Checkpointer can persist data and (hopefully) restore it later
@@ -12,7 +16,27 @@ type Checkpointer interface {
 	Restore(into interface{}) error
 }
 
-// NullCheckpoint discards data and always returns "not found". For testing only!
+// ErrFullCheckpointRequired is returned by CheckpointDelta when the change cannot be recorded on its own, e.g.
+// before the first checkpoint. The caller writes the whole checkpoint instead.
+var ErrFullCheckpointRequired = errors.New("datastore: a full checkpoint is required")
+
+// CheckpointDelta is a change to the last checkpoint. Unassigned allocations and released reservations are
+// removed before the assigned allocations and held reservations are added, so a changed row is unassigned and
+// assigned again.
+type CheckpointDelta struct {
+	Assigned   []CheckpointEntry
+	Unassigned []CheckpointEntry
+	Reserved   []CheckpointReservation
+	Released   []CheckpointReservation
+}
+
+// DeltaCheckpointer is a Checkpointer that can persist a change to the last checkpoint without the whole checkpoint
+type DeltaCheckpointer interface {
+	Checkpointer
+	CheckpointDelta(delta CheckpointDelta) error
+}
+
+// NullCheckpoint discards data and always returns "not found". For testing and the IPAM simulator only!
 type NullCheckpoint struct{}
 
 // Checkpoint implements the Checkpointer interface in the most
@@ -108,5 +132,5 @@ func (c *JSONFile) Restore(into interface{}) error {
 	}
 	defer f.Close()
 
-	return json.NewDecoder(f).Decode(into)
+	return restoreCheckpointFile(f, into, logger.Get())
 }
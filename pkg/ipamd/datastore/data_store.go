@@ -14,6 +14,7 @@
 package datastore
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
 	"os"
@@ -27,6 +28,7 @@ import (
 	"golang.org/x/sys/unix"
 
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/logger"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/ttime"
 	"github.com/aws/amazon-vpc-cni-k8s/utils"
 	"github.com/aws/amazon-vpc-cni-k8s/utils/prometheusmetrics"
 	"github.com/pkg/errors"
@@ -103,6 +105,10 @@ func (k IPAMKey) String() string {
 type IPAMMetadata struct {
 	K8SPodNamespace string `json:"k8sPodNamespace,omitempty"`
 	K8SPodName      string `json:"k8sPodName,omitempty"`
+	// StickyIP is set if the pod opted into getting its IP address back when it is recreated
+	StickyIP bool `json:"stickyIP,omitempty"`
+	// ENIPool is the ENIConfig backing the ENIs the pod gets its IP from, empty for the node's default pool
+	ENIPool string `json:"eniPool,omitempty"`
 }
 
 // ENI represents a single ENI. Exported fields will be marshaled for introspection.
@@ -118,6 +124,8 @@ type ENI struct {
 	IsEFA bool
 	// DeviceNumber is the device number of ENI (0 means the primary ENI)
 	DeviceNumber int
+	// Pool is the ENIConfig the ENI was created from when it backs an additional ENI pool, empty for the default pool
+	Pool string
 	// IPv4Addresses shows whether each address is assigned, the key is IP address, which must
 	// be in dot-decimal notation with no leading zeros and no whitespace(eg: "10.1.0.253")
 	// Key is the IP address - PD: "IP/28" and SIP: "IP/32"
@@ -134,6 +142,9 @@ type AddressInfo struct {
 	IPAMMetadata   IPAMMetadata
 	AssignedTime   time.Time
 	UnassignedTime time.Time
+	// ReservedFor is the deleted pod the unassigned address is held for until ReservedUntil
+	ReservedFor   IPAMMetadata
+	ReservedUntil time.Time
 }
 
 // CidrInfo
@@ -153,26 +164,24 @@ func (cidr *CidrInfo) Size() int {
 	return (1 << (bits - ones))
 }
 
-func (e *ENI) findAddressForSandbox(ipamKey IPAMKey) (*CidrInfo, *AddressInfo) {
-	// Either v4 or v6 for now.
-	// Check in V4 prefixes
-	for _, availableCidr := range e.AvailableIPv4Cidrs {
+// findAddressInCidrs returns the CIDR and the address assigned to the sandbox, or (nil, nil) if not found
+func findAddressInCidrs(cidrs map[string]*CidrInfo, ipamKey IPAMKey) (*CidrInfo, *AddressInfo) {
+	for _, availableCidr := range cidrs {
 		for _, addr := range availableCidr.IPAddresses {
 			if addr.IPAMKey == ipamKey {
 				return availableCidr, addr
 			}
 		}
 	}
+	return nil, nil
+}
 
-	// Check in V6 prefixes
-	for _, availableCidr := range e.IPv6Cidrs {
-		for _, addr := range availableCidr.IPAddresses {
-			if addr.IPAMKey == ipamKey {
-				return availableCidr, addr
-			}
-		}
+func (e *ENI) findAddressForSandbox(ipamKey IPAMKey) (*CidrInfo, *AddressInfo) {
+	// A dual-stack sandbox holds an address of each family, the IPv4 one is returned first
+	if availableCidr, addr := findAddressInCidrs(e.AvailableIPv4Cidrs, ipamKey); addr != nil {
+		return availableCidr, addr
 	}
-	return nil, nil
+	return findAddressInCidrs(e.IPv6Cidrs, ipamKey)
 }
 
 // AssignedIPv4Addresses is the number of IP addresses already assigned
@@ -200,15 +209,18 @@ func (cidr *CidrInfo) AssignedIPAddressesInCidr() int {
 type CidrStats struct {
 	AssignedIPs int
 	CooldownIPs int
+	ReservedIPs int
 }
 
 // Gets number of assigned IPs and the IPs in cooldown from a given CIDR
-func (cidr *CidrInfo) GetIPStatsFromCidr(ipCooldownPeriod time.Duration) CidrStats {
+func (cidr *CidrInfo) GetIPStatsFromCidr(now time.Time, ipCooldownPeriod time.Duration) CidrStats {
 	stats := CidrStats{}
 	for _, addr := range cidr.IPAddresses {
 		if addr.Assigned() {
 			stats.AssignedIPs++
-		} else if addr.inCoolingPeriod(ipCooldownPeriod) {
+		} else if addr.reserved(now) {
+			stats.ReservedIPs++
+		} else if addr.inCoolingPeriod(now, ipCooldownPeriod) {
 			stats.CooldownIPs++
 		}
 	}
@@ -229,9 +241,14 @@ func getCooldownPeriod() time.Duration {
 	return time.Duration(cooldownVal) * time.Second
 }
 
+// GetIPCooldownPeriod returns how long a released IP is kept from being assigned again
+func (ds *DataStore) GetIPCooldownPeriod() time.Duration {
+	return ds.ipCooldownPeriod
+}
+
 // InCoolingPeriod checks whether an addr is in ipCooldownPeriod
-func (addr AddressInfo) inCoolingPeriod(ipCooldownPeriod time.Duration) bool {
-	return time.Since(addr.UnassignedTime) <= ipCooldownPeriod
+func (addr AddressInfo) inCoolingPeriod(now time.Time, ipCooldownPeriod time.Duration) bool {
+	return now.Sub(addr.UnassignedTime) <= ipCooldownPeriod
 }
 
 // ENIPool is a collection of ENI, keyed by ENI ID
@@ -256,11 +273,48 @@ func (p *ENIPool) FindAddressForSandbox(ipamKey IPAMKey) (*ENI, *CidrInfo, *Addr
 	return nil, nil, nil
 }
 
+// findFamilyAddressForSandbox returns the IPv4 or the IPv6 address of the sandbox along with its ENI and CIDR
+func (p *ENIPool) findFamilyAddressForSandbox(ipamKey IPAMKey, isIPv6 bool) (*ENI, *CidrInfo, *AddressInfo) {
+	for _, eni := range *p {
+		cidrs := eni.AvailableIPv4Cidrs
+		if isIPv6 {
+			cidrs = eni.IPv6Cidrs
+		}
+		if availableCidr, addr := findAddressInCidrs(cidrs, ipamKey); addr != nil {
+			return eni, availableCidr, addr
+		}
+	}
+	return nil, nil, nil
+}
+
+// sandboxAddress is an address assigned to a sandbox along with the ENI and CIDR it belongs to
+type sandboxAddress struct {
+	eni    *ENI
+	cidr   *CidrInfo
+	addr   *AddressInfo
+	isIPv6 bool
+}
+
+// findSandboxAddresses returns every address assigned to the sandbox, the IPv4 one first
+func (p *ENIPool) findSandboxAddresses(ipamKey IPAMKey) []sandboxAddress {
+	var addresses []sandboxAddress
+	for _, isIPv6 := range []bool{false, true} {
+		if eni, availableCidr, addr := p.findFamilyAddressForSandbox(ipamKey, isIPv6); addr != nil {
+			addresses = append(addresses, sandboxAddress{eni: eni, cidr: availableCidr, addr: addr, isIPv6: isIPv6})
+		}
+	}
+	return addresses
+}
+
 // PodIPInfo contains pod's IP and the device number of the ENI
 type PodIPInfo struct {
 	IPAMKey IPAMKey
-	// IP is the IPv4 address of pod
+	// IPAMMetadata is the pod namespace and name recorded when the IP was assigned
+	IPAMMetadata IPAMMetadata
+	// IP is the IPv4 or the IPv6 address of pod, a dual-stack pod has a PodIPInfo for each of its addresses
 	IP string
+	// ENIID is the ID of the ENI the IP belongs to
+	ENIID string
 	// DeviceNumber is the device number of the ENI
 	DeviceNumber int
 }
@@ -277,6 +331,15 @@ type DataStore struct {
 	netLink          netlinkwrapper.NetLink
 	isPDEnabled      bool
 	ipCooldownPeriod time.Duration
+	watchers         map[chan AllocationEvent]struct{}
+	// checkpointVersion is the schema version the backing store is written in, 0 for the oldest that can hold it
+	checkpointVersion int
+	// writtenCheckpointVersion is the schema version of the last whole checkpoint, 0 until one is written
+	writtenCheckpointVersion int
+	ipSelection              IPSelectionStrategy
+	// stickyIPGracePeriod is how long the address of a deleted sticky IP pod is held for it
+	stickyIPGracePeriod time.Duration
+	clock               ttime.Time
 }
 
 // ENIInfos contains ENI IP information
@@ -292,17 +355,28 @@ type ENIInfos struct {
 // NewDataStore returns DataStore structure
 func NewDataStore(log logger.Logger, backingStore Checkpointer, isPDEnabled bool) *DataStore {
 	return &DataStore{
-		eniPool:          make(ENIPool),
-		log:              log,
-		backingStore:     backingStore,
-		netLink:          netlinkwrapper.NewNetLink(),
-		isPDEnabled:      isPDEnabled,
-		ipCooldownPeriod: getCooldownPeriod(),
+		eniPool:             make(ENIPool),
+		log:                 log,
+		backingStore:        backingStore,
+		netLink:             netlinkwrapper.NewNetLink(),
+		isPDEnabled:         isPDEnabled,
+		ipCooldownPeriod:    getCooldownPeriod(),
+		checkpointVersion:   getCheckpointWriteVersion(log),
+		ipSelection:         getIPSelectionStrategy(log),
+		stickyIPGracePeriod: getStickyIPGracePeriod(),
+		clock:               &ttime.DefaultTime{},
 	}
 }
 
-// CheckpointFormatVersion is the version stamp used on stored checkpoints.
-const CheckpointFormatVersion = "vpc-cni-ipam/1"
+// SetClock replaces the time source of the datastore, the IPAM simulator runs it on a virtual clock
+func (ds *DataStore) SetClock(clock ttime.Time) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	ds.clock = clock
+}
+
+// CheckpointFormatVersion is the version stamp used on stored checkpoints, derived from CheckpointSchemaVersion
+var CheckpointFormatVersion = formatCheckpointVersion(CheckpointSchemaVersion)
 
 // CheckpointData is the format of stored checkpoints. Note this is
 // deliberately a "dumb" format since efficiency is less important
@@ -310,10 +384,12 @@ const CheckpointFormatVersion = "vpc-cni-ipam/1"
 type CheckpointData struct {
 	Version     string            `json:"version"`
 	Allocations []CheckpointEntry `json:"allocations"`
+	// Reservations are the addresses held for deleted sticky IP pods, added in schema version 3
+	Reservations []CheckpointReservation `json:"reservations,omitempty"`
 }
 
 // CheckpointEntry is a "row" in the conceptual IPAM datastore, as stored
-// in checkpoints.
+// in checkpoints. A dual-stack sandbox has both its addresses in a single row.
 type CheckpointEntry struct {
 	IPAMKey
 	IPv4                string       `json:"ipv4,omitempty"`
@@ -324,13 +400,13 @@ type CheckpointEntry struct {
 
 // ReadBackingStore initializes the IP allocation state from the
 // configured backing store. Should be called before using data store.
-func (ds *DataStore) ReadBackingStore(isv6Enabled bool) error {
-	var data CheckpointData
+func (ds *DataStore) ReadBackingStore() error {
+	var raw json.RawMessage
 
 	// Read from checkpoint file
 	ds.log.Infof("Begin ipam state recovery from backing store")
 
-	if err := ds.backingStore.Restore(&data); err != nil {
+	if err := ds.backingStore.Restore(&raw); err != nil {
 		// Assume that no file == no containers are currently in use, e.g. a fresh reboot just cleared everything out.
 		// This is ok, and no-op.
 		if os.IsNotExist(err) {
@@ -339,8 +415,10 @@ func (ds *DataStore) ReadBackingStore(isv6Enabled bool) error {
 		}
 		return errors.Wrap(err, "failed ipam state recovery from backing store")
 	}
-	if data.Version != CheckpointFormatVersion {
-		return errors.Errorf("failed ipam state recovery due to unexpected checkpointVersion: %v/%v", data.Version, CheckpointFormatVersion)
+	// Older schema versions are migrated to the current one, newer ones are refused
+	data, err := decodeCheckpoint(raw)
+	if err != nil {
+		return errors.Wrap(err, "failed ipam state recovery")
 	}
 	if normalizedData, err := ds.normalizeCheckpointDataByPodVethExistence(data); err != nil {
 		return errors.Wrap(err, "failed normalize checkpoint data with veth check")
@@ -352,46 +430,18 @@ func (ds *DataStore) ReadBackingStore(isv6Enabled bool) error {
 	defer ds.lock.Unlock()
 
 	for _, allocation := range data.Allocations {
-		ipv4Addr := net.ParseIP(allocation.IPv4)
-		ipv6Addr := net.ParseIP(allocation.IPv6)
-		var ipAddr net.IP
-		found := false
-	eniloop:
-		for _, eni := range ds.eniPool {
-			eniCidrs := eni.AvailableIPv4Cidrs
-			ipAddr = ipv4Addr
-			if isv6Enabled {
-				ds.log.Debugf("v6 is enabled")
-				eniCidrs = eni.IPv6Cidrs
-				ipAddr = ipv6Addr
+		for _, ipAddr := range []net.IP{net.ParseIP(allocation.IPv4), net.ParseIP(allocation.IPv6)} {
+			if ipAddr == nil {
+				continue
 			}
-			for _, cidr := range eniCidrs {
-				ds.log.Debugf("Checking if IP: %v belongs to CIDR: %v", ipAddr, cidr.Cidr)
-				if cidr.Cidr.Contains(ipAddr) {
-					// Found!
-					found = true
-					if _, ok := cidr.IPAddresses[ipAddr.String()]; ok {
-						return errors.New(IPAlreadyInStoreError)
-					}
-					addr := &AddressInfo{Address: ipAddr.String()}
-					cidr.IPAddresses[ipAddr.String()] = addr
-					ds.assignPodIPAddressUnsafe(addr, allocation.IPAMKey, allocation.Metadata, time.Unix(0, allocation.AllocationTimestamp))
-					ds.log.Debugf("Recovered %s => %s/%s", allocation.IPAMKey, eni.ID, addr.Address)
-					// Increment ENI IP usage upon finding assigned ips
-					prometheusmetrics.EniIPsInUse.WithLabelValues(eni.ID).Inc()
-					// Update prometheus for ips per cidr
-					// Secondary IP mode will have /32:1 and Prefix mode will have /28:<number of /32s>
-					prometheusmetrics.IpsPerCidr.With(prometheus.Labels{"cidr": cidr.Cidr.String()}).Inc()
-					break eniloop
-				}
+			if err := ds.restoreAllocationUnsafe(allocation, ipAddr); err != nil {
+				return err
 			}
 		}
-		if !found {
-			ds.log.Infof("datastore: Sandbox %s uses unknown IP Address %s - presuming stale/dead",
-				allocation.IPAMKey, ipAddr.String())
-		}
 	}
 
+	ds.restoreReservationsUnsafe(data.Reservations)
+
 	// Some entries may have been purged during recovery, so write to backing store
 	if err := ds.writeBackingStoreUnsafe(); err != nil {
 		ds.log.Warnf("Unable to update backing store after restoration: %v", err)
@@ -401,46 +451,163 @@ func (ds *DataStore) ReadBackingStore(isv6Enabled bool) error {
 	return nil
 }
 
+// restoreAllocationUnsafe marks the address of a checkpoint row as assigned to its sandbox
+func (ds *DataStore) restoreAllocationUnsafe(allocation CheckpointEntry, ipAddr net.IP) error {
+	for _, eni := range ds.eniPool {
+		eniCidrs := eni.AvailableIPv4Cidrs
+		if ipAddr.To4() == nil {
+			eniCidrs = eni.IPv6Cidrs
+		}
+		for _, cidr := range eniCidrs {
+			ds.log.Debugf("Checking if IP: %v belongs to CIDR: %v", ipAddr, cidr.Cidr)
+			if cidr.Cidr.Contains(ipAddr) {
+				// Found!
+				if _, ok := cidr.IPAddresses[ipAddr.String()]; ok {
+					return errors.New(IPAlreadyInStoreError)
+				}
+				addr := &AddressInfo{Address: ipAddr.String()}
+				cidr.IPAddresses[ipAddr.String()] = addr
+				ds.assignPodIPAddressUnsafe(addr, allocation.IPAMKey, allocation.Metadata, time.Unix(0, allocation.AllocationTimestamp))
+				ds.log.Debugf("Recovered %s => %s/%s", allocation.IPAMKey, eni.ID, addr.Address)
+				// Increment ENI IP usage upon finding assigned ips
+				prometheusmetrics.EniIPsInUse.WithLabelValues(eni.ID).Inc()
+				// Update prometheus for ips per cidr
+				// Secondary IP mode will have /32:1 and Prefix mode will have /28:<number of /32s>
+				prometheusmetrics.IpsPerCidr.With(prometheus.Labels{"cidr": cidr.Cidr.String()}).Inc()
+				return nil
+			}
+		}
+	}
+	ds.log.Infof("datastore: Sandbox %s uses unknown IP Address %s - presuming stale/dead",
+		allocation.IPAMKey, ipAddr.String())
+	return nil
+}
+
 func (ds *DataStore) writeBackingStoreUnsafe() error {
 	allocations := make([]CheckpointEntry, 0, ds.assigned)
+	// rows maps a sandbox to its row in allocations, so that both addresses of a dual-stack sandbox share it
+	rows := make(map[IPAMKey]int, ds.assigned)
 
 	for _, eni := range ds.eniPool {
-		// Loop through ENI's v4 prefixes
-		for _, assignedAddr := range eni.AvailableIPv4Cidrs {
-			for _, addr := range assignedAddr.IPAddresses {
-				if addr.Assigned() {
-					entry := CheckpointEntry{
-						IPAMKey:             addr.IPAMKey,
-						IPv4:                addr.Address,
-						AllocationTimestamp: addr.AssignedTime.UnixNano(),
-						Metadata:            addr.IPAMMetadata,
+		for _, cidrs := range []map[string]*CidrInfo{eni.AvailableIPv4Cidrs, eni.IPv6Cidrs} {
+			for _, assignedAddr := range cidrs {
+				for _, addr := range assignedAddr.IPAddresses {
+					if !addr.Assigned() {
+						continue
 					}
-					allocations = append(allocations, entry)
-				}
-			}
-		}
-		// Loop through ENI's v6 prefixes
-		for _, assignedAddr := range eni.IPv6Cidrs {
-			for _, addr := range assignedAddr.IPAddresses {
-				if addr.Assigned() {
-					entry := CheckpointEntry{
-						IPAMKey:             addr.IPAMKey,
-						IPv6:                addr.Address,
-						AllocationTimestamp: addr.AssignedTime.UnixNano(),
-						Metadata:            addr.IPAMMetadata,
+					row, ok := rows[addr.IPAMKey]
+					if !ok {
+						row = len(allocations)
+						rows[addr.IPAMKey] = row
+						allocations = append(allocations, newCheckpointEntry(addr))
+					}
+					if net.ParseIP(addr.Address).To4() == nil {
+						allocations[row].IPv6 = addr.Address
+					} else {
+						// A dual-stack row takes the allocation time of the IPv4 address
+						allocations[row].IPv4 = addr.Address
+						allocations[row].AllocationTimestamp = addr.AssignedTime.UnixNano()
 					}
-					allocations = append(allocations, entry)
 				}
 			}
 		}
 	}
 
 	data := CheckpointData{
-		Version:     CheckpointFormatVersion,
-		Allocations: allocations,
+		Version:      CheckpointFormatVersion,
+		Allocations:  allocations,
+		Reservations: ds.reservationsUnsafe(),
+	}
+
+	version := ds.checkpointVersion
+	if version == 0 {
+		version = oldestCheckpointVersion(&data)
+	}
+	stored, err := encodeCheckpoint(&data, version)
+	if err != nil {
+		return err
+	}
+	if err := ds.backingStore.Checkpoint(stored); err != nil {
+		return err
 	}
+	ds.writtenCheckpointVersion = version
+	return nil
+}
 
-	return ds.backingStore.Checkpoint(&data)
+// writeBackingStoreDeltaUnsafe writes a single assign or unassign to the backing store, so that pod churn does not
+// rebuild the whole checkpoint. Checkpointers that cannot store changes, and changes that only a newer schema version
+// than the one of the last checkpoint can hold, get the whole checkpoint instead. The last checkpoint keeps its
+// schema version until the next whole checkpoint, even once the allocations would fit an older one.
+func (ds *DataStore) writeBackingStoreDeltaUnsafe(delta CheckpointDelta) error {
+	checkpointer, ok := ds.backingStore.(DeltaCheckpointer)
+	if !ok || ds.writtenCheckpointVersion == 0 || delta.version() > ds.writtenCheckpointVersion {
+		return ds.writeBackingStoreUnsafe()
+	}
+	if err := checkpointer.CheckpointDelta(delta); !errors.Is(err, ErrFullCheckpointRequired) {
+		return err
+	}
+	return ds.writeBackingStoreUnsafe()
+}
+
+// version returns the oldest schema version that can hold the change
+func (delta *CheckpointDelta) version() int {
+	return oldestCheckpointVersion(&CheckpointData{
+		Allocations:  append(append([]CheckpointEntry{}, delta.Assigned...), delta.Unassigned...),
+		Reservations: append(append([]CheckpointReservation{}, delta.Reserved...), delta.Released...),
+	})
+}
+
+// newCheckpointEntry returns the checkpoint row of the sandbox the address is assigned to, without its addresses
+func newCheckpointEntry(addr *AddressInfo) CheckpointEntry {
+	return CheckpointEntry{
+		IPAMKey:             addr.IPAMKey,
+		AllocationTimestamp: addr.AssignedTime.UnixNano(),
+		Metadata:            addr.IPAMMetadata,
+	}
+}
+
+// sandboxEntryUnsafe returns the checkpoint row of the sandbox the address is assigned to, with the address of the
+// other family if the sandbox is dual-stack. Like the rows of the whole checkpoint, a dual-stack row takes the
+// allocation time of the IPv4 address.
+func (ds *DataStore) sandboxEntryUnsafe(addr *AddressInfo, isIPv6 bool) CheckpointEntry {
+	ipv4, ipv6 := addr, addr
+	if isIPv6 {
+		_, _, ipv4 = ds.eniPool.findFamilyAddressForSandbox(addr.IPAMKey, false)
+	} else {
+		_, _, ipv6 = ds.eniPool.findFamilyAddressForSandbox(addr.IPAMKey, true)
+	}
+	var entry CheckpointEntry
+	if ipv4 != nil {
+		entry = newCheckpointEntry(ipv4)
+		entry.IPv4 = ipv4.Address
+	} else {
+		entry = newCheckpointEntry(ipv6)
+	}
+	if ipv6 != nil {
+		entry.IPv6 = ipv6.Address
+	}
+	return entry
+}
+
+// sandboxEntryDelta returns the change to the row of a sandbox when the address of one family is assigned or
+// unassigned. A dual-stack row is replaced by the row with, or without, the address.
+func sandboxEntryDelta(entry CheckpointEntry, isIPv6, assigned bool) CheckpointDelta {
+	var delta CheckpointDelta
+	other := entry
+	if isIPv6 {
+		other.IPv6 = ""
+	} else {
+		other.IPv4 = ""
+	}
+	full, partial := &delta.Assigned, &delta.Unassigned
+	if !assigned {
+		full, partial = &delta.Unassigned, &delta.Assigned
+	}
+	*full = append(*full, entry)
+	if other.IPv4 != "" || other.IPv6 != "" {
+		*partial = append(*partial, other)
+	}
+	return delta
 }
 
 // AddENI add ENI to data store
@@ -455,7 +622,7 @@ func (ds *DataStore) AddENI(eniID string, deviceNumber int, isPrimary, isTrunk,
 		return errors.New(DuplicatedENIError)
 	}
 	ds.eniPool[eniID] = &ENI{
-		createTime:         time.Now(),
+		createTime:         ds.clock.Now(),
 		IsPrimary:          isPrimary,
 		IsTrunk:            isTrunk,
 		IsEFA:              isEFA,
@@ -536,7 +703,9 @@ func (ds *DataStore) DelIPv4CidrFromStore(eniID string, cidr net.IPNet, force bo
 				return errors.New(IPInUseError)
 			}
 			prometheusmetrics.ForceRemovedIPs.Inc()
+			info := newPodIPInfo(curENI, addr)
 			ds.unassignPodIPAddressUnsafe(addr)
+			ds.notifyWatchersUnsafe(AllocationUnassigned, info)
 			updateBackingStore = true
 		}
 	}
@@ -600,15 +769,43 @@ func (ds *DataStore) AddIPv6CidrToStore(eniID string, ipv6Cidr net.IPNet, isPref
 	return nil
 }
 
+// AssignPodIPAddress assigns an address of every enabled family to the pod. A dual-stack pod gets its IPv4 address
+// first, and it is released again when no IPv6 address is available. The device number is the one of the ENI holding
+// the IPv4 address, IPv6 addresses always come from the primary ENI.
 func (ds *DataStore) AssignPodIPAddress(ipamKey IPAMKey, ipamMetadata IPAMMetadata, isIPv4Enabled bool, isIPv6Enabled bool) (ipv4Address string,
 	ipv6Address string, deviceNumber int, err error) {
-	//Currently it's either v4 or v6. Dual Stack mode isn't supported.
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	var assignments []*podAssignment
 	if isIPv4Enabled {
-		ipv4Address, deviceNumber, err = ds.AssignPodIPv4Address(ipamKey, ipamMetadata)
-	} else if isIPv6Enabled {
-		ipv6Address, deviceNumber, err = ds.AssignPodIPv6Address(ipamKey, ipamMetadata)
+		assignment, err := ds.assignPodIPv4AddressUnsafe(ipamKey, ipamMetadata)
+		if err != nil {
+			return "", "", -1, err
+		}
+		ipv4Address, deviceNumber = assignment.addr.Address, assignment.eni.DeviceNumber
+		assignments = append(assignments, assignment)
+	}
+	if isIPv6Enabled {
+		assignment, err := ds.assignPodIPv6AddressUnsafe(ipamKey, ipamMetadata)
+		if err != nil {
+			// Watchers have not been told about the IPv4 address, so it is freed without a trace
+			for _, assigned := range assignments {
+				ds.rollbackAssignmentUnsafe(assigned)
+			}
+			return "", "", -1, err
+		}
+		ipv6Address = assignment.addr.Address
+		if !isIPv4Enabled {
+			deviceNumber = assignment.eni.DeviceNumber
+		}
+		assignments = append(assignments, assignment)
+	}
+	// Watchers only learn about the pod once it has all its addresses
+	for _, assignment := range assignments {
+		ds.notifyAssignedUnsafe(assignment)
 	}
-	return ipv4Address, ipv6Address, deviceNumber, err
+	return ipv4Address, ipv6Address, deviceNumber, nil
 }
 
 // AssignPodIPv6Address assigns an IPv6 address to pod. Returns the assigned IPv6 address along with device number
@@ -616,52 +813,43 @@ func (ds *DataStore) AssignPodIPv6Address(ipamKey IPAMKey, ipamMetadata IPAMMeta
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 
-	if !ds.isPDEnabled {
-		return "", -1, fmt.Errorf("PD is not enabled. V6 is only supported in PD mode")
+	assignment, err := ds.assignPodIPv6AddressUnsafe(ipamKey, ipamMetadata)
+	if err != nil {
+		return "", -1, err
 	}
+	ds.notifyAssignedUnsafe(assignment)
+	return assignment.addr.Address, assignment.eni.DeviceNumber, nil
+}
+
+func (ds *DataStore) assignPodIPv6AddressUnsafe(ipamKey IPAMKey, ipamMetadata IPAMMetadata) (*podAssignment, error) {
 	ds.log.Debugf("AssignPodIPv6Address: IPv6 address pool stats: assigned %d", ds.assigned)
 
-	if eni, _, addr := ds.eniPool.FindAddressForSandbox(ipamKey); addr != nil {
+	if eni, cidr, addr := ds.eniPool.findFamilyAddressForSandbox(ipamKey, true); addr != nil {
 		ds.log.Infof("AssignPodIPv6Address: duplicate pod assign for sandbox %s", ipamKey)
-		return addr.Address, eni.DeviceNumber, nil
+		return &podAssignment{eni: eni, cidr: cidr, addr: addr, existing: true}, nil
 	}
 
-	// In IPv6 Prefix Delegation mode, eniPool will only have Primary ENI.
-	for _, eni := range ds.eniPool {
-		if len(eni.IPv6Cidrs) == 0 {
-			continue
-		}
-		for _, V6Cidr := range eni.IPv6Cidrs {
-			if !V6Cidr.IsPrefix {
-				continue
-			}
-			ipv6Address, err = ds.getFreeIPv6AddrFromCidr(V6Cidr)
-			if err != nil {
-				ds.log.Debugf("Unable to get IP address from prefix: %v", err)
-				//In v6 mode, we (should) only have one CIDR/Prefix. So, we can bail out but we will let the loop
-				//exit instead.
-				continue
-			}
-			ds.log.Debugf("New v6 IP from PD pool- %s", ipv6Address)
-			addr := &AddressInfo{Address: ipv6Address}
-			V6Cidr.IPAddresses[ipv6Address] = addr
-
-			ds.assignPodIPAddressUnsafe(addr, ipamKey, ipamMetadata, time.Now())
-			if err := ds.writeBackingStoreUnsafe(); err != nil {
-				ds.log.Warnf("Failed to update backing store: %v", err)
-				// Important! Unwind assignment
-				ds.unassignPodIPAddressUnsafe(addr)
-				//Remove the IP from eni DB
-				delete(V6Cidr.IPAddresses, addr.Address)
-				return "", -1, err
+	candidate, found := ds.findReservationUnsafe(ipamMetadata, func(eni *ENI) map[string]*CidrInfo { return eni.IPv6Cidrs })
+	if !found {
+		var cidrs []eniCidr
+		// IPv6 prefixes are assigned to the primary ENI, also when IPv4 addresses come from secondary IPs. With custom
+		// networking, they are assigned to the ENIs in the ENIConfig subnet instead.
+		for _, eni := range ds.eniPool {
+			for _, V6Cidr := range eni.IPv6Cidrs {
+				if V6Cidr.IsPrefix {
+					cidrs = append(cidrs, eniCidr{eni: eni, cidr: V6Cidr})
+				}
 			}
-			// Increment ENI IP usage on pod IPv6 allocation
-			prometheusmetrics.EniIPsInUse.WithLabelValues(eni.ID).Inc()
-			return addr.Address, eni.DeviceNumber, nil
+		}
+		var err error
+		if candidate, err = ds.selectFreeIPUnsafe(cidrs); err != nil {
+			ds.log.Debugf("Unable to get IP address from prefix: %v", err)
+			prometheusmetrics.NoAvailableIPAddrs.Inc()
+			return nil, errors.New("AssignPodIPv6Address: no available IP addresses")
 		}
 	}
-	prometheusmetrics.NoAvailableIPAddrs.Inc()
-	return "", -1, errors.New("AssignPodIPv6Address: no available IP addresses")
+	ds.log.Debugf("New v6 IP from PD pool- %s", candidate.Address)
+	return ds.assignCandidateUnsafe(candidate, ipamKey, ipamMetadata)
 }
 
 // AssignPodIPv4Address assigns an IPv4 address to pod
@@ -670,69 +858,145 @@ func (ds *DataStore) AssignPodIPv4Address(ipamKey IPAMKey, ipamMetadata IPAMMeta
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 
+	assignment, err := ds.assignPodIPv4AddressUnsafe(ipamKey, ipamMetadata)
+	if err != nil {
+		return "", -1, err
+	}
+	ds.notifyAssignedUnsafe(assignment)
+	return assignment.addr.Address, assignment.eni.DeviceNumber, nil
+}
+
+func (ds *DataStore) assignPodIPv4AddressUnsafe(ipamKey IPAMKey, ipamMetadata IPAMMetadata) (*podAssignment, error) {
 	ds.log.Debugf("AssignPodIPv4Address: IP address pool stats: total %d, assigned %d", ds.total, ds.assigned)
 
-	if eni, _, addr := ds.eniPool.FindAddressForSandbox(ipamKey); addr != nil {
+	if eni, cidr, addr := ds.eniPool.findFamilyAddressForSandbox(ipamKey, false); addr != nil {
 		ds.log.Infof("AssignPodIPv4Address: duplicate pod assign for sandbox %s", ipamKey)
-		return addr.Address, eni.DeviceNumber, nil
+		return &podAssignment{eni: eni, cidr: cidr, addr: addr, existing: true}, nil
 	}
 
-	for _, eni := range ds.eniPool {
-		for _, availableCidr := range eni.AvailableIPv4Cidrs {
-			var addr *AddressInfo
-			var strPrivateIPv4 string
-			var err error
-
-			if (ds.isPDEnabled && availableCidr.IsPrefix) || (!ds.isPDEnabled && !availableCidr.IsPrefix) {
-				strPrivateIPv4, err = ds.getFreeIPv4AddrfromCidr(availableCidr)
-				if err != nil {
-					ds.log.Debugf("Unable to get IP address from CIDR: %v", err)
-					// Check in next CIDR
-					continue
-				}
-				ds.log.Debugf("New IP from CIDR pool- %s", strPrivateIPv4)
-				if availableCidr.IPAddresses == nil {
-					availableCidr.IPAddresses = make(map[string]*AddressInfo)
-				}
-				// Update prometheus for ips per cidr
-				// Secondary IP mode will have /32:1 and Prefix mode will have /28:<number of /32s>
-				prometheusmetrics.IpsPerCidr.With(prometheus.Labels{"cidr": availableCidr.Cidr.String()}).Inc()
-			} else {
-				// This can happen during upgrade or PD enable/disable knob toggle
-				// ENI can have prefixes attached and no space for SIPs or vice versa
+	candidate, found := ds.findReservationUnsafe(ipamMetadata, func(eni *ENI) map[string]*CidrInfo { return eni.AvailableIPv4Cidrs })
+	if !found {
+		var cidrs []eniCidr
+		for _, eni := range ds.eniPool {
+			if eni.Pool != ipamMetadata.ENIPool {
 				continue
 			}
-
-			addr = availableCidr.IPAddresses[strPrivateIPv4]
-			if addr == nil {
-				// addr is nil when we are using a new IP from prefix or SIP pool
-				// if addr is out of cooldown or not assigned, we can reuse addr
-				addr = &AddressInfo{Address: strPrivateIPv4}
-			}
-
-			availableCidr.IPAddresses[strPrivateIPv4] = addr
-			ds.assignPodIPAddressUnsafe(addr, ipamKey, ipamMetadata, time.Now())
-
-			if err := ds.writeBackingStoreUnsafe(); err != nil {
-				ds.log.Warnf("Failed to update backing store: %v", err)
-				// Important! Unwind assignment
-				ds.unassignPodIPAddressUnsafe(addr)
-				// Remove the IP from eni DB
-				delete(availableCidr.IPAddresses, addr.Address)
-				// Update prometheus for ips per cidr
-				prometheusmetrics.IpsPerCidr.With(prometheus.Labels{"cidr": availableCidr.Cidr.String()}).Dec()
-				return "", -1, err
+			for _, availableCidr := range eni.AvailableIPv4Cidrs {
+				if (ds.isPDEnabled && availableCidr.IsPrefix) || (!ds.isPDEnabled && !availableCidr.IsPrefix) {
+					cidrs = append(cidrs, eniCidr{eni: eni, cidr: availableCidr})
+				}
+				// Else this can happen during upgrade or PD enable/disable knob toggle
+				// ENI can have prefixes attached and no space for SIPs or vice versa
 			}
-			// Increment ENI IP usage on pod IPv4 allocation
-			prometheusmetrics.EniIPsInUse.WithLabelValues(eni.ID).Inc()
-			return addr.Address, eni.DeviceNumber, nil
 		}
-		ds.log.Debugf("AssignPodIPv4Address: ENI %s does not have available addresses", eni.ID)
+		var err error
+		if candidate, err = ds.selectFreeIPUnsafe(cidrs); err != nil {
+			prometheusmetrics.NoAvailableIPAddrs.Inc()
+			ds.log.Errorf("DataStore has no available IP/Prefix addresses")
+			return nil, errors.New("AssignPodIPv4Address: no available IP/Prefix addresses")
+		}
+	}
+	ds.log.Debugf("New IP from CIDR pool- %s", candidate.Address)
+	return ds.assignCandidateUnsafe(candidate, ipamKey, ipamMetadata)
+}
+
+// podAssignment is an address assigned to a sandbox, with what it takes to take the assignment back
+type podAssignment struct {
+	eni  *ENI
+	cidr *CidrInfo
+	addr *AddressInfo
+	// existing is set when the sandbox already held the address before, so there is nothing to take back
+	existing bool
+	// reused is set when the address was kept in its CIDR after an earlier release, with its reservation
+	reused        bool
+	reservedFor   IPAMMetadata
+	reservedUntil time.Time
+}
+
+// assignCandidateUnsafe assigns the selected free address to the sandbox and writes the backing store. Watchers are
+// not notified, see notifyAssignedUnsafe.
+func (ds *DataStore) assignCandidateUnsafe(candidate IPCandidate, ipamKey IPAMKey, ipamMetadata IPAMMetadata) (*podAssignment, error) {
+	assignment := &podAssignment{eni: candidate.ENI, cidr: candidate.Cidr}
+	if assignment.cidr.IPAddresses == nil {
+		assignment.cidr.IPAddresses = make(map[string]*AddressInfo)
+	}
+	if assignment.cidr.AddressFamily != "6" {
+		// Update prometheus for ips per cidr
+		// Secondary IP mode will have /32:1 and Prefix mode will have /28:<number of /32s>
+		prometheusmetrics.IpsPerCidr.With(prometheus.Labels{"cidr": assignment.cidr.Cidr.String()}).Inc()
+	}
+
+	assignment.addr, assignment.reused = assignment.cidr.IPAddresses[candidate.Address]
+	if !assignment.reused {
+		// addr is nil when we are using a new IP from prefix or SIP pool
+		// if addr is out of cooldown or not assigned, we can reuse addr
+		assignment.addr = &AddressInfo{Address: candidate.Address}
+		assignment.cidr.IPAddresses[candidate.Address] = assignment.addr
+	}
+	assignment.reservedFor, assignment.reservedUntil = assignment.addr.ReservedFor, assignment.addr.ReservedUntil
+	now := ds.clock.Now()
+	ds.assignPodIPAddressUnsafe(assignment.addr, ipamKey, ipamMetadata, now)
+
+	isIPv6 := assignment.cidr.AddressFamily == "6"
+	delta := sandboxEntryDelta(ds.sandboxEntryUnsafe(assignment.addr, isIPv6), isIPv6, true)
+	held := AddressInfo{Address: assignment.addr.Address, ReservedFor: assignment.reservedFor, ReservedUntil: assignment.reservedUntil}
+	if held.reserved(now) {
+		// The address is no longer held for the pod
+		delta.Released = append(delta.Released, newCheckpointReservation(&held, isIPv6))
+	}
+	if err := ds.writeBackingStoreDeltaUnsafe(delta); err != nil {
+		ds.log.Warnf("Failed to update backing store: %v", err)
+		// Important! Unwind assignment
+		ds.unwindAssignmentUnsafe(assignment)
+		return nil, err
+	}
+	// Increment ENI IP usage on pod IP allocation
+	prometheusmetrics.EniIPsInUse.WithLabelValues(assignment.eni.ID).Inc()
+	return assignment, nil
+}
+
+// unwindAssignmentUnsafe returns the address to the state it was in before assignCandidateUnsafe. Unlike a pod
+// delete, the address is free right away, it is neither cooling down nor reserved for the pod.
+func (ds *DataStore) unwindAssignmentUnsafe(assignment *podAssignment) {
+	ds.unassignPodIPAddressUnsafe(assignment.addr)
+	if assignment.reused {
+		// Keep the release time and reservation of the address
+		assignment.addr.ReservedFor, assignment.addr.ReservedUntil = assignment.reservedFor, assignment.reservedUntil
+	} else {
+		// Remove the IP from eni DB
+		delete(assignment.cidr.IPAddresses, assignment.addr.Address)
 	}
+	if assignment.cidr.AddressFamily != "6" {
+		// Update prometheus for ips per cidr
+		prometheusmetrics.IpsPerCidr.With(prometheus.Labels{"cidr": assignment.cidr.Cidr.String()}).Dec()
+	}
+}
 
-	prometheusmetrics.NoAvailableIPAddrs.Inc()
-	ds.log.Errorf("DataStore has no available IP/Prefix addresses")
-	return "", -1, errors.New("AssignPodIPv4Address: no available IP/Prefix addresses")
+// rollbackAssignmentUnsafe takes back an address assigned by this request, before watchers were notified of it
+func (ds *DataStore) rollbackAssignmentUnsafe(assignment *podAssignment) {
+	if assignment.existing {
+		return
+	}
+	ds.log.Infof("Rolling back the assignment of %s to sandbox %s", assignment.addr.Address, assignment.addr.IPAMKey)
+	isIPv6 := assignment.cidr.AddressFamily == "6"
+	delta := sandboxEntryDelta(ds.sandboxEntryUnsafe(assignment.addr, isIPv6), isIPv6, false)
+	ds.unwindAssignmentUnsafe(assignment)
+	prometheusmetrics.EniIPsInUse.WithLabelValues(assignment.eni.ID).Dec()
+	if assignment.addr.reserved(ds.clock.Now()) {
+		// The address is held for the pod again
+		delta.Reserved = append(delta.Reserved, newCheckpointReservation(assignment.addr, isIPv6))
+	}
+	if err := ds.writeBackingStoreDeltaUnsafe(delta); err != nil {
+		// The next checkpoint drops the allocation, and a restart before it only leaks the address to the sandbox
+		ds.log.Warnf("Failed to update backing store after rolling back %s: %v", assignment.addr.Address, err)
+	}
+}
+
+// notifyAssignedUnsafe tells watchers about an address assigned by this request
+func (ds *DataStore) notifyAssignedUnsafe(assignment *podAssignment) {
+	if !assignment.existing {
+		ds.notifyWatchersUnsafe(AllocationAssigned, newPodIPInfo(assignment.eni, assignment.addr))
+	}
 }
 
 // assignPodIPAddressUnsafe mark Address as assigned.
@@ -746,6 +1010,8 @@ func (ds *DataStore) assignPodIPAddressUnsafe(addr *AddressInfo, ipamKey IPAMKey
 	addr.IPAMKey = ipamKey // This marks the addr as assigned
 	addr.IPAMMetadata = ipamMetadata
 	addr.AssignedTime = assignedTime
+	addr.ReservedFor = IPAMMetadata{}
+	addr.ReservedUntil = time.Time{}
 
 	ds.assigned++
 	// Prometheus gauge
@@ -777,15 +1043,18 @@ type DataStoreStats struct {
 	AssignedIPs int
 	// Number of addresses in cooldown
 	CooldownIPs int
+	// Number of addresses held for deleted sticky IP pods
+	ReservedIPs int
 }
 
 func (stats *DataStoreStats) String() string {
-	return fmt.Sprintf("Total IPs/Prefixes = %d/%d, AssignedIPs/CooldownIPs: %d/%d",
-		stats.TotalIPs, stats.TotalPrefixes, stats.AssignedIPs, stats.CooldownIPs)
+	return fmt.Sprintf("Total IPs/Prefixes = %d/%d, AssignedIPs/CooldownIPs/ReservedIPs: %d/%d/%d",
+		stats.TotalIPs, stats.TotalPrefixes, stats.AssignedIPs, stats.CooldownIPs, stats.ReservedIPs)
 }
 
+// AvailableAddresses returns the number of addresses that can be assigned to new pods, now or once out of cooldown
 func (stats *DataStoreStats) AvailableAddresses() int {
-	return stats.TotalIPs - stats.AssignedIPs
+	return stats.TotalIPs - stats.AssignedIPs - stats.ReservedIPs
 }
 
 // GetIPStats returns DataStoreStats for addressFamily
@@ -793,24 +1062,35 @@ func (ds *DataStore) GetIPStats(addressFamily string) *DataStoreStats {
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 
-	stats := &DataStoreStats{
-		TotalPrefixes: ds.allocatedPrefix,
-	}
+	stats := ds.getIPStatsUnsafe(addressFamily, func(*ENI) bool { return true })
+	stats.TotalPrefixes = ds.allocatedPrefix
+	return stats
+}
+
+func (ds *DataStore) getIPStatsUnsafe(addressFamily string, include func(*ENI) bool) *DataStoreStats {
+	stats := &DataStoreStats{}
 	for _, eni := range ds.eniPool {
+		if !include(eni) {
+			continue
+		}
 		AssignedCIDRs := eni.AvailableIPv4Cidrs
 		if addressFamily == "6" {
 			AssignedCIDRs = eni.IPv6Cidrs
 		}
 		for _, cidr := range AssignedCIDRs {
 			if addressFamily == "4" && ((ds.isPDEnabled && cidr.IsPrefix) || (!ds.isPDEnabled && !cidr.IsPrefix)) {
-				cidrStats := cidr.GetIPStatsFromCidr(ds.ipCooldownPeriod)
+				cidrStats := cidr.GetIPStatsFromCidr(ds.clock.Now(), ds.ipCooldownPeriod)
 				stats.AssignedIPs += cidrStats.AssignedIPs
 				stats.CooldownIPs += cidrStats.CooldownIPs
+				stats.ReservedIPs += cidrStats.ReservedIPs
 				stats.TotalIPs += cidr.Size()
 			} else if addressFamily == "6" {
 				stats.AssignedIPs += cidr.AssignedIPAddressesInCidr()
 				stats.TotalIPs += cidr.Size()
 			}
+			if cidr.IsPrefix {
+				stats.TotalPrefixes++
+			}
 		}
 	}
 	return stats
@@ -845,7 +1125,7 @@ func (ds *DataStore) GetEFAENIs() map[string]bool {
 func (ds *DataStore) isRequiredForWarmIPTarget(warmIPTarget int, eni *ENI) bool {
 	otherWarmIPs := 0
 	for _, other := range ds.eniPool {
-		if other.ID != eni.ID {
+		if other.ID != eni.ID && other.Pool == eni.Pool {
 			for _, otherPrefixes := range other.AvailableIPv4Cidrs {
 				if (ds.isPDEnabled && otherPrefixes.IsPrefix) || (!ds.isPDEnabled && !otherPrefixes.IsPrefix) {
 					otherWarmIPs += otherPrefixes.Size() - otherPrefixes.AssignedIPAddressesInCidr()
@@ -866,7 +1146,7 @@ func (ds *DataStore) isRequiredForWarmIPTarget(warmIPTarget int, eni *ENI) bool
 func (ds *DataStore) isRequiredForMinimumIPTarget(minimumIPTarget int, eni *ENI) bool {
 	otherIPs := 0
 	for _, other := range ds.eniPool {
-		if other.ID != eni.ID {
+		if other.ID != eni.ID && other.Pool == eni.Pool {
 			for _, otherPrefixes := range other.AvailableIPv4Cidrs {
 				if (ds.isPDEnabled && otherPrefixes.IsPrefix) || (!ds.isPDEnabled && !otherPrefixes.IsPrefix) {
 					otherIPs += otherPrefixes.Size()
@@ -887,7 +1167,7 @@ func (ds *DataStore) isRequiredForMinimumIPTarget(minimumIPTarget int, eni *ENI)
 func (ds *DataStore) isRequiredForWarmPrefixTarget(warmPrefixTarget int, eni *ENI) bool {
 	freePrefixes := 0
 	for _, other := range ds.eniPool {
-		if other.ID != eni.ID {
+		if other.ID != eni.ID && other.Pool == eni.Pool {
 			for _, otherPrefixes := range other.AvailableIPv4Cidrs {
 				if otherPrefixes.AssignedIPAddressesInCidr() == 0 {
 					freePrefixes++
@@ -898,19 +1178,24 @@ func (ds *DataStore) isRequiredForWarmPrefixTarget(warmPrefixTarget int, eni *EN
 	return freePrefixes < warmPrefixTarget
 }
 
-func (ds *DataStore) getDeletableENI(warmIPTarget, minimumIPTarget, warmPrefixTarget int) *ENI {
+func (ds *DataStore) getDeletableENI(pool string, warmIPTarget, minimumIPTarget, warmPrefixTarget int) *ENI {
+	now := ds.clock.Now()
 	for _, eni := range ds.eniPool {
+		if eni.Pool != pool {
+			continue
+		}
+
 		if eni.IsPrimary {
 			ds.log.Debugf("ENI %s cannot be deleted because it is primary", eni.ID)
 			continue
 		}
 
-		if eni.isTooYoung() {
+		if eni.isTooYoung(now) {
 			ds.log.Debugf("ENI %s cannot be deleted because it is too young", eni.ID)
 			continue
 		}
 
-		if eni.hasIPInCooling(ds.ipCooldownPeriod) {
+		if eni.hasIPInCooling(now, ds.ipCooldownPeriod) {
 			ds.log.Debugf("ENI %s cannot be deleted because has IPs in cooling", eni.ID)
 			continue
 		}
@@ -952,15 +1237,15 @@ func (ds *DataStore) getDeletableENI(warmIPTarget, minimumIPTarget, warmPrefixTa
 }
 
 // IsTooYoung returns true if the ENI hasn't been around long enough to be deleted.
-func (e *ENI) isTooYoung() bool {
-	return time.Since(e.createTime) < minENILifeTime
+func (e *ENI) isTooYoung(now time.Time) bool {
+	return now.Sub(e.createTime) < minENILifeTime
 }
 
-// HasIPInCooling returns true if an IP address was unassigned recently.
-func (e *ENI) hasIPInCooling(ipCooldownPeriod time.Duration) bool {
+// HasIPInCooling returns true if an IP address was unassigned recently, or is held for a deleted pod.
+func (e *ENI) hasIPInCooling(now time.Time, ipCooldownPeriod time.Duration) bool {
 	for _, assignedaddr := range e.AvailableIPv4Cidrs {
 		for _, addr := range assignedaddr.IPAddresses {
-			if addr.inCoolingPeriod(ipCooldownPeriod) {
+			if addr.inCoolingPeriod(now, ipCooldownPeriod) || addr.reserved(now) {
 				return true
 			}
 		}
@@ -992,14 +1277,14 @@ func (ds *DataStore) GetAllocatableENIs(maxIPperENI int, skipPrimary bool) []*EN
 	return enis
 }
 
-// RemoveUnusedENIFromStore removes a deletable ENI from the data store.
+// RemoveUnusedENIFromStore removes a deletable ENI of the given ENI pool from the data store.
 // It returns the name of the ENI which has been removed from the data store and needs to be deleted,
 // or empty string if no ENI could be removed.
-func (ds *DataStore) RemoveUnusedENIFromStore(warmIPTarget, minimumIPTarget, warmPrefixTarget int) string {
+func (ds *DataStore) RemoveUnusedENIFromStore(pool string, warmIPTarget, minimumIPTarget, warmPrefixTarget int) string {
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 
-	deletableENI := ds.getDeletableENI(warmIPTarget, minimumIPTarget, warmPrefixTarget)
+	deletableENI := ds.getDeletableENI(pool, warmIPTarget, minimumIPTarget, warmPrefixTarget)
 	if deletableENI == nil {
 		return ""
 	}
@@ -1048,7 +1333,9 @@ func (ds *DataStore) RemoveENIFromDataStore(eniID string, force bool) error {
 		for _, assignedaddr := range eni.AvailableIPv4Cidrs {
 			for _, addr := range assignedaddr.IPAddresses {
 				if addr.Assigned() {
+					info := newPodIPInfo(eni, addr)
 					ds.unassignPodIPAddressUnsafe(addr)
+					ds.notifyWatchersUnsafe(AllocationUnassigned, info)
 				}
 			}
 			ds.total -= assignedaddr.Size()
@@ -1080,46 +1367,198 @@ func (ds *DataStore) RemoveENIFromDataStore(eniID string, force bool) error {
 	return nil
 }
 
-// UnassignPodIPAddress a) find out the IP address based on PodName and PodNameSpace
-// b)  mark IP address as unassigned c) returns IP address, ENI's device number, error
-func (ds *DataStore) UnassignPodIPAddress(ipamKey IPAMKey) (e *ENI, ip string, deviceNumber int, err error) {
+// UnassignPodIPAddress a) find out the IP addresses based on PodName and PodNameSpace
+// b)  mark IP addresses as unassigned c) returns the ENI and its device number, the IP addresses, error.
+// A dual-stack sandbox releases both its addresses, the ENI and device number are the ones of its IPv4 address.
+func (ds *DataStore) UnassignPodIPAddress(ipamKey IPAMKey) (e *ENI, ipv4 string, ipv6 string, deviceNumber int, err error) {
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 	ds.log.Debugf("UnassignPodIPAddress: IP address pool stats: total %d, assigned %d, sandbox %s", ds.total, ds.assigned, ipamKey)
 
-	eni, availableCidr, addr := ds.eniPool.FindAddressForSandbox(ipamKey)
-	if addr == nil {
+	addresses := ds.eniPool.findSandboxAddresses(ipamKey)
+	if len(addresses) == 0 {
 		// If the entry is not present in state file, check if it is present under placeholder value.
 		// This scenario could happen if the pod was created by an older CNI version back when CRI read was done.
 		ds.log.Debugf("UnassignPodIPAddress: Failed to find IPAM entry under full key, trying CRI-migrated version")
 		ipamKey.NetworkName = backfillNetworkName
 		ipamKey.IfName = backfillNetworkIface
-		eni, availableCidr, addr = ds.eniPool.FindAddressForSandbox(ipamKey)
+		addresses = ds.eniPool.findSandboxAddresses(ipamKey)
 
 		// If entry is still not found, IPAMD has no knowledge of this pod, so there is nothing to do.
-		if addr == nil {
+		if len(addresses) == 0 {
 			ds.log.Warnf("UnassignPodIPAddress: Failed to find sandbox %s", ipamKey)
-			return nil, "", 0, ErrUnknownPod
+			return nil, "", "", 0, ErrUnknownPod
 		}
 	}
 
-	originalIPAMMetadata := addr.IPAMMetadata
-	originalAssignedTime := addr.AssignedTime
-	ds.unassignPodIPAddressUnsafe(addr)
+	infos := make([]PodIPInfo, len(addresses))
+	assignedTimes := make([]time.Time, len(addresses))
+	now := ds.clock.Now()
+	entry := newCheckpointEntry(addresses[0].addr)
+	var delta CheckpointDelta
+	for i, address := range addresses {
+		infos[i] = newPodIPInfo(address.eni, address.addr)
+		assignedTimes[i] = address.addr.AssignedTime
+		if address.isIPv6 {
+			entry.IPv6 = address.addr.Address
+		} else {
+			entry.IPv4 = address.addr.Address
+		}
+		ds.unassignPodIPAddressUnsafe(address.addr)
+		ds.reserveAddressUnsafe(address.addr, infos[i].IPAMMetadata, now)
+		if address.addr.reserved(now) {
+			delta.Reserved = append(delta.Reserved, newCheckpointReservation(address.addr, address.isIPv6))
+		}
+	}
+	delta.Unassigned = []CheckpointEntry{entry}
+	if err := ds.writeBackingStoreDeltaUnsafe(delta); err != nil {
+		// Unwind un-assignment
+		for i, address := range addresses {
+			ds.assignPodIPAddressUnsafe(address.addr, ipamKey, infos[i].IPAMMetadata, assignedTimes[i])
+		}
+		return nil, "", "", 0, err
+	}
+
+	for i, address := range addresses {
+		address.addr.UnassignedTime = now
+		//Update prometheus for ips per cidr
+		prometheusmetrics.IpsPerCidr.With(prometheus.Labels{"cidr": address.cidr.Cidr.String()}).Dec()
+		ds.log.Infof("UnassignPodIPAddress: sandbox %s's ipAddr %s, DeviceNumber %d",
+			ipamKey, address.addr.Address, address.eni.DeviceNumber)
+		// Decrement ENI IP usage when a pod is deallocated
+		prometheusmetrics.EniIPsInUse.WithLabelValues(address.eni.ID).Dec()
+		ds.notifyWatchersUnsafe(AllocationUnassigned, infos[i])
+		if address.isIPv6 {
+			ipv6 = address.addr.Address
+		} else {
+			ipv4 = address.addr.Address
+		}
+	}
+	// The IPv4 address is listed first
+	return addresses[0].eni, ipv4, ipv6, addresses[0].eni.DeviceNumber, nil
+}
+
+// GetPodIPAddress returns the IP addresses and ENI device number currently assigned to the sandbox, or ErrUnknownPod
+// if the datastore holds no allocation for it. Unlike UnassignPodIPAddress, the allocation is left untouched.
+func (ds *DataStore) GetPodIPAddress(ipamKey IPAMKey) (ipv4 string, ipv6 string, deviceNumber int, err error) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	addresses := ds.eniPool.findSandboxAddresses(ipamKey)
+	if len(addresses) == 0 {
+		// Pods created by an older CNI version may still be stored under the CRI-migrated placeholder key.
+		ipamKey.NetworkName = backfillNetworkName
+		ipamKey.IfName = backfillNetworkIface
+		addresses = ds.eniPool.findSandboxAddresses(ipamKey)
+		if len(addresses) == 0 {
+			ds.log.Debugf("GetPodIPAddress: Failed to find sandbox %s", ipamKey)
+			return "", "", 0, ErrUnknownPod
+		}
+	}
+	for _, address := range addresses {
+		if address.isIPv6 {
+			ipv6 = address.addr.Address
+		} else {
+			ipv4 = address.addr.Address
+		}
+	}
+	return ipv4, ipv6, addresses[0].eni.DeviceNumber, nil
+}
+
+// ReleaseStaleAllocations unassigns every allocation on networkName whose (ContainerID, IfName) is not present in
+// validAttachments and returns the released allocations so that the caller can tear down what is left of their pod
+// network. Allocations stored under the CRI-migrated placeholder key are matched on ContainerID only. Allocations
+// belonging to other networks are never touched.
+func (ds *DataStore) ReleaseStaleAllocations(networkName string, validAttachments []IPAMKey) ([]PodIPInfo, error) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	validKeys := make(map[IPAMKey]bool, len(validAttachments))
+	validContainers := make(map[string]bool, len(validAttachments))
+	for _, key := range validAttachments {
+		validKeys[IPAMKey{NetworkName: networkName, ContainerID: key.ContainerID, IfName: key.IfName}] = true
+		validContainers[key.ContainerID] = true
+	}
+	isStale := func(key IPAMKey) bool {
+		switch key.NetworkName {
+		case networkName:
+			return !validKeys[key]
+		case backfillNetworkName:
+			return !validContainers[key.ContainerID]
+		}
+		return false
+	}
+
+	type staleAddress struct {
+		cidr         *CidrInfo
+		addr         *AddressInfo
+		info         PodIPInfo
+		assignedTime time.Time
+	}
+	var stale []staleAddress
+	for _, eni := range ds.eniPool {
+		for _, cidrs := range []map[string]*CidrInfo{eni.AvailableIPv4Cidrs, eni.IPv6Cidrs} {
+			for _, cidr := range cidrs {
+				for _, addr := range cidr.IPAddresses {
+					if addr.Assigned() && isStale(addr.IPAMKey) {
+						stale = append(stale, staleAddress{
+							cidr:         cidr,
+							addr:         addr,
+							info:         newPodIPInfo(eni, addr),
+							assignedTime: addr.AssignedTime,
+						})
+					}
+				}
+			}
+		}
+	}
+	if len(stale) == 0 {
+		return nil, nil
+	}
+
+	for _, s := range stale {
+		ds.unassignPodIPAddressUnsafe(s.addr)
+	}
 	if err := ds.writeBackingStoreUnsafe(); err != nil {
 		// Unwind un-assignment
-		ds.assignPodIPAddressUnsafe(addr, ipamKey, originalIPAMMetadata, originalAssignedTime)
-		return nil, "", 0, err
+		for _, s := range stale {
+			ds.assignPodIPAddressUnsafe(s.addr, s.info.IPAMKey, s.info.IPAMMetadata, s.assignedTime)
+		}
+		return nil, err
 	}
-	addr.UnassignedTime = time.Now()
 
-	//Update prometheus for ips per cidr
-	prometheusmetrics.IpsPerCidr.With(prometheus.Labels{"cidr": availableCidr.Cidr.String()}).Dec()
-	ds.log.Infof("UnassignPodIPAddress: sandbox %s's ipAddr %s, DeviceNumber %d",
-		ipamKey, addr.Address, eni.DeviceNumber)
-	// Decrement ENI IP usage when a pod is deallocated
-	prometheusmetrics.EniIPsInUse.WithLabelValues(eni.ID).Dec()
-	return eni, addr.Address, eni.DeviceNumber, nil
+	released := make([]PodIPInfo, 0, len(stale))
+	now := ds.clock.Now()
+	for _, s := range stale {
+		s.addr.UnassignedTime = now
+		prometheusmetrics.IpsPerCidr.With(prometheus.Labels{"cidr": s.cidr.Cidr.String()}).Dec()
+		prometheusmetrics.EniIPsInUse.WithLabelValues(s.info.ENIID).Dec()
+		ds.log.Infof("ReleaseStaleAllocations: sandbox %s's ipAddr %s, DeviceNumber %d",
+			s.info.IPAMKey, s.info.IP, s.info.DeviceNumber)
+		ds.notifyWatchersUnsafe(AllocationUnassigned, s.info)
+		released = append(released, s.info)
+	}
+	return released, nil
+}
+
+// HasPodAllocation returns true if any sandbox of the given pod still holds an IP address. Host-side veth names are
+// derived from the pod namespace and name, so a veth must be left alone while another sandbox of the pod is using it.
+func (ds *DataStore) HasPodAllocation(podNamespace, podName string) bool {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	for _, eni := range ds.eniPool {
+		for _, cidrs := range []map[string]*CidrInfo{eni.AvailableIPv4Cidrs, eni.IPv6Cidrs} {
+			for _, cidr := range cidrs {
+				for _, addr := range cidr.IPAddresses {
+					if addr.Assigned() && addr.IPAMMetadata.K8SPodNamespace == podNamespace && addr.IPAMMetadata.K8SPodName == podName {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
 }
 
 // AllocatedIPs returns a recent snapshot of allocated sandbox<->IPs.
@@ -1133,12 +1572,26 @@ func (ds *DataStore) AllocatedIPs() []PodIPInfo {
 		for _, assignedaddr := range eni.AvailableIPv4Cidrs {
 			for _, addr := range assignedaddr.IPAddresses {
 				if addr.Assigned() {
-					info := PodIPInfo{
-						IPAMKey:      addr.IPAMKey,
-						IP:           addr.Address,
-						DeviceNumber: eni.DeviceNumber,
-					}
-					ret = append(ret, info)
+					ret = append(ret, newPodIPInfo(eni, addr))
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// AllocatedIPv6s is the IPv6 counterpart of AllocatedIPs.
+// Note result may already be stale by the time you look at it.
+func (ds *DataStore) AllocatedIPv6s() []PodIPInfo {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	var ret []PodIPInfo
+	for _, eni := range ds.eniPool {
+		for _, assignedaddr := range eni.IPv6Cidrs {
+			for _, addr := range assignedaddr.IPAddresses {
+				if addr.Assigned() {
+					ret = append(ret, newPodIPInfo(eni, addr))
 				}
 			}
 		}
@@ -1158,9 +1611,12 @@ func (ds *DataStore) FreeableIPs(eniID string) []net.IPNet {
 		return nil
 	}
 
+	// Addresses held for deleted sticky IP pods are kept until the end of their grace period
+	now := ds.clock.Now()
 	freeable := make([]net.IPNet, 0, len(eni.AvailableIPv4Cidrs))
 	for _, assignedaddr := range eni.AvailableIPv4Cidrs {
-		if !assignedaddr.IsPrefix && assignedaddr.AssignedIPAddressesInCidr() == 0 {
+		if !assignedaddr.IsPrefix && assignedaddr.AssignedIPAddressesInCidr() == 0 &&
+			assignedaddr.GetIPStatsFromCidr(now, ds.ipCooldownPeriod).ReservedIPs == 0 {
 			freeable = append(freeable, assignedaddr.Cidr)
 		}
 	}
@@ -1180,9 +1636,11 @@ func (ds *DataStore) FreeablePrefixes(eniID string) []net.IPNet {
 		return nil
 	}
 
+	now := ds.clock.Now()
 	freeable := make([]net.IPNet, 0, len(eni.AvailableIPv4Cidrs))
 	for _, assignedaddr := range eni.AvailableIPv4Cidrs {
-		if assignedaddr.IsPrefix && assignedaddr.AssignedIPAddressesInCidr() == 0 {
+		if assignedaddr.IsPrefix && assignedaddr.AssignedIPAddressesInCidr() == 0 &&
+			assignedaddr.GetIPStatsFromCidr(now, ds.ipCooldownPeriod).ReservedIPs == 0 {
 			freeable = append(freeable, assignedaddr.Cidr)
 		}
 	}
@@ -1262,13 +1720,16 @@ func (ds *DataStore) GetENICIDRs(eniID string) ([]string, []string, error) {
 	return ipPool, prefixPool, nil
 }
 
-// GetFreePrefixes return free prefixes
-func (ds *DataStore) GetFreePrefixes() int {
+// GetFreePrefixes return free prefixes of the given ENI pool
+func (ds *DataStore) GetFreePrefixes(pool string) int {
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 
 	freePrefixes := 0
 	for _, other := range ds.eniPool {
+		if other.Pool != pool {
+			continue
+		}
 		for _, otherPrefixes := range other.AvailableIPv4Cidrs {
 			if otherPrefixes.IsPrefix && otherPrefixes.AssignedIPAddressesInCidr() == 0 {
 				freePrefixes++
@@ -1278,77 +1739,6 @@ func (ds *DataStore) GetFreePrefixes() int {
 	return freePrefixes
 }
 
-// getFreeIPv4AddrfromCidr returs a free IP/32 address from CIDR
-func (ds *DataStore) getFreeIPv4AddrfromCidr(availableCidr *CidrInfo) (string, error) {
-	if availableCidr == nil {
-		ds.log.Errorf("Prefix datastore not initialized")
-		return "", errors.New("Prefix datastore not initialized")
-	}
-	strPrivateIPv4, err := ds.getUnusedIP(availableCidr)
-	if err != nil {
-		ds.log.Debugf("Get free IP from prefix failed %v", err)
-		return "", err
-	}
-	ds.log.Debugf("Returning Free IP %s", strPrivateIPv4)
-	return strPrivateIPv4, nil
-}
-
-func (ds *DataStore) getFreeIPv6AddrFromCidr(IPv6Cidr *CidrInfo) (string, error) {
-	if IPv6Cidr == nil {
-		ds.log.Errorf("Prefix datastore not initialized")
-		return "", errors.New("Prefix datastore not initialized")
-	}
-	ipv6Address, err := ds.getUnusedIP(IPv6Cidr)
-	if err != nil {
-		ds.log.Debugf("Get free IP from prefix failed %v", err)
-		return "", err
-	}
-	ds.log.Debugf("Returning Free IP %s", ipv6Address)
-	return ipv6Address, nil
-}
-
-/*
-  /28 -> 10.1.1.1/32[out of cooldown], 10.1.1.2/32[out of cooldown], 10.1.1.3/32[assigned]
-  cached ip = 10.1.1.1/32
-  /28 ->  10.1.1.3/32[assigned]
-  return 10.1.1.1/32
-*/
-
-func (ds *DataStore) getUnusedIP(availableCidr *CidrInfo) (string, error) {
-	//Check if there is any IP out of cooldown
-	var cachedIP string
-	for _, addr := range availableCidr.IPAddresses {
-		if !addr.Assigned() && !addr.inCoolingPeriod(ds.ipCooldownPeriod) {
-			//if the IP is out of cooldown and not assigned then cache the first available IP
-			//continue cleaning up the DB, this is to avoid stale entries and a new thread :)
-			if cachedIP == "" {
-				cachedIP = addr.Address
-			}
-			//availableCidr.IPAddresses[addr.Address] = nil //Avoid mem leak - TODO
-			delete(availableCidr.IPAddresses, addr.Address)
-		}
-	}
-
-	if cachedIP != "" {
-		return cachedIP, nil
-	}
-
-	//If not in cooldown then generate next IP
-	ipnet := availableCidr.Cidr
-	ip := availableCidr.Cidr.IP
-
-	for ip := ip.Mask(ipnet.Mask); ipnet.Contains(ip); getNextIPAddr(ip) {
-		strPrivateIPv4 := ip.String()
-		if _, ok := availableCidr.IPAddresses[strPrivateIPv4]; ok {
-			continue
-		}
-		ds.log.Debugf("Found a free IP not in DB - %s", strPrivateIPv4)
-		return strPrivateIPv4, nil
-	}
-
-	return "", fmt.Errorf("no free IP available in the prefix - %s/%s", availableCidr.Cidr.IP, availableCidr.Cidr.Mask)
-}
-
 func getNextIPAddr(ip net.IP) {
 	for j := len(ip) - 1; j >= 0; j-- {
 		ip[j]++
@@ -1381,7 +1771,7 @@ func (ds *DataStore) FindFreeableCidrs(eniID string) []CidrInfo {
 
 	var freeable []CidrInfo
 	for _, assignedaddr := range eni.AvailableIPv4Cidrs {
-		if assignedaddr.AssignedIPAddressesInCidr() == 0 {
+		if assignedaddr.AssignedIPAddressesInCidr() == 0 && assignedaddr.GetIPStatsFromCidr(ds.clock.Now(), ds.ipCooldownPeriod).ReservedIPs == 0 {
 			tempFreeable := CidrInfo{
 				Cidr:          assignedaddr.Cidr,
 				IPAddresses:   nil,
@@ -1399,13 +1789,17 @@ func DivCeil(x, y int) int {
 	return (x + y - 1) / y
 }
 
-// CheckFreeableENIexists will return true if there is an ENI which is unused.
+// CheckFreeableENIexists will return true if there is an ENI in the given ENI pool which is unused.
 // Could have just called getDeletaleENI, this is just to optimize a bit.
-func (ds *DataStore) CheckFreeableENIexists() bool {
+func (ds *DataStore) CheckFreeableENIexists(pool string) bool {
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
 
 	for _, eni := range ds.eniPool {
+		if eni.Pool != pool {
+			continue
+		}
+
 		if eni.IsPrimary {
 			ds.log.Debugf("ENI %s cannot be deleted because it is primary", eni.ID)
 			continue
@@ -1485,52 +1879,48 @@ func (ds *DataStore) PruneStaleAllocations(staleAllocations []CheckpointEntry) {
 func (ds *DataStore) DeleteToContainerRule(entry *CheckpointEntry) {
 	ds.log.Infof("Delete toContainer rule for v4: %s, v6: %s", entry.IPv4, entry.IPv6)
 	// Remove toContainer rule, if it exists. Note that toContainer rule will always be in main routing table.
-	toContainerRule := ds.netLink.NewRule()
-	toContainerRule.Priority = networkutils.ToContainerRulePriority
-	toContainerRule.Table = unix.RT_TABLE_MAIN
-	var addr *net.IPNet
-
-	if entry.IPv4 != "" {
-		addr = &net.IPNet{
-			IP:   net.ParseIP(entry.IPv4),
-			Mask: net.CIDRMask(32, 32),
-		}
-	} else {
-		addr = &net.IPNet{
-			IP:   net.ParseIP(entry.IPv6),
-			Mask: net.CIDRMask(128, 128),
+	for _, addr := range checkpointEntryAddrs(entry) {
+		toContainerRule := ds.netLink.NewRule()
+		toContainerRule.Priority = networkutils.ToContainerRulePriority
+		toContainerRule.Table = unix.RT_TABLE_MAIN
+		toContainerRule.Dst = addr
+		if err := ds.netLink.RuleDel(toContainerRule); err != nil && !networkutils.ContainsNoSuchRule(err) {
+			// Continue to prune, even on deletion error
+			ds.log.Errorf("failed to delete toContainer rule, addr=%s", addr.String())
 		}
 	}
-	toContainerRule.Dst = addr
-	if err := ds.netLink.RuleDel(toContainerRule); err != nil && !networkutils.ContainsNoSuchRule(err) {
-		// Continue to prune, even on deletion error
-		ds.log.Errorf("failed to delete toContainer rule, addr=%s", addr.String())
-	}
 }
 
 func (ds *DataStore) DeleteFromContainerRule(entry *CheckpointEntry) {
 	ds.log.Infof("Delete fromContainer rule for v4: %s, v6: %s", entry.IPv4, entry.IPv6)
 	// Remove fromContainer rule, if it exists. Note that fromContainer rule can be in any routing table,
 	// so no table is set.
-	fromContainerRule := ds.netLink.NewRule()
-	fromContainerRule.Priority = networkutils.FromPodRulePriority
-	fromContainerRule.Table = unix.RT_TABLE_UNSPEC
-	var addr *net.IPNet
+	for _, addr := range checkpointEntryAddrs(entry) {
+		fromContainerRule := ds.netLink.NewRule()
+		fromContainerRule.Priority = networkutils.FromPodRulePriority
+		fromContainerRule.Table = unix.RT_TABLE_UNSPEC
+		fromContainerRule.Src = addr
+		if err := ds.netLink.RuleDel(fromContainerRule); err != nil && !networkutils.ContainsNoSuchRule(err) {
+			// Continue to prune, even on deletion error
+			ds.log.Errorf("failed to delete fromPod rule, addr=%s", addr.String())
+		}
+	}
+}
 
+// checkpointEntryAddrs returns the host prefixes of the addresses in a checkpoint row, the IPv4 one first
+func checkpointEntryAddrs(entry *CheckpointEntry) []*net.IPNet {
+	var addrs []*net.IPNet
 	if entry.IPv4 != "" {
-		addr = &net.IPNet{
+		addrs = append(addrs, &net.IPNet{
 			IP:   net.ParseIP(entry.IPv4),
 			Mask: net.CIDRMask(32, 32),
-		}
-	} else {
-		addr = &net.IPNet{
+		})
+	}
+	if entry.IPv6 != "" {
+		addrs = append(addrs, &net.IPNet{
 			IP:   net.ParseIP(entry.IPv6),
 			Mask: net.CIDRMask(128, 128),
-		}
-	}
-	fromContainerRule.Src = addr
-	if err := ds.netLink.RuleDel(fromContainerRule); err != nil && !networkutils.ContainsNoSuchRule(err) {
-		// Continue to prune, even on deletion error
-		ds.log.Errorf("failed to delete fromPod rule, addr=%s", addr.String())
+		})
 	}
+	return addrs
 }
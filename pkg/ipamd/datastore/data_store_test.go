@@ -543,7 +543,7 @@ func TestPodIPv4Address(t *testing.T) {
 	assert.Equal(t, 1, ds.eniPool["eni-1"].AssignedIPv4Addresses())
 
 	expectedCheckpointData := &CheckpointData{
-		Version: CheckpointFormatVersion,
+		Version: formatCheckpointVersion(1),
 		Allocations: []CheckpointEntry{
 			{
 				IPAMKey:  IPAMKey{NetworkName: "net0", ContainerID: "sandbox-1", IfName: "eth0"},
@@ -560,6 +560,12 @@ func TestPodIPv4Address(t *testing.T) {
 	podsInfos := ds.AllocatedIPs()
 	assert.Equal(t, len(podsInfos), 1)
 
+	ip, _, deviceNum, err := ds.GetPodIPAddress(key1)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.1.1", ip)
+	assert.Equal(t, 1, deviceNum)
+	assert.Equal(t, 1, ds.assigned)
+
 	ipv4Addr2 := net.IPNet{IP: net.ParseIP("1.1.2.2"), Mask: net.IPv4Mask(255, 255, 255, 255)}
 	err = ds.AddIPv4CidrToStore("eni-2", ipv4Addr2, false)
 	assert.NoError(t, err)
@@ -582,7 +588,7 @@ func TestPodIPv4Address(t *testing.T) {
 	assert.Error(t, err)
 
 	expectedCheckpointData = &CheckpointData{
-		Version: CheckpointFormatVersion,
+		Version: formatCheckpointVersion(1),
 		Allocations: []CheckpointEntry{
 			{
 				IPAMKey:  IPAMKey{NetworkName: "net0", ContainerID: "sandbox-1", IfName: "eth0"},
@@ -606,7 +612,7 @@ func TestPodIPv4Address(t *testing.T) {
 	assert.Equal(t, ds.eniPool["eni-2"].AssignedIPv4Addresses(), 1)
 
 	expectedCheckpointData = &CheckpointData{
-		Version: CheckpointFormatVersion,
+		Version: formatCheckpointVersion(1),
 		Allocations: []CheckpointEntry{
 			{
 				IPAMKey:  IPAMKey{NetworkName: "net0", ContainerID: "sandbox-1", IfName: "eth0"},
@@ -641,7 +647,7 @@ func TestPodIPv4Address(t *testing.T) {
 	assert.Equal(t, len(ds.eniPool["eni-1"].AvailableIPv4Cidrs), 2)
 	assert.Equal(t, ds.eniPool["eni-1"].AssignedIPv4Addresses(), 2)
 	expectedCheckpointData = &CheckpointData{
-		Version: CheckpointFormatVersion,
+		Version: formatCheckpointVersion(1),
 		Allocations: []CheckpointEntry{
 			{
 				IPAMKey:  IPAMKey{NetworkName: "net0", ContainerID: "sandbox-1", IfName: "eth0"},
@@ -670,10 +676,12 @@ func TestPodIPv4Address(t *testing.T) {
 	_, _, err = ds.AssignPodIPv4Address(key4, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "sample-pod-4"})
 	assert.Error(t, err)
 	// Unassign unknown Pod
-	_, _, _, err = ds.UnassignPodIPAddress(key4)
+	_, _, _, _, err = ds.UnassignPodIPAddress(key4)
 	assert.Error(t, err)
+	_, _, _, err = ds.GetPodIPAddress(key4)
+	assert.Equal(t, ErrUnknownPod, err)
 
-	_, _, deviceNum, err := ds.UnassignPodIPAddress(key2)
+	_, _, _, deviceNum, err = ds.UnassignPodIPAddress(key2)
 	assert.NoError(t, err)
 	assert.Equal(t, ds.total, 3)
 	assert.Equal(t, ds.assigned, 2)
@@ -681,7 +689,7 @@ func TestPodIPv4Address(t *testing.T) {
 	assert.Equal(t, len(ds.eniPool["eni-2"].AvailableIPv4Cidrs), 1)
 	assert.Equal(t, ds.eniPool["eni-2"].AssignedIPv4Addresses(), 0)
 	expectedCheckpointData = &CheckpointData{
-		Version: CheckpointFormatVersion,
+		Version: formatCheckpointVersion(1),
 		Allocations: []CheckpointEntry{
 			{
 				IPAMKey:  IPAMKey{NetworkName: "net0", ContainerID: "sandbox-1", IfName: "eth0"},
@@ -705,18 +713,68 @@ func TestPodIPv4Address(t *testing.T) {
 	noWarmPrefixTarget := 0
 
 	// Should not be able to free this ENI
-	eni := ds.RemoveUnusedENIFromStore(noWarmIPTarget, noMinimumIPTarget, noWarmPrefixTarget)
+	eni := ds.RemoveUnusedENIFromStore(DefaultENIPool, noWarmIPTarget, noMinimumIPTarget, noWarmPrefixTarget)
 	assert.True(t, eni == "")
 
 	ds.eniPool["eni-2"].createTime = time.Time{}
 	ds.eniPool["eni-2"].AvailableIPv4Cidrs[ipv4Addr2.String()].IPAddresses["1.1.2.2"].UnassignedTime = time.Time{}
-	eni = ds.RemoveUnusedENIFromStore(noWarmIPTarget, noMinimumIPTarget, noWarmPrefixTarget)
+	eni = ds.RemoveUnusedENIFromStore(DefaultENIPool, noWarmIPTarget, noMinimumIPTarget, noWarmPrefixTarget)
 	assert.Equal(t, eni, "eni-2")
 
 	assert.Equal(t, ds.total, 2)
 	assert.Equal(t, ds.assigned, 2)
 }
 
+func TestReleaseStaleAllocations(t *testing.T) {
+	checkpoint := NewTestCheckpoint(struct{}{})
+	ds := NewDataStore(Testlog, checkpoint, false)
+
+	err := ds.AddENI("eni-1", 1, true, false, false)
+	assert.NoError(t, err)
+	for _, ip := range []string{"1.1.1.1", "1.1.1.2", "1.1.1.3", "1.1.1.4", "1.1.1.5"} {
+		err = ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP(ip), Mask: net.IPv4Mask(255, 255, 255, 255)}, false)
+		assert.NoError(t, err)
+	}
+
+	validKey := IPAMKey{"net0", "sandbox-1", "eth0"}
+	staleKey := IPAMKey{"net0", "sandbox-2", "eth0"}
+	otherNetworkKey := IPAMKey{"net1", "sandbox-3", "eth0"}
+	validMigratedKey := IPAMKey{backfillNetworkName, "sandbox-1", backfillNetworkIface}
+	staleMigratedKey := IPAMKey{backfillNetworkName, "sandbox-4", backfillNetworkIface}
+	for _, key := range []IPAMKey{validKey, staleKey, otherNetworkKey, validMigratedKey, staleMigratedKey} {
+		_, _, err = ds.AssignPodIPv4Address(key, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: key.ContainerID})
+		assert.NoError(t, err)
+	}
+
+	// A failed checkpoint must leave every allocation in place
+	checkpoint.Error = errors.New("fake checkpoint error")
+	released, err := ds.ReleaseStaleAllocations("net0", []IPAMKey{validKey})
+	assert.Error(t, err)
+	assert.Empty(t, released)
+	assert.Equal(t, 5, ds.assigned)
+
+	checkpoint.Error = nil
+	released, err = ds.ReleaseStaleAllocations("net0", []IPAMKey{validKey})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(released))
+	releasedKeys := []IPAMKey{released[0].IPAMKey, released[1].IPAMKey}
+	assert.ElementsMatch(t, []IPAMKey{staleKey, staleMigratedKey}, releasedKeys)
+	for _, info := range released {
+		assert.Equal(t, 1, info.DeviceNumber)
+		assert.Equal(t, info.IPAMKey.ContainerID, info.IPAMMetadata.K8SPodName)
+	}
+	assert.Equal(t, 3, ds.assigned)
+	assert.Equal(t, 3, len(checkpoint.Data.(*CheckpointData).Allocations))
+
+	assert.True(t, ds.HasPodAllocation("default", "sandbox-1"))
+	assert.False(t, ds.HasPodAllocation("default", "sandbox-2"))
+
+	// Nothing left to collect
+	released, err = ds.ReleaseStaleAllocations("net0", []IPAMKey{validKey})
+	assert.NoError(t, err)
+	assert.Empty(t, released)
+}
+
 func TestPodIPv4AddressWithPDEnabled(t *testing.T) {
 	checkpoint := NewTestCheckpoint(struct{}{})
 	ds := NewDataStore(Testlog, checkpoint, true)
@@ -748,7 +806,7 @@ func TestPodIPv4AddressWithPDEnabled(t *testing.T) {
 	assert.Equal(t, 1, ds.eniPool["eni-1"].AssignedIPv4Addresses())
 
 	expectedCheckpointData := &CheckpointData{
-		Version: CheckpointFormatVersion,
+		Version: formatCheckpointVersion(1),
 		Allocations: []CheckpointEntry{
 			{
 				IPAMKey:  IPAMKey{NetworkName: "net0", ContainerID: "sandbox-1", IfName: "eth0"},
@@ -781,7 +839,7 @@ func TestPodIPv4AddressWithPDEnabled(t *testing.T) {
 	assert.Error(t, err)
 
 	expectedCheckpointData = &CheckpointData{
-		Version: CheckpointFormatVersion,
+		Version: formatCheckpointVersion(1),
 		Allocations: []CheckpointEntry{
 			{
 				IPAMKey:  IPAMKey{NetworkName: "net0", ContainerID: "sandbox-1", IfName: "eth0"},
@@ -805,7 +863,7 @@ func TestPodIPv4AddressWithPDEnabled(t *testing.T) {
 	assert.Equal(t, ds.eniPool["eni-1"].AssignedIPv4Addresses(), 2)
 
 	expectedCheckpointData = &CheckpointData{
-		Version: CheckpointFormatVersion,
+		Version: formatCheckpointVersion(1),
 		Allocations: []CheckpointEntry{
 			{
 				IPAMKey:  IPAMKey{NetworkName: "net0", ContainerID: "sandbox-1", IfName: "eth0"},
@@ -836,7 +894,7 @@ func TestPodIPv4AddressWithPDEnabled(t *testing.T) {
 	assert.Equal(t, len(ds.eniPool["eni-1"].AvailableIPv4Cidrs), 1)
 	assert.Equal(t, ds.eniPool["eni-1"].AssignedIPv4Addresses(), 3)
 	expectedCheckpointData = &CheckpointData{
-		Version: CheckpointFormatVersion,
+		Version: formatCheckpointVersion(1),
 		Allocations: []CheckpointEntry{
 			{
 				IPAMKey:  IPAMKey{NetworkName: "net0", ContainerID: "sandbox-1", IfName: "eth0"},
@@ -860,7 +918,7 @@ func TestPodIPv4AddressWithPDEnabled(t *testing.T) {
 		cmp.Diff(checkpoint.Data, expectedCheckpointData, checkpointDataCmpOpts),
 	)
 
-	_, _, deviceNum, err := ds.UnassignPodIPAddress(key2)
+	_, _, _, deviceNum, err := ds.UnassignPodIPAddress(key2)
 	assert.NoError(t, err)
 	assert.Equal(t, ds.total, 16)
 	assert.Equal(t, ds.assigned, 2)
@@ -868,7 +926,7 @@ func TestPodIPv4AddressWithPDEnabled(t *testing.T) {
 	assert.Equal(t, len(ds.eniPool["eni-1"].AvailableIPv4Cidrs), 1)
 	assert.Equal(t, ds.eniPool["eni-1"].AssignedIPv4Addresses(), 2)
 	expectedCheckpointData = &CheckpointData{
-		Version: CheckpointFormatVersion,
+		Version: formatCheckpointVersion(1),
 		Allocations: []CheckpointEntry{
 			{
 				IPAMKey:  IPAMKey{NetworkName: "net0", ContainerID: "sandbox-1", IfName: "eth0"},
@@ -891,6 +949,103 @@ func TestPodIPv4AddressWithPDEnabled(t *testing.T) {
 	assert.Equal(t, ds.assigned, 2)
 }
 
+func newDualStackTestDataStore(t *testing.T, checkpoint Checkpointer) *DataStore {
+	ds := NewDataStore(Testlog, checkpoint, false)
+	assert.NoError(t, ds.AddENI("eni-1", 0, true, false, false))
+	assert.NoError(t, ds.AddENI("eni-2", 1, false, false, false))
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-2", net.IPNet{IP: net.ParseIP("1.1.1.1"), Mask: net.CIDRMask(32, 32)}, false))
+	assert.NoError(t, ds.AddIPv6CidrToStore("eni-1", net.IPNet{IP: net.ParseIP("2001:db8::"), Mask: net.CIDRMask(80, 128)}, true))
+	return ds
+}
+
+func TestPodDualStackAddress(t *testing.T) {
+	checkpoint := NewTestCheckpoint(struct{}{})
+	ds := newDualStackTestDataStore(t, checkpoint)
+
+	key := IPAMKey{"net0", "sandbox-1", "eth0"}
+	metadata := IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "sample-pod-1"}
+	ipv4, ipv6, deviceNumber, err := ds.AssignPodIPAddress(key, metadata, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.1.1", ipv4)
+	assert.Equal(t, "2001:db8::", ipv6)
+	// The device number is the one of the ENI that sponsors the IPv4 address
+	assert.Equal(t, 1, deviceNumber)
+
+	// Both addresses are written to a single checkpoint row, which takes schema version 2
+	expectedCheckpointData := &CheckpointData{
+		Version: formatCheckpointVersion(2),
+		Allocations: []CheckpointEntry{
+			{IPAMKey: key, IPv4: "1.1.1.1", IPv6: "2001:db8::", Metadata: metadata},
+		},
+	}
+	checkpointDataCmpOpts := cmpopts.IgnoreFields(CheckpointEntry{}, "AllocationTimestamp")
+	assert.True(t,
+		cmp.Equal(checkpoint.Data, expectedCheckpointData, checkpointDataCmpOpts),
+		cmp.Diff(checkpoint.Data, expectedCheckpointData, checkpointDataCmpOpts),
+	)
+
+	ipv4, ipv6, deviceNumber, err = ds.GetPodIPAddress(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.1.1", ipv4)
+	assert.Equal(t, "2001:db8::", ipv6)
+	assert.Equal(t, 1, deviceNumber)
+
+	// A restarted ipamd recovers both addresses from the combined row
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	netLink := mock_netlinkwrapper.NewMockNetLink(ctrl)
+	hostVeth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: networkutils.GeneratePodHostVethName("eni", "default", "sample-pod-1")}}
+	netLink.EXPECT().LinkList().Return([]netlink.Link{hostVeth}, nil)
+	restarted := newDualStackTestDataStore(t, checkpoint)
+	restarted.netLink = netLink
+	assert.NoError(t, restarted.ReadBackingStore())
+	ipv4, ipv6, deviceNumber, err = restarted.GetPodIPAddress(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.1.1", ipv4)
+	assert.Equal(t, "2001:db8::", ipv6)
+	assert.Equal(t, 1, deviceNumber)
+
+	eni, ipv4, ipv6, deviceNumber, err := restarted.UnassignPodIPAddress(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "eni-2", eni.ID)
+	assert.Equal(t, "1.1.1.1", ipv4)
+	assert.Equal(t, "2001:db8::", ipv6)
+	assert.Equal(t, 1, deviceNumber)
+	assert.Empty(t, checkpoint.Data.(*CheckpointData).Allocations)
+	assert.Equal(t, 0, restarted.eniPool["eni-2"].AssignedIPv4Addresses())
+	assert.Equal(t, 0, restarted.GetIPStats("6").AssignedIPs)
+
+	_, _, _, _, err = restarted.UnassignPodIPAddress(key)
+	assert.Equal(t, ErrUnknownPod, err)
+}
+
+func TestPodDualStackAddressRollback(t *testing.T) {
+	t.Setenv(envIPCooldownPeriod, "30")
+	checkpoint := NewTestCheckpoint(struct{}{})
+	ds := NewDataStore(Testlog, checkpoint, false)
+	ds.stickyIPGracePeriod = time.Minute
+	assert.NoError(t, ds.AddENI("eni-1", 0, true, false, false))
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP("1.1.1.1"), Mask: net.CIDRMask(32, 32)}, false))
+	events, stop := ds.WatchAllocations()
+	defer stop()
+
+	// Without an IPv6 prefix the pod gets no address at all
+	key := IPAMKey{"net0", "sandbox-1", "eth0"}
+	_, _, deviceNumber, err := ds.AssignPodIPAddress(key, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "sample-pod-1"}, true, true)
+	assert.Error(t, err)
+	assert.Equal(t, -1, deviceNumber)
+	assert.Equal(t, 0, ds.eniPool["eni-1"].AssignedIPv4Addresses())
+	assert.Empty(t, checkpoint.Data.(*CheckpointData).Allocations)
+	assert.Empty(t, checkpoint.Data.(*CheckpointData).Reservations)
+	assert.Empty(t, events)
+
+	// The IPv4 address is neither cooling down nor held for the pod
+	assert.Equal(t, 0, ds.GetIPStats("4").CooldownIPs)
+	ipv4, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-2", "eth0"}, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "sample-pod-2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.1.1", ipv4)
+}
+
 func TestGetIPStatsV4(t *testing.T) {
 	os.Setenv(envIPCooldownPeriod, "1")
 	defer os.Unsetenv(envIPCooldownPeriod)
@@ -919,7 +1074,7 @@ func TestGetIPStatsV4(t *testing.T) {
 		*ds.GetIPStats("4"),
 	)
 
-	_, _, _, err = ds.UnassignPodIPAddress(key2)
+	_, _, _, _, err = ds.UnassignPodIPAddress(key2)
 	assert.NoError(t, err)
 
 	assert.Equal(t,
@@ -972,7 +1127,7 @@ func TestGetIPStatsV4WithPD(t *testing.T) {
 		*ds.GetIPStats("4"),
 	)
 
-	_, _, _, err = ds.UnassignPodIPAddress(key2)
+	_, _, _, _, err = ds.UnassignPodIPAddress(key2)
 	assert.NoError(t, err)
 
 	assert.Equal(t,
@@ -1060,15 +1215,15 @@ func TestWarmENIInteractions(t *testing.T) {
 	// We should not be able to remove any ENIs if either warmIPTarget >= 3 or minimumWarmIPTarget >= 5
 
 	// WARM IP TARGET=3, MINIMUM_IP_TARGET=1 => no ENI should be removed
-	eni := ds.RemoveUnusedENIFromStore(3, 1, 0)
+	eni := ds.RemoveUnusedENIFromStore(DefaultENIPool, 3, 1, 0)
 	assert.Equal(t, "", eni)
 
 	// WARM IP TARGET=1, MINIMUM_IP_TARGET=5 => no ENI should be removed
-	eni = ds.RemoveUnusedENIFromStore(1, 5, 0)
+	eni = ds.RemoveUnusedENIFromStore(DefaultENIPool, 1, 5, 0)
 	assert.Equal(t, "", eni)
 
 	// WARM IP TARGET=2, MINIMUM_IP_TARGET=4 => ENI 3 should be removed as we only need 2 free IPs, which ENI 2 has
-	removedEni := ds.RemoveUnusedENIFromStore(2, 4, 0)
+	removedEni := ds.RemoveUnusedENIFromStore(DefaultENIPool, 2, 4, 0)
 	assert.Equal(t, "eni-3", removedEni)
 
 	// We have 2 ENIs, 4 IPs and 2 pods on ENI 1.
@@ -1077,11 +1232,11 @@ func TestWarmENIInteractions(t *testing.T) {
 	// => 2 free IPs
 
 	// WARM IP TARGET=0, MINIMUM_IP_TARGET=3 => no ENI should be removed
-	eni = ds.RemoveUnusedENIFromStore(0, 3, 0)
+	eni = ds.RemoveUnusedENIFromStore(DefaultENIPool, 0, 3, 0)
 	assert.Equal(t, "", eni)
 
 	// WARM IP TARGET=0, MINIMUM_IP_TARGET=2 => ENI 2 should be removed as ENI 1 covers the requirements
-	removedEni = ds.RemoveUnusedENIFromStore(0, 2, 0)
+	removedEni = ds.RemoveUnusedENIFromStore(DefaultENIPool, 0, 2, 0)
 	assert.Contains(t, "eni-2", removedEni)
 
 	// Add 2 more ENIs to the datastore and add 1 IP address to each of them
@@ -1101,7 +1256,7 @@ func TestWarmENIInteractions(t *testing.T) {
 	// => 2 free IPs
 
 	// WARM IP TARGET=0, MINIMUM_IP_TARGET=2 => no ENI can be removed because ENI 4 is a trunk ENI and ENI 5 is an EFA ENI
-	removedEni = ds.RemoveUnusedENIFromStore(0, 2, 0)
+	removedEni = ds.RemoveUnusedENIFromStore(DefaultENIPool, 0, 2, 0)
 	assert.Equal(t, "", removedEni)
 	assert.Equal(t, 3, ds.GetENIs())
 
@@ -1117,7 +1272,7 @@ func TestWarmENIInteractions(t *testing.T) {
 	// => 2 free IPs
 
 	// WARM IP TARGET=0, MINIMUM_IP_TARGET=2 => ENI 6 can be removed
-	removedEni = ds.RemoveUnusedENIFromStore(0, 2, 0)
+	removedEni = ds.RemoveUnusedENIFromStore(DefaultENIPool, 0, 2, 0)
 	assert.Equal(t, "eni-6", removedEni)
 	assert.Equal(t, 3, ds.GetENIs())
 }
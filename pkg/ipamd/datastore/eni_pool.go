@@ -0,0 +1,65 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultENIPool is the pool of ENIs created from the node's own subnet or ENIConfig
+const DefaultENIPool = ""
+
+// SetENIPool moves an ENI into the pool of the given ENIConfig. It has to be called before any address is added to the ENI.
+func (ds *DataStore) SetENIPool(eniID string, pool string) error {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	eni, ok := ds.eniPool[eniID]
+	if !ok {
+		return errors.New(UnknownENIError)
+	}
+	if eni.AssignedIPv4Addresses() > 0 {
+		return errors.Errorf("datastore: ENI %s has IPs assigned and can not change pool", eniID)
+	}
+	ds.log.Debugf("DataStore add ENI %s to ENI pool %q", eniID, pool)
+	eni.Pool = pool
+	return nil
+}
+
+// GetENIPools returns the ENI pools that have ENIs in the datastore, sorted by name
+func (ds *DataStore) GetENIPools() []string {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	seen := make(map[string]bool)
+	var pools []string
+	for _, eni := range ds.eniPool {
+		if !seen[eni.Pool] {
+			seen[eni.Pool] = true
+			pools = append(pools, eni.Pool)
+		}
+	}
+	sort.Strings(pools)
+	return pools
+}
+
+// GetENIPoolIPStats returns the IPv4 DataStoreStats of the ENIs in the given pool
+func (ds *DataStore) GetENIPoolIPStats(pool string) *DataStoreStats {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	return ds.getIPStatsUnsafe("4", func(eni *ENI) bool { return eni.Pool == pool })
+}
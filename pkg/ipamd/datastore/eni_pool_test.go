@@ -0,0 +1,77 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestENIPools(t *testing.T) {
+	ds := NewDataStore(Testlog, NullCheckpoint{}, false)
+	assert.NoError(t, ds.AddENI("eni-1", 0, true, false, false))
+	assert.NoError(t, ds.AddENI("eni-2", 1, false, false, false))
+	assert.NoError(t, ds.SetENIPool("eni-2", "blue"))
+	assert.Error(t, ds.SetENIPool("eni-3", "blue"))
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP("10.0.0.1"), Mask: net.CIDRMask(32, 32)}, false))
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-2", net.IPNet{IP: net.ParseIP("100.64.0.1"), Mask: net.CIDRMask(32, 32)}, false))
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-2", net.IPNet{IP: net.ParseIP("100.64.0.2"), Mask: net.CIDRMask(32, 32)}, false))
+	assert.Equal(t, []string{DefaultENIPool, "blue"}, ds.GetENIPools())
+
+	// Pods only get addresses from the ENIs of their pool
+	ip, deviceNumber, err := ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-1", "eth0"}, IPAMMetadata{K8SPodNamespace: "blue", K8SPodName: "pod-1", ENIPool: "blue"})
+	assert.NoError(t, err)
+	assert.Contains(t, []string{"100.64.0.1", "100.64.0.2"}, ip)
+	assert.Equal(t, 1, deviceNumber)
+	ip, _, err = ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-2", "eth0"}, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "pod-2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", ip)
+	_, _, err = ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-3", "eth0"}, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "pod-3"})
+	assert.Error(t, err)
+	_, _, err = ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-4", "eth0"}, IPAMMetadata{K8SPodNamespace: "green", K8SPodName: "pod-4", ENIPool: "green"})
+	assert.Error(t, err)
+	assert.Error(t, ds.SetENIPool("eni-2", DefaultENIPool))
+
+	stats := ds.GetENIPoolIPStats("blue")
+	assert.Equal(t, 2, stats.TotalIPs)
+	assert.Equal(t, 1, stats.AssignedIPs)
+	stats = ds.GetENIPoolIPStats(DefaultENIPool)
+	assert.Equal(t, 1, stats.TotalIPs)
+	assert.Equal(t, 1, stats.AssignedIPs)
+	assert.Equal(t, 3, ds.GetIPStats("4").TotalIPs)
+}
+
+func TestRemoveUnusedENIFromStoreByPool(t *testing.T) {
+	ds := NewDataStore(Testlog, NullCheckpoint{}, false)
+	assert.NoError(t, ds.AddENI("eni-1", 0, true, false, false))
+	assert.NoError(t, ds.AddENI("eni-2", 1, false, false, false))
+	assert.NoError(t, ds.AddENI("eni-3", 2, false, false, false))
+	assert.NoError(t, ds.SetENIPool("eni-3", "blue"))
+	for eni, ip := range map[string]string{"eni-1": "10.0.0.1", "eni-2": "10.0.0.2", "eni-3": "100.64.0.1"} {
+		assert.NoError(t, ds.AddIPv4CidrToStore(eni, net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(32, 32)}, false))
+	}
+	ds.eniPool["eni-2"].createTime = time.Time{}
+	ds.eniPool["eni-3"].createTime = time.Time{}
+
+	// An ENI of another pool is never freed to meet the targets of this pool
+	assert.True(t, ds.CheckFreeableENIexists("blue"))
+	assert.Equal(t, "eni-3", ds.RemoveUnusedENIFromStore("blue", 0, 0, 0))
+	assert.False(t, ds.CheckFreeableENIexists("blue"))
+	assert.Equal(t, "", ds.RemoveUnusedENIFromStore("blue", 0, 0, 0))
+	assert.Equal(t, "eni-2", ds.RemoveUnusedENIFromStore(DefaultENIPool, 0, 0, 0))
+	assert.Equal(t, []string{DefaultENIPool}, ds.GetENIPools())
+}
@@ -0,0 +1,236 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"bytes"
+	"math/rand"
+	"net"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/logger"
+)
+
+const (
+	// envIPSelectionStrategy selects the strategy used to pick the address assigned to a pod
+	envIPSelectionStrategy = "IP_SELECTION_STRATEGY"
+
+	// IPSelectionDefault assigns the lowest free address of the lowest ENI
+	IPSelectionDefault = "default"
+	// IPSelectionLeastRecentlyReleased assigns the address released the longest time ago, addresses never assigned
+	// first. It keeps addresses unused for as long as possible, so that stale conntrack and ARP entries of a deleted
+	// pod on peers expire before its address is reused.
+	IPSelectionLeastRecentlyReleased = "least-recently-released"
+	// IPSelectionRandom assigns a random free address
+	IPSelectionRandom = "random"
+	// IPSelectionPack assigns an address from the prefix with the most assigned addresses, so that the least used
+	// prefixes empty out and can be released sooner
+	IPSelectionPack = "pack"
+
+	// maxIPCandidatesPerCidr bounds the free addresses considered per CIDR, which matters for IPv6 prefixes.
+	// CIDRs larger than this do not remember released addresses once they are out of cooldown. Smaller ones keep
+	// them, since strategies other than the default one need to know when they were released.
+	maxIPCandidatesPerCidr = 256
+)
+
+// IPCandidate is a free address that can be assigned to a pod
+type IPCandidate struct {
+	ENI     *ENI
+	Cidr    *CidrInfo
+	Address string
+	// UnassignedTime is when the address was released, zero if it was never assigned
+	UnassignedTime time.Time
+	// ip is the parsed Address, used to sort candidates
+	ip net.IP
+}
+
+// eniCidr is a CIDR of an ENI that addresses can be assigned from
+type eniCidr struct {
+	eni  *ENI
+	cidr *CidrInfo
+}
+
+// IPSelectionStrategy decides which free address is assigned to a pod
+type IPSelectionStrategy interface {
+	// Select returns the index of the candidate to assign. Candidates are never empty and are sorted by ENI device
+	// number, CIDR and address.
+	Select(candidates []IPCandidate) int
+}
+
+// NewIPSelectionStrategy returns the strategy with the given name
+func NewIPSelectionStrategy(name string) (IPSelectionStrategy, error) {
+	switch name {
+	case "", IPSelectionDefault:
+		return defaultIPSelection{}, nil
+	case IPSelectionLeastRecentlyReleased:
+		return leastRecentlyReleasedIPSelection{}, nil
+	case IPSelectionRandom:
+		return &randomIPSelection{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}, nil
+	case IPSelectionPack:
+		return packIPSelection{}, nil
+	}
+	return nil, errors.Errorf("unknown IP selection strategy %q, supported strategies are %q, %q, %q and %q", name,
+		IPSelectionDefault, IPSelectionLeastRecentlyReleased, IPSelectionRandom, IPSelectionPack)
+}
+
+// getIPSelectionStrategy returns the strategy configured by the IP_SELECTION_STRATEGY env variable
+func getIPSelectionStrategy(log logger.Logger) IPSelectionStrategy {
+	strategy, err := NewIPSelectionStrategy(os.Getenv(envIPSelectionStrategy))
+	if err != nil {
+		log.Warnf("Invalid %s: %v, using %q", envIPSelectionStrategy, err, IPSelectionDefault)
+		return defaultIPSelection{}
+	}
+	return strategy
+}
+
+type defaultIPSelection struct{}
+
+func (defaultIPSelection) Select(candidates []IPCandidate) int {
+	return 0
+}
+
+type leastRecentlyReleasedIPSelection struct{}
+
+func (leastRecentlyReleasedIPSelection) Select(candidates []IPCandidate) int {
+	selected := 0
+	for i, candidate := range candidates {
+		if candidate.UnassignedTime.Before(candidates[selected].UnassignedTime) {
+			selected = i
+		}
+	}
+	return selected
+}
+
+type randomIPSelection struct {
+	rand *rand.Rand
+}
+
+func (s *randomIPSelection) Select(candidates []IPCandidate) int {
+	return s.rand.Intn(len(candidates))
+}
+
+type packIPSelection struct{}
+
+func (packIPSelection) Select(candidates []IPCandidate) int {
+	selected, selectedAssigned := 0, -1
+	assigned := make(map[*CidrInfo]int)
+	for i, candidate := range candidates {
+		count, ok := assigned[candidate.Cidr]
+		if !ok {
+			count = candidate.Cidr.AssignedIPAddressesInCidr()
+			assigned[candidate.Cidr] = count
+		}
+		if count > selectedAssigned {
+			selected, selectedAssigned = i, count
+		}
+	}
+	return selected
+}
+
+// selectFreeIPUnsafe returns a free address of the CIDRs, picked by the IP selection strategy. The default strategy
+// takes the first free address without collecting the others.
+func (ds *DataStore) selectFreeIPUnsafe(cidrs []eniCidr) (IPCandidate, error) {
+	sort.Slice(cidrs, func(i, j int) bool {
+		a, b := cidrs[i], cidrs[j]
+		if a.eni.DeviceNumber != b.eni.DeviceNumber {
+			return a.eni.DeviceNumber < b.eni.DeviceNumber
+		}
+		if a.eni.ID != b.eni.ID {
+			return a.eni.ID < b.eni.ID
+		}
+		return bytes.Compare(a.cidr.Cidr.IP.To16(), b.cidr.Cidr.IP.To16()) < 0
+	})
+	if _, ok := ds.ipSelection.(defaultIPSelection); ok {
+		for _, c := range cidrs {
+			if address, ok := ds.firstFreeIPUnsafe(c.cidr); ok {
+				return IPCandidate{ENI: c.eni, Cidr: c.cidr, Address: address}, nil
+			}
+		}
+		return IPCandidate{}, errors.New("no free IP available")
+	}
+
+	var candidates []IPCandidate
+	for _, c := range cidrs {
+		candidates = append(candidates, ds.ipCandidatesUnsafe(c.eni, c.cidr)...)
+	}
+	return ds.selectIPUnsafe(candidates)
+}
+
+// firstFreeIPUnsafe returns the lowest free address of the CIDR. Released addresses out of cooldown are forgotten
+// on the way, so that the map of addresses only holds those in use, cooling down or reserved.
+func (ds *DataStore) firstFreeIPUnsafe(cidr *CidrInfo) (string, bool) {
+	now := ds.clock.Now()
+	for _, addr := range cidr.IPAddresses {
+		if !addr.Assigned() && !addr.reserved(now) && !addr.inCoolingPeriod(now, ds.ipCooldownPeriod) {
+			delete(cidr.IPAddresses, addr.Address)
+		}
+	}
+
+	ipnet := cidr.Cidr
+	for ip := ipnet.IP.Mask(ipnet.Mask); ipnet.Contains(ip); getNextIPAddr(ip) {
+		if _, ok := cidr.IPAddresses[ip.String()]; !ok {
+			return ip.String(), true
+		}
+	}
+	return "", false
+}
+
+// ipCandidatesUnsafe returns the free addresses of the CIDR sorted by address, those released and out of cooldown
+// as well as those never assigned
+func (ds *DataStore) ipCandidatesUnsafe(eni *ENI, cidr *CidrInfo) []IPCandidate {
+	var candidates []IPCandidate
+	forgetReleased := cidr.Size() > maxIPCandidatesPerCidr
+	now := ds.clock.Now()
+	for _, addr := range cidr.IPAddresses {
+		if addr.Assigned() || addr.reserved(now) || addr.inCoolingPeriod(now, ds.ipCooldownPeriod) {
+			continue
+		}
+		if forgetReleased {
+			// Avoid growing the map of large prefixes, the address is found again as never assigned below
+			delete(cidr.IPAddresses, addr.Address)
+			continue
+		}
+		candidates = append(candidates, IPCandidate{ENI: eni, Cidr: cidr, Address: addr.Address,
+			UnassignedTime: addr.UnassignedTime, ip: net.ParseIP(addr.Address).To16()})
+	}
+
+	ipnet := cidr.Cidr
+	unused := 0
+	for ip := ipnet.IP.Mask(ipnet.Mask); ipnet.Contains(ip) && unused < maxIPCandidatesPerCidr; getNextIPAddr(ip) {
+		if _, ok := cidr.IPAddresses[ip.String()]; ok {
+			continue
+		}
+		candidates = append(candidates, IPCandidate{ENI: eni, Cidr: cidr, Address: ip.String(), ip: append(net.IP{}, ip.To16()...)})
+		unused++
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return bytes.Compare(candidates[i].ip, candidates[j].ip) < 0
+	})
+	return candidates
+}
+
+// selectIPUnsafe returns the candidate picked by the IP selection strategy. The candidates are sorted by ENI, CIDR
+// and address.
+func (ds *DataStore) selectIPUnsafe(candidates []IPCandidate) (IPCandidate, error) {
+	if len(candidates) == 0 {
+		return IPCandidate{}, errors.New("no free IP available")
+	}
+	candidate := candidates[ds.ipSelection.Select(candidates)]
+	ds.log.Debugf("Selected free IP %s out of %d candidates", candidate.Address, len(candidates))
+	return candidate, nil
+}
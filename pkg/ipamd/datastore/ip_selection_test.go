@@ -0,0 +1,183 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSelectionTestDataStore returns a datastore without IP cooldown using the given strategy
+func newSelectionTestDataStore(t *testing.T, strategy IPSelectionStrategy, isPDEnabled bool) *DataStore {
+	ds := NewDataStore(Testlog, NullCheckpoint{}, isPDEnabled)
+	ds.ipCooldownPeriod = 0
+	ds.ipSelection = strategy
+	assert.NoError(t, ds.AddENI("eni-1", 1, true, false, false))
+	assert.NoError(t, ds.AddENI("eni-2", 2, false, false, false))
+	return ds
+}
+
+func assignTestPod(t *testing.T, ds *DataStore, name string) string {
+	ip, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", name, "eth0"}, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: name})
+	assert.NoError(t, err)
+	return ip
+}
+
+func unassignTestPod(t *testing.T, ds *DataStore, name string, unassignedTime time.Time) {
+	eni, ip, _, _, err := ds.UnassignPodIPAddress(IPAMKey{"net0", name, "eth0"})
+	assert.NoError(t, err)
+	// Make the release order independent of the clock resolution
+	for _, cidr := range eni.AvailableIPv4Cidrs {
+		if addr, ok := cidr.IPAddresses[ip]; ok {
+			addr.UnassignedTime = unassignedTime
+		}
+	}
+}
+
+func TestNewIPSelectionStrategy(t *testing.T) {
+	for _, name := range []string{"", IPSelectionDefault, IPSelectionLeastRecentlyReleased, IPSelectionRandom, IPSelectionPack} {
+		strategy, err := NewIPSelectionStrategy(name)
+		assert.NoError(t, err)
+		assert.NotNil(t, strategy)
+	}
+	_, err := NewIPSelectionStrategy("lowest")
+	assert.Error(t, err)
+
+	t.Setenv(envIPSelectionStrategy, "lowest")
+	assert.Equal(t, defaultIPSelection{}, getIPSelectionStrategy(Testlog))
+	t.Setenv(envIPSelectionStrategy, IPSelectionPack)
+	assert.Equal(t, packIPSelection{}, getIPSelectionStrategy(Testlog))
+}
+
+func TestAssignPodIPv4AddressDefaultSelection(t *testing.T) {
+	ds := newSelectionTestDataStore(t, defaultIPSelection{}, false)
+	for _, ip := range []string{"10.0.0.3", "10.0.0.2", "10.0.0.1"} {
+		assert.NoError(t, ds.AddIPv4CidrToStore("eni-2", net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(32, 32)}, false))
+	}
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP("10.0.0.9"), Mask: net.CIDRMask(32, 32)}, false))
+
+	// The lowest ENI first, then the lowest address
+	assert.Equal(t, "10.0.0.9", assignTestPod(t, ds, "pod-1"))
+	assert.Equal(t, "10.0.0.1", assignTestPod(t, ds, "pod-2"))
+	assert.Equal(t, "10.0.0.2", assignTestPod(t, ds, "pod-3"))
+	unassignTestPod(t, ds, "pod-2", time.Now())
+	assert.Equal(t, "10.0.0.1", assignTestPod(t, ds, "pod-4"))
+}
+
+func TestAssignPodIPv4AddressDefaultSelectionPD(t *testing.T) {
+	ds := newSelectionTestDataStore(t, defaultIPSelection{}, true)
+	_, prefix, _ := net.ParseCIDR("10.0.0.0/28")
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", *prefix, true))
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, fmt.Sprintf("10.0.0.%d", i), assignTestPod(t, ds, fmt.Sprintf("pod-%d", i)))
+	}
+	unassignTestPod(t, ds, "pod-1", time.Now())
+	unassignTestPod(t, ds, "pod-3", time.Now())
+
+	// Released addresses out of cooldown are forgotten, and the lowest free address is assigned
+	assert.Equal(t, "10.0.0.1", assignTestPod(t, ds, "pod-4"))
+	cidr := ds.eniPool["eni-1"].AvailableIPv4Cidrs[prefix.String()]
+	assert.Len(t, cidr.IPAddresses, 3)
+	assert.NotContains(t, cidr.IPAddresses, "10.0.0.3")
+}
+
+func TestAssignPodIPv4AddressLeastRecentlyReleasedSelection(t *testing.T) {
+	ds := newSelectionTestDataStore(t, leastRecentlyReleasedIPSelection{}, false)
+	for i := 1; i <= 4; i++ {
+		assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.IPv4(10, 0, 0, byte(i)), Mask: net.CIDRMask(32, 32)}, false))
+	}
+	for i := 1; i <= 3; i++ {
+		assert.Equal(t, fmt.Sprintf("10.0.0.%d", i), assignTestPod(t, ds, fmt.Sprintf("pod-%d", i)))
+	}
+
+	now := time.Now()
+	unassignTestPod(t, ds, "pod-3", now.Add(-2*time.Minute))
+	unassignTestPod(t, ds, "pod-1", now.Add(-time.Minute))
+	// An address that was never assigned goes first
+	assert.Equal(t, "10.0.0.4", assignTestPod(t, ds, "pod-4"))
+	// Then the address released the longest time ago, even though a lower address is free
+	assert.Equal(t, "10.0.0.3", assignTestPod(t, ds, "pod-5"))
+	assert.Equal(t, "10.0.0.1", assignTestPod(t, ds, "pod-6"))
+}
+
+func TestAssignPodIPv4AddressLeastRecentlyReleasedSelectionPD(t *testing.T) {
+	ds := newSelectionTestDataStore(t, leastRecentlyReleasedIPSelection{}, true)
+	_, prefix, _ := net.ParseCIDR("10.0.0.0/28")
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", *prefix, true))
+
+	// Cycle through every address of the prefix before reusing one
+	seen := map[string]bool{}
+	for i := 0; i < 16; i++ {
+		ip := assignTestPod(t, ds, fmt.Sprintf("pod-%d", i))
+		assert.False(t, seen[ip], "address %s reused", ip)
+		seen[ip] = true
+		unassignTestPod(t, ds, fmt.Sprintf("pod-%d", i), time.Now().Add(time.Duration(i-16)*time.Second))
+	}
+	assert.Equal(t, "10.0.0.0", assignTestPod(t, ds, "pod-16"))
+}
+
+func TestAssignPodIPv4AddressRandomSelection(t *testing.T) {
+	ds := newSelectionTestDataStore(t, &randomIPSelection{rand: rand.New(rand.NewSource(1))}, true)
+	_, prefix, _ := net.ParseCIDR("10.0.0.0/28")
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", *prefix, true))
+
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		ip := assignTestPod(t, ds, "pod")
+		assert.True(t, prefix.Contains(net.ParseIP(ip)))
+		seen[ip] = true
+		unassignTestPod(t, ds, "pod", time.Now())
+	}
+	assert.Greater(t, len(seen), 1)
+
+	// Every address is still handed out exactly once
+	assigned := map[string]bool{}
+	for i := 0; i < 16; i++ {
+		ip := assignTestPod(t, ds, fmt.Sprintf("pod-%d", i))
+		assert.False(t, assigned[ip])
+		assigned[ip] = true
+	}
+	_, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", "pod-16", "eth0"}, IPAMMetadata{})
+	assert.Error(t, err)
+}
+
+func TestAssignPodIPv4AddressPackSelection(t *testing.T) {
+	ds := newSelectionTestDataStore(t, packIPSelection{}, true)
+	_, dense, _ := net.ParseCIDR("10.0.1.0/28")
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-2", *dense, true))
+	for i := 0; i < 3; i++ {
+		assignTestPod(t, ds, fmt.Sprintf("dense-%d", i))
+	}
+	_, sparse, _ := net.ParseCIDR("10.0.0.0/28")
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", *sparse, true))
+
+	// The default strategy would pick the lower ENI and prefix
+	assert.True(t, dense.Contains(net.ParseIP(assignTestPod(t, ds, "dense-3"))))
+
+	ds.ipSelection = defaultIPSelection{}
+	assert.True(t, sparse.Contains(net.ParseIP(assignTestPod(t, ds, "sparse-0"))))
+	assert.True(t, sparse.Contains(net.ParseIP(assignTestPod(t, ds, "sparse-1"))))
+	ds.ipSelection = packIPSelection{}
+
+	// Once most pods on the dense prefix are gone, the other prefix is packed and the first one can be released
+	for i := 0; i < 3; i++ {
+		unassignTestPod(t, ds, fmt.Sprintf("dense-%d", i), time.Now())
+	}
+	assert.True(t, sparse.Contains(net.ParseIP(assignTestPod(t, ds, "sparse-2"))))
+}
@@ -0,0 +1,511 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/logger"
+)
+
+const (
+	// defaultJournalCompactThreshold is the number of records appended to the journal before it is compacted
+	defaultJournalCompactThreshold = 1000
+
+	journalOpSnapshot = "snapshot"
+	journalOpAssign   = "assign"
+	journalOpUnassign = "unassign"
+	journalOpReserve  = "reserve"
+	journalOpRelease  = "release"
+	// journalOpCommit ends the records of a checkpoint, records without it are not replayed
+	journalOpCommit = "commit"
+)
+
+// journalCRC is the checksum table used to detect torn records
+var journalCRC = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrJournalCorrupted is returned by Restore when a record before the end of the journal is damaged
+var ErrJournalCorrupted = errors.New("datastore: journal is corrupted")
+
+// journalRecord is a single line of the journal. Each line is the CRC32C of the record followed by the JSON record.
+type journalRecord struct {
+	Op string `json:"op"`
+	// Data is the complete checkpoint, set on snapshot records
+	Data json.RawMessage `json:"data,omitempty"`
+	// Entry is the allocation assigned or unassigned
+	Entry *CheckpointEntry `json:"entry,omitempty"`
+	// Reservation is the address held or no longer held for a deleted pod
+	Reservation *CheckpointReservation `json:"reservation,omitempty"`
+}
+
+// Journal is a checkpointer that appends the allocations assigned and unassigned, and the addresses reserved and
+// released, since the last checkpoint to a write-ahead journal instead of rewriting the whole checkpoint. The journal starts with a snapshot record and is
+// compacted into a single snapshot every compactThreshold records. CheckpointDelta appends the records of a single
+// pod add or delete as handed over by the datastore. Checkpoint compares the whole checkpoint with the previous one
+// and is only used when the datastore rewrites its state, e.g. on start.
+// The records of a checkpoint are checksummed and followed by a commit record, so a write torn by a crash is
+// detected on Restore and the incomplete checkpoint is discarded.
+//
+// The journal is kept apart from the JSON checkpoint, which releases without the journal read. The JSON checkpoint
+// is restored from until the journal is first written, and rewritten on every compaction, so it lags the journal
+// by at most compactThreshold records. RemoveJournal brings it up to date once the journal is disabled.
+type Journal struct {
+	path string
+	// checkpointPath is the JSON checkpoint, empty when there is none
+	checkpointPath   string
+	log              logger.Logger
+	compactThreshold int
+
+	file *os.File
+	// size is the length of the journal up to the last complete record
+	size int64
+	// records is the number of records appended since the last snapshot
+	records int
+	// header, entries and reservations are the checkpoint as of the last record, nil until the journal is compacted.
+	// Changes to the header, everything but the allocations and reservations, are rare and compact the journal.
+	header       []byte
+	entries      map[string]CheckpointEntry
+	reservations map[string]CheckpointReservation
+}
+
+// NewJournal creates a new Journal at path that carries on from the JSON checkpoint at checkpointPath
+func NewJournal(path, checkpointPath string, log logger.Logger) *Journal {
+	return &Journal{path: path, checkpointPath: checkpointPath, log: log, compactThreshold: defaultJournalCompactThreshold}
+}
+
+// RemoveJournal writes the checkpoint recorded in the journal at path to the JSON checkpoint at checkpointPath and
+// removes the journal, so that the JSONFile checkpointer carries on from the journal once it is disabled
+func RemoveJournal(path, checkpointPath string, log logger.Logger) error {
+	var raw json.RawMessage
+	if err := NewJournal(path, "", log).Restore(&raw); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := NewJSONFile(checkpointPath).Checkpoint(raw); err != nil {
+		return err
+	}
+	log.Infof("Wrote journal %s to checkpoint %s", path, checkpointPath)
+	return os.Remove(path)
+}
+
+// Checkpoint implements the Checkpointer interface
+func (j *Journal) Checkpoint(data interface{}) error {
+	checkpoint, ok := data.(*CheckpointData)
+	if !ok || j.entries == nil || j.records >= j.compactThreshold {
+		return j.compact(data)
+	}
+	header, err := checkpointHeader(checkpoint)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(header, j.header) {
+		return j.compact(data)
+	}
+
+	entries := checkpointEntriesByAddress(checkpoint)
+	var buf bytes.Buffer
+	records := 0
+	for key, entry := range j.entries {
+		if _, ok := entries[key]; !ok {
+			entry := entry
+			if err := encodeJournalRecord(&buf, journalRecord{Op: journalOpUnassign, Entry: &entry}); err != nil {
+				return err
+			}
+			records++
+		}
+	}
+	for key, entry := range entries {
+		if old, ok := j.entries[key]; !ok || old != entry {
+			entry := entry
+			if err := encodeJournalRecord(&buf, journalRecord{Op: journalOpAssign, Entry: &entry}); err != nil {
+				return err
+			}
+			records++
+		}
+	}
+	reservations := checkpointReservationsByAddress(checkpoint)
+	for key, reservation := range j.reservations {
+		if _, ok := reservations[key]; !ok {
+			reservation := reservation
+			if err := encodeJournalRecord(&buf, journalRecord{Op: journalOpRelease, Reservation: &reservation}); err != nil {
+				return err
+			}
+			records++
+		}
+	}
+	for key, reservation := range reservations {
+		if old, ok := j.reservations[key]; !ok || old != reservation {
+			reservation := reservation
+			if err := encodeJournalRecord(&buf, journalRecord{Op: journalOpReserve, Reservation: &reservation}); err != nil {
+				return err
+			}
+			records++
+		}
+	}
+	if records == 0 {
+		return nil
+	}
+	if err := encodeJournalRecord(&buf, journalRecord{Op: journalOpCommit}); err != nil {
+		return err
+	}
+
+	if err := j.append(buf.Bytes()); err != nil {
+		return err
+	}
+	j.records += records
+	j.entries = entries
+	j.reservations = reservations
+	return nil
+}
+
+// CheckpointDelta implements the DeltaCheckpointer interface. It returns ErrFullCheckpointRequired until the
+// journal holds a snapshot, and when the journal is due for compaction.
+func (j *Journal) CheckpointDelta(delta CheckpointDelta) error {
+	if j.entries == nil || j.records >= j.compactThreshold {
+		return ErrFullCheckpointRequired
+	}
+
+	var buf bytes.Buffer
+	records := 0
+	write := func(record journalRecord) error {
+		records++
+		return encodeJournalRecord(&buf, record)
+	}
+	for i := range delta.Unassigned {
+		if err := write(journalRecord{Op: journalOpUnassign, Entry: &delta.Unassigned[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range delta.Assigned {
+		if err := write(journalRecord{Op: journalOpAssign, Entry: &delta.Assigned[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range delta.Released {
+		if err := write(journalRecord{Op: journalOpRelease, Reservation: &delta.Released[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range delta.Reserved {
+		if err := write(journalRecord{Op: journalOpReserve, Reservation: &delta.Reserved[i]}); err != nil {
+			return err
+		}
+	}
+	if records == 0 {
+		return nil
+	}
+	if err := encodeJournalRecord(&buf, journalRecord{Op: journalOpCommit}); err != nil {
+		return err
+	}
+
+	if err := j.append(buf.Bytes()); err != nil {
+		return err
+	}
+	j.records += records
+	// Keep the checkpoint as of the last record up to date for the next Checkpoint
+	for _, entry := range delta.Unassigned {
+		delete(j.entries, checkpointEntryAddress(entry))
+	}
+	for _, entry := range delta.Assigned {
+		j.entries[checkpointEntryAddress(entry)] = entry
+	}
+	for _, reservation := range delta.Released {
+		delete(j.reservations, checkpointReservationAddress(reservation))
+	}
+	for _, reservation := range delta.Reserved {
+		j.reservations[checkpointReservationAddress(reservation)] = reservation
+	}
+	return nil
+}
+
+// append writes the records to the end of the journal and syncs them to disk. On failure the journal is truncated
+// back to its last complete record, or compacted on the next checkpoint if even that fails.
+func (j *Journal) append(buf []byte) error {
+	_, err := j.file.Write(buf)
+	if err == nil {
+		err = j.file.Sync()
+	}
+	if err != nil {
+		if _, seekErr := j.file.Seek(j.size, io.SeekStart); seekErr != nil || j.file.Truncate(j.size) != nil {
+			j.log.Warnf("Failed to truncate journal %s after failed write, compacting on next checkpoint", j.path)
+			j.close()
+		}
+		return errors.Wrap(err, "failed to append to journal")
+	}
+	j.size += int64(len(buf))
+	return nil
+}
+
+// compact atomically replaces the journal with a single snapshot record and reopens it for appending
+func (j *Journal) compact(data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := encodeJournalRecord(&buf, journalRecord{Op: journalOpSnapshot, Data: raw}); err != nil {
+		return err
+	}
+
+	j.close()
+	f, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), j.path); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	// The temp file descriptor now refers to the journal, keep appending through it
+	j.file = f
+	j.size = int64(buf.Len())
+	j.records = 0
+	if checkpoint, ok := data.(*CheckpointData); ok {
+		if j.header, err = checkpointHeader(checkpoint); err != nil {
+			return err
+		}
+		j.entries = checkpointEntriesByAddress(checkpoint)
+		j.reservations = checkpointReservationsByAddress(checkpoint)
+	}
+	if j.checkpointPath != "" {
+		// The journal holds the checkpoint already, a stale JSON checkpoint only matters to a rollback
+		if err := NewJSONFile(j.checkpointPath).Checkpoint(json.RawMessage(raw)); err != nil {
+			j.log.Warnf("Failed to write checkpoint %s: %v", j.checkpointPath, err)
+		}
+	}
+	return nil
+}
+
+func (j *Journal) close() {
+	if j.file != nil {
+		j.file.Close()
+	}
+	j.file = nil
+	j.header = nil
+	j.entries = nil
+	j.reservations = nil
+}
+
+// Restore implements the Checkpointer interface. Until the journal is first written, the JSON checkpoint is restored.
+func (j *Journal) Restore(into interface{}) error {
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) && j.checkpointPath != "" {
+		f, err = os.Open(j.checkpointPath)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return restoreCheckpointFile(f, into, j.log)
+}
+
+// restoreCheckpointFile decodes a checkpoint written by either JSONFile or Journal, so that the checkpointer can
+// be switched without losing allocations
+func restoreCheckpointFile(f *os.File, into interface{}, log logger.Logger) error {
+	reader := bufio.NewReader(f)
+	if first, err := reader.Peek(1); err == nil && first[0] == '{' {
+		return json.NewDecoder(reader).Decode(into)
+	}
+
+	raw, err := replayJournal(reader, log)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, into)
+}
+
+// replayJournal returns the checkpoint recorded in the journal. A damaged last record, or records without a commit
+// record at the end of the journal, are the result of a write torn by a crash. Their checkpoint never completed, so
+// the records are logged and dropped. Damage anywhere else cannot be recovered from and returns ErrJournalCorrupted.
+func replayJournal(r io.Reader, log logger.Logger) (json.RawMessage, error) {
+	reader := bufio.NewReader(r)
+	var snapshot json.RawMessage
+	var checkpoint *CheckpointData
+	var entries map[string]CheckpointEntry
+	var reservations map[string]CheckpointReservation
+	// pending are the records of the checkpoint being replayed, applied once its commit record is read
+	var pending []journalRecord
+
+	for line := 1; ; line++ {
+		buf, readErr := reader.ReadBytes('\n')
+		if readErr == io.EOF && len(buf) == 0 {
+			break
+		}
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
+		}
+
+		record, err := decodeJournalRecord(buf)
+		if err != nil {
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				log.Warnf("Discarding torn write at the end of the journal, line %d: %v", line, err)
+				break
+			}
+			return nil, errors.Wrapf(ErrJournalCorrupted, "line %d: %v", line, err)
+		}
+
+		switch record.Op {
+		case journalOpSnapshot:
+			if len(pending) > 0 {
+				return nil, errors.Wrapf(ErrJournalCorrupted, "line %d: snapshot record before commit", line)
+			}
+			snapshot, checkpoint, entries, reservations = record.Data, nil, nil, nil
+		case journalOpAssign, journalOpUnassign, journalOpReserve, journalOpRelease:
+			if snapshot == nil {
+				return nil, errors.Wrapf(ErrJournalCorrupted, "line %d: %s record without snapshot", line, record.Op)
+			}
+			if record.Entry == nil && record.Reservation == nil {
+				return nil, errors.Wrapf(ErrJournalCorrupted, "line %d: empty %s record", line, record.Op)
+			}
+			pending = append(pending, record)
+		case journalOpCommit:
+			if checkpoint == nil && len(pending) > 0 {
+				checkpoint = &CheckpointData{}
+				if err := json.Unmarshal(snapshot, checkpoint); err != nil {
+					return nil, errors.Wrapf(ErrJournalCorrupted, "line %d: %v", line, err)
+				}
+				entries = checkpointEntriesByAddress(checkpoint)
+				reservations = checkpointReservationsByAddress(checkpoint)
+			}
+			for _, record := range pending {
+				switch {
+				case record.Op == journalOpAssign && record.Entry != nil:
+					entries[checkpointEntryAddress(*record.Entry)] = *record.Entry
+				case record.Op == journalOpUnassign && record.Entry != nil:
+					delete(entries, checkpointEntryAddress(*record.Entry))
+				case record.Op == journalOpReserve && record.Reservation != nil:
+					reservations[checkpointReservationAddress(*record.Reservation)] = *record.Reservation
+				case record.Op == journalOpRelease && record.Reservation != nil:
+					delete(reservations, checkpointReservationAddress(*record.Reservation))
+				default:
+					return nil, errors.Wrapf(ErrJournalCorrupted, "line %d: %s record without its payload", line, record.Op)
+				}
+			}
+			pending = nil
+		default:
+			return nil, errors.Wrapf(ErrJournalCorrupted, "line %d: unknown record %q", line, record.Op)
+		}
+	}
+
+	if snapshot == nil {
+		return nil, errors.Wrap(ErrJournalCorrupted, "no snapshot record")
+	}
+	if len(pending) > 0 {
+		log.Warnf("Discarding %d records of a checkpoint torn at the end of the journal", len(pending))
+	}
+	if checkpoint == nil {
+		return snapshot, nil
+	}
+	checkpoint.Allocations = make([]CheckpointEntry, 0, len(entries))
+	for _, entry := range entries {
+		checkpoint.Allocations = append(checkpoint.Allocations, entry)
+	}
+	checkpoint.Reservations = nil
+	for _, reservation := range reservations {
+		checkpoint.Reservations = append(checkpoint.Reservations, reservation)
+	}
+	return json.Marshal(checkpoint)
+}
+
+// encodeJournalRecord writes the record as a checksummed line
+func encodeJournalRecord(w io.Writer, record journalRecord) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%08x %s\n", crc32.Checksum(payload, journalCRC), payload)
+	return err
+}
+
+// decodeJournalRecord parses a checksummed line, including its newline
+func decodeJournalRecord(line []byte) (journalRecord, error) {
+	var record journalRecord
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		return record, errors.New("incomplete record")
+	}
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	if len(line) < 10 || line[8] != ' ' {
+		return record, errors.New("malformed record")
+	}
+	var checksum uint32
+	if _, err := fmt.Sscanf(string(line[:8]), "%08x", &checksum); err != nil {
+		return record, errors.New("malformed checksum")
+	}
+	payload := line[9:]
+	if crc32.Checksum(payload, journalCRC) != checksum {
+		return record, errors.New("checksum mismatch")
+	}
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return record, err
+	}
+	return record, nil
+}
+
+// checkpointEntryAddress returns the address an entry is keyed by, an address is assigned to a single sandbox
+func checkpointEntryAddress(entry CheckpointEntry) string {
+	return entry.IPv4 + "/" + entry.IPv6
+}
+
+// checkpointReservationAddress returns the address a reservation is keyed by
+func checkpointReservationAddress(reservation CheckpointReservation) string {
+	return reservation.IPv4 + "/" + reservation.IPv6
+}
+
+// checkpointHeader returns the checkpoint without its allocations and reservations
+func checkpointHeader(checkpoint *CheckpointData) ([]byte, error) {
+	header := *checkpoint
+	header.Allocations = nil
+	header.Reservations = nil
+	return json.Marshal(header)
+}
+
+func checkpointEntriesByAddress(checkpoint *CheckpointData) map[string]CheckpointEntry {
+	entries := make(map[string]CheckpointEntry, len(checkpoint.Allocations))
+	for _, entry := range checkpoint.Allocations {
+		entries[checkpointEntryAddress(entry)] = entry
+	}
+	return entries
+}
+
+func checkpointReservationsByAddress(checkpoint *CheckpointData) map[string]CheckpointReservation {
+	reservations := make(map[string]CheckpointReservation, len(checkpoint.Reservations))
+	for _, reservation := range checkpoint.Reservations {
+		reservations[checkpointReservationAddress(reservation)] = reservation
+	}
+	return reservations
+}
@@ -0,0 +1,410 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/logger"
+)
+
+func testCheckpointEntry(i int) CheckpointEntry {
+	return CheckpointEntry{
+		IPAMKey:             IPAMKey{NetworkName: "aws-cni", ContainerID: fmt.Sprintf("sandbox-%d", i), IfName: "eth0"},
+		IPv4:                fmt.Sprintf("10.0.%d.%d", i/256, i%256),
+		AllocationTimestamp: int64(1700000000000000000 + i),
+		Metadata:            IPAMMetadata{K8SPodNamespace: "default", K8SPodName: fmt.Sprintf("pod-%d", i)},
+	}
+}
+
+func testCheckpointData(n int) *CheckpointData {
+	data := &CheckpointData{Version: CheckpointFormatVersion, Allocations: []CheckpointEntry{}}
+	for i := 0; i < n; i++ {
+		data.Allocations = append(data.Allocations, testCheckpointEntry(i))
+	}
+	return data
+}
+
+func restoreJournal(t *testing.T, c Checkpointer) *CheckpointData {
+	var restored CheckpointData
+	assert.NoError(t, c.Restore(&restored))
+	return &restored
+}
+
+func TestJournalCheckpointRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipam.json")
+	journal := NewJournal(path, "", Testlog)
+
+	var restored CheckpointData
+	assert.True(t, os.IsNotExist(journal.Restore(&restored)))
+
+	data := testCheckpointData(3)
+	assert.NoError(t, journal.Checkpoint(data))
+	assert.Equal(t, 0, journal.records)
+
+	// Assign a new address and unassign another one
+	data = &CheckpointData{Version: CheckpointFormatVersion, Allocations: []CheckpointEntry{
+		testCheckpointEntry(0), testCheckpointEntry(2), testCheckpointEntry(3),
+	}}
+	assert.NoError(t, journal.Checkpoint(data))
+	assert.Equal(t, 2, journal.records)
+	// Unchanged checkpoints append nothing
+	assert.NoError(t, journal.Checkpoint(data))
+	assert.Equal(t, 2, journal.records)
+
+	assert.ElementsMatch(t, data.Allocations, restoreJournal(t, journal).Allocations)
+	// A restarted ipamd reads the same allocations
+	assert.ElementsMatch(t, data.Allocations, restoreJournal(t, NewJournal(path, "", Testlog)).Allocations)
+}
+
+func TestJournalCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipam.json")
+	journal := NewJournal(path, "", Testlog)
+	journal.compactThreshold = 5
+
+	data := testCheckpointData(0)
+	for i := 0; i < 12; i++ {
+		data.Allocations = append(data.Allocations, testCheckpointEntry(i))
+		assert.NoError(t, journal.Checkpoint(data))
+		assert.LessOrEqual(t, journal.records, journal.compactThreshold)
+	}
+	assert.ElementsMatch(t, data.Allocations, restoreJournal(t, NewJournal(path, "", Testlog)).Allocations)
+
+	// Checkpoints of other formats are written as snapshots
+	doc := checkpointDocument{"version": "vpc-cni-ipam/0", "allocations": []interface{}{}}
+	assert.NoError(t, journal.Checkpoint(doc))
+	var restored checkpointDocument
+	assert.NoError(t, NewJournal(path, "", Testlog).Restore(&restored))
+	assert.Equal(t, "vpc-cni-ipam/0", restored["version"])
+	assert.NoError(t, journal.Checkpoint(data))
+	assert.Equal(t, 0, journal.records)
+}
+
+func TestJournalCheckpointDelta(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipam.json")
+	journal := NewJournal(path, "", Testlog)
+	journal.compactThreshold = 4
+
+	// Changes need a snapshot to apply to
+	assert.ErrorIs(t, journal.CheckpointDelta(CheckpointDelta{Assigned: []CheckpointEntry{testCheckpointEntry(0)}}), ErrFullCheckpointRequired)
+	data := testCheckpointData(2)
+	assert.NoError(t, journal.Checkpoint(data))
+
+	// The IPv6 address of a dual-stack sandbox replaces its row
+	dualStack := testCheckpointEntry(1)
+	dualStack.IPv6 = "2001:db8::1"
+	assert.NoError(t, journal.CheckpointDelta(CheckpointDelta{
+		Assigned:   []CheckpointEntry{dualStack},
+		Unassigned: []CheckpointEntry{testCheckpointEntry(1)},
+	}))
+	assert.Equal(t, 2, journal.records)
+	reservation := CheckpointReservation{IPv4: "10.0.0.0", ExpiryTimestamp: 1700000000000000000,
+		Metadata: IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "pod-0"}}
+	assert.NoError(t, journal.CheckpointDelta(CheckpointDelta{
+		Unassigned: []CheckpointEntry{testCheckpointEntry(0)},
+		Reserved:   []CheckpointReservation{reservation},
+	}))
+	assert.Equal(t, 4, journal.records)
+
+	data = &CheckpointData{Version: CheckpointFormatVersion, Allocations: []CheckpointEntry{dualStack},
+		Reservations: []CheckpointReservation{reservation}}
+	restored := restoreJournal(t, NewJournal(path, "", Testlog))
+	assert.Equal(t, data.Allocations, restored.Allocations)
+	assert.Equal(t, data.Reservations, restored.Reservations)
+
+	// The journal is due for compaction, which takes the whole checkpoint
+	assert.ErrorIs(t, journal.CheckpointDelta(CheckpointDelta{Released: []CheckpointReservation{reservation}}), ErrFullCheckpointRequired)
+	assert.NoError(t, journal.Checkpoint(data))
+	assert.Equal(t, 0, journal.records)
+	assert.Equal(t, data, restoreJournal(t, NewJournal(path, "", Testlog)))
+}
+
+func TestJournalRestoreLegacyJSONFile(t *testing.T) {
+	dir := t.TempDir()
+	path, checkpointPath := filepath.Join(dir, "ipam.journal"), filepath.Join(dir, "ipam.json")
+	data := testCheckpointData(2)
+	assert.NoError(t, NewJSONFile(checkpointPath).Checkpoint(data))
+
+	// Enabling the journal carries on from the JSON checkpoint
+	journal := NewJournal(path, checkpointPath, Testlog)
+	assert.Equal(t, data, restoreJournal(t, journal))
+	assert.NoError(t, journal.Checkpoint(data))
+
+	// Compactions keep the JSON checkpoint readable by releases without the journal
+	data = &CheckpointData{Version: CheckpointFormatVersion, Allocations: []CheckpointEntry{testCheckpointEntry(0)}}
+	assert.NoError(t, journal.Checkpoint(data))
+	assert.Equal(t, testCheckpointData(2), restoreJSONFile(t, checkpointPath))
+	assert.Equal(t, data, restoreJournal(t, NewJournal(path, checkpointPath, Testlog)))
+
+	// Disabling the journal brings the JSON checkpoint up to date and removes the journal
+	assert.NoError(t, RemoveJournal(path, checkpointPath, Testlog))
+	assert.Equal(t, data, restoreJSONFile(t, checkpointPath))
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, RemoveJournal(path, checkpointPath, Testlog))
+	assert.Equal(t, data, restoreJSONFile(t, checkpointPath))
+}
+
+// restoreJSONFile decodes the JSON checkpoint at path, failing on anything but plain JSON
+func restoreJSONFile(t *testing.T, path string) *CheckpointData {
+	raw, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var restored CheckpointData
+	assert.NoError(t, json.Unmarshal(raw, &restored))
+	return &restored
+}
+
+func TestJournalTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipam.json")
+	journal := NewJournal(path, "", Testlog)
+	data := testCheckpointData(2)
+	assert.NoError(t, journal.Checkpoint(data))
+	data.Allocations = append(data.Allocations, testCheckpointEntry(2))
+	assert.NoError(t, journal.Checkpoint(data))
+	committed := append([]CheckpointEntry{}, data.Allocations...)
+	data.Allocations = append(data.Allocations, testCheckpointEntry(3))
+	assert.NoError(t, journal.Checkpoint(data))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	// A crash in the middle of the last append leaves a partial record behind
+	assert.NoError(t, os.WriteFile(path, content[:len(content)-10], 0644))
+	assert.ElementsMatch(t, committed, restoreJournal(t, NewJournal(path, "", Testlog)).Allocations)
+
+	// A complete last line with a bad checksum is torn as well
+	torn := append([]byte{}, content...)
+	torn[len(torn)-5] ^= 0xff
+	assert.NoError(t, os.WriteFile(path, torn, 0644))
+	assert.ElementsMatch(t, committed, restoreJournal(t, NewJournal(path, "", Testlog)).Allocations)
+
+	// The next checkpoint compacts the torn record away
+	restarted := NewJournal(path, "", Testlog)
+	assert.NoError(t, restarted.Checkpoint(data))
+	assert.ElementsMatch(t, data.Allocations, restoreJournal(t, NewJournal(path, "", Testlog)).Allocations)
+
+	// Damage before the last record cannot be recovered from
+	corrupted := append([]byte{}, content...)
+	corrupted[20] ^= 0xff
+	assert.NoError(t, os.WriteFile(path, corrupted, 0644))
+	var restored CheckpointData
+	err = NewJournal(path, "", Testlog).Restore(&restored)
+	assert.True(t, errors.Is(err, ErrJournalCorrupted))
+}
+
+func TestJournalTornCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipam.json")
+	journal := NewJournal(path, "", Testlog)
+	data := testCheckpointData(2)
+	assert.NoError(t, journal.Checkpoint(data))
+	committed := append([]CheckpointEntry{}, data.Allocations...)
+	// Unassign one address and assign two, written as three records and a commit record
+	data = &CheckpointData{Version: CheckpointFormatVersion, Allocations: []CheckpointEntry{
+		testCheckpointEntry(0), testCheckpointEntry(2), testCheckpointEntry(3),
+	}}
+	assert.NoError(t, journal.Checkpoint(data))
+	assert.Equal(t, 3, journal.records)
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	assert.Len(t, lines, 6)
+
+	// Complete records of a checkpoint whose commit record was never written are not replayed
+	for _, n := range []int{2, 3, 4} {
+		assert.NoError(t, os.WriteFile(path, bytes.Join(lines[:n], nil), 0644))
+		assert.ElementsMatch(t, committed, restoreJournal(t, NewJournal(path, "", Testlog)).Allocations)
+	}
+	assert.NoError(t, os.WriteFile(path, content, 0644))
+	assert.ElementsMatch(t, data.Allocations, restoreJournal(t, NewJournal(path, "", Testlog)).Allocations)
+}
+
+func TestJournalReservations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipam.json")
+	journal := NewJournal(path, "", Testlog)
+	data := testCheckpointData(2)
+	assert.NoError(t, journal.Checkpoint(data))
+
+	// Holding an address for a deleted pod is journaled, not compacted
+	reservation := CheckpointReservation{IPv4: "10.0.0.1", ExpiryTimestamp: 1700000000000000000,
+		Metadata: IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "pod-1"}}
+	data = &CheckpointData{Version: CheckpointFormatVersion, Allocations: []CheckpointEntry{testCheckpointEntry(0)},
+		Reservations: []CheckpointReservation{reservation}}
+	assert.NoError(t, journal.Checkpoint(data))
+	assert.Equal(t, 2, journal.records)
+	restored := restoreJournal(t, NewJournal(path, "", Testlog))
+	assert.ElementsMatch(t, data.Allocations, restored.Allocations)
+	assert.Equal(t, []CheckpointReservation{reservation}, restored.Reservations)
+
+	// So is the expiry of the reservation
+	data = &CheckpointData{Version: CheckpointFormatVersion, Allocations: []CheckpointEntry{testCheckpointEntry(0)}}
+	assert.NoError(t, journal.Checkpoint(data))
+	assert.Equal(t, 3, journal.records)
+	assert.Empty(t, restoreJournal(t, NewJournal(path, "", Testlog)).Reservations)
+}
+
+func TestJournalDataStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipam.json")
+	ds := NewDataStore(Testlog, NewJournal(path, "", Testlog), false)
+	assert.NoError(t, ds.AddENI("eni-1", 1, true, false, false))
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", *testIPv4Net(i), false))
+	}
+	for _, id := range []string{"sandbox-1", "sandbox-2", "sandbox-3"} {
+		_, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", id, "eth0"}, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: id})
+		assert.NoError(t, err)
+	}
+	_, _, _, _, err := ds.UnassignPodIPAddress(IPAMKey{"net0", "sandbox-2", "eth0"})
+	assert.NoError(t, err)
+
+	restored := restoreJournal(t, NewJournal(path, "", Testlog))
+	assert.Equal(t, formatCheckpointVersion(1), restored.Version)
+	var sandboxes []string
+	for _, entry := range restored.Allocations {
+		sandboxes = append(sandboxes, entry.ContainerID)
+	}
+	assert.ElementsMatch(t, []string{"sandbox-1", "sandbox-3"}, sandboxes)
+}
+
+func TestJournalDataStoreDelta(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipam.json")
+	journal := NewJournal(path, "", Testlog)
+	ds := NewDataStore(Testlog, journal, false)
+	ds.stickyIPGracePeriod = time.Minute
+	assert.NoError(t, ds.AddENI("eni-1", 0, true, false, false))
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", *testIPv4Net(i), false))
+	}
+	assert.NoError(t, ds.AddIPv6CidrToStore("eni-1", net.IPNet{IP: net.ParseIP("2001:db8::"), Mask: net.CIDRMask(80, 128)}, true))
+	metadata := func(name string) IPAMMetadata {
+		return IPAMMetadata{K8SPodNamespace: "default", K8SPodName: name, StickyIP: true}
+	}
+
+	// The first dual-stack sandbox and the first reservation take whole checkpoints in newer schema versions
+	for _, name := range []string{"pod-1", "pod-2"} {
+		_, _, _, err := ds.AssignPodIPAddress(IPAMKey{"net0", "sandbox-" + name, "eth0"}, metadata(name), true, true)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, journal.records)
+	_, _, _, _, err := ds.UnassignPodIPAddress(IPAMKey{"net0", "sandbox-pod-1", "eth0"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, journal.records)
+
+	// Later pod deletes and adds are journaled as changes
+	_, _, _, _, err = ds.UnassignPodIPAddress(IPAMKey{"net0", "sandbox-pod-2", "eth0"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, journal.records)
+	_, _, _, err = ds.AssignPodIPAddress(IPAMKey{"net0", "sandbox-pod-1-new", "eth0"}, metadata("pod-1"), true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, journal.records)
+
+	// A restarted ipamd reads the same checkpoint as if it had been written in full
+	full := NewTestCheckpoint(nil)
+	ds.backingStore = full
+	assert.NoError(t, ds.writeBackingStoreUnsafe())
+	expected := full.Data.(*CheckpointData)
+	restored := restoreJournal(t, NewJournal(path, "", Testlog))
+	assert.Equal(t, expected.Version, restored.Version)
+	assert.ElementsMatch(t, expected.Allocations, restored.Allocations)
+	assert.ElementsMatch(t, expected.Reservations, restored.Reservations)
+	assert.Len(t, restored.Reservations, 2)
+}
+
+func testIPv4Net(i int) *net.IPNet {
+	return &net.IPNet{IP: net.IPv4(10, 1, byte(i/256), byte(i%256)), Mask: net.CIDRMask(32, 32)}
+}
+
+// benchmarkCheckpointChurn measures the datastore assigns and unassigns, along with the checkpoints they write, with
+// n pods on the node
+func benchmarkCheckpointChurn(b *testing.B, n int, newCheckpointer func(path string) Checkpointer) {
+	log := logger.New(&logger.Configuration{LogLevel: "error", LogLocation: "stdout"})
+	ds := NewDataStore(log, newCheckpointer(filepath.Join(b.TempDir(), "ipam.json")), false)
+	ds.ipCooldownPeriod = 0
+	if err := ds.AddENI("eni-1", 0, true, false, false); err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i <= n; i++ {
+		if err := ds.AddIPv4CidrToStore("eni-1", *testIPv4Net(i), false); err != nil {
+			b.Fatal(err)
+		}
+	}
+	key := func(i int) IPAMKey { return IPAMKey{"net0", fmt.Sprintf("sandbox-%d", i), "eth0"} }
+	for i := 0; i < n; i++ {
+		if _, _, err := ds.AssignPodIPv4Address(key(i), IPAMMetadata{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Alternate between a pod going away and a new pod taking its place
+		if i%2 == 0 {
+			if _, _, _, _, err := ds.UnassignPodIPAddress(key(i / 2)); err != nil {
+				b.Fatal(err)
+			}
+		} else if _, _, err := ds.AssignPodIPv4Address(key(n+i/2), IPAMMetadata{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJSONFileCheckpoint(b *testing.B) {
+	for _, n := range []int{10, 250, 1000} {
+		b.Run(fmt.Sprintf("pods=%d", n), func(b *testing.B) {
+			benchmarkCheckpointChurn(b, n, func(path string) Checkpointer { return NewJSONFile(path) })
+		})
+	}
+}
+
+func BenchmarkJournalCheckpoint(b *testing.B) {
+	for _, n := range []int{10, 250, 1000} {
+		b.Run(fmt.Sprintf("pods=%d", n), func(b *testing.B) {
+			benchmarkCheckpointChurn(b, n, func(path string) Checkpointer { return NewJournal(path, "", Testlog) })
+		})
+	}
+}
+
+func BenchmarkJournalRestore(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "ipam.json")
+	journal := NewJournal(path, "", Testlog)
+	data := testCheckpointData(250)
+	if err := journal.Checkpoint(data); err != nil {
+		b.Fatal(err)
+	}
+	// Replay a journal with records appended since its snapshot
+	for i := 0; i < journal.compactThreshold-1; i++ {
+		data.Allocations[i%250] = testCheckpointEntry(250 + i)
+		if err := journal.Checkpoint(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var restored CheckpointData
+		if err := NewJournal(path, "", Testlog).Restore(&restored); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
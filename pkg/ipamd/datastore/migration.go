@@ -0,0 +1,325 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/logger"
+	"github.com/aws/amazon-vpc-cni-k8s/utils"
+)
+
+const (
+	// checkpointVersionPrefix prefixes the schema version in the version stamp of stored checkpoints
+	checkpointVersionPrefix = "vpc-cni-ipam/"
+
+	// CheckpointSchemaVersion is the schema version of CheckpointData. Bump it whenever the stored format changes,
+	// and register the migrations between the old and the new version.
+	CheckpointSchemaVersion = 3
+
+	// envCheckpointVersion pins the schema version checkpoints are written in. By default a checkpoint is written in
+	// the oldest schema version that can hold it, so that aws-node can be rolled back to a release that only knows
+	// an older schema without losing pod allocations.
+	envCheckpointVersion = "AWS_VPC_K8S_CNI_CHECKPOINT_VERSION"
+)
+
+// ErrCheckpointVersionTooNew is returned when the checkpoint was written by a newer release with a schema this
+// release does not know
+var ErrCheckpointVersionTooNew = errors.New("datastore: checkpoint was written with a newer schema version")
+
+// checkpointDocument is the schema agnostic form of a stored checkpoint that migrations operate on.
+// Numbers are kept as json.Number so that timestamps survive a round trip unchanged.
+type checkpointDocument map[string]interface{}
+
+// checkpointMigration converts a checkpoint between schema version N and N+1
+type checkpointMigration struct {
+	// upgrade converts a version N document into version N+1
+	upgrade func(checkpointDocument) error
+	// downgrade converts a version N+1 document into version N. It must keep every pod allocation.
+	downgrade func(checkpointDocument) error
+	// inUse reports whether the checkpoint holds data that only version N+1 can store. A nil inUse means that
+	// version N+1 changed the format, so the checkpoint can never be written in version N without a downgrade.
+	inUse func(*CheckpointData) bool
+}
+
+// checkpointMigrations holds the registered migrations, keyed by the version they upgrade from
+var checkpointMigrations = map[int]checkpointMigration{}
+
+// registerCheckpointMigration registers the migrations between schema version from and from+1
+func registerCheckpointMigration(from int, upgrade, downgrade func(checkpointDocument) error, inUse func(*CheckpointData) bool) {
+	if _, ok := checkpointMigrations[from]; ok {
+		panic(fmt.Sprintf("checkpoint migration from version %d is already registered", from))
+	}
+	checkpointMigrations[from] = checkpointMigration{upgrade: upgrade, downgrade: downgrade, inUse: inUse}
+}
+
+func init() {
+	// Version 2 stores both addresses of a dual-stack sandbox in a single allocation
+	registerCheckpointMigration(1, mergeDualStackAllocations, splitDualStackAllocations, hasDualStackAllocations)
+	// Version 3 stores the addresses held for deleted sticky IP pods
+	registerCheckpointMigration(2, addReservations, dropReservations, hasReservations)
+}
+
+// hasDualStackAllocations reports whether a sandbox holds both an IPv4 and an IPv6 address
+func hasDualStackAllocations(data *CheckpointData) bool {
+	for _, allocation := range data.Allocations {
+		if allocation.IPv4 != "" && allocation.IPv6 != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasReservations reports whether addresses are held for deleted sticky IP pods
+func hasReservations(data *CheckpointData) bool {
+	return len(data.Reservations) > 0
+}
+
+// addReservations upgrades a checkpoint without reservations, there is nothing to convert
+func addReservations(doc checkpointDocument) error {
+	return nil
+}
+
+// dropReservations removes the reservations, the addresses they held are free to be assigned again
+func dropReservations(doc checkpointDocument) error {
+	delete(doc, "reservations")
+	return nil
+}
+
+// mergeDualStackAllocations merges the IPv4 and the IPv6 allocation of a sandbox into a single one
+func mergeDualStackAllocations(doc checkpointDocument) error {
+	allocations, ok := doc["allocations"].([]interface{})
+	if !ok {
+		return nil
+	}
+	merged := make([]interface{}, 0, len(allocations))
+	rows := make(map[string]map[string]interface{}, len(allocations))
+	for _, item := range allocations {
+		allocation, ok := item.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("unexpected allocation %v", item)
+		}
+		key := fmt.Sprintf("%v/%v/%v", allocation["networkName"], allocation["containerID"], allocation["ifName"])
+		row, ok := rows[key]
+		if !ok {
+			rows[key] = allocation
+			merged = append(merged, allocation)
+			continue
+		}
+		for _, family := range []string{"ipv4", "ipv6"} {
+			if address, ok := allocation[family]; ok {
+				if _, taken := row[family]; taken {
+					return errors.Errorf("sandbox %s holds more than one %s address", key, family)
+				}
+				row[family] = address
+			}
+		}
+	}
+	doc["allocations"] = merged
+	return nil
+}
+
+// splitDualStackAllocations splits the allocation of a dual-stack sandbox into an IPv4 and an IPv6 one
+func splitDualStackAllocations(doc checkpointDocument) error {
+	allocations, ok := doc["allocations"].([]interface{})
+	if !ok {
+		return nil
+	}
+	split := make([]interface{}, 0, len(allocations))
+	for _, item := range allocations {
+		allocation, ok := item.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("unexpected allocation %v", item)
+		}
+		_, hasIPv4 := allocation["ipv4"]
+		_, hasIPv6 := allocation["ipv6"]
+		if !hasIPv4 || !hasIPv6 {
+			split = append(split, allocation)
+			continue
+		}
+		ipv6Allocation := make(map[string]interface{}, len(allocation))
+		for field, value := range allocation {
+			ipv6Allocation[field] = value
+		}
+		delete(allocation, "ipv6")
+		delete(ipv6Allocation, "ipv4")
+		split = append(split, allocation, ipv6Allocation)
+	}
+	doc["allocations"] = split
+	return nil
+}
+
+// formatCheckpointVersion returns the version stamp of a schema version
+func formatCheckpointVersion(version int) string {
+	return checkpointVersionPrefix + strconv.Itoa(version)
+}
+
+// parseCheckpointVersion returns the schema version of a version stamp
+func parseCheckpointVersion(stamp string) (int, error) {
+	if !strings.HasPrefix(stamp, checkpointVersionPrefix) {
+		return 0, errors.Errorf("unexpected checkpoint version %q", stamp)
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(stamp, checkpointVersionPrefix))
+	if err != nil || version < 1 {
+		return 0, errors.Errorf("unexpected checkpoint version %q", stamp)
+	}
+	return version, nil
+}
+
+// migrateCheckpoint converts the document from one schema version to another, one version at a time
+func migrateCheckpoint(doc checkpointDocument, from, to int) error {
+	for version := from; version < to; version++ {
+		migration, ok := checkpointMigrations[version]
+		if !ok || migration.upgrade == nil {
+			return errors.Errorf("no checkpoint migration registered from version %d to %d", version, version+1)
+		}
+		if err := migration.upgrade(doc); err != nil {
+			return errors.Wrapf(err, "failed to upgrade checkpoint from version %d to %d", version, version+1)
+		}
+	}
+	for version := from; version > to; version-- {
+		migration, ok := checkpointMigrations[version-1]
+		if !ok || migration.downgrade == nil {
+			return errors.Errorf("no checkpoint migration registered from version %d to %d", version, version-1)
+		}
+		if err := migration.downgrade(doc); err != nil {
+			return errors.Wrapf(err, "failed to downgrade checkpoint from version %d to %d", version, version-1)
+		}
+	}
+	doc["version"] = formatCheckpointVersion(to)
+	return nil
+}
+
+// decodeCheckpoint parses a stored checkpoint of any known schema version into the current CheckpointData.
+// Checkpoints written by a newer release are refused with ErrCheckpointVersionTooNew.
+func decodeCheckpoint(raw []byte) (CheckpointData, error) {
+	var data CheckpointData
+	doc, err := unmarshalCheckpointDocument(raw)
+	if err != nil {
+		return data, err
+	}
+
+	stamp, _ := doc["version"].(string)
+	version, err := parseCheckpointVersion(stamp)
+	if err != nil {
+		return data, err
+	}
+	if version > CheckpointSchemaVersion {
+		return data, errors.Wrapf(ErrCheckpointVersionTooNew, "checkpoint version %q is newer than %q, "+
+			"roll aws-node forward or have the newer release write version %d with %s",
+			stamp, CheckpointFormatVersion, CheckpointSchemaVersion, envCheckpointVersion)
+	}
+	if version < CheckpointSchemaVersion {
+		if err := migrateCheckpoint(doc, version, CheckpointSchemaVersion); err != nil {
+			return data, err
+		}
+		raw, err = json.Marshal(doc)
+		if err != nil {
+			return data, err
+		}
+	}
+
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return data, errors.Wrap(err, "failed to parse checkpoint")
+	}
+	return data, nil
+}
+
+// oldestCheckpointVersion returns the oldest schema version that can hold the checkpoint without a downgrade
+func oldestCheckpointVersion(data *CheckpointData) int {
+	version := CheckpointSchemaVersion
+	for ; version > 1; version-- {
+		migration, ok := checkpointMigrations[version-1]
+		if !ok || migration.inUse == nil || migration.inUse(data) {
+			break
+		}
+	}
+	return version
+}
+
+// encodeCheckpoint converts the current CheckpointData into the given schema version for storing
+func encodeCheckpoint(data *CheckpointData, version int) (interface{}, error) {
+	if version == CheckpointSchemaVersion {
+		return data, nil
+	}
+	// The checkpoint already fits the older schema, only its version stamp changes
+	if version >= oldestCheckpointVersion(data) {
+		stored := *data
+		stored.Version = formatCheckpointVersion(version)
+		return &stored, nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := unmarshalCheckpointDocument(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateCheckpoint(doc, CheckpointSchemaVersion, version); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func unmarshalCheckpointDocument(raw []byte) (checkpointDocument, error) {
+	var doc checkpointDocument
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, errors.Wrap(err, "failed to parse checkpoint")
+	}
+	if doc == nil {
+		return nil, errors.New("failed to parse checkpoint: empty document")
+	}
+	return doc, nil
+}
+
+// getCheckpointWriteVersion returns the schema version configured by the AWS_VPC_K8S_CNI_CHECKPOINT_VERSION env
+// variable. It returns 0 when the variable is not set or the configured version cannot be written, in which case
+// every checkpoint is written in the oldest schema version that can hold it.
+func getCheckpointWriteVersion(log logger.Logger) int {
+	version, err, input := utils.GetIntFromStringEnvVar(envCheckpointVersion, 0)
+	if err != nil {
+		log.Warnf("Invalid %s %q, writing checkpoints in the oldest version that can hold them", envCheckpointVersion, input)
+		return 0
+	}
+	if version == 0 {
+		return 0
+	}
+	if err := validateCheckpointWriteVersion(version); err != nil {
+		log.Warnf("Cannot write checkpoint version %d: %v, writing checkpoints in the oldest version that can hold them", version, err)
+		return 0
+	}
+	return version
+}
+
+// validateCheckpointWriteVersion checks that the current schema can be downgraded to the version
+func validateCheckpointWriteVersion(version int) error {
+	if version < 1 || version > CheckpointSchemaVersion {
+		return errors.Errorf("supported checkpoint versions are 1 to %d", CheckpointSchemaVersion)
+	}
+	for v := CheckpointSchemaVersion; v > version; v-- {
+		if migration, ok := checkpointMigrations[v-1]; !ok || migration.downgrade == nil {
+			return errors.Errorf("no checkpoint migration registered from version %d to %d", v, v-1)
+		}
+	}
+	return nil
+}
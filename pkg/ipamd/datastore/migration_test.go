@@ -0,0 +1,249 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointFormatVersion(t *testing.T) {
+	assert.Equal(t, CheckpointFormatVersion, formatCheckpointVersion(CheckpointSchemaVersion))
+	version, err := parseCheckpointVersion(CheckpointFormatVersion)
+	assert.NoError(t, err)
+	assert.Equal(t, CheckpointSchemaVersion, version)
+	// The current version is writable without any migration
+	assert.NoError(t, validateCheckpointWriteVersion(CheckpointSchemaVersion))
+}
+
+func TestParseCheckpointVersion(t *testing.T) {
+	tests := []struct {
+		stamp   string
+		want    int
+		wantErr bool
+	}{
+		{stamp: "vpc-cni-ipam/1", want: 1},
+		{stamp: "vpc-cni-ipam/12", want: 12},
+		{stamp: "", wantErr: true},
+		{stamp: "vpc-cni-ipam/", wantErr: true},
+		{stamp: "vpc-cni-ipam/0", wantErr: true},
+		{stamp: "vpc-cni-ipam/v2", wantErr: true},
+		{stamp: "other/1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stamp, func(t *testing.T) {
+			got, err := parseCheckpointVersion(tt.stamp)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// withTestMigrations registers migrations up to version 3 for the duration of the test. Version 2 renames
+// "allocations" to "entries" and version 3 moves the version stamp into a "meta" object.
+func withTestMigrations(t *testing.T) {
+	saved := checkpointMigrations
+	checkpointMigrations = map[int]checkpointMigration{}
+	t.Cleanup(func() { checkpointMigrations = saved })
+
+	registerCheckpointMigration(1,
+		func(doc checkpointDocument) error {
+			doc["entries"] = doc["allocations"]
+			delete(doc, "allocations")
+			return nil
+		},
+		func(doc checkpointDocument) error {
+			doc["allocations"] = doc["entries"]
+			delete(doc, "entries")
+			return nil
+		}, nil)
+	registerCheckpointMigration(2,
+		func(doc checkpointDocument) error {
+			doc["meta"] = map[string]interface{}{"schema": 3}
+			return nil
+		},
+		func(doc checkpointDocument) error {
+			if _, ok := doc["meta"]; !ok {
+				return errors.New("missing meta")
+			}
+			delete(doc, "meta")
+			return nil
+		}, nil)
+}
+
+func TestMigrateCheckpoint(t *testing.T) {
+	withTestMigrations(t)
+
+	raw := []byte(`{"version":"vpc-cni-ipam/1","allocations":[{"networkName":"net0","containerID":"sandbox-1","ifName":"eth0",` +
+		`"ipv4":"1.1.1.1","allocationTimestamp":1700000000123456789,"metadata":{"k8sPodNamespace":"default","k8sPodName":"pod-1"}}]}`)
+	doc, err := unmarshalCheckpointDocument(raw)
+	assert.NoError(t, err)
+
+	assert.NoError(t, migrateCheckpoint(doc, 1, 3))
+	assert.Equal(t, "vpc-cni-ipam/3", doc["version"])
+	assert.NotContains(t, doc, "allocations")
+	assert.Contains(t, doc, "entries")
+	assert.Contains(t, doc, "meta")
+
+	// Downgrading back must restore the original document, including the exact timestamp
+	assert.NoError(t, migrateCheckpoint(doc, 3, 1))
+	migrated, err := json.Marshal(doc)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(raw), string(migrated))
+
+	// A failing migration is reported
+	doc, err = unmarshalCheckpointDocument([]byte(`{"version":"vpc-cni-ipam/3"}`))
+	assert.NoError(t, err)
+	assert.Error(t, migrateCheckpoint(doc, 3, 1))
+
+	// Versions without registered migrations cannot be reached
+	assert.Error(t, migrateCheckpoint(doc, 3, 4))
+	assert.NoError(t, validateCheckpointWriteVersion(CheckpointSchemaVersion))
+	assert.Error(t, validateCheckpointWriteVersion(0))
+	assert.Error(t, validateCheckpointWriteVersion(CheckpointSchemaVersion+1))
+}
+
+func TestDualStackCheckpointMigration(t *testing.T) {
+	raw := []byte(`{"version":"vpc-cni-ipam/1","allocations":[` +
+		`{"networkName":"net0","containerID":"sandbox-1","ifName":"eth0","ipv4":"1.1.1.1","allocationTimestamp":1,"metadata":{"k8sPodNamespace":"default","k8sPodName":"pod-1"}},` +
+		`{"networkName":"net0","containerID":"sandbox-2","ifName":"eth0","ipv4":"1.1.1.2","allocationTimestamp":2,"metadata":{"k8sPodNamespace":"default","k8sPodName":"pod-2"}},` +
+		`{"networkName":"net0","containerID":"sandbox-1","ifName":"eth0","ipv6":"2001:db8::1","allocationTimestamp":1,"metadata":{"k8sPodNamespace":"default","k8sPodName":"pod-1"}}]}`)
+
+	// Version 1 checkpoints hold a row per address, version 2 a row per sandbox
+	data, err := decodeCheckpoint(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, []CheckpointEntry{
+		{IPAMKey: IPAMKey{"net0", "sandbox-1", "eth0"}, IPv4: "1.1.1.1", IPv6: "2001:db8::1", AllocationTimestamp: 1,
+			Metadata: IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "pod-1"}},
+		{IPAMKey: IPAMKey{"net0", "sandbox-2", "eth0"}, IPv4: "1.1.1.2", AllocationTimestamp: 2,
+			Metadata: IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "pod-2"}},
+	}, data.Allocations)
+
+	// Writing version 1 for a rollback splits the dual-stack row again
+	encoded, err := encodeCheckpoint(&data, 1)
+	assert.NoError(t, err)
+	downgraded, err := json.Marshal(encoded)
+	assert.NoError(t, err)
+	rolledBack, err := unmarshalCheckpointDocument(downgraded)
+	assert.NoError(t, err)
+	assert.Equal(t, "vpc-cni-ipam/1", rolledBack["version"])
+	assert.Len(t, rolledBack["allocations"], 3)
+	for _, item := range rolledBack["allocations"].([]interface{}) {
+		allocation := item.(map[string]interface{})
+		_, hasIPv4 := allocation["ipv4"]
+		_, hasIPv6 := allocation["ipv6"]
+		assert.NotEqual(t, hasIPv4, hasIPv6)
+	}
+
+	// A sandbox cannot hold two addresses of the same family
+	doc, err := unmarshalCheckpointDocument([]byte(`{"version":"vpc-cni-ipam/1","allocations":[` +
+		`{"networkName":"net0","containerID":"sandbox-1","ifName":"eth0","ipv4":"1.1.1.1"},` +
+		`{"networkName":"net0","containerID":"sandbox-1","ifName":"eth0","ipv4":"1.1.1.2"}]}`))
+	assert.NoError(t, err)
+	assert.Error(t, migrateCheckpoint(doc, 1, 2))
+}
+
+func TestReservationsCheckpointMigration(t *testing.T) {
+	raw := []byte(`{"version":"vpc-cni-ipam/2","allocations":[],"reservations":[` +
+		`{"ipv4":"1.1.1.1","metadata":{"k8sPodNamespace":"default","k8sPodName":"pod-1"},"expiryTimestamp":1}]}`)
+	data, err := decodeCheckpoint(raw)
+	assert.NoError(t, err)
+	assert.Len(t, data.Reservations, 1)
+
+	// Releases without reservations free the held addresses
+	encoded, err := encodeCheckpoint(&data, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "vpc-cni-ipam/2", encoded.(checkpointDocument)["version"])
+	assert.NotContains(t, encoded.(checkpointDocument), "reservations")
+}
+
+func TestReadBackingStoreRefusesNewerVersion(t *testing.T) {
+	checkpoint := NewTestCheckpoint(checkpointDocument{
+		"version":     formatCheckpointVersion(CheckpointSchemaVersion + 1),
+		"allocations": []interface{}{},
+	})
+	ds := NewDataStore(Testlog, checkpoint, false)
+
+	err := ds.ReadBackingStore()
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrCheckpointVersionTooNew))
+	// The checkpoint written by the newer release must be left alone
+	assert.Equal(t, formatCheckpointVersion(CheckpointSchemaVersion+1), checkpoint.Data.(checkpointDocument)["version"])
+}
+
+func TestReadBackingStoreRefusesUnknownVersion(t *testing.T) {
+	for _, data := range []interface{}{
+		struct{}{},
+		checkpointDocument{"version": "unknown/1"},
+	} {
+		ds := NewDataStore(Testlog, NewTestCheckpoint(data), false)
+		err := ds.ReadBackingStore()
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, ErrCheckpointVersionTooNew))
+	}
+}
+
+func TestGetCheckpointWriteVersion(t *testing.T) {
+	defer os.Unsetenv(envCheckpointVersion)
+
+	assert.Equal(t, 0, getCheckpointWriteVersion(Testlog))
+
+	os.Setenv(envCheckpointVersion, "not-a-number")
+	assert.Equal(t, 0, getCheckpointWriteVersion(Testlog))
+
+	os.Setenv(envCheckpointVersion, "0")
+	assert.Equal(t, 0, getCheckpointWriteVersion(Testlog))
+
+	os.Setenv(envCheckpointVersion, strconv.Itoa(CheckpointSchemaVersion+1))
+	assert.Equal(t, 0, getCheckpointWriteVersion(Testlog))
+
+	withTestMigrations(t)
+	os.Setenv(envCheckpointVersion, "1")
+	assert.Equal(t, 1, getCheckpointWriteVersion(Testlog))
+}
+
+func TestOldestCheckpointVersion(t *testing.T) {
+	data := &CheckpointData{Version: CheckpointFormatVersion, Allocations: []CheckpointEntry{
+		{IPAMKey: IPAMKey{"net0", "sandbox-1", "eth0"}, IPv4: "1.1.1.1"},
+		{IPAMKey: IPAMKey{"net0", "sandbox-2", "eth0"}, IPv6: "2001:db8::1"},
+	}}
+	assert.Equal(t, 1, oldestCheckpointVersion(data))
+
+	// A checkpoint that fits the older schema only gets its version stamp changed
+	encoded, err := encodeCheckpoint(data, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, formatCheckpointVersion(1), encoded.(*CheckpointData).Version)
+	assert.Equal(t, data.Allocations, encoded.(*CheckpointData).Allocations)
+	assert.Equal(t, CheckpointFormatVersion, data.Version)
+
+	data.Allocations[0].IPv6 = "2001:db8::2"
+	assert.Equal(t, 2, oldestCheckpointVersion(data))
+	data.Reservations = []CheckpointReservation{{IPv4: "1.1.1.2"}}
+	assert.Equal(t, 3, oldestCheckpointVersion(data))
+	data.Allocations[0].IPv6 = ""
+	assert.Equal(t, 3, oldestCheckpointVersion(data))
+
+	// Migrations without inUse changed the format, so nothing older than the current version can be written
+	withTestMigrations(t)
+	assert.Equal(t, CheckpointSchemaVersion, oldestCheckpointVersion(&CheckpointData{}))
+}
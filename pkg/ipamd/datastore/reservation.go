@@ -0,0 +1,172 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"net"
+	"time"
+
+	"github.com/aws/amazon-vpc-cni-k8s/utils"
+)
+
+// envStickyIPGracePeriod (default 0, disabled) specifies the number of seconds the address of a deleted pod that opted
+// into sticky IPs is held for a pod with the same namespace and name
+const envStickyIPGracePeriod = "STICKY_IP_GRACE_PERIOD"
+
+// CheckpointReservation is an address held for a deleted pod, as stored in checkpoints
+type CheckpointReservation struct {
+	IPv4            string       `json:"ipv4,omitempty"`
+	IPv6            string       `json:"ipv6,omitempty"`
+	Metadata        IPAMMetadata `json:"metadata"`
+	ExpiryTimestamp int64        `json:"expiryTimestamp"`
+}
+
+// getStickyIPGracePeriod returns the time duration configured by the STICKY_IP_GRACE_PERIOD env variable
+func getStickyIPGracePeriod() time.Duration {
+	graceVal, err, _ := utils.GetIntFromStringEnvVar(envStickyIPGracePeriod, 0)
+	if err != nil || graceVal < 0 {
+		return 0
+	}
+	return time.Duration(graceVal) * time.Second
+}
+
+// StickyIPEnabled returns true if addresses of deleted pods that opted into sticky IPs are held for them
+func (ds *DataStore) StickyIPEnabled() bool {
+	return ds.stickyIPGracePeriod > 0
+}
+
+// reserved returns true if the address is held for a deleted pod
+func (addr AddressInfo) reserved(now time.Time) bool {
+	return !addr.Assigned() && addr.ReservedFor != (IPAMMetadata{}) && now.Before(addr.ReservedUntil)
+}
+
+// reservedFor returns true if the address is held for the pod
+func (addr AddressInfo) reservedFor(now time.Time, ipamMetadata IPAMMetadata) bool {
+	return addr.reserved(now) &&
+		addr.ReservedFor.K8SPodNamespace == ipamMetadata.K8SPodNamespace &&
+		addr.ReservedFor.K8SPodName == ipamMetadata.K8SPodName
+}
+
+// reserveAddressUnsafe holds an address just released by a sticky pod for a pod with the same identity
+func (ds *DataStore) reserveAddressUnsafe(addr *AddressInfo, ipamMetadata IPAMMetadata, now time.Time) {
+	if !ipamMetadata.StickyIP || !ds.StickyIPEnabled() || ipamMetadata.K8SPodName == "" {
+		return
+	}
+	addr.ReservedFor = IPAMMetadata{K8SPodNamespace: ipamMetadata.K8SPodNamespace, K8SPodName: ipamMetadata.K8SPodName}
+	addr.ReservedUntil = now.Add(ds.stickyIPGracePeriod)
+	ds.log.Infof("Reserving IP %s for pod %s/%s until %s", addr.Address,
+		ipamMetadata.K8SPodNamespace, ipamMetadata.K8SPodName, addr.ReservedUntil.Format(time.RFC3339))
+}
+
+// findReservationUnsafe returns the address held for the pod in the given CIDRs, if any
+func (ds *DataStore) findReservationUnsafe(ipamMetadata IPAMMetadata, cidrs func(*ENI) map[string]*CidrInfo) (IPCandidate, bool) {
+	if !ipamMetadata.StickyIP || ipamMetadata.K8SPodName == "" {
+		return IPCandidate{}, false
+	}
+	now := ds.clock.Now()
+	for _, eni := range ds.eniPool {
+		if eni.Pool != ipamMetadata.ENIPool {
+			continue
+		}
+		for _, cidr := range cidrs(eni) {
+			for _, addr := range cidr.IPAddresses {
+				if addr.reservedFor(now, ipamMetadata) {
+					ds.log.Infof("Found IP %s reserved for pod %s/%s", addr.Address, ipamMetadata.K8SPodNamespace, ipamMetadata.K8SPodName)
+					return IPCandidate{ENI: eni, Cidr: cidr, Address: addr.Address, UnassignedTime: addr.UnassignedTime}, true
+				}
+			}
+		}
+	}
+	return IPCandidate{}, false
+}
+
+// reservationsUnsafe returns the current reservations for the checkpoint
+func (ds *DataStore) reservationsUnsafe() []CheckpointReservation {
+	var reservations []CheckpointReservation
+	now := ds.clock.Now()
+	for _, eni := range ds.eniPool {
+		for _, cidrs := range []map[string]*CidrInfo{eni.AvailableIPv4Cidrs, eni.IPv6Cidrs} {
+			for _, cidr := range cidrs {
+				for _, addr := range cidr.IPAddresses {
+					if addr.reserved(now) {
+						reservations = append(reservations, newCheckpointReservation(addr, cidr.AddressFamily == "6"))
+					}
+				}
+			}
+		}
+	}
+	return reservations
+}
+
+// newCheckpointReservation returns the reservation of the address for the checkpoint
+func newCheckpointReservation(addr *AddressInfo, isIPv6 bool) CheckpointReservation {
+	reservation := CheckpointReservation{
+		Metadata:        addr.ReservedFor,
+		ExpiryTimestamp: addr.ReservedUntil.UnixNano(),
+	}
+	if isIPv6 {
+		reservation.IPv6 = addr.Address
+	} else {
+		reservation.IPv4 = addr.Address
+	}
+	return reservation
+}
+
+// restoreReservationsUnsafe holds the addresses of the checkpointed reservations that have not expired yet
+func (ds *DataStore) restoreReservationsUnsafe(reservations []CheckpointReservation) {
+	now := ds.clock.Now()
+	for _, reservation := range reservations {
+		reservedUntil := time.Unix(0, reservation.ExpiryTimestamp)
+		if !now.Before(reservedUntil) {
+			continue
+		}
+		for _, ipAddr := range []net.IP{net.ParseIP(reservation.IPv4), net.ParseIP(reservation.IPv6)} {
+			if ipAddr != nil {
+				ds.restoreReservationUnsafe(reservation, ipAddr, reservedUntil)
+			}
+		}
+	}
+}
+
+// restoreReservationUnsafe holds the address of a checkpointed reservation for its pod
+func (ds *DataStore) restoreReservationUnsafe(reservation CheckpointReservation, ipAddr net.IP, reservedUntil time.Time) {
+	found := false
+	for _, eni := range ds.eniPool {
+		cidrs := eni.AvailableIPv4Cidrs
+		if ipAddr.To4() == nil {
+			cidrs = eni.IPv6Cidrs
+		}
+		for _, cidr := range cidrs {
+			if !cidr.Cidr.Contains(ipAddr) {
+				continue
+			}
+			found = true
+			addr, ok := cidr.IPAddresses[ipAddr.String()]
+			if !ok {
+				addr = &AddressInfo{Address: ipAddr.String()}
+				cidr.IPAddresses[ipAddr.String()] = addr
+			}
+			if !addr.Assigned() {
+				addr.ReservedFor = reservation.Metadata
+				addr.ReservedUntil = reservedUntil
+				ds.log.Debugf("Recovered reservation of %s for pod %s/%s", addr.Address,
+					reservation.Metadata.K8SPodNamespace, reservation.Metadata.K8SPodName)
+			}
+		}
+	}
+	if !found {
+		ds.log.Infof("datastore: Reservation for pod %s/%s uses unknown IP Address %s - dropping it",
+			reservation.Metadata.K8SPodNamespace, reservation.Metadata.K8SPodName, ipAddr.String())
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newStickyTestDataStore(t *testing.T, checkpoint Checkpointer) *DataStore {
+	t.Setenv(envStickyIPGracePeriod, "300")
+	ds := NewDataStore(Testlog, checkpoint, false)
+	assert.True(t, ds.StickyIPEnabled())
+	assert.NoError(t, ds.AddENI("eni-1", 1, true, false, false))
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(32, 32)}, false))
+	}
+	return ds
+}
+
+func TestStickyIPReservation(t *testing.T) {
+	ds := newStickyTestDataStore(t, NullCheckpoint{})
+	ds.ipCooldownPeriod = 0
+	sticky := IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "web-0", StickyIP: true}
+
+	ip, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-1", "eth0"}, sticky)
+	assert.NoError(t, err)
+	_, _, _, _, err = ds.UnassignPodIPAddress(IPAMKey{"net0", "sandbox-1", "eth0"})
+	assert.NoError(t, err)
+
+	// The address is held for web-0, other pods get other addresses
+	stats := ds.GetIPStats("4")
+	assert.Equal(t, 1, stats.ReservedIPs)
+	assert.Equal(t, 2, stats.AvailableAddresses())
+	for _, cidr := range ds.FindFreeableCidrs("eni-1") {
+		assert.NotEqual(t, ip, cidr.Cidr.IP.String())
+	}
+	for _, sandbox := range []string{"sandbox-2", "sandbox-3"} {
+		other, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", sandbox, "eth0"}, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: sandbox})
+		assert.NoError(t, err)
+		assert.NotEqual(t, ip, other)
+	}
+	_, _, err = ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-4", "eth0"}, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "web-1", StickyIP: true})
+	assert.Error(t, err)
+
+	// The recreated pod gets its address back
+	recreated, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-5", "eth0"}, sticky)
+	assert.NoError(t, err)
+	assert.Equal(t, ip, recreated)
+	assert.Equal(t, 0, ds.GetIPStats("4").ReservedIPs)
+}
+
+func TestStickyIPReservationExpires(t *testing.T) {
+	ds := newStickyTestDataStore(t, NullCheckpoint{})
+	ds.ipCooldownPeriod = 0
+	sticky := IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "web-0", StickyIP: true}
+
+	_, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-1", "eth0"}, sticky)
+	assert.NoError(t, err)
+	eni, ip, _, _, err := ds.UnassignPodIPAddress(IPAMKey{"net0", "sandbox-1", "eth0"})
+	assert.NoError(t, err)
+	addr := eni.AvailableIPv4Cidrs[ip+"/32"].IPAddresses[ip]
+	assert.True(t, addr.reserved(time.Now()))
+
+	addr.ReservedUntil = time.Now().Add(-time.Second)
+	assert.Equal(t, 0, ds.GetIPStats("4").ReservedIPs)
+	assert.Len(t, ds.FindFreeableCidrs("eni-1"), 3)
+}
+
+func TestStickyIPReservationNotFreeable(t *testing.T) {
+	ds := newStickyTestDataStore(t, NullCheckpoint{})
+	ds.ipCooldownPeriod = 0
+	sticky := IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "web-0", StickyIP: true}
+
+	_, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-1", "eth0"}, sticky)
+	assert.NoError(t, err)
+	eni, ip, _, _, err := ds.UnassignPodIPAddress(IPAMKey{"net0", "sandbox-1", "eth0"})
+	assert.NoError(t, err)
+
+	// The secondary IP held for web-0 is not returned to EC2 during the grace period
+	freeable := ds.FreeableIPs("eni-1")
+	assert.Len(t, freeable, 2)
+	for _, cidr := range freeable {
+		assert.NotEqual(t, ip, cidr.IP.String())
+	}
+
+	eni.AvailableIPv4Cidrs[ip+"/32"].IPAddresses[ip].ReservedUntil = time.Now().Add(-time.Second)
+	assert.Len(t, ds.FreeableIPs("eni-1"), 3)
+}
+
+func TestStickyIPNotRequested(t *testing.T) {
+	ds := newStickyTestDataStore(t, NullCheckpoint{})
+	_, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-1", "eth0"}, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "web-0"})
+	assert.NoError(t, err)
+	_, _, _, _, err = ds.UnassignPodIPAddress(IPAMKey{"net0", "sandbox-1", "eth0"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ds.GetIPStats("4").ReservedIPs)
+
+	// Nothing is reserved while the grace period is not configured
+	t.Setenv(envStickyIPGracePeriod, "")
+	ds = NewDataStore(Testlog, NullCheckpoint{}, false)
+	assert.False(t, ds.StickyIPEnabled())
+}
+
+func TestStickyIPReservationSurvivesRestart(t *testing.T) {
+	checkpoint := NewTestCheckpoint(struct{}{})
+	ds := newStickyTestDataStore(t, checkpoint)
+	sticky := IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "web-0", StickyIP: true}
+
+	ip, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-1", "eth0"}, sticky)
+	assert.NoError(t, err)
+	_, _, _, _, err = ds.UnassignPodIPAddress(IPAMKey{"net0", "sandbox-1", "eth0"})
+	assert.NoError(t, err)
+
+	data := checkpoint.Data.(*CheckpointData)
+	assert.Empty(t, data.Allocations)
+	assert.Len(t, data.Reservations, 1)
+	assert.Equal(t, ip, data.Reservations[0].IPv4)
+	assert.Equal(t, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "web-0"}, data.Reservations[0].Metadata)
+
+	// A restarted ipamd recovers the reservation and keeps writing it
+	restarted := newStickyTestDataStore(t, checkpoint)
+	assert.NoError(t, restarted.ReadBackingStore())
+	assert.Equal(t, 1, restarted.GetIPStats("4").ReservedIPs)
+	assert.Len(t, checkpoint.Data.(*CheckpointData).Reservations, 1)
+
+	recreated, _, err := restarted.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-2", "eth0"}, sticky)
+	assert.NoError(t, err)
+	assert.Equal(t, ip, recreated)
+	assert.Empty(t, checkpoint.Data.(*CheckpointData).Reservations)
+
+	// Expired reservations are dropped on restore
+	data = checkpoint.Data.(*CheckpointData)
+	data.Allocations = nil
+	data.Reservations = []CheckpointReservation{{IPv4: ip, Metadata: sticky, ExpiryTimestamp: time.Now().Add(-time.Minute).UnixNano()}}
+	restarted = newStickyTestDataStore(t, checkpoint)
+	assert.NoError(t, restarted.ReadBackingStore())
+	assert.Equal(t, 0, restarted.GetIPStats("4").ReservedIPs)
+}
@@ -0,0 +1,109 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrStaticIPNotInPool is returned when the requested address is not in any CIDR attached to the datastore ENIs
+	ErrStaticIPNotInPool = errors.New("datastore: requested IP is not in the IP pool")
+	// ErrStaticIPInUse is returned when the requested address is assigned to, or reserved for, another pod
+	ErrStaticIPInUse = errors.New("datastore: requested IP is already in use")
+	// ErrStaticIPCoolingDown is returned when the requested address was released too recently to be handed out again
+	ErrStaticIPCoolingDown = errors.New("datastore: requested IP is in its cooldown period")
+	// ErrStaticIPNoIPv6 is returned when a dual-stack pod with a requested IPv4 address cannot get an IPv6 address
+	ErrStaticIPNoIPv6 = errors.New("datastore: no IPv6 address for the pod with the requested IP")
+)
+
+// AssignPodIPv4AddressWithIP assigns the given IPv4 address to the pod. Only ENIs in the ENI pool of the pod are
+// considered, like for any other address. It returns the assigned IPv4 address, device number, error
+func (ds *DataStore) AssignPodIPv4AddressWithIP(ipamKey IPAMKey, ipamMetadata IPAMMetadata, ip net.IP) (ipv4address string, deviceNumber int, err error) {
+	ipv4address, _, deviceNumber, err = ds.AssignPodIPAddressWithIPv4(ipamKey, ipamMetadata, ip, false)
+	return ipv4address, deviceNumber, err
+}
+
+// AssignPodIPAddressWithIPv4 assigns the given IPv4 address to the pod like AssignPodIPv4AddressWithIP and, when
+// isIPv6Enabled, an IPv6 address from the pool. When no IPv6 address can be assigned, the IPv4 address is taken back
+// without cooldown or reservation and ErrStaticIPNoIPv6 is returned. Watchers only learn about the pod once it has
+// all its addresses. It returns the assigned IPv4 and IPv6 addresses, the device number of the IPv4 address, error
+func (ds *DataStore) AssignPodIPAddressWithIPv4(ipamKey IPAMKey, ipamMetadata IPAMMetadata, ip net.IP, isIPv6Enabled bool) (ipv4Address string, ipv6Address string, deviceNumber int, err error) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	assignment, err := ds.assignPodIPv4AddressWithIPUnsafe(ipamKey, ipamMetadata, ip)
+	if err != nil {
+		return "", "", -1, err
+	}
+	assignments := []*podAssignment{assignment}
+	if isIPv6Enabled {
+		ipv6Assignment, err := ds.assignPodIPv6AddressUnsafe(ipamKey, ipamMetadata)
+		if err != nil {
+			ds.rollbackAssignmentUnsafe(assignment)
+			return "", "", -1, errors.Wrapf(ErrStaticIPNoIPv6, "%v", err)
+		}
+		ipv6Address = ipv6Assignment.addr.Address
+		assignments = append(assignments, ipv6Assignment)
+	}
+	for _, assignment := range assignments {
+		ds.notifyAssignedUnsafe(assignment)
+	}
+	return assignment.addr.Address, ipv6Address, assignment.eni.DeviceNumber, nil
+}
+
+func (ds *DataStore) assignPodIPv4AddressWithIPUnsafe(ipamKey IPAMKey, ipamMetadata IPAMMetadata, ip net.IP) (*podAssignment, error) {
+	if eni, cidr, addr := ds.eniPool.findFamilyAddressForSandbox(ipamKey, false); addr != nil {
+		if addr.Address != ip.String() {
+			return nil, errors.Errorf("AssignPodIPv4AddressWithIP: sandbox %s already has IP %s assigned", ipamKey, addr.Address)
+		}
+		ds.log.Infof("AssignPodIPv4AddressWithIP: duplicate pod assign for sandbox %s", ipamKey)
+		return &podAssignment{eni: eni, cidr: cidr, addr: addr, existing: true}, nil
+	}
+
+	candidate, found := ds.findIPv4CandidateUnsafe(ip, ipamMetadata.ENIPool)
+	if !found {
+		return nil, ErrStaticIPNotInPool
+	}
+	if addr, ok := candidate.Cidr.IPAddresses[candidate.Address]; ok {
+		now := ds.clock.Now()
+		switch {
+		case addr.Assigned():
+			return nil, errors.Wrapf(ErrStaticIPInUse, "assigned to pod %s/%s", addr.IPAMMetadata.K8SPodNamespace, addr.IPAMMetadata.K8SPodName)
+		case addr.reserved(now) && !addr.reservedFor(now, ipamMetadata):
+			return nil, errors.Wrapf(ErrStaticIPInUse, "reserved for pod %s/%s", addr.ReservedFor.K8SPodNamespace, addr.ReservedFor.K8SPodName)
+		case !addr.reservedFor(now, ipamMetadata) && addr.inCoolingPeriod(now, ds.ipCooldownPeriod):
+			return nil, ErrStaticIPCoolingDown
+		}
+	}
+	ds.log.Debugf("Requested IP %s found in CIDR %s on ENI %s", candidate.Address, candidate.Cidr.Cidr.String(), candidate.ENI.ID)
+	return ds.assignCandidateUnsafe(candidate, ipamKey, ipamMetadata)
+}
+
+// findIPv4CandidateUnsafe finds the ENI of the ENI pool and the CIDR holding the given IPv4 address
+func (ds *DataStore) findIPv4CandidateUnsafe(ip net.IP, pool string) (IPCandidate, bool) {
+	for _, eni := range ds.eniPool {
+		if eni.Pool != pool {
+			continue
+		}
+		for _, availableCidr := range eni.AvailableIPv4Cidrs {
+			if availableCidr.Cidr.Contains(ip) {
+				return IPCandidate{ENI: eni, Cidr: availableCidr, Address: ip.String()}, true
+			}
+		}
+	}
+	return IPCandidate{}, false
+}
@@ -0,0 +1,128 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignPodIPv4AddressWithIP(t *testing.T) {
+	ds := NewDataStore(Testlog, NullCheckpoint{}, false)
+	assert.NoError(t, ds.AddENI("eni-1", 1, true, false, false))
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(32, 32)}, false))
+	}
+	pod := IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "static"}
+
+	ip, deviceNumber, err := ds.AssignPodIPv4AddressWithIP(IPAMKey{"net0", "sandbox-1", "eth0"}, pod, net.ParseIP("10.0.0.2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.2", ip)
+	assert.Equal(t, 1, deviceNumber)
+
+	// A repeated request for the same sandbox returns the same address
+	ip, _, err = ds.AssignPodIPv4AddressWithIP(IPAMKey{"net0", "sandbox-1", "eth0"}, pod, net.ParseIP("10.0.0.2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.2", ip)
+	_, _, err = ds.AssignPodIPv4AddressWithIP(IPAMKey{"net0", "sandbox-1", "eth0"}, pod, net.ParseIP("10.0.0.3"))
+	assert.Error(t, err)
+
+	_, _, err = ds.AssignPodIPv4AddressWithIP(IPAMKey{"net0", "sandbox-2", "eth0"}, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "other"}, net.ParseIP("10.0.0.2"))
+	assert.ErrorIs(t, err, ErrStaticIPInUse)
+
+	_, _, err = ds.AssignPodIPv4AddressWithIP(IPAMKey{"net0", "sandbox-2", "eth0"}, pod, net.ParseIP("10.0.0.9"))
+	assert.ErrorIs(t, err, ErrStaticIPNotInPool)
+
+	// A released address has to cool down before it is handed out again
+	_, _, _, _, err = ds.UnassignPodIPAddress(IPAMKey{"net0", "sandbox-1", "eth0"})
+	assert.NoError(t, err)
+	_, _, err = ds.AssignPodIPv4AddressWithIP(IPAMKey{"net0", "sandbox-3", "eth0"}, pod, net.ParseIP("10.0.0.2"))
+	assert.ErrorIs(t, err, ErrStaticIPCoolingDown)
+
+	ds.eniPool["eni-1"].AvailableIPv4Cidrs["10.0.0.2/32"].IPAddresses["10.0.0.2"].UnassignedTime = time.Now().Add(-time.Hour)
+	ip, _, err = ds.AssignPodIPv4AddressWithIP(IPAMKey{"net0", "sandbox-3", "eth0"}, pod, net.ParseIP("10.0.0.2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.2", ip)
+	assert.Equal(t, 1, ds.GetIPStats("4").AssignedIPs)
+}
+
+func TestAssignPodIPv4AddressWithIPFromPrefix(t *testing.T) {
+	ds := NewDataStore(Testlog, NullCheckpoint{}, true)
+	assert.NoError(t, ds.AddENI("eni-1", 1, true, false, false))
+	_, prefix, _ := net.ParseCIDR("10.0.0.16/28")
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", *prefix, true))
+
+	ip, _, err := ds.AssignPodIPv4AddressWithIP(IPAMKey{"net0", "sandbox-1", "eth0"}, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "static"}, net.ParseIP("10.0.0.27"))
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.27", ip)
+
+	// Other pods never get the static address
+	for i := 0; i < 15; i++ {
+		ip, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", "sandbox-other", "eth" + string(rune('a'+i))}, IPAMMetadata{})
+		assert.NoError(t, err)
+		assert.NotEqual(t, "10.0.0.27", ip)
+	}
+}
+
+func TestAssignPodIPv4AddressWithIPENIPool(t *testing.T) {
+	ds := NewDataStore(Testlog, NullCheckpoint{}, false)
+	assert.NoError(t, ds.AddENI("eni-1", 1, true, false, false))
+	assert.NoError(t, ds.AddENI("eni-2", 2, false, false, false))
+	assert.NoError(t, ds.SetENIPool("eni-2", "blue"))
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP("10.0.0.1"), Mask: net.CIDRMask(32, 32)}, false))
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-2", net.IPNet{IP: net.ParseIP("10.1.0.1"), Mask: net.CIDRMask(32, 32)}, false))
+	blue := IPAMMetadata{K8SPodNamespace: "team-blue", K8SPodName: "static", ENIPool: "blue"}
+
+	// An address on an ENI of another pool is not in the pod's IP pool
+	_, _, err := ds.AssignPodIPv4AddressWithIP(IPAMKey{"net0", "sandbox-1", "eth0"}, blue, net.ParseIP("10.0.0.1"))
+	assert.ErrorIs(t, err, ErrStaticIPNotInPool)
+	_, _, err = ds.AssignPodIPv4AddressWithIP(IPAMKey{"net0", "sandbox-2", "eth0"}, IPAMMetadata{K8SPodName: "default"}, net.ParseIP("10.1.0.1"))
+	assert.ErrorIs(t, err, ErrStaticIPNotInPool)
+
+	ip, deviceNumber, err := ds.AssignPodIPv4AddressWithIP(IPAMKey{"net0", "sandbox-1", "eth0"}, blue, net.ParseIP("10.1.0.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "10.1.0.1", ip)
+	assert.Equal(t, 2, deviceNumber)
+}
+
+func TestAssignPodIPAddressWithIPv4Rollback(t *testing.T) {
+	t.Setenv(envIPCooldownPeriod, "30")
+	checkpoint := NewTestCheckpoint(struct{}{})
+	ds := NewDataStore(Testlog, checkpoint, false)
+	ds.stickyIPGracePeriod = time.Minute
+	assert.NoError(t, ds.AddENI("eni-1", 1, true, false, false))
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP("10.0.0.1"), Mask: net.CIDRMask(32, 32)}, false))
+	events, stop := ds.WatchAllocations()
+	defer stop()
+	pod := IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "static", StickyIP: true}
+
+	// Without an IPv6 prefix the pod gets neither address
+	ipv4, ipv6, deviceNumber, err := ds.AssignPodIPAddressWithIPv4(IPAMKey{"net0", "sandbox-1", "eth0"}, pod, net.ParseIP("10.0.0.1"), true)
+	assert.ErrorIs(t, err, ErrStaticIPNoIPv6)
+	assert.Empty(t, ipv4)
+	assert.Empty(t, ipv6)
+	assert.Equal(t, -1, deviceNumber)
+	assert.Empty(t, events)
+	assert.Empty(t, checkpoint.Data.(*CheckpointData).Allocations)
+	assert.Empty(t, checkpoint.Data.(*CheckpointData).Reservations)
+
+	// The address is neither cooling down nor held for the pod
+	assert.Equal(t, 0, ds.GetIPStats("4").CooldownIPs)
+	ipv4, _, err = ds.AssignPodIPv4AddressWithIP(IPAMKey{"net0", "sandbox-2", "eth0"}, IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "other"}, net.ParseIP("10.0.0.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", ipv4)
+}
@@ -0,0 +1,82 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+// allocationWatchBuffer is the number of events a watcher may fall behind before it is dropped
+const allocationWatchBuffer = 256
+
+// AllocationEventType tells watchers whether an address was handed to a sandbox or taken back from it
+type AllocationEventType int
+
+const (
+	// AllocationAssigned is sent when an address is assigned to a sandbox
+	AllocationAssigned AllocationEventType = iota
+	// AllocationUnassigned is sent when an address is released by a sandbox, or force removed along with its ENI
+	AllocationUnassigned
+)
+
+// AllocationEvent describes a single change to the allocated addresses
+type AllocationEvent struct {
+	Type AllocationEventType
+	PodIPInfo
+}
+
+// WatchAllocations registers a watcher for allocation changes. Events are delivered in the order they happen.
+// The returned channel is closed once the cancel func is called, or when the watcher falls more than
+// allocationWatchBuffer events behind, in which case the caller has to list allocations again and re-watch.
+func (ds *DataStore) WatchAllocations() (<-chan AllocationEvent, func()) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+
+	ch := make(chan AllocationEvent, allocationWatchBuffer)
+	if ds.watchers == nil {
+		ds.watchers = make(map[chan AllocationEvent]struct{})
+	}
+	ds.watchers[ch] = struct{}{}
+
+	cancel := func() {
+		ds.lock.Lock()
+		defer ds.lock.Unlock()
+		if _, ok := ds.watchers[ch]; ok {
+			delete(ds.watchers, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel
+}
+
+// notifyWatchersUnsafe sends the event to every watcher without blocking. Watchers that cannot keep up are dropped.
+func (ds *DataStore) notifyWatchersUnsafe(eventType AllocationEventType, info PodIPInfo) {
+	event := AllocationEvent{Type: eventType, PodIPInfo: info}
+	for ch := range ds.watchers {
+		select {
+		case ch <- event:
+		default:
+			ds.log.Warnf("Dropping allocation watcher that fell %d events behind", allocationWatchBuffer)
+			delete(ds.watchers, ch)
+			close(ch)
+		}
+	}
+}
+
+// newPodIPInfo builds the PodIPInfo for an assigned address. It must be called before the address is unassigned.
+func newPodIPInfo(eni *ENI, addr *AddressInfo) PodIPInfo {
+	return PodIPInfo{
+		IPAMKey:      addr.IPAMKey,
+		IPAMMetadata: addr.IPAMMetadata,
+		IP:           addr.Address,
+		ENIID:        eni.ID,
+		DeviceNumber: eni.DeviceNumber,
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datastore
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchAllocations(t *testing.T) {
+	checkpoint := NewTestCheckpoint(struct{}{})
+	ds := NewDataStore(Testlog, checkpoint, false)
+	assert.NoError(t, ds.AddENI("eni-1", 1, true, false, false))
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP("1.1.1.1"), Mask: net.IPv4Mask(255, 255, 255, 255)}, false))
+
+	events, cancel := ds.WatchAllocations()
+	key := IPAMKey{"net0", "sandbox-1", "eth0"}
+	metadata := IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "sample-pod"}
+
+	// Failed assignments are not reported
+	checkpoint.Error = errors.New("fake checkpoint error")
+	_, _, err := ds.AssignPodIPv4Address(key, metadata)
+	assert.Error(t, err)
+	assert.Empty(t, events)
+
+	checkpoint.Error = nil
+	_, _, err = ds.AssignPodIPv4Address(key, metadata)
+	assert.NoError(t, err)
+	_, _, _, _, err = ds.UnassignPodIPAddress(key)
+	assert.NoError(t, err)
+
+	expected := PodIPInfo{IPAMKey: key, IPAMMetadata: metadata, IP: "1.1.1.1", ENIID: "eni-1", DeviceNumber: 1}
+	assert.Equal(t, AllocationEvent{Type: AllocationAssigned, PodIPInfo: expected}, <-events)
+	assert.Equal(t, AllocationEvent{Type: AllocationUnassigned, PodIPInfo: expected}, <-events)
+
+	cancel()
+	_, ok := <-events
+	assert.False(t, ok)
+	// Cancel is idempotent
+	cancel()
+}
+
+func TestWatchAllocationsDropsSlowWatcher(t *testing.T) {
+	ds := NewDataStore(Testlog, NullCheckpoint{}, false)
+	assert.NoError(t, ds.AddENI("eni-1", 1, true, false, false))
+	for i := 0; i <= allocationWatchBuffer; i++ {
+		ip := net.IPv4(10, 0, byte(i/256), byte(i%256))
+		assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: ip, Mask: net.CIDRMask(32, 32)}, false))
+	}
+
+	events, cancel := ds.WatchAllocations()
+	defer cancel()
+
+	for i := 0; i <= allocationWatchBuffer; i++ {
+		_, _, err := ds.AssignPodIPv4Address(IPAMKey{"net0", fmt.Sprintf("sandbox-%d", i), "eth0"}, IPAMMetadata{})
+		assert.NoError(t, err)
+	}
+
+	// The watcher is closed once its buffer overflows
+	received := 0
+	for range events {
+		received++
+	}
+	assert.Equal(t, allocationWatchBuffer, received)
+}
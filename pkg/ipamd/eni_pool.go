@@ -0,0 +1,46 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
+)
+
+// eniPoolNames returns the default ENI pool followed by the ENI pools configured in ENI_CONFIG_POOLS and the ENI pools
+// of the other network cards
+func (c *IPAMContext) eniPoolNames() []string {
+	pools := append([]string{datastore.DefaultENIPool}, c.eniConfigPools.Names()...)
+	return append(pools, c.networkCardPoolNames()...)
+}
+
+// isENIPoolConfigured reports whether ipamd should keep warm IPs for the ENI pool
+func (c *IPAMContext) isENIPoolConfigured(pool string) bool {
+	return pool == datastore.DefaultENIPool || c.eniConfigPools.Has(pool) || c.isNetworkCardPoolConfigured(pool)
+}
+
+// getPodENIPool returns the ENI pool the pod gets its IP from. A network card annotation on the pod takes precedence
+// over ENI_CONFIG_POOLS, whose ENIs are on the default network card.
+func (c *IPAMContext) getPodENIPool(podName, podNamespace string) (string, error) {
+	if c.eniConfigPools == nil && !c.enableMultiCardENIs {
+		return datastore.DefaultENIPool, nil
+	}
+	pod, err := c.GetPod(podName, podNamespace)
+	if err != nil {
+		return "", err
+	}
+	if pool, ok, err := c.podNetworkCardPool(pod); ok || err != nil {
+		return pool, err
+	}
+	return c.eniConfigPools.PoolForPod(pod)
+}
@@ -0,0 +1,102 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/apis/crd/v1alpha1"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/eniconfig"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/k8sapi"
+)
+
+// eniConfigStatusInterval is how often the status of an ENIConfig is validated. Every node using the ENIConfig checks
+// it, and the first one to find the status older than the interval updates it.
+const eniConfigStatusInterval = 5 * time.Minute
+
+// eniConfigsInUse returns the ENIConfig of this node followed by the ENIConfigs of ENI_CONFIG_POOLS
+func (c *IPAMContext) eniConfigsInUse(ctx context.Context) []string {
+	var names []string
+	node, err := k8sapi.GetNode(ctx, c.k8sClient)
+	if err == nil {
+		name, err := eniconfig.GetNodeSpecificENIConfigName(node)
+		if err == nil {
+			names = append(names, name)
+		}
+	}
+	for _, name := range c.eniConfigPools.Names() {
+		if len(names) == 0 || name != names[0] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// updateENIConfigStatuses validates the ENIConfigs used by this node and writes the result to their status
+func (c *IPAMContext) updateENIConfigStatuses(ctx context.Context) {
+	for _, name := range c.eniConfigsInUse(ctx) {
+		if err := c.updateENIConfigStatus(ctx, name); err != nil {
+			log.Warnf("Failed to update the status of ENIConfig %s: %v", name, err)
+		}
+	}
+}
+
+// updateENIConfigStatus refreshes the status of the ENIConfig unless another node did so within eniConfigStatusInterval
+func (c *IPAMContext) updateENIConfigStatus(ctx context.Context, name string) error {
+	var eniConfig v1alpha1.ENIConfig
+	if err := c.k8sClient.Get(ctx, types.NamespacedName{Name: name}, &eniConfig); err != nil {
+		return errors.Wrap(err, "failed to get ENIConfig")
+	}
+	now := c.now()
+	if now.Sub(eniConfig.Status.LastUpdateTime.Time) < eniConfigStatusInterval {
+		return nil
+	}
+
+	status := v1alpha1.ENIConfigStatus{LastUpdateTime: metav1.NewTime(now)}
+	network, err := c.awsClient.DescribeENIConfigNetwork(eniConfig.Spec.SubnetIDs(), eniConfig.Spec.SecurityGroups)
+	if err != nil {
+		status.LastError = err.Error()
+	} else {
+		status.SubnetResolved = network.SubnetFound
+		status.SecurityGroupsResolved = network.SecurityGroupsFound
+		status.AvailabilityZone = network.AvailabilityZone
+		status.AvailableIPAddressCount = network.AvailableIPAddressCount
+		status.LastError = strings.Join(network.Errors, "; ")
+	}
+	nodeCount, err := eniconfig.CountNodesUsingENIConfig(ctx, c.k8sAPIReader, name)
+	if err != nil {
+		return err
+	}
+	status.NodeCount = int32(nodeCount)
+
+	eniConfig.Status = status
+	if err := c.k8sClient.Status().Update(ctx, &eniConfig); err != nil {
+		// Another node updated the status first
+		if apierrors.IsConflict(err) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to update ENIConfig status")
+	}
+	if status.LastError != "" {
+		log.Warnf("ENIConfig %s is not valid: %s", name, status.LastError)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/apis/crd/v1alpha1"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils"
+	mock_awsutils "github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils/mocks"
+)
+
+func newENIConfigStatusClient(objs ...client.Object) client.Client {
+	k8sSchema := runtime.NewScheme()
+	clientgoscheme.AddToScheme(k8sSchema)
+	v1alpha1.AddToScheme(k8sSchema)
+	return testclient.NewClientBuilder().WithScheme(k8sSchema).WithStatusSubresource(&v1alpha1.ENIConfig{}).
+		WithObjects(objs...).Build()
+}
+
+func TestUpdateENIConfigStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	now := time.Unix(1700000000, 0)
+	t.Setenv("MY_NODE_NAME", myNodeName)
+
+	node := func(name, eniConfig string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name,
+			Labels: map[string]string{"k8s.amazonaws.com/eniConfig": eniConfig}}}
+	}
+	eniConfig := func(subnet string, lastUpdate time.Time) *v1alpha1.ENIConfig {
+		return &v1alpha1.ENIConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "az1"},
+			Spec:       v1alpha1.ENIConfigSpec{Subnet: subnet, SecurityGroups: []string{"sg-1"}},
+			Status:     v1alpha1.ENIConfigStatus{LastUpdateTime: metav1.NewTime(lastUpdate)},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		eniConfig  *v1alpha1.ENIConfig
+		network    *awsutils.ENIConfigNetwork
+		networkErr error
+		want       v1alpha1.ENIConfigStatus
+	}{
+		{
+			name:      "valid subnet",
+			eniConfig: eniConfig("subnet-1", time.Time{}),
+			network: &awsutils.ENIConfigNetwork{SubnetFound: true, AvailabilityZone: "us-west-2a",
+				AvailableIPAddressCount: 250, SecurityGroupsFound: true},
+			want: v1alpha1.ENIConfigStatus{SubnetResolved: true, SecurityGroupsResolved: true,
+				AvailabilityZone: "us-west-2a", AvailableIPAddressCount: 250, NodeCount: 2},
+		},
+		{
+			name:      "subnet typo",
+			eniConfig: eniConfig("subnet-typo", time.Time{}),
+			network: &awsutils.ENIConfigNetwork{SecurityGroupsFound: true,
+				Errors: []string{"subnet subnet-typo not found"}},
+			want: v1alpha1.ENIConfigStatus{SecurityGroupsResolved: true, NodeCount: 2,
+				LastError: "subnet subnet-typo not found"},
+		},
+		{
+			name:       "EC2 error",
+			eniConfig:  eniConfig("subnet-1", time.Time{}),
+			networkErr: errors.New("UnauthorizedOperation"),
+			want:       v1alpha1.ENIConfigStatus{NodeCount: 2, LastError: "UnauthorizedOperation"},
+		},
+		{
+			name:      "updated by another node",
+			eniConfig: eniConfig("subnet-1", now.Add(-time.Minute)),
+			want:      v1alpha1.ENIConfigStatus{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient := newENIConfigStatusClient(tt.eniConfig, node(myNodeName, "az1"), node("other", "az1"),
+				node("az2-node", "az2"))
+			awsClient := mock_awsutils.NewMockAPIs(ctrl)
+			if tt.network != nil || tt.networkErr != nil {
+				var network awsutils.ENIConfigNetwork
+				if tt.network != nil {
+					network = *tt.network
+				}
+				awsClient.EXPECT().DescribeENIConfigNetwork(tt.eniConfig.Spec.SubnetIDs(), []string{"sg-1"}).
+					Return(network, tt.networkErr)
+			}
+			c := &IPAMContext{awsClient: awsClient, k8sClient: k8sClient, k8sAPIReader: k8sClient, clock: &fakeClock{now: now}}
+
+			c.updateENIConfigStatuses(context.Background())
+
+			var got v1alpha1.ENIConfig
+			require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "az1"}, &got))
+			if tt.network == nil && tt.networkErr == nil {
+				assert.Equal(t, tt.eniConfig.Status.LastUpdateTime.Unix(), got.Status.LastUpdateTime.Unix())
+				return
+			}
+			assert.Equal(t, now.Unix(), got.Status.LastUpdateTime.Unix())
+			got.Status.LastUpdateTime = metav1.Time{}
+			assert.Equal(t, tt.want, got.Status)
+		})
+	}
+}
@@ -78,7 +78,11 @@ func (c *IPAMContext) setupIntrospectionServer() *http.Server {
 		"/v1/enis":                      eniV1RequestHandler(c),
 		"/v1/eni-configs":               eniConfigRequestHandler(c),
 		"/v1/networkutils-env-settings": networkEnvV1RequestHandler(),
-		"/v1/ipamd-env-settings":        ipamdEnvV1RequestHandler(),
+		"/v1/ipamd-env-settings":        ipamdEnvV1RequestHandler(c),
+		"/v1/warm-pool-schedule":        warmPoolScheduleRequestHandler(c),
+		"/v1/pool-decisions":            poolDecisionsRequestHandler(c),
+		"/v1/subnet-discovery":          subnetDiscoveryRequestHandler(c),
+		"/v1/leaked-enis":               leakedENIsRequestHandler(c),
 	}
 	paths := make([]string, 0, len(serverFunctions))
 	for path := range serverFunctions {
@@ -170,9 +174,9 @@ func networkEnvV1RequestHandler() func(http.ResponseWriter, *http.Request) {
 	}
 }
 
-func ipamdEnvV1RequestHandler() func(http.ResponseWriter, *http.Request) {
+func ipamdEnvV1RequestHandler(ipam *IPAMContext) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		responseJSON, err := json.Marshal(GetConfigForDebug())
+		responseJSON, err := json.Marshal(ipam.getConfigForDebug())
 		if err != nil {
 			log.Errorf("Failed to marshal ipamd env var data: %v", err)
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -182,6 +186,54 @@ func ipamdEnvV1RequestHandler() func(http.ResponseWriter, *http.Request) {
 	}
 }
 
+func warmPoolScheduleRequestHandler(ipam *IPAMContext) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseJSON, err := json.Marshal(ipam.getPoolScheduleForDebug())
+		if err != nil {
+			log.Errorf("Failed to marshal warm pool schedule data: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		logErr(w.Write(responseJSON))
+	}
+}
+
+func poolDecisionsRequestHandler(ipam *IPAMContext) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseJSON, err := json.Marshal(ipam.getPoolDecisionsForDebug())
+		if err != nil {
+			log.Errorf("Failed to marshal pool decisions data: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		logErr(w.Write(responseJSON))
+	}
+}
+
+func subnetDiscoveryRequestHandler(ipam *IPAMContext) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseJSON, err := json.Marshal(ipam.awsClient.GetSubnetDiscoveryState())
+		if err != nil {
+			log.Errorf("Failed to marshal subnet discovery data: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		logErr(w.Write(responseJSON))
+	}
+}
+
+func leakedENIsRequestHandler(ipam *IPAMContext) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseJSON, err := json.Marshal(ipam.awsClient.GetLeakedENIReport())
+		if err != nil {
+			log.Errorf("Failed to marshal leaked ENI report: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		logErr(w.Write(responseJSON))
+	}
+}
+
 func logErr(_ int, err error) {
 	if err != nil {
 		log.Errorf("Write failed: %v", err)
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -39,6 +40,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/apis/crd/v1alpha1"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/eniconfig"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
@@ -46,6 +48,8 @@ import (
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/networkutils"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/cniutils"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/logger"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/ttime"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/vpc"
 	"github.com/aws/amazon-vpc-cni-k8s/utils"
 	"github.com/aws/amazon-vpc-cni-k8s/utils/prometheusmetrics"
 	rcv1alpha1 "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
@@ -137,6 +141,11 @@ const (
 	envBackingStorePath     = "AWS_VPC_K8S_CNI_BACKING_STORE"
 	defaultBackingStorePath = "/var/run/aws-node/ipam.json"
 
+	// envBackingStoreJournal makes ipam append allocation changes to a journal instead of rewriting the whole backing store
+	envBackingStoreJournal = "AWS_VPC_K8S_CNI_BACKING_STORE_JOURNAL"
+	// backingStoreJournalExt replaces the extension of the backing store path to name the journal kept next to it
+	backingStoreJournalExt = ".journal"
+
 	// envEnablePodENI is used to attach a Trunk ENI to every node. Required in order to give Branch ENIs to pods.
 	envEnablePodENI = "ENABLE_POD_ENI"
 
@@ -170,6 +179,9 @@ const (
 
 	eniNodeTagKey = "node.k8s.amazonaws.com/instance_id"
 
+	// eniConfigPoolTagKey is set on ENIs created for one of the ENI_CONFIG_POOLS, its value is the ENIConfig name
+	eniConfigPoolTagKey = "node.k8s.amazonaws.com/eni-config-pool"
+
 	// envAnnotatePodIP is used to annotate[vpc.amazonaws.com/pod-ips] pod's with IPs
 	// Ref : https://github.com/projectcalico/calico/issues/3530
 	// not present; in which case we fall back to the k8s podIP
@@ -178,6 +190,21 @@ const (
 	// The empty string one helps close a trace at pod shutdown where it looks like the pod still has its IP when the IP has been released
 	envAnnotatePodIP = "ANNOTATE_POD_IP"
 
+	// envEnableStaticPodIP lets pods request a specific IPv4 address with the vpc.amazonaws.com/static-ip annotation
+	envEnableStaticPodIP = "ENABLE_STATIC_POD_IP"
+
+	// envEnableMultiCardENIs lets ipamd attach ENIs to all network cards of the instance, and keep warm IPs for each card
+	envEnableMultiCardENIs = "ENABLE_MULTI_CARD_ENIS"
+
+	// envEnableENIConfigStatus lets ipamd validate the ENIConfigs it uses and report the result in their status
+	envEnableENIConfigStatus = "ENABLE_ENICONFIG_STATUS"
+
+	// envEnableAdaptiveWarmIPTarget sizes the warm IP target from the observed pod churn, between
+	// ADAPTIVE_WARM_IP_TARGET_MIN and ADAPTIVE_WARM_IP_TARGET_MAX
+	envEnableAdaptiveWarmIPTarget = "ENABLE_ADAPTIVE_WARM_IP_TARGET"
+	envAdaptiveWarmIPTargetMin    = "ADAPTIVE_WARM_IP_TARGET_MIN"
+	envAdaptiveWarmIPTargetMax    = "ADAPTIVE_WARM_IP_TARGET_MAX"
+
 	// aws error codes for insufficient IP address scenario
 	INSUFFICIENT_CIDR_BLOCKS    = "InsufficientCidrBlocks"
 	INSUFFICIENT_FREE_IP_SUBNET = "InsufficientFreeAddressesInSubnet"
@@ -191,6 +218,10 @@ var log = logger.Get()
 
 var (
 	prometheusRegistered = false
+
+	// errIMDSDegraded is returned for ENI allocations while IMDS is degraded, as the new ENI would not show up in the
+	// IMDS cache
+	errIMDSDegraded = errors.New("IMDS is degraded, new ENIs would not be seen in the IMDS cache")
 )
 
 // IPAMContext contains node level control information
@@ -198,6 +229,7 @@ type IPAMContext struct {
 	awsClient                 awsutils.APIs
 	dataStore                 *datastore.DataStore
 	k8sClient                 client.Client
+	k8sAPIReader              client.Reader
 	enableIPv4                bool
 	enableIPv6                bool
 	useCustomNetworking       bool
@@ -208,8 +240,11 @@ type IPAMContext struct {
 	maxENI                    int
 	maxPrefixesPerENI         int
 	unmanagedENI              int
-	numNetworkCards           int
+	networkCards              []vpc.NetworkCard
 
+	// poolTargetsLock guards maxENI and the warm targets, which the pool ConfigMap, the warm pool schedule and the
+	// adaptive warm IP target replace while they are read. Read them through poolTargets.
+	poolTargetsLock      sync.RWMutex
 	warmENITarget        int
 	warmIPTarget         int
 	minimumIPTarget      int
@@ -228,8 +263,21 @@ type IPAMContext struct {
 	lastInsufficientCidrError time.Time
 	enableManageUntaggedMode  bool
 	enablePodIPAnnotation     bool
+	enableStaticPodIP         bool
+	enableMultiCardENIs       bool
+	enableENIConfigStatus     bool
+	eniConfigPools            *eniconfig.ENIConfigPools
+	adaptiveWarmTarget        *adaptiveWarmTarget
+	poolSettingsEnv           poolSettings
+	poolConfig                *poolConfig
+	poolSchedule              *poolSchedule
+	poolSettingsInUse         poolSettingsInUse
+	poolDecisions             *poolDecisionLog
+	allocations               allocationBatcher
 	maxPods                   int // maximum number of pods that can be scheduled on the node
 	networkPolicyMode         string
+	// clock is the time source of the IP pool manager, a virtual clock in the IPAM simulator
+	clock ttime.Time
 }
 
 // setUnmanagedENIs will rebuild the set of ENI IDs for ENIs tagged as "no_manage"
@@ -330,16 +378,46 @@ func containsPrivateIPAddressLimitExceededError(err error) bool {
 
 // inInsufficientCidrCoolingPeriod checks whether IPAMD is in insufficientCidrErrorCooldown
 func (c *IPAMContext) inInsufficientCidrCoolingPeriod() bool {
-	return time.Since(c.lastInsufficientCidrError) <= insufficientCidrErrorCooldown
+	return c.now().Sub(c.lastInsufficientCidrError) <= insufficientCidrErrorCooldown
 }
 
 // New retrieves IP address usage information from Instance MetaData service and Kubelet
 // then initializes IP address pool data store
 func New(k8sClient client.Client) (*IPAMContext, error) {
 	prometheusRegister()
+	networkClient := networkutils.New()
+	awsClient, err := awsutils.New(UseSubnetDiscovery(), UseCustomNetworkCfg(), disableLeakedENICleanup(), isIPv4Enabled(), isIPv6Enabled())
+	if err != nil {
+		return nil, errors.Wrap(err, "ipamd: can not initialize with AWS SDK interface")
+	}
+	var checkpointer datastore.Checkpointer = datastore.NewJSONFile(dsBackingStorePath())
+	if utils.GetBoolAsStringEnvVar(envBackingStoreJournal, false) {
+		checkpointer = datastore.NewJournal(dsJournalPath(), dsBackingStorePath(), log)
+	} else if err := datastore.RemoveJournal(dsJournalPath(), dsBackingStorePath(), log); err != nil {
+		return nil, errors.Wrap(err, "ipamd: failed to write the backing store journal to the backing store")
+	}
+	k8sAPIReader, err := k8sapi.CreateKubeAPIReader()
+	if err != nil {
+		return nil, errors.Wrap(err, "ipamd: can not create the kube API reader")
+	}
+	c, err := newIPAMContext(k8sClient, awsClient, networkClient, checkpointer, &ttime.DefaultTime{})
+	if err != nil {
+		return nil, err
+	}
+	c.k8sAPIReader = k8sAPIReader
+	return c, nil
+}
+
+// newIPAMContext configures the IPAMContext from the environment and initializes the node with the given clients
+func newIPAMContext(k8sClient client.Client, awsClient awsutils.APIs, networkClient networkutils.NetworkAPIs,
+	checkpointer datastore.Checkpointer, clock ttime.Time) (*IPAMContext, error) {
+	var err error
 	c := &IPAMContext{}
 	c.k8sClient = k8sClient
-	c.networkClient = networkutils.New()
+	c.k8sAPIReader = k8sClient
+	c.networkClient = networkClient
+	c.awsClient = awsClient
+	c.clock = clock
 	c.useCustomNetworking = UseCustomNetworkCfg()
 	c.manageENIsNonScheduleable = ManageENIsOnNonSchedulableNode()
 	c.useSubnetDiscovery = UseSubnetDiscovery()
@@ -347,23 +425,42 @@ func New(k8sClient client.Client) (*IPAMContext, error) {
 	c.enableIPv4 = isIPv4Enabled()
 	c.enableIPv6 = isIPv6Enabled()
 	c.disableENIProvisioning = disableENIProvisioning()
-	client, err := awsutils.New(c.useSubnetDiscovery, c.useCustomNetworking, disableLeakedENICleanup(), c.enableIPv4, c.enableIPv6)
-	if err != nil {
-		return nil, errors.Wrap(err, "ipamd: can not initialize with AWS SDK interface")
-	}
-	c.awsClient = client
 
 	c.primaryIP = make(map[string]string)
 	c.reconcileCooldownCache.cache = make(map[string]time.Time)
+	c.poolDecisions = newPoolDecisionLog(poolDecisionLogSize)
 	// WARM and Min IP/Prefix targets are ignored in IPv6 mode
 	c.warmENITarget = getWarmENITarget()
 	c.warmIPTarget = getWarmIPTarget()
 	c.minimumIPTarget = getMinimumIPTarget()
+	c.adaptiveWarmTarget = loadAdaptiveWarmTarget(clock, c.warmIPTarget)
+	if c.adaptiveWarmTarget != nil {
+		// Start from the lower bound until there is pod churn to learn from
+		c.warmIPTarget = c.adaptiveWarmTarget.minTarget
+	}
 	c.warmPrefixTarget = getWarmPrefixTarget()
+	c.initPoolSettings(poolSettings{
+		WarmIPTarget:     getWarmIPTarget(),
+		MinimumIPTarget:  c.minimumIPTarget,
+		WarmPrefixTarget: c.warmPrefixTarget,
+		WarmENITarget:    c.warmENITarget,
+		MaxENI:           getEnvMaxENI(),
+	})
+	c.poolConfig = newPoolConfig(c.poolSettingsEnv)
+	c.poolSchedule, err = c.loadPoolSchedule()
+	if err != nil {
+		return nil, err
+	}
 	c.enablePodENI = enablePodENI()
 	c.enableManageUntaggedMode = enableManageUntaggedMode()
 	c.enablePodIPAnnotation = enablePodIPAnnotation()
-	c.numNetworkCards = len(c.awsClient.GetNetworkCards())
+	c.enableStaticPodIP = enableStaticPodIP()
+	c.enableMultiCardENIs = enableMultiCardENIs()
+	c.enableENIConfigStatus = enableENIConfigStatus()
+	c.eniConfigPools, err = eniconfig.LoadENIConfigPools()
+	if err != nil {
+		return nil, err
+	}
 
 	c.networkPolicyMode, err = getNetworkPolicyMode()
 	if err != nil {
@@ -375,6 +472,7 @@ func New(k8sClient client.Client) (*IPAMContext, error) {
 		log.Errorf("Failed to get ENI limits from file:vpc_ip_limits or EC2 for %s", c.awsClient.GetInstanceType())
 		return nil, err
 	}
+	c.networkCards = c.awsClient.GetNetworkCards()
 
 	// Validate if the configured combination of env variables is supported before proceeding further
 	if !c.isConfigValid() {
@@ -382,9 +480,10 @@ func New(k8sClient client.Client) (*IPAMContext, error) {
 	}
 
 	c.awsClient.InitCachedPrefixDelegation(c.enablePrefixDelegation)
+	c.awsClient.InitCachedMultiCardENIs(c.enableMultiCardENIs)
 	c.myNodeName = os.Getenv(envNodeName)
-	checkpointer := datastore.NewJSONFile(dsBackingStorePath())
 	c.dataStore = datastore.NewDataStore(log, checkpointer, c.enablePrefixDelegation)
+	c.dataStore.SetClock(clock)
 
 	if err := c.nodeInit(); err != nil {
 		return nil, err
@@ -431,7 +530,7 @@ func (c *IPAMContext) nodeInit() error {
 	}
 
 	log.Debugf("DescribeAllENIs success: ENIs: %d, tagged: %d", len(metadataResult.ENIMetadata), len(metadataResult.TagMap))
-	c.awsClient.SetMultiCardENIs(metadataResult.MultiCardENIIDs)
+	c.awsClient.SetMultiCardENIs(c.skippedMultiCardENIs(metadataResult))
 	c.setUnmanagedENIs(metadataResult.TagMap)
 	enis := c.filterUnmanagedENIs(metadataResult.ENIMetadata)
 
@@ -449,7 +548,7 @@ func (c *IPAMContext) nodeInit() error {
 		retry := 0
 		for {
 			retry++
-			if err = c.setupENI(eni.ENIID, eni, isTrunkENI, isEFAENI); err == nil {
+			if err = c.setupENI(eni.ENIID, eni, isTrunkENI, isEFAENI, c.eniPoolOf(eni, metadataResult.TagMap[eni.ENIID])); err == nil {
 				log.Infof("ENI %s set up.", eni.ENIID)
 				break
 			}
@@ -471,11 +570,25 @@ func (c *IPAMContext) nodeInit() error {
 		}
 	}
 
-	if err := c.dataStore.ReadBackingStore(c.enableIPv6); err != nil {
+	if err := c.dataStore.ReadBackingStore(); err != nil {
 		return err
 	}
 
-	if c.enableIPv6 {
+	if c.enableENIConfigStatus && (c.useCustomNetworking || c.eniConfigPools != nil) {
+		// Validate the ENIConfigs in the background, jittered so that nodes do not race for the same status update
+		go wait.JitterUntil(func() {
+			c.updateENIConfigStatuses(ctx)
+		}, eniConfigStatusInterval, 0.2, true, wait.NeverStop)
+	}
+
+	if c.isIPv6Only() {
+		// With custom networking, pods get their IPv6 addresses from an ENI in the ENIConfig subnet. Custom Networking
+		// must be enabled before Security Groups for Pods.
+		if c.useCustomNetworking {
+			if err := c.setupIPv6CustomNetworking(ctx); err != nil {
+				return err
+			}
+		}
 		// Security Groups for Pods cannot be enabled for IPv4 at this point, as Custom Networking must be enabled first.
 		if c.enablePodENI {
 			// Try to patch CNINode with Security Groups for Pods feature.
@@ -483,10 +596,10 @@ func (c *IPAMContext) nodeInit() error {
 		}
 		// We will not support upgrading/converting an existing IPv4 cluster to operate in IPv6 mode. So, we will always
 		// start with a clean slate in IPv6 mode. We also do not have to deal with dynamic update of Prefix Delegation
-		// feature in IPv6 mode as we do not support (yet) a non-PD v6 option. In addition, we do not support custom
-		// networking in IPv6 mode yet, so we will skip the corresponding setup. This will save us from checking
-		// if IPv6 is enabled at multiple places. Once we start supporting these features in IPv6 mode, we can do away
-		// with this check and not change anything else in the below setup.
+		// feature in IPv6 mode as we do not support (yet) a non-PD v6 option. A single IPv6 prefix serves all pods, so
+		// the IP pool does not have to grow either. This will save us from checking if IPv6 is enabled at multiple
+		// places. Once we start supporting these features in IPv6 mode, we can do away with this check and not change
+		// anything else in the below setup.
 		return nil
 	}
 
@@ -540,23 +653,8 @@ func (c *IPAMContext) nodeInit() error {
 	c.maxPods = int(maxPods)
 
 	if c.useCustomNetworking {
-		// When custom networking is enabled and a valid ENIConfig is found, IPAMD patches the CNINode
-		// resource for this instance. The operation is safe as enabling/disabling custom networking
-		// requires terminating the previous instance.
-		eniConfigName, err := eniconfig.GetNodeSpecificENIConfigName(node)
-		if err == nil && eniConfigName != "default" {
-			// If Security Groups for Pods is enabled, the VPC Resource Controller must also know that Custom Networking is enabled
-			if c.enablePodENI {
-				err := c.AddFeatureToCNINode(ctx, rcv1alpha1.CustomNetworking, eniConfigName)
-				if err != nil {
-					log.Errorf("Failed to add feature custom networking into CNINode", err)
-					podENIErrInc("nodeInit")
-					return err
-				}
-				log.Infof("Enabled feature %s in CNINode for node %s if not existing", rcv1alpha1.CustomNetworking, c.myNodeName)
-			}
-		} else {
-			log.Errorf("No ENIConfig could be found for this node", err)
+		if err := c.enableCustomNetworkingFeature(ctx, node); err != nil {
+			return err
 		}
 	}
 
@@ -566,9 +664,9 @@ func (c *IPAMContext) nodeInit() error {
 	}
 
 	// On node init, check if datastore pool needs to be increased. If so, attach CIDRs from existing ENIs and attach new ENIs.
-	datastorePoolTooLow, _ := c.isDatastorePoolTooLow()
+	datastorePoolTooLow, _ := c.isDatastorePoolTooLow(datastore.DefaultENIPool)
 	if !c.disableENIProvisioning && datastorePoolTooLow {
-		if err := c.increaseDatastorePool(ctx); err != nil {
+		if err := c.increaseDatastorePool(ctx, datastore.DefaultENIPool); err != nil {
 			// Note that the only error currently returned by increaseDatastorePool is an error attaching CIDRs (other than insufficient IPs)
 			podENIErrInc("nodeInit")
 			return errors.New("error while trying to increase datastore pool")
@@ -580,11 +678,70 @@ func (c *IPAMContext) nodeInit() error {
 			return errors.New("Failed to attach any ENIs for custom networking")
 		}
 	}
+	// Pods using the additional ENI pools fail until their pool has IPs, but that should not keep the node from becoming ready
+	for _, pool := range c.eniPoolNames() {
+		if pool == datastore.DefaultENIPool {
+			continue
+		}
+		if poolTooLow, _ := c.isDatastorePoolTooLow(pool); !c.disableENIProvisioning && poolTooLow {
+			if err := c.increaseDatastorePool(ctx, pool); err != nil {
+				log.Errorf("Failed to increase ENI pool %s: %v", pool, err)
+			}
+		}
+	}
 
 	log.Debug("node init completed successfully")
 	return nil
 }
 
+// enableCustomNetworkingFeature patches the CNINode resource for this instance when custom networking is enabled and a
+// valid ENIConfig is found. The operation is safe as enabling/disabling custom networking requires terminating the
+// previous instance.
+func (c *IPAMContext) enableCustomNetworkingFeature(ctx context.Context, node corev1.Node) error {
+	eniConfigName, err := eniconfig.GetNodeSpecificENIConfigName(node)
+	if err == nil && eniConfigName != "default" {
+		// If Security Groups for Pods is enabled, the VPC Resource Controller must also know that Custom Networking is enabled
+		if c.enablePodENI {
+			err := c.AddFeatureToCNINode(ctx, rcv1alpha1.CustomNetworking, eniConfigName)
+			if err != nil {
+				log.Errorf("Failed to add feature custom networking into CNINode", err)
+				podENIErrInc("nodeInit")
+				return err
+			}
+			log.Infof("Enabled feature %s in CNINode for node %s if not existing", rcv1alpha1.CustomNetworking, c.myNodeName)
+		}
+	} else {
+		log.Errorf("No ENIConfig could be found for this node", err)
+	}
+	return nil
+}
+
+// setupIPv6CustomNetworking attaches an ENI in the subnet of this node's ENIConfig, unless an ENI found during node
+// init already has an IPv6 prefix. The prefix serves all pods on the node.
+func (c *IPAMContext) setupIPv6CustomNetworking(ctx context.Context) error {
+	node, err := k8sapi.GetNode(ctx, c.k8sClient)
+	if err != nil {
+		log.Errorf("Failed to get node", err)
+		podENIErrInc("nodeInit")
+		return err
+	}
+	if err := c.enableCustomNetworkingFeature(ctx, node); err != nil {
+		return err
+	}
+
+	if c.dataStore.GetIPStats(ipV6AddrFamily).TotalIPs > 0 {
+		return nil
+	}
+	if c.disableENIProvisioning {
+		log.Warnf("No IPv6 prefix found on the ENIs of this node and ENI provisioning is disabled")
+		return nil
+	}
+	if err := c.tryAllocateENI(ctx, datastore.DefaultENIPool); err != nil {
+		return errors.Wrap(err, "ipamd init: failed to attach an ENI for custom networking")
+	}
+	return nil
+}
+
 func (c *IPAMContext) configureIPRulesForPods() error {
 	rules, err := c.networkClient.GetRuleList()
 	if err != nil {
@@ -637,14 +794,16 @@ func (c *IPAMContext) updateCIDRsRulesOnChange(oldVPCCIDRs []string) []string {
 }
 
 func (c *IPAMContext) updateIPStats(unmanaged int) {
-	prometheusmetrics.IpMax.Set(float64(c.maxIPsPerENI * (c.maxENI - unmanaged)))
-	prometheusmetrics.EnisMax.Set(float64(c.maxENI - unmanaged))
+	maxENI := c.poolTargets().MaxENI
+	prometheusmetrics.IpMax.Set(float64(c.maxIPsPerENI * (maxENI - unmanaged)))
+	prometheusmetrics.EnisMax.Set(float64(maxENI - unmanaged))
 }
 
 // StartNodeIPPoolManager monitors the IP pool, add or del them when it is required.
 func (c *IPAMContext) StartNodeIPPoolManager() {
 	// For IPv6, if Security Groups for Pods is enabled, wait until trunk ENI is attached and add it to the datastore.
-	if c.enableIPv6 {
+	// In dual-stack mode, the IPv4 pool is managed as in IPv4 mode.
+	if c.isIPv6Only() {
 		if c.enablePodENI && c.dataStore.GetTrunkENI() == "" {
 			for !c.checkForTrunkENI() {
 				time.Sleep(ipPoolMonitorInterval)
@@ -655,37 +814,69 @@ func (c *IPAMContext) StartNodeIPPoolManager() {
 		return
 	}
 
+	targets := c.poolTargets()
 	log.Infof("IP pool manager - max pods: %d, warm IP target: %d, warm prefix target: %d, warm ENI target: %d, minimum IP target: %d",
-		c.maxPods, c.warmIPTarget, c.warmPrefixTarget, c.warmENITarget, c.minimumIPTarget)
-	sleepDuration := ipPoolMonitorInterval / 2
+		c.maxPods, targets.WarmIPTarget, targets.WarmPrefixTarget, targets.WarmENITarget, targets.MinimumIPTarget)
 	ctx := context.Background()
 	for {
-		if !c.disableENIProvisioning {
-			time.Sleep(sleepDuration)
-			c.updateIPPoolIfRequired(ctx)
-		}
-		time.Sleep(sleepDuration)
-		c.nodeIPPoolReconcile(ctx, nodeIPPoolReconcileInterval)
+		c.RunNodeIPPoolManagerOnce(ctx)
 	}
 }
 
+// RunNodeIPPoolManagerOnce runs one iteration of the IP pool manager, which takes ipPoolMonitorInterval on its clock
+func (c *IPAMContext) RunNodeIPPoolManagerOnce(ctx context.Context) {
+	sleepDuration := ipPoolMonitorInterval / 2
+	c.reloadPoolConfig(ctx)
+	c.applyEffectivePoolSettings(c.now())
+	if !c.disableENIProvisioning {
+		c.clock.Sleep(sleepDuration)
+		c.updateIPPoolIfRequired(ctx)
+	}
+	c.clock.Sleep(sleepDuration)
+	c.nodeIPPoolReconcile(ctx, nodeIPPoolReconcileInterval)
+}
+
+// now returns the time on the clock of the IP pool manager
+func (c *IPAMContext) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
+
 func (c *IPAMContext) updateIPPoolIfRequired(ctx context.Context) {
+	c.updateAdaptiveWarmIPTarget()
 	// When IPv4 Security Groups for Pods is configured, do not write to CNINode until there is room for a trunk ENI
 	if c.enablePodENI && c.enableIPv4 && c.dataStore.GetTrunkENI() == "" {
 		c.tryEnableSecurityGroupsForPods(ctx)
 	}
 
-	datastorePoolTooLow, stats := c.isDatastorePoolTooLow()
-	// Each iteration, log the current datastore IP stats
-	log.Debugf("IP stats - total IPs: %d, assigned IPs: %d, cooldown IPs: %d", stats.TotalIPs, stats.AssignedIPs, stats.CooldownIPs)
+	poolTooHigh := false
+	for _, pool := range c.eniPoolNames() {
+		datastorePoolTooLow, stats := c.isDatastorePoolTooLow(pool)
+		// Each iteration, log the current datastore IP stats
+		log.Debugf("IP stats - ENI pool: %q, total IPs: %d, assigned IPs: %d, cooldown IPs: %d", pool, stats.TotalIPs, stats.AssignedIPs, stats.CooldownIPs)
 
-	if datastorePoolTooLow {
-		c.increaseDatastorePool(ctx)
-	} else if c.isDatastorePoolTooHigh(stats) {
+		if datastorePoolTooLow {
+			c.increaseDatastorePool(ctx, pool)
+		} else if c.isDatastorePoolTooHigh(pool, stats) {
+			poolTooHigh = true
+		} else {
+			decision := c.newPoolDecision("updateIPPoolIfRequired", pool, stats)
+			decision.Reason = "available IPs meet the warm targets"
+			if c.nodeIPsCoverMaxPods(stats) {
+				decision.Reason = fmt.Sprintf("node has IPs for max pods (%d)", c.maxPods)
+			}
+			c.recordPoolDecision(decision)
+		}
+	}
+	if poolTooHigh {
 		c.decreaseDatastorePool(decreaseIPPoolInterval)
 	}
-	if c.shouldRemoveExtraENIs() {
-		c.tryFreeENI()
+	for _, pool := range c.dataStore.GetENIPools() {
+		if !c.isENIPoolConfigured(pool) || c.shouldRemoveExtraENIs(pool) {
+			c.tryFreeENI(pool)
+		}
 	}
 }
 
@@ -695,10 +886,13 @@ func (c *IPAMContext) decreaseDatastorePool(interval time.Duration) {
 	prometheusmetrics.IpamdActionsInprogress.WithLabelValues("decreaseDatastorePool").Add(float64(1))
 	defer prometheusmetrics.IpamdActionsInprogress.WithLabelValues("decreaseDatastorePool").Sub(float64(1))
 
-	now := time.Now()
+	now := c.now()
 	timeSinceLast := now.Sub(c.lastDecreaseIPPool)
 	if timeSinceLast <= interval {
 		log.Debugf("Skipping decrease Datastore pool because time since last %v <= %v", timeSinceLast, interval)
+		decision := c.newPoolDecision("decreaseDatastorePool", datastore.DefaultENIPool, c.dataStore.GetIPStats(ipV4AddrFamily))
+		decision.Reason = fmt.Sprintf("last decrease was less than %v ago", interval)
+		c.recordPoolDecision(decision)
 		return
 	}
 
@@ -711,25 +905,38 @@ func (c *IPAMContext) decreaseDatastorePool(interval time.Duration) {
 	c.logPoolStats(c.dataStore.GetIPStats(ipV4AddrFamily))
 }
 
-// tryFreeENI always tries to free one ENI
-func (c *IPAMContext) tryFreeENI() {
+// tryFreeENI always tries to free one ENI of the given ENI pool
+func (c *IPAMContext) tryFreeENI(pool string) {
+	decision := c.newPoolDecision("tryFreeENI", pool, c.dataStore.GetENIPoolIPStats(pool))
+	defer c.recordPoolDecision(decision)
+
 	if c.isTerminating() {
 		log.Debug("AWS CNI is terminating, not detaching any ENIs")
+		decision.Reason = "AWS CNI is terminating"
 		return
 	}
 
 	if !c.manageENIsNonScheduleable && c.isNodeNonSchedulable() {
 		log.Debug("AWS CNI is on a non schedulable node, not detaching any ENIs")
+		decision.Reason = "node is not schedulable"
 		return
 	}
 
-	eni := c.dataStore.RemoveUnusedENIFromStore(c.warmIPTarget, c.minimumIPTarget, c.warmPrefixTarget)
+	targets := c.poolTargets()
+	warmIPTarget, minimumIPTarget, warmPrefixTarget := targets.WarmIPTarget, targets.MinimumIPTarget, targets.WarmPrefixTarget
+	if !c.isENIPoolConfigured(pool) {
+		// The pool was removed from ENI_CONFIG_POOLS, its ENIs are freed once they have no pods left
+		warmIPTarget, minimumIPTarget, warmPrefixTarget = 0, 0, 0
+	}
+	eni := c.dataStore.RemoveUnusedENIFromStore(pool, warmIPTarget, minimumIPTarget, warmPrefixTarget)
 	if eni == "" {
+		decision.Reason = "no ENI can be freed without going below the warm targets"
 		return
 	}
 
 	log.Debugf("Start freeing ENI %s", eni)
 	err := c.awsClient.FreeENI(eni)
+	decision.setResult(poolActionFreeENI, fmt.Sprintf("ENI %s is not needed for the warm targets", eni), err)
 	if err != nil {
 		ipamdErrInc("decreaseIPPoolFreeENIFailed")
 		log.Errorf("Failed to free ENI %s, err: %v", eni, err)
@@ -740,15 +947,32 @@ func (c *IPAMContext) tryFreeENI() {
 
 // When warm IP/prefix targets are defined, free extra IPs
 func (c *IPAMContext) tryUnassignCidrsFromAll() {
-	_, over, warmIPTargetsDefined := c.datastoreTargetState(nil)
+	for _, pool := range c.eniPoolNames() {
+		c.tryUnassignCidrsFromPool(pool)
+	}
+}
+
+// tryUnassignCidrsFromPool frees the IPs of the given ENI pool over its warm IP/prefix targets
+func (c *IPAMContext) tryUnassignCidrsFromPool(pool string) {
+	decision := c.newPoolDecision("tryUnassignCidrsFromPool", pool, c.dataStore.GetENIPoolIPStats(pool))
+	defer c.recordPoolDecision(decision)
+
+	_, over, warmIPTargetsDefined := c.datastoreTargetState(pool, nil)
 	// If WARM IP targets are not defined, check if WARM_PREFIX_TARGET is defined.
 	if !warmIPTargetsDefined {
-		over = c.computeExtraPrefixesOverWarmTarget()
+		over = c.computeExtraPrefixesOverWarmTarget(pool)
 	}
 
+	decision.Reason = "no IPs or prefixes over the warm targets"
 	if over > 0 {
+		decision.Reason = fmt.Sprintf("%d IPs or prefixes over the warm targets are in use or in cooldown", over)
+		released := 0
+		var deallocErr error
 		eniInfos := c.dataStore.GetENIInfos()
-		for eniID := range eniInfos.ENIs {
+		for eniID, eni := range eniInfos.ENIs {
+			if eni.Pool != pool {
+				continue
+			}
 			// Either returns prefixes or IPs [Cidrs]
 			cidrs := c.dataStore.FindFreeableCidrs(eniID)
 			if cidrs == nil {
@@ -780,53 +1004,73 @@ func (c *IPAMContext) tryUnassignCidrsFromAll() {
 			}
 
 			// Deallocate Cidrs from the instance if they are not used by pods.
-			c.DeallocCidrs(eniID, deletedCidrs)
+			if err := c.DeallocCidrs(eniID, deletedCidrs); err != nil && deallocErr == nil {
+				deallocErr = err
+			}
+			released += len(deletedCidrs)
 
 			// reduce the deallocation target, if the deallocation target is achieved, we can exit
 			if over = over - len(deletedCidrs); over <= 0 {
 				break
 			}
 		}
+		if released > 0 {
+			decision.setResult(poolActionUnassignCidrs, fmt.Sprintf("released %d IPs or prefixes over the warm targets", released), deallocErr)
+		}
 	}
 }
 
-// PRECONDITION: isDatastorePoolTooLow returned true
-func (c *IPAMContext) increaseDatastorePool(ctx context.Context) error {
+// PRECONDITION: isDatastorePoolTooLow returned true for the ENI pool
+func (c *IPAMContext) increaseDatastorePool(ctx context.Context, pool string) error {
 	log.Debug("Starting to increase pool size")
 	prometheusmetrics.IpamdActionsInprogress.WithLabelValues("increaseDatastorePool").Add(float64(1))
 	defer prometheusmetrics.IpamdActionsInprogress.WithLabelValues("increaseDatastorePool").Sub(float64(1))
 
+	decision := c.newPoolDecision("increaseDatastorePool", pool, c.dataStore.GetENIPoolIPStats(pool))
+	defer c.recordPoolDecision(decision)
+
 	if c.isTerminating() {
 		log.Debug("AWS CNI is terminating, will not try to attach any new IPs or ENIs right now")
+		decision.Reason = "AWS CNI is terminating"
 		return nil
 	}
 	if !c.manageENIsNonScheduleable && c.isNodeNonSchedulable() {
 		log.Debug("AWS CNI is on a non schedulable node, will not try to attach any new IPs or ENIs right now")
+		decision.Reason = "node is not schedulable"
 		return nil
 	}
 
 	// Try to add more Cidrs to existing ENIs first.
 	if c.inInsufficientCidrCoolingPeriod() {
 		log.Debugf("Recently we had InsufficientCidr error hence will wait for %v before retrying", insufficientCidrErrorCooldown)
+		decision.Reason = fmt.Sprintf("waiting %v after the subnet ran out of IPs or prefixes", insufficientCidrErrorCooldown)
 		return nil
 	}
 
-	increasedPool, err := c.tryAssignCidrs()
+	increasedPool, err := c.tryAssignCidrs(pool)
 	if err != nil {
 		if containsInsufficientCIDRsOrSubnetIPs(err) {
 			log.Errorf("Unable to attach IPs/Prefixes for the ENI, subnet doesn't seem to have enough IPs/Prefixes. Consider using new subnet or carve a reserved range using create-subnet-cidr-reservation")
-			c.lastInsufficientCidrError = time.Now()
+			decision.setResult(poolActionAssignCidrs, "subnet has no free IPs or prefixes", err)
+			c.lastInsufficientCidrError = c.now()
 			return nil
 		}
 		log.Errorf(err.Error())
+		decision.setResult(poolActionAssignCidrs, "", err)
 		return err
 	}
 	if increasedPool {
+		decision.setResult(poolActionAssignCidrs, "added IPs or prefixes to an existing ENI", nil)
 		c.updateLastNodeIPPoolAction()
 	} else {
 		// If we did not add any IPs, try to allocate an ENI.
-		if c.hasRoomForEni() {
-			if err = c.tryAllocateENI(ctx); err == nil {
+		if c.awsClient.IsIMDSDegraded() {
+			log.Infof("Skipping ENI allocation while instance metadata is served from the IMDS cache")
+			decision.Reason = errIMDSDegraded.Error()
+		} else if c.hasRoomForEni(pool) {
+			err = c.tryAllocateENI(ctx, pool)
+			decision.setResult(poolActionAllocateENI, "no existing ENI has room for more IPs or prefixes", err)
+			if err == nil {
 				c.updateLastNodeIPPoolAction()
 			} else {
 				// Note that no error is returned if ENI allocation fails. This is because ENI allocation failure should not cause node to be "NotReady".
@@ -834,39 +1078,58 @@ func (c *IPAMContext) increaseDatastorePool(ctx context.Context) error {
 			}
 		} else {
 			log.Debugf("Skipping ENI allocation as the max ENI limit is already reached")
+			decision.Reason = fmt.Sprintf("no existing ENI has room and the max ENI limit (%d) is reached", c.poolTargets().MaxENI)
 		}
 	}
 	return nil
 }
 
 func (c *IPAMContext) updateLastNodeIPPoolAction() {
-	c.lastNodeIPPoolAction = time.Now()
+	c.lastNodeIPPoolAction = c.now()
 	stats := c.dataStore.GetIPStats(ipV4AddrFamily)
 	c.logPoolStats(stats)
 }
 
-func (c *IPAMContext) tryAllocateENI(ctx context.Context) error {
+func (c *IPAMContext) tryAllocateENI(ctx context.Context, pool string) error {
+	if c.awsClient.IsIMDSDegraded() {
+		return errIMDSDegraded
+	}
 	var securityGroups []*string
-	var eniCfgSubnet string
+	var eniCfgSubnets []string
 
 	if c.useCustomNetworking {
-		eniCfg, err := eniconfig.MyENIConfig(ctx, c.k8sClient)
+		var eniCfg *v1alpha1.ENIConfigSpec
+		var err error
+		if pool == datastore.DefaultENIPool || isNetworkCardPool(pool) {
+			eniCfg, err = eniconfig.MyENIConfig(ctx, c.k8sClient)
+		} else {
+			eniCfg, err = eniconfig.GetENIConfig(ctx, c.k8sClient, pool)
+		}
 		if err != nil {
 			log.Errorf("Failed to get pod ENI config")
 			return err
 		}
 
-		log.Infof("ipamd: using custom network config: %v, %s", eniCfg.SecurityGroups, eniCfg.Subnet)
+		eniCfgSubnets = eniCfg.SubnetIDs()
+		log.Infof("ipamd: using custom network config: %v, %v", eniCfg.SecurityGroups, eniCfgSubnets)
 		for _, sgID := range eniCfg.SecurityGroups {
 			log.Debugf("Found security-group id: %s", sgID)
 			securityGroups = append(securityGroups, aws.String(sgID))
 		}
-		eniCfgSubnet = eniCfg.Subnet
 	}
 
-	resourcesToAllocate := c.GetENIResourcesToAllocate()
+	resourcesToAllocate := c.GetENIResourcesToAllocate(pool)
 	if resourcesToAllocate > 0 {
-		eni, err := c.awsClient.AllocENI(c.useCustomNetworking, securityGroups, eniCfgSubnet, resourcesToAllocate)
+		var eni string
+		var err error
+		if networkCard, ok := networkCardOfPool(pool); ok {
+			eni, err = c.awsClient.AllocENIOnNetworkCard(c.useCustomNetworking, securityGroups, eniCfgSubnets, resourcesToAllocate, nil, networkCard)
+		} else if pool == datastore.DefaultENIPool {
+			eni, err = c.awsClient.AllocENI(c.useCustomNetworking, securityGroups, eniCfgSubnets, resourcesToAllocate)
+		} else {
+			eni, err = c.awsClient.AllocENIWithTags(c.useCustomNetworking, securityGroups, eniCfgSubnets, resourcesToAllocate,
+				map[string]string{eniConfigPoolTagKey: pool})
+		}
 		if err != nil {
 			log.Errorf("Failed to increase pool size due to not able to allocate ENI %v", err)
 			ipamdErrInc("increaseIPPoolAllocENI")
@@ -874,11 +1137,20 @@ func (c *IPAMContext) tryAllocateENI(ctx context.Context) error {
 			if containsInsufficientCIDRsOrSubnetIPs(err) {
 				ipamdErrInc("increaseIPPoolAllocIPAddressesFailed")
 				log.Errorf("Unable to attach IPs/Prefixes for the ENI, subnet doesn't seem to have enough IPs/Prefixes. Consider using new subnet or carve a reserved range using create-subnet-cidr-reservation")
-				c.lastInsufficientCidrError = time.Now()
+				c.lastInsufficientCidrError = c.now()
 			}
 			return err
 		}
 
+		if c.isIPv6Only() {
+			// IPv6 ENIs are created without prefixes, the ENI is set up once its prefix shows up in the metadata
+			if _, err := c.awsClient.AllocIPv6Prefixes(eni); err != nil {
+				ipamdErrInc("increaseIPPoolAllocIPv6PrefixFailed")
+				log.Errorf("Failed to increase pool size: Unable to allocate an IPv6 prefix to ENI %s: %v", eni, err)
+				return err
+			}
+		}
+
 		eniMetadata, err := c.awsClient.WaitForENIAndIPsAttached(eni, resourcesToAllocate)
 		if err != nil {
 			ipamdErrInc("increaseIPPoolwaitENIAttachedFailed")
@@ -887,7 +1159,7 @@ func (c *IPAMContext) tryAllocateENI(ctx context.Context) error {
 		}
 
 		// The CNI does not create trunk or EFA ENIs, so they will always be false here
-		err = c.setupENI(eni, eniMetadata, false, false)
+		err = c.setupENI(eni, eniMetadata, false, false, pool)
 		if err != nil {
 			ipamdErrInc("increaseIPPoolsetupENIFailed")
 			log.Errorf("Failed to increase pool size: %v", err)
@@ -899,22 +1171,23 @@ func (c *IPAMContext) tryAllocateENI(ctx context.Context) error {
 	return nil
 }
 
-// For an ENI, fill in missing IPs or prefixes.
+// For the ENIs of the pool, fill in missing IPs or prefixes. Concurrent requests for the pool are coalesced.
 // PRECONDITION: isDatastorePoolTooLow returned true
-func (c *IPAMContext) tryAssignCidrs() (increasedPool bool, err error) {
-	if c.enablePrefixDelegation {
-		return c.tryAssignPrefixes()
-	} else {
-		return c.tryAssignIPs()
-	}
+func (c *IPAMContext) tryAssignCidrs(pool string) (increasedPool bool, err error) {
+	return c.allocations.do(pool, func() (bool, error) {
+		if c.enablePrefixDelegation {
+			return c.tryAssignPrefixes(pool)
+		}
+		return c.tryAssignIPs(pool)
+	})
 }
 
-// For an ENI, try to fill in missing IPs on an existing ENI.
+// For the ENIs of the pool, try to fill in missing IPs on existing ENIs.
 // PRECONDITION: isDatastorePoolTooLow returned true
-func (c *IPAMContext) tryAssignIPs() (increasedPool bool, err error) {
+func (c *IPAMContext) tryAssignIPs(pool string) (increasedPool bool, err error) {
 
 	// If WARM_IP_TARGET is set, only proceed if we are short of target
-	short, _, warmIPTargetsDefined := c.datastoreTargetState(nil)
+	short, _, warmIPTargetsDefined := c.datastoreTargetState(pool, nil)
 	if warmIPTargetsDefined && short == 0 {
 		return false, nil
 	}
@@ -925,53 +1198,9 @@ func (c *IPAMContext) tryAssignIPs() (increasedPool bool, err error) {
 		toAllocate = short
 	}
 
-	// Find an ENI where we can add more IPs
+	// Spread the IPs over the ENIs where we can add more IPs
 	enis := c.dataStore.GetAllocatableENIs(c.maxIPsPerENI, c.useCustomNetworking)
-	for _, eni := range enis {
-		if len(eni.AvailableIPv4Cidrs) < c.maxIPsPerENI {
-			currentNumberOfAllocatedIPs := len(eni.AvailableIPv4Cidrs)
-			// Try to allocate all available IPs for this ENI
-			resourcesToAllocate := min((c.maxIPsPerENI - currentNumberOfAllocatedIPs), toAllocate)
-			output, err := c.awsClient.AllocIPAddresses(eni.ID, resourcesToAllocate)
-			if err != nil && !containsPrivateIPAddressLimitExceededError(err) {
-				log.Warnf("failed to allocate all available IP addresses on ENI %s, err: %v", eni.ID, err)
-				// Try to just get one more IP
-				output, err = c.awsClient.AllocIPAddresses(eni.ID, 1)
-				if err != nil && !containsPrivateIPAddressLimitExceededError(err) {
-					ipamdErrInc("increaseIPPoolAllocIPAddressesFailed")
-					if c.useSubnetDiscovery && containsInsufficientCIDRsOrSubnetIPs(err) {
-						continue
-					}
-					return false, errors.Wrap(err, fmt.Sprintf("failed to allocate one IP addresses on ENI %s, err ", eni.ID))
-				}
-			}
-
-			var ec2ip4s []ec2types.NetworkInterfacePrivateIpAddress
-			if containsPrivateIPAddressLimitExceededError(err) {
-				log.Debug("AssignPrivateIpAddresses returned PrivateIpAddressLimitExceeded. This can happen if the data store is out of sync." +
-					"Returning without an error here since we will verify the actual state by calling EC2 to see what addresses have already assigned to this ENI.")
-				// This call to EC2 is needed to verify which IPs got attached to this ENI.
-				ec2ip4s, err = c.awsClient.GetIPv4sFromEC2(eni.ID)
-				if err != nil {
-					ipamdErrInc("increaseIPPoolGetENIaddressesFailed")
-					return true, errors.Wrap(err, "failed to get ENI IP addresses during IP allocation")
-				}
-			} else {
-				if output == nil {
-					ipamdErrInc("increaseIPPoolGetENIaddressesFailed")
-					return true, errors.Wrap(err, "failed to get ENI IP addresses during IP allocation")
-				}
-
-				ec2Addrs := output.AssignedPrivateIpAddresses
-				for _, ec2Addr := range ec2Addrs {
-					ec2ip4s = append(ec2ip4s, ec2types.NetworkInterfacePrivateIpAddress{PrivateIpAddress: ec2Addr.PrivateIpAddress})
-				}
-			}
-			c.addENIsecondaryIPsToDataStore(ec2ip4s, eni.ID)
-			return true, nil
-		}
-	}
-	return false, nil
+	return c.assignCidrsToENIs(planENIAllocations(enis, pool, c.maxIPsPerENI, toAllocate), false)
 }
 
 func (c *IPAMContext) assignIPv6Prefix(eniID string) (err error) {
@@ -1014,103 +1243,77 @@ func (c *IPAMContext) assignIPv6Prefix(eniID string) (err error) {
 }
 
 // PRECONDITION: isDatastorePoolTooLow returned true
-func (c *IPAMContext) tryAssignPrefixes() (increasedPool bool, err error) {
-	toAllocate := c.getPrefixesNeeded()
-	// Returns an ENI which has space for more prefixes to be attached, but this
-	// ENI might not suffice the WARM_IP_TARGET/WARM_PREFIX_TARGET
+func (c *IPAMContext) tryAssignPrefixes(pool string) (increasedPool bool, err error) {
+	toAllocate := c.getPrefixesNeeded(pool)
+	// Returns the ENIs which have space for more prefixes to be attached
 	enis := c.dataStore.GetAllocatableENIs(c.maxPrefixesPerENI, c.useCustomNetworking)
-	for _, eni := range enis {
-		currentNumberOfAllocatedPrefixes := len(eni.AvailableIPv4Cidrs)
-		resourcesToAllocate := min((c.maxPrefixesPerENI - currentNumberOfAllocatedPrefixes), toAllocate)
-		output, err := c.awsClient.AllocIPAddresses(eni.ID, resourcesToAllocate)
-		if err != nil && !containsPrivateIPAddressLimitExceededError(err) {
-			log.Warnf("failed to allocate all available IPv4 Prefixes on ENI %s, err: %v", eni.ID, err)
-			// Try to just get one more prefix
-			output, err = c.awsClient.AllocIPAddresses(eni.ID, 1)
-			if err != nil && !containsPrivateIPAddressLimitExceededError(err) {
-				ipamdErrInc("increaseIPPoolAllocIPAddressesFailed")
-				if c.useSubnetDiscovery && containsInsufficientCIDRsOrSubnetIPs(err) {
-					continue
-				}
-				return false, errors.Wrap(err, fmt.Sprintf("failed to allocate one IPv4 prefix on ENI %s, err: %v", eni.ID, err))
-			}
-		}
-
-		var ec2Prefixes []ec2types.Ipv4PrefixSpecification
-		if containsPrivateIPAddressLimitExceededError(err) {
-			log.Debug("AssignPrivateIpAddresses returned PrivateIpAddressLimitExceeded. This can happen if the data store is out of sync." +
-				"Returning without an error here since we will verify the actual state by calling EC2 to see what addresses have already assigned to this ENI.")
-			// This call to EC2 is needed to verify which IPs got attached to this ENI.
-			ec2Prefixes, err = c.awsClient.GetIPv4PrefixesFromEC2(eni.ID)
-			if err != nil {
-				ipamdErrInc("increaseIPPoolGetENIaddressesFailed")
-				return true, errors.Wrap(err, "failed to get ENI IP addresses during IP allocation")
-			}
-		} else {
-			if output == nil {
-				ipamdErrInc("increaseIPPoolGetENIprefixedFailed")
-				return true, errors.Wrap(err, "failed to get ENI Prefix addresses during IPv4 Prefix allocation")
-			}
-			ec2Prefixes = output.AssignedIpv4Prefixes
-		}
-		c.addENIv4prefixesToDataStore(ec2Prefixes, eni.ID)
-		return true, nil
-	}
-	return false, nil
+	return c.assignCidrsToENIs(planENIAllocations(enis, pool, c.maxPrefixesPerENI, toAllocate), true)
 }
 
 // setupENI does following:
 // 1) add ENI to datastore
 // 2) set up linux ENI related networking stack.
 // 3) add all ENI's secondary IP addresses to datastore
-func (c *IPAMContext) setupENI(eni string, eniMetadata awsutils.ENIMetadata, isTrunkENI, isEFAENI bool) error {
+func (c *IPAMContext) setupENI(eni string, eniMetadata awsutils.ENIMetadata, isTrunkENI, isEFAENI bool, pool string) error {
 	primaryENI := c.awsClient.GetPrimaryENI()
 	// Add the ENI to the datastore
-	err := c.dataStore.AddENI(eni, eniMetadata.DeviceNumber, eni == primaryENI, isTrunkENI, isEFAENI)
+	// ENIs on other network cards reuse the device numbers of the default card, so they get their own range for route tables
+	deviceNumber := routeDeviceNumber(eniMetadata)
+	err := c.dataStore.AddENI(eni, deviceNumber, eni == primaryENI, isTrunkENI, isEFAENI)
 	if err != nil && err.Error() != datastore.DuplicatedENIError {
 		return errors.Wrapf(err, "failed to add ENI %s to data store", eni)
 	}
+	if pool != datastore.DefaultENIPool {
+		if err := c.dataStore.SetENIPool(eni, pool); err != nil {
+			return errors.Wrapf(err, "failed to add ENI %s to ENI pool %s", eni, pool)
+		}
+	}
 	// Store the addressable IP for the ENI
-	if c.enableIPv6 {
+	if c.isIPv6Only() {
 		c.primaryIP[eni] = eniMetadata.PrimaryIPv6Address()
 	} else {
 		c.primaryIP[eni] = eniMetadata.PrimaryIPv4Address()
 	}
 
-	if c.enableIPv6 && eni == primaryENI {
-		// In v6 PD mode, VPC CNI will only manage the primary ENI and trunk ENI. Once we start supporting secondary
-		// IP and custom networking modes for IPv6, this restriction can be relaxed.
+	if c.enableIPv6 && eni == primaryENI && !c.useCustomNetworking {
+		// In v6 PD mode, VPC CNI will only manage the primary ENI and trunk ENI. In dual-stack mode, the IPv6 prefix
+		// of the primary ENI serves the IPv6 addresses of all pods, while their IPv4 addresses come from any ENI.
+		// With custom networking, pods get their IPv6 addresses from ENIs in the ENIConfig subnet instead.
 		err := c.assignIPv6Prefix(eni)
 		if err != nil {
 			return errors.Wrapf(err, "Failed to allocate IPv6 Prefixes to Primary ENI")
 		}
-	} else {
-		// For other ENIs, set up the network
-		if eni != primaryENI {
-			subnetCidr := eniMetadata.SubnetIPv4CIDR
-			if c.enableIPv6 {
-				subnetCidr = eniMetadata.SubnetIPv6CIDR
-			}
-			err = c.networkClient.SetupENINetwork(c.primaryIP[eni], eniMetadata.MAC, eniMetadata.DeviceNumber, subnetCidr)
-			if err != nil {
-				// Failed to set up the ENI
-				errRemove := c.dataStore.RemoveENIFromDataStore(eni, true)
-				if errRemove != nil {
-					log.Warnf("failed to remove ENI %s: %v", eni, errRemove)
-				}
-				delete(c.primaryIP, eni)
-				return errors.Wrapf(err, "failed to set up ENI %s network", eni)
+	}
+	// For other ENIs, set up the network
+	if eni != primaryENI {
+		subnetCidr := eniMetadata.SubnetIPv4CIDR
+		if c.isIPv6Only() {
+			subnetCidr = eniMetadata.SubnetIPv6CIDR
+		}
+		err = c.networkClient.SetupENINetwork(c.primaryIP[eni], eniMetadata.MAC, deviceNumber, subnetCidr)
+		if err != nil {
+			// Failed to set up the ENI
+			errRemove := c.dataStore.RemoveENIFromDataStore(eni, true)
+			if errRemove != nil {
+				log.Warnf("failed to remove ENI %s: %v", eni, errRemove)
 			}
+			delete(c.primaryIP, eni)
+			return errors.Wrapf(err, "failed to set up ENI %s network", eni)
 		}
-		if !c.enableIPv6 {
-			log.Infof("Found ENIs having %d secondary IPs and %d Prefixes", len(eniMetadata.IPv4Addresses), len(eniMetadata.IPv4Prefixes))
-			// Either case add the IPs and prefixes to datastore.
-			c.addENIsecondaryIPsToDataStore(eniMetadata.IPv4Addresses, eni)
-			c.addENIv4prefixesToDataStore(eniMetadata.IPv4Prefixes, eni)
-		} else {
-			// This is a trunk ENI in IPv6 PD mode, so do not add IPs or prefixes to datastore
-			log.Infof("Found IPv6 trunk ENI having %d secondary IPs and %d Prefixes", len(eniMetadata.IPv6Addresses), len(eniMetadata.IPv6Prefixes))
+	}
+	if !c.isIPv6Only() {
+		log.Infof("Found ENIs having %d secondary IPs and %d Prefixes", len(eniMetadata.IPv4Addresses), len(eniMetadata.IPv4Prefixes))
+		// Either case add the IPs and prefixes to datastore.
+		c.addENIsecondaryIPsToDataStore(eniMetadata.IPv4Addresses, eni)
+		c.addENIv4prefixesToDataStore(eniMetadata.IPv4Prefixes, eni)
+	} else if eni != primaryENI && c.useCustomNetworking && !isTrunkENI {
+		// This is an ENI in the ENIConfig subnet, its IPv6 prefix serves the pods
+		if err := c.assignIPv6Prefix(eni); err != nil {
+			return errors.Wrapf(err, "failed to allocate IPv6 prefix to ENI %s", eni)
 		}
+	} else if eni != primaryENI {
+		// This is a trunk ENI in IPv6 PD mode, so do not add IPs or prefixes to datastore
+		log.Infof("Found IPv6 trunk ENI having %d secondary IPs and %d Prefixes", len(eniMetadata.IPv6Addresses), len(eniMetadata.IPv6Prefixes))
 	}
 	return nil
 }
@@ -1179,8 +1382,11 @@ func (c *IPAMContext) addENIv6prefixesToDataStore(ec2PrefixAddrs []ec2types.Ipv6
 // the limit for the instance type and the value configured via the MAX_ENI environment variable. If the value of
 // the environment variable is 0 or less, it will be ignored and the maximum for the instance is returned.
 func (c *IPAMContext) getMaxENI() (int, error) {
-	instanceMaxENI := c.awsClient.GetENILimit()
+	return c.limitMaxENI(getEnvMaxENI()), nil
+}
 
+// getEnvMaxENI returns the value of the MAX_ENI environment variable, or defaultMaxENI when it is not set or less than 1
+func getEnvMaxENI() int {
 	inputStr, found := os.LookupEnv(envMaxENI)
 	envMax := defaultMaxENI
 	if found {
@@ -1189,11 +1395,16 @@ func (c *IPAMContext) getMaxENI() (int, error) {
 			envMax = input
 		}
 	}
+	return envMax
+}
 
-	if envMax >= 1 && envMax < instanceMaxENI {
-		return envMax, nil
+// limitMaxENI returns the lesser of maxENI and the limit for the instance type. A maxENI less than 1 is ignored.
+func (c *IPAMContext) limitMaxENI(maxENI int) int {
+	instanceMaxENI := c.awsClient.GetENILimit()
+	if maxENI >= 1 && maxENI < instanceMaxENI {
+		return maxENI
 	}
-	return instanceMaxENI, nil
+	return instanceMaxENI
 }
 
 func getWarmENITarget() int {
@@ -1241,7 +1452,7 @@ func (c *IPAMContext) logPoolStats(dataStoreStats *datastore.DataStoreStats) {
 
 func (c *IPAMContext) tryEnableSecurityGroupsForPods(ctx context.Context) {
 	// For IPv4, check that there is room for a trunk ENI before patching CNINode CRD
-	if c.enableIPv4 && (c.dataStore.GetENIs() >= (c.maxENI - c.unmanagedENI)) {
+	if c.enableIPv4 && (c.dataStore.GetENIs() >= (c.poolTargets().MaxENI - c.unmanagedENI)) {
 		log.Error("No slot available for a trunk ENI to be attached.")
 		return
 	}
@@ -1260,21 +1471,22 @@ func (c *IPAMContext) tryEnableSecurityGroupsForPods(ctx context.Context) {
 // PD enabled: If the WARM_PREFIX_TARGET is spread across ENIs and we have more than needed, this function will return true.
 // If the number of prefixes are on just one ENI, and there are more than available, it returns true so getDeletableENI will
 // recheck if we need the ENI for prefix target.
-func (c *IPAMContext) shouldRemoveExtraENIs() bool {
+func (c *IPAMContext) shouldRemoveExtraENIs(pool string) bool {
 	// When WARM_IP_TARGET is set, return true as verification is always done in getDeletableENI()
 	if c.warmIPTargetsDefined() {
 		return true
 	}
 
-	stats := c.dataStore.GetIPStats(ipV4AddrFamily)
+	stats := c.dataStore.GetENIPoolIPStats(pool)
 	available := stats.AvailableAddresses()
 	var shouldRemoveExtra bool
 
 	// We need the +1 to make sure we are not going below the WARM_ENI_TARGET/WARM_PREFIX_TARGET
-	warmTarget := (c.warmENITarget + 1)
+	targets := c.poolTargets()
+	warmTarget := (targets.WarmENITarget + 1)
 
 	if c.enablePrefixDelegation {
-		warmTarget = (c.warmPrefixTarget + 1)
+		warmTarget = (targets.WarmPrefixTarget + 1)
 	}
 
 	shouldRemoveExtra = available >= (warmTarget)*c.maxIPsPerENI
@@ -1284,21 +1496,22 @@ func (c *IPAMContext) shouldRemoveExtraENIs() bool {
 	} else if c.enablePrefixDelegation {
 		// When prefix target count is reduced, datastore would have deleted extra prefixes over the warm prefix target.
 		// Hence available will be less than (warmTarget)*c.maxIPsPerENI, but there can be some extra ENIs which are not used hence see if we can clean it up.
-		shouldRemoveExtra = c.dataStore.CheckFreeableENIexists()
+		shouldRemoveExtra = c.dataStore.CheckFreeableENIexists(pool)
 	}
 	return shouldRemoveExtra
 }
 
-func (c *IPAMContext) computeExtraPrefixesOverWarmTarget() int {
+func (c *IPAMContext) computeExtraPrefixesOverWarmTarget(pool string) int {
 	if !c.warmPrefixTargetDefined() {
 		return 0
 	}
 
-	freePrefixes := c.dataStore.GetFreePrefixes()
-	over := max(freePrefixes-c.warmPrefixTarget, 0)
+	warmPrefixTarget := c.poolTargets().WarmPrefixTarget
+	freePrefixes := c.dataStore.GetFreePrefixes(pool)
+	over := max(freePrefixes-warmPrefixTarget, 0)
 
-	stats := c.dataStore.GetIPStats(ipV4AddrFamily)
-	log.Debugf("computeExtraPrefixesOverWarmTarget - available: %d, over: %d, warm_prefix_target: %d", stats.AvailableAddresses(), over, c.warmPrefixTarget)
+	stats := c.dataStore.GetENIPoolIPStats(pool)
+	log.Debugf("computeExtraPrefixesOverWarmTarget - available: %d, over: %d, warm_prefix_target: %d", stats.AvailableAddresses(), over, warmPrefixTarget)
 	c.logPoolStats(stats)
 	return over
 }
@@ -1321,7 +1534,7 @@ func (c *IPAMContext) checkForTrunkENI() bool {
 	if metadataResult.TrunkENI != "" {
 		for _, eni := range metadataResult.ENIMetadata {
 			if eni.ENIID == metadataResult.TrunkENI {
-				if err := c.setupENI(eni.ENIID, eni, true, false); err == nil {
+				if err := c.setupENI(eni.ENIID, eni, true, false, datastore.DefaultENIPool); err == nil {
 					log.Infof("ENI %s set up", eni.ENIID)
 					return true
 				} else {
@@ -1337,7 +1550,7 @@ func (c *IPAMContext) checkForTrunkENI() bool {
 // nodeIPPoolReconcile reconcile ENI and IP info from metadata service and IP addresses in datastore
 func (c *IPAMContext) nodeIPPoolReconcile(ctx context.Context, interval time.Duration) {
 	// To reduce the number of EC2 API calls, skip reconciliation if IPs were recently added to the datastore.
-	timeSinceLast := time.Since(c.lastNodeIPPoolAction)
+	timeSinceLast := c.now().Sub(c.lastNodeIPPoolAction)
 	// Make an exception if node needs a trunk ENI and one is not currently attached.
 	needsTrunkEni := c.enablePodENI && c.dataStore.GetTrunkENI() == ""
 	if timeSinceLast <= interval && !needsTrunkEni {
@@ -1347,7 +1560,16 @@ func (c *IPAMContext) nodeIPPoolReconcile(ctx context.Context, interval time.Dur
 	prometheusmetrics.IpamdActionsInprogress.WithLabelValues("nodeIPPoolReconcile").Add(float64(1))
 	defer prometheusmetrics.IpamdActionsInprogress.WithLabelValues("nodeIPPoolReconcile").Sub(float64(1))
 
+	// The ENIs and IPs in the IMDS cache may be stale, reconciling against them could remove ENIs that are in use
+	if c.awsClient.IsIMDSDegraded() {
+		log.Infof("Skipping the IP pool reconcile while instance metadata is served from the IMDS cache")
+		return
+	}
+
 	log.Debugf("Reconciling ENI/IP pool info because time since last %v > %v", timeSinceLast, interval)
+	// Do not reconcile against IPs or prefixes that are being assigned right now
+	c.allocations.lock()
+	defer c.allocations.unlock()
 	allENIs, err := c.awsClient.GetAttachedENIs()
 	if err != nil {
 		log.Errorf("IP pool reconcile: Failed to get attached ENI info: %v", err.Error())
@@ -1393,10 +1615,18 @@ func (c *IPAMContext) nodeIPPoolReconcile(ctx context.Context, interval time.Dur
 		efaENIs = metadataResult.EFAENIs
 		eniTagMap = metadataResult.TagMap
 		c.setUnmanagedENIs(metadataResult.TagMap)
-		c.awsClient.SetMultiCardENIs(metadataResult.MultiCardENIIDs)
+		c.awsClient.SetMultiCardENIs(c.skippedMultiCardENIs(metadataResult))
 		attachedENIs = c.filterUnmanagedENIs(metadataResult.ENIMetadata)
 	}
 
+	// When IMDS cannot be trusted, the addresses of all the ENIs in the data store are fetched from EC2 at once
+	ec2Addresses := &ec2ENIAddresses{awsClient: c.awsClient}
+	for _, attachedENI := range attachedENIs {
+		if _, ok := currentENIs[attachedENI.ENIID]; ok {
+			ec2Addresses.eniIDs = append(ec2Addresses.eniIDs, attachedENI.ENIID)
+		}
+	}
+
 	// Mark phase
 	for _, attachedENI := range attachedENIs {
 		eniIPPool, eniPrefixPool, err := c.dataStore.GetENICIDRs(attachedENI.ENIID)
@@ -1404,11 +1634,11 @@ func (c *IPAMContext) nodeIPPoolReconcile(ctx context.Context, interval time.Dur
 			// If the attached ENI is in the data store
 			log.Debugf("Reconcile existing ENI %s IP pool", attachedENI.ENIID)
 			// Reconcile IP pool
-			c.eniIPPoolReconcile(eniIPPool, attachedENI, attachedENI.ENIID)
+			c.eniIPPoolReconcile(eniIPPool, attachedENI, attachedENI.ENIID, ec2Addresses)
 			// If the attached ENI is in the data store
 			log.Debugf("Reconcile existing ENI %s IP prefixes", attachedENI.ENIID)
 			// Reconcile IP pool
-			c.eniPrefixPoolReconcile(eniPrefixPool, attachedENI, attachedENI.ENIID)
+			c.eniPrefixPoolReconcile(eniPrefixPool, attachedENI, attachedENI.ENIID, ec2Addresses)
 			// Mark action, remove this ENI from currentENIs map
 			delete(currentENIs, attachedENI.ENIID)
 			continue
@@ -1426,7 +1656,7 @@ func (c *IPAMContext) nodeIPPoolReconcile(ctx context.Context, interval time.Dur
 
 		// Add new ENI
 		log.Debugf("Reconcile and add a new ENI %s", attachedENI)
-		err = c.setupENI(attachedENI.ENIID, attachedENI, isTrunkENI, isEFAENI)
+		err = c.setupENI(attachedENI.ENIID, attachedENI, isTrunkENI, isEFAENI, c.eniPoolOf(attachedENI, eniTagMap[attachedENI.ENIID]))
 		if err != nil {
 			log.Errorf("IP pool reconcile: Failed to set up ENI %s network: %v", attachedENI.ENIID, err)
 			ipamdErrInc("eniReconcileAdd")
@@ -1450,13 +1680,13 @@ func (c *IPAMContext) nodeIPPoolReconcile(ctx context.Context, interval time.Dur
 		delete(c.primaryIP, eni)
 		prometheusmetrics.ReconcileCnt.With(prometheus.Labels{"fn": "eniReconcileDel"}).Inc()
 	}
-	c.lastNodeIPPoolAction = time.Now()
+	c.lastNodeIPPoolAction = c.now()
 
 	log.Debug("Successfully Reconciled ENI/IP pool")
 	c.logPoolStats(c.dataStore.GetIPStats(ipV4AddrFamily))
 }
 
-func (c *IPAMContext) eniIPPoolReconcile(ipPool []string, attachedENI awsutils.ENIMetadata, eni string) {
+func (c *IPAMContext) eniIPPoolReconcile(ipPool []string, attachedENI awsutils.ENIMetadata, eni string, ec2Addresses *ec2ENIAddresses) {
 	attachedENIIPs := attachedENI.IPv4Addresses
 	needEC2Reconcile := true
 	// Here we can't trust attachedENI since the IMDS metadata can be stale. We need to check with EC2 API.
@@ -1465,17 +1695,17 @@ func (c *IPAMContext) eniIPPoolReconcile(ipPool []string, attachedENI awsutils.E
 		log.Warnf("Instance metadata does not match data store! ipPool: %v, metadata: %v", ipPool, attachedENIIPs)
 		log.Debugf("We need to check the ENI status by calling the EC2 control plane.")
 		// Call EC2 to verify IPs on this ENI
-		ec2Addresses, err := c.awsClient.GetIPv4sFromEC2(eni)
+		addresses, err := ec2Addresses.get(eni)
 		if err != nil {
 			log.Errorf("Failed to fetch ENI IP addresses! Aborting reconcile of ENI %s", eni)
 			return
 		}
-		attachedENIIPs = ec2Addresses
+		attachedENIIPs = addresses.IPv4Addresses
 		needEC2Reconcile = false
 	}
 
 	// Add all known attached IPs to the datastore
-	seenIPs := c.verifyAndAddIPsToDatastore(eni, attachedENIIPs, needEC2Reconcile)
+	seenIPs := c.verifyAndAddIPsToDatastore(eni, attachedENIIPs, needEC2Reconcile, ec2Addresses)
 
 	// Sweep phase, delete remaining IPs since they should not remain in the datastore
 	for _, existingIP := range ipPool {
@@ -1497,7 +1727,7 @@ func (c *IPAMContext) eniIPPoolReconcile(ipPool []string, attachedENI awsutils.E
 	}
 }
 
-func (c *IPAMContext) eniPrefixPoolReconcile(prefixPool []string, attachedENI awsutils.ENIMetadata, eni string) {
+func (c *IPAMContext) eniPrefixPoolReconcile(prefixPool []string, attachedENI awsutils.ENIMetadata, eni string, ec2Addresses *ec2ENIAddresses) {
 	attachedENIIPs := attachedENI.IPv4Prefixes
 	needEC2Reconcile := true
 	// Here we can't trust attachedENI since the IMDS metadata can be stale. We need to check with EC2 API.
@@ -1507,17 +1737,17 @@ func (c *IPAMContext) eniPrefixPoolReconcile(prefixPool []string, attachedENI aw
 		log.Warnf("Instance metadata does not match data store! ipPool: %v, metadata: %v", prefixPool, attachedENIIPs)
 		log.Debugf("We need to check the ENI status by calling the EC2 control plane.")
 		// Call EC2 to verify IPs on this ENI
-		ec2Addresses, err := c.awsClient.GetIPv4PrefixesFromEC2(eni)
+		addresses, err := ec2Addresses.get(eni)
 		if err != nil {
 			log.Errorf("Failed to fetch ENI IP addresses! Aborting reconcile of ENI %s", eni)
 			return
 		}
-		attachedENIIPs = ec2Addresses
+		attachedENIIPs = addresses.IPv4Prefixes
 		needEC2Reconcile = false
 	}
 
 	// Add all known attached IPs to the datastore
-	seenIPs := c.verifyAndAddPrefixesToDatastore(eni, attachedENIIPs, needEC2Reconcile)
+	seenIPs := c.verifyAndAddPrefixesToDatastore(eni, attachedENIIPs, needEC2Reconcile, ec2Addresses)
 
 	// Sweep phase, delete remaining Prefixes since they should not remain in the datastore
 	for _, existingIP := range prefixPool {
@@ -1545,7 +1775,8 @@ func (c *IPAMContext) eniPrefixPoolReconcile(prefixPool []string, attachedENI aw
 
 // verifyAndAddIPsToDatastore updates the datastore with the known secondary IPs. IPs who are out of cooldown gets added
 // back to the datastore after being verified against EC2.
-func (c *IPAMContext) verifyAndAddIPsToDatastore(eni string, attachedENIIPs []ec2types.NetworkInterfacePrivateIpAddress, needEC2Reconcile bool) map[string]bool {
+func (c *IPAMContext) verifyAndAddIPsToDatastore(eni string, attachedENIIPs []ec2types.NetworkInterfacePrivateIpAddress, needEC2Reconcile bool,
+	ec2Addresses *ec2ENIAddresses) map[string]bool {
 	var ec2VerifiedAddresses []ec2types.NetworkInterfacePrivateIpAddress
 	seenIPs := make(map[string]bool)
 	for _, privateIPv4 := range attachedENIIPs {
@@ -1566,11 +1797,11 @@ func (c *IPAMContext) verifyAndAddIPsToDatastore(eni string, attachedENIIPs []ec
 				if needEC2Reconcile {
 					// IMDS data might be stale
 					log.Debugf("This IP was recently freed, but is now out of cooldown. We need to verify with EC2 control plane.")
-					// Only call EC2 once for this ENI
+					// EC2 is only called once for all the ENIs
 					if ec2VerifiedAddresses == nil {
-						var err error
 						// Call EC2 to verify IPs on this ENI
-						ec2VerifiedAddresses, err = c.awsClient.GetIPv4sFromEC2(eni)
+						addresses, err := ec2Addresses.get(eni)
+						ec2VerifiedAddresses = addresses.IPv4Addresses
 						if err != nil {
 							log.Errorf("Failed to fetch ENI IP addresses from EC2! %v", err)
 							// Do not delete this IP from the datastore or cooldown until we have confirmed with EC2
@@ -1616,7 +1847,8 @@ func (c *IPAMContext) verifyAndAddIPsToDatastore(eni string, attachedENIIPs []ec
 
 // verifyAndAddPrefixesToDatastore updates the datastore with the known Prefixes. Prefixes who are out of cooldown gets added
 // back to the datastore after being verified against EC2.
-func (c *IPAMContext) verifyAndAddPrefixesToDatastore(eni string, attachedENIPrefixes []ec2types.Ipv4PrefixSpecification, needEC2Reconcile bool) map[string]bool {
+func (c *IPAMContext) verifyAndAddPrefixesToDatastore(eni string, attachedENIPrefixes []ec2types.Ipv4PrefixSpecification, needEC2Reconcile bool,
+	ec2Addresses *ec2ENIAddresses) map[string]bool {
 	var ec2VerifiedAddresses []ec2types.Ipv4PrefixSpecification
 	seenIPs := make(map[string]bool)
 	for _, privateIPv4Cidr := range attachedENIPrefixes {
@@ -1638,12 +1870,11 @@ func (c *IPAMContext) verifyAndAddPrefixesToDatastore(eni string, attachedENIPre
 				if needEC2Reconcile {
 					// IMDS data might be stale
 					log.Debugf("This IP was recently freed, but is now out of cooldown. We need to verify with EC2 control plane.")
-					// Only call EC2 once for this ENI and post GA fix this logic for both prefixes
-					// and secondary IPs as per "split the loop" comment
+					// EC2 is only called once for all the ENIs
 					if ec2VerifiedAddresses == nil {
-						var err error
 						// Call EC2 to verify Prefixes on this ENI
-						ec2VerifiedAddresses, err = c.awsClient.GetIPv4PrefixesFromEC2(eni)
+						addresses, err := ec2Addresses.get(eni)
+						ec2VerifiedAddresses = addresses.IPv4Prefixes
 						if err != nil {
 							log.Errorf("Failed to fetch ENI IP addresses from EC2! %v", err)
 							// Do not delete this Prefix from the datastore or cooldown until we have confirmed with EC2
@@ -1688,7 +1919,8 @@ func (c *IPAMContext) verifyAndAddPrefixesToDatastore(eni string, attachedENIPre
 
 // return true when WARM_IP_TARGET or MINIMUM_IP_TARGET is defined
 func (c *IPAMContext) warmIPTargetsDefined() bool {
-	return c.warmIPTarget != noWarmIPTarget || c.minimumIPTarget != noMinimumIPTarget
+	targets := c.poolTargets()
+	return targets.WarmIPTarget != noWarmIPTarget || targets.MinimumIPTarget != noMinimumIPTarget
 }
 
 // UseCustomNetworkCfg returns whether Pods needs to use pod specific configuration or not.
@@ -1724,6 +1956,12 @@ func dsBackingStorePath() string {
 	return defaultBackingStorePath
 }
 
+// dsJournalPath returns the path of the backing store journal, next to the backing store
+func dsJournalPath() string {
+	path := dsBackingStorePath()
+	return strings.TrimSuffix(path, filepath.Ext(path)) + backingStoreJournalExt
+}
+
 func getWarmIPTarget() int {
 	inputStr, found := os.LookupEnv(envWarmIPTarget)
 	if !found {
@@ -1760,10 +1998,10 @@ func disableENIProvisioning() bool {
 
 func disableLeakedENICleanup() bool {
 	// Cases where leaked ENI cleanup is disabled:
-	// 1. IPv6 is enabled, so no ENIs are attached
+	// 1. IPv6 is enabled without IPv4, so no ENIs are attached
 	// 2. ENI provisioning is disabled, so ENIs are not managed by IPAMD
 	// 3. Environment var explicitly disabling task is set
-	return isIPv6Enabled() || disableENIProvisioning() || utils.GetBoolAsStringEnvVar(envDisableLeakedENICleanup, false)
+	return (isIPv6Enabled() && !isIPv4Enabled()) || disableENIProvisioning() || utils.GetBoolAsStringEnvVar(envDisableLeakedENICleanup, false)
 }
 
 func enablePodENI() bool {
@@ -1800,15 +2038,27 @@ func enablePodIPAnnotation() bool {
 	return utils.GetBoolAsStringEnvVar(envAnnotatePodIP, false)
 }
 
+func enableStaticPodIP() bool {
+	return utils.GetBoolAsStringEnvVar(envEnableStaticPodIP, false)
+}
+
+func enableMultiCardENIs() bool {
+	return utils.GetBoolAsStringEnvVar(envEnableMultiCardENIs, false)
+}
+
+func enableENIConfigStatus() bool {
+	return utils.GetBoolAsStringEnvVar(envEnableENIConfigStatus, false)
+}
+
 // filterUnmanagedENIs filters out ENIs marked with the "node.k8s.amazonaws.com/no_manage" tag
 func (c *IPAMContext) filterUnmanagedENIs(enis []awsutils.ENIMetadata) []awsutils.ENIMetadata {
 	numFiltered := 0
 	ret := make([]awsutils.ENIMetadata, 0, len(enis))
 	for _, eni := range enis {
-		//Filter out any Unmanaged ENIs. VPC CNI will only work with Primary ENI in IPv6 Prefix Delegation mode until
-		//we open up IPv6 support in Secondary IP and Custom networking modes. Filtering out the ENIs here will
+		//Filter out any Unmanaged ENIs. VPC CNI will only work with Primary ENI in IPv6 Prefix Delegation mode, unless
+		//custom networking is enabled and pods use ENIs from the ENIConfig subnet. Filtering out the ENIs here will
 		//help us avoid myriad of if/else loops elsewhere in the code.
-		if c.enableIPv6 && !c.awsClient.IsPrimaryENI(eni.ENIID) {
+		if c.isIPv6Only() && !c.useCustomNetworking && !c.awsClient.IsPrimaryENI(eni.ENIID) {
 			log.Debugf("Skipping ENI %s: IPv6 Mode is enabled and VPC CNI will only manage Primary ENI in v6 PD mode",
 				eni.ENIID)
 			numFiltered++
@@ -1830,7 +2080,7 @@ func (c *IPAMContext) filterUnmanagedENIs(enis []awsutils.ENIMetadata) []awsutil
 
 // datastoreTargetState determines the number of IPs `short` or `over` our WARM_IP_TARGET, accounting for the MINIMUM_IP_TARGET.
 // With prefix delegation, this function determines the number of Prefixes `short` or `over`
-func (c *IPAMContext) datastoreTargetState(stats *datastore.DataStoreStats) (short int, over int, enabled bool) {
+func (c *IPAMContext) datastoreTargetState(pool string, stats *datastore.DataStoreStats) (short int, over int, enabled bool) {
 	if !c.warmIPTargetsDefined() {
 		// there is no WARM_IP_TARGET defined and no MINIMUM_IP_TARGET, fallback to use all IP addresses on ENI
 		return 0, 0, false
@@ -1838,21 +2088,22 @@ func (c *IPAMContext) datastoreTargetState(stats *datastore.DataStoreStats) (sho
 
 	// Calculating DataStore stats can be expensive, so allow the caller to optionally pass stats it already calculated
 	if stats == nil {
-		stats = c.dataStore.GetIPStats(ipV4AddrFamily)
+		stats = c.dataStore.GetENIPoolIPStats(pool)
 	}
 	available := stats.AvailableAddresses()
+	targets := c.poolTargets()
 
 	// short is greater than 0 when we have fewer available IPs than the warm IP target
-	short = max(c.warmIPTarget-available, 0)
+	short = max(targets.WarmIPTarget-available, 0)
 
 	// short is greater than the warm IP target alone when we have fewer total IPs than the minimum target
-	short = max(short, c.minimumIPTarget-stats.TotalIPs)
+	short = max(short, targets.MinimumIPTarget-stats.TotalIPs)
 
 	// over is the number of available IPs we have beyond the warm IP target
-	over = max(available-c.warmIPTarget, 0)
+	over = max(available-targets.WarmIPTarget, 0)
 
 	// over is less than the warm IP target alone if it would imply reducing total IPs below the minimum target
-	over = max(min(over, stats.TotalIPs-c.minimumIPTarget), 0)
+	over = max(min(over, stats.TotalIPs-targets.MinimumIPTarget), 0)
 
 	if c.enablePrefixDelegation {
 		// short : number of IPs short to reach warm targets
@@ -1864,13 +2115,13 @@ func (c *IPAMContext) datastoreTargetState(stats *datastore.DataStoreStats) (sho
 		// Over will have number of IPs more than needed but with PD we would have allocated in chunks of /28
 		// Say assigned = 1, warm ip target = 16, this will need 2 prefixes. But over will return 15.
 		// Hence we need to check if 'over' number of IPs are needed to maintain the warm targets
-		prefixNeededForWarmIP := datastore.DivCeil(stats.AssignedIPs+c.warmIPTarget, numIPsPerPrefix)
-		prefixNeededForMinIP := datastore.DivCeil(c.minimumIPTarget, numIPsPerPrefix)
+		prefixNeededForWarmIP := datastore.DivCeil(stats.AssignedIPs+targets.WarmIPTarget, numIPsPerPrefix)
+		prefixNeededForMinIP := datastore.DivCeil(targets.MinimumIPTarget, numIPsPerPrefix)
 
 		// over will be number of prefixes over than needed but could be spread across used prefixes,
 		// say, after couple of pod churns, 3 prefixes are allocated with 1 IP each assigned and warm ip target is 15
 		// (J : is this needed? since we have to walk thru the loop of prefixes)
-		freePrefixes := c.dataStore.GetFreePrefixes()
+		freePrefixes := c.dataStore.GetFreePrefixes(pool)
 		overPrefix := max(min(freePrefixes, stats.TotalPrefixes-prefixNeededForWarmIP), 0)
 		overPrefix = max(min(overPrefix, stats.TotalPrefixes-prefixNeededForMinIP), 0)
 		return shortPrefix, overPrefix, true
@@ -1879,14 +2130,15 @@ func (c *IPAMContext) datastoreTargetState(stats *datastore.DataStoreStats) (sho
 }
 
 // datastorePrefixTargetState determines the number of prefixes short to reach WARM_PREFIX_TARGET
-func (c *IPAMContext) datastorePrefixTargetState() (short int, enabled bool) {
+func (c *IPAMContext) datastorePrefixTargetState(pool string) (short int, enabled bool) {
 	if !c.warmPrefixTargetDefined() {
 		return 0, false
 	}
 	// /28 will consume 16 IPs so let's not allocate if not needed.
-	freePrefixesInStore := c.dataStore.GetFreePrefixes()
-	toAllocate := max(c.warmPrefixTarget-freePrefixesInStore, 0)
-	log.Debugf("Prefix target is %d, short of %d prefixes, free %d prefixes", c.warmPrefixTarget, toAllocate, freePrefixesInStore)
+	warmPrefixTarget := c.poolTargets().WarmPrefixTarget
+	freePrefixesInStore := c.dataStore.GetFreePrefixes(pool)
+	toAllocate := max(warmPrefixTarget-freePrefixesInStore, 0)
+	log.Debugf("Prefix target is %d, short of %d prefixes, free %d prefixes", warmPrefixTarget, toAllocate, freePrefixesInStore)
 
 	return toAllocate, true
 }
@@ -2115,13 +2367,17 @@ func (c *IPAMContext) tryUnassignPrefixFromENI(eniID string) {
 	}
 }
 
-func (c *IPAMContext) GetENIResourcesToAllocate() int {
+func (c *IPAMContext) GetENIResourcesToAllocate(pool string) int {
+	// A single IPv6 prefix per ENI serves all pods
+	if c.isIPv6Only() {
+		return 1
+	}
 	var resourcesToAllocate int
 	if c.enablePrefixDelegation {
-		resourcesToAllocate = min(c.getPrefixesNeeded(), c.maxPrefixesPerENI)
+		resourcesToAllocate = min(c.getPrefixesNeeded(pool), c.maxPrefixesPerENI)
 	} else {
 		resourcesToAllocate = c.maxIPsPerENI
-		short, _, warmTargetDefined := c.datastoreTargetState(nil)
+		short, _, warmTargetDefined := c.datastoreTargetState(pool, nil)
 		if warmTargetDefined {
 			resourcesToAllocate = min(short, c.maxIPsPerENI)
 		}
@@ -2150,31 +2406,35 @@ func (c *IPAMContext) isDatastorePoolEmpty() bool {
 	return stats.TotalIPs == 0
 }
 
-// Return whether the maximum number of ENIs that can be attached to the node has already been reached
-func (c *IPAMContext) hasRoomForEni() bool {
+// Return whether the ENI pool can get another ENI, without reaching the maximum number of ENIs of the node or its network card
+func (c *IPAMContext) hasRoomForEni(pool string) bool {
 	trunkEni := 0
 	if c.enablePodENI && c.dataStore.GetTrunkENI() == "" {
 		trunkEni = 1
 	}
-	return c.dataStore.GetENIs() < (c.maxENI - c.unmanagedENI - trunkEni)
+	if c.dataStore.GetENIs() >= (c.poolTargets().MaxENI - c.unmanagedENI - trunkEni) {
+		return false
+	}
+	return !c.enableMultiCardENIs || c.networkCardHasRoomForENI(pool, trunkEni)
 }
 
-func (c *IPAMContext) isDatastorePoolTooLow() (bool, *datastore.DataStoreStats) {
-	stats := c.dataStore.GetIPStats(ipV4AddrFamily)
+func (c *IPAMContext) isDatastorePoolTooLow(pool string) (bool, *datastore.DataStoreStats) {
+	stats := c.dataStore.GetENIPoolIPStats(pool)
 	// If max pods has been reached, pool is not too low
-	if stats.TotalIPs >= c.maxPods {
+	if c.nodeIPsCoverMaxPods(stats) {
 		return false, stats
 	}
 
-	short, _, warmTargetDefined := c.datastoreTargetState(stats)
+	short, _, warmTargetDefined := c.datastoreTargetState(pool, stats)
 	if warmTargetDefined {
 		return short > 0, stats
 	}
 
-	warmTarget := c.warmENITarget
+	targets := c.poolTargets()
+	warmTarget := targets.WarmENITarget
 	totalIPs := c.maxIPsPerENI
 	if c.enablePrefixDelegation {
-		warmTarget = c.warmPrefixTarget
+		warmTarget = targets.WarmPrefixTarget
 		_, maxIpsPerPrefix, _ := datastore.GetPrefixDelegationDefaults()
 		totalIPs = maxIpsPerPrefix
 	}
@@ -2188,19 +2448,29 @@ func (c *IPAMContext) isDatastorePoolTooLow() (bool, *datastore.DataStoreStats)
 	return poolTooLow, stats
 }
 
-func (c *IPAMContext) isDatastorePoolTooHigh(stats *datastore.DataStoreStats) bool {
+// nodeIPsCoverMaxPods reports whether the node has as many IPs as it can run pods, given the stats of one of its ENI pools
+func (c *IPAMContext) nodeIPsCoverMaxPods(stats *datastore.DataStoreStats) bool {
+	nodeTotalIPs := stats.TotalIPs
+	if c.eniConfigPools != nil {
+		nodeTotalIPs = c.dataStore.GetIPStats(ipV4AddrFamily).TotalIPs
+	}
+	return nodeTotalIPs >= c.maxPods
+}
+
+func (c *IPAMContext) isDatastorePoolTooHigh(pool string, stats *datastore.DataStoreStats) bool {
 	// NOTE: IPs may be allocated in chunks (full ENIs of prefixes), so the "too-high" condition does not check max pods. The limit is enforced on the allocation side.
-	_, over, warmTargetDefined := c.datastoreTargetState(stats)
+	_, over, warmTargetDefined := c.datastoreTargetState(pool, stats)
 	if warmTargetDefined {
 		return over > 0
 	}
 
 	// For the existing ENIs check if we can cleanup prefixes
 	if c.warmPrefixTargetDefined() {
-		freePrefixes := c.dataStore.GetFreePrefixes()
-		poolTooHigh := freePrefixes > c.warmPrefixTarget
+		warmPrefixTarget := c.poolTargets().WarmPrefixTarget
+		freePrefixes := c.dataStore.GetFreePrefixes(pool)
+		poolTooHigh := freePrefixes > warmPrefixTarget
 		if poolTooHigh {
-			log.Debugf("Prefix pool is high so might be able to deallocate - free prefixes: %d, warm prefix target: %d", freePrefixes, c.warmPrefixTarget)
+			log.Debugf("Prefix pool is high so might be able to deallocate - free prefixes: %d, warm prefix target: %d", freePrefixes, warmPrefixTarget)
 		}
 		return poolTooHigh
 	}
@@ -2209,11 +2479,11 @@ func (c *IPAMContext) isDatastorePoolTooHigh(stats *datastore.DataStoreStats) bo
 }
 
 func (c *IPAMContext) warmPrefixTargetDefined() bool {
-	return c.warmPrefixTarget >= defaultWarmPrefixTarget && c.enablePrefixDelegation
+	return c.poolTargets().WarmPrefixTarget >= defaultWarmPrefixTarget && c.enablePrefixDelegation
 }
 
-// DeallocCidrs frees IPs and Prefixes from EC2
-func (c *IPAMContext) DeallocCidrs(eniID string, deletableCidrs []datastore.CidrInfo) {
+// DeallocCidrs frees IPs and Prefixes from EC2, and returns the first error of the EC2 calls
+func (c *IPAMContext) DeallocCidrs(eniID string, deletableCidrs []datastore.CidrInfo) error {
 	var deletableIPs []string
 	var deletablePrefixes []string
 
@@ -2233,24 +2503,30 @@ func (c *IPAMContext) DeallocCidrs(eniID string, deletableCidrs []datastore.Cidr
 		}
 	}
 
+	var deallocErr error
 	if err := c.awsClient.DeallocPrefixAddresses(eniID, deletablePrefixes); err != nil {
 		log.Warnf("Failed to free Prefixes %v from ENI %s: %s", deletablePrefixes, eniID, err)
+		deallocErr = err
 	}
 
 	if err := c.awsClient.DeallocIPAddresses(eniID, deletableIPs); err != nil {
 		log.Warnf("Failed to free IPs %v from ENI %s: %s", deletableIPs, eniID, err)
+		if deallocErr == nil {
+			deallocErr = err
+		}
 	}
+	return deallocErr
 }
 
 // getPrefixesNeeded returns the number of prefixes need to be allocated to the ENI
-func (c *IPAMContext) getPrefixesNeeded() int {
+func (c *IPAMContext) getPrefixesNeeded(pool string) int {
 	// By default allocate 1 prefix at a time
 	toAllocate := 1
 
 	// TODO - post GA we can evaluate to see if these two calls can be merged.
 	// datastoreTargetState already has complex math so adding Prefix target will make it even more complex.
-	short, _, warmIPTargetsDefined := c.datastoreTargetState(nil)
-	shortPrefixes, warmPrefixTargetDefined := c.datastorePrefixTargetState()
+	short, _, warmIPTargetsDefined := c.datastoreTargetState(pool, nil)
+	shortPrefixes, warmPrefixTargetDefined := c.datastorePrefixTargetState(pool)
 
 	// WARM_IP_TARGET takes precendence over WARM_PREFIX_TARGET
 	if warmIPTargetsDefined {
@@ -2269,7 +2545,9 @@ func (c *IPAMContext) initENIAndIPLimits() (err error) {
 			log.Error("Failed to get ENI limit")
 			return err
 		}
+		c.poolTargetsLock.Lock()
 		c.maxENI = nodeMaxENI
+		c.poolTargetsLock.Unlock()
 
 		c.maxIPsPerENI, c.maxPrefixesPerENI, err = c.GetIPv4Limit()
 		if err != nil {
@@ -2284,24 +2562,40 @@ func (c *IPAMContext) initENIAndIPLimits() (err error) {
 	return nil
 }
 
+// isIPv6Only returns true when pods only get an IPv6 address. In dual-stack mode, ENIs and the IPv4 pool are managed
+// as in IPv4 mode and only the primary ENI gets an IPv6 prefix.
+func (c *IPAMContext) isIPv6Only() bool {
+	return c.enableIPv6 && !c.enableIPv4
+}
+
 func (c *IPAMContext) isConfigValid() bool {
-	// Validate that only one among v4 and v6 is enabled.
-	if c.enableIPv4 && c.enableIPv6 {
-		log.Errorf("IPv4 and IPv6 are both enabled. VPC CNI currently does not support dual stack mode")
-		return false
-	} else if !c.enableIPv4 && !c.enableIPv6 {
+	// Validate that at least one among v4 and v6 is enabled, pods get an address of each family in dual-stack mode.
+	if !c.enableIPv4 && !c.enableIPv6 {
 		log.Errorf("IPv4 and IPv6 are both disabled. One of them have to be enabled")
 		return false
 	}
 
-	// Validate PD mode is enabled if VPC CNI is operating in IPv6 mode. Custom networking is not supported in IPv6 mode.
-	if c.enableIPv6 && (c.useCustomNetworking || !c.enablePrefixDelegation) {
-		log.Errorf("IPv6 is supported only in Prefix Delegation mode. Custom Networking is not supported in IPv6 mode. Please set the env variables accordingly.")
+	// Validate PD mode is enabled if VPC CNI is operating in IPv6 mode.
+	// In dual-stack mode, IPv4 addresses may come from secondary IPs, IPv6 addresses always come from a prefix.
+	if c.isIPv6Only() && !c.enablePrefixDelegation {
+		log.Errorf("IPv6 is supported only in Prefix Delegation mode. Please set the env variables accordingly.")
+		return false
+	}
+
+	// The IPv6 prefix of the primary ENI serves all pods in dual-stack mode
+	if c.enableIPv4 && c.enableIPv6 && c.useCustomNetworking {
+		log.Errorf("Custom Networking is not supported in dual-stack mode. Please set the env variables accordingly.")
+		return false
+	}
+
+	// Branch ENI pods are set up with a single address
+	if c.enableIPv4 && c.enableIPv6 && c.enablePodENI {
+		log.Errorf("Security Groups for Pods is not supported in dual-stack mode. Please set the env variables accordingly.")
 		return false
 	}
 
 	// Validate Prefix Delegation against v4 and v6 modes.
-	if c.enablePrefixDelegation && !c.awsClient.IsPrefixDelegationSupported() {
+	if (c.enablePrefixDelegation || c.enableIPv6) && !c.awsClient.IsPrefixDelegationSupported() {
 		if c.enableIPv6 {
 			log.Errorf("Prefix Delegation is not supported on non-nitro instance %s. IPv6 is only supported in Prefix delegation Mode. ", c.awsClient.GetInstanceType())
 			return false
@@ -2310,6 +2604,18 @@ func (c *IPAMContext) isConfigValid() bool {
 		c.enablePrefixDelegation = false
 	}
 
+	// ENIConfig backed ENI pools build on custom networking, which is IPv4 only
+	if c.eniConfigPools != nil && (!c.useCustomNetworking || !c.enableIPv4) {
+		log.Errorf("ENI_CONFIG_POOLS requires AWS_VPC_K8S_CNI_CUSTOM_NETWORK_CFG to be enabled in IPv4 mode")
+		return false
+	}
+
+	// In IPv6 mode, ipamd only manages the primary ENI
+	if c.enableMultiCardENIs && !c.enableIPv4 {
+		log.Errorf("%s is only supported in IPv4 mode", envEnableMultiCardENIs)
+		return false
+	}
+
 	return true
 }
 
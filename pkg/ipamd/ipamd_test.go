@@ -46,9 +46,11 @@ import (
 	eniconfigscheme "github.com/aws/amazon-vpc-cni-k8s/pkg/apis/crd/v1alpha1"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils"
 	mock_awsutils "github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils/mocks"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/eniconfig"
 	mock_eniconfig "github.com/aws/amazon-vpc-cni-k8s/pkg/eniconfig/mocks"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
 	mock_networkutils "github.com/aws/amazon-vpc-cni-k8s/pkg/networkutils/mocks"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/vpc"
 	"github.com/aws/amazon-vpc-cni-k8s/utils/prometheusmetrics"
 	rcscheme "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
 	"github.com/prometheus/client_golang/prometheus"
@@ -102,9 +104,11 @@ func setup(t *testing.T) *testMocks {
 	eniconfigscheme.AddToScheme(k8sSchema)
 	rcscheme.AddToScheme(k8sSchema)
 
+	awsutils := mock_awsutils.NewMockAPIs(ctrl)
+	awsutils.EXPECT().IsIMDSDegraded().Return(false).AnyTimes()
 	return &testMocks{
 		ctrl:      ctrl,
-		awsutils:  mock_awsutils.NewMockAPIs(ctrl),
+		awsutils:  awsutils,
 		k8sClient: testclient.NewClientBuilder().WithScheme(k8sSchema).Build(),
 		network:   mock_networkutils.NewMockNetworkAPIs(ctrl),
 		eniconfig: mock_eniconfig.NewMockENIConfig(ctrl),
@@ -144,8 +148,6 @@ func TestNodeInit(t *testing.T) {
 	var cidrs []string
 	m.awsutils.EXPECT().GetENILimit().Return(4)
 	m.awsutils.EXPECT().GetENIIPv4Limit().Return(14)
-	m.awsutils.EXPECT().GetIPv4sFromEC2(eni1.ENIID).AnyTimes().Return(eni1.IPv4Addresses, nil)
-	m.awsutils.EXPECT().GetIPv4sFromEC2(eni2.ENIID).AnyTimes().Return(eni2.IPv4Addresses, nil)
 	m.awsutils.EXPECT().IsUnmanagedENI(eni1.ENIID).Return(false).AnyTimes()
 	m.awsutils.EXPECT().IsUnmanagedENI(eni2.ENIID).Return(false).AnyTimes()
 	m.awsutils.EXPECT().TagENI(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
@@ -234,8 +236,6 @@ func TestNodeInitwithPDenabledIPv4Mode(t *testing.T) {
 	var cidrs []string
 	m.awsutils.EXPECT().GetENILimit().Return(4)
 	m.awsutils.EXPECT().GetENIIPv4Limit().Return(14)
-	m.awsutils.EXPECT().GetIPv4PrefixesFromEC2(eni1.ENIID).AnyTimes().Return(eni1.IPv4Prefixes, nil)
-	m.awsutils.EXPECT().GetIPv4PrefixesFromEC2(eni2.ENIID).AnyTimes().Return(eni2.IPv4Prefixes, nil)
 	m.awsutils.EXPECT().IsUnmanagedENI(eni1.ENIID).Return(false).AnyTimes()
 	m.awsutils.EXPECT().IsUnmanagedENI(eni2.ENIID).Return(false).AnyTimes()
 	m.awsutils.EXPECT().TagENI(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
@@ -355,6 +355,100 @@ func TestNodeInitwithPDenabledIPv6Mode(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestNodeInitwithIPv6CustomNetworking(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+	ctx := context.Background()
+
+	mockContext := &IPAMContext{
+		awsClient:              m.awsutils,
+		k8sClient:              m.k8sClient,
+		maxIPsPerENI:           224,
+		maxPrefixesPerENI:      1,
+		maxENI:                 2,
+		warmENITarget:          1,
+		primaryIP:              make(map[string]string),
+		terminating:            int32(0),
+		networkClient:          m.network,
+		dataStore:              datastore.NewDataStore(log, datastore.NewTestCheckpoint(datastore.CheckpointData{Version: datastore.CheckpointFormatVersion}), true),
+		myNodeName:             myNodeName,
+		enablePrefixDelegation: true,
+		enableIPv4:             false,
+		enableIPv6:             true,
+		useCustomNetworking:    true,
+	}
+
+	// The IPv6 prefix of the primary ENI is not used with custom networking
+	eni1 := getDummyENIMetadataWithV6Prefix()
+	eniV6Addr := "2001:db8:1::5"
+	eniV6Prefix := "2001:db8:1:2::/80"
+	eniV6Subnet := "2001:db8:1::/64"
+	eni2 := awsutils.ENIMetadata{
+		ENIID:          secENIid,
+		MAC:            secMAC,
+		DeviceNumber:   secDevice,
+		SubnetIPv6CIDR: eniV6Subnet,
+		IPv6Addresses:  []ec2types.NetworkInterfaceIpv6Address{{Ipv6Address: &eniV6Addr}},
+		IPv6Prefixes:   []ec2types.Ipv6PrefixSpecification{{Ipv6Prefix: &eniV6Prefix}},
+	}
+
+	var cidrs []string
+	m.awsutils.EXPECT().IsUnmanagedENI(eni1.ENIID).Return(false).AnyTimes()
+	m.awsutils.EXPECT().TagENI(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	m.awsutils.EXPECT().IsMultiCardENI(eni1.ENIID).Return(false).AnyTimes()
+
+	primaryIP := net.ParseIP(ipaddr01)
+	m.network.EXPECT().SetupHostNetwork(cidrs, eni1.MAC, &primaryIP, false, false, true).Return(nil)
+	m.network.EXPECT().CleanUpStaleAWSChains(false, true).Return(nil)
+	m.awsutils.EXPECT().GetPrimaryENI().AnyTimes().Return(primaryENIid)
+	m.awsutils.EXPECT().GetPrimaryENImac().Return(eni1.MAC)
+
+	resp := awsutils.DescribeAllENIsResult{
+		ENIMetadata: []awsutils.ENIMetadata{eni1},
+		TagMap:      map[string]awsutils.TagMap{},
+		TrunkENI:    "",
+		EFAENIs:     make(map[string]bool),
+	}
+	m.awsutils.EXPECT().DescribeAllENIs().Return(resp, nil)
+	m.awsutils.EXPECT().GetLocalIPv4().Return(primaryIP)
+	m.awsutils.EXPECT().SetMultiCardENIs(resp.MultiCardENIIDs).AnyTimes()
+
+	// An ENI is attached in the ENIConfig subnet, and gets an IPv6 prefix
+	sg := []*string{aws.String("sg1-id"), aws.String("sg2-id")}
+	m.awsutils.EXPECT().AllocENI(true, sg, []string{"subnet1"}, 1).Return(secENIid, nil)
+	m.awsutils.EXPECT().AllocIPv6Prefixes(secENIid).Return([]*string{&eniV6Prefix}, nil)
+	m.awsutils.EXPECT().WaitForENIAndIPsAttached(secENIid, 1).Return(eni2, nil)
+	m.awsutils.EXPECT().GetIPv6PrefixesFromEC2(secENIid).Return(eni2.IPv6Prefixes, nil)
+	m.network.EXPECT().SetupENINetwork(eniV6Addr, secMAC, secDevice, eniV6Subnet).Return(nil)
+
+	fakeNode := v1.Node{
+		TypeMeta:   metav1.TypeMeta{Kind: "Node"},
+		ObjectMeta: metav1.ObjectMeta{Name: myNodeName, Labels: map[string]string{"k8s.amazonaws.com/eniConfig": "az1"}},
+		Spec:       v1.NodeSpec{},
+		Status:     v1.NodeStatus{},
+	}
+	m.k8sClient.Create(ctx, &fakeNode)
+	fakeENIConfig := v1alpha1.ENIConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "az1"},
+		Spec: eniconfigscheme.ENIConfigSpec{
+			Subnet:         "subnet1",
+			SecurityGroups: []string{"sg1-id", "sg2-id"},
+		},
+	}
+	m.k8sClient.Create(ctx, &fakeENIConfig)
+
+	err := mockContext.nodeInit()
+	assert.NoError(t, err)
+
+	// Pods get their IPv6 addresses from the ENIConfig ENI, and route through its table
+	key := datastore.IPAMKey{NetworkName: "net0", ContainerID: "sandbox-id", IfName: "eth0"}
+	addr, deviceNumber, err := mockContext.dataStore.AssignPodIPv6Address(key, datastore.IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "sample-pod"})
+	assert.NoError(t, err)
+	assert.Equal(t, secDevice, deviceNumber)
+	_, prefix, _ := net.ParseCIDR(eniV6Prefix)
+	assert.True(t, prefix.Contains(net.ParseIP(addr)))
+}
+
 func getDummyENIMetadata() (awsutils.ENIMetadata, awsutils.ENIMetadata, awsutils.ENIMetadata) {
 	primary := true
 	notPrimary := false
@@ -621,7 +715,7 @@ func testIncreaseIPPool(t *testing.T, useENIConfig bool, unschedulabeNode bool,
 		}
 		m.k8sClient.Create(ctx, &fakeENIConfig)
 	}
-	mockContext.increaseDatastorePool(ctx)
+	mockContext.increaseDatastorePool(ctx, datastore.DefaultENIPool)
 }
 
 func assertAllocationExternalCalls(shouldCall bool, useENIConfig bool, m *testMocks, sg []*string, podENIConfig *eniconfigscheme.ENIConfigSpec, eni2 string, eniMetadata []awsutils.ENIMetadata, subnetDiscovery bool) {
@@ -633,7 +727,7 @@ func assertAllocationExternalCalls(shouldCall bool, useENIConfig bool, m *testMo
 	originalErr := errors.New("err")
 
 	if useENIConfig {
-		m.awsutils.EXPECT().AllocENI(true, sg, podENIConfig.Subnet, 14).Times(callCount).Return(eni2, nil)
+		m.awsutils.EXPECT().AllocENI(true, sg, []string{podENIConfig.Subnet}, 14).Times(callCount).Return(eni2, nil)
 	} else if subnetDiscovery {
 		m.awsutils.EXPECT().AllocIPAddresses(primaryENIid, 14).Times(callCount).Return(nil, &smithy.GenericAPIError{
 			Code:    "InsufficientFreeAddressesInSubnet",
@@ -645,15 +739,184 @@ func assertAllocationExternalCalls(shouldCall bool, useENIConfig bool, m *testMo
 			Message: originalErr.Error(),
 			Fault:   smithy.FaultUnknown,
 		})
-		m.awsutils.EXPECT().AllocENI(false, nil, "", 14).Times(callCount).Return(eni2, nil)
+		m.awsutils.EXPECT().AllocENI(false, nil, nil, 14).Times(callCount).Return(eni2, nil)
 	} else {
-		m.awsutils.EXPECT().AllocENI(false, nil, "", 14).Times(callCount).Return(eni2, nil)
+		m.awsutils.EXPECT().AllocENI(false, nil, nil, 14).Times(callCount).Return(eni2, nil)
 	}
 	m.awsutils.EXPECT().GetPrimaryENI().Times(callCount).Return(primaryENIid)
 	m.awsutils.EXPECT().WaitForENIAndIPsAttached(secENIid, 14).Times(callCount).Return(eniMetadata[1], nil)
 	m.network.EXPECT().SetupENINetwork(gomock.Any(), secMAC, secDevice, secSubnet).Times(callCount)
 }
 
+func TestIncreaseIPPoolENIConfigPool(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+	ctx := context.Background()
+
+	pools, err := eniconfig.NewENIConfigPools(map[string][]string{"blue": {"team-blue"}})
+	assert.NoError(t, err)
+	mockContext := &IPAMContext{
+		awsClient:                 m.awsutils,
+		k8sClient:                 m.k8sClient,
+		maxIPsPerENI:              14,
+		maxENI:                    4,
+		warmENITarget:             1,
+		networkClient:             m.network,
+		useCustomNetworking:       true,
+		manageENIsNonScheduleable: true,
+		eniConfigPools:            pools,
+		maxPods:                   110,
+		primaryIP:                 make(map[string]string),
+		terminating:               int32(0),
+	}
+	mockContext.dataStore = testDatastore()
+	assert.NoError(t, mockContext.dataStore.AddENI(primaryENIid, primaryDevice, true, false, false))
+	assert.NoError(t, mockContext.dataStore.AddIPv4CidrToStore(primaryENIid, net.IPNet{IP: net.ParseIP(ipaddr02), Mask: net.CIDRMask(32, 32)}, false))
+	assert.NoError(t, m.k8sClient.Create(ctx, &v1alpha1.ENIConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "blue"},
+		Spec: eniconfigscheme.ENIConfigSpec{
+			Subnet:         "subnet-blue",
+			Subnets:        []string{"subnet-blue-2"},
+			SecurityGroups: []string{"sg-blue"},
+		},
+	}))
+
+	notPrimary := false
+	testAddr11 := ipaddr11
+	testAddr12 := ipaddr12
+	eniMetadata := awsutils.ENIMetadata{
+		ENIID:          secENIid,
+		MAC:            secMAC,
+		DeviceNumber:   secDevice,
+		SubnetIPv4CIDR: secSubnet,
+		IPv4Addresses: []ec2types.NetworkInterfacePrivateIpAddress{
+			{PrivateIpAddress: &testAddr11, Primary: &notPrimary},
+			{PrivateIpAddress: &testAddr12, Primary: &notPrimary},
+		},
+	}
+	// The ENI of the pool is created from its own ENIConfig and tagged with the pool, even though the default pool is not low
+	m.awsutils.EXPECT().AllocENIWithTags(true, []*string{aws.String("sg-blue")}, []string{"subnet-blue", "subnet-blue-2"}, 14,
+		map[string]string{eniConfigPoolTagKey: "blue"}).Return(secENIid, nil)
+	m.awsutils.EXPECT().GetPrimaryENI().Return(primaryENIid)
+	m.awsutils.EXPECT().WaitForENIAndIPsAttached(secENIid, 14).Return(eniMetadata, nil)
+	m.network.EXPECT().SetupENINetwork(gomock.Any(), secMAC, secDevice, secSubnet)
+
+	tooLow, _ := mockContext.isDatastorePoolTooLow("blue")
+	assert.True(t, tooLow)
+	assert.NoError(t, mockContext.increaseDatastorePool(ctx, "blue"))
+	assert.Equal(t, 2, mockContext.dataStore.GetENIPoolIPStats("blue").TotalIPs)
+	assert.Equal(t, 1, mockContext.dataStore.GetENIPoolIPStats(datastore.DefaultENIPool).TotalIPs)
+	assert.Equal(t, []string{datastore.DefaultENIPool, "blue"}, mockContext.dataStore.GetENIPools())
+}
+
+func TestIncreaseIPPoolNetworkCard(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+	ctx := context.Background()
+
+	mockContext := &IPAMContext{
+		awsClient:                 m.awsutils,
+		k8sClient:                 m.k8sClient,
+		maxIPsPerENI:              14,
+		maxENI:                    4,
+		warmENITarget:             1,
+		networkClient:             m.network,
+		manageENIsNonScheduleable: true,
+		enableMultiCardENIs:       true,
+		networkCards:              []vpc.NetworkCard{{NetworkCardIndex: 0, MaximumNetworkInterfaces: 2}, {NetworkCardIndex: 1, MaximumNetworkInterfaces: 2}},
+		maxPods:                   110,
+		primaryIP:                 make(map[string]string),
+		terminating:               int32(0),
+	}
+	mockContext.dataStore = testDatastore()
+	assert.NoError(t, mockContext.dataStore.AddENI(primaryENIid, primaryDevice, true, false, false))
+	assert.NoError(t, mockContext.dataStore.AddIPv4CidrToStore(primaryENIid, net.IPNet{IP: net.ParseIP(ipaddr02), Mask: net.CIDRMask(32, 32)}, false))
+
+	pool := networkCardPool(1)
+	assert.Equal(t, []string{datastore.DefaultENIPool, pool}, mockContext.eniPoolNames())
+
+	notPrimary := false
+	testAddr11 := ipaddr11
+	testAddr12 := ipaddr12
+	eniMetadata := awsutils.ENIMetadata{
+		ENIID:          secENIid,
+		MAC:            secMAC,
+		DeviceNumber:   1,
+		NetworkCard:    1,
+		SubnetIPv4CIDR: secSubnet,
+		IPv4Addresses: []ec2types.NetworkInterfacePrivateIpAddress{
+			{PrivateIpAddress: &testAddr11, Primary: &notPrimary},
+			{PrivateIpAddress: &testAddr12, Primary: &notPrimary},
+		},
+	}
+	// The ENI of the network card pool is attached to its network card, and routed through a table of its own
+	m.awsutils.EXPECT().AllocENIOnNetworkCard(false, nil, nil, 14, nil, 1).Return(secENIid, nil)
+	m.awsutils.EXPECT().GetPrimaryENI().Return(primaryENIid)
+	m.awsutils.EXPECT().WaitForENIAndIPsAttached(secENIid, 14).Return(eniMetadata, nil)
+	m.network.EXPECT().SetupENINetwork(gomock.Any(), secMAC, 1001, secSubnet)
+
+	tooLow, _ := mockContext.isDatastorePoolTooLow(pool)
+	assert.True(t, tooLow)
+	assert.True(t, mockContext.hasRoomForEni(pool))
+	assert.NoError(t, mockContext.increaseDatastorePool(ctx, pool))
+	assert.Equal(t, 2, mockContext.dataStore.GetENIPoolIPStats(pool).TotalIPs)
+	assert.Equal(t, 1, mockContext.dataStore.GetENIPoolIPStats(datastore.DefaultENIPool).TotalIPs)
+
+	// Network card 1 takes a single ENI as its device index 0 stays free, while the default network card still has room for one
+	assert.False(t, mockContext.hasRoomForEni(pool))
+	assert.True(t, mockContext.hasRoomForEni(datastore.DefaultENIPool))
+}
+
+func TestIMDSDegraded(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+	ctx := context.Background()
+
+	// The ENIs in the IMDS cache may be stale, so neither ENIs are attached nor the pool reconciled against them
+	awsClient := mock_awsutils.NewMockAPIs(m.ctrl)
+	awsClient.EXPECT().IsIMDSDegraded().Return(true).AnyTimes()
+	mockContext := &IPAMContext{
+		awsClient:     awsClient,
+		k8sClient:     m.k8sClient,
+		dataStore:     testDatastore(),
+		maxIPsPerENI:  14,
+		maxENI:        4,
+		warmENITarget: 1,
+		myNodeName:    myNodeName,
+		primaryIP:     make(map[string]string),
+		poolDecisions: newPoolDecisionLog(poolDecisionLogSize),
+	}
+
+	assert.NoError(t, mockContext.increaseDatastorePool(ctx, datastore.DefaultENIPool))
+	decisions := mockContext.getPoolDecisionsForDebug()
+	assert.Len(t, decisions, 1)
+	assert.Equal(t, poolActionNone, decisions[0].Action)
+	assert.Equal(t, errIMDSDegraded.Error(), decisions[0].Reason)
+	assert.ErrorIs(t, mockContext.tryAllocateENI(ctx, datastore.DefaultENIPool), errIMDSDegraded)
+
+	mockContext.nodeIPPoolReconcile(ctx, 0)
+}
+
+func TestSkippedMultiCardENIs(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+
+	result := awsutils.DescribeAllENIsResult{
+		TagMap: map[string]awsutils.TagMap{
+			"eni-ipamd": {eniNodeTagKey: instanceID},
+			"eni-efa":   {"Name": "efa"},
+		},
+		MultiCardENIIDs: []string{"eni-efa", "eni-ipamd"},
+	}
+	c := &IPAMContext{awsClient: m.awsutils}
+	assert.Equal(t, []string{"eni-efa", "eni-ipamd"}, c.skippedMultiCardENIs(result))
+
+	// With multi-card ENIs, the ENIs ipamd attached to the other network cards are managed
+	m.awsutils.EXPECT().GetInstanceID().Return(instanceID).AnyTimes()
+	c.enableMultiCardENIs = true
+	assert.Equal(t, []string{"eni-efa"}, c.skippedMultiCardENIs(result))
+}
+
 func TestIncreasePrefixPoolDefault(t *testing.T) {
 	_ = os.Unsetenv(envCustomNetworkCfg)
 	testIncreasePrefixPool(t, false, false)
@@ -716,7 +979,7 @@ func testIncreasePrefixPool(t *testing.T, useENIConfig, subnetDiscovery bool) {
 	originalErr := errors.New("err")
 
 	if useENIConfig {
-		m.awsutils.EXPECT().AllocENI(true, sg, podENIConfig.Subnet, 1).Return(eni2, nil)
+		m.awsutils.EXPECT().AllocENI(true, sg, []string{podENIConfig.Subnet}, 1).Return(eni2, nil)
 	} else if subnetDiscovery {
 		m.awsutils.EXPECT().AllocIPAddresses(primaryENIid, 1).Return(nil, &smithy.GenericAPIError{
 			Code:    "InsufficientFreeAddressesInSubnet",
@@ -728,9 +991,9 @@ func testIncreasePrefixPool(t *testing.T, useENIConfig, subnetDiscovery bool) {
 			Message: originalErr.Error(),
 			Fault:   smithy.FaultUnknown,
 		})
-		m.awsutils.EXPECT().AllocENI(false, nil, "", 1).Return(eni2, nil)
+		m.awsutils.EXPECT().AllocENI(false, nil, nil, 1).Return(eni2, nil)
 	} else {
-		m.awsutils.EXPECT().AllocENI(false, nil, "", 1).Return(eni2, nil)
+		m.awsutils.EXPECT().AllocENI(false, nil, nil, 1).Return(eni2, nil)
 	}
 
 	eniMetadata := []awsutils.ENIMetadata{
@@ -800,7 +1063,7 @@ func testIncreasePrefixPool(t *testing.T, useENIConfig, subnetDiscovery bool) {
 		m.k8sClient.Create(ctx, &fakeENIConfig)
 	}
 
-	mockContext.increaseDatastorePool(ctx)
+	mockContext.increaseDatastorePool(ctx, datastore.DefaultENIPool)
 }
 
 // TestDecreaseIPPool checks that the deallocation honors the warm IP targets when deallocations happens across multiple enis
@@ -837,14 +1100,14 @@ func TestDecreaseIPPool(t *testing.T) {
 	m.awsutils.EXPECT().DeallocPrefixAddresses(gomock.Any(), gomock.Any()).Times(1)
 	m.awsutils.EXPECT().DeallocIPAddresses(gomock.Any(), gomock.Any()).Times(1)
 
-	short, over, enabled := mockContext.datastoreTargetState(nil)
+	short, over, enabled := mockContext.datastoreTargetState(datastore.DefaultENIPool, nil)
 	assert.Equal(t, 0, short)      // there would not be any shortage
 	assert.Equal(t, 1, over)       // out of 4 IPs we have 2 IPs assigned, warm IP target is 1, so over is 1
 	assert.Equal(t, true, enabled) // there is warm ip target enabled with the value of 1
 
 	mockContext.decreaseDatastorePool(10 * time.Second)
 
-	short, over, enabled = mockContext.datastoreTargetState(nil)
+	short, over, enabled = mockContext.datastoreTargetState(datastore.DefaultENIPool, nil)
 	assert.Equal(t, 0, short)      // there would not be any shortage
 	assert.Equal(t, 0, over)       // after the above deallocation this should be zero
 	assert.Equal(t, true, enabled) // there is warm ip target enabled with the value of 1
@@ -852,7 +1115,7 @@ func TestDecreaseIPPool(t *testing.T) {
 	// make another call just to ensure that more deallocations do not happen
 	mockContext.decreaseDatastorePool(10 * time.Second)
 
-	short, over, enabled = mockContext.datastoreTargetState(nil)
+	short, over, enabled = mockContext.datastoreTargetState(datastore.DefaultENIPool, nil)
 	assert.Equal(t, 0, short)      // there would not be any shortage
 	assert.Equal(t, 0, over)       // after the above deallocation this should be zero
 	assert.Equal(t, true, enabled) // there is warm ip target enabled with the value of 1
@@ -886,7 +1149,7 @@ func TestTryAddIPToENI(t *testing.T) {
 
 	mockContext.dataStore = testDatastore()
 
-	m.awsutils.EXPECT().AllocENI(false, nil, "", warmIPTarget).Return(secENIid, nil)
+	m.awsutils.EXPECT().AllocENI(false, nil, nil, warmIPTarget).Return(secENIid, nil)
 	eniMetadata := []awsutils.ENIMetadata{
 		{
 			ENIID:          primaryENIid,
@@ -931,7 +1194,7 @@ func TestTryAddIPToENI(t *testing.T) {
 		Status:     v1.NodeStatus{},
 	}
 	m.k8sClient.Create(ctx, &fakeNode)
-	mockContext.increaseDatastorePool(ctx)
+	mockContext.increaseDatastorePool(ctx, datastore.DefaultENIPool)
 }
 
 func TestNodeIPPoolReconcile(t *testing.T) {
@@ -989,7 +1252,9 @@ func TestNodeIPPoolReconcile(t *testing.T) {
 		},
 	}
 	m.awsutils.EXPECT().GetAttachedENIs().Return(oneIPUnassigned, nil)
-	m.awsutils.EXPECT().GetIPv4sFromEC2(primaryENIid).Return(oneIPUnassigned[0].IPv4Addresses, nil)
+	m.awsutils.EXPECT().DescribeENIAddresses([]string{primaryENIid}).Return(map[string]awsutils.ENIAddresses{
+		primaryENIid: {IPv4Addresses: oneIPUnassigned[0].IPv4Addresses},
+	}, nil)
 
 	mockContext.nodeIPPoolReconcile(ctx, 0)
 	curENIs = mockContext.dataStore.GetENIInfos()
@@ -1090,8 +1355,9 @@ func TestNodePrefixPoolReconcile(t *testing.T) {
 		},
 	}
 	m.awsutils.EXPECT().GetAttachedENIs().Return(oneIPUnassigned, nil)
-	m.awsutils.EXPECT().GetIPv4PrefixesFromEC2(primaryENIid).Return(oneIPUnassigned[0].IPv4Prefixes, nil)
-	// m.awsutils.EXPECT().GetIPv4sFromEC2(primaryENIid).Return(oneIPUnassigned[0].IPv4Addresses, nil)
+	m.awsutils.EXPECT().DescribeENIAddresses([]string{primaryENIid}).Return(map[string]awsutils.ENIAddresses{
+		primaryENIid: {IPv4Prefixes: oneIPUnassigned[0].IPv4Prefixes},
+	}, nil)
 
 	mockContext.nodeIPPoolReconcile(ctx, 0)
 	curENIs = mockContext.dataStore.GetENIInfos()
@@ -1179,11 +1445,11 @@ func TestGetWarmIPTargetState(t *testing.T) {
 	}
 	mockContext.dataStore = testDatastore()
 
-	_, _, warmIPTargetDefined := mockContext.datastoreTargetState(nil)
+	_, _, warmIPTargetDefined := mockContext.datastoreTargetState(datastore.DefaultENIPool, nil)
 	assert.False(t, warmIPTargetDefined)
 
 	mockContext.warmIPTarget = 5
-	short, over, warmIPTargetDefined := mockContext.datastoreTargetState(nil)
+	short, over, warmIPTargetDefined := mockContext.datastoreTargetState(datastore.DefaultENIPool, nil)
 	assert.True(t, warmIPTargetDefined)
 	assert.Equal(t, 5, short)
 	assert.Equal(t, 0, over)
@@ -1195,7 +1461,7 @@ func TestGetWarmIPTargetState(t *testing.T) {
 	ipv4Addr = net.IPNet{IP: net.ParseIP("1.1.1.2"), Mask: net.IPv4Mask(255, 255, 255, 255)}
 	_ = mockContext.dataStore.AddIPv4CidrToStore("eni-1", ipv4Addr, false)
 
-	short, over, warmIPTargetDefined = mockContext.datastoreTargetState(nil)
+	short, over, warmIPTargetDefined = mockContext.datastoreTargetState(datastore.DefaultENIPool, nil)
 	assert.True(t, warmIPTargetDefined)
 	assert.Equal(t, 3, short)
 	assert.Equal(t, 0, over)
@@ -1208,7 +1474,7 @@ func TestGetWarmIPTargetState(t *testing.T) {
 	ipv4Addr = net.IPNet{IP: net.ParseIP("1.1.1.5"), Mask: net.IPv4Mask(255, 255, 255, 255)}
 	_ = mockContext.dataStore.AddIPv4CidrToStore("eni-1", ipv4Addr, false)
 
-	short, over, warmIPTargetDefined = mockContext.datastoreTargetState(nil)
+	short, over, warmIPTargetDefined = mockContext.datastoreTargetState(datastore.DefaultENIPool, nil)
 	assert.True(t, warmIPTargetDefined)
 	assert.Equal(t, 0, short)
 	assert.Equal(t, 0, over)
@@ -1228,11 +1494,11 @@ func TestGetWarmIPTargetStateWithPDenabled(t *testing.T) {
 
 	mockContext.dataStore = testDatastorewithPrefix()
 
-	_, _, warmIPTargetDefined := mockContext.datastoreTargetState(nil)
+	_, _, warmIPTargetDefined := mockContext.datastoreTargetState(datastore.DefaultENIPool, nil)
 	assert.False(t, warmIPTargetDefined)
 
 	mockContext.warmIPTarget = 5
-	short, over, warmIPTargetDefined := mockContext.datastoreTargetState(nil)
+	short, over, warmIPTargetDefined := mockContext.datastoreTargetState(datastore.DefaultENIPool, nil)
 	assert.True(t, warmIPTargetDefined)
 	assert.Equal(t, 1, short)
 	assert.Equal(t, 0, over)
@@ -1245,7 +1511,7 @@ func TestGetWarmIPTargetStateWithPDenabled(t *testing.T) {
 	_, ipnet, _ = net.ParseCIDR("20.1.1.0/28")
 	_ = mockContext.dataStore.AddIPv4CidrToStore("eni-1", *ipnet, true)
 
-	short, over, warmIPTargetDefined = mockContext.datastoreTargetState(nil)
+	short, over, warmIPTargetDefined = mockContext.datastoreTargetState(datastore.DefaultENIPool, nil)
 	assert.True(t, warmIPTargetDefined)
 	assert.Equal(t, 0, short)
 	assert.Equal(t, 1, over)
@@ -1254,7 +1520,7 @@ func TestGetWarmIPTargetStateWithPDenabled(t *testing.T) {
 	_, ipnet, _ = net.ParseCIDR("20.1.1.0/28")
 	_ = mockContext.dataStore.DelIPv4CidrFromStore("eni-1", *ipnet, true)
 
-	short, over, warmIPTargetDefined = mockContext.datastoreTargetState(nil)
+	short, over, warmIPTargetDefined = mockContext.datastoreTargetState(datastore.DefaultENIPool, nil)
 	assert.True(t, warmIPTargetDefined)
 	assert.Equal(t, 0, short)
 	assert.Equal(t, 0, over)
@@ -1302,7 +1568,7 @@ func TestIPAMContext_nodeIPPoolTooLow(t *testing.T) {
 				enablePrefixDelegation: false,
 				maxPods:                tt.fields.maxPods,
 			}
-			if got, _ := c.isDatastorePoolTooLow(); got != tt.want {
+			if got, _ := c.isDatastorePoolTooLow(datastore.DefaultENIPool); got != tt.want {
 				t.Errorf("nodeIPPoolTooLow() = %v, want %v", got, tt.want)
 			}
 		})
@@ -1350,7 +1616,7 @@ func TestIPAMContext_nodePrefixPoolTooLow(t *testing.T) {
 				enablePrefixDelegation: true,
 				maxPods:                tt.fields.maxPods,
 			}
-			if got, _ := c.isDatastorePoolTooLow(); got != tt.want {
+			if got, _ := c.isDatastorePoolTooLow(datastore.DefaultENIPool); got != tt.want {
 				t.Errorf("nodeIPPoolTooLow() = %v, want %v", got, tt.want)
 			}
 		})
@@ -1706,7 +1972,7 @@ func TestNodeIPPoolReconcileBadIMDSData(t *testing.T) {
 	}, nil)
 
 	// eniIPPoolReconcile() calls EC2 to get the actual count, but that call fails
-	m.awsutils.EXPECT().GetIPv4sFromEC2(primaryENIid).Return(nil, errors.New("ec2 API call failed"))
+	m.awsutils.EXPECT().DescribeENIAddresses([]string{primaryENIid}).Return(nil, errors.New("ec2 API call failed"))
 	mockContext.nodeIPPoolReconcile(ctx, 0)
 	curENIs = mockContext.dataStore.GetENIInfos()
 	assert.Equal(t, 1, len(curENIs.ENIs))
@@ -1728,7 +1994,9 @@ func TestNodeIPPoolReconcileBadIMDSData(t *testing.T) {
 	}, nil)
 
 	// eniIPPoolReconcile() calls EC2 to get the actual count that should still be 2
-	m.awsutils.EXPECT().GetIPv4sFromEC2(primaryENIid).Return(primaryENIMetadata.IPv4Addresses, nil)
+	m.awsutils.EXPECT().DescribeENIAddresses([]string{primaryENIid}).Return(map[string]awsutils.ENIAddresses{
+		primaryENIid: {IPv4Addresses: primaryENIMetadata.IPv4Addresses},
+	}, nil)
 	mockContext.nodeIPPoolReconcile(ctx, 0)
 	curENIs = mockContext.dataStore.GetENIInfos()
 	assert.Equal(t, 1, len(curENIs.ENIs))
@@ -1792,7 +2060,7 @@ func TestNodePrefixPoolReconcileBadIMDSData(t *testing.T) {
 	}, nil)
 
 	// eniIPPoolReconcile() calls EC2 to get the actual count, but that call fails
-	m.awsutils.EXPECT().GetIPv4PrefixesFromEC2(primaryENIid).Return(nil, errors.New("ec2 API call failed"))
+	m.awsutils.EXPECT().DescribeENIAddresses([]string{primaryENIid}).Return(nil, errors.New("ec2 API call failed"))
 	mockContext.nodeIPPoolReconcile(ctx, 0)
 	curENIs = mockContext.dataStore.GetENIInfos()
 	assert.Equal(t, 1, len(curENIs.ENIs))
@@ -1814,7 +2082,9 @@ func TestNodePrefixPoolReconcileBadIMDSData(t *testing.T) {
 	}, nil)
 
 	// eniIPPoolReconcile() calls EC2 to get the actual count that should still be 16
-	m.awsutils.EXPECT().GetIPv4PrefixesFromEC2(primaryENIid).Return(primaryENIMetadata.IPv4Prefixes, nil)
+	m.awsutils.EXPECT().DescribeENIAddresses([]string{primaryENIid}).Return(map[string]awsutils.ENIAddresses{
+		primaryENIid: {IPv4Prefixes: primaryENIMetadata.IPv4Prefixes},
+	}, nil)
 	mockContext.nodeIPPoolReconcile(ctx, 0)
 	curENIs = mockContext.dataStore.GetENIInfos()
 	assert.Equal(t, 1, len(curENIs.ENIs))
@@ -1957,7 +2227,7 @@ func TestIPAMContext_setupENI(t *testing.T) {
 		},
 	}
 	m.awsutils.EXPECT().GetPrimaryENI().Return(primaryENIid)
-	err := mockContext.setupENI(primaryENIMetadata.ENIID, primaryENIMetadata, false, false)
+	err := mockContext.setupENI(primaryENIMetadata.ENIID, primaryENIMetadata, false, false, datastore.DefaultENIPool)
 	assert.NoError(t, err)
 	// Primary ENI added
 	assert.Equal(t, 1, len(mockContext.primaryIP))
@@ -1966,7 +2236,7 @@ func TestIPAMContext_setupENI(t *testing.T) {
 	m.awsutils.EXPECT().GetPrimaryENI().Return(primaryENIid)
 	m.network.EXPECT().SetupENINetwork(gomock.Any(), secMAC, secDevice, primarySubnet).Return(errors.New("not able to set route 0.0.0.0/0 via 10.10.10.1 table 2"))
 
-	err = mockContext.setupENI(newENIMetadata.ENIID, newENIMetadata, false, false)
+	err = mockContext.setupENI(newENIMetadata.ENIID, newENIMetadata, false, false, datastore.DefaultENIPool)
 	assert.Error(t, err)
 	assert.Equal(t, 1, len(mockContext.primaryIP))
 }
@@ -2003,7 +2273,7 @@ func TestIPAMContext_setupENIwithPDenabled(t *testing.T) {
 		},
 	}
 	m.awsutils.EXPECT().GetPrimaryENI().Return(primaryENIid)
-	err := mockContext.setupENI(primaryENIMetadata.ENIID, primaryENIMetadata, false, false)
+	err := mockContext.setupENI(primaryENIMetadata.ENIID, primaryENIMetadata, false, false, datastore.DefaultENIPool)
 	assert.NoError(t, err)
 	// Primary ENI added
 	assert.Equal(t, 1, len(mockContext.primaryIP))
@@ -2012,7 +2282,7 @@ func TestIPAMContext_setupENIwithPDenabled(t *testing.T) {
 	m.awsutils.EXPECT().GetPrimaryENI().Return(primaryENIid)
 	m.network.EXPECT().SetupENINetwork(gomock.Any(), secMAC, secDevice, primarySubnet).Return(errors.New("not able to set route 0.0.0.0/0 via 10.10.10.1 table 2"))
 
-	err = mockContext.setupENI(newENIMetadata.ENIID, newENIMetadata, false, false)
+	err = mockContext.setupENI(newENIMetadata.ENIID, newENIMetadata, false, false, datastore.DefaultENIPool)
 	assert.Error(t, err)
 	assert.Equal(t, 1, len(mockContext.primaryIP))
 }
@@ -2103,6 +2373,9 @@ func TestIsConfigValid(t *testing.T) {
 		customNetworkingEnabled bool
 		podENIEnabled           bool
 		isNitroInstance         bool
+		// checksNitroInstance is set when IPv6 makes the instance type check happen without prefix delegation
+		checksNitroInstance bool
+		eniConfigPools      bool
 	}
 
 	tests := []struct {
@@ -2152,9 +2425,63 @@ func TestIsConfigValid(t *testing.T) {
 		},
 		{
 			name: "both v4 and v6 enabled",
+			fields: fields{
+				ipV4Enabled:         true,
+				ipV6Enabled:         true,
+				isNitroInstance:     true,
+				checksNitroInstance: true,
+			},
+			want: true,
+		},
+		{
+			name: "both v4 and v6 enabled on Non-Nitro instance",
+			fields: fields{
+				ipV4Enabled:         true,
+				ipV6Enabled:         true,
+				isNitroInstance:     false,
+				checksNitroInstance: true,
+			},
+			want: false,
+		},
+		{
+			name: "both v4 and v6 enabled with custom networking",
+			fields: fields{
+				ipV4Enabled:             true,
+				ipV6Enabled:             true,
+				customNetworkingEnabled: true,
+				isNitroInstance:         true,
+			},
+			want: false,
+		},
+		{
+			name: "v4 disabled and v6 enabled in PD mode with custom networking",
+			fields: fields{
+				ipV4Enabled:             false,
+				ipV6Enabled:             true,
+				prefixDelegationEnabled: true,
+				customNetworkingEnabled: true,
+				isNitroInstance:         true,
+			},
+			want: true,
+		},
+		{
+			name: "eniconfig pools with custom networking in v6 mode",
+			fields: fields{
+				ipV4Enabled:             false,
+				ipV6Enabled:             true,
+				prefixDelegationEnabled: true,
+				customNetworkingEnabled: true,
+				isNitroInstance:         true,
+				eniConfigPools:          true,
+			},
+			want: false,
+		},
+		{
+			name: "ppsg enabled in dual-stack mode",
 			fields: fields{
 				ipV4Enabled:     true,
 				ipV6Enabled:     true,
+				podENIEnabled:   true,
 				isNitroInstance: true,
 			},
 			want: false,
@@ -2191,6 +2518,25 @@ func TestIsConfigValid(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "eniconfig pools with custom networking",
+			fields: fields{
+				ipV4Enabled:             true,
+				customNetworkingEnabled: true,
+				isNitroInstance:         true,
+				eniConfigPools:          true,
+			},
+			want: true,
+		},
+		{
+			name: "eniconfig pools without custom networking",
+			fields: fields{
+				ipV4Enabled:     true,
+				isNitroInstance: true,
+				eniConfigPools:  true,
+			},
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -2198,7 +2544,7 @@ func TestIsConfigValid(t *testing.T) {
 			m := setup(t)
 			defer m.ctrl.Finish()
 
-			if tt.fields.prefixDelegationEnabled {
+			if tt.fields.prefixDelegationEnabled || tt.fields.checksNitroInstance {
 				if tt.fields.isNitroInstance {
 					m.awsutils.EXPECT().IsPrefixDelegationSupported().Return(true)
 				} else {
@@ -2218,6 +2564,11 @@ func TestIsConfigValid(t *testing.T) {
 				useCustomNetworking:    tt.fields.customNetworkingEnabled,
 				dataStore:              ds,
 			}
+			if tt.fields.eniConfigPools {
+				pools, err := eniconfig.NewENIConfigPools(map[string][]string{"blue": {"team-blue"}})
+				assert.NoError(t, err)
+				mockContext.eniConfigPools = pools
+			}
 
 			resp := mockContext.isConfigValid()
 			assert.Equal(t, tt.want, resp)
@@ -2482,7 +2833,7 @@ func TestPodENIErrInc(t *testing.T) {
 
 func (c *IPAMContext) tryAssignPodENI(ctx context.Context, pod *corev1.Pod, fnName string) error {
 	// Mock implementation for the test
-	_, err := c.awsClient.AllocENI(false, nil, "", 0)
+	_, err := c.awsClient.AllocENI(false, nil, nil, 0)
 	if err != nil {
 		prometheusmetrics.PodENIErr.With(prometheus.Labels{"fn": fnName}).Inc()
 		return err
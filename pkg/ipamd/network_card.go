@@ -0,0 +1,175 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/vpc"
+)
+
+const (
+	// vpccniNetworkCardKey is the pod annotation selecting the network card whose ENIs the pod gets its IP from
+	vpccniNetworkCardKey = "vpc.amazonaws.com/network-card"
+
+	// networkCardPoolPrefix names the ENI pools of the non-default network cards. ENIConfig names cannot contain a
+	// slash, so these never clash with the ENI pools in ENI_CONFIG_POOLS.
+	networkCardPoolPrefix = "network-card/"
+
+	// networkCardDeviceNumbers is the range of device numbers, and so of route tables, given to each network card.
+	// It starts well above the route tables of branch ENIs, which are 100 + VLAN ID.
+	networkCardDeviceNumbers = 1000
+)
+
+// errUnknownNetworkCard is returned for pods asking for a network card that ipamd does not attach ENIs to
+var errUnknownNetworkCard = errors.New("unknown network card")
+
+// networkCardPool returns the ENI pool of the ENIs attached to the network card
+func networkCardPool(networkCard int) string {
+	if networkCard == 0 {
+		return datastore.DefaultENIPool
+	}
+	return networkCardPoolPrefix + strconv.Itoa(networkCard)
+}
+
+// networkCardOfPool returns the network card of the ENI pool, and whether it is the ENI pool of a non-default
+// network card. All other ENI pools are on network card 0.
+func networkCardOfPool(pool string) (int, bool) {
+	suffix, ok := strings.CutPrefix(pool, networkCardPoolPrefix)
+	if !ok {
+		return 0, false
+	}
+	networkCard, err := strconv.Atoi(suffix)
+	if err != nil {
+		return 0, false
+	}
+	return networkCard, true
+}
+
+func isNetworkCardPool(pool string) bool {
+	_, ok := networkCardOfPool(pool)
+	return ok
+}
+
+// routeDeviceNumber returns the device number that the route table of the ENI, and of the pods using it, is derived
+// from. Device numbers start over on each network card, so each card gets its own range.
+func routeDeviceNumber(eni awsutils.ENIMetadata) int {
+	return eni.NetworkCard*networkCardDeviceNumbers + eni.DeviceNumber
+}
+
+// networkCard returns the network card of the instance with the given index
+func (c *IPAMContext) networkCard(index int) (vpc.NetworkCard, bool) {
+	for _, card := range c.networkCards {
+		if int(card.NetworkCardIndex) == index {
+			return card, true
+		}
+	}
+	return vpc.NetworkCard{}, false
+}
+
+// networkCardPoolNames returns the ENI pools of the non-default network cards when ENABLE_MULTI_CARD_ENIS is set
+func (c *IPAMContext) networkCardPoolNames() []string {
+	if !c.enableMultiCardENIs {
+		return nil
+	}
+	var pools []string
+	for _, card := range c.networkCards {
+		if card.NetworkCardIndex != 0 {
+			pools = append(pools, networkCardPool(int(card.NetworkCardIndex)))
+		}
+	}
+	return pools
+}
+
+// isNetworkCardPoolConfigured reports whether the ENI pool is the one of a non-default network card ipamd manages
+func (c *IPAMContext) isNetworkCardPoolConfigured(pool string) bool {
+	index, ok := networkCardOfPool(pool)
+	if !ok || index == 0 || !c.enableMultiCardENIs {
+		return false
+	}
+	_, found := c.networkCard(index)
+	return found
+}
+
+// eniPoolOf returns the ENI pool of an attached ENI, from its network card or from the ENI pool tag set by ipamd
+func (c *IPAMContext) eniPoolOf(eni awsutils.ENIMetadata, tags awsutils.TagMap) string {
+	if eni.NetworkCard != 0 {
+		return networkCardPool(eni.NetworkCard)
+	}
+	return tags[eniConfigPoolTagKey]
+}
+
+// skippedMultiCardENIs returns the ENIs on non-default network cards that ipamd leaves alone. With
+// ENABLE_MULTI_CARD_ENIS, ipamd manages the ENIs it attached to the other network cards itself.
+func (c *IPAMContext) skippedMultiCardENIs(result awsutils.DescribeAllENIsResult) []string {
+	if !c.enableMultiCardENIs {
+		return result.MultiCardENIIDs
+	}
+	var skipped []string
+	for _, eniID := range result.MultiCardENIIDs {
+		if result.TagMap[eniID][eniNodeTagKey] != c.awsClient.GetInstanceID() {
+			skipped = append(skipped, eniID)
+		}
+	}
+	return skipped
+}
+
+// networkCardHasRoomForENI reports whether the network card of the ENI pool can take another ENI. A trunk ENI that
+// is yet to be attached goes on the default network card. Device index 0 of the other network cards is left free, so
+// they take one ENI less than their limit.
+func (c *IPAMContext) networkCardHasRoomForENI(pool string, trunkENI int) bool {
+	index, _ := networkCardOfPool(pool)
+	card, found := c.networkCard(index)
+	if !found {
+		// Without the network cards of the instance type, only the node limit applies to the default network card
+		return index == 0
+	}
+	reserved := 1
+	if index == 0 {
+		reserved = trunkENI
+	}
+	enis := 0
+	for _, eni := range c.dataStore.GetENIInfos().ENIs {
+		if eniCard, _ := networkCardOfPool(eni.Pool); eniCard == index {
+			enis++
+		}
+	}
+	return enis+reserved < int(card.MaximumNetworkInterfaces)
+}
+
+// podNetworkCardPool returns the ENI pool of the network card the pod asks for with the vpc.amazonaws.com/network-card
+// annotation, and whether the pod asks for a non-default network card
+func (c *IPAMContext) podNetworkCardPool(pod *corev1.Pod) (string, bool, error) {
+	val, ok := pod.Annotations[vpccniNetworkCardKey]
+	if !ok || !c.enableMultiCardENIs {
+		return "", false, nil
+	}
+	index, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || index < 0 {
+		return "", false, errors.Wrapf(errUnknownNetworkCard, "%q", val)
+	}
+	if index == 0 {
+		return "", false, nil
+	}
+	if !c.isNetworkCardPoolConfigured(networkCardPool(index)) {
+		return "", false, errors.Wrapf(errUnknownNetworkCard, "%d", index)
+	}
+	return networkCardPool(index), true, nil
+}
@@ -0,0 +1,362 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/k8sapi"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/eventrecorder"
+)
+
+const (
+	// poolConfigNodeOverridesKey holds a JSON list of settings for the nodes matching a label selector, e.g.
+	// [{"nodeSelector": "node.kubernetes.io/instance-type=m5.large", "settings": {"WARM_IP_TARGET": "2"}}].
+	// The other keys of the pool ConfigMap are named after the env variables they replace.
+	poolConfigNodeOverridesKey = "nodeOverrides"
+	// poolConfigSourceKey shows where the pool settings come from in the ipamd env settings
+	poolConfigSourceKey = "POOL_CONFIG_SOURCE"
+	poolConfigSourceEnv = "env"
+
+	poolConfigAppliedReason  = "PoolConfigApplied"
+	poolConfigRejectedReason = "PoolConfigRejected"
+)
+
+// poolSettings are the warm pool settings that can be changed without restarting ipamd
+type poolSettings struct {
+	WarmIPTarget     int `json:"WARM_IP_TARGET"`
+	MinimumIPTarget  int `json:"MINIMUM_IP_TARGET"`
+	WarmPrefixTarget int `json:"WARM_PREFIX_TARGET"`
+	WarmENITarget    int `json:"WARM_ENI_TARGET"`
+	MaxENI           int `json:"MAX_ENI"`
+}
+
+func (s *poolSettings) set(key, value string) error {
+	input, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return errors.Errorf("%s %q is not an integer", key, value)
+	}
+	switch key {
+	case envWarmIPTarget:
+		s.WarmIPTarget = input
+	case envMinimumIPTarget:
+		s.MinimumIPTarget = input
+	case envWarmPrefixTarget:
+		s.WarmPrefixTarget = input
+	case envWarmENITarget:
+		s.WarmENITarget = input
+	case envMaxENI:
+		s.MaxENI = input
+	default:
+		return errors.Errorf("unknown setting %s", key)
+	}
+	return nil
+}
+
+func (s *poolSettings) setAll(settings map[string]string) error {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := s.set(key, settings[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s poolSettings) String() string {
+	return fmt.Sprintf("%s=%d, %s=%d, %s=%d, %s=%d, %s=%d", envWarmIPTarget, s.WarmIPTarget, envMinimumIPTarget, s.MinimumIPTarget,
+		envWarmPrefixTarget, s.WarmPrefixTarget, envWarmENITarget, s.WarmENITarget, envMaxENI, s.MaxENI)
+}
+
+// poolConfigNodeOverride replaces settings on the nodes matching its label selector
+type poolConfigNodeOverride struct {
+	NodeSelector string            `json:"nodeSelector"`
+	Settings     map[string]string `json:"settings"`
+}
+
+// poolConfig tracks the pool settings read from the pool ConfigMap
+type poolConfig struct {
+	name types.NamespacedName
+
+	lock         sync.RWMutex
+	applied      poolSettings
+	source       string
+	lastRejected string
+}
+
+// poolSettingsInUse is the snapshot of the pool settings the pool manager runs with, for the introspection endpoints
+type poolSettingsInUse struct {
+	lock     sync.RWMutex
+	settings poolSettings
+	source   string
+	window   string
+}
+
+func (p *poolSettingsInUse) get() (settings poolSettings, source string, window string) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.settings, p.source, p.window
+}
+
+// describe returns where the settings in use come from
+func (p *poolSettingsInUse) describe() string {
+	_, source, window := p.get()
+	if window == "" {
+		return source
+	}
+	return fmt.Sprintf("%s, %s window %s", source, envWarmPoolSchedule, window)
+}
+
+func (p *poolSettingsInUse) set(settings poolSettings, source string, window string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.settings, p.source, p.window = settings, source, window
+}
+
+// newPoolConfig returns the pool config for the ConfigMap named by POOL_CONFIG_MAP, or nil when it is not set.
+// Until the ConfigMap is read, the settings of the env variables apply.
+func newPoolConfig(env poolSettings) *poolConfig {
+	name, ok := k8sapi.GetPoolConfigMapName()
+	if !ok {
+		return nil
+	}
+	log.Infof("Reading pool settings from ConfigMap %s", name)
+	return &poolConfig{name: name, applied: env, source: poolConfigSourceEnv}
+}
+
+// current returns the settings of the ConfigMap and where they come from
+func (p *poolConfig) current() (poolSettings, string) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.applied, p.source
+}
+
+// resolve merges the settings of the ConfigMap and of the node overrides matching the node labels into the env settings.
+// Overrides are applied in order, so a later match wins.
+func (p *poolConfig) resolve(env poolSettings, cm *corev1.ConfigMap, nodeLabels map[string]string) (poolSettings, string, error) {
+	settings := env
+	source := "ConfigMap " + p.name.String()
+	base := make(map[string]string, len(cm.Data))
+	for key, value := range cm.Data {
+		if key != poolConfigNodeOverridesKey {
+			base[key] = value
+		}
+	}
+	if err := settings.setAll(base); err != nil {
+		return settings, source, err
+	}
+
+	overridesJSON, ok := cm.Data[poolConfigNodeOverridesKey]
+	if !ok {
+		return settings, source, nil
+	}
+	var overrides []poolConfigNodeOverride
+	if err := json.Unmarshal([]byte(overridesJSON), &overrides); err != nil {
+		return settings, source, errors.Wrapf(err, "failed to parse %s", poolConfigNodeOverridesKey)
+	}
+	var matched []string
+	for i, override := range overrides {
+		selector, err := labels.Parse(override.NodeSelector)
+		if err != nil {
+			return settings, source, errors.Wrapf(err, "invalid nodeSelector in %s[%d]", poolConfigNodeOverridesKey, i)
+		}
+		if !selector.Matches(labels.Set(nodeLabels)) {
+			continue
+		}
+		if err := settings.setAll(override.Settings); err != nil {
+			return settings, source, errors.Wrapf(err, "%s[%d]", poolConfigNodeOverridesKey, i)
+		}
+		matched = append(matched, strconv.Itoa(i))
+	}
+	if len(matched) > 0 {
+		source = fmt.Sprintf("%s, %s %s", source, poolConfigNodeOverridesKey, strings.Join(matched, ","))
+	}
+	return settings, source, nil
+}
+
+// validatePoolSettings checks settings from the pool ConfigMap or a schedule window before they replace the running ones
+func (c *IPAMContext) validatePoolSettings(settings, env poolSettings) error {
+	for key, value := range map[string]int{
+		envWarmIPTarget:     settings.WarmIPTarget,
+		envMinimumIPTarget:  settings.MinimumIPTarget,
+		envWarmPrefixTarget: settings.WarmPrefixTarget,
+		envWarmENITarget:    settings.WarmENITarget,
+	} {
+		if value < 0 {
+			return errors.Errorf("%s %d is negative", key, value)
+		}
+	}
+	if c.adaptiveWarmTarget != nil && settings.WarmIPTarget != env.WarmIPTarget {
+		return errors.Errorf("%s can not be changed while %s is enabled", envWarmIPTarget, envEnableAdaptiveWarmIPTarget)
+	}
+	return nil
+}
+
+// poolTargets returns maxENI and the warm targets the pool manager runs with
+func (c *IPAMContext) poolTargets() poolSettings {
+	c.poolTargetsLock.RLock()
+	defer c.poolTargetsLock.RUnlock()
+	return poolSettings{
+		WarmIPTarget:     c.warmIPTarget,
+		MinimumIPTarget:  c.minimumIPTarget,
+		WarmPrefixTarget: c.warmPrefixTarget,
+		WarmENITarget:    c.warmENITarget,
+		MaxENI:           c.maxENI,
+	}
+}
+
+// applyPoolSettings replaces the running pool settings
+func (c *IPAMContext) applyPoolSettings(settings poolSettings) {
+	// Lowering MAX_ENI does not detach ENIs, it only stops new ones from being attached
+	maxENI := c.limitMaxENI(settings.MaxENI)
+	c.poolTargetsLock.Lock()
+	if c.adaptiveWarmTarget == nil {
+		c.warmIPTarget = settings.WarmIPTarget
+	}
+	c.minimumIPTarget = settings.MinimumIPTarget
+	c.warmPrefixTarget = settings.WarmPrefixTarget
+	c.warmENITarget = settings.WarmENITarget
+	c.maxENI = maxENI
+	c.poolTargetsLock.Unlock()
+	c.updateIPStats(c.unmanagedENI)
+}
+
+// initPoolSettings records the settings of the env variables, which are in use until the pool ConfigMap or
+// the warm pool schedule replace them
+func (c *IPAMContext) initPoolSettings(env poolSettings) {
+	c.poolSettingsEnv = env
+	c.poolSettingsInUse.set(env, poolConfigSourceEnv, "")
+}
+
+// applyEffectivePoolSettings applies the settings of the pool ConfigMap, or of the env variables when there is none,
+// with the settings of the active schedule window on top
+func (c *IPAMContext) applyEffectivePoolSettings(now time.Time) {
+	settings, source := c.poolSettingsEnv, poolConfigSourceEnv
+	if c.poolConfig != nil {
+		settings, source = c.poolConfig.current()
+	}
+	windowName := ""
+	if window := c.poolSchedule.activeWindow(now); window != nil {
+		settings = window.apply(settings)
+		windowName = window.Name
+	}
+
+	inUse, inUseSource, inUseWindow := c.poolSettingsInUse.get()
+	if settings == inUse && source == inUseSource && windowName == inUseWindow {
+		return
+	}
+	if windowName != inUseWindow {
+		log.Infof("Warm pool schedule window changed from %q to %q", inUseWindow, windowName)
+	}
+	c.applyPoolSettings(settings)
+	c.poolSettingsInUse.set(settings, source, windowName)
+	log.Infof("Using pool settings %s from %s", settings, c.poolSettingsInUse.describe())
+}
+
+// reloadPoolConfig applies changes to the pool ConfigMap. A deleted ConfigMap restores the settings of the env variables.
+func (c *IPAMContext) reloadPoolConfig(ctx context.Context) {
+	if c.poolConfig == nil {
+		return
+	}
+	settings, source := c.poolSettingsEnv, poolConfigSourceEnv
+	var cm corev1.ConfigMap
+	err := c.k8sClient.Get(ctx, c.poolConfig.name, &cm)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		log.Warnf("Failed to get pool ConfigMap %s, keeping the current pool settings: %v", c.poolConfig.name, err)
+		return
+	}
+	var node corev1.Node
+	if err := c.k8sClient.Get(ctx, types.NamespacedName{Name: c.myNodeName}, &node); err != nil {
+		log.Warnf("Failed to get node %s for the pool ConfigMap overrides: %v", c.myNodeName, err)
+		return
+	}
+	// Events are recorded on the ConfigMap, or on the node once the ConfigMap is deleted
+	var regarding, related runtime.Object = &node, nil
+	if err == nil {
+		regarding, related = &cm, &node
+		settings, source, err = c.poolConfig.resolve(c.poolSettingsEnv, &cm, node.Labels)
+		if err == nil {
+			err = c.validatePoolSettings(settings, c.poolSettingsEnv)
+		}
+		if err != nil {
+			c.rejectPoolConfig(&cm, &node, err)
+			return
+		}
+	}
+
+	applied, appliedSource := c.poolConfig.current()
+	if settings == applied && source == appliedSource {
+		return
+	}
+	c.poolConfig.lock.Lock()
+	c.poolConfig.applied, c.poolConfig.source, c.poolConfig.lastRejected = settings, source, ""
+	c.poolConfig.lock.Unlock()
+	c.applyEffectivePoolSettings(c.now())
+
+	message := fmt.Sprintf("Applied pool settings from %s: %s", source, settings)
+	log.Info(message)
+	if eventRecorder := eventrecorder.Get(); eventRecorder != nil {
+		eventRecorder.SendEvent(regarding, related, corev1.EventTypeNormal, poolConfigAppliedReason, "PoolConfig", message)
+	}
+}
+
+// rejectPoolConfig keeps the current settings and reports the invalid ConfigMap once per change
+func (c *IPAMContext) rejectPoolConfig(cm *corev1.ConfigMap, node *corev1.Node, err error) {
+	message := fmt.Sprintf("Rejected pool ConfigMap %s, keeping the current pool settings: %v", c.poolConfig.name, err)
+	c.poolConfig.lock.Lock()
+	repeated := c.poolConfig.lastRejected == cm.ResourceVersion+message
+	c.poolConfig.lastRejected = cm.ResourceVersion + message
+	c.poolConfig.lock.Unlock()
+	if repeated {
+		return
+	}
+	log.Error(message)
+	if eventRecorder := eventrecorder.Get(); eventRecorder != nil {
+		eventRecorder.SendEvent(cm, node, corev1.EventTypeWarning, poolConfigRejectedReason, "PoolConfig", message)
+	}
+}
+
+// getConfigForDebug returns GetConfigForDebug with the pool settings that are in use
+func (c *IPAMContext) getConfigForDebug() map[string]interface{} {
+	config := GetConfigForDebug()
+	if c.poolConfig == nil && c.poolSchedule == nil {
+		return config
+	}
+	settings, _, _ := c.poolSettingsInUse.get()
+	config[envWarmIPTarget] = settings.WarmIPTarget
+	config[envMinimumIPTarget] = settings.MinimumIPTarget
+	config[envWarmPrefixTarget] = settings.WarmPrefixTarget
+	config[envWarmENITarget] = settings.WarmENITarget
+	config[envMaxENI] = settings.MaxENI
+	config[poolConfigSourceKey] = c.poolSettingsInUse.describe()
+	return config
+}
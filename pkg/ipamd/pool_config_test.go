@@ -0,0 +1,178 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/eventrecorder"
+)
+
+func TestPoolConfigResolve(t *testing.T) {
+	env := poolSettings{WarmIPTarget: 5, WarmENITarget: 1, MaxENI: -1}
+	p := &poolConfig{name: types.NamespacedName{Namespace: "kube-system", Name: "ipamd-pool"}}
+	nodeLabels := map[string]string{"node.kubernetes.io/instance-type": "m5.large", "pool": "small"}
+
+	tests := []struct {
+		name       string
+		data       map[string]string
+		want       poolSettings
+		wantSource string
+		wantErr    bool
+	}{
+		{
+			name:       "empty ConfigMap keeps the env settings",
+			want:       poolSettings{WarmIPTarget: 5, WarmENITarget: 1, MaxENI: -1},
+			wantSource: "ConfigMap kube-system/ipamd-pool",
+		},
+		{
+			name:       "ConfigMap settings",
+			data:       map[string]string{"WARM_IP_TARGET": "10", "MINIMUM_IP_TARGET": "20", "MAX_ENI": "3"},
+			want:       poolSettings{WarmIPTarget: 10, MinimumIPTarget: 20, WarmENITarget: 1, MaxENI: 3},
+			wantSource: "ConfigMap kube-system/ipamd-pool",
+		},
+		{
+			name: "matching overrides win in order",
+			data: map[string]string{
+				"WARM_IP_TARGET": "10",
+				"nodeOverrides": `[
+					{"nodeSelector": "node.kubernetes.io/instance-type=m5.large", "settings": {"WARM_IP_TARGET": "2", "WARM_ENI_TARGET": "0"}},
+					{"nodeSelector": "pool=large", "settings": {"WARM_IP_TARGET": "30"}},
+					{"nodeSelector": "pool in (small,medium)", "settings": {"WARM_IP_TARGET": "3"}}
+				]`,
+			},
+			want:       poolSettings{WarmIPTarget: 3, WarmENITarget: 0, MaxENI: -1},
+			wantSource: "ConfigMap kube-system/ipamd-pool, nodeOverrides 0,2",
+		},
+		{
+			name:    "value is not an integer",
+			data:    map[string]string{"WARM_IP_TARGET": "ten"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown setting",
+			data:    map[string]string{"WARM_IP_TARGETS": "10"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid node selector",
+			data:    map[string]string{"nodeOverrides": `[{"nodeSelector": "pool in small", "settings": {}}]`},
+			wantErr: true,
+		},
+		{
+			name:    "unknown setting in a matching override",
+			data:    map[string]string{"nodeOverrides": `[{"nodeSelector": "pool=small", "settings": {"MAX_PODS": "10"}}]`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, source, err := p.resolve(env, &corev1.ConfigMap{Data: tt.data}, nodeLabels)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, settings)
+			assert.Equal(t, tt.wantSource, source)
+		})
+	}
+}
+
+func TestReloadPoolConfig(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+	ctx := context.Background()
+	fakeRecorder := eventrecorder.InitMockEventRecorder()
+
+	t.Setenv("POOL_CONFIG_MAP", "ipamd-pool")
+	env := poolSettings{WarmIPTarget: 5, WarmENITarget: 1, MaxENI: -1}
+	c := &IPAMContext{
+		awsClient:     m.awsutils,
+		k8sClient:     m.k8sClient,
+		myNodeName:    "node-1",
+		warmIPTarget:  5,
+		warmENITarget: 1,
+		maxENI:        4,
+		poolConfig:    newPoolConfig(env),
+	}
+	c.initPoolSettings(env)
+	m.awsutils.EXPECT().GetENILimit().Return(4).AnyTimes()
+	assert.NoError(t, m.k8sClient.Create(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"pool": "small"}}}))
+
+	// Without the ConfigMap the env settings stay in place
+	c.reloadPoolConfig(ctx)
+	assert.Len(t, fakeRecorder.Events, 0)
+	assert.Equal(t, poolConfigSourceEnv, c.getConfigForDebug()[poolConfigSourceKey])
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "ipamd-pool"},
+		Data: map[string]string{
+			"MINIMUM_IP_TARGET": "10",
+			"MAX_ENI":           "2",
+			"nodeOverrides":     `[{"nodeSelector": "pool=small", "settings": {"WARM_IP_TARGET": "1"}}]`,
+		},
+	}
+	assert.NoError(t, m.k8sClient.Create(ctx, cm))
+	c.reloadPoolConfig(ctx)
+	assert.Equal(t, 1, c.warmIPTarget)
+	assert.Equal(t, 10, c.minimumIPTarget)
+	assert.Equal(t, 2, c.maxENI)
+	assert.Contains(t, <-fakeRecorder.Events, poolConfigAppliedReason)
+	config := c.getConfigForDebug()
+	assert.Equal(t, 1, config[envWarmIPTarget])
+	assert.Equal(t, 2, config[envMaxENI])
+	assert.Equal(t, "ConfigMap kube-system/ipamd-pool, nodeOverrides 0", config[poolConfigSourceKey])
+
+	// Unchanged settings are not applied again
+	c.reloadPoolConfig(ctx)
+	assert.Len(t, fakeRecorder.Events, 0)
+
+	// Invalid settings are rejected once and the running ones are kept
+	cm.Data["WARM_ENI_TARGET"] = "-1"
+	assert.NoError(t, m.k8sClient.Update(ctx, cm))
+	c.reloadPoolConfig(ctx)
+	c.reloadPoolConfig(ctx)
+	assert.Len(t, fakeRecorder.Events, 1)
+	assert.Contains(t, <-fakeRecorder.Events, poolConfigRejectedReason)
+	assert.Equal(t, 1, c.warmIPTarget)
+	assert.Equal(t, 1, c.warmENITarget)
+
+	// Deleting the ConfigMap restores the env settings
+	assert.NoError(t, m.k8sClient.Delete(ctx, cm))
+	c.reloadPoolConfig(ctx)
+	assert.Equal(t, 5, c.warmIPTarget)
+	assert.Equal(t, 0, c.minimumIPTarget)
+	assert.Equal(t, 4, c.maxENI)
+	assert.Contains(t, <-fakeRecorder.Events, poolConfigAppliedReason)
+	assert.Equal(t, poolConfigSourceEnv, c.getConfigForDebug()[poolConfigSourceKey])
+}
+
+func TestValidatePoolSettings(t *testing.T) {
+	c := &IPAMContext{}
+	env := poolSettings{WarmIPTarget: 5, WarmENITarget: 1, MaxENI: -1}
+	assert.NoError(t, c.validatePoolSettings(poolSettings{WarmIPTarget: 2, MaxENI: -1}, env))
+	assert.Error(t, c.validatePoolSettings(poolSettings{MinimumIPTarget: -2}, env))
+
+	// The adaptive warm IP target owns WARM_IP_TARGET
+	c.adaptiveWarmTarget = newAdaptiveWarmTarget(nil, 1, 0)
+	assert.Error(t, c.validatePoolSettings(poolSettings{WarmIPTarget: 2, MaxENI: -1}, env))
+	assert.NoError(t, c.validatePoolSettings(poolSettings{WarmIPTarget: 5, MinimumIPTarget: 3, MaxENI: -1}, env))
+}
@@ -0,0 +1,150 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
+)
+
+// poolDecisionLogSize is the number of pool decisions kept for /v1/pool-decisions
+const poolDecisionLogSize = 256
+
+// Actions of a pool decision
+const (
+	poolActionNone          = "none"
+	poolActionAssignCidrs   = "assign_cidrs"
+	poolActionAllocateENI   = "allocate_eni"
+	poolActionFreeENI       = "free_eni"
+	poolActionUnassignCidrs = "unassign_cidrs"
+)
+
+// Outcomes of the EC2 calls made for a pool decision
+const (
+	ec2OutcomeSuccess = "success"
+	ec2OutcomeError   = "error"
+)
+
+// PoolDecision records why the IP pool manager did or did not change the warm pool of an ENI pool
+type PoolDecision struct {
+	// Time of the latest occurrence of the decision
+	Time time.Time `json:"time"`
+	// FirstTime is the time of the first of Count identical decisions in a row for the ENI pool
+	FirstTime time.Time `json:"firstTime"`
+	Count     int       `json:"count"`
+	// Function making the decision, such as increaseDatastorePool or tryFreeENI
+	Function string                    `json:"function"`
+	ENIPool  string                    `json:"eniPool,omitempty"`
+	Stats    *datastore.DataStoreStats `json:"stats,omitempty"`
+	Targets  poolSettings              `json:"targets"`
+	Action   string                    `json:"action"`
+	Reason   string                    `json:"reason,omitempty"`
+	// EC2Outcome is empty when no EC2 call was made
+	EC2Outcome string `json:"ec2Outcome,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// sameAs reports whether both decisions only differ by their time and stats
+func (d *PoolDecision) sameAs(other *PoolDecision) bool {
+	return d.Function == other.Function && d.ENIPool == other.ENIPool && d.Targets == other.Targets &&
+		d.Action == other.Action && d.Reason == other.Reason && d.EC2Outcome == other.EC2Outcome && d.Error == other.Error
+}
+
+// setResult sets the action of the decision and the outcome of its EC2 calls
+func (d *PoolDecision) setResult(action, reason string, err error) {
+	d.Action = action
+	d.Reason = reason
+	if err != nil {
+		d.EC2Outcome = ec2OutcomeError
+		d.Error = err.Error()
+	} else {
+		d.EC2Outcome = ec2OutcomeSuccess
+	}
+}
+
+// poolDecisionLog keeps the latest pool decisions, oldest first. A decision identical to the previous decision of
+// the same function for the same ENI pool, such as the pool manager skipping an ENI pool on every tick, replaces
+// it and moves to the end, so ENI pools interleaving their decisions do not evict the others.
+type poolDecisionLog struct {
+	lock    sync.Mutex
+	entries []PoolDecision
+	size    int
+}
+
+func newPoolDecisionLog(size int) *poolDecisionLog {
+	return &poolDecisionLog{entries: make([]PoolDecision, 0, size), size: size}
+}
+
+func (l *poolDecisionLog) record(decision PoolDecision) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	decision.FirstTime = decision.Time
+	decision.Count = 1
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		previous := &l.entries[i]
+		if previous.Function != decision.Function || previous.ENIPool != decision.ENIPool {
+			continue
+		}
+		if previous.sameAs(&decision) {
+			decision.FirstTime = previous.FirstTime
+			decision.Count = previous.Count + 1
+			l.entries = append(l.entries[:i], l.entries[i+1:]...)
+		}
+		break
+	}
+	if len(l.entries) == l.size {
+		l.entries = append(l.entries[:0], l.entries[1:]...)
+	}
+	l.entries = append(l.entries, decision)
+}
+
+// list returns the decisions from the oldest to the latest
+func (l *poolDecisionLog) list() []PoolDecision {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return append([]PoolDecision(nil), l.entries...)
+}
+
+// newPoolDecision starts a decision of the given function with the current targets
+func (c *IPAMContext) newPoolDecision(function, pool string, stats *datastore.DataStoreStats) *PoolDecision {
+	return &PoolDecision{
+		Time:     c.now(),
+		Function: function,
+		ENIPool:  pool,
+		Stats:    stats,
+		Targets:  c.poolTargets(),
+		Action:   poolActionNone,
+	}
+}
+
+func (c *IPAMContext) recordPoolDecision(decision *PoolDecision) {
+	if c.poolDecisions == nil {
+		return
+	}
+	log.Debugf("Pool decision - function: %s, ENI pool: %q, action: %s, reason: %s, error: %s",
+		decision.Function, decision.ENIPool, decision.Action, decision.Reason, decision.Error)
+	c.poolDecisions.record(*decision)
+}
+
+// getPoolDecisionsForDebug returns the latest pool decisions, oldest first
+func (c *IPAMContext) getPoolDecisionsForDebug() []PoolDecision {
+	if c.poolDecisions == nil {
+		return nil
+	}
+	return c.poolDecisions.list()
+}
@@ -0,0 +1,147 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
+)
+
+func TestPoolDecisionLog(t *testing.T) {
+	l := newPoolDecisionLog(3)
+	assert.Empty(t, l.list())
+
+	start := time.Now()
+	for i, reason := range []string{"a", "a", "b", "c", "c", "c", "d"} {
+		l.record(PoolDecision{Time: start.Add(time.Duration(i) * time.Second), Function: "tryFreeENI", Reason: reason})
+	}
+
+	decisions := l.list()
+	assert.Len(t, decisions, 3)
+	assert.Equal(t, []string{"b", "c", "d"}, []string{decisions[0].Reason, decisions[1].Reason, decisions[2].Reason})
+	assert.Equal(t, 1, decisions[0].Count)
+	assert.Equal(t, 3, decisions[1].Count)
+	assert.Equal(t, start.Add(3*time.Second), decisions[1].FirstTime)
+	assert.Equal(t, start.Add(5*time.Second), decisions[1].Time)
+
+	l.record(PoolDecision{Time: start.Add(7 * time.Second), Function: "tryFreeENI", Reason: "e"})
+	decisions = l.list()
+	assert.Equal(t, []string{"c", "d", "e"}, []string{decisions[0].Reason, decisions[1].Reason, decisions[2].Reason})
+}
+
+func TestPoolDecisionLogENIPools(t *testing.T) {
+	l := newPoolDecisionLog(3)
+
+	// The pools interleave their decisions, which still share one entry per pool
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		for j, pool := range []string{"default", "gpu"} {
+			l.record(PoolDecision{Time: start.Add(time.Duration(2*i+j) * time.Second), Function: "tryFreeENI", ENIPool: pool, Reason: "skip"})
+		}
+	}
+	decisions := l.list()
+	assert.Len(t, decisions, 2)
+	assert.Equal(t, "default", decisions[0].ENIPool)
+	assert.Equal(t, 5, decisions[0].Count)
+	assert.Equal(t, start, decisions[0].FirstTime)
+	assert.Equal(t, start.Add(8*time.Second), decisions[0].Time)
+	assert.Equal(t, "gpu", decisions[1].ENIPool)
+	assert.Equal(t, 5, decisions[1].Count)
+
+	// A different decision for a pool starts a new entry, and the next repeat of the other pool moves to the end
+	l.record(PoolDecision{Time: start.Add(10 * time.Second), Function: "tryFreeENI", ENIPool: "default", Reason: "freed"})
+	l.record(PoolDecision{Time: start.Add(11 * time.Second), Function: "tryFreeENI", ENIPool: "gpu", Reason: "skip"})
+	decisions = l.list()
+	assert.Len(t, decisions, 3)
+	assert.Equal(t, []string{"skip", "freed", "skip"}, []string{decisions[0].Reason, decisions[1].Reason, decisions[2].Reason})
+	assert.Equal(t, "gpu", decisions[2].ENIPool)
+	assert.Equal(t, 6, decisions[2].Count)
+}
+
+func TestPoolDecisionsIncreaseDatastorePool(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+	ctx := context.Background()
+
+	mockContext := &IPAMContext{
+		awsClient:     m.awsutils,
+		k8sClient:     m.k8sClient,
+		dataStore:     testDatastore(),
+		maxIPsPerENI:  14,
+		maxENI:        0,
+		warmENITarget: 1,
+		myNodeName:    myNodeName,
+		poolDecisions: newPoolDecisionLog(poolDecisionLogSize),
+	}
+
+	assert.NoError(t, mockContext.increaseDatastorePool(ctx, datastore.DefaultENIPool))
+	mockContext.lastInsufficientCidrError = time.Now()
+	assert.NoError(t, mockContext.increaseDatastorePool(ctx, datastore.DefaultENIPool))
+
+	decisions := mockContext.getPoolDecisionsForDebug()
+	assert.Len(t, decisions, 2)
+	assert.Equal(t, "increaseDatastorePool", decisions[0].Function)
+	assert.Equal(t, poolActionNone, decisions[0].Action)
+	assert.Equal(t, "no existing ENI has room and the max ENI limit (0) is reached", decisions[0].Reason)
+	assert.Equal(t, 1, decisions[0].Targets.WarmENITarget)
+	assert.Equal(t, 0, decisions[0].Stats.TotalIPs)
+	assert.Empty(t, decisions[0].EC2Outcome)
+	assert.Contains(t, decisions[1].Reason, "after the subnet ran out of IPs or prefixes")
+}
+
+func TestPoolDecisionsDecreaseDatastorePool(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+
+	mockContext := &IPAMContext{
+		awsClient:          m.awsutils,
+		dataStore:          testDatastore(),
+		warmIPTarget:       1,
+		lastDecreaseIPPool: time.Now().Add(-60 * time.Second),
+		poolDecisions:      newPoolDecisionLog(poolDecisionLogSize),
+	}
+	mockContext.reconcileCooldownCache.cache = make(map[string]time.Time)
+
+	mockContext.dataStore.AddENI(primaryENIid, primaryDevice, true, false, false)
+	for _, ip := range []string{ipaddr01, ipaddr02, ipaddr03} {
+		mockContext.dataStore.AddIPv4CidrToStore(primaryENIid, net.IPNet{IP: net.ParseIP(ip), Mask: net.IPv4Mask(255, 255, 255, 255)}, false)
+	}
+
+	m.awsutils.EXPECT().DeallocPrefixAddresses(gomock.Any(), gomock.Any()).Return(nil)
+	m.awsutils.EXPECT().DeallocIPAddresses(primaryENIid, gomock.Any()).Return(errors.New("RequestLimitExceeded"))
+
+	mockContext.decreaseDatastorePool(10 * time.Second)
+	mockContext.decreaseDatastorePool(10 * time.Second)
+	mockContext.decreaseDatastorePool(10 * time.Second)
+
+	decisions := mockContext.getPoolDecisionsForDebug()
+	assert.Len(t, decisions, 2)
+	assert.Equal(t, "tryUnassignCidrsFromPool", decisions[0].Function)
+	assert.Equal(t, poolActionUnassignCidrs, decisions[0].Action)
+	assert.Equal(t, "released 2 IPs or prefixes over the warm targets", decisions[0].Reason)
+	assert.Equal(t, 3, decisions[0].Stats.TotalIPs)
+	assert.Equal(t, ec2OutcomeError, decisions[0].EC2Outcome)
+	assert.Equal(t, "RequestLimitExceeded", decisions[0].Error)
+	assert.Equal(t, "decreaseDatastorePool", decisions[1].Function)
+	assert.Equal(t, "last decrease was less than 10s ago", decisions[1].Reason)
+	assert.Equal(t, 2, decisions[1].Count)
+}
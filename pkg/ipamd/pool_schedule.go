@@ -0,0 +1,189 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// envWarmPoolSchedule is a JSON list of time-of-day windows, each replacing some of the pool settings while it is
+// active, e.g. [{"name": "business-hours", "days": "Mon-Fri", "start": "07:30", "end": "19:00",
+// "timezone": "Europe/Paris", "settings": {"WARM_IP_TARGET": "20", "MINIMUM_IP_TARGET": "40"}}].
+// The first active window wins.
+const envWarmPoolSchedule = "WARM_POOL_SCHEDULE"
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// poolScheduleWindow replaces pool settings on the selected days between start and end. A window ending before
+// it starts runs past midnight, and a window starting when it ends lasts the whole day.
+type poolScheduleWindow struct {
+	Name string `json:"name"`
+	// Days is a list of days and ranges of days like "Mon-Fri,Sun". Empty means every day.
+	Days     string            `json:"days,omitempty"`
+	Start    string            `json:"start"`
+	End      string            `json:"end"`
+	Timezone string            `json:"timezone,omitempty"`
+	Settings map[string]string `json:"settings"`
+
+	days     [7]bool
+	start    time.Duration
+	end      time.Duration
+	location *time.Location
+}
+
+// poolSchedule holds the windows of WARM_POOL_SCHEDULE
+type poolSchedule struct {
+	windows []*poolScheduleWindow
+}
+
+// loadPoolSchedule parses and validates WARM_POOL_SCHEDULE. It returns nil when no schedule is configured.
+func (c *IPAMContext) loadPoolSchedule() (*poolSchedule, error) {
+	inputStr := os.Getenv(envWarmPoolSchedule)
+	if inputStr == "" {
+		return nil, nil
+	}
+	var windows []*poolScheduleWindow
+	if err := json.Unmarshal([]byte(inputStr), &windows); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", envWarmPoolSchedule)
+	}
+	if len(windows) == 0 {
+		return nil, nil
+	}
+	names := make(map[string]bool, len(windows))
+	for i, window := range windows {
+		if window.Name == "" || names[window.Name] {
+			return nil, errors.Errorf("%s window %d needs a unique name", envWarmPoolSchedule, i)
+		}
+		names[window.Name] = true
+		if err := window.parse(); err != nil {
+			return nil, errors.Wrapf(err, "%s window %s", envWarmPoolSchedule, window.Name)
+		}
+		// Windows are applied over the ConfigMap settings as well, so only check the values against the env settings
+		if err := c.validatePoolSettings(window.apply(c.poolSettingsEnv), c.poolSettingsEnv); err != nil {
+			return nil, errors.Wrapf(err, "%s window %s", envWarmPoolSchedule, window.Name)
+		}
+	}
+	log.Infof("Using warm pool schedule with %d windows", len(windows))
+	return &poolSchedule{windows: windows}, nil
+}
+
+func (w *poolScheduleWindow) parse() error {
+	var err error
+	if w.start, err = parseTimeOfDay(w.Start); err != nil {
+		return err
+	}
+	if w.end, err = parseTimeOfDay(w.End); err != nil {
+		return err
+	}
+	w.location = time.UTC
+	if w.Timezone != "" {
+		if w.location, err = time.LoadLocation(w.Timezone); err != nil {
+			return err
+		}
+	}
+	if w.Days == "" {
+		w.Days = "Sun-Sat"
+	}
+	for _, part := range strings.Split(w.Days, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		if !isRange {
+			last = first
+		}
+		from, ok := weekdays[strings.ToLower(strings.TrimSpace(first))]
+		to, ok2 := weekdays[strings.ToLower(strings.TrimSpace(last))]
+		if !ok || !ok2 {
+			return errors.Errorf("invalid days %q", w.Days)
+		}
+		for day := from; ; day = (day + 1) % 7 {
+			w.days[day] = true
+			if day == to {
+				break
+			}
+		}
+	}
+	var settings poolSettings
+	return settings.setAll(w.Settings)
+}
+
+// parseTimeOfDay parses "HH:MM" into the time since midnight
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errors.Errorf("invalid time of day %q, expected HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// isActive returns true if now is within the window
+func (w *poolScheduleWindow) isActive(now time.Time) bool {
+	local := now.In(w.location)
+	sinceMidnight := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second
+	today := local.Weekday()
+	yesterday := (today + 6) % 7
+	switch {
+	case w.start == w.end:
+		return w.days[today]
+	case w.start < w.end:
+		return w.days[today] && sinceMidnight >= w.start && sinceMidnight < w.end
+	default:
+		return (w.days[today] && sinceMidnight >= w.start) || (w.days[yesterday] && sinceMidnight < w.end)
+	}
+}
+
+// apply returns the settings with the ones of the window replacing them
+func (w *poolScheduleWindow) apply(settings poolSettings) poolSettings {
+	// The settings were checked when the schedule was loaded
+	_ = settings.setAll(w.Settings)
+	return settings
+}
+
+// activeWindow returns the first window active at the given time, or nil
+func (s *poolSchedule) activeWindow(now time.Time) *poolScheduleWindow {
+	if s == nil {
+		return nil
+	}
+	for _, window := range s.windows {
+		if window.isActive(now) {
+			return window
+		}
+	}
+	return nil
+}
+
+// poolScheduleResponse is served by the warm pool schedule introspection endpoint
+type poolScheduleResponse struct {
+	Windows      []*poolScheduleWindow `json:"windows"`
+	ActiveWindow string                `json:"activeWindow"`
+	Settings     poolSettings          `json:"settings"`
+	Source       string                `json:"source"`
+}
+
+// getPoolScheduleForDebug returns the schedule with the window and the pool settings in use
+func (c *IPAMContext) getPoolScheduleForDebug() poolScheduleResponse {
+	settings, _, window := c.poolSettingsInUse.get()
+	response := poolScheduleResponse{ActiveWindow: window, Settings: settings, Source: c.poolSettingsInUse.describe()}
+	if c.poolSchedule != nil {
+		response.Windows = c.poolSchedule.windows
+	}
+	return response
+}
@@ -0,0 +1,145 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPoolSchedule(t *testing.T) {
+	c := &IPAMContext{}
+	c.initPoolSettings(poolSettings{WarmIPTarget: 5, WarmENITarget: 1, MaxENI: -1})
+
+	schedule, err := c.loadPoolSchedule()
+	assert.NoError(t, err)
+	assert.Nil(t, schedule)
+
+	t.Setenv(envWarmPoolSchedule, `[
+		{"name": "business-hours", "days": "Mon-Fri", "start": "07:30", "end": "19:00", "timezone": "America/New_York",
+		 "settings": {"WARM_IP_TARGET": "20", "MINIMUM_IP_TARGET": "40"}},
+		{"name": "nights", "start": "22:00", "end": "06:00", "settings": {"WARM_IP_TARGET": "1"}}
+	]`)
+	schedule, err = c.loadPoolSchedule()
+	assert.NoError(t, err)
+	assert.Len(t, schedule.windows, 2)
+	assert.Equal(t, [7]bool{false, true, true, true, true, true, false}, schedule.windows[0].days)
+	assert.Equal(t, 7*time.Hour+30*time.Minute, schedule.windows[0].start)
+	assert.Equal(t, "Sun-Sat", schedule.windows[1].Days)
+
+	for _, invalid := range []string{
+		`{"name": "not-a-list"}`,
+		`[{"start": "07:00", "end": "08:00", "settings": {}}]`,
+		`[{"name": "a", "start": "07:00", "end": "08:00"}, {"name": "a", "start": "09:00", "end": "10:00"}]`,
+		`[{"name": "a", "start": "7am", "end": "08:00"}]`,
+		`[{"name": "a", "start": "07:00", "end": "24:30"}]`,
+		`[{"name": "a", "days": "Mon-Funday", "start": "07:00", "end": "08:00"}]`,
+		`[{"name": "a", "start": "07:00", "end": "08:00", "timezone": "Mars/Olympus_Mons"}]`,
+		`[{"name": "a", "start": "07:00", "end": "08:00", "settings": {"WARM_IP_TARGETS": "2"}}]`,
+		`[{"name": "a", "start": "07:00", "end": "08:00", "settings": {"MINIMUM_IP_TARGET": "-2"}}]`,
+	} {
+		t.Setenv(envWarmPoolSchedule, invalid)
+		_, err = c.loadPoolSchedule()
+		assert.Error(t, err, invalid)
+	}
+
+	// The adaptive warm IP target owns WARM_IP_TARGET
+	c.adaptiveWarmTarget = newAdaptiveWarmTarget(nil, 1, 0)
+	t.Setenv(envWarmPoolSchedule, `[{"name": "a", "start": "07:00", "end": "08:00", "settings": {"WARM_IP_TARGET": "2"}}]`)
+	_, err = c.loadPoolSchedule()
+	assert.Error(t, err)
+}
+
+func TestPoolScheduleWindowIsActive(t *testing.T) {
+	newWindow := func(days, start, end, timezone string) *poolScheduleWindow {
+		w := &poolScheduleWindow{Name: "w", Days: days, Start: start, End: end, Timezone: timezone}
+		assert.NoError(t, w.parse())
+		return w
+	}
+	// 2026-10-16 is a Friday
+	at := func(day int, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	weekdays := newWindow("Mon-Fri", "07:30", "19:00", "")
+	assert.False(t, weekdays.isActive(at(16, 7, 29)))
+	assert.True(t, weekdays.isActive(at(16, 7, 30)))
+	assert.True(t, weekdays.isActive(at(16, 18, 59)))
+	assert.False(t, weekdays.isActive(at(16, 19, 0)))
+	assert.False(t, weekdays.isActive(at(17, 12, 0)))
+
+	// A window past midnight belongs to the day it starts on
+	fridayNight := newWindow("Fri", "22:00", "06:00", "")
+	assert.False(t, fridayNight.isActive(at(16, 3, 0)))
+	assert.True(t, fridayNight.isActive(at(16, 23, 0)))
+	assert.True(t, fridayNight.isActive(at(17, 5, 59)))
+	assert.False(t, fridayNight.isActive(at(17, 6, 0)))
+	assert.False(t, fridayNight.isActive(at(17, 23, 0)))
+
+	weekend := newWindow("Sat,Sun", "00:00", "00:00", "")
+	assert.False(t, weekend.isActive(at(16, 23, 59)))
+	assert.True(t, weekend.isActive(at(17, 0, 0)))
+	assert.True(t, weekend.isActive(at(18, 23, 59)))
+
+	wrapping := newWindow("Sat-Mon", "00:00", "00:00", "")
+	assert.True(t, wrapping.isActive(at(19, 12, 0)))
+	assert.False(t, wrapping.isActive(at(20, 12, 0)))
+
+	// 12:00 UTC is 08:00 in New York during daylight saving time
+	newYork := newWindow("Mon-Fri", "07:30", "19:00", "America/New_York")
+	assert.True(t, newYork.isActive(at(16, 12, 0)))
+	assert.False(t, newYork.isActive(at(16, 11, 0)))
+}
+
+func TestApplyEffectivePoolSettings(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+	m.awsutils.EXPECT().GetENILimit().Return(4).AnyTimes()
+
+	t.Setenv(envWarmPoolSchedule, `[{"name": "mornings", "start": "07:00", "end": "10:00", "settings": {"WARM_IP_TARGET": "20", "MINIMUM_IP_TARGET": "40"}}]`)
+	c := &IPAMContext{awsClient: m.awsutils, warmIPTarget: 5, warmENITarget: 1, maxENI: 4}
+	c.initPoolSettings(poolSettings{WarmIPTarget: 5, WarmENITarget: 1, MaxENI: -1})
+	var err error
+	c.poolSchedule, err = c.loadPoolSchedule()
+	assert.NoError(t, err)
+
+	c.applyEffectivePoolSettings(time.Date(2026, 10, 16, 6, 0, 0, 0, time.UTC))
+	assert.Equal(t, 5, c.warmIPTarget)
+	assert.Equal(t, "", c.getPoolScheduleForDebug().ActiveWindow)
+
+	c.applyEffectivePoolSettings(time.Date(2026, 10, 16, 7, 0, 0, 0, time.UTC))
+	assert.Equal(t, 20, c.warmIPTarget)
+	assert.Equal(t, 40, c.minimumIPTarget)
+	assert.Equal(t, 1, c.warmENITarget)
+	response := c.getPoolScheduleForDebug()
+	assert.Equal(t, "mornings", response.ActiveWindow)
+	assert.Equal(t, 20, response.Settings.WarmIPTarget)
+	assert.Equal(t, "env, WARM_POOL_SCHEDULE window mornings", response.Source)
+	assert.Len(t, response.Windows, 1)
+	assert.Equal(t, "env, WARM_POOL_SCHEDULE window mornings", c.getConfigForDebug()[poolConfigSourceKey])
+
+	// The window applies over the ConfigMap settings
+	c.poolConfig = &poolConfig{applied: poolSettings{WarmIPTarget: 8, WarmENITarget: 2, MaxENI: 3}, source: "ConfigMap kube-system/ipamd-pool"}
+	c.applyEffectivePoolSettings(time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC))
+	assert.Equal(t, 20, c.warmIPTarget)
+	assert.Equal(t, 2, c.warmENITarget)
+	assert.Equal(t, 3, c.maxENI)
+
+	c.applyEffectivePoolSettings(time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC))
+	assert.Equal(t, 8, c.warmIPTarget)
+	assert.Equal(t, 0, c.minimumIPTarget)
+	assert.Equal(t, "", c.getPoolScheduleForDebug().ActiveWindow)
+}
@@ -31,6 +31,7 @@ import (
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/eniconfig"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/networkutils"
 	"github.com/aws/amazon-vpc-cni-k8s/rpc"
@@ -43,6 +44,13 @@ const (
 	grpcHealthServiceName = "grpc.health.v1.aws-node"
 
 	vpccniPodIPKey = "vpc.amazonaws.com/pod-ips"
+
+	// vpccniStickyIPKey opts a pod into getting its IP address back when it is recreated on the node within
+	// STICKY_IP_GRACE_PERIOD, as StatefulSet pods are
+	vpccniStickyIPKey = "vpc.amazonaws.com/sticky-ip"
+
+	// vpccniStaticIPKey names the IPv4 address a pod wants, honoured when ENABLE_STATIC_POD_IP is set
+	vpccniStaticIPKey = "vpc.amazonaws.com/static-ip"
 )
 
 // server controls RPC service responses.
@@ -68,6 +76,7 @@ func (s *server) AddNetwork(ctx context.Context, in *rpc.AddNetworkRequest) (*rp
 		in.Netns, in.ContainerID, in.IfName)
 	log.Debugf("AddNetworkRequest: %s", in)
 	prometheusmetrics.AddIPCnt.Inc()
+	s.ipamContext.adaptiveWarmTarget.recordAdd()
 
 	// Do this early, but after logging trace
 	if err := s.validateVersion(in.ClientVersion); err != nil {
@@ -170,8 +179,30 @@ func (s *server) AddNetwork(ctx context.Context, in *rpc.AddNetworkRequest) (*rp
 		ipamMetadata := datastore.IPAMMetadata{
 			K8SPodNamespace: in.K8S_POD_NAMESPACE,
 			K8SPodName:      in.K8S_POD_NAME,
+			StickyIP:        s.isStickyIPPod(in.K8S_POD_NAME, in.K8S_POD_NAMESPACE),
+		}
+		ipamMetadata.ENIPool, err = s.podENIPool(in.K8S_POD_NAME, in.K8S_POD_NAMESPACE)
+		if err != nil {
+			log.Warnf("Rejecting AddNetwork request: %v", err)
+			return nil, err
+		}
+		var staticIP net.IP
+		staticIP, err = s.staticPodIP(in.K8S_POD_NAME, in.K8S_POD_NAMESPACE)
+		if err != nil {
+			log.Warnf("Rejecting AddNetwork request: %v", err)
+			return nil, err
+		}
+		if staticIP != nil {
+			// In dual-stack mode, the pod gets its IPv6 address from the pool. Running out of IPv6 addresses fails the
+			// request like for any other pod.
+			ipv4Addr, ipv6Addr, deviceNumber, err = s.ipamContext.assignStaticIPAddress(ipamKey, ipamMetadata, staticIP)
+			if err != nil && !errors.Is(err, datastore.ErrStaticIPNoIPv6) {
+				log.Errorf("Send AddNetworkReply: failed to assign static IP %s: %v", staticIP.String(), err)
+				return nil, staticIPStatus(staticIP, err)
+			}
+		} else {
+			ipv4Addr, ipv6Addr, deviceNumber, err = s.ipamContext.dataStore.AssignPodIPAddress(ipamKey, ipamMetadata, s.ipamContext.enableIPv4, s.ipamContext.enableIPv6)
 		}
-		ipv4Addr, ipv6Addr, deviceNumber, err = s.ipamContext.dataStore.AssignPodIPAddress(ipamKey, ipamMetadata, s.ipamContext.enableIPv4, s.ipamContext.enableIPv6)
 	}
 
 	var pbVPCV4cidrs, pbVPCV6cidrs []string
@@ -191,7 +222,8 @@ func (s *server) AddNetwork(ctx context.Context, in *rpc.AddNetworkRequest) (*rp
 				pbVPCV4cidrs = append(pbVPCV4cidrs, cidr)
 			}
 		}
-	} else if s.ipamContext.enableIPv6 && ipv6Addr != "" {
+	}
+	if s.ipamContext.enableIPv6 && ipv6Addr != "" {
 		pbVPCV6cidrs, err = s.ipamContext.awsClient.GetVPCIPv6CIDRs()
 		if err != nil {
 			return nil, err
@@ -203,13 +235,8 @@ func (s *server) AddNetwork(ctx context.Context, in *rpc.AddNetworkRequest) (*rp
 
 	if s.ipamContext.enablePodIPAnnotation {
 		// On ADD, we pass empty string as there is no IP being released
-		if ipv4Addr != "" {
-			err = s.ipamContext.AnnotatePod(in.K8S_POD_NAME, in.K8S_POD_NAMESPACE, vpccniPodIPKey, ipv4Addr, "")
-			if err != nil {
-				log.Errorf("Failed to add the pod annotation: %v", err)
-			}
-		} else if ipv6Addr != "" {
-			err = s.ipamContext.AnnotatePod(in.K8S_POD_NAME, in.K8S_POD_NAMESPACE, vpccniPodIPKey, ipv6Addr, "")
+		if podIPs := podIPAnnotation(ipv4Addr, ipv6Addr); podIPs != "" {
+			err = s.ipamContext.AnnotatePod(in.K8S_POD_NAME, in.K8S_POD_NAMESPACE, vpccniPodIPKey, podIPs, "")
 			if err != nil {
 				log.Errorf("Failed to add the pod annotation: %v", err)
 			}
@@ -245,7 +272,8 @@ func (s *server) DelNetwork(ctx context.Context, in *rpc.DelNetworkRequest) (*rp
 	log.Infof("Received DelNetwork for Sandbox %s", in.ContainerID)
 	log.Debugf("DelNetworkRequest: %s", in)
 	prometheusmetrics.DelIPCnt.With(prometheus.Labels{"reason": in.Reason}).Inc()
-	var ipv4Addr, ipv6Addr, cidrStr string
+	s.ipamContext.adaptiveWarmTarget.recordDel()
+	var cidrStr string
 
 	// Do this early, but after logging trace
 	if err := s.validateVersion(in.ClientVersion); err != nil {
@@ -258,13 +286,10 @@ func (s *server) DelNetwork(ctx context.Context, in *rpc.DelNetworkRequest) (*rp
 		IfName:      in.IfName,
 		NetworkName: in.NetworkName,
 	}
-	eni, ip, deviceNumber, err := s.ipamContext.dataStore.UnassignPodIPAddress(ipamKey)
+	eni, ipv4Addr, ipv6Addr, deviceNumber, err := s.ipamContext.dataStore.UnassignPodIPAddress(ipamKey)
 	if s.ipamContext.enableIPv4 {
-		ipv4Addr = ip
-		cidr := net.IPNet{IP: net.ParseIP(ip), Mask: net.IPv4Mask(255, 255, 255, 255)}
+		cidr := net.IPNet{IP: net.ParseIP(ipv4Addr), Mask: net.IPv4Mask(255, 255, 255, 255)}
 		cidrStr = cidr.String()
-	} else if s.ipamContext.enableIPv6 {
-		ipv6Addr = ip
 	}
 
 	if s.ipamContext.enableIPv4 && eni != nil {
@@ -309,7 +334,7 @@ func (s *server) DelNetwork(ctx context.Context, in *rpc.DelNetworkRequest) (*rp
 
 	if s.ipamContext.enablePodIPAnnotation {
 		// On DEL, we pass IP being released
-		err = s.ipamContext.AnnotatePod(in.K8S_POD_NAME, in.K8S_POD_NAMESPACE, vpccniPodIPKey, "", ip)
+		err = s.ipamContext.AnnotatePod(in.K8S_POD_NAME, in.K8S_POD_NAMESPACE, vpccniPodIPKey, "", podIPAnnotation(ipv4Addr, ipv6Addr))
 		if err != nil {
 			log.Errorf("Failed to delete the pod annotation: %v", err)
 		}
@@ -320,6 +345,325 @@ func (s *server) DelNetwork(ctx context.Context, in *rpc.DelNetworkRequest) (*rp
 	return &rpc.DelNetworkReply{Success: err == nil, IPv4Addr: ipv4Addr, IPv6Addr: ipv6Addr, DeviceNumber: int32(deviceNumber)}, err
 }
 
+// CheckNetwork confirms that the datastore still holds an allocation for the sandbox and returns it
+func (s *server) CheckNetwork(ctx context.Context, in *rpc.CheckNetworkRequest) (*rpc.CheckNetworkReply, error) {
+	log.Infof("Received CheckNetwork for Sandbox %s", in.ContainerID)
+	log.Debugf("CheckNetworkRequest: %s", in)
+
+	// Do this early, but after logging trace
+	if err := s.validateVersion(in.ClientVersion); err != nil {
+		log.Warnf("Rejecting CheckNetwork request: %v", err)
+		return nil, err
+	}
+
+	ipamKey := datastore.IPAMKey{
+		ContainerID: in.ContainerID,
+		IfName:      in.IfName,
+		NetworkName: in.NetworkName,
+	}
+	ipv4Addr, ipv6Addr, deviceNumber, err := s.ipamContext.dataStore.GetPodIPAddress(ipamKey)
+	if err != nil {
+		log.Warnf("Send CheckNetworkReply: sandbox %s: %v", ipamKey, err)
+		return &rpc.CheckNetworkReply{Success: false}, err
+	}
+	log.Infof("Send CheckNetworkReply: IPv4Addr: %s, IPv6Addr: %s, DeviceNumber: %d", ipv4Addr, ipv6Addr, deviceNumber)
+	return &rpc.CheckNetworkReply{Success: true, IPv4Addr: ipv4Addr, IPv6Addr: ipv6Addr, DeviceNumber: int32(deviceNumber)}, nil
+}
+
+// GCNetwork releases every allocation on the network that the container runtime no longer considers valid. Released
+// allocations are returned so that the CNI can tear down the routing rules and host-side veths left behind for them.
+func (s *server) GCNetwork(ctx context.Context, in *rpc.GCNetworkRequest) (*rpc.GCNetworkReply, error) {
+	log.Infof("Received GCNetwork for network %s with %d valid attachments", in.NetworkName, len(in.ValidAttachments))
+	log.Debugf("GCNetworkRequest: %s", in)
+
+	// Do this early, but after logging trace
+	if err := s.validateVersion(in.ClientVersion); err != nil {
+		log.Warnf("Rejecting GCNetwork request: %v", err)
+		return nil, err
+	}
+
+	validAttachments := make([]datastore.IPAMKey, 0, len(in.ValidAttachments))
+	for _, attachment := range in.ValidAttachments {
+		validAttachments = append(validAttachments, datastore.IPAMKey{
+			ContainerID: attachment.ContainerID,
+			IfName:      attachment.IfName,
+			NetworkName: in.NetworkName,
+		})
+	}
+	released, err := s.ipamContext.dataStore.ReleaseStaleAllocations(in.NetworkName, validAttachments)
+	if err != nil {
+		log.Errorf("Send GCNetworkReply: failed to release stale allocations: %v", err)
+		return &rpc.GCNetworkReply{Success: false}, err
+	}
+
+	reply := &rpc.GCNetworkReply{Success: true}
+	// Both addresses of a dual-stack sandbox are released together and reported as a single allocation
+	allocations := make(map[datastore.IPAMKey]*rpc.GCReleasedAllocation, len(released))
+	for _, info := range released {
+		allocation, ok := allocations[info.IPAMKey]
+		if !ok {
+			allocation = &rpc.GCReleasedAllocation{
+				ContainerID:       info.IPAMKey.ContainerID,
+				IfName:            info.IPAMKey.IfName,
+				K8S_POD_NAME:      info.IPAMMetadata.K8SPodName,
+				K8S_POD_NAMESPACE: info.IPAMMetadata.K8SPodNamespace,
+				DeviceNumber:      int32(info.DeviceNumber),
+				PodInUse:          s.ipamContext.dataStore.HasPodAllocation(info.IPAMMetadata.K8SPodNamespace, info.IPAMMetadata.K8SPodName),
+			}
+			allocations[info.IPAMKey] = allocation
+			reply.Released = append(reply.Released, allocation)
+		}
+		if net.ParseIP(info.IP).To4() == nil {
+			allocation.IPv6Addr = info.IP
+		} else {
+			// The device number is the one of the ENI holding the IPv4 address, as in AddNetworkReply
+			allocation.IPv4Addr = info.IP
+			allocation.DeviceNumber = int32(info.DeviceNumber)
+		}
+	}
+	log.Infof("Send GCNetworkReply: released %d stale allocations", len(reply.Released))
+	return reply, nil
+}
+
+// Status reports whether ipamd is ready to serve AddNetwork requests
+func (s *server) Status(ctx context.Context, in *rpc.StatusRequest) (*rpc.StatusReply, error) {
+	log.Debugf("Received Status request: %s", in)
+
+	// Do this early, but after logging trace
+	if err := s.validateVersion(in.ClientVersion); err != nil {
+		log.Warnf("Rejecting Status request: %v", err)
+		return nil, err
+	}
+
+	if s.ipamContext.dataStore == nil {
+		return &rpc.StatusReply{Ready: false, Reason: "datastore is not initialized"}, nil
+	}
+	if s.ipamContext.dataStore.GetENIs() == 0 {
+		return &rpc.StatusReply{Ready: false, Reason: "datastore has no ENIs"}, nil
+	}
+	return &rpc.StatusReply{Ready: true}, nil
+}
+
+// isStickyIPPod returns true if the pod opted into getting its IP address back when it is recreated
+func (s *server) isStickyIPPod(podName, podNamespace string) bool {
+	if !s.ipamContext.dataStore.StickyIPEnabled() || podName == "" {
+		return false
+	}
+	pod, err := s.ipamContext.GetPod(podName, podNamespace)
+	if err != nil {
+		log.Warnf("Failed to get pod %s/%s, not reserving its IP on delete: %v", podNamespace, podName, err)
+		return false
+	}
+	return pod.Annotations[vpccniStickyIPKey] == "true"
+}
+
+// staticPodIP returns the IPv4 address requested in the pod's static IP annotation, or nil when there is none
+func (s *server) staticPodIP(podName, podNamespace string) (net.IP, error) {
+	if !s.ipamContext.enableStaticPodIP || podName == "" {
+		return nil, nil
+	}
+	pod, err := s.ipamContext.GetPod(podName, podNamespace)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to get pod %s/%s to check for a static IP: %v", podNamespace, podName, err)
+	}
+	val, ok := pod.Annotations[vpccniStaticIPKey]
+	if !ok {
+		return nil, nil
+	}
+	if !s.ipamContext.enableIPv4 {
+		return nil, status.Errorf(codes.InvalidArgument, "%s annotation is only supported in IPv4 mode", vpccniStaticIPKey)
+	}
+	ip := net.ParseIP(strings.TrimSpace(val))
+	if ip == nil || ip.To4() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s annotation %q is not an IPv4 address", vpccniStaticIPKey, val)
+	}
+	return ip.To4(), nil
+}
+
+// podIPAnnotation returns the value of the pod IP annotation, a dual-stack pod lists its IPv4 address first
+func podIPAnnotation(ipv4Addr, ipv6Addr string) string {
+	var podIPs []string
+	for _, ip := range []string{ipv4Addr, ipv6Addr} {
+		if ip != "" {
+			podIPs = append(podIPs, ip)
+		}
+	}
+	return strings.Join(podIPs, ",")
+}
+
+// podENIPool returns the ENI pool, from ENI_CONFIG_POOLS or of a network card, that the pod gets its IP from
+func (s *server) podENIPool(podName, podNamespace string) (string, error) {
+	if podName == "" {
+		return datastore.DefaultENIPool, nil
+	}
+	pool, err := s.ipamContext.getPodENIPool(podName, podNamespace)
+	if errors.Is(err, eniconfig.ErrUnknownENIConfigPool) {
+		return "", status.Errorf(codes.InvalidArgument, "pod %s/%s: %v, check ENI_CONFIG_POOLS", podNamespace, podName, err)
+	}
+	if errors.Is(err, errUnknownNetworkCard) {
+		return "", status.Errorf(codes.InvalidArgument, "pod %s/%s: %s annotation: %v", podNamespace, podName, vpccniNetworkCardKey, err)
+	}
+	if err != nil {
+		return "", status.Errorf(codes.Unavailable, "failed to get pod %s/%s to select its ENI pool: %v", podNamespace, podName, err)
+	}
+	return pool, nil
+}
+
+// staticIPStatus converts a failure to assign a static IP into the gRPC status returned to the CNI plugin
+func staticIPStatus(ip net.IP, err error) error {
+	code := codes.Unavailable
+	switch {
+	case errors.Is(err, datastore.ErrStaticIPInUse):
+		code = codes.AlreadyExists
+	case errors.Is(err, errStaticIPOutsideSubnets):
+		code = codes.InvalidArgument
+	}
+	return status.Errorf(code, "failed to assign static IP %s: %v", ip.String(), err)
+}
+
+// allocationFilter selects allocations by pod and sandbox. Empty fields match every allocation.
+type allocationFilter struct {
+	podName      string
+	podNamespace string
+	containerID  string
+}
+
+func (f allocationFilter) matches(info datastore.PodIPInfo) bool {
+	return (f.podName == "" || f.podName == info.IPAMMetadata.K8SPodName) &&
+		(f.podNamespace == "" || f.podNamespace == info.IPAMMetadata.K8SPodNamespace) &&
+		(f.containerID == "" || f.containerID == info.IPAMKey.ContainerID)
+}
+
+// toRPCAllocation converts a datastore allocation into its gRPC representation
+func toRPCAllocation(info datastore.PodIPInfo) *rpc.Allocation {
+	allocation := &rpc.Allocation{
+		ContainerID:       info.IPAMKey.ContainerID,
+		IfName:            info.IPAMKey.IfName,
+		NetworkName:       info.IPAMKey.NetworkName,
+		K8S_POD_NAME:      info.IPAMMetadata.K8SPodName,
+		K8S_POD_NAMESPACE: info.IPAMMetadata.K8SPodNamespace,
+		ENIID:             info.ENIID,
+		DeviceNumber:      int32(info.DeviceNumber),
+	}
+	if ip := net.ParseIP(info.IP); ip != nil && ip.To4() == nil {
+		allocation.IPv6Addr = info.IP
+	} else {
+		allocation.IPv4Addr = info.IP
+	}
+	return allocation
+}
+
+// listAllocations returns the allocations in the datastore that match the filter
+func (s *server) listAllocations(filter allocationFilter) []*rpc.Allocation {
+	var allocations []*rpc.Allocation
+	infos := append(s.ipamContext.dataStore.AllocatedIPs(), s.ipamContext.dataStore.AllocatedIPv6s()...)
+	for _, info := range infos {
+		if filter.matches(info) {
+			allocations = append(allocations, toRPCAllocation(info))
+		}
+	}
+	return allocations
+}
+
+// ListAllocations returns the IP allocations held by ipamd, optionally filtered by pod and sandbox
+func (s *server) ListAllocations(ctx context.Context, in *rpc.ListAllocationsRequest) (*rpc.ListAllocationsReply, error) {
+	log.Debugf("Received ListAllocations: %s", in)
+
+	allocations := s.listAllocations(allocationFilter{
+		podName:      in.K8S_POD_NAME,
+		podNamespace: in.K8S_POD_NAMESPACE,
+		containerID:  in.ContainerID,
+	})
+	log.Debugf("Send ListAllocationsReply: %d allocations", len(allocations))
+	return &rpc.ListAllocationsReply{Success: true, Allocations: allocations}, nil
+}
+
+// GetPodNetwork returns the IP, ENI, device number and, for pods using security groups, the VLAN of a pod
+func (s *server) GetPodNetwork(ctx context.Context, in *rpc.GetPodNetworkRequest) (*rpc.GetPodNetworkReply, error) {
+	log.Debugf("Received GetPodNetwork: %s", in)
+
+	if in.K8S_POD_NAME == "" || in.K8S_POD_NAMESPACE == "" {
+		return &rpc.GetPodNetworkReply{Success: false}, status.Error(codes.InvalidArgument, "pod name and namespace are required")
+	}
+
+	allocations := s.listAllocations(allocationFilter{podName: in.K8S_POD_NAME, podNamespace: in.K8S_POD_NAMESPACE})
+	if len(allocations) > 0 {
+		return &rpc.GetPodNetworkReply{Success: true, Allocations: allocations}, nil
+	}
+
+	// Branch ENI pods are not tracked in the datastore, their network is described by the pod-eni annotation
+	if s.ipamContext.enablePodENI {
+		pod, err := s.ipamContext.GetPod(in.K8S_POD_NAME, in.K8S_POD_NAMESPACE)
+		if err != nil {
+			log.Warnf("Send GetPodNetworkReply: Failed to get pod spec: %v", err)
+		} else if val, branch := pod.Annotations["vpc.amazonaws.com/pod-eni"]; branch {
+			var podENIData []PodENIData
+			if err := json.Unmarshal([]byte(val), &podENIData); err != nil || len(podENIData) < 1 {
+				log.Errorf("Failed to unmarshal PodENIData JSON: %v", err)
+				return &rpc.GetPodNetworkReply{Success: false}, status.Errorf(codes.Internal, "malformed pod-eni annotation on pod %s/%s", in.K8S_POD_NAMESPACE, in.K8S_POD_NAME)
+			}
+			return &rpc.GetPodNetworkReply{Success: true, Allocations: []*rpc.Allocation{{
+				K8S_POD_NAME:      in.K8S_POD_NAME,
+				K8S_POD_NAMESPACE: in.K8S_POD_NAMESPACE,
+				IPv4Addr:          podENIData[0].PrivateIP,
+				IPv6Addr:          podENIData[0].IPV6Addr,
+				ENIID:             podENIData[0].ENIID,
+				DeviceNumber:      -1, // Branch ENIs hang off the trunk ENI
+				PodVlanId:         int32(podENIData[0].VlanID),
+			}}}, nil
+		}
+	}
+	return &rpc.GetPodNetworkReply{Success: false}, status.Errorf(codes.NotFound, "no network found for pod %s/%s", in.K8S_POD_NAMESPACE, in.K8S_POD_NAME)
+}
+
+// WatchAllocations streams allocation changes that match the filter. The stream starts with an ASSIGNED event for
+// every current allocation, so a watcher may see an allocation made while the watch is being set up twice.
+func (s *server) WatchAllocations(in *rpc.WatchAllocationsRequest, stream rpc.CNIBackend_WatchAllocationsServer) error {
+	log.Infof("Received WatchAllocations: %s", in)
+	filter := allocationFilter{
+		podName:      in.K8S_POD_NAME,
+		podNamespace: in.K8S_POD_NAMESPACE,
+		containerID:  in.ContainerID,
+	}
+
+	// Subscribe before listing so that no change is lost in between
+	events, cancel := s.ipamContext.dataStore.WatchAllocations()
+	defer cancel()
+
+	for _, allocation := range s.listAllocations(filter) {
+		if err := stream.Send(&rpc.AllocationEvent{Type: rpc.AllocationEvent_ASSIGNED, Allocation: allocation}); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Infof("WatchAllocations stream closed: %v", stream.Context().Err())
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "allocation watcher fell behind, list allocations and watch again")
+			}
+			if !filter.matches(event.PodIPInfo) {
+				continue
+			}
+			eventType := rpc.AllocationEvent_ASSIGNED
+			if event.Type == datastore.AllocationUnassigned {
+				eventType = rpc.AllocationEvent_UNASSIGNED
+			}
+			if err := stream.Send(&rpc.AllocationEvent{Type: eventType, Allocation: toRPCAllocation(event.PodIPInfo)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// CNIBackendServer returns the handler of the CNI plugin requests, without serving it over gRPC
+func (c *IPAMContext) CNIBackendServer(version string) rpc.CNIBackendServer {
+	return &server{version: version, ipamContext: c}
+}
+
 // RunRPCHandler handles request from gRPC
 func (c *IPAMContext) RunRPCHandler(version string) error {
 	log.Infof("Serving RPC Handler version %s on %s", version, ipamdgRPCaddress)
@@ -329,7 +673,7 @@ func (c *IPAMContext) RunRPCHandler(version string) error {
 		return errors.Wrap(err, "ipamd: failed to listen to gRPC port")
 	}
 	grpcServer := grpc.NewServer()
-	rpc.RegisterCNIBackendServer(grpcServer, &server{version: version, ipamContext: c})
+	rpc.RegisterCNIBackendServer(grpcServer, c.CNIBackendServer(version))
 	healthServer := health.NewServer()
 	// If ipamd can talk to the API server and to the EC2 API, the pod is healthy.
 	// No need to ever change this to HealthCheckResponse_NOT_SERVING since it's a local service only
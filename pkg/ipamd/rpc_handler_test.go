@@ -17,8 +17,16 @@ import (
 	"context"
 	"net"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/eniconfig"
 	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/vpc"
 
 	pb "github.com/aws/amazon-vpc-cni-k8s/rpc"
 
@@ -26,6 +34,11 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestServer_VersionCheck(t *testing.T) {
@@ -72,6 +85,15 @@ func TestServer_VersionCheck(t *testing.T) {
 	_, err = rpcServer.DelNetwork(context.TODO(), delReq)
 	assert.EqualError(t, err, datastore.ErrUnknownPod.Error())
 
+	checkReq := &pb.CheckNetworkRequest{
+		ClientVersion: "1.2.3",
+		NetworkName:   "net0",
+		ContainerID:   "cid",
+		IfName:        "eni",
+	}
+	_, err = rpcServer.CheckNetwork(context.TODO(), checkReq)
+	assert.EqualError(t, err, datastore.ErrUnknownPod.Error())
+
 	// Sad path
 
 	addReq.ClientVersion = "1.2.4"
@@ -81,6 +103,368 @@ func TestServer_VersionCheck(t *testing.T) {
 	delReq.ClientVersion = "1.2.4"
 	_, err = rpcServer.DelNetwork(context.TODO(), delReq)
 	assert.Error(t, err)
+
+	checkReq.ClientVersion = "1.2.4"
+	_, err = rpcServer.CheckNetwork(context.TODO(), checkReq)
+	assert.Error(t, err)
+
+	_, err = rpcServer.GCNetwork(context.TODO(), &pb.GCNetworkRequest{ClientVersion: "1.2.4", NetworkName: "net0"})
+	assert.Error(t, err)
+
+	_, err = rpcServer.Status(context.TODO(), &pb.StatusRequest{ClientVersion: "1.2.4"})
+	assert.Error(t, err)
+}
+
+func TestServer_CheckNetwork(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+
+	ds := datastore.NewDataStore(log, datastore.NullCheckpoint{}, false)
+	assert.NoError(t, ds.AddENI("eni-1", 1, true, false, false))
+	assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP("192.168.1.100"), Mask: net.CIDRMask(32, 32)}, false))
+	ipamKey := datastore.IPAMKey{NetworkName: "net0", ContainerID: "cid", IfName: "eth0"}
+	_, _, err := ds.AssignPodIPv4Address(ipamKey, datastore.IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "sample-pod"})
+	assert.NoError(t, err)
+
+	rpcServer := server{
+		version: "1.2.3",
+		ipamContext: &IPAMContext{
+			enableIPv4: true,
+			dataStore:  ds,
+		},
+	}
+
+	reply, err := rpcServer.CheckNetwork(context.TODO(), &pb.CheckNetworkRequest{
+		ClientVersion: "1.2.3",
+		NetworkName:   "net0",
+		ContainerID:   "cid",
+		IfName:        "eth0",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &pb.CheckNetworkReply{Success: true, IPv4Addr: "192.168.1.100", DeviceNumber: 1}, reply)
+
+	// A check must not release the allocation
+	assert.Equal(t, 1, len(ds.AllocatedIPs()))
+
+	reply, err = rpcServer.CheckNetwork(context.TODO(), &pb.CheckNetworkRequest{
+		ClientVersion: "1.2.3",
+		NetworkName:   "net0",
+		ContainerID:   "other-cid",
+		IfName:        "eth0",
+	})
+	assert.EqualError(t, err, datastore.ErrUnknownPod.Error())
+	assert.False(t, reply.Success)
+}
+
+func TestServer_GCNetwork(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+
+	ds := datastore.NewDataStore(log, datastore.NullCheckpoint{}, false)
+	assert.NoError(t, ds.AddENI("eni-1", 1, true, false, false))
+	for _, ip := range []string{"192.168.1.100", "192.168.1.101", "192.168.1.102"} {
+		assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(32, 32)}, false))
+	}
+	for _, key := range []struct {
+		containerID string
+		podName     string
+	}{
+		{"valid-cid", "sample-pod"},
+		{"stale-cid", "sample-pod"},
+		{"deleted-cid", "deleted-pod"},
+	} {
+		_, _, err := ds.AssignPodIPv4Address(datastore.IPAMKey{NetworkName: "net0", ContainerID: key.containerID, IfName: "eth0"},
+			datastore.IPAMMetadata{K8SPodNamespace: "default", K8SPodName: key.podName})
+		assert.NoError(t, err)
+	}
+
+	rpcServer := server{
+		version: "1.2.3",
+		ipamContext: &IPAMContext{
+			enableIPv4: true,
+			dataStore:  ds,
+		},
+	}
+
+	reply, err := rpcServer.GCNetwork(context.TODO(), &pb.GCNetworkRequest{
+		ClientVersion:    "1.2.3",
+		NetworkName:      "net0",
+		ValidAttachments: []*pb.GCAttachment{{ContainerID: "valid-cid", IfName: "eth0"}},
+	})
+	assert.NoError(t, err)
+	assert.True(t, reply.Success)
+	assert.Equal(t, 2, len(reply.Released))
+
+	released := make(map[string]*pb.GCReleasedAllocation)
+	for _, allocation := range reply.Released {
+		released[allocation.ContainerID] = allocation
+	}
+	// The valid sandbox of sample-pod still owns the host veth
+	assert.True(t, released["stale-cid"].PodInUse)
+	assert.Equal(t, int32(1), released["stale-cid"].DeviceNumber)
+	assert.False(t, released["deleted-cid"].PodInUse)
+	assert.Equal(t, "deleted-pod", released["deleted-cid"].K8S_POD_NAME)
+
+	allocated := ds.AllocatedIPs()
+	assert.Equal(t, 1, len(allocated))
+	assert.Equal(t, "valid-cid", allocated[0].IPAMKey.ContainerID)
+}
+
+func TestServer_isStickyIPPod(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+
+	for name, annotations := range map[string]map[string]string{
+		"sticky-pod":  {vpccniStickyIPKey: "true"},
+		"regular-pod": nil,
+	} {
+		assert.NoError(t, m.k8sClient.Create(context.TODO(), &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+		}))
+	}
+
+	// Pods are not looked up while sticky IPs are disabled
+	rpcServer := server{
+		ipamContext: &IPAMContext{
+			dataStore: datastore.NewDataStore(log, datastore.NullCheckpoint{}, false),
+		},
+	}
+	assert.False(t, rpcServer.isStickyIPPod("sticky-pod", "default"))
+
+	t.Setenv("STICKY_IP_GRACE_PERIOD", "300")
+	rpcServer.ipamContext.dataStore = datastore.NewDataStore(log, datastore.NullCheckpoint{}, false)
+	rpcServer.ipamContext.k8sClient = m.k8sClient
+	assert.True(t, rpcServer.isStickyIPPod("sticky-pod", "default"))
+	assert.False(t, rpcServer.isStickyIPPod("regular-pod", "default"))
+	assert.False(t, rpcServer.isStickyIPPod("missing-pod", "default"))
+}
+
+func TestServer_Status(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+
+	rpcServer := server{
+		version:     "1.2.3",
+		ipamContext: &IPAMContext{},
+	}
+	reply, err := rpcServer.Status(context.TODO(), &pb.StatusRequest{ClientVersion: "1.2.3"})
+	assert.NoError(t, err)
+	assert.Equal(t, &pb.StatusReply{Ready: false, Reason: "datastore is not initialized"}, reply)
+
+	rpcServer.ipamContext.dataStore = datastore.NewDataStore(log, datastore.NullCheckpoint{}, false)
+	reply, err = rpcServer.Status(context.TODO(), &pb.StatusRequest{ClientVersion: "1.2.3"})
+	assert.NoError(t, err)
+	assert.Equal(t, &pb.StatusReply{Ready: false, Reason: "datastore has no ENIs"}, reply)
+
+	assert.NoError(t, rpcServer.ipamContext.dataStore.AddENI("eni-1", 0, true, false, false))
+	reply, err = rpcServer.Status(context.TODO(), &pb.StatusRequest{ClientVersion: "1.2.3"})
+	assert.NoError(t, err)
+	assert.Equal(t, &pb.StatusReply{Ready: true}, reply)
+}
+
+// allocationsDatastore returns a datastore with sample-pod and other-pod assigned an address each on eni-1
+func allocationsDatastore(t *testing.T) *datastore.DataStore {
+	ds := datastore.NewDataStore(log, datastore.NullCheckpoint{}, false)
+	assert.NoError(t, ds.AddENI("eni-1", 1, true, false, false))
+	for _, ip := range []string{"192.168.1.100", "192.168.1.101", "192.168.1.102"} {
+		assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(32, 32)}, false))
+	}
+	for _, pod := range []struct {
+		containerID string
+		namespace   string
+		name        string
+	}{
+		{"sample-cid", "default", "sample-pod"},
+		{"other-cid", "kube-system", "other-pod"},
+	} {
+		_, _, err := ds.AssignPodIPv4Address(datastore.IPAMKey{NetworkName: "aws-cni", ContainerID: pod.containerID, IfName: "eth0"},
+			datastore.IPAMMetadata{K8SPodNamespace: pod.namespace, K8SPodName: pod.name})
+		assert.NoError(t, err)
+	}
+	return ds
+}
+
+func TestServer_ListAllocations(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+
+	rpcServer := server{
+		ipamContext: &IPAMContext{
+			enableIPv4: true,
+			dataStore:  allocationsDatastore(t),
+		},
+	}
+
+	tests := []struct {
+		name    string
+		request *pb.ListAllocationsRequest
+		want    []string
+	}{
+		{
+			name:    "no filter",
+			request: &pb.ListAllocationsRequest{},
+			want:    []string{"other-cid", "sample-cid"},
+		},
+		{
+			name:    "filter by namespace",
+			request: &pb.ListAllocationsRequest{K8S_POD_NAMESPACE: "kube-system"},
+			want:    []string{"other-cid"},
+		},
+		{
+			name:    "filter by pod",
+			request: &pb.ListAllocationsRequest{K8S_POD_NAMESPACE: "default", K8S_POD_NAME: "sample-pod"},
+			want:    []string{"sample-cid"},
+		},
+		{
+			name:    "filter by container ID",
+			request: &pb.ListAllocationsRequest{ContainerID: "other-cid"},
+			want:    []string{"other-cid"},
+		},
+		{
+			name:    "no match",
+			request: &pb.ListAllocationsRequest{K8S_POD_NAMESPACE: "default", ContainerID: "other-cid"},
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, err := rpcServer.ListAllocations(context.TODO(), tt.request)
+			assert.NoError(t, err)
+			assert.True(t, reply.Success)
+
+			var got []string
+			for _, allocation := range reply.Allocations {
+				assert.Equal(t, "eni-1", allocation.ENIID)
+				assert.Equal(t, int32(1), allocation.DeviceNumber)
+				assert.Equal(t, "eth0", allocation.IfName)
+				assert.Equal(t, "aws-cni", allocation.NetworkName)
+				assert.NotEmpty(t, allocation.IPv4Addr)
+				assert.Empty(t, allocation.IPv6Addr)
+				got = append(got, allocation.ContainerID)
+			}
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+func TestServer_GetPodNetwork(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+
+	branchPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "branch-pod",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"vpc.amazonaws.com/pod-eni": `[{"eniId":"eni-branch","ifAddress":"02:34:a5:25:0b:63","privateIp":"192.168.2.10","vlanID":7,"subnetCidr":"192.168.2.0/24"}]`,
+			},
+		},
+	}
+	assert.NoError(t, m.k8sClient.Create(context.TODO(), &branchPod))
+
+	rpcServer := server{
+		ipamContext: &IPAMContext{
+			enableIPv4:   true,
+			enablePodENI: true,
+			k8sClient:    m.k8sClient,
+			dataStore:    allocationsDatastore(t),
+		},
+	}
+
+	reply, err := rpcServer.GetPodNetwork(context.TODO(), &pb.GetPodNetworkRequest{K8S_POD_NAMESPACE: "default", K8S_POD_NAME: "sample-pod"})
+	assert.NoError(t, err)
+	assert.True(t, reply.Success)
+	assert.Equal(t, 1, len(reply.Allocations))
+	assert.Equal(t, "sample-cid", reply.Allocations[0].ContainerID)
+	assert.Equal(t, "eni-1", reply.Allocations[0].ENIID)
+
+	reply, err = rpcServer.GetPodNetwork(context.TODO(), &pb.GetPodNetworkRequest{K8S_POD_NAMESPACE: "default", K8S_POD_NAME: "branch-pod"})
+	assert.NoError(t, err)
+	assert.True(t, reply.Success)
+	assert.Equal(t, []*pb.Allocation{{
+		K8S_POD_NAME:      "branch-pod",
+		K8S_POD_NAMESPACE: "default",
+		IPv4Addr:          "192.168.2.10",
+		ENIID:             "eni-branch",
+		DeviceNumber:      -1,
+		PodVlanId:         7,
+	}}, reply.Allocations)
+
+	reply, err = rpcServer.GetPodNetwork(context.TODO(), &pb.GetPodNetworkRequest{K8S_POD_NAMESPACE: "default", K8S_POD_NAME: "missing-pod"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.False(t, reply.Success)
+
+	reply, err = rpcServer.GetPodNetwork(context.TODO(), &pb.GetPodNetworkRequest{K8S_POD_NAME: "sample-pod"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.False(t, reply.Success)
+}
+
+// fakeWatchAllocationsServer records the events sent on a WatchAllocations stream
+type fakeWatchAllocationsServer struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *pb.AllocationEvent
+}
+
+func (f *fakeWatchAllocationsServer) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeWatchAllocationsServer) Send(event *pb.AllocationEvent) error {
+	f.events <- event
+	return nil
+}
+
+func TestServer_WatchAllocations(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+
+	ds := allocationsDatastore(t)
+	rpcServer := server{
+		ipamContext: &IPAMContext{
+			enableIPv4: true,
+			dataStore:  ds,
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeWatchAllocationsServer{ctx: ctx, events: make(chan *pb.AllocationEvent, 10)}
+	done := make(chan error)
+	go func() {
+		done <- rpcServer.WatchAllocations(&pb.WatchAllocationsRequest{K8S_POD_NAMESPACE: "default"}, stream)
+	}()
+
+	receive := func() *pb.AllocationEvent {
+		select {
+		case event := <-stream.events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for allocation event")
+			return nil
+		}
+	}
+
+	// The current allocations are replayed first
+	event := receive()
+	assert.Equal(t, pb.AllocationEvent_ASSIGNED, event.Type)
+	assert.Equal(t, "sample-cid", event.Allocation.ContainerID)
+
+	// Changes in other namespaces are filtered out
+	_, _, err := ds.AssignPodIPv4Address(datastore.IPAMKey{NetworkName: "aws-cni", ContainerID: "filtered-cid", IfName: "eth0"},
+		datastore.IPAMMetadata{K8SPodNamespace: "kube-system", K8SPodName: "filtered-pod"})
+	assert.NoError(t, err)
+	_, _, _, _, err = ds.UnassignPodIPAddress(datastore.IPAMKey{NetworkName: "aws-cni", ContainerID: "sample-cid", IfName: "eth0"})
+	assert.NoError(t, err)
+
+	event = receive()
+	assert.Equal(t, pb.AllocationEvent_UNASSIGNED, event.Type)
+	assert.Equal(t, "sample-cid", event.Allocation.ContainerID)
+	assert.Equal(t, "sample-pod", event.Allocation.K8S_POD_NAME)
+	assert.Equal(t, "eni-1", event.Allocation.ENIID)
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Empty(t, stream.events)
 }
 
 func TestServer_AddNetwork(t *testing.T) {
@@ -223,18 +607,53 @@ func TestServer_AddNetwork(t *testing.T) {
 			},
 		},
 		{
-			name: "failed allocating IPv6Address - PD disabled",
+			name: "successfully allocated IPv4Address & IPv6Address in dual-stack mode",
 			fields: fields{
+				ipV4AddressByENIID: map[string][]string{
+					"eni-1": {"192.168.1.100"},
+				},
 				ipV6PrefixByENIID: map[string][]string{
 					"eni-1": {"2001:db8::/64"},
 				},
-				ipV4Enabled:             false,
-				ipV6Enabled:             true,
-				prefixDelegationEnabled: false,
+				getVPCIPv4CIDRsCalls: []getVPCIPv4CIDRsCall{
+					{
+						cidrs: []string{"10.10.0.0/16"},
+					},
+				},
+				getVPCIPv6CIDRsCalls: []getVPCIPv6CIDRsCall{
+					{
+						cidrs: []string{"2001:db8::/56"},
+					},
+				},
+				useExternalSNATCalls: []useExternalSNATCall{
+					{
+						useExternalSNAT: true,
+					},
+				},
+				ipV4Enabled: true,
+				ipV6Enabled: true,
+			},
+			want: &pb.AddNetworkReply{
+				Success:         true,
+				IPv4Addr:        "192.168.1.100",
+				IPv6Addr:        "2001:db8::",
+				DeviceNumber:    int32(0),
+				UseExternalSNAT: true,
+				VPCv4CIDRs:      []string{"10.10.0.0/16"},
+				VPCv6CIDRs:      []string{"2001:db8::/56"},
+			},
+		},
+		{
+			name: "failed allocating IPv6Address in dual-stack mode",
+			fields: fields{
+				ipV4AddressByENIID: map[string][]string{
+					"eni-1": {"192.168.1.100"},
+				},
+				ipV4Enabled: true,
+				ipV6Enabled: true,
 			},
 			want: &pb.AddNetworkReply{
 				Success:      false,
-				IPv6Addr:     "",
 				DeviceNumber: int32(-1),
 			},
 		},
@@ -318,3 +737,334 @@ func TestServer_AddNetwork(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_AddNetworkStaticIP(t *testing.T) {
+	attachedENIs := []awsutils.ENIMetadata{
+		{
+			ENIID:          "eni-1",
+			SubnetIPv4CIDR: "10.0.1.0/24",
+			IPv4Addresses:  []ec2types.NetworkInterfacePrivateIpAddress{{PrivateIpAddress: aws.String("10.0.1.4"), Primary: aws.Bool(true)}},
+		},
+	}
+	tests := []struct {
+		name          string
+		annotation    string
+		assignedIP    string
+		attachedENIs  []awsutils.ENIMetadata
+		allocatedIP   string
+		enableIPv6    bool
+		wantIPv4Addr  string
+		wantErrorCode codes.Code
+	}{
+		{
+			name:         "IP in the IP pool",
+			annotation:   "10.0.1.6",
+			wantIPv4Addr: "10.0.1.6",
+		},
+		{
+			name:         "IP allocated on the ENI in its subnet",
+			annotation:   "10.0.1.20",
+			attachedENIs: attachedENIs,
+			allocatedIP:  "10.0.1.20",
+			wantIPv4Addr: "10.0.1.20",
+		},
+		{
+			name:          "IP outside the attached subnets",
+			annotation:    "10.0.9.9",
+			attachedENIs:  attachedENIs,
+			wantErrorCode: codes.InvalidArgument,
+		},
+		{
+			name:          "IP assigned to another pod",
+			annotation:    "10.0.1.6",
+			assignedIP:    "10.0.1.6",
+			wantErrorCode: codes.AlreadyExists,
+		},
+		{
+			name:          "IP is the primary IP of an ENI",
+			annotation:    "10.0.1.4",
+			attachedENIs:  attachedENIs,
+			wantErrorCode: codes.AlreadyExists,
+		},
+		{
+			// Without an IPv6 prefix the request fails, and the static IP is free again right away
+			name:       "dual-stack pod without an IPv6 address",
+			annotation: "10.0.1.6",
+			enableIPv6: true,
+		},
+		{
+			name:          "annotation is not an IPv4 address",
+			annotation:    "not-an-ip",
+			wantErrorCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := setup(t)
+			defer m.ctrl.Finish()
+
+			assert.NoError(t, m.k8sClient.Create(context.TODO(), &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "static-pod", Namespace: "default", Annotations: map[string]string{vpccniStaticIPKey: tt.annotation}},
+			}))
+			ds := datastore.NewDataStore(log, datastore.NullCheckpoint{}, false)
+			assert.NoError(t, ds.AddENI("eni-1", 0, true, false, false))
+			for _, ip := range []string{"10.0.1.5", "10.0.1.6"} {
+				assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(32, 32)}, false))
+			}
+			if tt.assignedIP != "" {
+				_, _, err := ds.AssignPodIPv4AddressWithIP(datastore.IPAMKey{NetworkName: "net0", ContainerID: "other", IfName: "eth0"},
+					datastore.IPAMMetadata{K8SPodNamespace: "default", K8SPodName: "other-pod"}, net.ParseIP(tt.assignedIP))
+				assert.NoError(t, err)
+			}
+			if tt.attachedENIs != nil {
+				m.awsutils.EXPECT().GetAttachedENIs().Return(tt.attachedENIs, nil)
+			}
+			if tt.allocatedIP != "" {
+				m.awsutils.EXPECT().AllocSpecificIPAddress("eni-1", net.ParseIP(tt.allocatedIP).To4()).Return(&ec2.AssignPrivateIpAddressesOutput{
+					AssignedPrivateIpAddresses: []ec2types.AssignedPrivateIpAddress{{PrivateIpAddress: aws.String(tt.allocatedIP)}},
+				}, nil)
+			}
+			if tt.wantIPv4Addr != "" {
+				m.awsutils.EXPECT().GetVPCIPv4CIDRs().Return([]string{"10.0.0.0/16"}, nil)
+				m.network.EXPECT().UseExternalSNAT().Return(true)
+			}
+
+			s := &server{
+				version: "1.2.3",
+				ipamContext: &IPAMContext{
+					awsClient:         m.awsutils,
+					k8sClient:         m.k8sClient,
+					networkClient:     m.network,
+					maxIPsPerENI:      14,
+					enableIPv4:        true,
+					enableIPv6:        tt.enableIPv6,
+					enableStaticPodIP: true,
+					dataStore:         ds,
+				},
+			}
+			resp, err := s.AddNetwork(context.Background(), &pb.AddNetworkRequest{
+				ClientVersion:     "1.2.3",
+				K8S_POD_NAME:      "static-pod",
+				K8S_POD_NAMESPACE: "default",
+				Netns:             "netns",
+				NetworkName:       "net0",
+				ContainerID:       "cid",
+				IfName:            "eth0",
+			})
+			if tt.wantErrorCode != codes.OK {
+				assert.Equal(t, tt.wantErrorCode, status.Code(err))
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantIPv4Addr != "", resp.Success)
+			assert.Equal(t, tt.wantIPv4Addr, resp.IPv4Addr)
+			if tt.wantIPv4Addr == "" {
+				stats := ds.GetIPStats("4")
+				assert.Equal(t, 0, stats.AssignedIPs)
+				assert.Equal(t, 0, stats.CooldownIPs)
+			}
+		})
+	}
+}
+
+func TestFindENIForStaticIPENIPool(t *testing.T) {
+	m := setup(t)
+	defer m.ctrl.Finish()
+
+	ds := datastore.NewDataStore(log, datastore.NullCheckpoint{}, false)
+	assert.NoError(t, ds.AddENI("eni-1", 0, true, false, false))
+	assert.NoError(t, ds.AddENI("eni-2", 1, false, false, false))
+	assert.NoError(t, ds.SetENIPool("eni-2", "blue"))
+	// Both pools share the subnet but not their security groups
+	attachedENIs := []awsutils.ENIMetadata{
+		{ENIID: "eni-1", SubnetIPv4CIDR: "10.0.1.0/24"},
+		{ENIID: "eni-2", SubnetIPv4CIDR: "10.0.1.0/24"},
+	}
+	m.awsutils.EXPECT().GetAttachedENIs().Return(attachedENIs, nil).Times(3)
+	c := &IPAMContext{awsClient: m.awsutils, dataStore: ds, maxIPsPerENI: 14}
+
+	eniID, err := c.findENIForStaticIP(net.ParseIP("10.0.1.20"), "blue")
+	assert.NoError(t, err)
+	assert.Equal(t, "eni-2", eniID)
+	eniID, err = c.findENIForStaticIP(net.ParseIP("10.0.1.20"), datastore.DefaultENIPool)
+	assert.NoError(t, err)
+	assert.Equal(t, "eni-1", eniID)
+	_, err = c.findENIForStaticIP(net.ParseIP("10.0.1.20"), "green")
+	assert.ErrorIs(t, err, errStaticIPOutsideSubnets)
+}
+
+func TestServer_AddNetworkENIConfigPool(t *testing.T) {
+	t.Setenv("ENI_CONFIG_ANNOTATION_DEF", "k8s.amazonaws.com/eniConfig")
+	pools, err := eniconfig.NewENIConfigPools(map[string][]string{"blue": {"team-blue"}})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		namespace     string
+		annotations   map[string]string
+		wantIPv4Addr  string
+		wantErrorCode codes.Code
+	}{
+		{
+			name:         "pod outside the pools",
+			namespace:    "default",
+			wantIPv4Addr: "10.0.1.5",
+		},
+		{
+			name:         "pod in a pool namespace",
+			namespace:    "team-blue",
+			wantIPv4Addr: "100.64.0.5",
+		},
+		{
+			name:         "pod annotated with a pool",
+			namespace:    "default",
+			annotations:  map[string]string{"k8s.amazonaws.com/eniConfig": "blue"},
+			wantIPv4Addr: "100.64.0.5",
+		},
+		{
+			name:          "pod annotated with an ENIConfig without a pool",
+			namespace:     "default",
+			annotations:   map[string]string{"k8s.amazonaws.com/eniConfig": "red"},
+			wantErrorCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := setup(t)
+			defer m.ctrl.Finish()
+
+			assert.NoError(t, m.k8sClient.Create(context.TODO(), &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pool-pod", Namespace: tt.namespace, Annotations: tt.annotations},
+			}))
+			ds := datastore.NewDataStore(log, datastore.NullCheckpoint{}, false)
+			assert.NoError(t, ds.AddENI("eni-1", 0, true, false, false))
+			assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP("10.0.1.5"), Mask: net.CIDRMask(32, 32)}, false))
+			assert.NoError(t, ds.AddENI("eni-2", 1, false, false, false))
+			assert.NoError(t, ds.SetENIPool("eni-2", "blue"))
+			assert.NoError(t, ds.AddIPv4CidrToStore("eni-2", net.IPNet{IP: net.ParseIP("100.64.0.5"), Mask: net.CIDRMask(32, 32)}, false))
+			if tt.wantErrorCode == codes.OK {
+				m.awsutils.EXPECT().GetVPCIPv4CIDRs().Return([]string{"10.0.0.0/16"}, nil)
+				m.network.EXPECT().UseExternalSNAT().Return(true)
+			}
+
+			s := &server{
+				version: "1.2.3",
+				ipamContext: &IPAMContext{
+					awsClient:      m.awsutils,
+					k8sClient:      m.k8sClient,
+					networkClient:  m.network,
+					maxIPsPerENI:   14,
+					enableIPv4:     true,
+					eniConfigPools: pools,
+					dataStore:      ds,
+				},
+			}
+			resp, err := s.AddNetwork(context.Background(), &pb.AddNetworkRequest{
+				ClientVersion:     "1.2.3",
+				K8S_POD_NAME:      "pool-pod",
+				K8S_POD_NAMESPACE: tt.namespace,
+				Netns:             "netns",
+				NetworkName:       "net0",
+				ContainerID:       "cid",
+				IfName:            "eth0",
+			})
+			if tt.wantErrorCode != codes.OK {
+				assert.Equal(t, tt.wantErrorCode, status.Code(err))
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, resp.Success)
+			assert.Equal(t, tt.wantIPv4Addr, resp.IPv4Addr)
+		})
+	}
+}
+
+func TestServer_AddNetworkNetworkCard(t *testing.T) {
+	tests := []struct {
+		name             string
+		annotations      map[string]string
+		wantIPv4Addr     string
+		wantDeviceNumber int32
+		wantErrorCode    codes.Code
+	}{
+		{
+			name:         "pod without a network card",
+			wantIPv4Addr: "10.0.1.5",
+		},
+		{
+			name:         "pod annotated with the default network card",
+			annotations:  map[string]string{vpccniNetworkCardKey: "0"},
+			wantIPv4Addr: "10.0.1.5",
+		},
+		{
+			name:             "pod annotated with another network card",
+			annotations:      map[string]string{vpccniNetworkCardKey: "1"},
+			wantIPv4Addr:     "10.0.2.5",
+			wantDeviceNumber: 1001,
+		},
+		{
+			name:          "pod annotated with a network card the instance does not have",
+			annotations:   map[string]string{vpccniNetworkCardKey: "2"},
+			wantErrorCode: codes.InvalidArgument,
+		},
+		{
+			name:          "pod annotated with an invalid network card",
+			annotations:   map[string]string{vpccniNetworkCardKey: "first"},
+			wantErrorCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := setup(t)
+			defer m.ctrl.Finish()
+
+			assert.NoError(t, m.k8sClient.Create(context.TODO(), &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "card-pod", Namespace: "default", Annotations: tt.annotations},
+			}))
+			ds := datastore.NewDataStore(log, datastore.NullCheckpoint{}, false)
+			assert.NoError(t, ds.AddENI("eni-1", 0, true, false, false))
+			assert.NoError(t, ds.AddIPv4CidrToStore("eni-1", net.IPNet{IP: net.ParseIP("10.0.1.5"), Mask: net.CIDRMask(32, 32)}, false))
+			assert.NoError(t, ds.AddENI("eni-2", 1001, false, false, false))
+			assert.NoError(t, ds.SetENIPool("eni-2", networkCardPool(1)))
+			assert.NoError(t, ds.AddIPv4CidrToStore("eni-2", net.IPNet{IP: net.ParseIP("10.0.2.5"), Mask: net.CIDRMask(32, 32)}, false))
+			if tt.wantErrorCode == codes.OK {
+				m.awsutils.EXPECT().GetVPCIPv4CIDRs().Return([]string{"10.0.0.0/16"}, nil)
+				m.network.EXPECT().UseExternalSNAT().Return(true)
+			}
+
+			s := &server{
+				version: "1.2.3",
+				ipamContext: &IPAMContext{
+					awsClient:           m.awsutils,
+					k8sClient:           m.k8sClient,
+					networkClient:       m.network,
+					maxIPsPerENI:        14,
+					enableIPv4:          true,
+					enableMultiCardENIs: true,
+					networkCards:        []vpc.NetworkCard{{NetworkCardIndex: 0, MaximumNetworkInterfaces: 4}, {NetworkCardIndex: 1, MaximumNetworkInterfaces: 4}},
+					dataStore:           ds,
+				},
+			}
+			resp, err := s.AddNetwork(context.Background(), &pb.AddNetworkRequest{
+				ClientVersion:     "1.2.3",
+				K8S_POD_NAME:      "card-pod",
+				K8S_POD_NAMESPACE: "default",
+				Netns:             "netns",
+				NetworkName:       "net0",
+				ContainerID:       "cid",
+				IfName:            "eth0",
+			})
+			if tt.wantErrorCode != codes.OK {
+				assert.Equal(t, tt.wantErrorCode, status.Code(err))
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, resp.Success)
+			assert.Equal(t, tt.wantIPv4Addr, resp.IPv4Addr)
+			assert.Equal(t, tt.wantDeviceNumber, resp.DeviceNumber)
+		})
+	}
+}
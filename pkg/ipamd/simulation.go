@@ -0,0 +1,39 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/networkutils"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/ttime"
+)
+
+// NewSimulation initializes an IPAMContext for the IPAM simulator. Its settings are read from the environment as in
+// ipamd, while EC2, the host network and the clock are the in-memory implementations of the simulator. Nothing is
+// checkpointed, and only IPv4 is supported.
+func NewSimulation(k8sClient client.Client, awsClient awsutils.APIs, networkClient networkutils.NetworkAPIs, clock ttime.Time) (*IPAMContext, error) {
+	if isIPv6Enabled() {
+		return nil, errors.New("ipamd: the simulator does not support IPv6")
+	}
+	return newIPAMContext(k8sClient, awsClient, networkClient, datastore.NullCheckpoint{}, clock)
+}
+
+// GetIPStats returns the IPv4 address stats of the node
+func (c *IPAMContext) GetIPStats() *datastore.DataStoreStats {
+	return c.dataStore.GetIPStats(ipV4AddrFamily)
+}
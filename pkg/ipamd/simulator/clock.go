@@ -0,0 +1,161 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package simulator replays pod traces against the real ipamd pool management on an in-memory EC2 and a virtual
+// clock, so that warm pool settings can be compared offline.
+package simulator
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/ttime"
+)
+
+// Clock is a discrete-event implementation of ttime.Time. Time only moves forward when Sleep is called, and timers
+// that become due while sleeping run on the sleeping goroutine in the order of their due time. A timer callback may
+// sleep itself, e.g. on an EC2 call. The timers that become due meanwhile run during that sleep, as they would run
+// concurrently on a node, and the clock can end up past the end of the outer Sleep.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	seq    uint64
+	timers timerHeap
+}
+
+var _ ttime.Time = &Clock{}
+
+// NewClock returns a virtual clock that starts at the given time
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the current virtual time
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep advances the virtual time by d, running the timers that become due on the way
+func (c *Clock) Sleep(d time.Duration) {
+	c.advanceTo(c.Now().Add(d))
+}
+
+// After returns a channel that receives the virtual time once d has passed. Since only Sleep moves the clock, the
+// channel must not be waited on by the goroutine that drives the clock.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.AfterFunc(d, func() {
+		ch <- c.Now()
+	})
+	return ch
+}
+
+// AfterFunc calls f once d has passed
+func (c *Clock) AfterFunc(d time.Duration, f func()) ttime.Timer {
+	t := &timer{clock: c, f: f, index: -1}
+	t.Reset(d)
+	return t
+}
+
+// advanceTo runs all timers due up to target and then moves the clock to target, unless a timer moved it further
+func (c *Clock) advanceTo(target time.Time) {
+	for {
+		c.mu.Lock()
+		if len(c.timers) == 0 || c.timers[0].when.After(target) {
+			if target.After(c.now) {
+				c.now = target
+			}
+			c.mu.Unlock()
+			return
+		}
+		t := heap.Pop(&c.timers).(*timer)
+		if t.when.After(c.now) {
+			c.now = t.when
+		}
+		c.mu.Unlock()
+		t.f()
+	}
+}
+
+type timer struct {
+	clock *Clock
+	when  time.Time
+	seq   uint64
+	f     func()
+	// index in the timer heap, -1 when the timer is not scheduled
+	index int
+}
+
+// Reset reschedules the timer to fire d from now. It returns whether the timer was still scheduled.
+func (t *timer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	active := t.index >= 0
+	if active {
+		heap.Remove(&c.timers, t.index)
+	}
+	c.seq++
+	t.when = c.now.Add(d)
+	t.seq = c.seq
+	heap.Push(&c.timers, t)
+	return active
+}
+
+// Stop unschedules the timer. It returns whether the timer was still scheduled.
+func (t *timer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.index < 0 {
+		return false
+	}
+	heap.Remove(&c.timers, t.index)
+	return true
+}
+
+// timerHeap orders timers by due time, and timers due at the same time by when they were scheduled
+type timerHeap []*timer
+
+func (h timerHeap) Len() int { return len(h) }
+
+func (h timerHeap) Less(i, j int) bool {
+	if h[i].when.Equal(h[j].when) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].when.Before(h[j].when)
+}
+
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x any) {
+	t := x.(*timer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *timerHeap) Pop() any {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	t.index = -1
+	*h = old[:len(old)-1]
+	return t
+}
@@ -0,0 +1,69 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	var fired []string
+	record := func(name string) func() {
+		return func() {
+			fired = append(fired, name+"@"+clock.Now().Sub(start).String())
+		}
+	}
+
+	clock.AfterFunc(3*time.Second, record("c"))
+	clock.AfterFunc(time.Second, record("a"))
+	clock.AfterFunc(time.Second, record("b"))
+	stopped := clock.AfterFunc(2*time.Second, record("stopped"))
+	reset := clock.AfterFunc(time.Second, record("reset"))
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+	assert.True(t, reset.Reset(4*time.Second))
+
+	clock.Sleep(2 * time.Second)
+	assert.Equal(t, []string{"a@1s", "b@1s"}, fired)
+	assert.Equal(t, start.Add(2*time.Second), clock.Now())
+
+	clock.Sleep(10 * time.Second)
+	assert.Equal(t, []string{"a@1s", "b@1s", "c@3s", "reset@4s"}, fired)
+	assert.Equal(t, start.Add(12*time.Second), clock.Now())
+	assert.False(t, reset.Stop())
+}
+
+func TestClockNestedSleep(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	var during time.Duration
+	clock.AfterFunc(time.Second, func() {
+		// Timers due while a callback sleeps run during its sleep
+		clock.Sleep(5 * time.Second)
+	})
+	clock.AfterFunc(2*time.Second, func() {
+		during = clock.Now().Sub(start)
+	})
+	ch := clock.After(3 * time.Second)
+
+	clock.Sleep(4 * time.Second)
+	assert.Equal(t, 2*time.Second, during)
+	assert.Equal(t, start.Add(3*time.Second), <-ch)
+	assert.Equal(t, start.Add(6*time.Second), clock.Now())
+}
@@ -0,0 +1,572 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package simulator
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/pkg/errors"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/awsutils/fakeaws"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/ttime"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/vpc"
+)
+
+const (
+	simInstanceID = "i-00000000000000001"
+	// vpcCIDR is the VPC and the only subnet of the simulated node
+	vpcCIDR        = "10.0.0.0/16"
+	simSubnetID    = "subnet-00000000000000001"
+	nodeTagKey     = "node.k8s.amazonaws.com/instance_id"
+	eniDescription = "aws-K8S-" + simInstanceID
+	prefixLen      = 28
+)
+
+// EC2 API names used in the call counts, after the EC2 APIs that awsutils calls for each method
+const (
+	apiAssignPrivateIPAddresses   = "AssignPrivateIpAddresses"
+	apiAttachNetworkInterface     = "AttachNetworkInterface"
+	apiCreateNetworkInterface     = "CreateNetworkInterface"
+	apiCreateTags                 = "CreateTags"
+	apiDeleteNetworkInterface     = "DeleteNetworkInterface"
+	apiDescribeInstances          = "DescribeInstances"
+	apiDescribeNetworkInterfaces  = "DescribeNetworkInterfaces"
+	apiDetachNetworkInterface     = "DetachNetworkInterface"
+	apiModifyNetworkInterface     = "ModifyNetworkInterfaceAttribute"
+	apiUnassignPrivateIPAddresses = "UnassignPrivateIpAddresses"
+)
+
+// EC2 is an implementation of awsutils.APIs for a single instance, on top of the ENI and subnet state of a
+// fakeaws.Server. EC2 and IMDS always agree with each other, every EC2 call takes the configured latency on the
+// virtual clock, and attaching an ENI takes the configured attach latency before its addresses show up.
+type EC2 struct {
+	clock         ttime.Time
+	instanceType  string
+	limits        vpc.InstanceTypeLimits
+	latency       time.Duration
+	attachLatency time.Duration
+	fake          *fakeaws.Server
+	primaryENI    string
+
+	mu               sync.Mutex
+	prefixDelegation bool
+	unmanagedENIs    map[string]bool
+	calls            map[string]int
+}
+
+var _ awsutils.APIs = &EC2{}
+
+// NewEC2 returns an EC2 for an instance of the given type, with only its primary ENI attached
+func NewEC2(clock ttime.Time, instanceType string, latency, attachLatency time.Duration) (*EC2, error) {
+	limits, ok := vpc.GetInstance(instanceType)
+	if !ok {
+		return nil, errors.Errorf("simulator: unknown instance type %q", instanceType)
+	}
+	fake, err := fakeaws.New(fakeaws.Config{
+		InstanceType: instanceType,
+		InstanceID:   simInstanceID,
+		VPCCIDR:      vpcCIDR,
+		Subnets:      []fakeaws.SubnetConfig{{ID: simSubnetID, CIDR: vpcCIDR}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "simulator: failed to create the instance")
+	}
+	return &EC2{
+		clock:         clock,
+		instanceType:  instanceType,
+		limits:        limits,
+		latency:       latency,
+		attachLatency: attachLatency,
+		fake:          fake,
+		primaryENI:    fake.AttachedENIs()[0].ID,
+		unmanagedENIs: make(map[string]bool),
+		calls:         make(map[string]int),
+	}, nil
+}
+
+// Calls returns the number of calls made to each EC2 API
+func (e *EC2) Calls() map[string]int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	calls := make(map[string]int, len(e.calls))
+	for api, n := range e.calls {
+		calls[api] = n
+	}
+	return calls
+}
+
+// ENIs returns the number of ENIs attached to the instance
+func (e *EC2) ENIs() int {
+	return len(e.fake.AttachedENIs())
+}
+
+// call counts a call to an EC2 API and waits for its latency
+func (e *EC2) call(api string) {
+	e.mu.Lock()
+	e.calls[api]++
+	e.mu.Unlock()
+	e.clock.Sleep(e.latency)
+}
+
+// AllocENI creates an ENI, attaches it to the instance and assigns numIPs secondary IPs or prefixes to it
+func (e *EC2) AllocENI(useCustomCfg bool, sg []*string, eniCfgSubnets []string, numIPs int) (string, error) {
+	return e.AllocENIWithTags(useCustomCfg, sg, eniCfgSubnets, numIPs, nil)
+}
+
+// AllocENIWithTags is AllocENI with extra tags on the new ENI
+func (e *EC2) AllocENIWithTags(useCustomCfg bool, sg []*string, eniCfgSubnets []string, numIPs int, tags map[string]string) (string, error) {
+	return e.AllocENIOnNetworkCard(useCustomCfg, sg, eniCfgSubnets, numIPs, tags, 0)
+}
+
+// AllocENIOnNetworkCard is AllocENIWithTags on the given network card
+func (e *EC2) AllocENIOnNetworkCard(useCustomCfg bool, sg []*string, eniCfgSubnets []string, numIPs int, tags map[string]string, networkCard int) (string, error) {
+	e.call(apiDescribeInstances)
+	e.mu.Lock()
+	deviceNumber, err := e.freeDeviceNumberUnsafe(networkCard)
+	e.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	e.call(apiCreateNetworkInterface)
+	e.call(apiAttachNetworkInterface)
+	e.call(apiModifyNetworkInterface)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// ENIs created by awsutils carry the node tag from the start, the primary ENI is tagged by ipamd
+	eniTags := map[string]string{nodeTagKey: simInstanceID}
+	for k, v := range tags {
+		eniTags[k] = v
+	}
+	eni, err := e.fake.CreateENI(simSubnetID, eniDescription, eniTags)
+	if err != nil {
+		return "", err
+	}
+	if err := e.fake.AttachENI(eni.ID, deviceNumber, networkCard); err != nil {
+		_ = e.fake.DeleteENI(eni.ID)
+		return "", err
+	}
+	if _, _, err := e.assignUnsafe(eni.ID, min(numIPs, e.limits.IPv4Limit-1)); err != nil {
+		_ = e.deleteENIUnsafe(eni.ID)
+		return "", err
+	}
+	return eni.ID, nil
+}
+
+// FreeENI detaches and deletes an ENI
+func (e *EC2) FreeENI(eniName string) error {
+	e.call(apiDescribeNetworkInterfaces)
+	if _, err := e.fake.ENI(eniName); err != nil {
+		return err
+	}
+	if eniName == e.primaryENI {
+		return errors.Errorf("simulator: cannot free primary ENI %s", eniName)
+	}
+	e.call(apiDetachNetworkInterface)
+	e.call(apiDeleteNetworkInterface)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.deleteENIUnsafe(eniName)
+}
+
+// TagENI adds the node tag to an ENI that is missing it
+func (e *EC2) TagENI(eniID string, currentTags map[string]string) error {
+	if currentTags[nodeTagKey] == simInstanceID {
+		return nil
+	}
+	e.call(apiCreateTags)
+	return e.fake.TagENI(eniID, map[string]string{nodeTagKey: simInstanceID})
+}
+
+// GetAttachedENIs returns the ENIs attached to the instance, as seen by IMDS
+func (e *EC2) GetAttachedENIs() ([]awsutils.ENIMetadata, error) {
+	return e.enisMetadata(), nil
+}
+
+// DescribeENIAddresses returns the IPv4 addresses and prefixes of the ENIs that exist
+func (e *EC2) DescribeENIAddresses(eniIDs []string) (map[string]awsutils.ENIAddresses, error) {
+	e.call(apiDescribeNetworkInterfaces)
+	addresses := make(map[string]awsutils.ENIAddresses, len(eniIDs))
+	for _, eniID := range eniIDs {
+		if eni, err := e.fake.ENI(eniID); err == nil {
+			metadata := eniMetadata(eni)
+			addresses[eniID] = awsutils.ENIAddresses{IPv4Addresses: metadata.IPv4Addresses, IPv4Prefixes: metadata.IPv4Prefixes}
+		}
+	}
+	return addresses, nil
+}
+
+// DescribeENIConfigNetwork resolves every subnet and security group, as custom networking is not simulated
+func (e *EC2) DescribeENIConfigNetwork(subnetIDs []string, securityGroups []string) (awsutils.ENIConfigNetwork, error) {
+	return awsutils.ENIConfigNetwork{SubnetFound: true, SecurityGroupsFound: true}, nil
+}
+
+// GetSubnetDiscoveryState returns an empty state, as subnet discovery is not simulated
+func (e *EC2) GetSubnetDiscoveryState() awsutils.SubnetDiscoveryState {
+	return awsutils.SubnetDiscoveryState{}
+}
+
+// GetLeakedENIReport returns no report, as the leaked ENI cleanup is not simulated
+func (e *EC2) GetLeakedENIReport() *awsutils.LeakedENIReport {
+	return nil
+}
+
+// GetIPv6PrefixesFromEC2 returns no prefixes, as IPv6 is not simulated
+func (e *EC2) GetIPv6PrefixesFromEC2(eniID string) ([]ec2types.Ipv6PrefixSpecification, error) {
+	return nil, nil
+}
+
+// DescribeAllENIs returns the ENIs attached to the instance and their tags
+func (e *EC2) DescribeAllENIs() (awsutils.DescribeAllENIsResult, error) {
+	e.call(apiDescribeNetworkInterfaces)
+	tagMap := make(map[string]awsutils.TagMap)
+	for _, eni := range e.fake.AttachedENIs() {
+		if len(eni.Tags) == 0 {
+			continue
+		}
+		tags := make(awsutils.TagMap, len(eni.Tags))
+		for k, v := range eni.Tags {
+			tags[k] = v
+		}
+		tagMap[eni.ID] = tags
+	}
+	return awsutils.DescribeAllENIsResult{
+		ENIMetadata: e.enisMetadata(),
+		TagMap:      tagMap,
+		EFAENIs:     make(map[string]bool),
+	}, nil
+}
+
+// AllocIPAddress assigns one secondary IP or prefix to an ENI
+func (e *EC2) AllocIPAddress(eniID string) error {
+	_, err := e.AllocIPAddresses(eniID, 1)
+	return err
+}
+
+// AllocIPAddresses assigns up to numIPs secondary IPs or prefixes to an ENI. Like EC2, it fails with
+// PrivateIpAddressLimitExceeded when the ENI cannot take all of them.
+func (e *EC2) AllocIPAddresses(eniID string, numIPs int) (*ec2.AssignPrivateIpAddressesOutput, error) {
+	needIPs := min(numIPs, e.GetENIIPv4Limit())
+	if needIPs < 1 {
+		return nil, nil
+	}
+	e.call(apiAssignPrivateIPAddresses)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ips, prefixes, err := e.assignUnsafe(eniID, needIPs)
+	if err != nil {
+		return nil, err
+	}
+	return assignOutput(eniID, ips, prefixes), nil
+}
+
+// AllocSpecificIPAddress assigns the given IP to an ENI, or the /28 prefix holding it with prefix delegation
+func (e *EC2) AllocSpecificIPAddress(eniID string, ip net.IP) (*ec2.AssignPrivateIpAddressesOutput, error) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return nil, apiError("InvalidParameterValue", "%s is not an IPv4 address", ip)
+	}
+	e.call(apiAssignPrivateIPAddresses)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.prefixDelegation {
+		mask := net.CIDRMask(prefixLen, 32)
+		prefix := &net.IPNet{IP: ip4.Mask(mask), Mask: mask}
+		_, prefixes, err := e.fake.AssignAddresses(eniID, 0, 0, nil, []string{prefix.String()})
+		if err != nil {
+			return nil, err
+		}
+		return assignOutput(eniID, nil, prefixes), nil
+	}
+	ips, _, err := e.fake.AssignAddresses(eniID, 0, 0, []string{ip4.String()}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return assignOutput(eniID, ips, nil), nil
+}
+
+// DeallocIPAddresses unassigns secondary IPs from an ENI
+func (e *EC2) DeallocIPAddresses(eniID string, ips []string) error {
+	if len(ips) == 0 {
+		return nil
+	}
+	e.call(apiUnassignPrivateIPAddresses)
+	return e.fake.UnassignAddresses(eniID, ips, nil)
+}
+
+// DeallocPrefixAddresses unassigns prefixes from an ENI
+func (e *EC2) DeallocPrefixAddresses(eniID string, prefixes []string) error {
+	if len(prefixes) == 0 {
+		return nil
+	}
+	e.call(apiUnassignPrivateIPAddresses)
+	return e.fake.UnassignAddresses(eniID, nil, prefixes)
+}
+
+// AllocIPv6Prefixes fails, as IPv6 is not simulated
+func (e *EC2) AllocIPv6Prefixes(eniID string) ([]*string, error) {
+	return nil, errors.New("simulator: IPv6 is not supported")
+}
+
+// GetVPCIPv4CIDRs returns the VPC CIDR
+func (e *EC2) GetVPCIPv4CIDRs() ([]string, error) {
+	return []string{vpcCIDR}, nil
+}
+
+// GetLocalIPv4 returns the primary IP of the instance
+func (e *EC2) GetLocalIPv4() net.IP {
+	eni, _ := e.fake.ENI(e.primaryENI)
+	return net.ParseIP(eni.PrimaryIP)
+}
+
+// GetVPCIPv6CIDRs returns no CIDRs, as IPv6 is not simulated
+func (e *EC2) GetVPCIPv6CIDRs() ([]string, error) {
+	return nil, nil
+}
+
+// GetPrimaryENI returns the ID of the primary ENI
+func (e *EC2) GetPrimaryENI() string {
+	return e.primaryENI
+}
+
+// GetENIIPv4Limit returns the number of secondary IPs or prefixes an ENI can have
+func (e *EC2) GetENIIPv4Limit() int {
+	return e.limits.IPv4Limit - 1
+}
+
+// GetENILimit returns the number of ENIs the instance can have
+func (e *EC2) GetENILimit() int {
+	return e.limits.ENILimit
+}
+
+// GetNetworkCards returns the network cards of the instance type
+func (e *EC2) GetNetworkCards() []vpc.NetworkCard {
+	return e.limits.NetworkCards
+}
+
+// GetPrimaryENImac returns the MAC address of the primary ENI
+func (e *EC2) GetPrimaryENImac() string {
+	eni, _ := e.fake.ENI(e.primaryENI)
+	return eni.MAC
+}
+
+// SetUnmanagedENIs records the ENIs that ipamd must not manage
+func (e *EC2) SetUnmanagedENIs(eniIDs []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.unmanagedENIs = make(map[string]bool, len(eniIDs))
+	for _, id := range eniIDs {
+		e.unmanagedENIs[id] = true
+	}
+}
+
+// IsUnmanagedENI returns whether ipamd must not manage an ENI
+func (e *EC2) IsUnmanagedENI(eniID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.unmanagedENIs[eniID]
+}
+
+// WaitForENIAndIPsAttached waits for the attach latency and returns the ENI as seen by IMDS
+func (e *EC2) WaitForENIAndIPsAttached(eniID string, wantedSecondaryIPs int) (awsutils.ENIMetadata, error) {
+	e.clock.Sleep(e.attachLatency)
+	eni, err := e.fake.ENI(eniID)
+	if err != nil || eni.AttachmentID == "" {
+		return awsutils.ENIMetadata{}, awsutils.ErrENINotFound
+	}
+	return eniMetadata(eni), nil
+}
+
+// SetMultiCardENIs does nothing, as all ENIs are on the default network card
+func (e *EC2) SetMultiCardENIs(eniID []string) error {
+	return nil
+}
+
+// IsMultiCardENI returns false, as all ENIs are on the default network card
+func (e *EC2) IsMultiCardENI(eniID string) bool {
+	return false
+}
+
+// IsPrimaryENI returns whether an ENI is the primary ENI
+func (e *EC2) IsPrimaryENI(eniID string) bool {
+	return eniID == e.primaryENI
+}
+
+// RefreshSGIDs does nothing, as security groups are not simulated
+func (e *EC2) RefreshSGIDs(mac string, store *datastore.DataStore) error {
+	return nil
+}
+
+// GetInstanceHypervisorFamily returns the hypervisor of the instance type
+func (e *EC2) GetInstanceHypervisorFamily() string {
+	return e.limits.HypervisorType
+}
+
+// GetInstanceType returns the instance type
+func (e *EC2) GetInstanceType() string {
+	return e.instanceType
+}
+
+// InitCachedPrefixDelegation sets whether ENIs get prefixes instead of secondary IPs
+func (e *EC2) InitCachedPrefixDelegation(enablePrefixDelegation bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.prefixDelegation = enablePrefixDelegation
+}
+
+// IsIMDSDegraded returns false, the simulated IMDS always answers
+func (e *EC2) IsIMDSDegraded() bool {
+	return false
+}
+
+// InitCachedMultiCardENIs is a no-op, the simulated ENIs always report their network card
+func (e *EC2) InitCachedMultiCardENIs(bool) {}
+
+// GetInstanceID returns the ID of the instance
+func (e *EC2) GetInstanceID() string {
+	return simInstanceID
+}
+
+// FetchInstanceTypeLimits does nothing, as the limits come from pkg/vpc
+func (e *EC2) FetchInstanceTypeLimits() error {
+	return nil
+}
+
+// IsPrefixDelegationSupported returns whether the instance type is on Nitro
+func (e *EC2) IsPrefixDelegationSupported() bool {
+	return e.limits.HypervisorType == "nitro"
+}
+
+// freeDeviceNumberUnsafe returns the lowest device number not used by an ENI attached to the network card
+func (e *EC2) freeDeviceNumberUnsafe(networkCard int) (int, error) {
+	enis := e.fake.AttachedENIs()
+	if len(enis) >= e.limits.ENILimit {
+		return 0, apiError("AttachmentLimitExceeded", "Interface count %d exceeds the limit for %s", len(enis)+1, e.instanceType)
+	}
+	cardLimit := e.limits.ENILimit
+	if len(e.limits.NetworkCards) > 0 {
+		if networkCard >= len(e.limits.NetworkCards) {
+			return 0, apiError("InvalidParameterValue", "Network card index %d is out of range", networkCard)
+		}
+		cardLimit = int(e.limits.NetworkCards[networkCard].MaximumNetworkInterfaces)
+	}
+	used := make(map[int]bool, len(enis))
+	for _, eni := range enis {
+		if eni.NetworkCardIndex == networkCard {
+			used[eni.DeviceIndex] = true
+		}
+	}
+	if len(used) >= cardLimit {
+		return 0, apiError("AttachmentLimitExceeded", "Interface count %d exceeds the limit for network card %d", len(used)+1, networkCard)
+	}
+	deviceNumber := 0
+	if networkCard != 0 {
+		deviceNumber = 1
+	}
+	for used[deviceNumber] {
+		deviceNumber++
+	}
+	return deviceNumber, nil
+}
+
+func (e *EC2) deleteENIUnsafe(eniID string) error {
+	if err := e.fake.DetachENI(eniID); err != nil {
+		return err
+	}
+	return e.fake.DeleteENI(eniID)
+}
+
+// assignUnsafe assigns n secondary IPs, or prefixes with prefix delegation, to an ENI, all or nothing
+func (e *EC2) assignUnsafe(eniID string, n int) (ips, prefixes []string, err error) {
+	if n < 1 {
+		return nil, nil, nil
+	}
+	if e.prefixDelegation {
+		return e.fake.AssignAddresses(eniID, 0, n, nil, nil)
+	}
+	return e.fake.AssignAddresses(eniID, n, 0, nil, nil)
+}
+
+func (e *EC2) enisMetadata() []awsutils.ENIMetadata {
+	attached := e.fake.AttachedENIs()
+	enis := make([]awsutils.ENIMetadata, 0, len(attached))
+	for _, eni := range attached {
+		enis = append(enis, eniMetadata(eni))
+	}
+	sort.Slice(enis, func(i, j int) bool {
+		if enis[i].NetworkCard != enis[j].NetworkCard {
+			return enis[i].NetworkCard < enis[j].NetworkCard
+		}
+		return enis[i].DeviceNumber < enis[j].DeviceNumber
+	})
+	return enis
+}
+
+// eniMetadata returns the ENI as seen by IMDS
+func eniMetadata(eni fakeaws.ENI) awsutils.ENIMetadata {
+	addrs := []ec2types.NetworkInterfacePrivateIpAddress{{
+		PrivateIpAddress: aws.String(eni.PrimaryIP),
+		Primary:          aws.Bool(true),
+	}}
+	for _, ip := range eni.SecondaryIPs {
+		addrs = append(addrs, ec2types.NetworkInterfacePrivateIpAddress{
+			PrivateIpAddress: aws.String(ip),
+			Primary:          aws.Bool(false),
+		})
+	}
+	var prefixes []ec2types.Ipv4PrefixSpecification
+	for _, prefix := range eni.Prefixes {
+		prefixes = append(prefixes, ec2types.Ipv4PrefixSpecification{Ipv4Prefix: aws.String(prefix)})
+	}
+	return awsutils.ENIMetadata{
+		ENIID:          eni.ID,
+		MAC:            eni.MAC,
+		DeviceNumber:   eni.DeviceIndex,
+		NetworkCard:    eni.NetworkCardIndex,
+		SubnetIPv4CIDR: vpcCIDR,
+		IPv4Addresses:  addrs,
+		IPv4Prefixes:   prefixes,
+	}
+}
+
+func assignOutput(eniID string, ips, prefixes []string) *ec2.AssignPrivateIpAddressesOutput {
+	output := &ec2.AssignPrivateIpAddressesOutput{NetworkInterfaceId: aws.String(eniID)}
+	for _, ip := range ips {
+		output.AssignedPrivateIpAddresses = append(output.AssignedPrivateIpAddresses, ec2types.AssignedPrivateIpAddress{
+			PrivateIpAddress: aws.String(ip),
+		})
+	}
+	for _, prefix := range prefixes {
+		output.AssignedIpv4Prefixes = append(output.AssignedIpv4Prefixes, ec2types.Ipv4PrefixSpecification{
+			Ipv4Prefix: aws.String(prefix),
+		})
+	}
+	return output
+}
+
+func apiError(code, format string, args ...any) error {
+	return &smithy.GenericAPIError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
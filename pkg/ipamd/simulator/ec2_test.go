@@ -0,0 +1,104 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package simulator
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEC2(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	e, err := NewEC2(clock, "m5.large", 100*time.Millisecond, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 3, e.GetENILimit())
+	assert.Equal(t, 9, e.GetENIIPv4Limit())
+
+	eniID, err := e.AllocENI(false, nil, nil, 20)
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(400*time.Millisecond), clock.Now())
+	eni, err := e.WaitForENIAndIPsAttached(eniID, 9)
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(1400*time.Millisecond), clock.Now())
+	assert.Equal(t, 1, eni.DeviceNumber)
+	// The primary IP and the 9 secondary IPs
+	assert.Len(t, eni.IPv4Addresses, 10)
+
+	_, err = e.AllocIPAddresses(eniID, 1)
+	var apiErr smithy.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "PrivateIpAddressLimitExceeded", apiErr.ErrorCode())
+
+	secondary := aws.ToString(eni.IPv4Addresses[1].PrivateIpAddress)
+	require.NoError(t, e.DeallocIPAddresses(eniID, []string{secondary}))
+	assert.Error(t, e.DeallocIPAddresses(eniID, []string{secondary}))
+	addresses, err := e.DescribeENIAddresses([]string{eniID})
+	require.NoError(t, err)
+	assert.Len(t, addresses[eniID].IPv4Addresses, 9)
+	output, err := e.AllocIPAddresses(eniID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, secondary, aws.ToString(output.AssignedPrivateIpAddresses[0].PrivateIpAddress))
+
+	_, err = e.AllocENI(false, nil, nil, 1)
+	require.NoError(t, err)
+	_, err = e.AllocENI(false, nil, nil, 1)
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "AttachmentLimitExceeded", apiErr.ErrorCode())
+
+	require.NoError(t, e.FreeENI(eniID))
+	enis, err := e.GetAttachedENIs()
+	require.NoError(t, err)
+	assert.Len(t, enis, 2)
+	assert.Error(t, e.FreeENI(e.GetPrimaryENI()))
+
+	assert.Equal(t, map[string]int{
+		apiAssignPrivateIPAddresses:   2,
+		apiAttachNetworkInterface:     2,
+		apiCreateNetworkInterface:     2,
+		apiDeleteNetworkInterface:     1,
+		apiDescribeInstances:          3,
+		apiDescribeNetworkInterfaces:  3,
+		apiDetachNetworkInterface:     1,
+		apiModifyNetworkInterface:     2,
+		apiUnassignPrivateIPAddresses: 2,
+	}, e.Calls())
+}
+
+func TestEC2PrefixDelegation(t *testing.T) {
+	e, err := NewEC2(NewClock(DefaultStart), "m5.large", 0, 0)
+	require.NoError(t, err)
+	e.InitCachedPrefixDelegation(true)
+
+	output, err := e.AllocIPAddresses(e.GetPrimaryENI(), 2)
+	require.NoError(t, err)
+	require.Len(t, output.AssignedIpv4Prefixes, 2)
+	// The primary IP of the primary ENI, 10.0.0.4, is in the first /28 of the subnet
+	assert.Equal(t, "10.0.0.16/28", aws.ToString(output.AssignedIpv4Prefixes[0].Ipv4Prefix))
+	assert.Equal(t, "10.0.0.32/28", aws.ToString(output.AssignedIpv4Prefixes[1].Ipv4Prefix))
+	_, err = e.AllocSpecificIPAddress(e.GetPrimaryENI(), net.ParseIP("10.0.0.5"))
+	assert.Error(t, err)
+
+	require.NoError(t, e.DeallocPrefixAddresses(e.GetPrimaryENI(), []string{"10.0.0.16/28"}))
+	addresses, err := e.DescribeENIAddresses([]string{e.GetPrimaryENI()})
+	require.NoError(t, err)
+	assert.Len(t, addresses[e.GetPrimaryENI()].IPv4Prefixes, 1)
+}
@@ -0,0 +1,92 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package simulator
+
+import (
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/networkutils"
+)
+
+// Network is a host network that accepts every change and keeps no state, since the simulator only models IP address
+// management
+type Network struct{}
+
+var _ networkutils.NetworkAPIs = &Network{}
+
+// SetupHostNetwork does nothing
+func (*Network) SetupHostNetwork(vpcCIDRs []string, primaryMAC string, primaryAddr *net.IP, enablePodENI bool,
+	v4Enabled bool, v6Enabled bool) error {
+	return nil
+}
+
+// SetupENINetwork does nothing
+func (*Network) SetupENINetwork(eniIP string, mac string, deviceNumber int, subnetCIDR string) error {
+	return nil
+}
+
+// UpdateHostIptablesRules does nothing
+func (*Network) UpdateHostIptablesRules(vpcCIDRs []string, primaryMAC string, primaryAddr *net.IP, v4Enabled bool,
+	v6Enabled bool) error {
+	return nil
+}
+
+// CleanUpStaleAWSChains does nothing
+func (*Network) CleanUpStaleAWSChains(v4Enabled, v6Enabled bool) error {
+	return nil
+}
+
+// UseExternalSNAT returns false
+func (*Network) UseExternalSNAT() bool {
+	return false
+}
+
+// GetExcludeSNATCIDRs returns no CIDRs
+func (*Network) GetExcludeSNATCIDRs() []string {
+	return nil
+}
+
+// GetExternalServiceCIDRs returns no CIDRs
+func (*Network) GetExternalServiceCIDRs() []string {
+	return nil
+}
+
+// GetRuleList returns no rules
+func (*Network) GetRuleList() ([]netlink.Rule, error) {
+	return nil, nil
+}
+
+// GetRuleListBySrc returns no rules
+func (*Network) GetRuleListBySrc(ruleList []netlink.Rule, src net.IPNet) ([]netlink.Rule, error) {
+	return nil, nil
+}
+
+// UpdateRuleListBySrc does nothing
+func (*Network) UpdateRuleListBySrc(ruleList []netlink.Rule, src net.IPNet) error {
+	return nil
+}
+
+// UpdateExternalServiceIpRules does nothing
+func (*Network) UpdateExternalServiceIpRules(ruleList []netlink.Rule, externalIPs []string) error {
+	return nil
+}
+
+// GetLinkByMac fails, as there are no links to look up
+func (*Network) GetLinkByMac(mac string, retryInterval time.Duration) (netlink.Link, error) {
+	return nil, errors.Errorf("simulator: no link with MAC address %s", mac)
+}
@@ -0,0 +1,129 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
+)
+
+// Report of a simulation
+type Report struct {
+	InstanceType string
+	MaxPods      int
+	// Duration of the simulation in virtual time, from the start of the trace
+	Duration time.Duration
+	// EC2Calls is the number of calls to each EC2 API, including the ones made while initializing ipamd
+	EC2Calls      map[string]int
+	TotalEC2Calls int
+	PodsAdded     int
+	// FailedAdds is the number of CNI ADD calls that did not get an IP
+	FailedAdds int
+	// PodsWithoutIP is the number of pods that were deleted, or still waiting at the end, before they got an IP
+	PodsWithoutIP int
+	// TimeToIP is the time from a pod being added to it getting an IP, for the pods that got one
+	TimeToIP Percentiles
+	// PeakIdleIPs is the highest number of addresses on the node not assigned to a pod
+	PeakIdleIPs int
+	PeakENIs    int
+	FinalENIs   int
+	FinalStats  datastore.DataStoreStats
+
+	timesToIP []time.Duration
+}
+
+// Percentiles of a distribution of durations
+type Percentiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// MarshalJSON writes the percentiles as duration strings, such as "1.5s"
+func (p Percentiles) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		P50, P90, P99, Max string
+	}{p.P50.String(), p.P90.String(), p.P99.String(), p.Max.String()})
+}
+
+func (r *Report) finish() {
+	r.TotalEC2Calls = 0
+	for _, n := range r.EC2Calls {
+		r.TotalEC2Calls += n
+	}
+	r.TimeToIP = percentiles(r.timesToIP)
+}
+
+// percentiles uses the nearest-rank method
+func percentiles(durations []time.Duration) Percentiles {
+	if len(durations) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := func(p int) time.Duration {
+		i := (p*len(sorted)+99)/100 - 1
+		return sorted[max(i, 0)]
+	}
+	return Percentiles{
+		P50: rank(50),
+		P90: rank(90),
+		P99: rank(99),
+		Max: sorted[len(sorted)-1],
+	}
+}
+
+// WriteText writes the report in a human readable form
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Instance type:\t%s\n", r.InstanceType)
+	fmt.Fprintf(tw, "Max pods:\t%d\n", r.MaxPods)
+	fmt.Fprintf(tw, "Simulated time:\t%s\n", r.Duration)
+	fmt.Fprintf(tw, "Pods added:\t%d\n", r.PodsAdded)
+	fmt.Fprintf(tw, "Failed CNI ADDs:\t%d\n", r.FailedAdds)
+	fmt.Fprintf(tw, "Pods never given an IP:\t%d\n", r.PodsWithoutIP)
+	fmt.Fprintf(tw, "Time to IP p50/p90/p99/max:\t%s / %s / %s / %s\n",
+		r.TimeToIP.P50, r.TimeToIP.P90, r.TimeToIP.P99, r.TimeToIP.Max)
+	fmt.Fprintf(tw, "Peak idle IPs:\t%d\n", r.PeakIdleIPs)
+	fmt.Fprintf(tw, "Peak ENIs:\t%d\n", r.PeakENIs)
+	fmt.Fprintf(tw, "Final ENIs:\t%d\n", r.FinalENIs)
+	fmt.Fprintf(tw, "Final pool:\t%s\n", r.FinalStats.String())
+	fmt.Fprintf(tw, "EC2 calls:\t%d\n", r.TotalEC2Calls)
+	apis := make([]string, 0, len(r.EC2Calls))
+	for api := range r.EC2Calls {
+		apis = append(apis, api)
+	}
+	sort.Strings(apis)
+	for _, api := range apis {
+		fmt.Fprintf(tw, "  %s:\t%d\n", api, r.EC2Calls[api])
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		*Report
+		Duration string
+	}{r, r.Duration.String()})
+}
@@ -0,0 +1,299 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	eniconfigscheme "github.com/aws/amazon-vpc-cni-k8s/pkg/apis/crd/v1alpha1"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/utils/logger"
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/vpc"
+	"github.com/aws/amazon-vpc-cni-k8s/rpc"
+	rcscheme "github.com/aws/amazon-vpc-resource-controller-k8s/apis/vpcresources/v1alpha1"
+)
+
+const (
+	// cniVersion is the version the simulated CNI plugin and ipamd agree on
+	cniVersion  = "simulator"
+	envNodeName = "MY_NODE_NAME"
+)
+
+var log = logger.Get()
+
+// DefaultStart is the virtual time a simulation starts at, unless configured otherwise
+var DefaultStart = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Config of a simulation. The warm pool settings are read from the environment, as in ipamd.
+type Config struct {
+	// InstanceType of the simulated node, which sets its ENI and IP limits
+	InstanceType string
+	// MaxPods of the node, derived from the ENI and IP limits when zero
+	MaxPods int
+	// Start is the virtual time of the start of the trace, DefaultStart when zero
+	Start time.Time
+	// EC2Latency is the time taken by each EC2 API call
+	EC2Latency time.Duration
+	// ENIAttachLatency is the time it takes for a new ENI and its addresses to show up in IMDS
+	ENIAttachLatency time.Duration
+	// RetryInterval is how long a pod that did not get an IP waits before the CNI plugin is called again
+	RetryInterval time.Duration
+	// Drain is how long the simulation keeps running after the last event of the trace
+	Drain time.Duration
+}
+
+// DefaultMaxPods returns the max pods of an instance type when each pod on the node takes an IP of the ENIs
+func DefaultMaxPods(instanceType string) (int, error) {
+	limits, ok := vpc.GetInstance(instanceType)
+	if !ok {
+		return 0, errors.Errorf("simulator: unknown instance type %q", instanceType)
+	}
+	return limits.ENILimit*(limits.IPv4Limit-1) + 2, nil
+}
+
+// pod is a pod of the trace, from the time it is added
+type pod struct {
+	event       Event
+	containerID string
+	added       time.Time
+	hasIP       bool
+	deleted     bool
+}
+
+type simulation struct {
+	cfg     Config
+	clock   *Clock
+	ec2     *EC2
+	ipam    *ipamd.IPAMContext
+	backend rpc.CNIBackendServer
+	k8s     client.Client
+	report  *Report
+	pods    map[string]*pod
+	podNum  int
+}
+
+// Run replays a trace of pod events on a simulated node and reports how the warm pool handled it
+func Run(ctx context.Context, cfg Config, trace []Event) (*Report, error) {
+	if cfg.MaxPods == 0 {
+		maxPods, err := DefaultMaxPods(cfg.InstanceType)
+		if err != nil {
+			return nil, err
+		}
+		cfg.MaxPods = maxPods
+	}
+	if cfg.Start.IsZero() {
+		cfg.Start = DefaultStart
+	}
+	if cfg.RetryInterval <= 0 {
+		return nil, errors.New("simulator: the pod retry interval must be positive")
+	}
+	nodeName := os.Getenv(envNodeName)
+	if nodeName == "" {
+		return nil, errors.Errorf("simulator: %s must be set", envNodeName)
+	}
+
+	clock := NewClock(cfg.Start)
+	ec2, err := NewEC2(clock, cfg.InstanceType, cfg.EC2Latency, cfg.ENIAttachLatency)
+	if err != nil {
+		return nil, err
+	}
+	k8sClient, err := newK8sClient(nodeName, cfg.MaxPods)
+	if err != nil {
+		return nil, err
+	}
+	ipam, err := ipamd.NewSimulation(k8sClient, ec2, &Network{}, clock)
+	if err != nil {
+		return nil, errors.Wrap(err, "simulator: failed to initialize ipamd")
+	}
+
+	s := &simulation{
+		cfg:     cfg,
+		clock:   clock,
+		ec2:     ec2,
+		ipam:    ipam,
+		backend: ipam.CNIBackendServer(cniVersion),
+		k8s:     k8sClient,
+		report: &Report{
+			InstanceType: cfg.InstanceType,
+			MaxPods:      cfg.MaxPods,
+		},
+		pods: make(map[string]*pod),
+	}
+	return s.run(ctx, trace)
+}
+
+func (s *simulation) run(ctx context.Context, trace []Event) (*Report, error) {
+	// The trace starts once ipamd is initialized
+	start := s.clock.Now()
+	var end time.Time
+	for _, event := range trace {
+		event := event
+		s.clock.AfterFunc(event.Offset, func() {
+			s.handle(ctx, event)
+		})
+		if at := start.Add(event.Offset); at.After(end) {
+			end = at
+		}
+	}
+	end = end.Add(s.cfg.Drain)
+
+	s.observe()
+	for s.clock.Now().Before(end) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		s.ipam.RunNodeIPPoolManagerOnce(ctx)
+		s.observe()
+	}
+
+	for _, p := range s.pods {
+		if !p.hasIP {
+			s.report.PodsWithoutIP++
+		}
+	}
+	s.report.Duration = s.clock.Now().Sub(start)
+	s.report.EC2Calls = s.ec2.Calls()
+	s.report.FinalENIs = s.ec2.ENIs()
+	s.report.FinalStats = *s.ipam.GetIPStats()
+	s.report.finish()
+	return s.report, nil
+}
+
+func (s *simulation) handle(ctx context.Context, event Event) {
+	key := event.Namespace + "/" + event.Name
+	switch event.Type {
+	case EventAdd:
+		s.podNum++
+		p := &pod{
+			event:       event,
+			containerID: fmt.Sprintf("%064x", s.podNum),
+			added:       s.clock.Now(),
+		}
+		s.pods[key] = p
+		s.report.PodsAdded++
+		if err := s.k8s.Create(ctx, k8sPod(event)); err != nil {
+			log.Warnf("Failed to create pod %s: %v", key, err)
+		}
+		s.addNetwork(ctx, p)
+	case EventDel:
+		p := s.pods[key]
+		delete(s.pods, key)
+		p.deleted = true
+		if p.hasIP {
+			s.delNetwork(ctx, p)
+		} else {
+			s.report.PodsWithoutIP++
+		}
+		if err := s.k8s.Delete(ctx, k8sPod(event)); err != nil {
+			log.Warnf("Failed to delete pod %s: %v", key, err)
+		}
+	}
+	s.observe()
+}
+
+// addNetwork calls ipamd as the CNI plugin does on ADD, and calls it again after the retry interval until the pod
+// gets an IP or is deleted
+func (s *simulation) addNetwork(ctx context.Context, p *pod) {
+	reply, err := s.backend.AddNetwork(ctx, &rpc.AddNetworkRequest{
+		ClientVersion:              cniVersion,
+		K8S_POD_NAME:               p.event.Name,
+		K8S_POD_NAMESPACE:          p.event.Namespace,
+		K8S_POD_INFRA_CONTAINER_ID: p.containerID,
+		ContainerID:                p.containerID,
+		IfName:                     "eth0",
+		NetworkName:                "aws-cni",
+	})
+	if err == nil && reply.Success {
+		p.hasIP = true
+		s.report.timesToIP = append(s.report.timesToIP, s.clock.Now().Sub(p.added))
+		return
+	}
+	s.report.FailedAdds++
+	s.clock.AfterFunc(s.cfg.RetryInterval, func() {
+		if !p.deleted {
+			s.addNetwork(ctx, p)
+			s.observe()
+		}
+	})
+}
+
+func (s *simulation) delNetwork(ctx context.Context, p *pod) {
+	_, err := s.backend.DelNetwork(ctx, &rpc.DelNetworkRequest{
+		ClientVersion:              cniVersion,
+		K8S_POD_NAME:               p.event.Name,
+		K8S_POD_NAMESPACE:          p.event.Namespace,
+		K8S_POD_INFRA_CONTAINER_ID: p.containerID,
+		ContainerID:                p.containerID,
+		IfName:                     "eth0",
+		NetworkName:                "aws-cni",
+		Reason:                     "PodDeleted",
+	})
+	if err != nil {
+		log.Warnf("DelNetwork for pod %s/%s failed: %v", p.event.Namespace, p.event.Name, err)
+	}
+}
+
+// observe samples the pool of the node
+func (s *simulation) observe() {
+	stats := s.ipam.GetIPStats()
+	if idle := stats.TotalIPs - stats.AssignedIPs; idle > s.report.PeakIdleIPs {
+		s.report.PeakIdleIPs = idle
+	}
+	if enis := s.ec2.ENIs(); enis > s.report.PeakENIs {
+		s.report.PeakENIs = enis
+	}
+}
+
+func newK8sClient(nodeName string, maxPods int) (client.Client, error) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		eniconfigscheme.AddToScheme,
+		rcscheme.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			return nil, errors.Wrap(err, "simulator: failed to build the k8s scheme")
+		}
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{
+				corev1.ResourcePods: *resource.NewQuantity(int64(maxPods), resource.DecimalSI),
+			},
+		},
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(node).Build(), nil
+}
+
+func k8sPod(event Event) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: event.Namespace,
+			Name:      event.Name,
+		},
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTrace = `# 12 pods over 12s, half of them deleted after a minute
+0s,add,default/p01
+1s,add,default/p02
+2s,add,default/p03
+3s,add,default/p04
+4s,add,default/p05
+5s,add,default/p06
+6s,add,default/p07
+7s,add,default/p08
+8s,add,default/p09
+9s,add,default/p10
+10s,add,default/p11
+11s,add,default/p12
+70s,del,default/p01
+70s,del,default/p02
+70s,del,default/p03
+70s,del,default/p04
+70s,del,default/p05
+70s,del,default/p06
+`
+
+func testConfig() Config {
+	return Config{
+		InstanceType:     "m5.large",
+		EC2Latency:       200 * time.Millisecond,
+		ENIAttachLatency: 3 * time.Second,
+		RetryInterval:    time.Second,
+		Drain:            time.Minute,
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("MY_NODE_NAME", "sim-node")
+	t.Setenv("ENABLE_IPv4", "true")
+	trace, err := ParseTrace(strings.NewReader(testTrace))
+	require.NoError(t, err)
+
+	report, err := Run(context.Background(), testConfig(), trace)
+	require.NoError(t, err)
+	assert.Equal(t, 29, report.MaxPods)
+	assert.Equal(t, 12, report.PodsAdded)
+	assert.Equal(t, 0, report.PodsWithoutIP)
+	assert.Equal(t, 6, report.FinalStats.AssignedIPs)
+	assert.GreaterOrEqual(t, report.Duration, 130*time.Second)
+	// The default WARM_ENI_TARGET of 1 keeps a whole ENI of IPs warm, so no pod waits
+	assert.Equal(t, 0, report.FailedAdds)
+	assert.Equal(t, time.Duration(0), report.TimeToIP.Max)
+	assert.GreaterOrEqual(t, report.PeakIdleIPs, 9)
+	assert.Equal(t, 3, report.PeakENIs)
+	assert.Equal(t, 2, report.EC2Calls[apiCreateNetworkInterface])
+	assert.Equal(t, report.TotalEC2Calls, sumCalls(report.EC2Calls))
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteText(&buf))
+	assert.Contains(t, buf.String(), "Peak idle IPs:")
+	buf.Reset()
+	require.NoError(t, report.WriteJSON(&buf))
+	assert.Contains(t, buf.String(), `"P50": "0s"`)
+}
+
+func TestRunWarmIPTarget(t *testing.T) {
+	t.Setenv("MY_NODE_NAME", "sim-node")
+	t.Setenv("ENABLE_IPv4", "true")
+	t.Setenv("WARM_IP_TARGET", "1")
+	t.Setenv("MINIMUM_IP_TARGET", "1")
+	trace, err := ParseTrace(strings.NewReader(testTrace))
+	require.NoError(t, err)
+
+	report, err := Run(context.Background(), testConfig(), trace)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.PodsWithoutIP)
+	// Pods arrive faster than the pool manager adds one warm IP, so some of them have to wait
+	assert.Greater(t, report.FailedAdds, 0)
+	assert.Greater(t, report.TimeToIP.Max, time.Duration(0))
+	assert.Less(t, report.PeakIdleIPs, 9)
+}
+
+func TestPercentiles(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i > 0; i-- {
+		durations = append(durations, time.Duration(i)*time.Second)
+	}
+	assert.Equal(t, Percentiles{P50: 50 * time.Second, P90: 90 * time.Second, P99: 99 * time.Second, Max: 100 * time.Second},
+		percentiles(durations))
+	assert.Equal(t, Percentiles{}, percentiles(nil))
+}
+
+func sumCalls(calls map[string]int) int {
+	total := 0
+	for _, n := range calls {
+		total += n
+	}
+	return total
+}
@@ -0,0 +1,89 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package simulator
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Pod event types of a trace
+const (
+	EventAdd = "add"
+	EventDel = "del"
+)
+
+// Event is a pod being added to or deleted from the node, at an offset from the start of the trace
+type Event struct {
+	Offset    time.Duration
+	Type      string
+	Namespace string
+	Name      string
+}
+
+// ParseTrace reads a trace with one event per line, in the form "<offset>,<add|del>,<namespace>/<name>", where the
+// offset is a Go duration such as "90s" or "1h2m". Empty lines and lines starting with "#" are ignored. A pod can only
+// be deleted after it was added, and added again after it was deleted. Events are returned in the order of their
+// offsets, keeping the order of the trace for events at the same offset.
+func ParseTrace(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != 3 {
+			return nil, errors.Errorf("trace line %d: expected <offset>,<add|del>,<namespace>/<name>, got %q", lineNum, line)
+		}
+		offset, err := time.ParseDuration(strings.TrimSpace(fields[0]))
+		if err != nil || offset < 0 {
+			return nil, errors.Errorf("trace line %d: invalid offset %q", lineNum, fields[0])
+		}
+		eventType := strings.TrimSpace(fields[1])
+		if eventType != EventAdd && eventType != EventDel {
+			return nil, errors.Errorf("trace line %d: invalid event %q, must be %q or %q", lineNum, eventType, EventAdd, EventDel)
+		}
+		namespace, name, ok := strings.Cut(strings.TrimSpace(fields[2]), "/")
+		if !ok || namespace == "" || name == "" {
+			return nil, errors.Errorf("trace line %d: invalid pod %q, must be <namespace>/<name>", lineNum, fields[2])
+		}
+		events = append(events, Event{Offset: offset, Type: eventType, Namespace: namespace, Name: name})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read trace")
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Offset < events[j].Offset
+	})
+
+	running := make(map[string]bool)
+	for _, event := range events {
+		pod := event.Namespace + "/" + event.Name
+		switch {
+		case event.Type == EventAdd && running[pod]:
+			return nil, errors.Errorf("trace: pod %s added at %s is already running", pod, event.Offset)
+		case event.Type == EventDel && !running[pod]:
+			return nil, errors.Errorf("trace: pod %s deleted at %s is not running", pod, event.Offset)
+		}
+		running[pod] = event.Type == EventAdd
+	}
+	return events, nil
+}
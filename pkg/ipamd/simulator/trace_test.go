@@ -0,0 +1,58 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package simulator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrace(t *testing.T) {
+	trace := `# warm-up
+0s,add,default/a
+1m,add,kube-system/b
+30s, del , default/a
+
+90s,add,default/a
+`
+	events, err := ParseTrace(strings.NewReader(trace))
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{
+		{Offset: 0, Type: EventAdd, Namespace: "default", Name: "a"},
+		{Offset: 30 * time.Second, Type: EventDel, Namespace: "default", Name: "a"},
+		{Offset: time.Minute, Type: EventAdd, Namespace: "kube-system", Name: "b"},
+		{Offset: 90 * time.Second, Type: EventAdd, Namespace: "default", Name: "a"},
+	}, events)
+}
+
+func TestParseTraceErrors(t *testing.T) {
+	for name, trace := range map[string]string{
+		"missing field":   "0s,add",
+		"invalid offset":  "soon,add,default/a",
+		"negative offset": "-1s,add,default/a",
+		"invalid event":   "0s,update,default/a",
+		"no namespace":    "0s,add,a",
+		"added twice":     "0s,add,default/a\n1s,add,default/a",
+		"not running":     "0s,del,default/a",
+		"deleted first":   "1s,add,default/a\n0s,del,default/a",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseTrace(strings.NewReader(trace))
+			assert.Error(t, err)
+		})
+	}
+}
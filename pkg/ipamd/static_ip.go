@@ -0,0 +1,98 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ipamd
+
+import (
+	"net"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/pkg/errors"
+
+	"github.com/aws/amazon-vpc-cni-k8s/pkg/ipamd/datastore"
+)
+
+// errStaticIPOutsideSubnets is returned when no ENI of the pod's ENI pool is in a subnet containing the requested address
+var errStaticIPOutsideSubnets = errors.New("requested IP is outside the subnets of all attached ENIs in the ENI pool of the pod")
+
+// assignStaticIPAddress assigns the requested IPv4 address to the pod, along with an IPv6 address from the pool in
+// dual-stack mode. When the IPv4 address is not in the IP pool yet, it is allocated on an attached ENI of the pod's
+// ENI pool whose subnet contains it.
+func (c *IPAMContext) assignStaticIPAddress(ipamKey datastore.IPAMKey, ipamMetadata datastore.IPAMMetadata, ip net.IP) (string, string, int, error) {
+	ipv4Addr, ipv6Addr, deviceNumber, err := c.dataStore.AssignPodIPAddressWithIPv4(ipamKey, ipamMetadata, ip, c.enableIPv6)
+	if !errors.Is(err, datastore.ErrStaticIPNotInPool) {
+		return ipv4Addr, ipv6Addr, deviceNumber, err
+	}
+
+	// AddNetwork runs outside of the pool manager, so keep reconciles and pool increases from running in between the
+	// EC2 call and the datastore update
+	c.allocations.lock()
+	defer c.allocations.unlock()
+	eniID, err := c.findENIForStaticIP(ip, ipamMetadata.ENIPool)
+	if err != nil {
+		return "", "", -1, err
+	}
+	output, err := c.awsClient.AllocSpecificIPAddress(eniID, ip)
+	if err != nil {
+		ipamdErrInc("assignStaticIPAllocIPAddressFailed")
+		return "", "", -1, errors.Wrapf(err, "failed to allocate IP %s on ENI %s", ip.String(), eniID)
+	}
+	if c.enablePrefixDelegation {
+		c.addENIv4prefixesToDataStore(output.AssignedIpv4Prefixes, eniID)
+	} else {
+		var ec2ip4s []ec2types.NetworkInterfacePrivateIpAddress
+		for _, ec2Addr := range output.AssignedPrivateIpAddresses {
+			ec2ip4s = append(ec2ip4s, ec2types.NetworkInterfacePrivateIpAddress{PrivateIpAddress: ec2Addr.PrivateIpAddress})
+		}
+		c.addENIsecondaryIPsToDataStore(ec2ip4s, eniID)
+	}
+	return c.dataStore.AssignPodIPAddressWithIPv4(ipamKey, ipamMetadata, ip, c.enableIPv6)
+}
+
+// findENIForStaticIP returns an ENI of the ENI pool that can get the requested address allocated, i.e. one whose
+// subnet contains the address and that still has room for another IP or prefix. ENIs of other pools are in other
+// subnets or security groups, so they are never used.
+func (c *IPAMContext) findENIForStaticIP(ip net.IP, pool string) (string, error) {
+	attachedENIs, err := c.awsClient.GetAttachedENIs()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get attached ENIs")
+	}
+	allocatable := make(map[string]bool)
+	for _, eni := range c.dataStore.GetAllocatableENIs(c.maxIPsPerENI, c.useCustomNetworking) {
+		allocatable[eni.ID] = true
+	}
+	enis := c.dataStore.GetENIInfos().ENIs
+
+	inSubnet := false
+	for _, eni := range attachedENIs {
+		if eni.PrimaryIPv4Address() == ip.String() {
+			return "", errors.Wrapf(datastore.ErrStaticIPInUse, "primary IP of ENI %s", eni.ENIID)
+		}
+		if dsENI, ok := enis[eni.ENIID]; !ok || dsENI.Pool != pool {
+			continue
+		}
+		_, subnet, err := net.ParseCIDR(eni.SubnetIPv4CIDR)
+		if err != nil || !subnet.Contains(ip) {
+			continue
+		}
+		if allocatable[eni.ENIID] {
+			log.Infof("Allocating static IP %s on ENI %s in subnet %s", ip.String(), eni.ENIID, eni.SubnetIPv4CIDR)
+			return eni.ENIID, nil
+		}
+		inSubnet = true
+	}
+	if inSubnet {
+		return "", errors.Errorf("no ENI in the subnet of requested IP %s can take another IP address", ip.String())
+	}
+	return "", errStaticIPOutsideSubnets
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -27,6 +28,11 @@ import (
 
 const (
 	awsNode = "aws-node"
+
+	// envPoolConfigMap names the ConfigMap ipamd reads its warm pool settings from, as <name> or <namespace>/<name>
+	envPoolConfigMap = "POOL_CONFIG_MAP"
+	// defaultPoolConfigMapNamespace is the namespace of the pool ConfigMap when POOL_CONFIG_MAP has no namespace
+	defaultPoolConfigMapNamespace = "kube-system"
 )
 
 var log = logger.Get()
@@ -34,7 +40,7 @@ var log = logger.Get()
 // Get cache filters for IPAMD
 func getIPAMDCacheFilters() map[client.Object]cache.ByObject {
 	if nodeName := os.Getenv("MY_NODE_NAME"); nodeName != "" {
-		return map[client.Object]cache.ByObject{
+		filters := map[client.Object]cache.ByObject{
 			&corev1.Pod{}: {
 				Field: fields.Set{"spec.nodeName": nodeName}.AsSelector(),
 			},
@@ -42,10 +48,30 @@ func getIPAMDCacheFilters() map[client.Object]cache.ByObject {
 				Field: fields.Set{"metadata.name": nodeName}.AsSelector(),
 			},
 		}
+		// Only watch the pool ConfigMap, aws-node is not allowed to list the other ConfigMaps
+		if poolConfigMap, ok := GetPoolConfigMapName(); ok {
+			filters[&corev1.ConfigMap{}] = cache.ByObject{
+				Namespaces: map[string]cache.Config{poolConfigMap.Namespace: {}},
+				Field:      fields.Set{"metadata.name": poolConfigMap.Name}.AsSelector(),
+			}
+		}
+		return filters
 	}
 	return nil
 }
 
+// GetPoolConfigMapName returns the ConfigMap named by POOL_CONFIG_MAP, or false when it is not set
+func GetPoolConfigMapName() (types.NamespacedName, bool) {
+	value := os.Getenv(envPoolConfigMap)
+	if value == "" {
+		return types.NamespacedName{}, false
+	}
+	if namespace, name, found := strings.Cut(value, "/"); found {
+		return types.NamespacedName{Namespace: namespace, Name: name}, true
+	}
+	return types.NamespacedName{Namespace: defaultPoolConfigMapNamespace, Name: value}, true
+}
+
 // Get cache filters for CNI Metrics Helper
 func getMetricsHelperCacheFilters() map[client.Object]cache.ByObject {
 	return map[client.Object]cache.ByObject{
@@ -128,6 +154,17 @@ func CreateKubeClient(appName string) (client.Client, error) {
 	return k8sClient, nil
 }
 
+// CreateKubeAPIReader creates a k8s reader that reads from the API server instead of the client cache
+func CreateKubeAPIReader() (client.Reader, error) {
+	restCfg, err := getRestConfig()
+	if err != nil {
+		return nil, err
+	}
+	vpcCniScheme := runtime.NewScheme()
+	corev1.AddToScheme(vpcCniScheme)
+	return client.New(restCfg, client.Options{Scheme: vpcCniScheme})
+}
+
 func GetKubeClientSet() (kubernetes.Interface, error) {
 	// creates the in-cluster config
 	config, err := getRestConfig()
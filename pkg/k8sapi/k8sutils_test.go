@@ -10,6 +10,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -34,3 +35,23 @@ func TestGetNode(t *testing.T) {
 	_, err = GetNode(ctx, k8sClient)
 	assert.Error(t, err)
 }
+
+func TestGetPoolConfigMapName(t *testing.T) {
+	t.Setenv(envPoolConfigMap, "")
+	_, ok := GetPoolConfigMapName()
+	assert.False(t, ok)
+
+	t.Setenv(envPoolConfigMap, "ipamd-pool")
+	name, ok := GetPoolConfigMapName()
+	assert.True(t, ok)
+	assert.Equal(t, types.NamespacedName{Namespace: "kube-system", Name: "ipamd-pool"}, name)
+
+	t.Setenv(envPoolConfigMap, "networking/ipamd-pool")
+	name, ok = GetPoolConfigMapName()
+	assert.True(t, ok)
+	assert.Equal(t, types.NamespacedName{Namespace: "networking", Name: "ipamd-pool"}, name)
+
+	t.Setenv("MY_NODE_NAME", "testNode")
+	filters := getIPAMDCacheFilters()
+	assert.Len(t, filters, 3)
+}
@@ -299,9 +299,12 @@ func (n *linuxNetwork) SetupHostNetwork(vpcv4CIDRs []string, primaryMAC string,
 		return errors.Wrapf(err, "setupHostNetwork: failed to set primary interface MTU to %d", n.mtu)
 	}
 
+	// In dual-stack mode, IPv6 pods are always behind the primary ENI, so the rules below are only needed for IPv4.
 	ipFamily := unix.AF_INET
 	if v6Enabled {
-		ipFamily = unix.AF_INET6
+		if !v4Enabled {
+			ipFamily = unix.AF_INET6
+		}
 		if err := n.enableIPv6(); err != nil {
 			return errors.Wrapf(err, "failed to enable IPv6")
 		}
@@ -377,7 +380,7 @@ func (n *linuxNetwork) UpdateHostIptablesRules(vpcCIDRs []string, primaryMAC str
 
 func (n *linuxNetwork) CleanUpStaleAWSChains(v4Enabled, v6Enabled bool) error {
 	ipProtocol := iptables.ProtocolIPv4
-	if v6Enabled {
+	if v6Enabled && !v4Enabled {
 		ipProtocol = iptables.ProtocolIPv6
 	}
 
@@ -427,38 +430,32 @@ func (n *linuxNetwork) updateHostIptablesRules(vpcCIDRs []string, primaryMAC str
 		return errors.Wrapf(err, "failed to SetupHostNetwork")
 	}
 
-	ipProtocol := iptables.ProtocolIPv4
-	if v6Enabled {
-		// Essentially a stub function for now in V6 mode. We will need it when we support v6 in secondary IP and
-		// custom networking modes. We don't need to install any SNAT rules in v6 mode and currently there is no need
-		// to mark packets entering via Primary ENI as all the pods in v6 mode will be behind primary ENI. Will have to
-		// start doing that once we start supporting custom networking mode in v6.
-		ipProtocol = iptables.ProtocolIPv6
+	if !v4Enabled {
+		// We don't need to install any SNAT rules in v6 mode and currently there is no need to mark packets entering
+		// via Primary ENI as all the pods in v6 mode will be behind primary ENI. Will have to start doing that once we
+		// start supporting custom networking mode in v6.
+		return nil
 	}
 
-	ipt, err := n.newIptables(ipProtocol)
+	// SNAT and connmark rules only apply to IPv4 pod traffic, also in dual-stack mode.
+	ipt, err := n.newIptables(iptables.ProtocolIPv4)
 	if err != nil {
 		return errors.Wrap(err, "host network setup: failed to create iptables")
 	}
 
-	if v4Enabled {
-		iptablesSNATRules, err := n.buildIptablesSNATRules(vpcCIDRs, primaryAddr, primaryIntf, ipt)
-		if err != nil {
-			return err
-		}
-		if err := n.updateIptablesRules(iptablesSNATRules, ipt); err != nil {
-			return err
-		}
+	iptablesSNATRules, err := n.buildIptablesSNATRules(vpcCIDRs, primaryAddr, primaryIntf, ipt)
+	if err != nil {
+		return err
+	}
+	if err := n.updateIptablesRules(iptablesSNATRules, ipt); err != nil {
+		return err
+	}
 
-		iptablesConnmarkRules, err := n.buildIptablesConnmarkRules(vpcCIDRs, ipt)
-		if err != nil {
-			return err
-		}
-		if err := n.updateIptablesRules(iptablesConnmarkRules, ipt); err != nil {
-			return err
-		}
+	iptablesConnmarkRules, err := n.buildIptablesConnmarkRules(vpcCIDRs, ipt)
+	if err != nil {
+		return err
 	}
-	return nil
+	return n.updateIptablesRules(iptablesConnmarkRules, ipt)
 }
 
 func (n *linuxNetwork) buildIptablesSNATRules(vpcCIDRs []string, primaryAddr *net.IP, primaryIntf string, ipt iptableswrapper.IPTablesIface) ([]iptablesRule, error) {
@@ -1131,6 +1128,22 @@ func setupENINetwork(eniIP string, eniMAC string, deviceNumber int, eniSubnetCID
 		}
 	}
 
+	if isV6 {
+		// IPv6 ENI addresses are global, so traffic sourced from one must leave through its own ENI, like the traffic of
+		// the pods using the ENI's prefix. Otherwise it follows the main table out of the primary ENI and is dropped.
+		eniRule := netLink.NewRule()
+		eniRule.Src = &net.IPNet{IP: eniIPNet, Mask: net.CIDRMask(128, 128)}
+		eniRule.Table = tableNumber
+		eniRule.Priority = FromPodRulePriority
+		eniRule.Family = unix.AF_INET6
+		if err := netLink.RuleDel(eniRule); err != nil && !containsNoSuchRule(err) {
+			return errors.Wrapf(err, "setupENINetwork: failed to clean up rule for ENI address %s", eniIP)
+		}
+		if err := netLink.RuleAdd(eniRule); err != nil && !isRuleExistsError(err) {
+			return errors.Wrapf(err, "setupENINetwork: failed to add rule for ENI address %s", eniIP)
+		}
+	}
+
 	// Remove the route that default out to ENI-x out of main route table
 	var defaultRoute netlink.Route
 	if isV6 {
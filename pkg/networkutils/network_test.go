@@ -169,10 +169,21 @@ func TestSetupENIV6Network(t *testing.T) {
 
 	mockNetLink.EXPECT().RouteDel(gomock.Any())
 	mockNetLink.EXPECT().RouteReplace(gomock.Any()).Return(nil)
+
+	// Traffic from the ENI's address uses the ENI's route table
+	eniRule := netlink.NewRule()
+	mockNetLink.EXPECT().NewRule().Return(eniRule)
+	mockNetLink.EXPECT().RuleDel(eniRule).Return(syscall.ENOENT)
+	mockNetLink.EXPECT().RuleAdd(eniRule).Return(nil)
+
 	mockNetLink.EXPECT().RouteDel(gomock.Any()).Return(nil)
 
 	err = setupENINetwork(testEniIP6, testMAC2, testTable, testEniV6Subnet, mockNetLink, 0*time.Second, 0*time.Second, testMTU)
 	assert.NoError(t, err)
+	assert.Equal(t, &net.IPNet{IP: testEniIP6Net, Mask: net.CIDRMask(128, 128)}, eniRule.Src)
+	assert.Equal(t, testTable+1, eniRule.Table)
+	assert.Equal(t, FromPodRulePriority, eniRule.Priority)
+	assert.Equal(t, unix.AF_INET6, eniRule.Family)
 }
 
 func TestSetupENINetworkMACFail(t *testing.T) {
@@ -1008,6 +1019,49 @@ func TestSetupHostNetworkWithIPv6Enabled(t *testing.T) {
 	}, mockIptables.(*mock_iptables.MockIptables).DataplaneState)
 }
 
+func TestSetupHostNetworkWithDualStackEnabled(t *testing.T) {
+	ctrl, mockNetLink, _, mockNS, mockIptables := setup(t)
+	defer ctrl.Finish()
+
+	var protocols []iptables.Protocol
+	ln := &linuxNetwork{
+		useExternalSNAT:        false,
+		excludeSNATCIDRs:       nil,
+		nodePortSupportEnabled: true,
+		mainENIMark:            defaultConnmark,
+		mtu:                    testMTU,
+		vethPrefix:             eniPrefix,
+
+		netLink: mockNetLink,
+		ns:      mockNS,
+		newIptables: func(protocol iptables.Protocol) (iptableswrapper.IPTablesIface, error) {
+			protocols = append(protocols, protocol)
+			return mockIptables, nil
+		},
+	}
+	mockPrimaryInterfaceLookup(ctrl, mockNetLink)
+	mockNetLink.EXPECT().LinkSetMTU(gomock.Any(), testMTU).Return(nil)
+	var mainENIRule netlink.Rule
+	mockNetLink.EXPECT().NewRule().Return(&mainENIRule)
+	mockNetLink.EXPECT().RuleDel(&mainENIRule)
+	mockNetLink.EXPECT().RuleAdd(&mainENIRule)
+
+	vpcCIDRs := []string{"10.10.0.0/16"}
+	err := ln.SetupHostNetwork(vpcCIDRs, loopback, &testEniIPNet, false, true, true)
+	assert.NoError(t, err)
+
+	// IPv6 pods are behind the primary ENI, so the main ENI rule and the SNAT rules are only set up for IPv4
+	assert.Equal(t, unix.AF_INET, mainENIRule.Family)
+	for _, protocol := range protocols {
+		assert.Equal(t, iptables.ProtocolIPv4, protocol)
+	}
+	dataplaneState := mockIptables.(*mock_iptables.MockIptables).DataplaneState
+	assert.Equal(t, [][]string{{"-m", "comment", "--comment", "AWS SNAT CHAIN", "-j", "AWS-SNAT-CHAIN-0"}},
+		dataplaneState["nat"]["POSTROUTING"])
+	assert.Contains(t, dataplaneState["nat"]["AWS-SNAT-CHAIN-0"],
+		[]string{"-d", "10.10.0.0/16", "-m", "comment", "--comment", "AWS SNAT CHAIN", "-j", "RETURN"})
+}
+
 func TestIncrementIPAddr(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -21,6 +21,14 @@ const (
 	ipv6ForwardKey = "net/ipv6/conf/all/forwarding"
 )
 
+// Error codes defined by the CNI 1.1 spec for the STATUS verb, which the cni library does not export yet
+const (
+	// ErrPluginNotAvailable means the plugin cannot service ADD requests
+	ErrPluginNotAvailable uint = 50
+	// ErrLimitedConnectivity means the plugin is not available and existing containers may have limited connectivity
+	ErrLimitedConnectivity uint = 51
+)
+
 func FindInterfaceByName(ifaceList []*current.Interface, ifaceName string) (ifaceIndex int, iface *current.Interface, found bool) {
 	for ifaceIndex, iface := range ifaceList {
 		if iface.Name == ifaceName {
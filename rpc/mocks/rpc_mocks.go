@@ -13,7 +13,7 @@
 //
 
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/aws/amazon-vpc-cni-k8s/rpc (interfaces: CNIBackendClient,NPBackendClient)
+// Source: github.com/aws/amazon-vpc-cni-k8s/rpc (interfaces: CNIBackendClient,CNIBackend_WatchAllocationsClient,NPBackendClient)
 
 // Package mock_rpc is a generated GoMock package.
 package mock_rpc
@@ -25,6 +25,7 @@ import (
 	rpc "github.com/aws/amazon-vpc-cni-k8s/rpc"
 	gomock "github.com/golang/mock/gomock"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
 )
 
 // MockCNIBackendClient is a mock of CNIBackendClient interface.
@@ -70,6 +71,26 @@ func (mr *MockCNIBackendClientMockRecorder) AddNetwork(arg0, arg1 interface{}, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNetwork", reflect.TypeOf((*MockCNIBackendClient)(nil).AddNetwork), varargs...)
 }
 
+// CheckNetwork mocks base method.
+func (m *MockCNIBackendClient) CheckNetwork(arg0 context.Context, arg1 *rpc.CheckNetworkRequest, arg2 ...grpc.CallOption) (*rpc.CheckNetworkReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CheckNetwork", varargs...)
+	ret0, _ := ret[0].(*rpc.CheckNetworkReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckNetwork indicates an expected call of CheckNetwork.
+func (mr *MockCNIBackendClientMockRecorder) CheckNetwork(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckNetwork", reflect.TypeOf((*MockCNIBackendClient)(nil).CheckNetwork), varargs...)
+}
+
 // DelNetwork mocks base method.
 func (m *MockCNIBackendClient) DelNetwork(arg0 context.Context, arg1 *rpc.DelNetworkRequest, arg2 ...grpc.CallOption) (*rpc.DelNetworkReply, error) {
 	m.ctrl.T.Helper()
@@ -90,6 +111,229 @@ func (mr *MockCNIBackendClientMockRecorder) DelNetwork(arg0, arg1 interface{}, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelNetwork", reflect.TypeOf((*MockCNIBackendClient)(nil).DelNetwork), varargs...)
 }
 
+// GCNetwork mocks base method.
+func (m *MockCNIBackendClient) GCNetwork(arg0 context.Context, arg1 *rpc.GCNetworkRequest, arg2 ...grpc.CallOption) (*rpc.GCNetworkReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GCNetwork", varargs...)
+	ret0, _ := ret[0].(*rpc.GCNetworkReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GCNetwork indicates an expected call of GCNetwork.
+func (mr *MockCNIBackendClientMockRecorder) GCNetwork(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GCNetwork", reflect.TypeOf((*MockCNIBackendClient)(nil).GCNetwork), varargs...)
+}
+
+// GetPodNetwork mocks base method.
+func (m *MockCNIBackendClient) GetPodNetwork(arg0 context.Context, arg1 *rpc.GetPodNetworkRequest, arg2 ...grpc.CallOption) (*rpc.GetPodNetworkReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetPodNetwork", varargs...)
+	ret0, _ := ret[0].(*rpc.GetPodNetworkReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPodNetwork indicates an expected call of GetPodNetwork.
+func (mr *MockCNIBackendClientMockRecorder) GetPodNetwork(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPodNetwork", reflect.TypeOf((*MockCNIBackendClient)(nil).GetPodNetwork), varargs...)
+}
+
+// ListAllocations mocks base method.
+func (m *MockCNIBackendClient) ListAllocations(arg0 context.Context, arg1 *rpc.ListAllocationsRequest, arg2 ...grpc.CallOption) (*rpc.ListAllocationsReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListAllocations", varargs...)
+	ret0, _ := ret[0].(*rpc.ListAllocationsReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllocations indicates an expected call of ListAllocations.
+func (mr *MockCNIBackendClientMockRecorder) ListAllocations(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllocations", reflect.TypeOf((*MockCNIBackendClient)(nil).ListAllocations), varargs...)
+}
+
+// Status mocks base method.
+func (m *MockCNIBackendClient) Status(arg0 context.Context, arg1 *rpc.StatusRequest, arg2 ...grpc.CallOption) (*rpc.StatusReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Status", varargs...)
+	ret0, _ := ret[0].(*rpc.StatusReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Status indicates an expected call of Status.
+func (mr *MockCNIBackendClientMockRecorder) Status(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockCNIBackendClient)(nil).Status), varargs...)
+}
+
+// WatchAllocations mocks base method.
+func (m *MockCNIBackendClient) WatchAllocations(arg0 context.Context, arg1 *rpc.WatchAllocationsRequest, arg2 ...grpc.CallOption) (rpc.CNIBackend_WatchAllocationsClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WatchAllocations", varargs...)
+	ret0, _ := ret[0].(rpc.CNIBackend_WatchAllocationsClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchAllocations indicates an expected call of WatchAllocations.
+func (mr *MockCNIBackendClientMockRecorder) WatchAllocations(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchAllocations", reflect.TypeOf((*MockCNIBackendClient)(nil).WatchAllocations), varargs...)
+}
+
+// MockCNIBackend_WatchAllocationsClient is a mock of CNIBackend_WatchAllocationsClient interface.
+type MockCNIBackend_WatchAllocationsClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockCNIBackend_WatchAllocationsClientMockRecorder
+}
+
+// MockCNIBackend_WatchAllocationsClientMockRecorder is the mock recorder for MockCNIBackend_WatchAllocationsClient.
+type MockCNIBackend_WatchAllocationsClientMockRecorder struct {
+	mock *MockCNIBackend_WatchAllocationsClient
+}
+
+// NewMockCNIBackend_WatchAllocationsClient creates a new mock instance.
+func NewMockCNIBackend_WatchAllocationsClient(ctrl *gomock.Controller) *MockCNIBackend_WatchAllocationsClient {
+	mock := &MockCNIBackend_WatchAllocationsClient{ctrl: ctrl}
+	mock.recorder = &MockCNIBackend_WatchAllocationsClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCNIBackend_WatchAllocationsClient) EXPECT() *MockCNIBackend_WatchAllocationsClientMockRecorder {
+	return m.recorder
+}
+
+// CloseSend mocks base method.
+func (m *MockCNIBackend_WatchAllocationsClient) CloseSend() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseSend")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseSend indicates an expected call of CloseSend.
+func (mr *MockCNIBackend_WatchAllocationsClientMockRecorder) CloseSend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseSend", reflect.TypeOf((*MockCNIBackend_WatchAllocationsClient)(nil).CloseSend))
+}
+
+// Context mocks base method.
+func (m *MockCNIBackend_WatchAllocationsClient) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context.
+func (mr *MockCNIBackend_WatchAllocationsClientMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockCNIBackend_WatchAllocationsClient)(nil).Context))
+}
+
+// Header mocks base method.
+func (m *MockCNIBackend_WatchAllocationsClient) Header() (metadata.MD, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header")
+	ret0, _ := ret[0].(metadata.MD)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Header indicates an expected call of Header.
+func (mr *MockCNIBackend_WatchAllocationsClientMockRecorder) Header() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockCNIBackend_WatchAllocationsClient)(nil).Header))
+}
+
+// Recv mocks base method.
+func (m *MockCNIBackend_WatchAllocationsClient) Recv() (*rpc.AllocationEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recv")
+	ret0, _ := ret[0].(*rpc.AllocationEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recv indicates an expected call of Recv.
+func (mr *MockCNIBackend_WatchAllocationsClientMockRecorder) Recv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recv", reflect.TypeOf((*MockCNIBackend_WatchAllocationsClient)(nil).Recv))
+}
+
+// RecvMsg mocks base method.
+func (m *MockCNIBackend_WatchAllocationsClient) RecvMsg(arg0 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecvMsg", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg.
+func (mr *MockCNIBackend_WatchAllocationsClientMockRecorder) RecvMsg(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockCNIBackend_WatchAllocationsClient)(nil).RecvMsg), arg0)
+}
+
+// SendMsg mocks base method.
+func (m *MockCNIBackend_WatchAllocationsClient) SendMsg(arg0 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMsg", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg.
+func (mr *MockCNIBackend_WatchAllocationsClientMockRecorder) SendMsg(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockCNIBackend_WatchAllocationsClient)(nil).SendMsg), arg0)
+}
+
+// Trailer mocks base method.
+func (m *MockCNIBackend_WatchAllocationsClient) Trailer() metadata.MD {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trailer")
+	ret0, _ := ret[0].(metadata.MD)
+	return ret0
+}
+
+// Trailer indicates an expected call of Trailer.
+func (mr *MockCNIBackend_WatchAllocationsClientMockRecorder) Trailer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockCNIBackend_WatchAllocationsClient)(nil).Trailer))
+}
+
 // MockNPBackendClient is a mock of NPBackendClient interface.
 type MockNPBackendClient struct {
 	ctrl     *gomock.Controller
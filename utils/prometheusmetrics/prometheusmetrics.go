@@ -88,6 +88,45 @@ var (
 		},
 		[]string{"api", "error", "status"},
 	)
+	AwsAPIRateLimitWait = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name: "awscni_aws_api_ratelimit_wait_ms",
+			Help: "Time AWS API calls waited for the client-side rate limiter in ms",
+		},
+		[]string{"api"},
+	)
+	AwsAPIThrottled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "awscni_aws_api_throttled_count",
+			Help: "The number of AWS API calls that were throttled",
+		},
+		[]string{"api"},
+	)
+	AwsAPIRateLimit = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "awscni_aws_api_rate_limit",
+			Help: "The current client-side rate limit of an AWS API in calls per second, lowered while the API is throttled",
+		},
+		[]string{"api"},
+	)
+	IMDSCacheDegraded = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "awscni_imds_cache_degraded",
+			Help: "1 while IMDS is unavailable and instance metadata is served from the last-known-good cache, 0 otherwise",
+		},
+	)
+	IMDSCacheAge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "awscni_imds_cache_age_seconds",
+			Help: "The age of the instance metadata served from the last-known-good cache, 0 while IMDS answers",
+		},
+	)
+	IMDSCacheHits = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "awscni_imds_cache_hit_count",
+			Help: "The number of IMDS requests answered from the last-known-good cache",
+		},
+	)
 	AwsAPIErr = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "awscni_aws_api_error_count",
@@ -172,6 +211,26 @@ var (
 		},
 		[]string{"eni"},
 	)
+	AdaptiveWarmIPTarget = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "awscni_adaptive_warm_ip_target",
+			Help: "The warm IP target chosen from the observed pod churn",
+		},
+	)
+	AdaptiveWarmIPTargetReason = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "awscni_adaptive_warm_ip_target_reason",
+			Help: "Set to 1 for the reason the adaptive warm IP target was chosen, 0 for the other reasons",
+		},
+		[]string{"reason"},
+	)
+	CustomNetworkingSubnet = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "awscni_custom_networking_subnet",
+			Help: "Set to 1 for the ENIConfig subnet the last ENI was created in, 0 for the other subnets of the ENIConfig",
+		},
+		[]string{"subnet"},
+	)
 )
 
 // ServeMetrics sets up ipamd metrics and introspection endpoints
@@ -212,6 +271,12 @@ func PrometheusRegister() {
 	prometheus.MustRegister(DelIPCnt)
 	prometheus.MustRegister(PodENIErr)
 	prometheus.MustRegister(AwsAPILatency)
+	prometheus.MustRegister(AwsAPIRateLimitWait)
+	prometheus.MustRegister(AwsAPIThrottled)
+	prometheus.MustRegister(AwsAPIRateLimit)
+	prometheus.MustRegister(IMDSCacheDegraded)
+	prometheus.MustRegister(IMDSCacheAge)
+	prometheus.MustRegister(IMDSCacheHits)
 	prometheus.MustRegister(AwsAPIErr)
 	prometheus.MustRegister(AwsUtilsErr)
 	prometheus.MustRegister(Ec2ApiReq)
@@ -225,6 +290,9 @@ func PrometheusRegister() {
 	prometheus.MustRegister(IpsPerCidr)
 	prometheus.MustRegister(NoAvailableIPAddrs)
 	prometheus.MustRegister(EniIPsInUse)
+	prometheus.MustRegister(AdaptiveWarmIPTarget)
+	prometheus.MustRegister(AdaptiveWarmIPTargetReason)
+	prometheus.MustRegister(CustomNetworkingSubnet)
 
 }
 